@@ -70,6 +70,15 @@ type ReminderCallee interface {
 	ReminderCall(string, []byte, string, string)
 }
 
+// DeactivationCallee is implemented by the actors to be called when the
+// sidecar deactivates them, before the instance is removed, such as to flush
+// their state or release resources. The state changes made by OnDeactivate
+// are saved. If it returns an error, the deactivation fails and the instance
+// is kept.
+type DeactivationCallee interface {
+	OnDeactivate(ctx context.Context) error
+}
+
 type (
 	Factory        func() Server
 	FactoryContext func() ServerContext
@@ -201,6 +210,12 @@ type StateManager interface {
 	WithContext() StateManagerContext
 }
 
+// Deprecated: StateDefaulter is deprecated in favour of StateDefaulterContext.
+type StateDefaulter interface {
+	// GetOrDefault is like Get, but sets @reply to @defaultValue when @stateName doesn't exist
+	GetOrDefault(stateName string, reply, defaultValue any) error
+}
+
 type StateManagerContext interface {
 	// Add is to add new state store with @stateName and @value
 	Add(ctx context.Context, stateName string, value any) error
@@ -223,3 +238,31 @@ type StateManagerContext interface {
 	// Flush is called by StateManager after Save
 	Flush(ctx context.Context)
 }
+
+// StateDefaulterContext is implemented by the state managers that can read a
+// state with a default value.
+type StateDefaulterContext interface {
+	// GetOrDefault is like Get, but sets @reply to @defaultValue, or to the
+	// value it points to, when @stateName doesn't exist or is marked for
+	// removal.
+	GetOrDefault(ctx context.Context, stateName string, reply, defaultValue any) error
+}
+
+// StateBulkGetter is implemented by the state managers that can read several
+// states at once.
+type StateBulkGetter interface {
+	// GetBulk returns the serialized values of the states in @stateNames.
+	// Changes made earlier in the turn and not yet saved are included;
+	// states that do not exist or are marked for removal are omitted.
+	GetBulk(ctx context.Context, stateNames []string) (map[string][]byte, error)
+}
+
+// StateMerger is implemented by the state managers that can apply JSON merge
+// patches to states.
+type StateMerger interface {
+	// Merge applies the JSON merge patch (RFC 7386) @patch to the JSON value of
+	// @stateName and sets the result, which is saved with the other changes of
+	// the turn in a single transaction. If the state doesn't exist, the patch
+	// becomes its initial value.
+	Merge(ctx context.Context, stateName string, patch any) error
+}
@@ -17,7 +17,23 @@ import "github.com/dapr/go-sdk/actor/codec/constant"
 
 // ActorConfig is Actor's configuration struct.
 type ActorConfig struct {
-	SerializerType string
+	SerializerType       string
+	HostLifecycleHandler ActorHostLifecycleHandler
+}
+
+// ActorHostLifecycleHandler is notified of the changes of the set of actors
+// hosted by the app, such as the ones caused by placement table rebalances,
+// to drain or warm up its caches.
+type ActorHostLifecycleHandler interface {
+	// OnPlacementPaused is called when the host stops accepting activations
+	// of new actors.
+	OnPlacementPaused()
+	// OnPlacementResumed is called when the host accepts activations of new
+	// actors again.
+	OnPlacementResumed()
+	// OnActorOwnershipLost is called once an actor has been deactivated by the
+	// sidecar, such as when it has been moved to another host.
+	OnActorOwnershipLost(actorType, actorID string)
 }
 
 // Option is option function of ActorConfig.
@@ -30,6 +46,14 @@ func WithSerializerName(serializerType string) Option {
 	}
 }
 
+// WithHostLifecycleHandler notifies h of the lifecycle of the host of the
+// actor, and of the deactivations of its instances.
+func WithHostLifecycleHandler(h ActorHostLifecycleHandler) Option {
+	return func(config *ActorConfig) {
+		config.HostLifecycleHandler = h
+	}
+}
+
 // GetConfigFromOptions get final ActorConfig set by @opts.
 func GetConfigFromOptions(opts ...Option) *ActorConfig {
 	conf := &ActorConfig{
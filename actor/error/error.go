@@ -30,4 +30,7 @@ const (
 	ErrTimerParamsInvalid         = ActorErr(10)
 	ErrSaveStateFailed            = ActorErr(11)
 	ErrActorServerInvalid         = ActorErr(12)
+	ErrActorMethodArityMismatch   = ActorErr(13)
+	ErrActorDeactivateFailed      = ActorErr(14)
+	ErrActorActivationPaused      = ActorErr(15)
 )
@@ -15,6 +15,7 @@ package manager
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"reflect"
 
@@ -77,7 +78,7 @@ func (d *DefaultActorContainer) Invoke(methodName string, param []byte) ([]refle
 // NewDefaultActorContainerContext is the same as NewDefaultActorContainer, but with initial context.
 func NewDefaultActorContainerContext(ctx context.Context, actorID string, impl actor.ServerContext, serializer codec.Codec) (ActorContainerContext, actorErr.ActorErr) {
 	impl.SetID(actorID)
-	daprClient, _ := dapr.NewClient()
+	daprClient, _ := dapr.DefaultClient()
 	// create state manager for this new actor
 	impl.SetStateManager(state.NewActorStateManagerContext(impl.Type(), actorID, state.NewDaprStateAsyncProvider(daprClient)))
 	// save state of this actor
@@ -98,26 +99,61 @@ func NewDefaultActorContainerContext(ctx context.Context, actorID string, impl a
 }
 
 // Invoke call actor method with given context, methodName and param.
+// The param of methods with multiple arguments is a JSON array holding the
+// arguments in order.
 func (d *DefaultActorContainerContext) Invoke(ctx context.Context, methodName string, param []byte) ([]reflect.Value, actorErr.ActorErr) {
 	methodType, ok := d.methodType[methodName]
 	if !ok {
 		return nil, actorErr.ErrActorMethodNoFound
 	}
-	argsValues := make([]reflect.Value, 0)
-	argsValues = append(argsValues, reflect.ValueOf(d.actor), reflect.ValueOf(ctx))
-	if len(methodType.argsType) > 0 {
-		typ := methodType.argsType[0]
-		paramValue := reflect.New(typ)
-		paramInterface := paramValue.Interface()
-		if err := d.serializer.Unmarshal(param, paramInterface); err != nil {
+	argsValues := make([]reflect.Value, 0, 2+len(methodType.argsType))
+	argsValues = append(argsValues, reflect.ValueOf(d.actor))
+	if methodType.ctxType != nil {
+		argsValues = append(argsValues, reflect.ValueOf(ctx))
+	}
+	switch len(methodType.argsType) {
+	case 0:
+	case 1:
+		arg, err := d.unmarshalArg(param, methodType.argsType[0])
+		if err != nil {
+			return nil, actorErr.ErrActorMethodSerializeFailed
+		}
+		argsValues = append(argsValues, arg)
+	default:
+		// Multiple arguments are encoded as a JSON array, matched positionally.
+		var params []json.RawMessage
+		if err := d.serializer.Unmarshal(param, &params); err != nil {
+			log.Printf("actor %s method %s expects %d arguments encoded as an array, err = %s",
+				d.actor.Type(), methodName, len(methodType.argsType), err)
 			return nil, actorErr.ErrActorMethodSerializeFailed
 		}
-		argsValues = append(argsValues, reflect.ValueOf(paramInterface).Elem())
+		if len(params) != len(methodType.argsType) {
+			log.Printf("actor %s method %s expects %d arguments, got %d",
+				d.actor.Type(), methodName, len(methodType.argsType), len(params))
+			return nil, actorErr.ErrActorMethodArityMismatch
+		}
+		for i, typ := range methodType.argsType {
+			arg, err := d.unmarshalArg(params[i], typ)
+			if err != nil {
+				log.Printf("actor %s method %s argument %d is invalid, err = %s", d.actor.Type(), methodName, i, err)
+				return nil, actorErr.ErrActorMethodSerializeFailed
+			}
+			argsValues = append(argsValues, arg)
+		}
 	}
 	returnValue := methodType.method.Func.Call(argsValues)
 	return returnValue, actorErr.Success
 }
 
+// unmarshalArg decodes data into a new value of type typ.
+func (d *DefaultActorContainerContext) unmarshalArg(data []byte, typ reflect.Type) (reflect.Value, error) {
+	paramValue := reflect.New(typ)
+	if err := d.serializer.Unmarshal(data, paramValue.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return paramValue.Elem(), nil
+}
+
 func (d *DefaultActorContainerContext) GetActor() actor.ServerContext {
 	return d.actor
 }
@@ -14,12 +14,18 @@ limitations under the License.
 package manager
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dapr/go-sdk/actor"
+	"github.com/dapr/go-sdk/actor/codec"
+	"github.com/dapr/go-sdk/actor/codec/constant"
+	_ "github.com/dapr/go-sdk/actor/codec/impl"
 	actorErr "github.com/dapr/go-sdk/actor/error"
 	actorMock "github.com/dapr/go-sdk/actor/mock"
 )
@@ -76,3 +82,90 @@ func TestContainerInvoke(t *testing.T) {
 	require.Equal(t, actorErr.Success, err)
 	assert.Equal(t, param, rsp[0].Interface().(string))
 }
+
+type ArgsRequest struct {
+	Name string `json:"name"`
+}
+
+type ArgsActor struct {
+	actor.ServerImplBaseCtx
+}
+
+func (a *ArgsActor) Type() string {
+	return "argsActor"
+}
+
+func (a *ArgsActor) Ping(ctx context.Context) (string, error) {
+	return "pong", nil
+}
+
+func (a *ArgsActor) Greet(ctx context.Context, req *ArgsRequest) (string, error) {
+	return "hello " + req.Name, nil
+}
+
+func (a *ArgsActor) Repeat(ctx context.Context, req ArgsRequest, times int) (string, error) {
+	return strings.Repeat(req.Name, times), nil
+}
+
+func (a *ArgsActor) Join(sep string, parts []string) (string, error) {
+	return strings.Join(parts, sep), nil
+}
+
+func TestContainerInvokeArguments(t *testing.T) {
+	ctx := context.Background()
+	serializer, err := codec.GetActorCodec(constant.DefaultSerializerType)
+	require.NoError(t, err)
+	container, aerr := NewDefaultActorContainerContext(ctx, mockActorID, &ArgsActor{}, serializer)
+	require.Equal(t, actorErr.Success, aerr)
+
+	invoke := func(method, param string) (string, actorErr.ActorErr) {
+		rsp, aerr := container.Invoke(ctx, method, []byte(param))
+		if aerr != actorErr.Success {
+			return "", aerr
+		}
+		require.Len(t, rsp, 2)
+		require.Nil(t, rsp[1].Interface())
+		return rsp[0].Interface().(string), aerr
+	}
+
+	t.Run("no arguments", func(t *testing.T) {
+		out, aerr := invoke("Ping", "")
+		require.Equal(t, actorErr.Success, aerr)
+		assert.Equal(t, "pong", out)
+	})
+
+	t.Run("single struct argument", func(t *testing.T) {
+		out, aerr := invoke("Greet", `{"name":"dapr"}`)
+		require.Equal(t, actorErr.Success, aerr)
+		assert.Equal(t, "hello dapr", out)
+	})
+
+	t.Run("two arguments", func(t *testing.T) {
+		out, aerr := invoke("Repeat", `[{"name":"ab"},3]`)
+		require.Equal(t, actorErr.Success, aerr)
+		assert.Equal(t, "ababab", out)
+	})
+
+	t.Run("two arguments without context", func(t *testing.T) {
+		out, aerr := invoke("Join", `["-",["a","b"]]`)
+		require.Equal(t, actorErr.Success, aerr)
+		assert.Equal(t, "a-b", out)
+	})
+
+	t.Run("arity mismatch", func(t *testing.T) {
+		_, aerr := invoke("Repeat", `[{"name":"ab"}]`)
+		assert.Equal(t, actorErr.ErrActorMethodArityMismatch, aerr)
+		_, aerr = invoke("Repeat", `[{"name":"ab"},3,4]`)
+		assert.Equal(t, actorErr.ErrActorMethodArityMismatch, aerr)
+	})
+
+	t.Run("multiple arguments not in an array", func(t *testing.T) {
+		_, aerr := invoke("Repeat", `{"name":"ab"}`)
+		assert.Equal(t, actorErr.ErrActorMethodSerializeFailed, aerr)
+	})
+
+	t.Run("invalid argument", func(t *testing.T) {
+		_, aerr := invoke("Repeat", `[{"name":"ab"},"three"]`)
+		assert.Equal(t, actorErr.ErrActorMethodSerializeFailed, aerr)
+	})
+}
@@ -53,6 +53,11 @@ type DefaultActorManagerContext struct {
 	// activeActors stores the map actorID -> ActorContainer
 	activeActors sync.Map
 
+	// locksMu guards locks, which stores the map actorID -> actorLock held
+	// while the actor is activated or deactivated.
+	locksMu sync.Mutex
+	locks   map[string]*actorLock
+
 	// serializer is the param and response serializer of the actor
 	serializer codec.Codec
 }
@@ -109,18 +114,57 @@ func (m *DefaultActorManagerContext) RegisterActorImplFactory(f actor.FactoryCon
 	m.factory = f
 }
 
-// getAndCreateActorContainerIfNotExist will.
-func (m *DefaultActorManagerContext) getAndCreateActorContainerIfNotExist(ctx context.Context, actorID string) (ActorContainerContext, actorErr.ActorErr) {
-	val, ok := m.activeActors.Load(actorID)
+// actorLock serializes the activation and the deactivation of an actor.
+type actorLock struct {
+	sync.Mutex
+	// refs counts the callers holding or waiting for the lock.
+	refs int
+}
+
+// lockActor locks the actor with the given ID and returns the function
+// unlocking it.
+func (m *DefaultActorManagerContext) lockActor(actorID string) func() {
+	m.locksMu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*actorLock)
+	}
+	l, ok := m.locks[actorID]
 	if !ok {
-		newContainer, aerr := NewDefaultActorContainerContext(ctx, actorID, m.factory(), m.serializer)
-		if aerr != actorErr.Success {
-			return nil, aerr
+		l = &actorLock{}
+		m.locks[actorID] = l
+	}
+	l.refs++
+	m.locksMu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.locksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, actorID)
 		}
-		m.activeActors.Store(actorID, newContainer)
-		val, _ = m.activeActors.Load(actorID)
+		m.locksMu.Unlock()
+	}
+}
+
+// getAndCreateActorContainerIfNotExist returns the container of the actor,
+// activating it if needed. It waits for a deactivation in progress.
+func (m *DefaultActorManagerContext) getAndCreateActorContainerIfNotExist(ctx context.Context, actorID string) (ActorContainerContext, actorErr.ActorErr) {
+	if val, ok := m.activeActors.Load(actorID); ok {
+		return val.(ActorContainerContext), actorErr.Success
+	}
+
+	unlock := m.lockActor(actorID)
+	defer unlock()
+	if val, ok := m.activeActors.Load(actorID); ok {
+		return val.(ActorContainerContext), actorErr.Success
+	}
+	newContainer, aerr := NewDefaultActorContainerContext(ctx, actorID, m.factory(), m.serializer)
+	if aerr != actorErr.Success {
+		return nil, aerr
 	}
-	return val.(ActorContainerContext), actorErr.Success
+	m.activeActors.Store(actorID, newContainer)
+	return newContainer, actorErr.Success
 }
 
 // InvokeMethod to invoke local function by @actorID, @methodName and @request request param.
@@ -164,16 +208,49 @@ func (m *DefaultActorManagerContext) InvokeMethod(ctx context.Context, actorID,
 	return rspData, actorErr.Success
 }
 
-// DeactivateActor removes actor from actor manager.
-func (m *DefaultActorManagerContext) DeactivateActor(_ context.Context, actorID string) actorErr.ActorErr {
-	_, ok := m.activeActors.Load(actorID)
+// DeactivateActor removes actor from actor manager, after calling its
+// OnDeactivate hook if it is an actor.DeactivationCallee and saving its state.
+// The actor stays registered while the hook runs, so invocations meanwhile are
+// served by the same instance, and is kept if the hook or the save fails.
+// Of concurrent deactivations of the same actor, only one succeeds.
+func (m *DefaultActorManagerContext) DeactivateActor(ctx context.Context, actorID string) actorErr.ActorErr {
+	unlock := m.lockActor(actorID)
+	defer unlock()
+
+	val, ok := m.activeActors.Load(actorID)
 	if !ok {
 		return actorErr.ErrActorIDNotFound
 	}
+	if aerr := onDeactivate(ctx, val.(ActorContainerContext).GetActor()); aerr != actorErr.Success {
+		return aerr
+	}
 	m.activeActors.Delete(actorID)
 	return actorErr.Success
 }
 
+// onDeactivate calls the OnDeactivate hook of the actor, if any, and saves the
+// state changes it made.
+func onDeactivate(ctx context.Context, a actor.ServerContext) actorErr.ActorErr {
+	callee, ok := a.(actor.DeactivationCallee)
+	if !ok {
+		return actorErr.Success
+	}
+	if err := callee.OnDeactivate(ctx); err != nil {
+		log.Printf("failed to deactivate actor %s of type %s, err: %v", a.ID(), a.Type(), err)
+		return actorErr.ErrActorDeactivateFailed
+	}
+	if err := a.SaveState(ctx); err != nil {
+		return actorErr.ErrSaveStateFailed
+	}
+	return actorErr.Success
+}
+
+// IsActorActive reports whether the actor is activated.
+func (m *DefaultActorManagerContext) IsActorActive(actorID string) bool {
+	_, ok := m.activeActors.Load(actorID)
+	return ok
+}
+
 // InvokeReminder invoke reminder function with given params.
 func (m *DefaultActorManagerContext) InvokeReminder(ctx context.Context, actorID, reminderName string, params []byte) actorErr.ActorErr {
 	if m.factory == nil {
@@ -250,6 +327,10 @@ func suitableMethods(typ reflect.Type) map[string]*MethodType {
 	methods := make(map[string]*MethodType)
 	for m := 0; m < typ.NumMethod(); m++ {
 		method := typ.Method(m)
+		if method.Name == "OnDeactivate" {
+			// The hook of actor.DeactivationCallee is not an actor method.
+			continue
+		}
 		if mt, err := suiteMethod(method); err != nil {
 			log.Printf("method %s is illegal, err = %s, just skip it", method.Name, err)
 		} else {
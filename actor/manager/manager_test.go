@@ -14,12 +14,15 @@ limitations under the License.
 package manager
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dapr/go-sdk/actor"
 	"github.com/dapr/go-sdk/actor/api"
 	actorErr "github.com/dapr/go-sdk/actor/error"
 	"github.com/dapr/go-sdk/actor/mock"
@@ -83,6 +86,100 @@ func TestDeactivateActor(t *testing.T) {
 	assert.Equal(t, actorErr.Success, err)
 }
 
+// DeactivatingActor records the calls of its OnDeactivate hook, which fails
+// with err. If release is set, the hook signals entered and waits for it.
+type DeactivatingActor struct {
+	actor.ServerImplBaseCtx
+	deactivations *[]string
+	err           error
+	entered       chan<- struct{}
+	release       <-chan struct{}
+}
+
+func (a *DeactivatingActor) Type() string {
+	return "deactivatingActorType"
+}
+
+func (a *DeactivatingActor) Invoke(_ context.Context, req string) (string, error) {
+	return req, nil
+}
+
+func (a *DeactivatingActor) OnDeactivate(context.Context) error {
+	*a.deactivations = append(*a.deactivations, a.ID())
+	if a.release != nil {
+		a.entered <- struct{}{}
+		<-a.release
+	}
+	return a.err
+}
+
+func TestDeactivateActorHook(t *testing.T) {
+	ctx := context.Background()
+	mng, aerr := NewDefaultActorManagerContext("json")
+	require.Equal(t, actorErr.Success, aerr)
+	var (
+		deactivations []string
+		hookErr       error
+	)
+	mng.RegisterActorImplFactory(func() actor.ServerContext {
+		return &DeactivatingActor{deactivations: &deactivations, err: hookErr}
+	})
+
+	_, aerr = mng.InvokeMethod(ctx, "a1", "OnDeactivate", nil)
+	assert.Equal(t, actorErr.ErrActorMethodNoFound, aerr, "the hook is not an actor method")
+	assert.Empty(t, deactivations)
+
+	assert.Equal(t, actorErr.Success, mng.DeactivateActor(ctx, "a1"))
+	assert.Equal(t, []string{"a1"}, deactivations)
+	_, active := mng.(*DefaultActorManagerContext).activeActors.Load("a1")
+	assert.False(t, active)
+
+	// A failing hook aborts the deactivation.
+	hookErr = errors.New("flush failed")
+	_, aerr = mng.InvokeMethod(ctx, "a2", "Invoke", []byte(`"hello"`))
+	require.Equal(t, actorErr.Success, aerr)
+	assert.Equal(t, actorErr.ErrActorDeactivateFailed, mng.DeactivateActor(ctx, "a2"))
+	assert.Equal(t, []string{"a1", "a2"}, deactivations)
+	_, active = mng.(*DefaultActorManagerContext).activeActors.Load("a2")
+	assert.True(t, active)
+}
+
+func TestDeactivateActorConcurrent(t *testing.T) {
+	ctx := context.Background()
+	mng, aerr := NewDefaultActorManagerContext("json")
+	require.Equal(t, actorErr.Success, aerr)
+	var (
+		deactivations []string
+		created       int
+	)
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	mng.RegisterActorImplFactory(func() actor.ServerContext {
+		created++
+		return &DeactivatingActor{deactivations: &deactivations, entered: entered, release: release}
+	})
+	_, aerr = mng.InvokeMethod(ctx, "a1", "Invoke", []byte(`"hello"`))
+	require.Equal(t, actorErr.Success, aerr)
+
+	results := make(chan actorErr.ActorErr, 2)
+	for i := 0; i < 2; i++ {
+		go func() { results <- mng.DeactivateActor(ctx, "a1") }()
+	}
+	<-entered
+
+	// While the hook runs, the actor is still served by the same instance.
+	assert.True(t, mng.(*DefaultActorManagerContext).IsActorActive("a1"))
+	_, aerr = mng.InvokeMethod(ctx, "a1", "Invoke", []byte(`"hello"`))
+	assert.Equal(t, actorErr.Success, aerr)
+	assert.Equal(t, 1, created)
+
+	close(release)
+	assert.ElementsMatch(t, []actorErr.ActorErr{actorErr.Success, actorErr.ErrActorIDNotFound}, []actorErr.ActorErr{<-results, <-results})
+	assert.Equal(t, []string{"a1"}, deactivations)
+	assert.False(t, mng.(*DefaultActorManagerContext).IsActorActive("a1"))
+	assert.Empty(t, mng.(*DefaultActorManagerContext).locks)
+}
+
 func TestInvokeReminder(t *testing.T) {
 	mng, err := NewDefaultActorManager("json")
 	assert.NotNil(t, mng)
@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	actor "github.com/dapr/go-sdk/actor"
 	gomock "github.com/golang/mock/gomock"
@@ -255,19 +256,6 @@ func (mr *MockServerContextMockRecorder) Type() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Type", reflect.TypeOf((*MockServerContext)(nil).Type))
 }
 
-func (mr *MockServerContextMockRecorder) Invoke(arg0, arg1 interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invoke", reflect.TypeOf((*MockServerContext)(nil).Invoke), arg0, arg1)
-}
-
-func (m *MockServerContext) Invoke(ctx context.Context, input string) (string, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Invoke", ctx, input)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
 // MockReminderCallee is a mock of ReminderCallee interface.
 type MockReminderCallee struct {
 	ctrl     *gomock.Controller
@@ -303,6 +291,43 @@ func (mr *MockReminderCalleeMockRecorder) ReminderCall(arg0, arg1, arg2, arg3 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReminderCall", reflect.TypeOf((*MockReminderCallee)(nil).ReminderCall), arg0, arg1, arg2, arg3)
 }
 
+// MockDeactivationCallee is a mock of DeactivationCallee interface.
+type MockDeactivationCallee struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeactivationCalleeMockRecorder
+}
+
+// MockDeactivationCalleeMockRecorder is the mock recorder for MockDeactivationCallee.
+type MockDeactivationCalleeMockRecorder struct {
+	mock *MockDeactivationCallee
+}
+
+// NewMockDeactivationCallee creates a new mock instance.
+func NewMockDeactivationCallee(ctrl *gomock.Controller) *MockDeactivationCallee {
+	mock := &MockDeactivationCallee{ctrl: ctrl}
+	mock.recorder = &MockDeactivationCalleeMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeactivationCallee) EXPECT() *MockDeactivationCalleeMockRecorder {
+	return m.recorder
+}
+
+// OnDeactivate mocks base method.
+func (m *MockDeactivationCallee) OnDeactivate(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OnDeactivate", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OnDeactivate indicates an expected call of OnDeactivate.
+func (mr *MockDeactivationCalleeMockRecorder) OnDeactivate(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnDeactivate", reflect.TypeOf((*MockDeactivationCallee)(nil).OnDeactivate), ctx)
+}
+
 // MockStateManager is a mock of StateManager interface.
 type MockStateManager struct {
 	ctrl     *gomock.Controller
@@ -437,6 +462,43 @@ func (mr *MockStateManagerMockRecorder) WithContext() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithContext", reflect.TypeOf((*MockStateManager)(nil).WithContext))
 }
 
+// MockStateDefaulter is a mock of StateDefaulter interface.
+type MockStateDefaulter struct {
+	ctrl     *gomock.Controller
+	recorder *MockStateDefaulterMockRecorder
+}
+
+// MockStateDefaulterMockRecorder is the mock recorder for MockStateDefaulter.
+type MockStateDefaulterMockRecorder struct {
+	mock *MockStateDefaulter
+}
+
+// NewMockStateDefaulter creates a new mock instance.
+func NewMockStateDefaulter(ctrl *gomock.Controller) *MockStateDefaulter {
+	mock := &MockStateDefaulter{ctrl: ctrl}
+	mock.recorder = &MockStateDefaulterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStateDefaulter) EXPECT() *MockStateDefaulterMockRecorder {
+	return m.recorder
+}
+
+// GetOrDefault mocks base method.
+func (m *MockStateDefaulter) GetOrDefault(stateName string, reply, defaultValue any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrDefault", stateName, reply, defaultValue)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetOrDefault indicates an expected call of GetOrDefault.
+func (mr *MockStateDefaulterMockRecorder) GetOrDefault(stateName, reply, defaultValue interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrDefault", reflect.TypeOf((*MockStateDefaulter)(nil).GetOrDefault), stateName, reply, defaultValue)
+}
+
 // MockStateManagerContext is a mock of StateManagerContext interface.
 type MockStateManagerContext struct {
 	ctrl     *gomock.Controller
@@ -556,3 +618,129 @@ func (mr *MockStateManagerContextMockRecorder) Set(ctx, stateName, value interfa
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockStateManagerContext)(nil).Set), ctx, stateName, value)
 }
+
+// SetWithTTL mocks base method.
+func (m *MockStateManagerContext) SetWithTTL(ctx context.Context, stateName string, value any, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWithTTL", ctx, stateName, value, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWithTTL indicates an expected call of SetWithTTL.
+func (mr *MockStateManagerContextMockRecorder) SetWithTTL(ctx, stateName, value, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithTTL", reflect.TypeOf((*MockStateManagerContext)(nil).SetWithTTL), ctx, stateName, value, ttl)
+}
+
+// MockStateDefaulterContext is a mock of StateDefaulterContext interface.
+type MockStateDefaulterContext struct {
+	ctrl     *gomock.Controller
+	recorder *MockStateDefaulterContextMockRecorder
+}
+
+// MockStateDefaulterContextMockRecorder is the mock recorder for MockStateDefaulterContext.
+type MockStateDefaulterContextMockRecorder struct {
+	mock *MockStateDefaulterContext
+}
+
+// NewMockStateDefaulterContext creates a new mock instance.
+func NewMockStateDefaulterContext(ctrl *gomock.Controller) *MockStateDefaulterContext {
+	mock := &MockStateDefaulterContext{ctrl: ctrl}
+	mock.recorder = &MockStateDefaulterContextMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStateDefaulterContext) EXPECT() *MockStateDefaulterContextMockRecorder {
+	return m.recorder
+}
+
+// GetOrDefault mocks base method.
+func (m *MockStateDefaulterContext) GetOrDefault(ctx context.Context, stateName string, reply, defaultValue any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrDefault", ctx, stateName, reply, defaultValue)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetOrDefault indicates an expected call of GetOrDefault.
+func (mr *MockStateDefaulterContextMockRecorder) GetOrDefault(ctx, stateName, reply, defaultValue interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrDefault", reflect.TypeOf((*MockStateDefaulterContext)(nil).GetOrDefault), ctx, stateName, reply, defaultValue)
+}
+
+// MockStateBulkGetter is a mock of StateBulkGetter interface.
+type MockStateBulkGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockStateBulkGetterMockRecorder
+}
+
+// MockStateBulkGetterMockRecorder is the mock recorder for MockStateBulkGetter.
+type MockStateBulkGetterMockRecorder struct {
+	mock *MockStateBulkGetter
+}
+
+// NewMockStateBulkGetter creates a new mock instance.
+func NewMockStateBulkGetter(ctrl *gomock.Controller) *MockStateBulkGetter {
+	mock := &MockStateBulkGetter{ctrl: ctrl}
+	mock.recorder = &MockStateBulkGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStateBulkGetter) EXPECT() *MockStateBulkGetterMockRecorder {
+	return m.recorder
+}
+
+// GetBulk mocks base method.
+func (m *MockStateBulkGetter) GetBulk(ctx context.Context, stateNames []string) (map[string][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBulk", ctx, stateNames)
+	ret0, _ := ret[0].(map[string][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBulk indicates an expected call of GetBulk.
+func (mr *MockStateBulkGetterMockRecorder) GetBulk(ctx, stateNames interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBulk", reflect.TypeOf((*MockStateBulkGetter)(nil).GetBulk), ctx, stateNames)
+}
+
+// MockStateMerger is a mock of StateMerger interface.
+type MockStateMerger struct {
+	ctrl     *gomock.Controller
+	recorder *MockStateMergerMockRecorder
+}
+
+// MockStateMergerMockRecorder is the mock recorder for MockStateMerger.
+type MockStateMergerMockRecorder struct {
+	mock *MockStateMerger
+}
+
+// NewMockStateMerger creates a new mock instance.
+func NewMockStateMerger(ctrl *gomock.Controller) *MockStateMerger {
+	mock := &MockStateMerger{ctrl: ctrl}
+	mock.recorder = &MockStateMergerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStateMerger) EXPECT() *MockStateMergerMockRecorder {
+	return m.recorder
+}
+
+// Merge mocks base method.
+func (m *MockStateMerger) Merge(ctx context.Context, stateName string, patch any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", ctx, stateName, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockStateMergerMockRecorder) Merge(ctx, stateName, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockStateMerger)(nil).Merge), ctx, stateName, patch)
+}
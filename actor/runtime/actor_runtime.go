@@ -16,6 +16,10 @@ package runtime
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
 	"sync"
 
 	"github.com/dapr/go-sdk/actor"
@@ -33,6 +37,44 @@ type ActorRunTime struct {
 type ActorRunTimeContext struct {
 	config        api.ActorRuntimeConfig
 	actorManagers sync.Map
+
+	lock              sync.RWMutex
+	interceptors      []MethodInterceptor
+	lifecycleHandlers map[string]config.ActorHostLifecycleHandler
+	paused            bool
+}
+
+// activationTracker is implemented by the actor managers that can report
+// whether an actor is activated.
+type activationTracker interface {
+	IsActorActive(actorID string) bool
+}
+
+// MethodInterceptor wraps the invocation of an actor method. It must call
+// next to proceed with the invocation, and may act before and after it.
+// Returning an error without calling next short-circuits the invocation.
+type MethodInterceptor func(ctx context.Context, actorType, actorID, method string, next func() error) error
+
+// Option configures an ActorRunTimeContext.
+type Option func(*ActorRunTimeContext)
+
+// WithMethodInterceptors adds interceptors around every actor method
+// invocation. Interceptors run in the order they are added, the first one
+// being the outermost.
+func WithMethodInterceptors(interceptors ...MethodInterceptor) Option {
+	return func(r *ActorRunTimeContext) {
+		r.interceptors = append(r.interceptors, interceptors...)
+	}
+}
+
+// invokeError carries the ActorErr of a method invocation through the
+// interceptor chain.
+type invokeError struct {
+	code actorErr.ActorErr
+}
+
+func (e invokeError) Error() string {
+	return fmt.Sprintf("actor method invocation failed with code %d", e.code)
 }
 
 var (
@@ -47,8 +89,20 @@ func NewActorRuntime() *ActorRunTime {
 }
 
 // NewActorRuntimeContext creates an empty ActorRuntimeContext.
-func NewActorRuntimeContext() *ActorRunTimeContext {
-	return &ActorRunTimeContext{}
+func NewActorRuntimeContext(opts ...Option) *ActorRunTimeContext {
+	r := &ActorRunTimeContext{}
+	r.ApplyOptions(opts...)
+	return r
+}
+
+// ApplyOptions applies the options to the runtime, such as the shared
+// instance returned by GetActorRuntimeInstanceContext.
+func (r *ActorRunTimeContext) ApplyOptions(opts ...Option) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, opt := range opts {
+		opt(r)
+	}
 }
 
 // GetActorRuntimeInstance gets or create runtime instance.
@@ -73,6 +127,14 @@ func (r *ActorRunTimeContext) RegisterActorFactory(f actor.FactoryContext, opt .
 	conf := config.GetConfigFromOptions(opt...)
 	actType := f().Type()
 	r.config.RegisteredActorTypes = append(r.config.RegisteredActorTypes, actType)
+	if conf.HostLifecycleHandler != nil {
+		r.lock.Lock()
+		if r.lifecycleHandlers == nil {
+			r.lifecycleHandlers = make(map[string]config.ActorHostLifecycleHandler)
+		}
+		r.lifecycleHandlers[actType] = conf.HostLifecycleHandler
+		r.lock.Unlock()
+	}
 	mng, ok := r.actorManagers.Load(actType)
 	if !ok {
 		newMng, err := manager.NewDefaultActorManagerContext(conf.SerializerType)
@@ -91,12 +153,133 @@ func (r *ActorRunTimeContext) GetJSONSerializedConfig() ([]byte, error) {
 	return data, err
 }
 
+// PauseActivations refuses the activation of new actors, which fail with
+// ErrActorActivationPaused, while the actors already activated keep being
+// served, so that the host can drain. The HTTP service responds to the
+// invocations, reminders and timers of the refused actors with 503, so that
+// the sidecar retries them, and keeps reporting the app as healthy.
+func (r *ActorRunTimeContext) PauseActivations() {
+	r.setPaused(true)
+}
+
+// ResumeActivations accepts the activation of new actors again after
+// PauseActivations.
+func (r *ActorRunTimeContext) ResumeActivations() {
+	r.setPaused(false)
+}
+
+// ActivationsPaused reports whether the activation of new actors is paused.
+func (r *ActorRunTimeContext) ActivationsPaused() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.paused
+}
+
+// setPaused sets whether activations are paused and notifies the lifecycle
+// handlers of the change, if any.
+func (r *ActorRunTimeContext) setPaused(paused bool) {
+	r.lock.Lock()
+	if r.paused == paused {
+		r.lock.Unlock()
+		return
+	}
+	r.paused = paused
+	handlers := r.distinctLifecycleHandlers()
+	r.lock.Unlock()
+
+	for _, h := range handlers {
+		if paused {
+			h.OnPlacementPaused()
+		} else {
+			h.OnPlacementResumed()
+		}
+	}
+}
+
+// distinctLifecycleHandlers returns the lifecycle handlers, each once even if
+// it is registered for several actor types. It must be called with the lock
+// held.
+func (r *ActorRunTimeContext) distinctLifecycleHandlers() []config.ActorHostLifecycleHandler {
+	types := make([]string, 0, len(r.lifecycleHandlers))
+	for t := range r.lifecycleHandlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	handlers := make([]config.ActorHostLifecycleHandler, 0, len(types))
+	for _, t := range types {
+		h := r.lifecycleHandlers[t]
+		if !containsHandler(handlers, h) {
+			handlers = append(handlers, h)
+		}
+	}
+	return handlers
+}
+
+func containsHandler(handlers []config.ActorHostLifecycleHandler, h config.ActorHostLifecycleHandler) bool {
+	if !reflect.TypeOf(h).Comparable() {
+		return false
+	}
+	for _, o := range handlers {
+		if o == h {
+			return true
+		}
+	}
+	return false
+}
+
+// checkActivation returns ErrActorActivationPaused if activations are paused
+// and the actor is not activated.
+func (r *ActorRunTimeContext) checkActivation(mng interface{}, actorID string) actorErr.ActorErr {
+	if !r.ActivationsPaused() {
+		return actorErr.Success
+	}
+	if tracker, ok := mng.(activationTracker); ok && !tracker.IsActorActive(actorID) {
+		return actorErr.ErrActorActivationPaused
+	}
+	return actorErr.Success
+}
+
 func (r *ActorRunTimeContext) InvokeActorMethod(ctx context.Context, actorTypeName, actorID, actorMethod string, payload []byte) ([]byte, actorErr.ActorErr) {
 	mng, ok := r.actorManagers.Load(actorTypeName)
 	if !ok {
 		return nil, actorErr.ErrActorTypeNotFound
 	}
-	return mng.(manager.ActorManagerContext).InvokeMethod(ctx, actorID, actorMethod, payload)
+	if aerr := r.checkActivation(mng, actorID); aerr != actorErr.Success {
+		return nil, aerr
+	}
+
+	r.lock.RLock()
+	interceptors := r.interceptors
+	r.lock.RUnlock()
+	if len(interceptors) == 0 {
+		return mng.(manager.ActorManagerContext).InvokeMethod(ctx, actorID, actorMethod, payload)
+	}
+
+	var rsp []byte
+	next := func() error {
+		var code actorErr.ActorErr
+		rsp, code = mng.(manager.ActorManagerContext).InvokeMethod(ctx, actorID, actorMethod, payload)
+		if code != actorErr.Success {
+			return invokeError{code: code}
+		}
+		return nil
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := interceptors[i], next
+		next = func() error {
+			return interceptor(ctx, actorTypeName, actorID, actorMethod, inner)
+		}
+	}
+
+	if err := next(); err != nil {
+		var ie invokeError
+		if errors.As(err, &ie) {
+			return nil, ie.code
+		}
+		return nil, actorErr.ErrActorInvokeFailed
+	}
+	return rsp, actorErr.Success
 }
 
 func (r *ActorRunTimeContext) Deactivate(ctx context.Context, actorTypeName, actorID string) actorErr.ActorErr {
@@ -104,7 +287,18 @@ func (r *ActorRunTimeContext) Deactivate(ctx context.Context, actorTypeName, act
 	if !ok {
 		return actorErr.ErrActorTypeNotFound
 	}
-	return targetManager.(manager.ActorManagerContext).DeactivateActor(ctx, actorID)
+	aerr := targetManager.(manager.ActorManagerContext).DeactivateActor(ctx, actorID)
+	if aerr != actorErr.Success {
+		return aerr
+	}
+
+	r.lock.RLock()
+	h := r.lifecycleHandlers[actorTypeName]
+	r.lock.RUnlock()
+	if h != nil {
+		h.OnActorOwnershipLost(actorTypeName, actorID)
+	}
+	return actorErr.Success
 }
 
 func (r *ActorRunTimeContext) InvokeReminder(ctx context.Context, actorTypeName, actorID, reminderName string, params []byte) actorErr.ActorErr {
@@ -112,6 +306,9 @@ func (r *ActorRunTimeContext) InvokeReminder(ctx context.Context, actorTypeName,
 	if !ok {
 		return actorErr.ErrActorTypeNotFound
 	}
+	if aerr := r.checkActivation(targetManager, actorID); aerr != actorErr.Success {
+		return aerr
+	}
 	mng := targetManager.(manager.ActorManagerContext)
 	return mng.InvokeReminder(ctx, actorID, reminderName, params)
 }
@@ -121,6 +318,9 @@ func (r *ActorRunTimeContext) InvokeTimer(ctx context.Context, actorTypeName, ac
 	if !ok {
 		return actorErr.ErrActorTypeNotFound
 	}
+	if aerr := r.checkActivation(targetManager, actorID); aerr != actorErr.Success {
+		return aerr
+	}
 	mng := targetManager.(manager.ActorManagerContext)
 	return mng.InvokeTimer(ctx, actorID, timerName, params)
 }
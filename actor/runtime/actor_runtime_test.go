@@ -15,13 +15,21 @@ package runtime
 
 import (
 	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
 	"testing"
 
+	"github.com/dapr/go-sdk/actor/config"
 	actorErr "github.com/dapr/go-sdk/actor/error"
 	actorMock "github.com/dapr/go-sdk/actor/mock"
+	dapr "github.com/dapr/go-sdk/client"
+	"github.com/dapr/go-sdk/client/daprtest"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewActorRuntime(t *testing.T) {
@@ -114,3 +122,176 @@ func TestInvokeTimer(t *testing.T) {
 
 	assert.Equal(t, actorErr.Success, err)
 }
+
+func TestMethodInterceptors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var calls []string
+	recording := func(name string) MethodInterceptor {
+		return func(ctx context.Context, actorType, actorID, method string, next func() error) error {
+			assert.Equal(t, "testActorType", actorType)
+			assert.Equal(t, "mockActorID", actorID)
+			assert.Equal(t, "Invoke", method)
+			calls = append(calls, name+" before")
+			err := next()
+			calls = append(calls, name+" after")
+			return err
+		}
+	}
+
+	t.Run("interceptors wrap the method", func(t *testing.T) {
+		calls = nil
+		rt := NewActorRuntimeContext(WithMethodInterceptors(recording("outer"), recording("inner")))
+		mockServer := actorMock.NewMockActorManagerContext(ctrl)
+		rt.actorManagers.Store("testActorType", mockServer)
+		mockServer.EXPECT().InvokeMethod(gomock.Any(), "mockActorID", "Invoke", []byte("param")).
+			DoAndReturn(func(context.Context, string, string, []byte) ([]byte, actorErr.ActorErr) {
+				calls = append(calls, "method")
+				return []byte("response"), actorErr.Success
+			})
+
+		rspData, err := rt.InvokeActorMethod(context.Background(), "testActorType", "mockActorID", "Invoke", []byte("param"))
+		assert.Equal(t, actorErr.Success, err)
+		assert.Equal(t, []byte("response"), rspData)
+		assert.Equal(t, []string{"outer before", "inner before", "method", "inner after", "outer after"}, calls)
+	})
+
+	t.Run("method errors are preserved", func(t *testing.T) {
+		calls = nil
+		rt := NewActorRuntimeContext()
+		rt.ApplyOptions(WithMethodInterceptors(recording("outer")))
+		mockServer := actorMock.NewMockActorManagerContext(ctrl)
+		rt.actorManagers.Store("testActorType", mockServer)
+		mockServer.EXPECT().InvokeMethod(gomock.Any(), "mockActorID", "Invoke", gomock.Any()).
+			Return(nil, actorErr.ErrActorMethodNoFound)
+
+		_, err := rt.InvokeActorMethod(context.Background(), "testActorType", "mockActorID", "Invoke", nil)
+		assert.Equal(t, actorErr.ErrActorMethodNoFound, err)
+		assert.Equal(t, []string{"outer before", "outer after"}, calls)
+	})
+
+	t.Run("interceptor short-circuits", func(t *testing.T) {
+		rt := NewActorRuntimeContext(WithMethodInterceptors(func(context.Context, string, string, string, func() error) error {
+			return errors.New("unauthorized")
+		}))
+		mockServer := actorMock.NewMockActorManagerContext(ctrl)
+		rt.actorManagers.Store("testActorType", mockServer)
+
+		rspData, err := rt.InvokeActorMethod(context.Background(), "testActorType", "mockActorID", "Invoke", nil)
+		assert.Equal(t, actorErr.ErrActorInvokeFailed, err)
+		assert.Nil(t, rspData)
+	})
+}
+
+// recordingLifecycleHandler records the lifecycle callbacks it receives.
+type recordingLifecycleHandler struct {
+	lock    sync.Mutex
+	paused  int
+	resumed int
+	lost    map[string]int
+}
+
+func (h *recordingLifecycleHandler) OnPlacementPaused() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.paused++
+}
+
+func (h *recordingLifecycleHandler) OnPlacementResumed() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.resumed++
+}
+
+func (h *recordingLifecycleHandler) OnActorOwnershipLost(actorType, actorID string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.lost == nil {
+		h.lost = map[string]int{}
+	}
+	h.lost[actorType+"/"+actorID]++
+}
+
+// useFakeSidecar points the default client, used by the actors to manage
+// their state, to a fake sidecar, so that activations don't wait for one.
+func useFakeSidecar(t *testing.T) {
+	srv, err := daprtest.NewFaultyServer()
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(srv.Address())
+	require.NoError(t, err)
+	t.Setenv("DAPR_GRPC_PORT", port)
+	dapr.ResetDefaultClient()
+	t.Cleanup(func() {
+		dapr.ResetDefaultClient()
+		srv.Close()
+	})
+}
+
+func TestHostLifecycleHandler(t *testing.T) {
+	ctx := context.Background()
+	useFakeSidecar(t)
+
+	t.Run("deactivation storm", func(t *testing.T) {
+		h := &recordingLifecycleHandler{}
+		rt := NewActorRuntimeContext()
+		rt.RegisterActorFactory(actorMock.ActorImplFactoryCtx, config.WithHostLifecycleHandler(h))
+
+		const actors = 20
+		for i := 0; i < actors; i++ {
+			_, err := rt.InvokeActorMethod(ctx, "testActorType", strconv.Itoa(i), "Invoke", []byte(`"param"`))
+			require.Equal(t, actorErr.Success, err)
+		}
+
+		// The sidecar may deactivate the same actor several times concurrently
+		// during a rebalance.
+		var wg sync.WaitGroup
+		for i := 0; i < actors; i++ {
+			for j := 0; j < 5; j++ {
+				wg.Add(1)
+				go func(id string) {
+					defer wg.Done()
+					rt.Deactivate(ctx, "testActorType", id)
+				}(strconv.Itoa(i))
+			}
+		}
+		wg.Wait()
+
+		require.Len(t, h.lost, actors)
+		for id, n := range h.lost {
+			assert.Equal(t, 1, n, "ownership of %s lost %d times", id, n)
+		}
+		assert.Equal(t, actorErr.ErrActorIDNotFound, rt.Deactivate(ctx, "testActorType", "0"))
+		assert.Len(t, h.lost, actors)
+	})
+
+	t.Run("pause and resume activations", func(t *testing.T) {
+		h := &recordingLifecycleHandler{}
+		rt := NewActorRuntimeContext()
+		rt.RegisterActorFactory(actorMock.ActorImplFactoryCtx, config.WithHostLifecycleHandler(h))
+		// The same handler registered for two actor types is notified once.
+		rt.RegisterActorFactory(actorMock.NotReminderCalleeActorFactory, config.WithHostLifecycleHandler(h))
+
+		_, err := rt.InvokeActorMethod(ctx, "testActorType", "active", "Invoke", []byte(`"param"`))
+		require.Equal(t, actorErr.Success, err)
+
+		rt.PauseActivations()
+		rt.PauseActivations()
+		assert.True(t, rt.ActivationsPaused())
+		assert.Equal(t, 1, h.paused)
+
+		_, err = rt.InvokeActorMethod(ctx, "testActorType", "new", "Invoke", []byte(`"param"`))
+		assert.Equal(t, actorErr.ErrActorActivationPaused, err)
+		assert.Equal(t, actorErr.ErrActorActivationPaused, rt.InvokeTimer(ctx, "testActorType", "new", "timer", []byte(`{"callback":"Invoke","data":"\"param\""}`)))
+		rsp, err := rt.InvokeActorMethod(ctx, "testActorType", "active", "Invoke", []byte(`"param"`))
+		assert.Equal(t, actorErr.Success, err)
+		assert.Equal(t, []byte(`"param"`), rsp)
+
+		rt.ResumeActivations()
+		rt.ResumeActivations()
+		assert.False(t, rt.ActivationsPaused())
+		assert.Equal(t, 1, h.resumed)
+		_, err = rt.InvokeActorMethod(ctx, "testActorType", "new", "Invoke", []byte(`"param"`))
+		assert.Equal(t, actorErr.Success, err)
+	})
+}
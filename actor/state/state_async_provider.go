@@ -16,12 +16,17 @@ package state
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/dapr/go-sdk/actor/codec"
 	"github.com/dapr/go-sdk/actor/codec/constant"
 	client "github.com/dapr/go-sdk/client"
 )
 
+// maxConcurrentStateLoads bounds the number of actor state reads issued in
+// parallel by LoadBulkContext.
+const maxConcurrentStateLoads = 8
+
 type DaprStateAsyncProvider struct {
 	daprClient      client.Client
 	stateSerializer codec.Codec
@@ -67,6 +72,57 @@ func (d *DaprStateAsyncProvider) LoadContext(ctx context.Context, actorType, act
 	return nil
 }
 
+// LoadBulkContext loads the serialized values of the given states.
+// States that do not exist are omitted from the result.
+// The runtime does not expose a bulk actor state API, so states are read
+// individually, with at most maxConcurrentStateLoads requests in flight.
+func (d *DaprStateAsyncProvider) LoadBulkContext(ctx context.Context, actorType, actorID string, stateNames []string) (map[string][]byte, error) {
+	var (
+		lock     sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := make(map[string][]byte, len(stateNames))
+	sem := make(chan struct{}, maxConcurrentStateLoads)
+	for _, stateName := range stateNames {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(stateName string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			item, err := d.daprClient.GetActorState(ctx, &client.GetActorStateRequest{
+				ActorType: actorType,
+				ActorID:   actorID,
+				KeyName:   stateName,
+			})
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("get actor state %s error = %w", stateName, err)
+					cancel()
+				}
+				return
+			}
+			if item != nil && len(item.Data) > 0 {
+				result[stateName] = item.Data
+			}
+		}(stateName)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
+
 // Deprecated: use ApplyContext instead.
 func (d *DaprStateAsyncProvider) Apply(actorType, actorID string, changes []*ActorStateChange) error {
 	return d.ApplyContext(context.Background(), actorType, actorID, changes)
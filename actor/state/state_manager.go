@@ -15,6 +15,7 @@ package state
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -22,6 +23,17 @@ import (
 	"time"
 
 	"github.com/dapr/go-sdk/actor"
+	"github.com/dapr/go-sdk/actor/codec"
+	"github.com/dapr/go-sdk/actor/codec/constant"
+)
+
+// The state managers implement the optional interfaces of actor.StateManager
+// and actor.StateManagerContext.
+var (
+	_ actor.StateDefaulter        = (*stateManager)(nil)
+	_ actor.StateDefaulterContext = (*stateManagerCtx)(nil)
+	_ actor.StateBulkGetter       = (*stateManagerCtx)(nil)
+	_ actor.StateMerger           = (*stateManagerCtx)(nil)
 )
 
 type stateManager struct {
@@ -45,6 +57,11 @@ func (s *stateManager) Get(stateName string, reply any) error {
 	return s.stateManagerCtx.Get(context.Background(), stateName, reply)
 }
 
+// Deprecated: use NewActorStateManagerContext instead.
+func (s *stateManager) GetOrDefault(stateName string, reply, defaultValue any) error {
+	return s.stateManagerCtx.GetOrDefault(context.Background(), stateName, reply, defaultValue)
+}
+
 // Deprecated: use NewActorStateManagerContext instead.
 func (s *stateManager) Set(stateName string, value any) error {
 	return s.stateManagerCtx.Set(context.Background(), stateName, value)
@@ -115,6 +132,9 @@ func (s *stateManagerCtx) Get(ctx context.Context, stateName string, reply any)
 		if metadata.Kind == Remove {
 			return fmt.Errorf("state is marked for removal: %s", stateName)
 		}
+		if merged, ok := metadata.Value.(mergedState); ok {
+			return s.stateAsyncProvider.stateSerializer.Unmarshal(merged, reply)
+		}
 		replyVal := reflect.ValueOf(reply).Elem()
 		metadataValue := reflect.ValueOf(metadata.Value)
 		if metadataValue.Kind() == reflect.Ptr {
@@ -134,6 +154,101 @@ func (s *stateManagerCtx) Get(ctx context.Context, stateName string, reply any)
 	return err
 }
 
+func (s *stateManagerCtx) GetOrDefault(ctx context.Context, stateName string, reply, defaultValue any) error {
+	if stateName == "" {
+		return errors.New("state name can't be empty")
+	}
+
+	if val, ok := s.stateChangeTracker.Load(stateName); ok {
+		if val.(*ChangeMetadata).Kind == Remove {
+			return setDefaultState(reply, defaultValue)
+		}
+		return s.Get(ctx, stateName, reply)
+	}
+
+	loaded, err := s.stateAsyncProvider.LoadBulkContext(ctx, s.actorTypeName, s.actorID, []string{stateName})
+	if err != nil {
+		return err
+	}
+	data, ok := loaded[stateName]
+	if !ok {
+		return setDefaultState(reply, defaultValue)
+	}
+	if err := s.stateAsyncProvider.stateSerializer.Unmarshal(data, reply); err != nil {
+		return fmt.Errorf("unmarshal state data error = %w", err)
+	}
+	s.stateChangeTracker.Store(stateName, &ChangeMetadata{
+		Kind:  None,
+		Value: reply,
+	})
+	return nil
+}
+
+// setDefaultState sets the value reply points to to defaultValue, or to the
+// value defaultValue points to, or to the zero value if defaultValue is nil.
+func setDefaultState(reply, defaultValue any) error {
+	replyVal := reflect.ValueOf(reply)
+	if replyVal.Kind() != reflect.Ptr || replyVal.IsNil() {
+		return fmt.Errorf("reply must be a non-nil pointer, got %T", reply)
+	}
+	target := replyVal.Elem()
+	if defaultValue == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	v := reflect.ValueOf(defaultValue)
+	if !v.Type().AssignableTo(target.Type()) && v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.Type().AssignableTo(target.Type()) {
+		return fmt.Errorf("default value of type %T can't be assigned to %s", defaultValue, target.Type())
+	}
+	target.Set(v)
+	return nil
+}
+
+func (s *stateManagerCtx) GetBulk(ctx context.Context, stateNames []string) (map[string][]byte, error) {
+	result := make(map[string][]byte, len(stateNames))
+	missing := make([]string, 0, len(stateNames))
+	seen := make(map[string]struct{}, len(stateNames))
+	for _, stateName := range stateNames {
+		if stateName == "" {
+			return nil, errors.New("state name can't be empty")
+		}
+		if _, ok := seen[stateName]; ok {
+			continue
+		}
+		seen[stateName] = struct{}{}
+
+		val, ok := s.stateChangeTracker.Load(stateName)
+		if !ok {
+			missing = append(missing, stateName)
+			continue
+		}
+		metadata := val.(*ChangeMetadata)
+		if metadata.Kind == Remove {
+			continue
+		}
+		data, err := s.stateAsyncProvider.stateSerializer.Marshal(metadata.Value)
+		if err != nil {
+			return nil, fmt.Errorf("marshal cached state %s error = %w", stateName, err)
+		}
+		result[stateName] = data
+	}
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+	loaded, err := s.stateAsyncProvider.LoadBulkContext(ctx, s.actorTypeName, s.actorID, missing)
+	if err != nil {
+		return nil, err
+	}
+	for stateName, data := range loaded {
+		result[stateName] = data
+	}
+	return result, nil
+}
+
 func (s *stateManagerCtx) Set(_ context.Context, stateName string, value any) error {
 	if stateName == "" {
 		return errors.New("state name can't be empty")
@@ -177,6 +292,39 @@ func (s *stateManagerCtx) SetWithTTL(_ context.Context, stateName string, value
 	return nil
 }
 
+func (s *stateManagerCtx) Merge(ctx context.Context, stateName string, patch any) error {
+	if stateName == "" {
+		return errors.New("state name can't be empty")
+	}
+	patchData, ok := patch.(json.RawMessage)
+	if !ok {
+		var err error
+		if patchData, err = json.Marshal(patch); err != nil {
+			return fmt.Errorf("marshal merge patch for state %s error = %w", stateName, err)
+		}
+	}
+	var patchValue any
+	if err := json.Unmarshal(patchData, &patchValue); err != nil {
+		return fmt.Errorf("unmarshal merge patch for state %s error = %w", stateName, err)
+	}
+
+	current, err := s.GetBulk(ctx, []string{stateName})
+	if err != nil {
+		return err
+	}
+	var target any
+	if data, ok := current[stateName]; ok {
+		if err := json.Unmarshal(data, &target); err != nil {
+			return fmt.Errorf("unmarshal state %s error = %w", stateName, err)
+		}
+	}
+	merged, err := json.Marshal(applyMergePatch(target, patchValue))
+	if err != nil {
+		return fmt.Errorf("marshal merged state %s error = %w", stateName, err)
+	}
+	return s.Set(ctx, stateName, mergedState(merged))
+}
+
 func (s *stateManagerCtx) Remove(ctx context.Context, stateName string) error {
 	if stateName == "" {
 		return errors.New("state name can't be empty")
@@ -249,6 +397,35 @@ func (s *stateManagerCtx) Flush(_ context.Context) {
 	})
 }
 
+// mergedState is a JSON state value produced by Merge. As its Go type is
+// unknown, Get decodes it into the reply.
+type mergedState []byte
+
+func (m mergedState) MarshalJSON() ([]byte, error) {
+	return m, nil
+}
+
+// applyMergePatch applies the JSON merge patch (RFC 7386) patch to target,
+// both decoded into generic JSON values.
+func applyMergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any, len(patchObj))
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = applyMergePatch(targetObj[k], v)
+	}
+	return targetObj
+}
+
 // Deprecated: use NewActorStateManagerContext instead.
 func NewActorStateManager(actorTypeName string, actorID string, provider *DaprStateAsyncProvider) actor.StateManager {
 	return &stateManager{
@@ -267,3 +444,27 @@ func NewActorStateManagerContext(actorTypeName string, actorID string, provider
 		actorID:            actorID,
 	}
 }
+
+// GetBulkInto reads the states in stateNames with a single GetBulk call on sm
+// and decodes each value found into a T, using the default actor state
+// serializer. The state managers of this package implement
+// actor.StateBulkGetter.
+func GetBulkInto[T any](ctx context.Context, sm actor.StateBulkGetter, stateNames []string) (map[string]T, error) {
+	data, err := sm.GetBulk(ctx, stateNames)
+	if err != nil {
+		return nil, err
+	}
+	serializer, err := codec.GetActorCodec(constant.DefaultSerializerType)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]T, len(data))
+	for stateName, raw := range data {
+		var v T
+		if err := serializer.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("unmarshal state %s error = %w", stateName, err)
+		}
+		result[stateName] = v
+	}
+	return result, nil
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/dapr/go-sdk/actor/codec/impl"
+	"github.com/dapr/go-sdk/client"
+)
+
+// actorStateClient serves actor state reads from an in-memory map.
+type actorStateClient struct {
+	client.Client
+
+	lock  sync.Mutex
+	state map[string][]byte
+	reads []string
+	err   error
+}
+
+func (c *actorStateClient) GetActorState(_ context.Context, in *client.GetActorStateRequest) (*client.GetActorStateResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reads = append(c.reads, in.KeyName)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &client.GetActorStateResponse{Data: c.state[in.KeyName]}, nil
+}
+
+func newTestStateManager(c *actorStateClient) *stateManagerCtx {
+	return NewActorStateManagerContext("testActor", "test-0", NewDaprStateAsyncProvider(c)).(*stateManagerCtx)
+}
+
+func TestGetBulk(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pending changes are visible", func(t *testing.T) {
+		c := &actorStateClient{state: map[string][]byte{
+			"stored":  []byte(`"stored-value"`),
+			"updated": []byte(`"old-value"`),
+			"removed": []byte(`"removed-value"`),
+		}}
+		sm := newTestStateManager(c)
+		require.NoError(t, sm.Set(ctx, "updated", "new-value"))
+		require.NoError(t, sm.Set(ctx, "added", "added-value"))
+		var removed string
+		require.NoError(t, sm.Get(ctx, "removed", &removed))
+		require.NoError(t, sm.Remove(ctx, "removed"))
+		c.reads = nil
+
+		res, err := sm.GetBulk(ctx, []string{"stored", "updated", "added", "removed", "unknown"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{
+			"stored":  []byte(`"stored-value"`),
+			"updated": []byte(`"new-value"`),
+			"added":   []byte(`"added-value"`),
+		}, res)
+		assert.ElementsMatch(t, []string{"stored", "unknown"}, c.reads)
+	})
+
+	t.Run("values read in the turn are served from the cache", func(t *testing.T) {
+		c := &actorStateClient{state: map[string][]byte{"key": []byte(`"value"`)}}
+		sm := newTestStateManager(c)
+		var v string
+		require.NoError(t, sm.Get(ctx, "key", &v))
+
+		res, err := sm.GetBulk(ctx, []string{"key", "key"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"key": []byte(`"value"`)}, res)
+		assert.Equal(t, []string{"key"}, c.reads)
+	})
+
+	t.Run("many keys", func(t *testing.T) {
+		c := &actorStateClient{state: map[string][]byte{}}
+		keys := make([]string, 0, 3*maxConcurrentStateLoads)
+		for i := 0; i < 3*maxConcurrentStateLoads; i++ {
+			k := string(rune('a' + i))
+			keys = append(keys, k)
+			c.state[k] = []byte(`"` + k + `"`)
+		}
+		sm := newTestStateManager(c)
+
+		res, err := sm.GetBulk(ctx, keys)
+		require.NoError(t, err)
+		assert.Equal(t, c.state, res)
+	})
+
+	t.Run("load error", func(t *testing.T) {
+		c := &actorStateClient{err: errors.New("test error")}
+		sm := newTestStateManager(c)
+		_, err := sm.GetBulk(ctx, []string{"a", "b"})
+		require.Error(t, err)
+	})
+
+	t.Run("empty state name", func(t *testing.T) {
+		sm := newTestStateManager(&actorStateClient{})
+		_, err := sm.GetBulk(ctx, []string{""})
+		require.Error(t, err)
+	})
+}
+
+func TestGetBulkInto(t *testing.T) {
+	type item struct {
+		Count int `json:"count"`
+	}
+	ctx := context.Background()
+	c := &actorStateClient{state: map[string][]byte{
+		"a": []byte(`{"count":1}`),
+		"b": []byte(`{"count":2}`),
+	}}
+	sm := newTestStateManager(c)
+	require.NoError(t, sm.Set(ctx, "b", item{Count: 20}))
+	require.NoError(t, sm.Set(ctx, "c", &item{Count: 3}))
+
+	res, err := GetBulkInto[item](ctx, sm, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]item{
+		"a": {Count: 1},
+		"b": {Count: 20},
+		"c": {Count: 3},
+	}, res)
+
+	c.state["bad"] = []byte(`not json`)
+	_, err = GetBulkInto[item](ctx, sm, []string{"bad"})
+	require.Error(t, err)
+}
+
+func TestGetOrDefault(t *testing.T) {
+	type counter struct {
+		Count int `json:"count"`
+	}
+	ctx := context.Background()
+
+	t.Run("present", func(t *testing.T) {
+		c := &actorStateClient{state: map[string][]byte{"counter": []byte(`{"count":7}`)}}
+		sm := newTestStateManager(c)
+		var v counter
+		require.NoError(t, sm.GetOrDefault(ctx, "counter", &v, counter{Count: 1}))
+		assert.Equal(t, counter{Count: 7}, v)
+
+		// The value read is cached for the turn.
+		var cached counter
+		require.NoError(t, sm.Get(ctx, "counter", &cached))
+		assert.Equal(t, counter{Count: 7}, cached)
+		assert.Equal(t, []string{"counter"}, c.reads)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		c := &actorStateClient{state: map[string][]byte{}}
+		sm := newTestStateManager(c)
+		var v counter
+		require.NoError(t, sm.GetOrDefault(ctx, "counter", &v, counter{Count: 1}))
+		assert.Equal(t, counter{Count: 1}, v)
+		require.NoError(t, sm.GetOrDefault(ctx, "counter", &v, &counter{Count: 2}))
+		assert.Equal(t, counter{Count: 2}, v)
+		require.NoError(t, sm.GetOrDefault(ctx, "counter", &v, nil))
+		assert.Equal(t, counter{}, v)
+
+		contains, err := sm.Contains(ctx, "counter")
+		require.NoError(t, err)
+		assert.False(t, contains, "the default is not stored")
+	})
+
+	t.Run("pending changes", func(t *testing.T) {
+		c := &actorStateClient{state: map[string][]byte{"removed": []byte(`{"count":5}`)}}
+		sm := newTestStateManager(c)
+		require.NoError(t, sm.Set(ctx, "set", counter{Count: 3}))
+		var v counter
+		require.NoError(t, sm.Get(ctx, "removed", &v))
+		require.NoError(t, sm.Remove(ctx, "removed"))
+
+		require.NoError(t, sm.GetOrDefault(ctx, "set", &v, counter{Count: 1}))
+		assert.Equal(t, counter{Count: 3}, v)
+		require.NoError(t, sm.GetOrDefault(ctx, "removed", &v, counter{Count: 1}))
+		assert.Equal(t, counter{Count: 1}, v)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		sm := newTestStateManager(&actorStateClient{state: map[string][]byte{}})
+		var v counter
+		assert.Error(t, sm.GetOrDefault(ctx, "", &v, nil))
+		assert.Error(t, sm.GetOrDefault(ctx, "counter", &v, "not a counter"))
+		assert.Error(t, sm.GetOrDefault(ctx, "counter", v, counter{}))
+
+		sm = newTestStateManager(&actorStateClient{err: errors.New("test error")})
+		assert.Error(t, sm.GetOrDefault(ctx, "counter", &v, counter{}))
+	})
+}
+
+func TestMerge(t *testing.T) {
+	type profile struct {
+		Name  string            `json:"name"`
+		Email string            `json:"email,omitempty"`
+		Tags  map[string]string `json:"tags,omitempty"`
+	}
+	ctx := context.Background()
+
+	t.Run("merge onto existing state", func(t *testing.T) {
+		c := &actorStateClient{state: map[string][]byte{
+			"profile": []byte(`{"name":"alice","email":"a@example.com","tags":{"a":"1","b":"2"}}`),
+		}}
+		sm := newTestStateManager(c)
+		patch := map[string]any{
+			"email": nil,
+			"tags":  map[string]any{"b": nil, "c": "3"},
+		}
+		require.NoError(t, sm.Merge(ctx, "profile", patch))
+
+		var p profile
+		require.NoError(t, sm.Get(ctx, "profile", &p))
+		assert.Equal(t, profile{Name: "alice", Tags: map[string]string{"a": "1", "c": "3"}}, p)
+
+		val, ok := sm.stateChangeTracker.Load("profile")
+		require.True(t, ok)
+		assert.Equal(t, Add, val.(*ChangeMetadata).Kind)
+	})
+
+	t.Run("merge onto cached state", func(t *testing.T) {
+		sm := newTestStateManager(&actorStateClient{state: map[string][]byte{}})
+		require.NoError(t, sm.Set(ctx, "profile", profile{Name: "bob"}))
+		require.NoError(t, sm.Merge(ctx, "profile", json.RawMessage(`{"email":"b@example.com"}`)))
+		require.NoError(t, sm.Merge(ctx, "profile", profile{Name: "robert"}))
+
+		res, err := sm.GetBulk(ctx, []string{"profile"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"robert","email":"b@example.com"}`, string(res["profile"]))
+	})
+
+	t.Run("patch becomes the initial value of absent state", func(t *testing.T) {
+		sm := newTestStateManager(&actorStateClient{state: map[string][]byte{}})
+		require.NoError(t, sm.Merge(ctx, "profile", map[string]any{"name": "carol", "email": nil}))
+
+		var p profile
+		require.NoError(t, sm.Get(ctx, "profile", &p))
+		assert.Equal(t, profile{Name: "carol"}, p)
+	})
+
+	t.Run("invalid existing state", func(t *testing.T) {
+		sm := newTestStateManager(&actorStateClient{state: map[string][]byte{"profile": []byte(`not json`)}})
+		require.Error(t, sm.Merge(ctx, "profile", map[string]any{"name": "dave"}))
+	})
+
+	t.Run("empty state name", func(t *testing.T) {
+		sm := newTestStateManager(&actorStateClient{})
+		require.Error(t, sm.Merge(ctx, "", map[string]any{}))
+	})
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actor
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MismatchKind is the category of a Mismatch.
+type MismatchKind string
+
+const (
+	// MismatchMissingMethod is a method of the interface missing from the
+	// implementation or the client stub.
+	MismatchMissingMethod MismatchKind = "missing method"
+	// MismatchArity is a method with a different number of parameters or
+	// results than in the interface.
+	MismatchArity MismatchKind = "wrong arity"
+	// MismatchSignature is a method with different parameter or result types
+	// than in the interface.
+	MismatchSignature MismatchKind = "wrong signature"
+	// MismatchShape is a method of the interface that can't be invoked as an
+	// actor method: it must take an optional context.Context followed by the
+	// request arguments, and return an optional response followed by an error.
+	MismatchShape MismatchKind = "invalid shape"
+	// MismatchNotSerializable is a method of the interface with a parameter or
+	// result that can't be serialized, such as a channel or a function.
+	MismatchNotSerializable MismatchKind = "not serializable"
+)
+
+// Mismatch is a difference between an actor interface and an implementation
+// or client stub.
+type Mismatch struct {
+	Method string
+	Kind   MismatchKind
+	Detail string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s: %s", m.Method, m.Kind, m.Detail)
+}
+
+// ValidationError lists the mismatches between an actor interface and an
+// implementation or client stub.
+type ValidationError struct {
+	// Interface is the name of the actor interface.
+	Interface string
+	// Type is the name of the implementation or client stub.
+	Type       string
+	Mismatches []Mismatch
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		msgs[i] = m.String()
+	}
+	return fmt.Sprintf("%s does not match actor interface %s: %s", e.Type, e.Interface, strings.Join(msgs, "; "))
+}
+
+var (
+	typeOfContext = reflect.TypeOf((*context.Context)(nil)).Elem()
+	typeOfError   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// ValidateImplementation checks that impl implements every method of the
+// actor interface iface, which is a nil pointer to the interface such as
+// (*CounterActor)(nil), with the same signature, and that these methods can be
+// invoked as actor methods. It returns a *ValidationError listing all the
+// mismatches.
+func ValidateImplementation(iface interface{}, impl ServerContext) error {
+	it, err := interfaceType(iface)
+	if err != nil {
+		return err
+	}
+	typ := reflect.TypeOf(impl)
+	if typ == nil {
+		return fmt.Errorf("nil implementation of actor interface %s", it)
+	}
+	mismatches := validateShapes(it)
+	for i := 0; i < it.NumMethod(); i++ {
+		want := it.Method(i)
+		got, ok := typ.MethodByName(want.Name)
+		if !ok {
+			mismatches = append(mismatches, Mismatch{Method: want.Name, Kind: MismatchMissingMethod, Detail: "not implemented"})
+			continue
+		}
+		// The type of a method of a concrete type has the receiver as first
+		// parameter, unlike the one of an interface.
+		if m, ok := compareFunc(want.Name, want.Type, got.Type, 1); !ok {
+			mismatches = append(mismatches, m)
+		}
+	}
+	return validationError(it, typ, mismatches)
+}
+
+// ValidateClientStub checks that the client stub, a pointer to a struct whose
+// function fields are implemented by the client, has a field for every method
+// of the actor interface iface, which is a nil pointer to the interface, with
+// the same signature. It returns a *ValidationError listing all the
+// mismatches.
+func ValidateClientStub(iface interface{}, stub Client) error {
+	it, err := interfaceType(iface)
+	if err != nil {
+		return err
+	}
+	typ := reflect.TypeOf(stub)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("client stub of actor interface %s is %v, not a pointer to a struct", it, typ)
+	}
+	mismatches := validateShapes(it)
+	for i := 0; i < it.NumMethod(); i++ {
+		want := it.Method(i)
+		field, ok := typ.Elem().FieldByName(want.Name)
+		if !ok || field.Type.Kind() != reflect.Func {
+			mismatches = append(mismatches, Mismatch{Method: want.Name, Kind: MismatchMissingMethod, Detail: "no function field"})
+			continue
+		}
+		if m, ok := compareFunc(want.Name, want.Type, field.Type, 0); !ok {
+			mismatches = append(mismatches, m)
+		}
+	}
+	return validationError(it, typ, mismatches)
+}
+
+// ValidatedFactory returns factory as a FactoryContext, after checking with
+// ValidateImplementation that the actors it creates implement TIface, so that
+// mismatches are reported when the actor is registered rather than when it is
+// invoked:
+//
+//	f, err := actor.ValidatedFactory[CounterActor](func() *CounterActorImpl { return &CounterActorImpl{} })
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	s.RegisterActorImplFactoryContext(f)
+func ValidatedFactory[TIface any, TImpl ServerContext](factory func() TImpl) (FactoryContext, error) {
+	if err := ValidateImplementation((*TIface)(nil), factory()); err != nil {
+		return nil, err
+	}
+	return func() ServerContext { return factory() }, nil
+}
+
+// interfaceType returns the interface type iface points to.
+func interfaceType(iface interface{}) (reflect.Type, error) {
+	typ := reflect.TypeOf(iface)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Interface {
+		return nil, fmt.Errorf("actor interface must be a nil pointer to an interface, not %v", typ)
+	}
+	return typ.Elem(), nil
+}
+
+func validationError(iface, typ reflect.Type, mismatches []Mismatch) error {
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return &ValidationError{Interface: iface.String(), Type: typ.String(), Mismatches: mismatches}
+}
+
+// compareFunc compares the signature of a method of an interface with got,
+// skipping its first skip parameters.
+func compareFunc(name string, want, got reflect.Type, skip int) (Mismatch, bool) {
+	if got.NumIn()-skip != want.NumIn() || got.NumOut() != want.NumOut() {
+		return Mismatch{Method: name, Kind: MismatchArity, Detail: fmt.Sprintf(
+			"has %d parameters and %d results, want %d and %d", got.NumIn()-skip, got.NumOut(), want.NumIn(), want.NumOut())}, false
+	}
+	for i := 0; i < want.NumIn(); i++ {
+		if got.In(i+skip) != want.In(i) {
+			return Mismatch{Method: name, Kind: MismatchSignature, Detail: fmt.Sprintf(
+				"parameter %d is %s, want %s", i, got.In(i+skip), want.In(i))}, false
+		}
+	}
+	for i := 0; i < want.NumOut(); i++ {
+		if got.Out(i) != want.Out(i) {
+			return Mismatch{Method: name, Kind: MismatchSignature, Detail: fmt.Sprintf(
+				"result %d is %s, want %s", i, got.Out(i), want.Out(i))}, false
+		}
+	}
+	return Mismatch{}, true
+}
+
+// validateShapes checks that the methods of the interface can be invoked as
+// actor methods.
+func validateShapes(iface reflect.Type) []Mismatch {
+	var mismatches []Mismatch
+	for i := 0; i < iface.NumMethod(); i++ {
+		m := iface.Method(i)
+		if isServerMethod(m.Name) {
+			continue
+		}
+		mt := m.Type
+		if mt.NumOut() == 0 || mt.NumOut() > 2 || mt.Out(mt.NumOut()-1) != typeOfError {
+			mismatches = append(mismatches, Mismatch{Method: m.Name, Kind: MismatchShape, Detail: "must return an optional response followed by an error"})
+			continue
+		}
+		for j := 0; j < mt.NumIn(); j++ {
+			if j == 0 && mt.In(j) == typeOfContext {
+				continue
+			}
+			if reason := notSerializable(mt.In(j), map[reflect.Type]bool{}); reason != "" {
+				mismatches = append(mismatches, Mismatch{Method: m.Name, Kind: MismatchNotSerializable, Detail: fmt.Sprintf("parameter %d is %s: %s", j, mt.In(j), reason)})
+			}
+		}
+		if mt.NumOut() == 2 {
+			if reason := notSerializable(mt.Out(0), map[reflect.Type]bool{}); reason != "" {
+				mismatches = append(mismatches, Mismatch{Method: m.Name, Kind: MismatchNotSerializable, Detail: fmt.Sprintf("result is %s: %s", mt.Out(0), reason)})
+			}
+		}
+	}
+	return mismatches
+}
+
+// isServerMethod reports whether name is a method of ServerContext or Client,
+// such as Type, which is not invoked as an actor method.
+func isServerMethod(name string) bool {
+	switch name {
+	case "ID", "SetID", "Type", "SetStateManager", "SaveState":
+		return true
+	}
+	return false
+}
+
+// notSerializable returns why values of typ can't be serialized by the actor
+// codecs, or an empty string if they can.
+func notSerializable(typ reflect.Type, seen map[reflect.Type]bool) string {
+	if seen[typ] {
+		return ""
+	}
+	seen[typ] = true
+	switch typ.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return typ.Kind().String() + " values can't be serialized"
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return notSerializable(typ.Elem(), seen)
+	case reflect.Map:
+		if reason := notSerializable(typ.Key(), seen); reason != "" {
+			return reason
+		}
+		return notSerializable(typ.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if !f.IsExported() || f.Tag.Get("json") == "-" {
+				continue
+			}
+			if reason := notSerializable(f.Type, seen); reason != "" {
+				return fmt.Sprintf("field %s: %s", f.Name, reason)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Order struct {
+	ID    string `json:"id"`
+	Items []string
+}
+
+type OrderActor interface {
+	Get(context.Context) (*Order, error)
+	Add(context.Context, string, int) error
+	Cancel(context.Context) error
+}
+
+type OrderActorImpl struct {
+	ServerImplBaseCtx
+}
+
+func (a *OrderActorImpl) Type() string                                { return "order" }
+func (a *OrderActorImpl) Get(context.Context) (*Order, error)         { return &Order{}, nil }
+func (a *OrderActorImpl) Add(context.Context, string, int) error      { return nil }
+func (a *OrderActorImpl) Cancel(context.Context) error                { return nil }
+func (a *OrderActorImpl) NotInInterface(context.Context) error        { return nil }
+func (a *OrderActorImpl) ReminderCall(string, []byte, string, string) {}
+
+// BrokenOrderActorImpl lacks Cancel, takes one argument less in Add and
+// returns an Order instead of a pointer in Get.
+type BrokenOrderActorImpl struct {
+	ServerImplBaseCtx
+}
+
+func (a *BrokenOrderActorImpl) Type() string                       { return "order" }
+func (a *BrokenOrderActorImpl) Get(context.Context) (Order, error) { return Order{}, nil }
+func (a *BrokenOrderActorImpl) Add(context.Context, string) error  { return nil }
+
+type StreamActor interface {
+	Subscribe(context.Context, chan string) error
+	Handler(context.Context) (func(), error)
+	Nested(context.Context, map[string]struct{ C chan int }) error
+	NoError(context.Context) string
+	Skipped(context.Context, struct {
+		C chan int `json:"-"`
+	}) error
+}
+
+type StreamActorImpl struct {
+	ServerImplBaseCtx
+}
+
+func (a *StreamActorImpl) Type() string                                                  { return "stream" }
+func (a *StreamActorImpl) Subscribe(context.Context, chan string) error                  { return nil }
+func (a *StreamActorImpl) Handler(context.Context) (func(), error)                       { return nil, nil }
+func (a *StreamActorImpl) Nested(context.Context, map[string]struct{ C chan int }) error { return nil }
+func (a *StreamActorImpl) NoError(context.Context) string                                { return "" }
+func (a *StreamActorImpl) Skipped(context.Context, struct {
+	C chan int `json:"-"`
+}) error {
+	return nil
+}
+
+type OrderActorStub struct {
+	Get    func(context.Context) (*Order, error)
+	Add    func(context.Context, string, int) error
+	Cancel func(context.Context) error
+}
+
+func (s *OrderActorStub) Type() string { return "order" }
+func (s *OrderActorStub) ID() string   { return "1" }
+
+type BrokenOrderActorStub struct {
+	Get func(context.Context) (*Order, error)
+	Add func(context.Context, string, string) error
+}
+
+func (s *BrokenOrderActorStub) Type() string { return "order" }
+func (s *BrokenOrderActorStub) ID() string   { return "1" }
+
+func mismatches(t *testing.T, err error) []Mismatch {
+	t.Helper()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr), "want *ValidationError, got %v", err)
+	return verr.Mismatches
+}
+
+func TestValidateImplementation(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, ValidateImplementation((*OrderActor)(nil), &OrderActorImpl{}))
+	})
+
+	t.Run("missing method, wrong arity and signature", func(t *testing.T) {
+		err := ValidateImplementation((*OrderActor)(nil), &BrokenOrderActorImpl{})
+		assert.Equal(t, []Mismatch{
+			{Method: "Add", Kind: MismatchArity, Detail: "has 2 parameters and 1 results, want 3 and 1"},
+			{Method: "Cancel", Kind: MismatchMissingMethod, Detail: "not implemented"},
+			{Method: "Get", Kind: MismatchSignature, Detail: "result 0 is actor.Order, want *actor.Order"},
+		}, mismatches(t, err))
+		assert.Contains(t, err.Error(), "*actor.BrokenOrderActorImpl does not match actor interface actor.OrderActor")
+	})
+
+	t.Run("not serializable and invalid shape", func(t *testing.T) {
+		var kinds []MismatchKind
+		var methods []string
+		for _, m := range mismatches(t, ValidateImplementation((*StreamActor)(nil), &StreamActorImpl{})) {
+			kinds = append(kinds, m.Kind)
+			methods = append(methods, m.Method)
+		}
+		assert.Equal(t, []string{"Handler", "Nested", "NoError", "Subscribe"}, methods)
+		assert.Equal(t, []MismatchKind{MismatchNotSerializable, MismatchNotSerializable, MismatchShape, MismatchNotSerializable}, kinds)
+	})
+
+	t.Run("not an interface", func(t *testing.T) {
+		assert.Error(t, ValidateImplementation(OrderActorImpl{}, &OrderActorImpl{}))
+	})
+}
+
+func TestValidateClientStub(t *testing.T) {
+	assert.NoError(t, ValidateClientStub((*OrderActor)(nil), &OrderActorStub{}))
+
+	err := ValidateClientStub((*OrderActor)(nil), &BrokenOrderActorStub{})
+	assert.Equal(t, []Mismatch{
+		{Method: "Add", Kind: MismatchSignature, Detail: "parameter 2 is string, want int"},
+		{Method: "Cancel", Kind: MismatchMissingMethod, Detail: "no function field"},
+	}, mismatches(t, err))
+}
+
+func TestValidatedFactory(t *testing.T) {
+	f, err := ValidatedFactory[OrderActor](func() *OrderActorImpl { return &OrderActorImpl{} })
+	require.NoError(t, err)
+	assert.Equal(t, "order", f().Type())
+
+	_, err = ValidatedFactory[OrderActor](func() *BrokenOrderActorImpl { return &BrokenOrderActorImpl{} })
+	assert.Len(t, mismatches(t, err), 3)
+}
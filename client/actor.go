@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"time"
 
 	anypb "github.com/golang/protobuf/ptypes/any"
 
@@ -45,19 +46,10 @@ type InvokeActorResponse struct {
 
 // InvokeActor invokes specific operation on the configured Dapr binding.
 // This method covers input, output, and bi-directional bindings.
-func (c *GRPCClient) InvokeActor(ctx context.Context, in *InvokeActorRequest) (out *InvokeActorResponse, err error) {
+func (c *GRPCClient) InvokeActor(ctx context.Context, in *InvokeActorRequest, opts ...InvokeActorOption) (out *InvokeActorResponse, err error) {
 	if in == nil {
 		return nil, errors.New("actor invocation required")
 	}
-	if in.Method == "" {
-		return nil, errors.New("actor invocation method required")
-	}
-	if in.ActorType == "" {
-		return nil, errors.New("actor invocation actorType required")
-	}
-	if in.ActorID == "" {
-		return nil, errors.New("actor invocation actorID required")
-	}
 
 	req := &pb.InvokeActorRequest{
 		ActorType: in.ActorType,
@@ -65,9 +57,32 @@ func (c *GRPCClient) InvokeActor(ctx context.Context, in *InvokeActorRequest) (o
 		Method:    in.Method,
 		Data:      in.Data,
 	}
+	for _, o := range opts {
+		o(req)
+	}
 
-	resp, err := c.protoClient.InvokeActor(c.withAuthToken(ctx), req)
+	ctx, cancel := c.actorInvokeContext(ctx, in.ActorType)
+	defer cancel()
+	start := time.Now()
+	var resp *pb.InvokeActorResponse
+	backoff := actorInvokeInitialBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err = c.protoClient.InvokeActor(c.withAuthToken(ctx), req)
+		if err == nil || attempt >= ActorInvokeMaxRetries || !isActorInvokeRetryable(req, err) {
+			break
+		}
+		if waitErr := waitRetry(ctx, backoff); waitErr != nil {
+			break
+		}
+		backoff *= 2
+		if backoff > actorInvokeMaxBackoff {
+			backoff = actorInvokeMaxBackoff
+		}
+	}
 	if err != nil {
+		if isDeadlineExceeded(ctx, err) {
+			return nil, &ErrActorInvokeTimeout{ActorType: in.ActorType, ActorID: in.ActorID, Method: in.Method, Elapsed: time.Since(start)}
+		}
 		return nil, fmt.Errorf("error invoking binding %s/%s: %w", in.ActorType, in.ActorID, err)
 	}
 
@@ -133,15 +148,6 @@ func (c *GRPCClient) RegisterActorReminder(ctx context.Context, in *RegisterActo
 	if in == nil {
 		return errors.New("actor register reminder invocation request param required")
 	}
-	if in.ActorType == "" {
-		return errors.New("actor register reminder invocation actorType required")
-	}
-	if in.ActorID == "" {
-		return errors.New("actor register reminder invocation actorID required")
-	}
-	if in.Name == "" {
-		return errors.New("actor register reminder invocation name required")
-	}
 
 	req := &pb.RegisterActorReminderRequest{
 		ActorType: in.ActorType,
@@ -171,15 +177,6 @@ func (c *GRPCClient) UnregisterActorReminder(ctx context.Context, in *Unregister
 	if in == nil {
 		return errors.New("actor unregister reminder invocation request param required")
 	}
-	if in.ActorType == "" {
-		return errors.New("actor unregister reminder invocation actorType required")
-	}
-	if in.ActorID == "" {
-		return errors.New("actor unregister reminder invocation actorID required")
-	}
-	if in.Name == "" {
-		return errors.New("actor unregister reminder invocation name required")
-	}
 
 	req := &pb.UnregisterActorReminderRequest{
 		ActorType: in.ActorType,
@@ -211,15 +208,6 @@ func (c *GRPCClient) RegisterActorTimer(ctx context.Context, in *RegisterActorTi
 	if in == nil {
 		return errors.New("actor register timer invocation request param required")
 	}
-	if in.ActorType == "" {
-		return errors.New("actor register timer invocation actorType required")
-	}
-	if in.ActorID == "" {
-		return errors.New("actor register timer invocation actorID required")
-	}
-	if in.Name == "" {
-		return errors.New("actor register timer invocation name required")
-	}
 	if in.CallBack == "" {
 		return errors.New("actor register timer invocation callback function required")
 	}
@@ -254,15 +242,6 @@ func (c *GRPCClient) UnregisterActorTimer(ctx context.Context, in *UnregisterAct
 	if in == nil {
 		return errors.New("actor unregister timer invocation request param required")
 	}
-	if in.ActorType == "" {
-		return errors.New("actor unregister timer invocation actorType required")
-	}
-	if in.ActorID == "" {
-		return errors.New("actor unregister timer invocation actorID required")
-	}
-	if in.Name == "" {
-		return errors.New("actor unregister timer invocation name required")
-	}
 	req := &pb.UnregisterActorTimerRequest{
 		ActorType: in.ActorType,
 		ActorId:   in.ActorID,
@@ -349,18 +328,18 @@ func (c *GRPCClient) makeCallProxyFunction(actor actor.Client, methodName string
 			}
 		}
 
-		if end-start <= 0 {
-			inIArr = []interface{}{}
-		} else if end-start == 1 {
-			inIArr = []interface{}{in[start].Interface()}
-		} else {
-			fmt.Println("[Actor] ERROR: param nums is zero or one is allowed by actor")
-			return nil
+		for _, v := range in[start:end] {
+			inIArr = append(inIArr, v.Interface())
 		}
 
+		// A single argument is sent as is, multiple arguments as an array.
 		var data []byte
-		if len(inIArr) > 0 {
+		switch len(inIArr) {
+		case 0:
+		case 1:
 			data, err = serializer.Marshal(inIArr[0])
+		default:
+			data, err = serializer.Marshal(inIArr)
 		}
 		if err != nil {
 			panic(err)
@@ -404,15 +383,6 @@ func (c *GRPCClient) GetActorState(ctx context.Context, in *GetActorStateRequest
 	if in == nil {
 		return nil, errors.New("actor get state invocation request param required")
 	}
-	if in.ActorType == "" {
-		return nil, errors.New("actor get state invocation actorType required")
-	}
-	if in.ActorID == "" {
-		return nil, errors.New("actor get state invocation actorID required")
-	}
-	if in.KeyName == "" {
-		return nil, errors.New("actor get state invocation keyName required")
-	}
 	rsp, err := c.protoClient.GetActorState(c.withAuthToken(ctx), &pb.GetActorStateRequest{
 		ActorId:   in.ActorID,
 		ActorType: in.ActorType,
@@ -432,15 +402,10 @@ type ActorStateOperation struct {
 }
 
 func (c *GRPCClient) SaveStateTransactionally(ctx context.Context, actorType, actorID string, operations []*ActorStateOperation) error {
+	ctx = withSDKMethod(ctx, "SaveStateTransactionally")
 	if len(operations) == 0 {
 		return errors.New("actor save state transactionally invocation request param operations is empty")
 	}
-	if actorType == "" {
-		return errors.New("actor save state transactionally invocation actorType required")
-	}
-	if actorID == "" {
-		return errors.New("actor save state transactionally invocation actorID required")
-	}
 	grpcOperations := make([]*pb.TransactionalActorStateOperation, 0)
 	for _, op := range operations {
 		var metadata map[string]string
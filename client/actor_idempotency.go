@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+const (
+	// ActorIdempotencyKeyMetadata is the metadata key, forwarded to the actor
+	// as a header, of the idempotency key set with WithIdempotencyKey.
+	ActorIdempotencyKeyMetadata = "idempotency-key"
+
+	// ActorInvokeMaxRetries is the number of times InvokeActor retries an
+	// invocation with an idempotency key after the sidecar is unavailable.
+	ActorInvokeMaxRetries = 3
+
+	actorInvokeInitialBackoff = 100 * time.Millisecond
+	actorInvokeMaxBackoff     = 2 * time.Second
+)
+
+// InvokeActorOption is the type for the functional option of InvokeActor.
+type InvokeActorOption func(*pb.InvokeActorRequest)
+
+// WithIdempotencyKey sets the idempotency key of the invocation, sent to the
+// actor in the ActorIdempotencyKeyMetadata header so that it can discard the
+// invocations it already handled. It marks the method as safe to retry:
+// InvokeActor retries the invocation, with the same key, up to
+// ActorInvokeMaxRetries times when the sidecar is unavailable.
+func WithIdempotencyKey(key string) InvokeActorOption {
+	return func(r *pb.InvokeActorRequest) {
+		if r.Metadata == nil {
+			r.Metadata = make(map[string]string)
+		}
+		r.Metadata[ActorIdempotencyKeyMetadata] = key
+	}
+}
+
+// isActorInvokeRetryable reports whether the invocation req, which failed
+// with err, can be retried.
+func isActorInvokeRetryable(req *pb.InvokeActorRequest, err error) bool {
+	return req.Metadata[ActorIdempotencyKeyMetadata] != "" && status.Code(err) == codes.Unavailable
+}
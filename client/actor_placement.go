@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ActorPlacementMetadataKey is the key of the extended metadata of the sidecar
+// holding the placement of the actors, as a JSON array of ActorPlacement.
+const ActorPlacementMetadataKey = "actors.placement"
+
+// ErrUnsupported is returned when the sidecar does not expose the requested
+// information.
+var ErrUnsupported = errors.New("not supported by the sidecar")
+
+// ErrActorNotPlaced is returned by GetActorPlacement when the sidecar exposes
+// the placement of the actors but not the one of the requested actor.
+var ErrActorNotPlaced = errors.New("actor not placed")
+
+// ActorPlacement is the host an actor is placed on.
+type ActorPlacement struct {
+	ActorType string `json:"actorType"`
+	ActorID   string `json:"actorID"`
+	// Host is the address of the sidecar hosting the actor.
+	Host string `json:"host"`
+	// AppID is the ID of the app hosting the actor, if known.
+	AppID string `json:"appID,omitempty"`
+}
+
+// GetActorPlacement returns the host the actor is placed on, as reported by the
+// extended metadata of the sidecar. It is meant for diagnostics, and returns
+// ErrUnsupported if the sidecar doesn't report the placement of the actors.
+func (c *GRPCClient) GetActorPlacement(ctx context.Context, actorType, actorID string) (*ActorPlacement, error) {
+	if err := requireArgs("GetActorPlacement", "actorType", actorType, "actorID", actorID); err != nil {
+		return nil, err
+	}
+	md, err := c.GetMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	placements, err := parseActorPlacements(md.ExtendedMetadata)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range placements {
+		if p.ActorType == actorType && p.ActorID == actorID {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s/%s", ErrActorNotPlaced, actorType, actorID)
+}
+
+// parseActorPlacements parses the placement of the actors from the extended
+// metadata of the sidecar.
+func parseActorPlacements(extended map[string]string) ([]*ActorPlacement, error) {
+	raw, ok := extended[ActorPlacementMetadataKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: actor placement", ErrUnsupported)
+	}
+	var placements []*ActorPlacement
+	if err := json.Unmarshal([]byte(raw), &placements); err != nil {
+		return nil, fmt.Errorf("error parsing actor placement: %w", err)
+	}
+	return placements, nil
+}
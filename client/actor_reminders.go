@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BulkReminderParallelism is the default maximum number of reminders
+// registered or unregistered concurrently by RegisterActorRemindersBulk and
+// UnregisterActorRemindersBulk.
+const BulkReminderParallelism = 8
+
+// ReminderRegistration is a reminder registered by RegisterActorRemindersBulk.
+type ReminderRegistration = RegisterActorReminderRequest
+
+// ReminderUnregistration is a reminder unregistered by
+// UnregisterActorRemindersBulk.
+type ReminderUnregistration = UnregisterActorReminderRequest
+
+// BulkOptions are the options of RegisterActorRemindersBulk and
+// UnregisterActorRemindersBulk.
+type BulkOptions struct {
+	// Concurrency is the maximum number of reminders processed concurrently,
+	// BulkReminderParallelism if not positive.
+	Concurrency int
+	// Timeout is the deadline of each reminder, none if zero: a slow reminder
+	// fails without holding up the others.
+	Timeout time.Duration
+	// ContinueOnError processes all the reminders even when some fail. By
+	// default, the reminders not started yet when one fails are skipped.
+	ContinueOnError bool
+}
+
+// BulkItemError is the error of a reminder of a bulk operation.
+type BulkItemError struct {
+	// Index is the index of the reminder in the reminders of the operation.
+	Index   int
+	ActorID string
+	Name    string
+	Err     error
+}
+
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("reminder %s of actor %s: %v", e.Name, e.ActorID, e.Err)
+}
+
+func (e *BulkItemError) Unwrap() error {
+	return e.Err
+}
+
+// BulkResult is the outcome of each reminder of a bulk operation, by index in
+// the reminders of the operation, in increasing order. Registering and
+// unregistering reminders is idempotent, so an interrupted operation resumes
+// with the reminders of Remaining.
+type BulkResult struct {
+	Succeeded []int
+	// Failed are the reminders that failed, including the ones interrupted
+	// by the cancellation of the context, which may have been processed by
+	// the runtime.
+	Failed []*BulkItemError
+	// Skipped are the reminders not sent to the runtime, because the context
+	// was cancelled or, without ContinueOnError, another reminder failed.
+	Skipped []int
+}
+
+// Remaining returns the indexes of the failed and skipped reminders, in
+// increasing order.
+func (r BulkResult) Remaining() []int {
+	remaining := make([]int, 0, len(r.Failed)+len(r.Skipped))
+	i, j := 0, 0
+	for i < len(r.Failed) || j < len(r.Skipped) {
+		if j == len(r.Skipped) || (i < len(r.Failed) && r.Failed[i].Index < r.Skipped[j]) {
+			remaining = append(remaining, r.Failed[i].Index)
+			i++
+			continue
+		}
+		remaining = append(remaining, r.Skipped[j])
+		j++
+	}
+	return remaining
+}
+
+// RegisterActorRemindersBulk registers the reminders of actors of type
+// actorType, ignoring their ActorType, concurrently. It returns the outcome
+// of each reminder, with an error if any of them failed or was skipped.
+func (c *GRPCClient) RegisterActorRemindersBulk(ctx context.Context, actorType string, reminders []ReminderRegistration, opts BulkOptions) (BulkResult, error) {
+	ctx = withSDKMethod(ctx, "RegisterActorRemindersBulk")
+	res := runRemindersBulk(ctx, len(reminders), opts, func(i int) (string, string) {
+		return reminders[i].ActorID, reminders[i].Name
+	}, func(ctx context.Context, i int) error {
+		r := reminders[i]
+		r.ActorType = actorType
+		return c.RegisterActorReminder(ctx, &r)
+	})
+	return res, res.err(ctx, "registering", len(reminders))
+}
+
+// UnregisterActorRemindersBulk unregisters the reminders of actors of type
+// actorType, ignoring their ActorType, concurrently. It returns the outcome
+// of each reminder, with an error if any of them failed or was skipped.
+func (c *GRPCClient) UnregisterActorRemindersBulk(ctx context.Context, actorType string, reminders []ReminderUnregistration, opts BulkOptions) (BulkResult, error) {
+	ctx = withSDKMethod(ctx, "UnregisterActorRemindersBulk")
+	res := runRemindersBulk(ctx, len(reminders), opts, func(i int) (string, string) {
+		return reminders[i].ActorID, reminders[i].Name
+	}, func(ctx context.Context, i int) error {
+		r := reminders[i]
+		r.ActorType = actorType
+		return c.UnregisterActorReminder(ctx, &r)
+	})
+	return res, res.err(ctx, "unregistering", len(reminders))
+}
+
+// runRemindersBulk calls fn for the n reminders, with at most
+// opts.Concurrency calls in flight.
+func runRemindersBulk(ctx context.Context, n int, opts BulkOptions, reminder func(i int) (actorID, name string), fn func(ctx context.Context, i int) error) BulkResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = BulkReminderParallelism
+	}
+	errs := make([]error, n)
+	started := make([]bool, n)
+	sem := make(chan struct{}, concurrency)
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+loop:
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+		if ctx.Err() != nil || (failed.Load() && !opts.ContinueOnError) {
+			<-sem
+			break
+		}
+		started[i] = true
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			itemCtx := ctx
+			if opts.Timeout > 0 {
+				var cancel context.CancelFunc
+				itemCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+				defer cancel()
+			}
+			if errs[i] = fn(itemCtx, i); errs[i] != nil {
+				failed.Store(true)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var res BulkResult
+	for i := 0; i < n; i++ {
+		switch {
+		case !started[i]:
+			res.Skipped = append(res.Skipped, i)
+		case errs[i] != nil:
+			actorID, name := reminder(i)
+			res.Failed = append(res.Failed, &BulkItemError{Index: i, ActorID: actorID, Name: name, Err: errs[i]})
+		default:
+			res.Succeeded = append(res.Succeeded, i)
+		}
+	}
+	return res
+}
+
+// err returns the error of a bulk operation on total reminders, wrapping the
+// error of the context if it was cancelled, or else the first failure.
+func (r BulkResult) err(ctx context.Context, verb string, total int) error {
+	if len(r.Failed) == 0 && len(r.Skipped) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("error %s reminders: %d of %d failed, %d skipped", verb, len(r.Failed), total, len(r.Skipped))
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	return fmt.Errorf("%s: %w", msg, r.Failed[0])
+}
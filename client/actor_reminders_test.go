@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// remindersDaprServer records the registered and unregistered reminders,
+// fails the ones named "fail", and blocks the ones named "slow" until their
+// deadline.
+type remindersDaprServer struct {
+	testDaprServer
+
+	lock         sync.Mutex
+	registered   []string
+	unregistered []string
+	// onRegister is called with the context and the name of each reminder
+	// registered.
+	onRegister func(ctx context.Context, name string)
+
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (s *remindersDaprServer) RegisterActorReminder(ctx context.Context, req *pb.RegisterActorReminderRequest) (*empty.Empty, error) {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		max := s.maxInFlight.Load()
+		if n <= max || s.maxInFlight.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	switch req.Name {
+	case "fail":
+		return nil, status.Error(codes.Internal, "reminder failed")
+	case "slow":
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	if req.ActorType != testActorType {
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected actor type %s", req.ActorType)
+	}
+	if s.onRegister != nil {
+		s.onRegister(ctx, req.Name)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.registered = append(s.registered, req.Name)
+	return &empty.Empty{}, nil
+}
+
+func (s *remindersDaprServer) UnregisterActorReminder(ctx context.Context, req *pb.UnregisterActorReminderRequest) (*empty.Empty, error) {
+	if req.Name == "fail" {
+		return nil, status.Error(codes.Internal, "reminder failed")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.unregistered = append(s.unregistered, req.Name)
+	return &empty.Empty{}, nil
+}
+
+func TestRegisterActorRemindersBulk(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("all registered", func(t *testing.T) {
+		srv := &remindersDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		reminders := make([]ReminderRegistration, 20)
+		want := make([]string, len(reminders))
+		for i := range reminders {
+			want[i] = fmt.Sprintf("reminder-%02d", i)
+			reminders[i] = ReminderRegistration{ActorID: "1", Name: want[i], DueTime: "1s"}
+		}
+		res, err := c.(ActorReminderBulkRegistrar).RegisterActorRemindersBulk(ctx, testActorType, reminders, BulkOptions{Concurrency: 3})
+		require.NoError(t, err)
+		assert.Len(t, res.Succeeded, len(reminders))
+		assert.Empty(t, res.Remaining())
+		sort.Strings(srv.registered)
+		assert.Equal(t, want, srv.registered)
+		assert.LessOrEqual(t, srv.maxInFlight.Load(), int32(3))
+	})
+
+	t.Run("partial failure accounting", func(t *testing.T) {
+		srv := &remindersDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		reminders := []ReminderRegistration{
+			{ActorID: "1", Name: "ok-1"},
+			{ActorID: "1", Name: "fail"},
+			{ActorID: "2", Name: "slow"},
+			{ActorID: "2", Name: "ok-2"},
+		}
+		res, err := c.(ActorReminderBulkRegistrar).RegisterActorRemindersBulk(ctx, testActorType, reminders, BulkOptions{
+			Concurrency:     1,
+			Timeout:         50 * time.Millisecond,
+			ContinueOnError: true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "2 of 4 failed, 0 skipped")
+		assert.Contains(t, err.Error(), "reminder fail of actor 1")
+		assert.Equal(t, []int{0, 3}, res.Succeeded)
+		assert.Empty(t, res.Skipped)
+		require.Len(t, res.Failed, 2)
+		assert.Equal(t, 1, res.Failed[0].Index)
+		assert.Equal(t, codes.Internal, status.Code(errors.Unwrap(res.Failed[0].Err)))
+		assert.Equal(t, "slow", res.Failed[1].Name)
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(errors.Unwrap(res.Failed[1].Err)))
+		assert.Equal(t, []int{1, 2}, res.Remaining())
+		assert.Equal(t, []string{"ok-1", "ok-2"}, srv.registered)
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		srv := &remindersDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		res, err := c.(ActorReminderBulkRegistrar).RegisterActorRemindersBulk(ctx, testActorType, []ReminderRegistration{
+			{ActorID: "1", Name: "ok-1"},
+			{ActorID: "1", Name: "fail"},
+			{ActorID: "1", Name: "ok-2"},
+			{ActorID: "1", Name: "ok-3"},
+		}, BulkOptions{Concurrency: 1})
+		var itemErr *BulkItemError
+		require.True(t, errors.As(err, &itemErr))
+		assert.Equal(t, 1, itemErr.Index)
+		assert.Equal(t, []int{0}, res.Succeeded)
+		assert.Equal(t, []int{2, 3}, res.Skipped)
+		assert.Equal(t, []int{1, 2, 3}, res.Remaining())
+	})
+
+	t.Run("cancellation mid-batch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		srv := &remindersDaprServer{onRegister: func(ctx context.Context, name string) {
+			if name == "reminder-02" {
+				cancel()
+				// Wait for the call to be cancelled, before responding.
+				<-ctx.Done()
+			}
+		}}
+		c, closer := getTestClientWithServer(context.Background(), srv)
+		defer closer()
+
+		reminders := make([]ReminderRegistration, 10)
+		for i := range reminders {
+			reminders[i] = ReminderRegistration{ActorID: "1", Name: fmt.Sprintf("reminder-%02d", i)}
+		}
+		res, err := c.(ActorReminderBulkRegistrar).RegisterActorRemindersBulk(ctx, testActorType, reminders, BulkOptions{Concurrency: 1})
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, []int{0, 1}, res.Succeeded)
+		// The third reminder was registered by the runtime, but the client
+		// was cancelled before receiving the response.
+		require.Len(t, res.Failed, 1)
+		assert.Equal(t, 2, res.Failed[0].Index)
+		assert.Equal(t, []int{3, 4, 5, 6, 7, 8, 9}, res.Skipped)
+		assert.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9}, res.Remaining())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		res, err := testClient.(ActorReminderBulkRegistrar).RegisterActorRemindersBulk(ctx, testActorType, nil, BulkOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, res.Remaining())
+	})
+}
+
+func TestUnregisterActorRemindersBulk(t *testing.T) {
+	ctx := context.Background()
+	srv := &remindersDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	res, err := c.(ActorReminderBulkRegistrar).UnregisterActorRemindersBulk(ctx, testActorType, []ReminderUnregistration{
+		{ActorID: "1", Name: "a"},
+		{ActorID: "1", Name: "fail"},
+		{ActorID: "1", Name: "b"},
+	}, BulkOptions{ContinueOnError: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error unregistering reminders: 1 of 3 failed")
+	assert.Equal(t, []int{0, 2}, res.Succeeded)
+	assert.Equal(t, []int{1}, res.Remaining())
+	sort.Strings(srv.unregistered)
+	assert.Equal(t, []string{"a", "b"}, srv.unregistered)
+}
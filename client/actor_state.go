@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ActorStateParallelism is the maximum number of actors whose state is
+// visited concurrently by ForEachActorState.
+const ActorStateParallelism = 8
+
+// ActorStateFunc is called by ForEachActorState for each actor, with functions
+// reading a key of its state and executing a transaction on its state.
+type ActorStateFunc func(id string, get func(key string) ([]byte, error), tx func(ops []*ActorStateOperation) error) error
+
+// ActorStateError is the error of the visit of the state of an actor.
+type ActorStateError struct {
+	ActorType string
+	ActorID   string
+	Err       error
+}
+
+func (e *ActorStateError) Error() string {
+	return fmt.Sprintf("state of actor %s/%s: %v", e.ActorType, e.ActorID, e.Err)
+}
+
+func (e *ActorStateError) Unwrap() error {
+	return e.Err
+}
+
+// ActorStatesError is returned by ForEachActorState when the visit of the
+// state of some of the actors failed.
+type ActorStatesError struct {
+	// Failed holds the errors of the failed actors, in the order of the IDs.
+	Failed []*ActorStateError
+	// Total is the number of actors to visit.
+	Total int
+}
+
+func (e *ActorStatesError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("error visiting the state of %d of %d actors: %s", len(e.Failed), e.Total, strings.Join(msgs, "; "))
+}
+
+// ForEachActorState calls fn for the actors of type actorType with the given
+// IDs, concurrently, at most ActorStateParallelism at a time, to inspect or
+// patch their state from administration tooling. It returns an
+// *ActorStatesError listing the actors for which fn failed, fn having
+// completed for the others. Once ctx is done, the remaining actors fail with
+// its error.
+//
+// The state is read and written through GetActorState and
+// SaveStateTransactionally, outside of the actors: this bypasses their
+// turn-based concurrency, so that a write may race with, or be overwritten by,
+// an active actor. Only patch the state of actors which are not running, or
+// whose state is not being modified.
+func ForEachActorState(ctx context.Context, c Client, actorType string, ids []string, fn ActorStateFunc) error {
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, ActorStateParallelism)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			id := ids[i]
+			get := func(key string) ([]byte, error) {
+				rsp, err := c.GetActorState(ctx, &GetActorStateRequest{ActorType: actorType, ActorID: id, KeyName: key})
+				if err != nil {
+					return nil, err
+				}
+				return rsp.Data, nil
+			}
+			tx := func(ops []*ActorStateOperation) error {
+				return c.SaveStateTransactionally(ctx, actorType, id, ops)
+			}
+			errs[i] = fn(id, get, tx)
+		}(i)
+	}
+	wg.Wait()
+
+	var failed []*ActorStateError
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, &ActorStateError{ActorType: actorType, ActorID: ids[i], Err: err})
+		}
+	}
+	if len(failed) > 0 {
+		return &ActorStatesError{Failed: failed, Total: len(ids)}
+	}
+	return nil
+}
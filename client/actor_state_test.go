@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// actorStateDaprServer stores the actor state in memory, and records the
+// transactions. The state of the actor "fail" can't be read.
+type actorStateDaprServer struct {
+	testDaprServer
+
+	lock         sync.Mutex
+	state        map[string][]byte
+	transactions []*pb.ExecuteActorStateTransactionRequest
+}
+
+func (s *actorStateDaprServer) GetActorState(ctx context.Context, req *pb.GetActorStateRequest) (*pb.GetActorStateResponse, error) {
+	if req.ActorId == "fail" {
+		return nil, status.Error(codes.Internal, "state failed")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return &pb.GetActorStateResponse{Data: s.state[req.ActorType+"/"+req.ActorId+"/"+req.Key]}, nil
+}
+
+func (s *actorStateDaprServer) ExecuteActorStateTransaction(ctx context.Context, req *pb.ExecuteActorStateTransactionRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.transactions = append(s.transactions, req)
+	for _, op := range req.Operations {
+		k := req.ActorType + "/" + req.ActorId + "/" + op.Key
+		switch op.OperationType {
+		case "upsert":
+			s.state[k] = op.Value.GetValue()
+		case "delete":
+			delete(s.state, k)
+		}
+	}
+	return &empty.Empty{}, nil
+}
+
+func TestSaveStateTransactionallyMapping(t *testing.T) {
+	ctx := context.Background()
+	srv := &actorStateDaprServer{state: map[string][]byte{}}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	ttl := int64(60)
+	require.NoError(t, c.SaveStateTransactionally(ctx, testActorType, "1", []*ActorStateOperation{
+		{OperationType: "upsert", Key: "k1", Value: []byte("v1"), TTLInSeconds: &ttl},
+		{OperationType: "delete", Key: "k2"},
+	}))
+	require.Len(t, srv.transactions, 1)
+	req := srv.transactions[0]
+	assert.Equal(t, testActorType, req.ActorType)
+	assert.Equal(t, "1", req.ActorId)
+	require.Len(t, req.Operations, 2)
+	assert.Equal(t, "upsert", req.Operations[0].OperationType)
+	assert.Equal(t, "k1", req.Operations[0].Key)
+	assert.Equal(t, []byte("v1"), req.Operations[0].Value.GetValue())
+	assert.Equal(t, map[string]string{metadataKeyTTLInSeconds: "60"}, req.Operations[0].Metadata)
+	assert.Equal(t, "delete", req.Operations[1].OperationType)
+	assert.Equal(t, "k2", req.Operations[1].Key)
+	assert.Nil(t, req.Operations[1].Metadata)
+}
+
+func TestForEachActorState(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("patches every actor", func(t *testing.T) {
+		srv := &actorStateDaprServer{state: map[string][]byte{}}
+		ids := make([]string, 20)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("%02d", i)
+			srv.state[testActorType+"/"+ids[i]+"/field"] = []byte("corrupted")
+		}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		err := ForEachActorState(ctx, c, testActorType, ids, func(id string, get func(string) ([]byte, error), tx func([]*ActorStateOperation) error) error {
+			v, err := get("field")
+			if err != nil {
+				return err
+			}
+			if string(v) != "corrupted" {
+				return nil
+			}
+			return tx([]*ActorStateOperation{{OperationType: "upsert", Key: "field", Value: []byte("fixed-" + id)}})
+		})
+		require.NoError(t, err)
+		for _, id := range ids {
+			assert.Equal(t, "fixed-"+id, string(srv.state[testActorType+"/"+id+"/field"]))
+		}
+	})
+
+	t.Run("aggregates the errors", func(t *testing.T) {
+		srv := &actorStateDaprServer{state: map[string][]byte{}}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		errSkip := errors.New("skipped")
+		err := ForEachActorState(ctx, c, testActorType, []string{"ok", "fail", "skip"}, func(id string, get func(string) ([]byte, error), tx func([]*ActorStateOperation) error) error {
+			if id == "skip" {
+				return errSkip
+			}
+			_, err := get("field")
+			return err
+		})
+		var statesErr *ActorStatesError
+		require.True(t, errors.As(err, &statesErr))
+		assert.Equal(t, 3, statesErr.Total)
+		require.Len(t, statesErr.Failed, 2)
+		assert.Equal(t, "fail", statesErr.Failed[0].ActorID)
+		assert.Equal(t, codes.Internal, status.Code(errors.Unwrap(statesErr.Failed[0].Err)))
+		assert.Equal(t, "skip", statesErr.Failed[1].ActorID)
+		assert.ErrorIs(t, statesErr.Failed[1], errSkip)
+		assert.Contains(t, err.Error(), "state of actor test/fail")
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		called := false
+		err := ForEachActorState(ctx, testClient, testActorType, []string{"1"}, func(string, func(string) ([]byte, error), func([]*ActorStateOperation) error) error {
+			called = true
+			return nil
+		})
+		var statesErr *ActorStatesError
+		require.True(t, errors.As(err, &statesErr))
+		assert.ErrorIs(t, statesErr.Failed[0], context.Canceled)
+		assert.False(t, called)
+	})
+}
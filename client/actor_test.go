@@ -15,9 +15,16 @@ package client
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 const testActorType = "test"
@@ -301,3 +308,176 @@ func TestUnregisterActorTimer(t *testing.T) {
 		assert.Error(t, testClient.UnregisterActorTimer(ctx, nil))
 	})
 }
+
+// actorArgsDaprServer records the data of actor invocations.
+type actorArgsDaprServer struct {
+	testDaprServer
+	data [][]byte
+}
+
+func (s *actorArgsDaprServer) InvokeActor(ctx context.Context, req *pb.InvokeActorRequest) (*pb.InvokeActorResponse, error) {
+	s.data = append(s.data, req.Data)
+	return &pb.InvokeActorResponse{Data: []byte(`3`)}, nil
+}
+
+type multiArgActorStub struct {
+	Ping func(context.Context) error
+	Echo func(context.Context, string) (int, error)
+	Add  func(context.Context, int, int) (int, error)
+}
+
+func (a *multiArgActorStub) Type() string {
+	return testActorType
+}
+
+func (a *multiArgActorStub) ID() string {
+	return "1"
+}
+
+func TestActorClientStubArguments(t *testing.T) {
+	ctx := context.Background()
+	srv := &actorArgsDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	stub := &multiArgActorStub{}
+	c.ImplActorClientStub(stub)
+
+	require.NoError(t, stub.Ping(ctx))
+	_, err := stub.Echo(ctx, "hello")
+	require.NoError(t, err)
+	sum, err := stub.Add(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, sum)
+
+	require.Len(t, srv.data, 3)
+	assert.Empty(t, srv.data[0])
+	assert.JSONEq(t, `"hello"`, string(srv.data[1]))
+	assert.JSONEq(t, `[1,2]`, string(srv.data[2]))
+}
+
+// slowActorDaprServer answers actor invocations after a delay.
+type slowActorDaprServer struct {
+	testDaprServer
+	delay time.Duration
+}
+
+func (s *slowActorDaprServer) InvokeActor(ctx context.Context, req *pb.InvokeActorRequest) (*pb.InvokeActorResponse, error) {
+	select {
+	case <-time.After(s.delay):
+		return &pb.InvokeActorResponse{Data: []byte(`3`)}, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func TestActorInvokeTimeout(t *testing.T) {
+	ctx := context.Background()
+	c, closer := getTestClientWithServer(ctx, &slowActorDaprServer{delay: 300 * time.Millisecond},
+		WithActorInvokeTimeout(testActorType, 50*time.Millisecond),
+		WithActorInvokeTimeout("", time.Second))
+	defer closer()
+	in := &InvokeActorRequest{ActorType: testActorType, ActorID: "1", Method: "slow"}
+
+	t.Run("default of the actor type", func(t *testing.T) {
+		start := time.Now()
+		_, err := c.InvokeActor(ctx, in)
+		var te *ErrActorInvokeTimeout
+		require.ErrorAs(t, err, &te)
+		assert.Equal(t, ErrActorInvokeTimeout{ActorType: testActorType, ActorID: "1", Method: "slow", Elapsed: te.Elapsed}, *te)
+		assert.GreaterOrEqual(t, te.Elapsed, 50*time.Millisecond)
+		assert.Less(t, time.Since(start), 300*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("fallback default", func(t *testing.T) {
+		_, err := c.InvokeActor(ctx, &InvokeActorRequest{ActorType: "other", ActorID: "1", Method: "slow"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("caller deadline takes precedence", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		_, err := c.InvokeActor(ctx, in)
+		assert.NoError(t, err)
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = c.InvokeActor(ctx, &InvokeActorRequest{ActorType: "other", ActorID: "1", Method: "slow"})
+		var te *ErrActorInvokeTimeout
+		require.ErrorAs(t, err, &te)
+		assert.Less(t, te.Elapsed, 300*time.Millisecond)
+	})
+
+	t.Run("client stubs", func(t *testing.T) {
+		stub := &multiArgActorStub{}
+		c.ImplActorClientStub(stub)
+		_, err := stub.Add(ctx, 1, 2)
+		var te *ErrActorInvokeTimeout
+		require.ErrorAs(t, err, &te)
+		assert.Equal(t, "Add", te.Method)
+	})
+
+	t.Run("business errors are not timeouts", func(t *testing.T) {
+		_, err := c.InvokeActor(ctx, &InvokeActorRequest{ActorType: testActorType, Method: "slow"})
+		require.Error(t, err)
+		var te *ErrActorInvokeTimeout
+		assert.False(t, errors.As(err, &te))
+	})
+}
+
+// flakyActorDaprServer fails the first actor invocations as unavailable, and
+// records the metadata of every attempt.
+type flakyActorDaprServer struct {
+	testDaprServer
+	failures int
+	metadata []map[string]string
+}
+
+func (s *flakyActorDaprServer) InvokeActor(ctx context.Context, req *pb.InvokeActorRequest) (*pb.InvokeActorResponse, error) {
+	s.metadata = append(s.metadata, req.Metadata)
+	if len(s.metadata) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "sidecar unavailable")
+	}
+	return &pb.InvokeActorResponse{Data: []byte(`3`)}, nil
+}
+
+func TestInvokeActorIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	in := &InvokeActorRequest{ActorType: testActorType, ActorID: "1", Method: "add"}
+
+	t.Run("retried with the same key", func(t *testing.T) {
+		srv := &flakyActorDaprServer{failures: 2}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		out, err := c.InvokeActor(ctx, in, WithIdempotencyKey("op-1"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`3`), out.Data)
+		require.Len(t, srv.metadata, 3)
+		for _, md := range srv.metadata {
+			assert.Equal(t, "op-1", md[ActorIdempotencyKeyMetadata])
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		srv := &flakyActorDaprServer{failures: ActorInvokeMaxRetries + 1}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		_, err := c.InvokeActor(ctx, in, WithIdempotencyKey("op-2"))
+		assert.Equal(t, codes.Unavailable, status.Code(errors.Unwrap(err)))
+		assert.Len(t, srv.metadata, ActorInvokeMaxRetries+1)
+	})
+
+	t.Run("not retried without a key", func(t *testing.T) {
+		srv := &flakyActorDaprServer{failures: 1}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		_, err := c.InvokeActor(ctx, in)
+		assert.Equal(t, codes.Unavailable, status.Code(errors.Unwrap(err)))
+		require.Len(t, srv.metadata, 1)
+		assert.Empty(t, srv.metadata[0][ActorIdempotencyKeyMetadata])
+	})
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WithActorInvokeTimeout sets a deadline of d on the invocations of the actors
+// of actorType, by InvokeActor and the actor client stubs, whose context
+// doesn't already have one. An empty actorType sets the timeout of the actor
+// types without one. It takes precedence over WithDefaultTimeout.
+func WithActorInvokeTimeout(actorType string, d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		if o.actorInvokeTimeouts == nil {
+			o.actorInvokeTimeouts = make(map[string]time.Duration)
+		}
+		o.actorInvokeTimeouts[actorType] = d
+	}
+}
+
+// ErrActorInvokeTimeout is returned by InvokeActor when the deadline of the
+// invocation expires, set by the caller or with WithActorInvokeTimeout, so
+// that it can be told apart from the errors returned by the actor. It matches
+// context.DeadlineExceeded with errors.Is.
+type ErrActorInvokeTimeout struct {
+	ActorType string
+	ActorID   string
+	Method    string
+	// Elapsed is the time the invocation ran before timing out.
+	Elapsed time.Duration
+}
+
+func (e *ErrActorInvokeTimeout) Error() string {
+	return fmt.Sprintf("invocation of method %s of actor %s/%s timed out after %s", e.Method, e.ActorType, e.ActorID, e.Elapsed)
+}
+
+func (e *ErrActorInvokeTimeout) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// actorInvokeContext returns ctx with the deadline of the invocations of the
+// actors of actorType, unless ctx already has one or there is none.
+func (c *GRPCClient) actorInvokeContext(ctx context.Context, actorType string) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	d, ok := c.actorInvokeTimeouts[actorType]
+	if !ok {
+		d = c.actorInvokeTimeouts[""]
+	}
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// isDeadlineExceeded reports whether err is the expiry of the deadline of ctx,
+// as returned by a call with ctx.
+func isDeadlineExceeded(ctx context.Context, err error) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
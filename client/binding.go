@@ -14,9 +14,13 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
@@ -44,14 +48,22 @@ type BindingEvent struct {
 // InvokeBinding invokes specific operation on the configured Dapr binding.
 // This method covers input, output, and bi-directional bindings.
 func (c *GRPCClient) InvokeBinding(ctx context.Context, in *InvokeBindingRequest) (*BindingEvent, error) {
+	ctx = withSDKMethod(ctx, "InvokeBinding")
+	return c.invokeBinding(ctx, in)
+}
+
+func (c *GRPCClient) invokeBinding(ctx context.Context, in *InvokeBindingRequest, opts ...grpc.CallOption) (*BindingEvent, error) {
 	if in == nil {
 		return nil, errors.New("binding invocation required")
 	}
 	if in.Name == "" {
-		return nil, errors.New("binding invocation name required")
+		return nil, invalidArgument(sdkMethod(ctx, "InvokeBinding"), "name", reasonEmpty)
 	}
 	if in.Operation == "" {
-		return nil, errors.New("binding invocation operation required")
+		return nil, invalidArgument(sdkMethod(ctx, "InvokeBinding"), "operation", reasonEmpty)
+	}
+	if err := c.checkBindingOperation(ctx, in.Name, in.Operation); err != nil {
+		return nil, err
 	}
 
 	req := &pb.InvokeBindingRequest{
@@ -60,8 +72,19 @@ func (c *GRPCClient) InvokeBinding(ctx context.Context, in *InvokeBindingRequest
 		Data:      in.Data,
 		Metadata:  in.Metadata,
 	}
+	contentType := req.Metadata[metadataKeyContentType]
+	if err := c.resolveContentType("InvokeBinding", in.Name+"/"+in.Operation, &contentType, req.Data); err != nil {
+		return nil, err
+	}
+	if contentType != req.Metadata[metadataKeyContentType] {
+		req.Metadata = make(map[string]string, len(in.Metadata)+1)
+		for k, v := range in.Metadata {
+			req.Metadata[k] = v
+		}
+		req.Metadata[metadataKeyContentType] = contentType
+	}
 
-	resp, err := c.protoClient.InvokeBinding(c.withAuthToken(ctx), req)
+	resp, err := c.protoClient.InvokeBinding(c.withAuthToken(ctx), req, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error invoking binding %s/%s: %w", in.Name, in.Operation, err)
 	}
@@ -76,9 +99,32 @@ func (c *GRPCClient) InvokeBinding(ctx context.Context, in *InvokeBindingRequest
 	return nil, nil
 }
 
+// InvokeBindingStream invokes specific operation on the configured Dapr binding
+// and returns a reader of the response data, which the caller must close, and
+// the response metadata.
+// The Dapr runtime doesn't stream binding responses yet, so the response is
+// received at once and buffered; WithMaxBindingResponseSize raises the maximum
+// size of the responses, for bindings returning large payloads.
+func (c *GRPCClient) InvokeBindingStream(ctx context.Context, in *InvokeBindingRequest) (io.ReadCloser, map[string]string, error) {
+	ctx = withSDKMethod(ctx, "InvokeBindingStream")
+	var opts []grpc.CallOption
+	if c.maxBindingResponseSize > 0 {
+		opts = append(opts, grpc.MaxCallRecvMsgSize(c.maxBindingResponseSize))
+	}
+	out, err := c.invokeBinding(ctx, in, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if out == nil {
+		return io.NopCloser(bytes.NewReader(nil)), nil, nil
+	}
+	return io.NopCloser(bytes.NewReader(out.Data)), out.Metadata, nil
+}
+
 // InvokeOutputBinding invokes configured Dapr binding with data (allows nil).InvokeOutputBinding
 // This method differs from InvokeBinding in that it doesn't expect any content being returned from the invoked method.
 func (c *GRPCClient) InvokeOutputBinding(ctx context.Context, in *InvokeBindingRequest) error {
+	ctx = withSDKMethod(ctx, "InvokeOutputBinding")
 	if _, err := c.InvokeBinding(ctx, in); err != nil {
 		return fmt.Errorf("error invoking output binding: %w", err)
 	}
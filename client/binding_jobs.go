@@ -0,0 +1,333 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/go-sdk/ids"
+	"github.com/dapr/go-sdk/service/common"
+)
+
+const (
+	// DefaultBindingJobMaxAttempts is the default number of times a binding job
+	// is processed before it is considered poison.
+	DefaultBindingJobMaxAttempts = 5
+	// DefaultBindingJobPollInterval is the default interval at which Run drains
+	// the pending binding jobs.
+	DefaultBindingJobPollInterval = time.Second
+
+	bindingJobIndexKey        = "bindingjobs"
+	bindingJobKeyPrefix       = "bindingjob."
+	poisonBindingJobKeyPrefix = "bindingjob-poison."
+
+	// maxBindingJobIndexUpdates is the number of times an update of the job
+	// index is attempted when it conflicts with a concurrent update.
+	maxBindingJobIndexUpdates = 10
+)
+
+// BindingJob is a binding event persisted for later processing.
+type BindingJob struct {
+	ID        string            `json:"id"`
+	Data      []byte            `json:"data,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Attempts  int               `json:"attempts"`
+	LastError string            `json:"lastError,omitempty"`
+}
+
+// BindingJobRunner persists binding events as jobs in a state store and
+// processes them in the background, so that long-running work doesn't hold the
+// binding delivery open.
+type BindingJobRunner struct {
+	client       Client
+	storeName    string
+	process      func(ctx context.Context, in *common.BindingEvent) error
+	maxAttempts  int
+	pollInterval time.Duration
+	onPoison     func(ctx context.Context, job *BindingJob, err error)
+	idGenerator  ids.Generator
+}
+
+// BindingJobRunnerOption configures a BindingJobRunner.
+type BindingJobRunnerOption func(*BindingJobRunner)
+
+// WithJobMaxAttempts sets the number of times a job is processed before it is
+// moved aside as poison. The default is DefaultBindingJobMaxAttempts.
+func WithJobMaxAttempts(n int) BindingJobRunnerOption {
+	return func(r *BindingJobRunner) {
+		if n > 0 {
+			r.maxAttempts = n
+		}
+	}
+}
+
+// WithJobPollInterval sets the interval at which Run drains the pending jobs.
+// The default is DefaultBindingJobPollInterval.
+func WithJobPollInterval(d time.Duration) BindingJobRunnerOption {
+	return func(r *BindingJobRunner) {
+		if d > 0 {
+			r.pollInterval = d
+		}
+	}
+}
+
+// WithPoisonJobHandler sets a function invoked with the last processing error
+// when a job is moved aside as poison.
+func WithPoisonJobHandler(fn func(ctx context.Context, job *BindingJob, err error)) BindingJobRunnerOption {
+	return func(r *BindingJobRunner) {
+		r.onPoison = fn
+	}
+}
+
+// NewBindingJobRunner creates a runner persisting jobs in the named state store
+// and processing them with process. The store must support transactions.
+// Use its Accept method as the handler of AddBindingInvocationHandlerAsync, and
+// call Run to process the accepted jobs.
+func NewBindingJobRunner(c Client, storeName string, process func(ctx context.Context, in *common.BindingEvent) error, opts ...BindingJobRunnerOption) *BindingJobRunner {
+	gen := ids.UUID()
+	if gc, ok := c.(*GRPCClient); ok {
+		gen = gc.ids()
+	}
+	r := &BindingJobRunner{
+		client:       c,
+		storeName:    storeName,
+		process:      process,
+		maxAttempts:  DefaultBindingJobMaxAttempts,
+		pollInterval: DefaultBindingJobPollInterval,
+		idGenerator:  gen,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Accept persists the binding event as a job and returns the job ID.
+// The job is persisted when Accept returns successfully, so it is safe to
+// acknowledge the event then.
+func (r *BindingJobRunner) Accept(ctx context.Context, in *common.BindingEvent) (string, error) {
+	if in == nil {
+		return "", errors.New("nil binding event")
+	}
+	job := &BindingJob{
+		ID:       r.idGenerator.NewID(),
+		Data:     in.Data,
+		Metadata: in.Metadata,
+	}
+	op, err := saveJobOperation(bindingJobKeyPrefix, job)
+	if err != nil {
+		return "", fmt.Errorf("error persisting binding job: %w", err)
+	}
+	err = r.updateIndex(ctx, func(jobIDs []string) []string {
+		return append(jobIDs, job.ID)
+	}, op)
+	if err != nil {
+		return "", fmt.Errorf("error persisting binding job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// Drain processes each pending job once.
+// Jobs that fail are processed again by the next Drain, until they have failed
+// as many times as allowed and are moved aside as poison.
+// Returns the first error accessing the state store.
+func (r *BindingJobRunner) Drain(ctx context.Context) error {
+	jobIDs, _, err := r.loadIndex(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range jobIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.runJob(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run drains the pending jobs every poll interval until ctx is done.
+func (r *BindingJobRunner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			logger.Printf("error draining binding jobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// PoisonJob returns the job with the given ID that was moved aside as poison,
+// or nil if there is none.
+func (r *BindingJobRunner) PoisonJob(ctx context.Context, id string) (*BindingJob, error) {
+	return r.loadJob(ctx, poisonBindingJobKeyPrefix, id)
+}
+
+func (r *BindingJobRunner) runJob(ctx context.Context, id string) error {
+	job, err := r.loadJob(ctx, bindingJobKeyPrefix, id)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return r.removeJob(ctx, id)
+	}
+
+	perr := r.process(ctx, &common.BindingEvent{
+		Data:     job.Data,
+		Metadata: job.Metadata,
+	})
+	if perr == nil {
+		return r.removeJob(ctx, id)
+	}
+
+	job.Attempts++
+	job.LastError = perr.Error()
+	if job.Attempts < r.maxAttempts {
+		return r.saveJob(ctx, bindingJobKeyPrefix, job)
+	}
+
+	op, err := saveJobOperation(poisonBindingJobKeyPrefix, job)
+	if err != nil {
+		return err
+	}
+	if err := r.removeJob(ctx, id, op); err != nil {
+		return err
+	}
+	if r.onPoison != nil {
+		r.onPoison(ctx, job, perr)
+	}
+	return nil
+}
+
+// removeJob deletes the job and removes it from the index, together with ops.
+func (r *BindingJobRunner) removeJob(ctx context.Context, id string, ops ...*StateOperation) error {
+	ops = append(ops, &StateOperation{
+		Type: StateOperationTypeDelete,
+		Item: &SetStateItem{Key: bindingJobKeyPrefix + id},
+	})
+	return r.updateIndex(ctx, func(jobIDs []string) []string {
+		kept := jobIDs[:0]
+		for _, jobID := range jobIDs {
+			if jobID != id {
+				kept = append(kept, jobID)
+			}
+		}
+		return kept
+	}, ops...)
+}
+
+func (r *BindingJobRunner) saveJob(ctx context.Context, prefix string, job *BindingJob) error {
+	data, err := jsonMarshal(job)
+	if err != nil {
+		return fmt.Errorf("error serializing binding job %s: %w", job.ID, err)
+	}
+	return r.client.SaveState(ctx, r.storeName, prefix+job.ID, data, nil)
+}
+
+// saveJobOperation returns the transaction operation saving the job.
+func saveJobOperation(prefix string, job *BindingJob) (*StateOperation, error) {
+	data, err := jsonMarshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing binding job %s: %w", job.ID, err)
+	}
+	return &StateOperation{
+		Type: StateOperationTypeUpsert,
+		Item: &SetStateItem{Key: prefix + job.ID, Value: data},
+	}, nil
+}
+
+func (r *BindingJobRunner) loadJob(ctx context.Context, prefix, id string) (*BindingJob, error) {
+	item, err := r.client.GetState(ctx, r.storeName, prefix+id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error loading binding job %s: %w", id, err)
+	}
+	if item == nil || len(item.Value) == 0 {
+		return nil, nil
+	}
+	job := &BindingJob{}
+	if err := jsonUnmarshal(item.Value, job); err != nil {
+		return nil, fmt.Errorf("error deserializing binding job %s: %w", id, err)
+	}
+	return job, nil
+}
+
+// loadIndex returns the IDs of the pending jobs and the ETag of the index.
+func (r *BindingJobRunner) loadIndex(ctx context.Context) ([]string, string, error) {
+	item, err := r.client.GetState(ctx, r.storeName, bindingJobIndexKey, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("error loading binding job index: %w", err)
+	}
+	if item == nil {
+		return nil, "", nil
+	}
+	if len(item.Value) == 0 {
+		return nil, item.Etag, nil
+	}
+	var jobIDs []string
+	if err := jsonUnmarshal(item.Value, &jobIDs); err != nil {
+		return nil, "", fmt.Errorf("error deserializing binding job index: %w", err)
+	}
+	return jobIDs, item.Etag, nil
+}
+
+// updateIndex replaces the job index with the result of fn in a transaction
+// with ops, retrying when the index was updated concurrently.
+func (r *BindingJobRunner) updateIndex(ctx context.Context, fn func(jobIDs []string) []string, ops ...*StateOperation) error {
+	for i := 0; i < maxBindingJobIndexUpdates; i++ {
+		jobIDs, etag, err := r.loadIndex(ctx)
+		if err != nil {
+			return err
+		}
+		data, err := jsonMarshal(fn(jobIDs))
+		if err != nil {
+			return fmt.Errorf("error serializing binding job index: %w", err)
+		}
+		index := &SetStateItem{
+			Key:   bindingJobIndexKey,
+			Value: data,
+			Options: &StateOptions{
+				Concurrency: StateConcurrencyFirstWrite,
+				Consistency: StateConsistencyStrong,
+			},
+		}
+		if etag != "" {
+			index.Etag = &ETag{Value: etag}
+		}
+		txOps := append(append(make([]*StateOperation, 0, len(ops)+1), ops...), &StateOperation{
+			Type: StateOperationTypeUpsert,
+			Item: index,
+		})
+		err = r.client.ExecuteStateTransaction(ctx, r.storeName, nil, txOps)
+		if status.Code(err) == codes.Aborted {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error saving binding job index: %w", err)
+		}
+		return nil
+	}
+	return errors.New("error saving binding job index: too many concurrent updates")
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	v1 "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/ids"
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// jobStateDaprServer is a state store honoring ETags and first-write
+// concurrency, whose saves can be made to fail.
+type jobStateDaprServer struct {
+	testDaprServer
+	lock      sync.Mutex
+	versions  map[string]int
+	failSaves bool
+	// staleIndex makes reads of the job index return an outdated ETag, as if
+	// the index was always updated concurrently.
+	staleIndex bool
+}
+
+func newJobStateDaprServer() *jobStateDaprServer {
+	return &jobStateDaprServer{
+		testDaprServer: testDaprServer{state: make(map[string][]byte)},
+		versions:       make(map[string]int),
+	}
+}
+
+func (s *jobStateDaprServer) GetState(ctx context.Context, req *pb.GetStateRequest) (*pb.GetStateResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.state[req.Key]
+	if !ok {
+		return &pb.GetStateResponse{}, nil
+	}
+	etag := strconv.Itoa(s.versions[req.Key])
+	if s.staleIndex && req.Key == bindingJobIndexKey {
+		etag = "stale"
+	}
+	return &pb.GetStateResponse{Data: data, Etag: etag}, nil
+}
+
+func (s *jobStateDaprServer) SaveState(ctx context.Context, req *pb.SaveStateRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.failSaves {
+		return nil, status.Error(codes.Unavailable, "state store unavailable")
+	}
+	for _, item := range req.States {
+		if err := s.checkETag(item); err != nil {
+			return nil, err
+		}
+		s.state[item.Key] = item.Value
+		s.versions[item.Key]++
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *jobStateDaprServer) ExecuteStateTransaction(ctx context.Context, req *pb.ExecuteStateTransactionRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.failSaves {
+		return nil, status.Error(codes.Unavailable, "state store unavailable")
+	}
+	for _, op := range req.Operations {
+		if err := s.checkETag(op.Request); err != nil {
+			return nil, err
+		}
+	}
+	for _, op := range req.Operations {
+		item := op.Request
+		if op.OperationType == "delete" {
+			delete(s.state, item.Key)
+			delete(s.versions, item.Key)
+			continue
+		}
+		s.state[item.Key] = item.Value
+		s.versions[item.Key]++
+	}
+	return &empty.Empty{}, nil
+}
+
+// checkETag returns an Aborted error if item can't be written, per its ETag
+// and concurrency.
+func (s *jobStateDaprServer) checkETag(item *v1.StateItem) error {
+	_, exists := s.state[item.Key]
+	switch {
+	case item.GetEtag().GetValue() != "":
+		if item.GetEtag().GetValue() != strconv.Itoa(s.versions[item.Key]) {
+			return status.Error(codes.Aborted, "etag mismatch")
+		}
+	case item.GetOptions().GetConcurrency() == v1.StateOptions_CONCURRENCY_FIRST_WRITE && exists:
+		return status.Error(codes.Aborted, "etag required")
+	}
+	return nil
+}
+
+func (s *jobStateDaprServer) DeleteState(ctx context.Context, req *pb.DeleteStateRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.state, req.Key)
+	delete(s.versions, req.Key)
+	return &empty.Empty{}, nil
+}
+
+func (s *jobStateDaprServer) pendingJobs(t *testing.T) []string {
+	t.Helper()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var jobIDs []string
+	if data, ok := s.state[bindingJobIndexKey]; ok {
+		require.NoError(t, json.Unmarshal(data, &jobIDs))
+	}
+	return jobIDs
+}
+
+func (s *jobStateDaprServer) has(key string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, ok := s.state[key]
+	return ok
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestBindingJobRunner$
+func TestBindingJobRunner(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("job is persisted before accept returns", func(t *testing.T) {
+		srv := newJobStateDaprServer()
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		r := NewBindingJobRunner(c, testStore, func(ctx context.Context, in *common.BindingEvent) error {
+			return nil
+		})
+		id, err := r.Accept(ctx, &common.BindingEvent{Data: []byte("work"), Metadata: map[string]string{"k": "v"}})
+		require.NoError(t, err)
+		assert.NotEmpty(t, id)
+		assert.True(t, srv.has(bindingJobKeyPrefix+id))
+		assert.Equal(t, []string{id}, srv.pendingJobs(t))
+	})
+
+	t.Run("job IDs come from the client generator", func(t *testing.T) {
+		srv := newJobStateDaprServer()
+		c, closer := getTestClientWithServer(ctx, srv, WithIDGenerator(ids.NewSequence("job-")))
+		defer closer()
+
+		r := NewBindingJobRunner(c, testStore, func(ctx context.Context, in *common.BindingEvent) error {
+			return nil
+		})
+		id, err := r.Accept(ctx, &common.BindingEvent{Data: []byte("work")})
+		require.NoError(t, err)
+		assert.Equal(t, "job-1", id)
+	})
+
+	t.Run("job is not persisted when the index update conflicts", func(t *testing.T) {
+		srv := newJobStateDaprServer()
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		r := NewBindingJobRunner(c, testStore, func(ctx context.Context, in *common.BindingEvent) error {
+			return nil
+		})
+		_, err := r.Accept(ctx, &common.BindingEvent{Data: []byte("a")})
+		require.NoError(t, err)
+
+		srv.staleIndex = true
+		_, err = r.Accept(ctx, &common.BindingEvent{Data: []byte("b")})
+		assert.Error(t, err)
+		srv.lock.Lock()
+		defer srv.lock.Unlock()
+		assert.Len(t, srv.state, 2)
+	})
+
+	t.Run("accept fails when the job can't be persisted", func(t *testing.T) {
+		srv := newJobStateDaprServer()
+		srv.failSaves = true
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		r := NewBindingJobRunner(c, testStore, func(ctx context.Context, in *common.BindingEvent) error {
+			return nil
+		})
+		_, err := r.Accept(ctx, &common.BindingEvent{Data: []byte("work")})
+		assert.Error(t, err)
+		assert.Empty(t, srv.pendingJobs(t))
+	})
+
+	t.Run("processed jobs are removed", func(t *testing.T) {
+		srv := newJobStateDaprServer()
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		var processed []string
+		r := NewBindingJobRunner(c, testStore, func(ctx context.Context, in *common.BindingEvent) error {
+			processed = append(processed, string(in.Data)+"/"+in.Metadata["k"])
+			return nil
+		})
+		id1, err := r.Accept(ctx, &common.BindingEvent{Data: []byte("a"), Metadata: map[string]string{"k": "1"}})
+		require.NoError(t, err)
+		id2, err := r.Accept(ctx, &common.BindingEvent{Data: []byte("b"), Metadata: map[string]string{"k": "2"}})
+		require.NoError(t, err)
+
+		require.NoError(t, r.Drain(ctx))
+		assert.Equal(t, []string{"a/1", "b/2"}, processed)
+		assert.Empty(t, srv.pendingJobs(t))
+		assert.False(t, srv.has(bindingJobKeyPrefix+id1))
+		assert.False(t, srv.has(bindingJobKeyPrefix+id2))
+	})
+
+	t.Run("failing jobs are retried then moved aside as poison", func(t *testing.T) {
+		srv := newJobStateDaprServer()
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		attempts := 0
+		var poisoned *BindingJob
+		r := NewBindingJobRunner(c, testStore,
+			func(ctx context.Context, in *common.BindingEvent) error {
+				attempts++
+				return errors.New("boom")
+			},
+			WithJobMaxAttempts(3),
+			WithPoisonJobHandler(func(ctx context.Context, job *BindingJob, err error) {
+				poisoned = job
+			}),
+		)
+		id, err := r.Accept(ctx, &common.BindingEvent{Data: []byte("bad")})
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, r.Drain(ctx))
+			assert.Equal(t, []string{id}, srv.pendingJobs(t))
+			assert.Nil(t, poisoned)
+		}
+
+		require.NoError(t, r.Drain(ctx))
+		assert.Equal(t, 3, attempts)
+		assert.Empty(t, srv.pendingJobs(t))
+		assert.False(t, srv.has(bindingJobKeyPrefix+id))
+		require.NotNil(t, poisoned)
+		assert.Equal(t, id, poisoned.ID)
+		assert.Equal(t, 3, poisoned.Attempts)
+		assert.Equal(t, "boom", poisoned.LastError)
+
+		job, err := r.PoisonJob(ctx, id)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		assert.Equal(t, []byte("bad"), job.Data)
+
+		// Poison jobs are not processed again.
+		require.NoError(t, r.Drain(ctx))
+		assert.Equal(t, 3, attempts)
+	})
+}
@@ -14,10 +14,19 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 // go test -timeout 30s ./client -count 1 -run ^TestInvokeBinding$
@@ -56,3 +65,98 @@ func TestInvokeBinding(t *testing.T) {
 		assert.Equal(t, "test", string(out.Data))
 	})
 }
+
+// bindingsDaprServer reports a set of registered binding components.
+type bindingsDaprServer struct {
+	testDaprServer
+	metadataCalls int
+}
+
+func (s *bindingsDaprServer) GetMetadata(ctx context.Context, req *empty.Empty) (*pb.GetMetadataResponse, error) {
+	s.metadataCalls++
+	return &pb.GetMetadataResponse{
+		RegisteredComponents: []*pb.RegisteredComponents{
+			{Name: "blob", Type: "bindings.azure.blobstorage", Version: "v1"},
+			{Name: "custom", Type: "bindings.custom", Version: "v1"},
+		},
+	}, nil
+}
+
+func TestInvokeBindingWithValidation(t *testing.T) {
+	ctx := context.Background()
+	srv := &bindingsDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv, WithBindingValidation())
+	defer closer()
+
+	t.Run("unsupported operation of a known binding type is rejected", func(t *testing.T) {
+		_, err := c.InvokeBinding(ctx, &InvokeBindingRequest{Name: "blob", Operation: "query"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnsupportedBindingOperation)
+		assert.Contains(t, err.Error(), "bindings.azure.blobstorage")
+	})
+
+	t.Run("supported operation of a known binding type", func(t *testing.T) {
+		_, err := c.InvokeBinding(ctx, &InvokeBindingRequest{Name: "blob", Operation: "get"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown binding types are not validated", func(t *testing.T) {
+		_, err := c.InvokeBinding(ctx, &InvokeBindingRequest{Name: "custom", Operation: "anything"})
+		assert.NoError(t, err)
+		assert.NoError(t, c.InvokeOutputBinding(ctx, &InvokeBindingRequest{Name: "unregistered", Operation: "anything"}))
+	})
+
+	t.Run("without the option", func(t *testing.T) {
+		c, closer := getTestClientWithServer(ctx, &bindingsDaprServer{})
+		defer closer()
+		_, err := c.InvokeBinding(ctx, &InvokeBindingRequest{Name: "blob", Operation: "query"})
+		assert.NoError(t, err)
+	})
+
+	assert.Equal(t, 2, srv.metadataCalls, "binding types are cached")
+}
+
+// largeBindingDaprServer returns a payload of the given size from bindings.
+type largeBindingDaprServer struct {
+	testDaprServer
+	size int
+}
+
+func (s *largeBindingDaprServer) InvokeBinding(ctx context.Context, req *pb.InvokeBindingRequest) (*pb.InvokeBindingResponse, error) {
+	return &pb.InvokeBindingResponse{
+		Data:     bytes.Repeat([]byte("x"), s.size),
+		Metadata: map[string]string{"contentType": "application/octet-stream"},
+	}, nil
+}
+
+func TestInvokeBindingStream(t *testing.T) {
+	ctx := context.Background()
+	in := &InvokeBindingRequest{Name: "blob", Operation: "get"}
+	srv := &largeBindingDaprServer{size: 8 * 1024 * 1024}
+
+	t.Run("large response", func(t *testing.T) {
+		c, closer := getTestClientWithServer(ctx, srv, WithMaxBindingResponseSize(16*1024*1024))
+		defer closer()
+
+		body, meta, err := c.(BindingStreamInvoker).InvokeBindingStream(ctx, in)
+		require.NoError(t, err)
+		defer body.Close()
+		n, err := io.Copy(io.Discard, body)
+		require.NoError(t, err)
+		assert.EqualValues(t, srv.size, n)
+		assert.Equal(t, map[string]string{"contentType": "application/octet-stream"}, meta)
+	})
+
+	t.Run("response over the default limit", func(t *testing.T) {
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		_, _, err := c.(BindingStreamInvoker).InvokeBindingStream(ctx, in)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(errors.Unwrap(err)))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, _, err := testClient.(BindingStreamInvoker).InvokeBindingStream(ctx, &InvokeBindingRequest{Name: "blob"})
+		assert.Error(t, err)
+	})
+}
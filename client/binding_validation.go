@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedBindingOperation is returned by binding invocations when the
+// binding validation determines that the binding doesn't support the operation.
+var ErrUnsupportedBindingOperation = errors.New("unsupported binding operation")
+
+// knownBindingOperations lists the operations supported by common output
+// bindings, by component type.
+var knownBindingOperations = map[string][]string{
+	"bindings.aws.s3":                 {"create", "get", "delete", "list", "presign", "bulkCreate"},
+	"bindings.azure.blobstorage":      {"create", "get", "delete", "list", "bulkGet"},
+	"bindings.azure.storagequeues":    {"create"},
+	"bindings.cron":                   {"delete"},
+	"bindings.gcp.bucket":             {"create", "get", "delete", "list", "bulkGet", "copy", "move", "rename"},
+	"bindings.http":                   {"create", "get", "head", "post", "put", "patch", "delete", "options", "trace"},
+	"bindings.kafka":                  {"create"},
+	"bindings.localstorage":           {"create", "get", "delete", "list"},
+	"bindings.mqtt3":                  {"create"},
+	"bindings.mysql":                  {"exec", "query", "close"},
+	"bindings.postgres":               {"exec", "query", "close"},
+	"bindings.postgresql":             {"exec", "query", "close"},
+	"bindings.rabbitmq":               {"create"},
+	"bindings.redis":                  {"create", "get", "delete", "increment"},
+	"bindings.smtp":                   {"create"},
+	"bindings.twilio.sms":             {"create"},
+	"bindings.azure.eventhubs":        {"create"},
+	"bindings.azure.servicebusqueues": {"create"},
+}
+
+// bindingTypeCache caches the component type of bindings, by name.
+type bindingTypeCache struct {
+	lock  sync.RWMutex
+	types map[string]string
+}
+
+func newBindingTypeCache() *bindingTypeCache {
+	return &bindingTypeCache{
+		types: map[string]string{},
+	}
+}
+
+// bindingType returns the component type of the named binding, or an empty
+// string if it is not registered with the sidecar.
+func (c *GRPCClient) bindingType(ctx context.Context, name string) (string, error) {
+	c.bindingTypes.lock.RLock()
+	t, ok := c.bindingTypes.types[name]
+	c.bindingTypes.lock.RUnlock()
+	if ok {
+		return t, nil
+	}
+
+	md, err := c.GetMetadata(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error validating binding operation: %w", err)
+	}
+	c.bindingTypes.lock.Lock()
+	defer c.bindingTypes.lock.Unlock()
+	for _, rc := range md.RegisteredComponents {
+		if strings.HasPrefix(rc.Type, "bindings.") {
+			c.bindingTypes.types[rc.Name] = rc.Type
+		}
+	}
+	if _, ok := c.bindingTypes.types[name]; !ok {
+		c.bindingTypes.types[name] = ""
+	}
+	return c.bindingTypes.types[name], nil
+}
+
+// checkBindingOperation returns ErrUnsupportedBindingOperation if the binding
+// validation is enabled and the operation is not supported by the binding.
+// Bindings whose type isn't known are not validated.
+func (c *GRPCClient) checkBindingOperation(ctx context.Context, name, operation string) error {
+	if c.bindingTypes == nil {
+		return nil
+	}
+	t, err := c.bindingType(ctx, name)
+	if err != nil {
+		return err
+	}
+	ops, ok := knownBindingOperations[t]
+	if !ok {
+		return nil
+	}
+	for _, op := range ops {
+		if op == operation {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: binding %s of type %s supports %s, not %s", ErrUnsupportedBindingOperation, name, t, strings.Join(ops, ", "), operation)
+}
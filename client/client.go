@@ -28,6 +28,7 @@ import (
 
 	"github.com/dapr/go-sdk/actor"
 	"github.com/dapr/go-sdk/actor/config"
+	"github.com/dapr/go-sdk/ids"
 	"github.com/dapr/go-sdk/version"
 
 	"google.golang.org/grpc"
@@ -55,10 +56,12 @@ var (
 	logger               = log.New(os.Stdout, "", 0)
 	lock                 = &sync.Mutex{}
 	_             Client = (*GRPCClient)(nil)
-	defaultClient Client
+	defaultClient        = &defaultClientOnce{}
 )
 
 // Client is the interface for Dapr client implementation.
+// GRPCClient also implements optional interfaces, such as
+// ConditionalStateWriter, which callers check for with a type assertion.
 //
 //nolint:interfacebloat
 type Client interface {
@@ -131,7 +134,7 @@ type Client interface {
 	DeleteStateWithETag(ctx context.Context, storeName, key string, etag *ETag, meta map[string]string, opts *StateOptions) error
 
 	// ExecuteStateTransaction provides way to execute multiple operations on a specified store.
-	ExecuteStateTransaction(ctx context.Context, storeName string, meta map[string]string, ops []*StateOperation) error
+	ExecuteStateTransaction(ctx context.Context, storeName string, meta map[string]string, ops []*StateOperation, opts ...StateTransactionOption) error
 
 	// GetConfigurationItem can get target configuration item by storeName and key
 	GetConfigurationItem(ctx context.Context, storeName, key string, opts ...ConfigurationOpt) (*ConfigurationItem, error)
@@ -193,7 +196,7 @@ type Client interface {
 	UnregisterActorReminder(ctx context.Context, req *UnregisterActorReminderRequest) error
 
 	// InvokeActor calls a method on an actor.
-	InvokeActor(ctx context.Context, req *InvokeActorRequest) (*InvokeActorResponse, error)
+	InvokeActor(ctx context.Context, req *InvokeActorRequest, opts ...InvokeActorOption) (*InvokeActorResponse, error)
 
 	// GetActorState get actor state
 	GetActorState(ctx context.Context, req *GetActorStateRequest) (data *GetActorStateResponse, err error)
@@ -208,54 +211,269 @@ type Client interface {
 	GrpcClient() pb.DaprClient
 }
 
-// NewClient instantiates Dapr client using DAPR_GRPC_PORT environment variable as port.
-// Note, this default factory function creates Dapr client only once. All subsequent invocations
-// will return the already created instance. To create multiple instances of the Dapr client,
-// use one of the parameterized factory functions:
+// ConditionalStateWriter is implemented by clients that can write state only if
+// its ETag still matches.
+type ConditionalStateWriter interface {
+	// DeleteStateIfUnchanged deletes content from store only if its ETag still matches, returning ErrETagMismatch otherwise.
+	DeleteStateIfUnchanged(ctx context.Context, storeName, key, etag string) error
+
+	// SaveStateAndPublishIfUnchanged saves content to store, and publishes it with the transactional outbox, only if its ETag still matches, returning ErrETagMismatch otherwise.
+	SaveStateAndPublishIfUnchanged(ctx context.Context, storeName, key string, data []byte, etag string, event []byte) error
+}
+
+// FeatureChecker is implemented by clients that can check the features enabled
+// in the sidecar.
+type FeatureChecker interface {
+	// SupportedFeatures returns the list of features enabled in the sidecar.
+	SupportedFeatures(ctx context.Context) ([]string, error)
+
+	// HasFeature reports whether the named feature is enabled in the sidecar.
+	HasFeature(ctx context.Context, name string) (bool, error)
+}
+
+// StateWatcher is implemented by clients that can watch state keys for changes.
+type StateWatcher interface {
+	// WatchState polls the given keys every interval and emits their changes on the returned channel until ctx is done.
+	WatchState(ctx context.Context, storeName string, keys []string, interval time.Duration) (<-chan StateChange, error)
+}
+
+// StoreDefaulter is implemented by clients with default component metadata.
+type StoreDefaulter interface {
+	// StoreDefaults returns the default metadata set with WithStoreDefaults for the named component.
+	StoreDefaults(componentName string) map[string]string
+}
+
+// SecretReader is implemented by clients with the extended secret retrieval
+// methods.
+type SecretReader interface {
+	// GetBulkSecretPartial retrieves the named secrets, returning the readable ones and a per-secret error map for the rest.
+	GetBulkSecretPartial(ctx context.Context, storeName string, keys []string, meta map[string]string) (data map[string]map[string]string, errs map[string]error, err error)
+
+	// GetBulkSecretInto retrieves all secrets of the store into the fields of target tagged with `secret:"<name>"`.
+	GetBulkSecretInto(ctx context.Context, storeName string, target any) error
+
+	// BulkSecretIterator returns an iterator over the secrets of the store that fetches them one page at a time.
+	BulkSecretIterator(ctx context.Context, storeName string, meta map[string]string) (SecretIterator, error)
+
+	// GetSecretInto retrieves the value of a secret and writes it to target.
+	GetSecretInto(ctx context.Context, storeName, name string, target io.Writer) error
+}
+
+// BindingStreamInvoker is implemented by clients that can stream the response
+// data of a binding.
+type BindingStreamInvoker interface {
+	// InvokeBindingStream invokes specific operation on the configured Dapr binding and returns a reader of the
+	// response data, which the caller must close, and the response metadata.
+	InvokeBindingStream(ctx context.Context, in *InvokeBindingRequest) (io.ReadCloser, map[string]string, error)
+}
+
+// ActorPlacementGetter is implemented by clients that can locate actors.
+type ActorPlacementGetter interface {
+	// GetActorPlacement returns the host an actor is placed on, if the sidecar reports it.
+	GetActorPlacement(ctx context.Context, actorType, actorID string) (*ActorPlacement, error)
+}
+
+// AsyncPublisher is implemented by clients that can publish events in the
+// background.
+type AsyncPublisher interface {
+	// PublishEventAsync queues data to be published onto topic in specific pubsub component, and calls cb with the result.
+	PublishEventAsync(ctx context.Context, pubsubName, topicName string, data interface{}, cb func(error), opts ...PublishEventOption) error
+}
+
+// PublishChecker is implemented by clients that can check whether the app may
+// publish to a topic.
+type PublishChecker interface {
+	// CanPublish reports whether the app may publish to the topic, based on the sidecar metadata.
+	CanPublish(ctx context.Context, pubsubName, topicName string) (allowed bool, reason string, err error)
+}
+
+// ConfigurationUnsubscriber is implemented by clients that track their
+// configuration subscriptions.
+type ConfigurationUnsubscriber interface {
+	// UnsubscribeAllConfiguration unsubscribes all the configuration subscriptions created by the client.
+	UnsubscribeAllConfiguration(ctx context.Context) error
+}
+
+// GracefulCloser is implemented by clients that can wait for the calls in
+// flight before closing.
+type GracefulCloser interface {
+	// GracefulClose stops accepting new calls, waits for the calls in flight to finish, bounded by ctx, and then closes the client.
+	GracefulClose(ctx context.Context) error
+}
+
+// CallTracker is implemented by clients that track their calls in flight.
+type CallTracker interface {
+	// InFlight returns the calls to the runtime in flight, oldest first.
+	InFlight() []CallInfo
+
+	// CancelCall cancels the context of the call in flight with the given ID, returning false if there is none.
+	CancelCall(id string) bool
+}
+
+// ActorReminderBulkRegistrar is implemented by clients that can register actor
+// reminders in bulk.
+type ActorReminderBulkRegistrar interface {
+	// RegisterActorRemindersBulk registers actor reminders concurrently, reporting the outcome of each one.
+	RegisterActorRemindersBulk(ctx context.Context, actorType string, reminders []ReminderRegistration, opts BulkOptions) (BulkResult, error)
+
+	// UnregisterActorRemindersBulk unregisters actor reminders concurrently, reporting the outcome of each one.
+	UnregisterActorRemindersBulk(ctx context.Context, actorType string, reminders []ReminderUnregistration, opts BulkOptions) (BulkResult, error)
+}
+
+// RawClient is implemented by clients that expose their connection to the
+// runtime.
+type RawClient interface {
+	// DaprClient returns the generated Dapr runtime client, for the runtime APIs the SDK doesn't wrap yet.
+	// Its calls go through the client interceptors, but not through the retries and validations of the SDK methods.
+	DaprClient() pb.DaprClient
+
+	// GrpcClientConn returns the connection to the runtime, to make raw calls on it.
+	// Its calls bypass the retries, validations, metrics and other interceptors of the SDK.
+	GrpcClientConn() *grpc.ClientConn
+}
+
+// WorkflowManager is implemented by clients that can manage workflow instances.
+type WorkflowManager interface {
+	// GetWorkflow retrieves the state of a workflow instance.
+	GetWorkflow(ctx context.Context, component, instanceID string) (*WorkflowState, error)
+
+	// RaiseWorkflowEvent raises an event with a JSON-serialized payload on a running workflow instance.
+	RaiseWorkflowEvent(ctx context.Context, component, instanceID, eventName string, payload any) error
+
+	// WaitForWorkflowStatusChange polls a workflow instance until its status differs from `from` or ctx is done.
+	WaitForWorkflowStatusChange(ctx context.Context, component, instanceID string, from WorkflowStatus, pollInterval time.Duration) (*WorkflowState, error)
+
+	// StartWorkflow starts an instance of a workflow and returns its ID.
+	StartWorkflow(ctx context.Context, component, workflowName string, opts StartWorkflowOptions) (string, error)
+
+	// TerminateWorkflow terminates a workflow instance.
+	TerminateWorkflow(ctx context.Context, component, instanceID string) error
+
+	// PurgeWorkflow removes the state of a workflow instance in a terminal state.
+	PurgeWorkflow(ctx context.Context, component, instanceID string) error
+}
+
+// ResponseInvoker is implemented by clients that can return the details of a
+// service invocation response.
+type ResponseInvoker interface {
+	// InvokeMethodWithResponse invokes service with optional content and returns the response with its content type
+	// and the outcome of the resiliency policies applied by the sidecar.
+	InvokeMethodWithResponse(ctx context.Context, appID, methodName, verb string, content *DataContent) (*InvokeResponse, error)
+}
+
+// GRPCClient implements the optional interfaces of Client.
+var (
+	_ ConditionalStateWriter     = (*GRPCClient)(nil)
+	_ FeatureChecker             = (*GRPCClient)(nil)
+	_ StateWatcher               = (*GRPCClient)(nil)
+	_ StoreDefaulter             = (*GRPCClient)(nil)
+	_ SecretReader               = (*GRPCClient)(nil)
+	_ BindingStreamInvoker       = (*GRPCClient)(nil)
+	_ ActorPlacementGetter       = (*GRPCClient)(nil)
+	_ AsyncPublisher             = (*GRPCClient)(nil)
+	_ PublishChecker             = (*GRPCClient)(nil)
+	_ ConfigurationUnsubscriber  = (*GRPCClient)(nil)
+	_ GracefulCloser             = (*GRPCClient)(nil)
+	_ CallTracker                = (*GRPCClient)(nil)
+	_ ActorReminderBulkRegistrar = (*GRPCClient)(nil)
+	_ RawClient                  = (*GRPCClient)(nil)
+	_ WorkflowManager            = (*GRPCClient)(nil)
+	_ ResponseInvoker            = (*GRPCClient)(nil)
+)
+
+// NewClient instantiates Dapr client using DAPR_GRPC_PORT environment variable as port,
+// or the address returned by the resolver set with WithAddressResolver.
+// The address is resolved each time a client is created. To share a single
+// instance of the Dapr client, use DefaultClient. To create a client for a
+// given address, use one of the parameterized factory functions:
 //
-//	NewClientWithPort(port string) (client Client, err error)
-//	NewClientWithAddress(address string) (client Client, err error)
-//	NewClientWithConnection(conn *grpc.ClientConn) Client
-//	NewClientWithSocket(socket string) (client Client, err error)
-func NewClient() (client Client, err error) {
+//	NewClientWithPort(port string, opts ...ClientOption) (client Client, err error)
+//	NewClientWithAddress(address string, opts ...ClientOption) (client Client, err error)
+//	NewClientWithConnection(conn *grpc.ClientConn, opts ...ClientOption) Client
+//	NewClientWithSocket(socket string, opts ...ClientOption) (client Client, err error)
+func NewClient(opts ...ClientOption) (client Client, err error) {
+	resolve := newClientOptions(opts...).addressResolver
+	if resolve == nil {
+		resolve = defaultAddress
+	}
+	address, err := resolve()
+	if err != nil {
+		return nil, fmt.Errorf("error resolving client address: %w", err)
+	}
+	return NewClientWithAddress(address, opts...)
+}
+
+// defaultAddress returns the address of the Dapr sidecar on localhost, on the
+// port in the DAPR_GRPC_PORT environment variable.
+func defaultAddress() (string, error) {
 	port := os.Getenv(daprPortEnvVarName)
 	if port == "" {
 		port = daprPortDefault
 	}
-	if defaultClient != nil {
-		return defaultClient, nil
-	}
+	return net.JoinHostPort("127.0.0.1", port), nil
+}
+
+// defaultClientOnce holds the shared client created by DefaultClient.
+type defaultClientOnce struct {
+	once   sync.Once
+	client Client
+	err    error
+}
+
+// DefaultClient returns the Dapr client shared by the process, creating it with
+// NewClient on the first call. The options are only used to create the client.
+// When the creation fails the error is returned and the next call tries again.
+func DefaultClient(opts ...ClientOption) (Client, error) {
 	lock.Lock()
-	defer lock.Unlock()
-	if defaultClient != nil {
-		return defaultClient, nil
-	}
-	c, err := NewClientWithPort(port)
-	if err != nil {
-		return nil, fmt.Errorf("error creating default client: %w", err)
+	d := defaultClient
+	lock.Unlock()
+
+	d.once.Do(func() {
+		d.client, d.err = NewClient(opts...)
+	})
+	if d.err != nil {
+		lock.Lock()
+		if defaultClient == d {
+			defaultClient = &defaultClientOnce{}
+		}
+		lock.Unlock()
+		return nil, fmt.Errorf("error creating default client: %w", d.err)
 	}
-	defaultClient = c
+	return d.client, nil
+}
 
-	return defaultClient, nil
+// ResetDefaultClient closes the client shared by DefaultClient, if any, so that
+// the next call to DefaultClient creates a new one. It is meant for tests.
+func ResetDefaultClient() {
+	lock.Lock()
+	d := defaultClient
+	defaultClient = &defaultClientOnce{}
+	lock.Unlock()
+
+	// Waits for a creation in progress, so that its client is closed as well.
+	d.once.Do(func() {})
+	if d.client != nil {
+		d.client.Close()
+	}
 }
 
 // NewClientWithPort instantiates Dapr using specific gRPC port.
-func NewClientWithPort(port string) (client Client, err error) {
+func NewClientWithPort(port string, opts ...ClientOption) (client Client, err error) {
 	if port == "" {
 		return nil, errors.New("nil port")
 	}
-	return NewClientWithAddress(net.JoinHostPort("127.0.0.1", port))
+	return NewClientWithAddress(net.JoinHostPort("127.0.0.1", port), opts...)
 }
 
 // NewClientWithAddress instantiates Dapr using specific address (including port).
 // Deprecated: use NewClientWithAddressContext instead.
-func NewClientWithAddress(address string) (client Client, err error) {
-	return NewClientWithAddressContext(context.Background(), address)
+func NewClientWithAddress(address string, opts ...ClientOption) (client Client, err error) {
+	return NewClientWithAddressContext(context.Background(), address, opts...)
 }
 
 // NewClientWithAddressContext instantiates Dapr using specific address (including port).
 // Uses the provided context to create the connection.
-func NewClientWithAddressContext(ctx context.Context, address string) (client Client, err error) {
+func NewClientWithAddressContext(ctx context.Context, address string, opts ...ClientOption) (client Client, err error) {
 	if address == "" {
 		return nil, errors.New("empty address")
 	}
@@ -265,14 +483,14 @@ func NewClientWithAddressContext(ctx context.Context, address string) (client Cl
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
-	conn, err := grpc.DialContext(
-		ctx,
-		address,
+	dialOpts := append([]grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithUserAgent(userAgent()),
 		grpc.WithBlock(),
-	)
+	}, newClientOptions(opts...).dialOptions()...)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+	conn, err := grpc.DialContext(ctx, address, dialOpts...)
 	cancel()
 	if err != nil {
 		return nil, fmt.Errorf("error creating connection to '%s': %w", address, err)
@@ -281,7 +499,7 @@ func NewClientWithAddressContext(ctx context.Context, address string) (client Cl
 		logger.Println("client uses API token")
 	}
 
-	return NewClientWithConnection(conn), nil
+	return NewClientWithConnection(conn, opts...), nil
 }
 
 func getClientTimeoutSeconds() (int, error) {
@@ -300,32 +518,63 @@ func getClientTimeoutSeconds() (int, error) {
 }
 
 // NewClientWithSocket instantiates Dapr using specific socket.
-func NewClientWithSocket(socket string) (client Client, err error) {
+func NewClientWithSocket(socket string, opts ...ClientOption) (client Client, err error) {
 	if socket == "" {
 		return nil, errors.New("nil socket")
 	}
 	logger.Printf("dapr client initializing for: %s", socket)
 	addr := "unix://" + socket
-	conn, err := grpc.Dial(
-		addr,
+	dialOpts := append([]grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithUserAgent(userAgent()),
-	)
+	}, newClientOptions(opts...).dialOptions()...)
+	conn, err := grpc.Dial(addr, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("error creating connection to '%s': %w", addr, err)
 	}
 	if hasToken := os.Getenv(apiTokenEnvVarName); hasToken != "" {
 		logger.Println("client uses API token")
 	}
-	return NewClientWithConnection(conn), nil
+	return NewClientWithConnection(conn, opts...), nil
 }
 
 // NewClientWithConnection instantiates Dapr client using specific connection.
-func NewClientWithConnection(conn *grpc.ClientConn) Client {
+func NewClientWithConnection(conn *grpc.ClientConn, opts ...ClientOption) Client {
+	c := newClientWithTransport(conn, newClientOptions(opts...))
+	c.connection = conn
+	return c
+}
+
+// newClientWithTransport returns a client making its calls on transport, a
+// *grpc.ClientConn or the HTTP transport of NewHTTPClient.
+func newClientWithTransport(transport grpc.ClientConnInterface, o *clientOptions) *GRPCClient {
+	var bindingTypes *bindingTypeCache
+	if o.bindingValidation {
+		bindingTypes = newBindingTypeCache()
+	}
+	calls := newInflightCalls(o.callObserver)
 	return &GRPCClient{
-		connection:  conn,
-		protoClient: pb.NewDaprClient(conn),
+		protoClient: pb.NewDaprClient(newInterceptedConn(transport, o, calls)),
 		authToken:   os.Getenv(apiTokenEnvVarName),
+
+		maxWorkflowEventSize:    o.maxWorkflowEventSize,
+		publishPreflightEnabled: o.publishPreflight,
+		preflight:               newPublishPreflight(),
+		idGenerator:             o.idGenerator,
+		bindingTypes:            bindingTypes,
+		storeDefaults:           o.storeDefaults,
+		secretPageSize:          o.secretPageSize,
+		maxBulkSecretMsgSize:    o.maxBulkSecretMsgSize,
+		maxBindingResponseSize:  o.maxBindingRespSize,
+		asyncPublisher:          newAsyncPublisher(o.asyncPublishWorkers, o.asyncPublishQueue, o.asyncPublishDrain),
+		configSubs:              &configSubscriptions{subs: make(map[string]*configSubscription)},
+		actorInvokeTimeouts:     o.actorInvokeTimeouts,
+		defaultContentType:      o.defaultContentType,
+		strictContentType:       o.strictContentType,
+		contentTypeWarnings:     &sync.Map{},
+		callObserver:            o.callObserver,
+		pubsubNamespace:         o.pubsubNamespace,
+		inflight:                calls,
 	}
 }
 
@@ -334,16 +583,64 @@ type GRPCClient struct {
 	connection  *grpc.ClientConn
 	protoClient pb.DaprClient
 	authToken   string
+
+	maxWorkflowEventSize    int
+	publishPreflightEnabled bool
+	preflight               *publishPreflight
+	idGenerator             ids.Generator
+	bindingTypes            *bindingTypeCache
+	storeDefaults           map[string]map[string]string
+	secretPageSize          int
+	maxBulkSecretMsgSize    int
+	maxBindingResponseSize  int
+	asyncPublisher          *asyncPublisher
+	configSubs              *configSubscriptions
+	actorInvokeTimeouts     map[string]time.Duration
+	defaultContentType      string
+	strictContentType       bool
+	callObserver            CallObserver
+	pubsubNamespace         string
+	inflight                *inflightCalls
+	httpTransport           *httpTransport
+	// contentTypeWarnings holds the methods and targets whose missing content
+	// type was logged.
+	contentTypeWarnings *sync.Map
 }
 
-// Close cleans up all resources created by the client.
+// ids returns the generator of the identifiers created by the client.
+func (c *GRPCClient) ids() ids.Generator {
+	if c.idGenerator == nil {
+		return ids.UUID()
+	}
+	return c.idGenerator
+}
+
+// Close cleans up all resources created by the client, after waiting for the
+// events queued by PublishEventAsync up to the drain timeout.
 func (c *GRPCClient) Close() {
+	c.asyncPublisher.close()
 	if c.connection != nil {
 		c.connection.Close()
 		c.connection = nil
 	}
 }
 
+// GracefulClose closes the client after waiting for the events queued by
+// PublishEventAsync, and then for the calls in flight, to be sent. Calls made
+// after it is called fail with ErrClientClosed. If ctx is done before the calls
+// finish, the connection is closed anyway, failing them, and the error of ctx
+// is returned. Streaming calls, such as configuration subscriptions, are not
+// waited for.
+func (c *GRPCClient) GracefulClose(ctx context.Context) error {
+	c.asyncPublisher.close()
+	err := c.inflight.drain(ctx)
+	if c.connection != nil {
+		c.connection.Close()
+		c.connection = nil
+	}
+	return err
+}
+
 // WithAuthToken sets Dapr API token on the instantiated client.
 // Allows empty string to reset token on existing client.
 func (c *GRPCClient) WithAuthToken(token string) {
@@ -361,7 +658,7 @@ func (c *GRPCClient) WithTraceID(ctx context.Context, id string) context.Context
 }
 
 func (c *GRPCClient) withAuthToken(ctx context.Context) context.Context {
-	if c.authToken == "" {
+	if c.authToken == "" || ctx == nil {
 		return ctx
 	}
 	return metadata.NewOutgoingContext(ctx, metadata.Pairs(apiTokenKey, c.authToken))
@@ -381,11 +678,34 @@ func (c *GRPCClient) GrpcClient() pb.DaprClient {
 	return c.protoClient
 }
 
+// DaprClient returns the generated Dapr runtime client, for the runtime APIs
+// the SDK doesn't wrap yet. Its calls go through the client interceptors, such
+// as the metrics and the propagation of metadata, but not through the retries
+// and validations of the SDK methods, and carry the API token only if set in
+// their context.
+// The access is recorded by the observer set with WithCallObserver, if it is a
+// RawAccessObserver.
+func (c *GRPCClient) DaprClient() pb.DaprClient {
+	c.observeRawAccess("DaprClient")
+	return c.protoClient
+}
+
 // GrpcClientConn returns the grpc.ClientConn object used by this client.
+// Calls made on it bypass the retries, validations, metrics and other
+// interceptors of the SDK.
+// The access is recorded by the observer set with WithCallObserver, if it is a
+// RawAccessObserver.
 func (c *GRPCClient) GrpcClientConn() *grpc.ClientConn {
+	c.observeRawAccess("GrpcClientConn")
 	return c.connection
 }
 
+func (c *GRPCClient) observeRawAccess(accessor string) {
+	if o, ok := c.callObserver.(RawAccessObserver); ok {
+		o.ObserveRawAccess(accessor)
+	}
+}
+
 func userAgent() string {
 	return "dapr-sdk-go/" + strings.TrimSpace(version.SDKVersion)
 }
@@ -134,11 +134,15 @@ func TestShutdown(t *testing.T) {
 }
 
 func getTestClient(ctx context.Context) (client Client, closer func()) {
-	s := grpc.NewServer()
-	pb.RegisterDaprServer(s, &testDaprServer{
+	return getTestClientWithServer(ctx, &testDaprServer{
 		state:                       make(map[string][]byte),
 		configurationSubscriptionID: map[string]chan struct{}{},
 	})
+}
+
+func getTestClientWithServer(ctx context.Context, srv pb.DaprServer, opts ...ClientOption) (client Client, closer func()) {
+	s := grpc.NewServer()
+	pb.RegisterDaprServer(s, srv)
 
 	l := bufconn.Listen(testBufSize)
 	go func() {
@@ -161,7 +165,7 @@ func getTestClient(ctx context.Context) (client Client, closer func()) {
 		s.Stop()
 	}
 
-	client = NewClientWithConnection(c)
+	client = NewClientWithConnection(c, opts...)
 	return
 }
 
@@ -505,3 +509,120 @@ func TestGrpcClient(t *testing.T) {
 	client := &GRPCClient{protoClient: protoClient}
 	assert.Equal(t, protoClient, client.GrpcClient())
 }
+
+// startTCPTestServer serves a test Dapr server on a TCP port of localhost and
+// returns the port.
+func startTCPTestServer(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterDaprServer(s, &testDaprServer{state: make(map[string][]byte)})
+	go func() {
+		_ = s.Serve(l)
+	}()
+	t.Cleanup(s.Stop)
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+func clientTarget(t *testing.T, c Client) string {
+	t.Helper()
+
+	gc, ok := c.(*GRPCClient)
+	require.True(t, ok)
+	return gc.connection.Target()
+}
+
+func TestNewClientResolvesAddress(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		t.Run("port from env", func(t *testing.T) {
+			port := startTCPTestServer(t)
+			t.Setenv(daprPortEnvVarName, port)
+			c, err := NewClient()
+			require.NoError(t, err)
+			defer c.Close()
+			assert.Equal(t, net.JoinHostPort("127.0.0.1", port), clientTarget(t, c))
+		})
+	}
+
+	t.Run("address resolver", func(t *testing.T) {
+		address := net.JoinHostPort("127.0.0.1", startTCPTestServer(t))
+		c, err := NewClient(WithAddressResolver(func() (string, error) { return address, nil }))
+		require.NoError(t, err)
+		defer c.Close()
+		assert.Equal(t, address, clientTarget(t, c))
+	})
+
+	t.Run("address resolver error", func(t *testing.T) {
+		_, err := NewClient(WithAddressResolver(func() (string, error) { return "", errors.New("no sidecar") }))
+		assert.ErrorContains(t, err, "no sidecar")
+	})
+}
+
+func TestDefaultClient(t *testing.T) {
+	defer ResetDefaultClient()
+
+	t.Run("shared until reset", func(t *testing.T) {
+		ResetDefaultClient()
+		port := startTCPTestServer(t)
+		t.Setenv(daprPortEnvVarName, port)
+		c1, err := DefaultClient()
+		require.NoError(t, err)
+		c2, err := DefaultClient()
+		require.NoError(t, err)
+		assert.Same(t, c1, c2)
+
+		ResetDefaultClient()
+		port = startTCPTestServer(t)
+		t.Setenv(daprPortEnvVarName, port)
+		c3, err := DefaultClient()
+		require.NoError(t, err)
+		assert.NotSame(t, c1, c3)
+		assert.Equal(t, net.JoinHostPort("127.0.0.1", port), clientTarget(t, c3))
+	})
+
+	t.Run("failed creation is retried", func(t *testing.T) {
+		ResetDefaultClient()
+		_, err := DefaultClient(WithAddressResolver(func() (string, error) { return "", errors.New("no sidecar") }))
+		require.Error(t, err)
+
+		address := net.JoinHostPort("127.0.0.1", startTCPTestServer(t))
+		c, err := DefaultClient(WithAddressResolver(func() (string, error) { return address, nil }))
+		require.NoError(t, err)
+		assert.Equal(t, address, clientTarget(t, c))
+	})
+
+	t.Run("parallel calls and resets", func(t *testing.T) {
+		ResetDefaultClient()
+		address := net.JoinHostPort("127.0.0.1", startTCPTestServer(t))
+		resolver := WithAddressResolver(func() (string, error) { return address, nil })
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := DefaultClient(resolver)
+				assert.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				c, err := NewClient(resolver)
+				if assert.NoError(t, err) {
+					c.Close()
+				}
+			}()
+			if i%5 == 0 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ResetDefaultClient()
+				}()
+			}
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// PassthroughCodec is a gRPC codec for the protobuf messages of the Dapr API
+// that avoids copying raw byte payloads, such as state values and invocation
+// responses, out of the received message.
+//
+// The default codec decodes a response by copying every bytes field out of the
+// message received by gRPC, so that large payloads are held twice in memory.
+// PassthroughCodec makes the bytes fields of the decoded message, including
+// the ones of nested messages such as the value of an Any, refer to the
+// received message instead. It relies on gRPC not reusing the buffers of
+// received messages, which holds for the gRPC version required by the SDK.
+//
+// Requests are encoded as by the default codec. The payloads passed to the
+// client as []byte, such as SetStateItem.Value, DataContent.Data and the data
+// of PublishEvent, are not copied before the request is encoded, so that they
+// are copied once, into the message sent by gRPC.
+//
+// Use it with WithCodec.
+type PassthroughCodec struct{}
+
+var _ encoding.Codec = PassthroughCodec{}
+
+// Name returns the name of the codec, which is the one of the protobuf codec
+// so that the content type of the calls is unchanged.
+func (PassthroughCodec) Name() string {
+	return "proto"
+}
+
+// Marshal encodes v, which must be a proto.Message.
+func (PassthroughCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal, message is %T, want proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal decodes data into v, which must be a proto.Message. The bytes
+// fields of v refer to data after the call.
+func (PassthroughCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal, message is %T, want proto.Message", v)
+	}
+	proto.Reset(m)
+	return unmarshalAliased(data, m.ProtoReflect())
+}
+
+// unmarshalAliased decodes b into m, setting the singular bytes fields to
+// slices of b and decoding the singular message fields recursively. The other
+// fields are decoded by proto.Unmarshal.
+func unmarshalAliased(b []byte, m protoreflect.Message) error {
+	fields := m.Descriptor().Fields()
+	var rest []byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if fd := fields.ByNumber(num); fd != nil && typ == protowire.BytesType && fd.Cardinality() != protoreflect.Repeated {
+			v, vn := protowire.ConsumeBytes(b[n:])
+			if vn < 0 {
+				return protowire.ParseError(vn)
+			}
+			switch fd.Kind() {
+			case protoreflect.BytesKind:
+				m.Set(fd, protoreflect.ValueOfBytes(v[:len(v):len(v)]))
+				b = b[n+vn:]
+				continue
+			case protoreflect.MessageKind:
+				if err := unmarshalAliased(v, m.Mutable(fd).Message()); err != nil {
+					return err
+				}
+				b = b[n+vn:]
+				continue
+			}
+		}
+		vn := protowire.ConsumeFieldValue(num, typ, b[n:])
+		if vn < 0 {
+			return protowire.ParseError(vn)
+		}
+		rest = append(rest, b[:n+vn]...)
+		b = b[n+vn:]
+	}
+	if len(rest) == 0 {
+		return nil
+	}
+	return proto.UnmarshalOptions{Merge: true}.Unmarshal(rest, m.Interface())
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"unsafe"
+
+	anypb "github.com/golang/protobuf/ptypes/any"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// refersTo returns whether b is a slice of buf.
+func refersTo(b, buf []byte) bool {
+	if len(b) == 0 || len(buf) == 0 {
+		return false
+	}
+	start := uintptr(unsafe.Pointer(&buf[0]))
+	p := uintptr(unsafe.Pointer(&b[0]))
+	return p >= start && p < start+uintptr(len(buf))
+}
+
+func TestPassthroughCodec(t *testing.T) {
+	codec := PassthroughCodec{}
+	assert.Equal(t, "proto", codec.Name())
+
+	t.Run("state response", func(t *testing.T) {
+		data, err := codec.Marshal(&pb.GetStateResponse{
+			Data:     bytes.Repeat([]byte("x"), 1024),
+			Etag:     "1",
+			Metadata: map[string]string{"ttl": "60"},
+		})
+		require.NoError(t, err)
+
+		got := &pb.GetStateResponse{Etag: "stale"}
+		require.NoError(t, codec.Unmarshal(data, got))
+		want := &pb.GetStateResponse{}
+		require.NoError(t, proto.Unmarshal(data, want))
+		assert.True(t, proto.Equal(want, got))
+		assert.True(t, refersTo(got.Data, data))
+	})
+
+	t.Run("invocation response", func(t *testing.T) {
+		data, err := codec.Marshal(&commonv1pb.InvokeResponse{
+			ContentType: "application/octet-stream",
+			Data:        &anypb.Any{TypeUrl: "type", Value: bytes.Repeat([]byte("x"), 1024)},
+		})
+		require.NoError(t, err)
+
+		got := &commonv1pb.InvokeResponse{}
+		require.NoError(t, codec.Unmarshal(data, got))
+		want := &commonv1pb.InvokeResponse{}
+		require.NoError(t, proto.Unmarshal(data, want))
+		assert.True(t, proto.Equal(want, got))
+		assert.True(t, refersTo(got.Data.Value, data))
+	})
+
+	t.Run("repeated messages", func(t *testing.T) {
+		data, err := codec.Marshal(&pb.GetBulkStateResponse{Items: []*pb.BulkStateItem{
+			{Key: "a", Data: []byte("1")},
+			{Key: "b", Data: []byte("2"), Metadata: map[string]string{"k": "v"}},
+		}})
+		require.NoError(t, err)
+
+		got := &pb.GetBulkStateResponse{}
+		require.NoError(t, codec.Unmarshal(data, got))
+		want := &pb.GetBulkStateResponse{}
+		require.NoError(t, proto.Unmarshal(data, want))
+		assert.True(t, proto.Equal(want, got))
+	})
+
+	t.Run("invalid messages", func(t *testing.T) {
+		assert.Error(t, codec.Unmarshal([]byte{0x0a, 0x05, 'x'}, &pb.GetStateResponse{}))
+		assert.Error(t, codec.Unmarshal(nil, "not a message"))
+		_, err := codec.Marshal("not a message")
+		assert.Error(t, err)
+	})
+}
+
+func TestWithCodec(t *testing.T) {
+	ctx := context.Background()
+	c, closer := getTestClientWithServer(ctx, &testDaprServer{
+		state:                       make(map[string][]byte),
+		configurationSubscriptionID: map[string]chan struct{}{},
+	}, WithCodec(PassthroughCodec{}))
+	defer closer()
+
+	value := bytes.Repeat([]byte("x"), 64*1024)
+	require.NoError(t, c.SaveState(ctx, "store", "key", value, nil))
+	item, err := c.GetState(ctx, "store", "key", nil)
+	require.NoError(t, err)
+	assert.Equal(t, value, item.Value)
+
+	out, err := c.InvokeMethodWithContent(ctx, "app", "method", "post", &DataContent{
+		ContentType: "application/octet-stream",
+		Data:        value,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, value, out)
+}
+
+// BenchmarkCodecUnmarshal compares the allocations made by the default codec
+// and PassthroughCodec to decode a state response with a 1MB value.
+func BenchmarkCodecUnmarshal(b *testing.B) {
+	data, err := proto.Marshal(&pb.GetStateResponse{
+		Data: bytes.Repeat([]byte("x"), 1024*1024),
+		Etag: "1",
+	})
+	require.NoError(b, err)
+
+	b.Run("default", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := proto.Unmarshal(data, &pb.GetStateResponse{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("passthrough", func(b *testing.B) {
+		codec := PassthroughCodec{}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := codec.Unmarshal(data, &pb.GetStateResponse{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"sync"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
@@ -24,6 +27,7 @@ func WithConfigurationMetadata(key, value string) ConfigurationOpt {
 }
 
 func (c *GRPCClient) GetConfigurationItem(ctx context.Context, storeName, key string, opts ...ConfigurationOpt) (*ConfigurationItem, error) {
+	ctx = withSDKMethod(ctx, "GetConfigurationItem")
 	items, err := c.GetConfigurationItems(ctx, storeName, []string{key}, opts...)
 	if err != nil {
 		return nil, err
@@ -36,6 +40,7 @@ func (c *GRPCClient) GetConfigurationItem(ctx context.Context, storeName, key st
 }
 
 func (c *GRPCClient) GetConfigurationItems(ctx context.Context, storeName string, keys []string, opts ...ConfigurationOpt) (map[string]*ConfigurationItem, error) {
+	ctx = withSDKMethod(ctx, "GetConfigurationItems")
 	metadata := make(map[string]string)
 	for _, opt := range opts {
 		opt(metadata)
@@ -43,7 +48,7 @@ func (c *GRPCClient) GetConfigurationItems(ctx context.Context, storeName string
 	rsp, err := c.protoClient.GetConfiguration(ctx, &pb.GetConfigurationRequest{
 		StoreName: storeName,
 		Keys:      keys,
-		Metadata:  metadata,
+		Metadata:  c.withStoreDefaults(storeName, metadata),
 	})
 	if err != nil {
 		return nil, err
@@ -63,22 +68,37 @@ func (c *GRPCClient) GetConfigurationItems(ctx context.Context, storeName string
 type ConfigurationHandleFunction func(string, map[string]*ConfigurationItem)
 
 func (c *GRPCClient) SubscribeConfigurationItems(ctx context.Context, storeName string, keys []string, handler ConfigurationHandleFunction, opts ...ConfigurationOpt) (string, error) {
+	ctx = withSDKMethod(ctx, "SubscribeConfigurationItems")
 	metadata := make(map[string]string)
 	for _, opt := range opts {
 		opt(metadata)
 	}
 
+	// The stream is canceled by UnsubscribeAllConfiguration.
+	ctx, cancel := context.WithCancel(ctx)
 	client, err := c.protoClient.SubscribeConfiguration(ctx, &pb.SubscribeConfigurationRequest{
 		StoreName: storeName,
 		Keys:      keys,
-		Metadata:  metadata,
+		Metadata:  c.withStoreDefaults(storeName, metadata),
 	})
 	if err != nil {
+		cancel()
 		return "", fmt.Errorf("subscribe configuration failed with error = %w", err)
 	}
+	sub := &configSubscription{storeName: storeName, cancel: cancel, done: make(chan struct{})}
 	subscribeIDChan := make(chan string, 1)
 	go func() {
+		defer close(sub.done)
+		defer cancel()
 		isFirst := true
+		var id string
+		defer func() {
+			if isFirst {
+				close(subscribeIDChan)
+			} else {
+				c.configSubs.remove(id, sub)
+			}
+		}()
 		for {
 			rsp, err := client.Recv()
 			if errors.Is(err, io.EOF) || rsp == nil {
@@ -97,7 +117,9 @@ func (c *GRPCClient) SubscribeConfigurationItems(ctx context.Context, storeName
 			}
 			// Get the subscription ID from the first response.
 			if isFirst {
-				subscribeIDChan <- rsp.Id
+				id = rsp.Id
+				c.configSubs.add(id, sub)
+				subscribeIDChan <- id
 				isFirst = false
 			}
 			// Do not invoke handler in case there are no items.
@@ -106,12 +128,15 @@ func (c *GRPCClient) SubscribeConfigurationItems(ctx context.Context, storeName
 			}
 		}
 	}()
-	subscribeID := <-subscribeIDChan
-	close(subscribeIDChan)
+	subscribeID, ok := <-subscribeIDChan
+	if !ok {
+		return "", errors.New("subscribe configuration failed: stream closed before the subscription ID was received")
+	}
 	return subscribeID, nil
 }
 
 func (c *GRPCClient) UnsubscribeConfigurationItems(ctx context.Context, storeName string, id string, opts ...ConfigurationOpt) error {
+	ctx = withSDKMethod(ctx, "UnsubscribeConfigurationItems")
 	resp, err := c.protoClient.UnsubscribeConfiguration(ctx, &pb.UnsubscribeConfigurationRequest{
 		StoreName: storeName,
 		Id:        id,
@@ -122,5 +147,110 @@ func (c *GRPCClient) UnsubscribeConfigurationItems(ctx context.Context, storeNam
 	if !resp.Ok {
 		return fmt.Errorf("unsubscribe error message = %s", resp.GetMessage())
 	}
+	if sub := c.configSubs.remove(id, nil); sub != nil {
+		sub.cancel()
+	}
+	return nil
+}
+
+// UnsubscribeConfigurationError is returned by UnsubscribeAllConfiguration
+// when some of the subscriptions failed to unsubscribe.
+type UnsubscribeConfigurationError struct {
+	// Errors are the errors of the failed subscriptions, by subscription ID.
+	Errors map[string]error
+	// Total is the number of subscriptions to unsubscribe.
+	Total int
+}
+
+func (e *UnsubscribeConfigurationError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("subscription %s: %v", id, e.Errors[id])
+	}
+	return fmt.Sprintf("error unsubscribing %d of %d configuration subscriptions: %s", len(e.Errors), e.Total, strings.Join(msgs, "; "))
+}
+
+// UnsubscribeAllConfiguration unsubscribes all the configuration subscriptions
+// created with SubscribeConfigurationItems by this client and still active,
+// then waits for their handlers to return. The streams of the subscriptions
+// are closed even if unsubscribing fails, in which case it returns an
+// *UnsubscribeConfigurationError.
+func (c *GRPCClient) UnsubscribeAllConfiguration(ctx context.Context) error {
+	ctx = withSDKMethod(ctx, "UnsubscribeAllConfiguration")
+	subs := c.configSubs.removeAll()
+	errs := make(map[string]error)
+	for id, sub := range subs {
+		if err := c.UnsubscribeConfigurationItems(ctx, sub.storeName, id); err != nil {
+			errs[id] = err
+		}
+		sub.cancel()
+	}
+	for _, sub := range subs {
+		select {
+		case <-sub.done:
+		case <-ctx.Done():
+			return fmt.Errorf("error waiting for the configuration subscriptions to end: %w", ctx.Err())
+		}
+	}
+	if len(errs) > 0 {
+		return &UnsubscribeConfigurationError{Errors: errs, Total: len(subs)}
+	}
 	return nil
 }
+
+// configSubscription is an active configuration subscription.
+type configSubscription struct {
+	storeName string
+	// cancel closes the stream of the subscription.
+	cancel context.CancelFunc
+	// done is closed when the goroutine receiving the updates ends.
+	done chan struct{}
+}
+
+// configSubscriptions tracks the active configuration subscriptions of a
+// client, by ID. A nil *configSubscriptions tracks nothing.
+type configSubscriptions struct {
+	lock sync.Mutex
+	subs map[string]*configSubscription
+}
+
+func (s *configSubscriptions) add(id string, sub *configSubscription) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.subs[id] = sub
+}
+
+// remove stops tracking the subscription id, if it is sub or sub is nil, and
+// returns it.
+func (s *configSubscriptions) remove(id string, sub *configSubscription) *configSubscription {
+	if s == nil {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	cur, ok := s.subs[id]
+	if !ok || (sub != nil && cur != sub) {
+		return nil
+	}
+	delete(s.subs, id)
+	return cur
+}
+
+func (s *configSubscriptions) removeAll() map[string]*configSubscription {
+	if s == nil {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	subs := s.subs
+	s.subs = make(map[string]*configSubscription)
+	return subs
+}
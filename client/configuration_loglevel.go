@@ -0,0 +1,239 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+const (
+	bindLogLevelInitialBackoff = 100 * time.Millisecond
+	bindLogLevelMaxBackoff     = 10 * time.Second
+)
+
+// DefaultLogLevels are the log levels accepted by BindLogLevel, unless set
+// with WithLogLevels.
+var DefaultLogLevels = []string{"debug", "info", "warn", "error"}
+
+// LogLevelBindingOption is the type for the functional option of
+// BindLogLevel.
+type LogLevelBindingOption func(*LogLevelBinding)
+
+// WithLogLevels sets the log levels accepted by BindLogLevel, compared case
+// insensitively. The setter is called with the level as given here.
+func WithLogLevels(levels ...string) LogLevelBindingOption {
+	return func(b *LogLevelBinding) {
+		b.levels = make(map[string]string, len(levels))
+		for _, l := range levels {
+			b.levels[strings.ToLower(l)] = l
+		}
+	}
+}
+
+// LogLevelBinding keeps a log level in sync with a configuration item. It is
+// created with BindLogLevel.
+type LogLevelBinding struct {
+	c      Client
+	store  string
+	key    string
+	setter func(level string) error
+	levels map[string]string
+
+	lock  sync.Mutex
+	level string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// BindLogLevel reads the log level from the key of the configuration store,
+// calls setter with it, then subscribes to the changes of the key and calls
+// setter with each new level, until ctx is done or Close is called. The levels
+// that are not accepted, DefaultLogLevels unless set with WithLogLevels, are
+// ignored. If setter fails, it is called again with the previous level, to
+// roll back the change. When c is a client created by this package, the
+// subscription is resubscribed with exponential backoff when its stream is
+// lost; other implementations of Client are subscribed once.
+// It fails if the initial level can't be read or set.
+func BindLogLevel(ctx context.Context, c Client, store, key string, setter func(level string) error, opts ...LogLevelBindingOption) (*LogLevelBinding, error) {
+	if c == nil {
+		return nil, errors.New("nil client")
+	}
+	if store == "" || key == "" {
+		return nil, errors.New("configuration store and key are required")
+	}
+	if setter == nil {
+		return nil, errors.New("nil log level setter")
+	}
+	b := &LogLevelBinding{c: c, store: store, key: key, setter: setter, done: make(chan struct{})}
+	WithLogLevels(DefaultLogLevels...)(b)
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	item, err := c.GetConfigurationItem(ctx, store, key)
+	if err != nil {
+		return nil, fmt.Errorf("error getting log level from %s/%s: %w", store, key, err)
+	}
+	if item != nil && item.Value != "" {
+		if err := b.apply(item.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, b.cancel = context.WithCancel(ctx)
+	go b.watch(ctx)
+	return b, nil
+}
+
+// Level returns the current log level, set by the last successful call to the
+// setter, or an empty string if the key has no value yet.
+func (b *LogLevelBinding) Level() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.level
+}
+
+// Close stops following the changes of the log level.
+func (b *LogLevelBinding) Close() {
+	b.cancel()
+	<-b.done
+}
+
+// apply calls the setter with value, if it is an accepted level different
+// from the current one, rolling back to the current level if it fails.
+func (b *LogLevelBinding) apply(value string) error {
+	level, ok := b.levels[strings.ToLower(strings.TrimSpace(value))]
+	if !ok {
+		return fmt.Errorf("invalid log level %q", value)
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if level == b.level {
+		return nil
+	}
+	if err := b.setter(level); err != nil {
+		err = fmt.Errorf("error setting log level %s: %w", level, err)
+		if b.level != "" {
+			if rerr := b.setter(b.level); rerr != nil {
+				return fmt.Errorf("%w; error rolling back to log level %s: %v", err, b.level, rerr)
+			}
+		}
+		return err
+	}
+	b.level = level
+	return nil
+}
+
+func (b *LogLevelBinding) handle(_ string, items map[string]*ConfigurationItem) {
+	item, ok := items[b.key]
+	if !ok || item == nil {
+		return
+	}
+	if err := b.apply(item.Value); err != nil {
+		logger.Printf("error updating log level from %s/%s: %v", b.store, b.key, err)
+	}
+}
+
+// configurationStreamer is implemented by the clients that can run a
+// configuration subscription until its stream ends.
+type configurationStreamer interface {
+	streamConfiguration(ctx context.Context, storeName string, keys []string, handler ConfigurationHandleFunction) (subscribed bool, err error)
+}
+
+// watch follows the changes of the log level until ctx is done.
+func (b *LogLevelBinding) watch(ctx context.Context) {
+	defer close(b.done)
+	keys := []string{b.key}
+
+	s, ok := b.c.(configurationStreamer)
+	if !ok {
+		id, err := b.c.SubscribeConfigurationItems(ctx, b.store, keys, b.handle)
+		if err != nil {
+			logger.Printf("error subscribing to log level %s/%s: %v", b.store, b.key, err)
+			return
+		}
+		<-ctx.Done()
+		if err := b.c.UnsubscribeConfigurationItems(context.Background(), b.store, id); err != nil {
+			logger.Printf("error unsubscribing from log level %s/%s: %v", b.store, b.key, err)
+		}
+		return
+	}
+
+	backoff := bindLogLevelInitialBackoff
+	for {
+		subscribed, err := s.streamConfiguration(ctx, b.store, keys, b.handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if subscribed {
+			backoff = bindLogLevelInitialBackoff
+		}
+		logger.Printf("configuration subscription to log level %s/%s lost, resubscribing in %s: %v", b.store, b.key, backoff, err)
+		if waitRetry(ctx, backoff) != nil {
+			return
+		}
+		backoff *= 2
+		if backoff > bindLogLevelMaxBackoff {
+			backoff = bindLogLevelMaxBackoff
+		}
+		// Catch up with the changes made while the stream was lost.
+		item, err := b.c.GetConfigurationItem(ctx, b.store, b.key)
+		if err == nil && item != nil {
+			b.handle("", map[string]*ConfigurationItem{b.key: item})
+		}
+	}
+}
+
+// streamConfiguration subscribes to the changes of the keys of the
+// configuration store, and calls handler with them until the stream ends or
+// ctx is done. It reports whether the subscription was established.
+func (c *GRPCClient) streamConfiguration(ctx context.Context, storeName string, keys []string, handler ConfigurationHandleFunction) (subscribed bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.protoClient.SubscribeConfiguration(ctx, &pb.SubscribeConfigurationRequest{
+		StoreName: storeName,
+		Keys:      keys,
+		Metadata:  c.withStoreDefaults(storeName, map[string]string{}),
+	})
+	if err != nil {
+		return false, err
+	}
+	for {
+		rsp, err := stream.Recv()
+		if err != nil {
+			return subscribed, err
+		}
+		subscribed = true
+		if len(rsp.Items) == 0 {
+			continue
+		}
+		items := make(map[string]*ConfigurationItem, len(rsp.Items))
+		for k, v := range rsp.Items {
+			items[k] = &ConfigurationItem{
+				Value:    v.Value,
+				Version:  v.Version,
+				Metadata: v.Metadata,
+			}
+		}
+		handler(rsp.Id, items)
+	}
+}
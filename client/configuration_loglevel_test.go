@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// logLevelDaprServer serves the log level of its configuration store, and
+// streams the values sent to updates to the subscriptions. Sending
+// "!disconnect" ends the current stream with an error.
+type logLevelDaprServer struct {
+	testDaprServer
+
+	lock    sync.Mutex
+	current string
+
+	updates       chan string
+	subscriptions atomic.Int32
+}
+
+func (s *logLevelDaprServer) GetConfiguration(ctx context.Context, in *pb.GetConfigurationRequest) (*pb.GetConfigurationResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	items := map[string]*commonv1pb.ConfigurationItem{}
+	if s.current != "" {
+		items["loglevel"] = &commonv1pb.ConfigurationItem{Value: s.current}
+	}
+	return &pb.GetConfigurationResponse{Items: items}, nil
+}
+
+func (s *logLevelDaprServer) SubscribeConfiguration(in *pb.SubscribeConfigurationRequest, server pb.Dapr_SubscribeConfigurationServer) error {
+	s.subscriptions.Add(1)
+	if err := server.Send(&pb.SubscribeConfigurationResponse{Id: "sub"}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-server.Context().Done():
+			return nil
+		case v := <-s.updates:
+			if v == "!disconnect" {
+				return status.Error(codes.Unavailable, "stream lost")
+			}
+			s.lock.Lock()
+			s.current = v
+			s.lock.Unlock()
+			if err := server.Send(&pb.SubscribeConfigurationResponse{
+				Id:    "sub",
+				Items: map[string]*commonv1pb.ConfigurationItem{"loglevel": {Value: v}},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// levelRecorder is a log level setter failing for the level "error".
+type levelRecorder struct {
+	lock  sync.Mutex
+	calls []string
+}
+
+func (r *levelRecorder) set(level string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.calls = append(r.calls, level)
+	if level == "error" {
+		return errors.New("unsupported level")
+	}
+	return nil
+}
+
+func (r *levelRecorder) get() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func TestBindLogLevel(t *testing.T) {
+	ctx := context.Background()
+	srv := &logLevelDaprServer{current: "info", updates: make(chan string)}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		r := &levelRecorder{}
+		_, err := BindLogLevel(ctx, c, "", "loglevel", r.set)
+		assert.Error(t, err)
+		_, err = BindLogLevel(ctx, c, "store", "loglevel", nil)
+		assert.Error(t, err)
+		_, err = BindLogLevel(ctx, c, "store", "loglevel", r.set, WithLogLevels("verbose"))
+		assert.ErrorContains(t, err, `invalid log level "info"`)
+		assert.Empty(t, r.get())
+	})
+
+	r := &levelRecorder{}
+	b, err := BindLogLevel(ctx, c, "store", "loglevel", r.set, WithLogLevels("DEBUG", "info", "warn", "error"))
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, "info", b.Level())
+	assert.Equal(t, []string{"info"}, r.get())
+
+	send := func(v string) {
+		select {
+		case srv.updates <- v:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no subscription received %s", v)
+		}
+	}
+
+	t.Run("level change", func(t *testing.T) {
+		send("debug")
+		assert.Eventually(t, func() bool { return b.Level() == "DEBUG" }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{"info", "DEBUG"}, r.get())
+	})
+
+	t.Run("invalid level is ignored", func(t *testing.T) {
+		send("verbose")
+		send("debug")
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, "DEBUG", b.Level())
+		assert.Equal(t, []string{"info", "DEBUG"}, r.get())
+	})
+
+	t.Run("failed setter is rolled back", func(t *testing.T) {
+		send("error")
+		assert.Eventually(t, func() bool { return len(r.get()) == 4 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{"info", "DEBUG", "error", "DEBUG"}, r.get())
+		assert.Equal(t, "DEBUG", b.Level())
+	})
+
+	t.Run("resubscribe when the stream is lost", func(t *testing.T) {
+		srv.lock.Lock()
+		srv.current = "warn"
+		srv.lock.Unlock()
+		send("!disconnect")
+		// The change made while the stream was lost is read on resubscription.
+		assert.Eventually(t, func() bool { return b.Level() == "warn" }, 5*time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool { return srv.subscriptions.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+		send("info")
+		assert.Eventually(t, func() bool { return b.Level() == "info" }, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -2,11 +2,17 @@ package client
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 const (
@@ -88,3 +94,84 @@ func TestUnSubscribeConfigurationItems(t *testing.T) {
 	assert.Equal(t, uint32(3), atomic.LoadUint32(&counter))
 	assert.Equal(t, uint32(9), atomic.LoadUint32(&totalCounter))
 }
+
+// failingUnsubscribeDaprServer fails to unsubscribe the subscriptions of the
+// "failing" store.
+type failingUnsubscribeDaprServer struct {
+	*testDaprServer
+	stores sync.Map
+}
+
+func (s *failingUnsubscribeDaprServer) SubscribeConfiguration(in *pb.SubscribeConfigurationRequest, server pb.Dapr_SubscribeConfigurationServer) error {
+	return s.testDaprServer.SubscribeConfiguration(in, &storeRecordingStream{Dapr_SubscribeConfigurationServer: server, store: in.StoreName, stores: &s.stores})
+}
+
+func (s *failingUnsubscribeDaprServer) UnsubscribeConfiguration(ctx context.Context, in *pb.UnsubscribeConfigurationRequest) (*pb.UnsubscribeConfigurationResponse, error) {
+	if store, _ := s.stores.Load(in.Id); store == "failing" {
+		return nil, status.Error(codes.Internal, "unsubscribe failed")
+	}
+	return s.testDaprServer.UnsubscribeConfiguration(ctx, in)
+}
+
+// storeRecordingStream records the store of the subscription IDs it sends.
+type storeRecordingStream struct {
+	pb.Dapr_SubscribeConfigurationServer
+	store  string
+	stores *sync.Map
+}
+
+func (s *storeRecordingStream) Send(rsp *pb.SubscribeConfigurationResponse) error {
+	s.stores.Store(rsp.Id, s.store)
+	return s.Dapr_SubscribeConfigurationServer.Send(rsp)
+}
+
+func TestUnsubscribeAllConfiguration(t *testing.T) {
+	ctx := context.Background()
+	c, closer := getTestClientWithServer(ctx, &failingUnsubscribeDaprServer{testDaprServer: &testDaprServer{
+		state:                       make(map[string][]byte),
+		configurationSubscriptionID: map[string]chan struct{}{},
+	}})
+	defer closer()
+
+	var updates uint32
+	handler := func(string, map[string]*ConfigurationItem) {
+		atomic.AddUint32(&updates, 1)
+	}
+	ids := make(map[string]string)
+	for _, store := range []string{"store-1", "store-2", "failing"} {
+		id, err := c.SubscribeConfigurationItems(ctx, store, []string{"key"}, handler)
+		require.NoError(t, err)
+		ids[id] = store
+	}
+	subs := c.(*GRPCClient).configSubs.subs
+	require.Len(t, subs, 3)
+	done := make([]chan struct{}, 0, len(subs))
+	for _, sub := range subs {
+		done = append(done, sub.done)
+	}
+
+	err := c.(ConfigurationUnsubscriber).UnsubscribeAllConfiguration(ctx)
+	var ue *UnsubscribeConfigurationError
+	require.ErrorAs(t, err, &ue)
+	assert.Equal(t, 3, ue.Total)
+	require.Len(t, ue.Errors, 1)
+	for id := range ue.Errors {
+		assert.Equal(t, "failing", ids[id])
+	}
+
+	// The goroutines receiving the updates have ended, including the one of
+	// the subscription that failed to unsubscribe.
+	for _, d := range done {
+		select {
+		case <-d:
+		default:
+			t.Fatal("subscription goroutine still running")
+		}
+	}
+	assert.Empty(t, c.(*GRPCClient).configSubs.subs)
+	n := atomic.LoadUint32(&updates)
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, n, atomic.LoadUint32(&updates))
+
+	assert.NoError(t, c.(ConfigurationUnsubscriber).UnsubscribeAllConfiguration(ctx))
+}
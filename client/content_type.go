@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrMissingContentType is returned by PublishEvent and InvokeBinding, with
+// WithStrictContentType, when the content type of a payload is neither set
+// nor detected.
+var ErrMissingContentType = errors.New("payload without content type")
+
+// WithDefaultContentType sets the content type of the payloads sent by
+// PublishEvent and InvokeBinding without one, such as []byte payloads, when
+// it isn't detected: payloads that are valid JSON are sent as
+// application/json, and the protobuf messages published are serialized with
+// protobuf, instead of JSON, as application/x-protobuf.
+func WithDefaultContentType(contentType string) ClientOption {
+	return func(o *clientOptions) {
+		o.defaultContentType = contentType
+	}
+}
+
+// WithStrictContentType makes PublishEvent and InvokeBinding fail with
+// ErrMissingContentType when the content type of a payload is neither set nor
+// detected, as with WithDefaultContentType, instead of logging a warning.
+func WithStrictContentType() ClientOption {
+	return func(o *clientOptions) {
+		o.strictContentType = true
+	}
+}
+
+// detectsContentType reports whether the content type of the payloads is
+// detected, which it is once WithDefaultContentType or WithStrictContentType
+// is set so that the payloads are sent as before otherwise.
+func (c *GRPCClient) detectsContentType() bool {
+	return c.defaultContentType != "" || c.strictContentType
+}
+
+// resolveContentType sets the content type of data, sent by method to target,
+// when it isn't set. Without WithDefaultContentType and
+// WithStrictContentType, it is left empty and a warning is logged once per
+// method and target.
+func (c *GRPCClient) resolveContentType(method, target string, contentType *string, data []byte) error {
+	if *contentType != "" || len(data) == 0 {
+		return nil
+	}
+	if !c.detectsContentType() {
+		c.warnMissingContentType(method, target)
+		return nil
+	}
+	switch {
+	case json.Valid(data):
+		*contentType = "application/json"
+	case c.defaultContentType != "":
+		*contentType = c.defaultContentType
+	default:
+		return fmt.Errorf("error in %s to %s: %w", method, target, ErrMissingContentType)
+	}
+	return nil
+}
+
+func (c *GRPCClient) warnMissingContentType(method, target string) {
+	if c.contentTypeWarnings != nil {
+		if _, warned := c.contentTypeWarnings.LoadOrStore(method+" "+target, struct{}{}); warned {
+			return
+		}
+	}
+	logger.Printf("warning: %s to %s sends a payload without a content type, which some components reject; set one, or use WithDefaultContentType", method, target)
+}
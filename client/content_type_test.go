@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestPublishEventContentType(t *testing.T) {
+	ctx := context.Background()
+	msg := wrapperspb.String("hello")
+	protoData, err := proto.Marshal(msg)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		opts     []ClientOption
+		data     interface{}
+		pubOpts  []PublishEventOption
+		wantType string
+		wantData []byte
+	}{
+		{name: "bytes left without content type by default", data: []byte("ping")},
+		{name: "JSON bytes left without content type by default", data: []byte(`{"a":1}`), wantData: []byte(`{"a":1}`)},
+		{name: "struct as JSON", data: map[string]int{"a": 1}, wantType: "application/json", wantData: []byte(`{"a":1}`)},
+		{
+			name: "JSON bytes detected", opts: []ClientOption{WithDefaultContentType("text/plain")},
+			data: []byte(`{"a":1}`), wantType: "application/json", wantData: []byte(`{"a":1}`),
+		},
+		{
+			name: "JSON string detected", opts: []ClientOption{WithStrictContentType()},
+			data: `[1,2]`, wantType: "application/json", wantData: []byte(`[1,2]`),
+		},
+		{
+			name: "default for other bytes", opts: []ClientOption{WithDefaultContentType("text/plain")},
+			data: []byte("ping"), wantType: "text/plain",
+		},
+		{
+			name: "explicit content type kept", opts: []ClientOption{WithDefaultContentType("text/plain")},
+			data: []byte(`{"a":1}`), pubOpts: []PublishEventOption{PublishEventWithContentType("application/xml")},
+			wantType: "application/xml", wantData: []byte(`{"a":1}`),
+		},
+		{
+			name: "protobuf message detected", opts: []ClientOption{WithDefaultContentType("text/plain")},
+			data: msg, wantType: contentTypeProtobuf, wantData: protoData,
+		},
+		{name: "protobuf message as JSON by default", data: msg, wantType: "application/json", wantData: []byte(`{"value":"hello"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &publishRecordingDaprServer{}
+			c, closer := getTestClientWithServer(ctx, srv, tt.opts...)
+			defer closer()
+
+			require.NoError(t, c.PublishEvent(ctx, "messages", "test", tt.data, tt.pubOpts...))
+			require.Len(t, srv.contentTypes, 1)
+			assert.Equal(t, tt.wantType, srv.contentTypes[0])
+			if tt.wantData != nil {
+				if tt.wantType == contentTypeProtobuf {
+					assert.Equal(t, tt.wantData, srv.data[0])
+				} else {
+					assert.JSONEq(t, string(tt.wantData), string(srv.data[0]))
+				}
+			}
+		})
+	}
+}
+
+func TestStrictContentType(t *testing.T) {
+	ctx := context.Background()
+	srv := &publishRecordingDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv, WithStrictContentType())
+	defer closer()
+
+	err := c.PublishEvent(ctx, "messages", "test", []byte("ping"))
+	require.ErrorIs(t, err, ErrMissingContentType)
+	assert.Contains(t, err.Error(), "messages/test")
+	assert.Empty(t, srv.contentTypes)
+
+	_, err = c.InvokeBinding(ctx, &InvokeBindingRequest{Name: "test", Operation: "create", Data: []byte("ping")})
+	require.ErrorIs(t, err, ErrMissingContentType)
+
+	require.NoError(t, c.PublishEvent(ctx, "messages", "test", []byte("ping"), PublishEventWithContentType("text/plain")))
+	require.NoError(t, c.PublishEvent(ctx, "messages", "test", nil))
+}
+
+func TestInvokeBindingContentType(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("detected without changing the request metadata", func(t *testing.T) {
+		c, closer := getTestClientWithServer(ctx, &testDaprServer{}, WithDefaultContentType("application/octet-stream"))
+		defer closer()
+
+		meta := map[string]string{"k": "v"}
+		out, err := c.InvokeBinding(ctx, &InvokeBindingRequest{Name: "test", Operation: "create", Data: []byte(`{"a":1}`), Metadata: meta})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"k": "v", metadataKeyContentType: "application/json"}, out.Metadata)
+		assert.Equal(t, map[string]string{"k": "v"}, meta)
+
+		out, err = c.InvokeBinding(ctx, &InvokeBindingRequest{Name: "test", Operation: "create", Data: []byte("ping")})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{metadataKeyContentType: "application/octet-stream"}, out.Metadata)
+	})
+
+	t.Run("unchanged by default", func(t *testing.T) {
+		c, closer := getTestClientWithServer(ctx, &testDaprServer{})
+		defer closer()
+
+		out, err := c.InvokeBinding(ctx, &InvokeBindingRequest{Name: "test", Operation: "create", Data: []byte(`{"a":1}`)})
+		require.NoError(t, err)
+		assert.Empty(t, out.Metadata)
+	})
+}
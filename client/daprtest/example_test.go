@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daprtest_test
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	dapr "github.com/dapr/go-sdk/client"
+	"github.com/dapr/go-sdk/client/daprtest"
+)
+
+// The client has no retry middleware of its own: retries are configured on
+// the gRPC connection passed to NewClientWithConnection.
+const retryServiceConfig = `{
+	"methodConfig": [{
+		"name": [{"service": "dapr.proto.runtime.v1.Dapr"}],
+		"retryPolicy": {
+			"maxAttempts": 3,
+			"initialBackoff": "0.01s",
+			"maxBackoff": "0.1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// This example validates that GetState is retried when the runtime is
+// unavailable.
+func ExampleFaultyServer() {
+	ctx := context.Background()
+	srv, err := daprtest.NewFaultyServer()
+	if err != nil {
+		panic(err)
+	}
+	defer srv.Close()
+	srv.Script("GetState", daprtest.FaultScript{Code: codes.Unavailable, Times: 2})
+
+	conn, err := srv.Dial(ctx, grpc.WithDefaultServiceConfig(retryServiceConfig))
+	if err != nil {
+		panic(err)
+	}
+	client := dapr.NewClientWithConnection(conn)
+	defer client.Close()
+
+	if _, err := client.GetState(ctx, "store", "key", nil); err != nil {
+		panic(err)
+	}
+	for _, req := range srv.Requests("GetState") {
+		fmt.Println(req.Code)
+	}
+	// Output:
+	// Unavailable
+	// Unavailable
+	// OK
+}
@@ -0,0 +1,421 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package daprtest provides a fake Dapr runtime with programmable faults, to
+// test the resilience of code using the Dapr client.
+package daprtest
+
+import (
+	"context"
+	"net"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// dropGrace is the time given to the first response of a stream to reach the
+// client before its connection is dropped.
+const dropGrace = 50 * time.Millisecond
+
+// FaultScript is the behavior of an API of a FaultyServer.
+type FaultScript struct {
+	// Code is the status code returned instead of the response.
+	Code codes.Code
+	// DropConnection closes the connection of the call instead of responding.
+	// Streaming calls are dropped after their first response.
+	DropConnection bool
+	// Times is the number of calls Code and DropConnection apply to, after
+	// which the calls succeed. Zero means all calls.
+	Times int
+	// Delay delays every response.
+	Delay time.Duration
+	// CorruptETags replaces the etags of every response with etags that don't
+	// match the stored ones.
+	CorruptETags bool
+}
+
+// Request is a call received by a FaultyServer.
+type Request struct {
+	// API is the name of the API, such as "GetState".
+	API string
+	// Message is the request, or the first request of a stream.
+	Message proto.Message
+	// Metadata is the metadata of the call.
+	Metadata metadata.MD
+	// Code is the status code of the response.
+	Code codes.Code
+}
+
+// FaultyServer is a fake Dapr runtime serving the state, pub/sub, invocation,
+// binding, metadata and configuration subscription APIs from memory, whose
+// APIs fail as scripted with Script. The other APIs are unimplemented.
+type FaultyServer struct {
+	pb.UnimplementedDaprServer
+
+	listener *trackingListener
+	server   *grpc.Server
+
+	lock     sync.Mutex
+	scripts  map[string]*scriptState
+	requests []Request
+	state    map[string]*stateEntry
+}
+
+type scriptState struct {
+	FaultScript
+	calls int
+}
+
+type stateEntry struct {
+	value   []byte
+	version int
+}
+
+// NewFaultyServer starts a FaultyServer on a local TCP port. Close stops it.
+func NewFaultyServer() (*FaultyServer, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &FaultyServer{
+		listener: &trackingListener{Listener: lis, conns: make(map[string]net.Conn)},
+		scripts:  make(map[string]*scriptState),
+		state:    make(map[string]*stateEntry),
+	}
+	s.server = grpc.NewServer(
+		grpc.UnaryInterceptor(s.unaryInterceptor),
+		grpc.StreamInterceptor(s.streamInterceptor),
+	)
+	pb.RegisterDaprServer(s.server, s)
+	go func() {
+		_ = s.server.Serve(s.listener)
+	}()
+	return s, nil
+}
+
+// Address returns the address of the server, for client.NewClientWithAddress.
+func (s *FaultyServer) Address() string {
+	return s.listener.Addr().String()
+}
+
+// Dial returns a connection to the server, for client.NewClientWithConnection.
+func (s *FaultyServer) Dial(ctx context.Context, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	return grpc.DialContext(ctx, s.Address(), opts...)
+}
+
+// Close stops the server.
+func (s *FaultyServer) Close() {
+	s.server.Stop()
+}
+
+// Script sets the behavior of api, such as "GetState", replacing the previous
+// one.
+func (s *FaultyServer) Script(api string, script FaultScript) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.scripts[api] = &scriptState{FaultScript: script}
+}
+
+// Requests returns the calls received for api, or for all APIs if api is
+// empty, in the order they completed.
+func (s *FaultyServer) Requests(api string) []Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var reqs []Request
+	for _, r := range s.requests {
+		if api == "" || r.API == api {
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs
+}
+
+// fault is the behavior of a call.
+type fault struct {
+	code         codes.Code
+	drop         bool
+	delay        time.Duration
+	corruptETags bool
+}
+
+// nextFault returns the behavior of the next call of api.
+func (s *FaultyServer) nextFault(api string) fault {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	sc, ok := s.scripts[api]
+	if !ok {
+		return fault{}
+	}
+	f := fault{delay: sc.Delay, corruptETags: sc.CorruptETags}
+	sc.calls++
+	if sc.Times == 0 || sc.calls <= sc.Times {
+		f.code = sc.Code
+		f.drop = sc.DropConnection
+	}
+	return f
+}
+
+func (s *FaultyServer) record(ctx context.Context, api string, req any, err error) {
+	msg, _ := req.(proto.Message)
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests = append(s.requests, Request{API: api, Message: msg, Metadata: md, Code: status.Code(err)})
+}
+
+// dropConnection closes the connection of the call of ctx.
+func (s *FaultyServer) dropConnection(ctx context.Context) error {
+	if p, ok := peer.FromContext(ctx); ok {
+		s.listener.close(p.Addr.String())
+	}
+	return status.Error(codes.Unavailable, "connection dropped")
+}
+
+func (s *FaultyServer) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	api := path.Base(info.FullMethod)
+	defer func() { s.record(ctx, api, req, err) }()
+
+	f := s.nextFault(api)
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	if f.drop {
+		return nil, s.dropConnection(ctx)
+	}
+	if f.code != codes.OK {
+		return nil, status.Error(f.code, "injected fault")
+	}
+	resp, err = handler(ctx, req)
+	if err == nil && f.corruptETags {
+		if m, ok := resp.(proto.Message); ok {
+			corruptETags(m.ProtoReflect())
+		}
+	}
+	return resp, err
+}
+
+func (s *FaultyServer) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	api := path.Base(info.FullMethod)
+	stream := &faultyStream{ServerStream: ss, server: s, fault: s.nextFault(api)}
+	defer func() { s.record(ss.Context(), api, stream.first, err) }()
+
+	if stream.fault.delay > 0 {
+		time.Sleep(stream.fault.delay)
+	}
+	if !stream.fault.drop && stream.fault.code != codes.OK {
+		return status.Error(stream.fault.code, "injected fault")
+	}
+	return handler(srv, stream)
+}
+
+// faultyStream applies a fault to a server stream and records its first
+// request.
+type faultyStream struct {
+	grpc.ServerStream
+	server *FaultyServer
+	fault  fault
+	first  any
+	sent   bool
+}
+
+func (s *faultyStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.first == nil {
+		s.first = m
+	}
+	return err
+}
+
+func (s *faultyStream) SendMsg(m any) error {
+	if s.fault.drop && s.sent {
+		return s.server.dropConnection(s.Context())
+	}
+	if msg, ok := m.(proto.Message); ok && s.fault.corruptETags {
+		corruptETags(msg.ProtoReflect())
+	}
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.sent = true
+	if s.fault.drop {
+		time.Sleep(dropGrace)
+		return s.server.dropConnection(s.Context())
+	}
+	return nil
+}
+
+// corruptETags changes the etag fields of m and of its nested messages.
+func corruptETags(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Name() == "etag" && fd.Kind() == protoreflect.StringKind && !fd.IsList():
+			m.Set(fd, protoreflect.ValueOfString(v.String()+"-corrupted"))
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			for i := 0; i < v.List().Len(); i++ {
+				corruptETags(v.List().Get(i).Message())
+			}
+		case fd.Kind() == protoreflect.MessageKind && !fd.IsMap():
+			corruptETags(v.Message())
+		}
+		return true
+	})
+}
+
+// trackingListener tracks the accepted connections by remote address, so
+// that they can be dropped.
+type trackingListener struct {
+	net.Listener
+
+	lock  sync.Mutex
+	conns map[string]net.Conn
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.lock.Lock()
+	l.conns[conn.RemoteAddr().String()] = conn
+	l.lock.Unlock()
+	return conn, nil
+}
+
+func (l *trackingListener) close(addr string) {
+	l.lock.Lock()
+	conn, ok := l.conns[addr]
+	delete(l.conns, addr)
+	l.lock.Unlock()
+	if ok {
+		conn.Close()
+	}
+}
+
+func stateKey(store, key string) string {
+	return store + "||" + key
+}
+
+// GetState returns the stored value of the key.
+func (s *FaultyServer) GetState(ctx context.Context, in *pb.GetStateRequest) (*pb.GetStateResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e, ok := s.state[stateKey(in.StoreName, in.Key)]
+	if !ok {
+		return &pb.GetStateResponse{}, nil
+	}
+	return &pb.GetStateResponse{Data: e.value, Etag: strconv.Itoa(e.version)}, nil
+}
+
+// GetBulkState returns the stored values of the keys.
+func (s *FaultyServer) GetBulkState(ctx context.Context, in *pb.GetBulkStateRequest) (*pb.GetBulkStateResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	resp := &pb.GetBulkStateResponse{}
+	for _, key := range in.Keys {
+		item := &pb.BulkStateItem{Key: key}
+		if e, ok := s.state[stateKey(in.StoreName, key)]; ok {
+			item.Data = e.value
+			item.Etag = strconv.Itoa(e.version)
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	return resp, nil
+}
+
+// SaveState stores the values of the items, rejecting the items whose etag
+// doesn't match the stored one with codes.Aborted.
+func (s *FaultyServer) SaveState(ctx context.Context, in *pb.SaveStateRequest) (*emptypb.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, item := range in.States {
+		k := stateKey(in.StoreName, item.Key)
+		e, ok := s.state[k]
+		if item.GetEtag() != nil && (!ok || item.GetEtag().GetValue() != strconv.Itoa(e.version)) {
+			return nil, status.Errorf(codes.Aborted, "etag mismatch for key %s", item.Key)
+		}
+		if !ok {
+			e = &stateEntry{}
+			s.state[k] = e
+		}
+		e.value = item.Value
+		e.version++
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteState deletes the stored value of the key, rejecting the request
+// with codes.Aborted if its etag doesn't match the stored one.
+func (s *FaultyServer) DeleteState(ctx context.Context, in *pb.DeleteStateRequest) (*emptypb.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	k := stateKey(in.StoreName, in.Key)
+	e, ok := s.state[k]
+	if in.GetEtag() != nil && (!ok || in.GetEtag().GetValue() != strconv.Itoa(e.version)) {
+		return nil, status.Errorf(codes.Aborted, "etag mismatch for key %s", in.Key)
+	}
+	delete(s.state, k)
+	return &emptypb.Empty{}, nil
+}
+
+// PublishEvent accepts the event.
+func (s *FaultyServer) PublishEvent(ctx context.Context, in *pb.PublishEventRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+// InvokeService responds with the data of the request.
+func (s *FaultyServer) InvokeService(ctx context.Context, in *pb.InvokeServiceRequest) (*commonv1pb.InvokeResponse, error) {
+	return &commonv1pb.InvokeResponse{
+		Data:        in.GetMessage().GetData(),
+		ContentType: in.GetMessage().GetContentType(),
+	}, nil
+}
+
+// InvokeBinding responds with the data and metadata of the request.
+func (s *FaultyServer) InvokeBinding(ctx context.Context, in *pb.InvokeBindingRequest) (*pb.InvokeBindingResponse, error) {
+	return &pb.InvokeBindingResponse{Data: in.Data, Metadata: in.Metadata}, nil
+}
+
+// GetMetadata returns the metadata of a runtime without components.
+func (s *FaultyServer) GetMetadata(ctx context.Context, in *emptypb.Empty) (*pb.GetMetadataResponse, error) {
+	return &pb.GetMetadataResponse{Id: "daprtest"}, nil
+}
+
+// SubscribeConfiguration sends a response with the ID of the subscription,
+// then keeps the stream open until the call ends.
+func (s *FaultyServer) SubscribeConfiguration(in *pb.SubscribeConfigurationRequest, stream pb.Dapr_SubscribeConfigurationServer) error {
+	if err := stream.Send(&pb.SubscribeConfigurationResponse{Id: "daprtest-" + in.StoreName}); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+// Shutdown does nothing.
+func (s *FaultyServer) Shutdown(ctx context.Context, in *emptypb.Empty) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
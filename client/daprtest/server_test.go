@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daprtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	dapr "github.com/dapr/go-sdk/client"
+)
+
+func newTestClient(t *testing.T) (*FaultyServer, dapr.Client) {
+	t.Helper()
+	srv, err := NewFaultyServer()
+	require.NoError(t, err)
+	t.Cleanup(srv.Close)
+
+	conn, err := srv.Dial(context.Background())
+	require.NoError(t, err)
+	c := dapr.NewClientWithConnection(conn)
+	t.Cleanup(c.Close)
+	return srv, c
+}
+
+func TestFaultyServerState(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestClient(t)
+
+	require.NoError(t, c.SaveState(ctx, "store", "key", []byte("v1"), nil))
+	item, err := c.GetState(ctx, "store", "key", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), item.Value)
+	assert.Equal(t, "1", item.Etag)
+
+	err = c.SaveStateWithETag(ctx, "store", "key", []byte("v2"), "0", nil)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	require.NoError(t, c.SaveStateWithETag(ctx, "store", "key", []byte("v2"), item.Etag, nil))
+
+	require.NoError(t, c.DeleteState(ctx, "store", "key", nil))
+	item, err = c.GetState(ctx, "store", "key", nil)
+	require.NoError(t, err)
+	assert.Empty(t, item.Value)
+
+	reqs := srv.Requests("SaveState")
+	require.Len(t, reqs, 3)
+	assert.Equal(t, codes.OK, reqs[0].Code)
+	assert.Equal(t, codes.Aborted, reqs[1].Code)
+	assert.Equal(t, "store", reqs[2].Message.(*pb.SaveStateRequest).StoreName)
+	assert.Len(t, srv.Requests(""), 6)
+}
+
+func TestFaultyServerCode(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestClient(t)
+
+	srv.Script("PublishEvent", FaultScript{Code: codes.ResourceExhausted, Times: 2})
+	for i := 0; i < 2; i++ {
+		err := c.PublishEvent(ctx, "pubsub", "topic", []byte("{}"))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	}
+	require.NoError(t, c.PublishEvent(ctx, "pubsub", "topic", []byte("{}")))
+
+	srv.Script("PublishEvent", FaultScript{Code: codes.Internal})
+	for i := 0; i < 3; i++ {
+		err := c.PublishEvent(ctx, "pubsub", "topic", []byte("{}"))
+		assert.Equal(t, codes.Internal, status.Code(err))
+	}
+	assert.Len(t, srv.Requests("PublishEvent"), 6)
+}
+
+func TestFaultyServerDelay(t *testing.T) {
+	srv, c := newTestClient(t)
+	srv.Script("InvokeBinding", FaultScript{Delay: 200 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.InvokeBinding(ctx, &dapr.InvokeBindingRequest{Name: "binding", Operation: "create"})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	start := time.Now()
+	out, err := c.InvokeBinding(context.Background(), &dapr.InvokeBindingRequest{Name: "binding", Operation: "create", Data: []byte("data")})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), out.Data)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestFaultyServerDropConnection(t *testing.T) {
+	t.Run("unary", func(t *testing.T) {
+		ctx := context.Background()
+		srv, c := newTestClient(t)
+		srv.Script("GetMetadata", FaultScript{DropConnection: true, Times: 1})
+
+		_, err := c.GetMetadata(ctx)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		md, err := c.GetMetadata(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "daprtest", md.ID)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		ctx := context.Background()
+		srv, c := newTestClient(t)
+		srv.Script("SubscribeConfiguration", FaultScript{DropConnection: true})
+
+		conn, err := srv.Dial(ctx)
+		require.NoError(t, err)
+		defer conn.Close()
+		stream, err := pb.NewDaprClient(conn).SubscribeConfiguration(ctx, &pb.SubscribeConfigurationRequest{StoreName: "store"})
+		require.NoError(t, err)
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, "daprtest-store", resp.Id)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+
+		// The client of the other connection isn't affected.
+		_, err = c.GetMetadata(ctx)
+		require.NoError(t, err)
+	})
+}
+
+func TestFaultyServerCorruptETags(t *testing.T) {
+	ctx := context.Background()
+	srv, c := newTestClient(t)
+	require.NoError(t, c.SaveState(ctx, "store", "key", []byte("v1"), nil))
+	srv.Script("GetState", FaultScript{CorruptETags: true})
+	srv.Script("GetBulkState", FaultScript{CorruptETags: true})
+
+	item, err := c.GetState(ctx, "store", "key", nil)
+	require.NoError(t, err)
+	err = c.SaveStateWithETag(ctx, "store", "key", []byte("v2"), item.Etag, nil)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	items, err := c.GetBulkState(ctx, "store", []string{"key"}, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.NotEqual(t, "1", items[0].Etag)
+}
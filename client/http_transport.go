@@ -0,0 +1,619 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	anypb "github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// ErrNotSupportedByTransport is returned by the methods of a client created
+// with NewHTTPClient whose runtime API is not implemented over HTTP, such as
+// the streaming ones. The error also has the Unimplemented gRPC status code.
+var ErrNotSupportedByTransport = errors.New("not supported by the transport")
+
+// NewHTTPClient instantiates a Dapr client calling the Dapr HTTP API at
+// baseURL, such as http://localhost:3500, for environments where the gRPC
+// port of the sidecar is not reachable.
+//
+// The client implements the state, pubsub publishing, service invocation,
+// secrets and metadata APIs. The other methods fail with an error that is
+// ErrNotSupportedByTransport, and GrpcClientConn returns nil. The options
+// work as with a gRPC client: the calls go through the same validations,
+// metrics, timeouts and propagation of metadata, which is sent as HTTP
+// headers.
+func NewHTTPClient(baseURL string, opts ...ClientOption) (Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Dapr HTTP address %q: %w", baseURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Dapr HTTP address %q: must be an http or https URL", baseURL)
+	}
+	transport := &httpTransport{baseURL: strings.TrimSuffix(u.String(), "/"), client: &http.Client{}}
+	c := newClientWithTransport(transport, newClientOptions(opts...))
+	c.httpTransport = transport
+	return c, nil
+}
+
+// httpTransport is a grpc.ClientConnInterface making the calls of the runtime
+// API over the Dapr HTTP API.
+type httpTransport struct {
+	baseURL string
+	client  *http.Client
+}
+
+// httpCall makes a call over HTTP, returning its response and the headers to
+// return with grpc.Header.
+type httpCall func(ctx context.Context, t *httpTransport, req proto.Message) (proto.Message, http.Header, error)
+
+// httpCalls are the calls of the runtime API implemented over HTTP, by method
+// name.
+var httpCalls = map[string]httpCall{
+	"GetState":                httpGetState,
+	"GetBulkState":            httpGetBulkState,
+	"SaveState":               httpSaveState,
+	"DeleteState":             httpDeleteState,
+	"DeleteBulkState":         httpDeleteBulkState,
+	"ExecuteStateTransaction": httpExecuteStateTransaction,
+	"PublishEvent":            httpPublishEvent,
+	"BulkPublishEventAlpha1":  httpBulkPublishEvent,
+	"InvokeService":           httpInvokeService,
+	"GetSecret":               httpGetSecret,
+	"GetBulkSecret":           httpGetBulkSecret,
+	"GetMetadata":             httpGetMetadata,
+	"SetMetadata":             httpSetMetadata,
+}
+
+// unsupportedCallError is the error of a call not implemented by the HTTP
+// transport.
+type unsupportedCallError struct {
+	method string
+}
+
+func (e *unsupportedCallError) Error() string {
+	return e.method + " is " + ErrNotSupportedByTransport.Error() + " of the Dapr HTTP client"
+}
+
+func (e *unsupportedCallError) GRPCStatus() *status.Status {
+	return status.New(codes.Unimplemented, e.Error())
+}
+
+func (e *unsupportedCallError) Unwrap() error {
+	return ErrNotSupportedByTransport
+}
+
+func (t *httpTransport) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	call, ok := httpCalls[path.Base(method)]
+	req, isMessage := args.(proto.Message)
+	if !ok || !isMessage {
+		return &unsupportedCallError{method: path.Base(method)}
+	}
+	resp, header, err := call(ctx, t, req)
+	for _, opt := range opts {
+		if h, ok := opt.(grpc.HeaderCallOption); ok && h.HeaderAddr != nil {
+			*h.HeaderAddr = httpHeaderMetadata(header)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if m, ok := reply.(proto.Message); ok && resp != nil {
+		proto.Merge(m, resp)
+	}
+	return nil
+}
+
+func (t *httpTransport) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, &unsupportedCallError{method: path.Base(method)}
+}
+
+// wait waits for the sidecar to report its outbound APIs as healthy.
+func (t *httpTransport) wait(ctx context.Context) error {
+	for {
+		_, _, err := t.do(ctx, http.MethodGet, "/v1.0/healthz/outbound", nil, nil, nil)
+		if err == nil {
+			return nil
+		}
+		if err := waitRetry(ctx, 100*time.Millisecond); err != nil {
+			return errWaitTimedOut
+		}
+	}
+}
+
+// do makes a request to the Dapr HTTP API, with the outgoing metadata of ctx
+// as headers, returning the body and headers of the response. A response with
+// an error status is returned as a gRPC status error.
+func (t *httpTransport) do(ctx context.Context, verb, p string, query url.Values, header http.Header, body []byte) ([]byte, http.Header, error) {
+	u := t.baseURL + p
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, verb, u, r)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for k, vs := range md {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("User-Agent", userAgent())
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, status.FromContextError(ctxErr).Err()
+		}
+		return nil, nil, status.Error(codes.Unavailable, err.Error())
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.Header, status.Error(codes.Unavailable, err.Error())
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return data, resp.Header, httpStatusError(resp.StatusCode, data)
+	}
+	return data, resp.Header, nil
+}
+
+func (t *httpTransport) doJSON(ctx context.Context, verb, p string, query url.Values, in, out any) (http.Header, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	data, header, err := t.do(ctx, verb, p, query, http.Header{"Content-Type": {"application/json"}}, body)
+	if err != nil || out == nil || len(data) == 0 {
+		return header, err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return header, status.Errorf(codes.Internal, "error decoding the response: %v", err)
+	}
+	return header, nil
+}
+
+// httpStatusError returns the gRPC status error matching an error response of
+// the Dapr HTTP API, whose body is {"errorCode": "...", "message": "..."}.
+func httpStatusError(code int, body []byte) error {
+	var e struct {
+		ErrorCode string `json:"errorCode"`
+		Message   string `json:"message"`
+	}
+	msg := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &e) == nil && (e.ErrorCode != "" || e.Message != "") {
+		msg = strings.TrimSpace(e.ErrorCode + " " + e.Message)
+	}
+	if msg == "" {
+		msg = http.StatusText(code)
+	}
+	return status.Error(httpStatusCode(code), msg)
+}
+
+// httpStatusCode returns the gRPC status code of an HTTP status code. An ETag
+// mismatch, which is a 409 Conflict, is Aborted as over gRPC.
+func httpStatusCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+// httpHeaderMetadata returns the headers of a response as gRPC metadata.
+func httpHeaderMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for k, vs := range header {
+		md.Append(k, vs...)
+	}
+	return md
+}
+
+// metadataQuery returns the metadata of a request as the metadata.<key>
+// query parameters of the Dapr HTTP API.
+func metadataQuery(md map[string]string) url.Values {
+	q := url.Values{}
+	for k, v := range md {
+		q.Set("metadata."+k, v)
+	}
+	return q
+}
+
+func escapePath(segments ...string) string {
+	var sb strings.Builder
+	for _, s := range segments {
+		sb.WriteByte('/')
+		sb.WriteString(url.PathEscape(s))
+	}
+	return sb.String()
+}
+
+// httpStateValue returns a state value as the JSON value of the Dapr HTTP
+// API: JSON data is sent as is, and other data as a JSON string.
+func httpStateValue(data []byte) json.RawMessage {
+	if len(data) > 0 && json.Valid(data) {
+		return data
+	}
+	v, _ := json.Marshal(string(data))
+	return v
+}
+
+type httpStateOptions struct {
+	Concurrency string `json:"concurrency,omitempty"`
+	Consistency string `json:"consistency,omitempty"`
+}
+
+type httpStateItem struct {
+	Key      string            `json:"key"`
+	Value    json.RawMessage   `json:"value,omitempty"`
+	ETag     *string           `json:"etag,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Options  *httpStateOptions `json:"options,omitempty"`
+}
+
+func newHTTPStateItem(item *commonv1pb.StateItem, withValue bool) httpStateItem {
+	s := httpStateItem{Key: item.GetKey(), Metadata: item.GetMetadata()}
+	if withValue {
+		s.Value = httpStateValue(item.GetValue())
+	}
+	if item.GetEtag() != nil {
+		etag := item.GetEtag().GetValue()
+		s.ETag = &etag
+	}
+	if o := item.GetOptions(); o != nil {
+		opts := httpStateOptions{Concurrency: httpConcurrency(o.GetConcurrency()), Consistency: httpConsistency(o.GetConsistency())}
+		if opts != (httpStateOptions{}) {
+			s.Options = &opts
+		}
+	}
+	return s
+}
+
+func httpConcurrency(c commonv1pb.StateOptions_StateConcurrency) string {
+	switch c {
+	case commonv1pb.StateOptions_CONCURRENCY_FIRST_WRITE:
+		return "first-write"
+	case commonv1pb.StateOptions_CONCURRENCY_LAST_WRITE:
+		return "last-write"
+	}
+	return ""
+}
+
+func httpConsistency(c commonv1pb.StateOptions_StateConsistency) string {
+	switch c {
+	case commonv1pb.StateOptions_CONSISTENCY_EVENTUAL:
+		return "eventual"
+	case commonv1pb.StateOptions_CONSISTENCY_STRONG:
+		return "strong"
+	}
+	return ""
+}
+
+func httpGetState(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.GetStateRequest)
+	q := metadataQuery(req.GetMetadata())
+	if c := httpConsistency(req.GetConsistency()); c != "" {
+		q.Set("consistency", c)
+	}
+	data, header, err := t.do(ctx, http.MethodGet, "/v1.0/state"+escapePath(req.GetStoreName(), req.GetKey()), q, nil, nil)
+	if err != nil {
+		return nil, header, err
+	}
+	return &pb.GetStateResponse{Data: data, Etag: header.Get("ETag")}, header, nil
+}
+
+func httpGetBulkState(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.GetBulkStateRequest)
+	in := struct {
+		Keys        []string `json:"keys"`
+		Parallelism int32    `json:"parallelism,omitempty"`
+	}{Keys: req.GetKeys(), Parallelism: req.GetParallelism()}
+	var out []struct {
+		Key      string            `json:"key"`
+		Data     json.RawMessage   `json:"data"`
+		ETag     string            `json:"etag"`
+		Metadata map[string]string `json:"metadata"`
+		Error    string            `json:"error"`
+	}
+	header, err := t.doJSON(ctx, http.MethodPost, "/v1.0/state"+escapePath(req.GetStoreName(), "bulk"), metadataQuery(req.GetMetadata()), in, &out)
+	if err != nil {
+		return nil, header, err
+	}
+	resp := &pb.GetBulkStateResponse{Items: make([]*pb.BulkStateItem, len(out))}
+	for i, item := range out {
+		resp.Items[i] = &pb.BulkStateItem{Key: item.Key, Data: item.Data, Etag: item.ETag, Metadata: item.Metadata, Error: item.Error}
+	}
+	return resp, header, nil
+}
+
+func httpSaveState(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.SaveStateRequest)
+	items := make([]httpStateItem, len(req.GetStates()))
+	for i, item := range req.GetStates() {
+		items[i] = newHTTPStateItem(item, true)
+	}
+	header, err := t.doJSON(ctx, http.MethodPost, "/v1.0/state"+escapePath(req.GetStoreName()), nil, items, nil)
+	return nil, header, err
+}
+
+func httpDeleteState(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.DeleteStateRequest)
+	return nil, nil, httpDelete(ctx, t, req.GetStoreName(), &commonv1pb.StateItem{
+		Key: req.GetKey(), Etag: req.GetEtag(), Metadata: req.GetMetadata(), Options: req.GetOptions(),
+	})
+}
+
+// httpDeleteBulkState deletes the items one by one, the Dapr HTTP API having no
+// bulk delete.
+func httpDeleteBulkState(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.DeleteBulkStateRequest)
+	for _, item := range req.GetStates() {
+		if err := httpDelete(ctx, t, req.GetStoreName(), item); err != nil {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, nil
+}
+
+func httpDelete(ctx context.Context, t *httpTransport, store string, item *commonv1pb.StateItem) error {
+	q := metadataQuery(item.GetMetadata())
+	if o := item.GetOptions(); o != nil {
+		if c := httpConcurrency(o.GetConcurrency()); c != "" {
+			q.Set("concurrency", c)
+		}
+		if c := httpConsistency(o.GetConsistency()); c != "" {
+			q.Set("consistency", c)
+		}
+	}
+	var header http.Header
+	if item.GetEtag() != nil {
+		header = http.Header{"If-Match": {item.GetEtag().GetValue()}}
+	}
+	_, _, err := t.do(ctx, http.MethodDelete, "/v1.0/state"+escapePath(store, item.GetKey()), q, header, nil)
+	return err
+}
+
+func httpExecuteStateTransaction(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.ExecuteStateTransactionRequest)
+	type operation struct {
+		Operation string        `json:"operation"`
+		Request   httpStateItem `json:"request"`
+	}
+	in := struct {
+		Operations []operation       `json:"operations"`
+		Metadata   map[string]string `json:"metadata,omitempty"`
+	}{Operations: make([]operation, len(req.GetOperations())), Metadata: req.GetMetadata()}
+	for i, op := range req.GetOperations() {
+		in.Operations[i] = operation{
+			Operation: op.GetOperationType(),
+			Request:   newHTTPStateItem(op.GetRequest(), op.GetOperationType() == StateOperationTypeUpsert.String()),
+		}
+	}
+	header, err := t.doJSON(ctx, http.MethodPost, "/v1.0/state"+escapePath(req.GetStoreName(), "transaction"), nil, in, nil)
+	return nil, header, err
+}
+
+func httpPublishEvent(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.PublishEventRequest)
+	var header http.Header
+	if req.GetDataContentType() != "" {
+		header = http.Header{"Content-Type": {req.GetDataContentType()}}
+	}
+	_, respHeader, err := t.do(ctx, http.MethodPost, "/v1.0/publish"+escapePath(req.GetPubsubName(), req.GetTopic()), metadataQuery(req.GetMetadata()), header, req.GetData())
+	return nil, respHeader, err
+}
+
+func httpBulkPublishEvent(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.BulkPublishRequest)
+	type entry struct {
+		EntryID     string            `json:"entryId"`
+		Event       json.RawMessage   `json:"event"`
+		ContentType string            `json:"contentType,omitempty"`
+		Metadata    map[string]string `json:"metadata,omitempty"`
+	}
+	entries := make([]entry, len(req.GetEntries()))
+	for i, e := range req.GetEntries() {
+		entries[i] = entry{EntryID: e.GetEntryId(), Event: httpStateValue(e.GetEvent()), ContentType: e.GetContentType(), Metadata: e.GetMetadata()}
+	}
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	data, header, err := t.do(ctx, http.MethodPost, "/v1.0-alpha1/publish/bulk"+escapePath(req.GetPubsubName(), req.GetTopic()),
+		metadataQuery(req.GetMetadata()), http.Header{"Content-Type": {"application/json"}}, body)
+	// The entries that failed are listed in the body of an error response.
+	var out struct {
+		FailedEntries []struct {
+			EntryID string `json:"entryId"`
+			Error   string `json:"error"`
+		} `json:"failedEntries"`
+	}
+	if len(data) > 0 && json.Unmarshal(data, &out) != nil && err == nil {
+		return nil, header, status.Error(codes.Internal, "error decoding the bulk publish response")
+	}
+	if err != nil && len(out.FailedEntries) == 0 {
+		return nil, header, err
+	}
+	resp := &pb.BulkPublishResponse{FailedEntries: make([]*pb.BulkPublishResponseFailedEntry, len(out.FailedEntries))}
+	for i, e := range out.FailedEntries {
+		resp.FailedEntries[i] = &pb.BulkPublishResponseFailedEntry{EntryId: e.EntryID, Error: e.Error}
+	}
+	return resp, header, nil
+}
+
+func httpInvokeService(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.InvokeServiceRequest)
+	msg := req.GetMessage()
+	verb := http.MethodPost
+	if v := msg.GetHttpExtension().GetVerb(); v != commonv1pb.HTTPExtension_NONE {
+		verb = v.String()
+	}
+	q, err := url.ParseQuery(msg.GetHttpExtension().GetQuerystring())
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid query string: %v", err)
+	}
+	var header http.Header
+	if msg.GetContentType() != "" {
+		header = http.Header{"Content-Type": {msg.GetContentType()}}
+	}
+	var body []byte
+	if verb != http.MethodGet && verb != http.MethodHead {
+		body = msg.GetData().GetValue()
+	}
+	p := "/v1.0/invoke" + escapePath(req.GetId()) + "/method/" + strings.TrimPrefix(msg.GetMethod(), "/")
+	data, respHeader, err := t.do(ctx, verb, p, q, header, body)
+	if err != nil {
+		return nil, respHeader, err
+	}
+	return &commonv1pb.InvokeResponse{Data: &anypb.Any{Value: data}, ContentType: respHeader.Get("Content-Type")}, respHeader, nil
+}
+
+func httpGetSecret(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.GetSecretRequest)
+	var out map[string]string
+	header, err := t.doJSON(ctx, http.MethodGet, "/v1.0/secrets"+escapePath(req.GetStoreName(), req.GetKey()), metadataQuery(req.GetMetadata()), nil, &out)
+	if err != nil {
+		return nil, header, err
+	}
+	return &pb.GetSecretResponse{Data: out}, header, nil
+}
+
+func httpGetBulkSecret(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.GetBulkSecretRequest)
+	var out map[string]map[string]string
+	header, err := t.doJSON(ctx, http.MethodGet, "/v1.0/secrets"+escapePath(req.GetStoreName(), "bulk"), metadataQuery(req.GetMetadata()), nil, &out)
+	if err != nil {
+		return nil, header, err
+	}
+	resp := &pb.GetBulkSecretResponse{Data: make(map[string]*pb.SecretResponse, len(out))}
+	for k, secrets := range out {
+		resp.Data[k] = &pb.SecretResponse{Secrets: secrets}
+	}
+	return resp, header, nil
+}
+
+func httpGetMetadata(ctx context.Context, t *httpTransport, _ proto.Message) (proto.Message, http.Header, error) {
+	var out struct {
+		ID                string            `json:"id"`
+		RuntimeVersion    string            `json:"runtimeVersion"`
+		EnabledFeatures   []string          `json:"enabledFeatures"`
+		Extended          map[string]string `json:"extended"`
+		ActiveActorsCount []struct {
+			Type  string `json:"type"`
+			Count int32  `json:"count"`
+		} `json:"actors"`
+		RegisteredComponents []struct {
+			Name         string   `json:"name"`
+			Type         string   `json:"type"`
+			Version      string   `json:"version"`
+			Capabilities []string `json:"capabilities"`
+		} `json:"components"`
+		Subscriptions []struct {
+			PubsubName      string            `json:"pubsubname"`
+			Topic           string            `json:"topic"`
+			DeadLetterTopic string            `json:"deadLetterTopic"`
+			Metadata        map[string]string `json:"metadata"`
+			Rules           []struct {
+				Match string `json:"match"`
+				Path  string `json:"path"`
+			} `json:"rules"`
+		} `json:"subscriptions"`
+		HTTPEndpoints []struct {
+			Name string `json:"name"`
+		} `json:"httpEndpoints"`
+	}
+	header, err := t.doJSON(ctx, http.MethodGet, "/v1.0/metadata", nil, nil, &out)
+	if err != nil {
+		return nil, header, err
+	}
+	resp := &pb.GetMetadataResponse{
+		Id:               out.ID,
+		RuntimeVersion:   out.RuntimeVersion,
+		EnabledFeatures:  out.EnabledFeatures,
+		ExtendedMetadata: out.Extended,
+	}
+	for _, a := range out.ActiveActorsCount {
+		resp.ActiveActorsCount = append(resp.ActiveActorsCount, &pb.ActiveActorsCount{Type: a.Type, Count: a.Count})
+	}
+	for _, c := range out.RegisteredComponents {
+		resp.RegisteredComponents = append(resp.RegisteredComponents, &pb.RegisteredComponents{Name: c.Name, Type: c.Type, Version: c.Version, Capabilities: c.Capabilities})
+	}
+	for _, s := range out.Subscriptions {
+		sub := &pb.PubsubSubscription{PubsubName: s.PubsubName, Topic: s.Topic, DeadLetterTopic: s.DeadLetterTopic, Metadata: s.Metadata}
+		if len(s.Rules) > 0 {
+			sub.Rules = &pb.PubsubSubscriptionRules{}
+			for _, r := range s.Rules {
+				sub.Rules.Rules = append(sub.Rules.Rules, &pb.PubsubSubscriptionRule{Match: r.Match, Path: r.Path})
+			}
+		}
+		resp.Subscriptions = append(resp.Subscriptions, sub)
+	}
+	for _, e := range out.HTTPEndpoints {
+		resp.HttpEndpoints = append(resp.HttpEndpoints, &pb.MetadataHTTPEndpoint{Name: e.Name})
+	}
+	return resp, header, nil
+}
+
+func httpSetMetadata(ctx context.Context, t *httpTransport, m proto.Message) (proto.Message, http.Header, error) {
+	req := m.(*pb.SetMetadataRequest)
+	_, header, err := t.do(ctx, http.MethodPut, "/v1.0/metadata"+escapePath(req.GetKey()), nil, http.Header{"Content-Type": {"text/plain"}}, []byte(req.GetValue()))
+	return nil, header, err
+}
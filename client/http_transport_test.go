@@ -0,0 +1,300 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/go-sdk/ids"
+)
+
+// httpFixture is a request to the Dapr HTTP API and its response, recorded in
+// testdata/http. The bodies are JSON values, compared as such, or, if text is
+// set, raw text.
+type httpFixture struct {
+	Request struct {
+		Method  string            `json:"method"`
+		Path    string            `json:"path"`
+		Query   string            `json:"query"`
+		Headers map[string]string `json:"headers"`
+		Body    json.RawMessage   `json:"body"`
+		Text    *string           `json:"text"`
+	} `json:"request"`
+	Response struct {
+		Status  int               `json:"status"`
+		Headers map[string]string `json:"headers"`
+		Body    json.RawMessage   `json:"body"`
+		Text    *string           `json:"text"`
+	} `json:"response"`
+}
+
+// matches reports whether r, whose body is body, is the request of the
+// fixture.
+func (f *httpFixture) matches(r *http.Request, body []byte) bool {
+	if r.Method != f.Request.Method || r.URL.Path != f.Request.Path || !sameQuery(r.URL.RawQuery, f.Request.Query) {
+		return false
+	}
+	for k, v := range f.Request.Headers {
+		if r.Header.Get(k) != v {
+			return false
+		}
+	}
+	switch {
+	case f.Request.Text != nil:
+		return string(body) == *f.Request.Text
+	case f.Request.Body != nil:
+		return sameJSON(body, f.Request.Body)
+	}
+	return len(body) == 0
+}
+
+func sameQuery(a, b string) bool {
+	qa, errA := url.ParseQuery(a)
+	qb, errB := url.ParseQuery(b)
+	return errA == nil && errB == nil && reflect.DeepEqual(qa, qb)
+}
+
+func sameJSON(a, b []byte) bool {
+	var va, vb any
+	return json.Unmarshal(a, &va) == nil && json.Unmarshal(b, &vb) == nil && reflect.DeepEqual(va, vb)
+}
+
+// newFixtureServer returns a fake Dapr HTTP API replaying the fixtures of
+// testdata/http. The requests matching no fixture fail the test.
+func newFixtureServer(t *testing.T) (*httptest.Server, func() []string) {
+	files, err := filepath.Glob(filepath.Join("testdata", "http", "*.json"))
+	require.NoError(t, err)
+	var fixtures []*httpFixture
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		var ff []*httpFixture
+		require.NoError(t, json.Unmarshal(data, &ff), file)
+		fixtures = append(fixtures, ff...)
+	}
+
+	var (
+		lock       sync.Mutex
+		unexpected []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		for _, f := range fixtures {
+			if !f.matches(r, body) {
+				continue
+			}
+			for k, v := range f.Response.Headers {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(f.Response.Status)
+			if f.Response.Text != nil {
+				io.WriteString(w, *f.Response.Text)
+			} else if f.Response.Body != nil {
+				w.Write(f.Response.Body)
+			}
+			return
+		}
+		lock.Lock()
+		unexpected = append(unexpected, r.Method+" "+r.URL.String()+" "+string(body))
+		lock.Unlock()
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	return srv, func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return unexpected
+	}
+}
+
+func newFixtureClient(t *testing.T, opts ...ClientOption) Client {
+	srv, unexpected := newFixtureServer(t)
+	t.Cleanup(func() {
+		srv.Close()
+		assert.Empty(t, unexpected(), "requests matching no fixture")
+	})
+	c, err := NewHTTPClient(srv.URL+"/", opts...)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	for _, addr := range []string{"localhost:3500", "grpc://localhost:3500", "http://", "%"} {
+		_, err := NewHTTPClient(addr)
+		assert.Error(t, err, addr)
+	}
+	c, err := NewHTTPClient("https://localhost:3500")
+	require.NoError(t, err)
+	assert.Nil(t, c.(RawClient).GrpcClientConn())
+}
+
+func TestHTTPClientState(t *testing.T) {
+	ctx := context.Background()
+	c := newFixtureClient(t)
+
+	c.WithAuthToken("token")
+	item, err := c.GetStateWithConsistency(ctx, "store", "order-1", nil, StateConsistencyStrong)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": 1, "status": "paid"}`, string(item.Value))
+	assert.Equal(t, "7", item.Etag)
+	c.WithAuthToken("")
+
+	item, err = c.GetState(ctx, "store", "missing", map[string]string{"partitionKey": "p1"})
+	require.NoError(t, err)
+	assert.Empty(t, item.Value)
+
+	opts := []StateOption{WithConcurrency(StateConcurrencyFirstWrite), WithConsistency(StateConsistencyStrong)}
+	require.NoError(t, c.SaveStateWithETag(ctx, "store", "order-1", []byte(`{"id":1,"status":"paid"}`), "7", nil, opts...))
+	require.NoError(t, c.SaveState(ctx, "store", "note", []byte("plain text"), map[string]string{"ttlInSeconds": "60"}))
+
+	err = c.SaveStateWithETag(ctx, "store", "order-1", []byte(`{"id":1}`), "6", nil, opts...)
+	require.Error(t, err)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	assert.True(t, isETagMismatch(err))
+
+	items, err := c.GetBulkState(ctx, "store", []string{"order-1", "order-2"}, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.JSONEq(t, `{"id": 1, "status": "paid"}`, string(items[0].Value))
+	assert.Equal(t, "7", items[0].Etag)
+	assert.Equal(t, "item not found", items[1].Error)
+
+	require.NoError(t, c.DeleteStateWithETag(ctx, "store", "order-1", &ETag{Value: "7"}, nil,
+		&StateOptions{Concurrency: StateConcurrencyFirstWrite, Consistency: StateConsistencyStrong}))
+	require.NoError(t, c.DeleteBulkState(ctx, "store", []string{"order-2", "order-3"}, nil))
+
+	require.NoError(t, c.ExecuteStateTransaction(ctx, "store", map[string]string{"partitionKey": "p1"}, []*StateOperation{
+		{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "order-1", Value: []byte(`{"id":1}`)}},
+		{Type: StateOperationTypeDelete, Item: &SetStateItem{Key: "order-2", Etag: &ETag{Value: "3"}}},
+	}))
+}
+
+func TestHTTPClientPublish(t *testing.T) {
+	ctx := context.Background()
+	c := newFixtureClient(t, WithIDGenerator(ids.NewSequence("entry-")))
+
+	require.NoError(t, c.PublishEvent(ctx, "messages", "orders", map[string]int{"id": 1}, PublishEventWithMetadata(map[string]string{"ttlInSeconds": "30"})))
+
+	err := c.PublishEvent(ctx, "messages", "unknown", []byte("hello"), PublishEventWithContentType("text/plain"))
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Contains(t, err.Error(), "ERR_PUBSUB_NOT_FOUND")
+
+	res := c.PublishEvents(ctx, "messages", "orders", []interface{}{map[string]int{"id": 1}, "plain text"})
+	require.Error(t, res.Error)
+	require.Len(t, res.FailedEvents, 1)
+	assert.Equal(t, "plain text", res.FailedEvents[0])
+}
+
+func TestHTTPClientInvoke(t *testing.T) {
+	ctx := context.Background()
+	c := newFixtureClient(t)
+
+	out, err := c.InvokeMethodWithContent(ctx, "orders", "greet?lang=en", "post", &DataContent{Data: []byte("world"), ContentType: "text/plain"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(out))
+
+	_, err = c.InvokeMethod(ctx, "orders", "missing", "get")
+	require.Error(t, err)
+	var ie *InvocationError
+	require.ErrorAs(t, err, &ie)
+	assert.Equal(t, codes.NotFound, ie.Code())
+}
+
+func TestHTTPClientSecrets(t *testing.T) {
+	ctx := context.Background()
+	c := newFixtureClient(t)
+
+	secret, err := c.GetSecret(ctx, "vault", "db", map[string]string{"version_id": "2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "s3cret"}, secret)
+
+	secrets, err := c.GetBulkSecret(ctx, "vault", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{"db": {"password": "s3cret"}, "api": {"key": "abc"}}, secrets)
+
+	_, err = c.GetSecret(ctx, "vault", "forbidden", nil)
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestHTTPClientMetadata(t *testing.T) {
+	ctx := context.Background()
+	c := newFixtureClient(t)
+
+	require.NoError(t, c.Wait(ctx, time.Second))
+
+	md, err := c.GetMetadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "orders", md.ID)
+	assert.Equal(t, []string{"ServiceInvocationStreaming"}, md.EnabledFeatures)
+	assert.Equal(t, []*MetadataActiveActorsCount{{Type: "counter", Count: 2}}, md.ActiveActorsCount)
+	assert.Equal(t, []*MetadataRegisteredComponents{{Name: "store", Type: "state.redis", Version: "v1", Capabilities: []string{"ETAG", "TRANSACTIONAL"}}}, md.RegisteredComponents)
+	assert.Equal(t, map[string]string{"color": "blue"}, md.ExtendedMetadata)
+	require.Len(t, md.Subscriptions, 1)
+	assert.Equal(t, "orders-dead", md.Subscriptions[0].DeadLetterTopic)
+	assert.Equal(t, []*MetadataHTTPEndpoint{{Name: "payments"}}, md.HTTPEndpoints)
+
+	require.NoError(t, c.SetMetadata(ctx, "color", "blue"))
+}
+
+func TestHTTPClientNotSupported(t *testing.T) {
+	ctx := context.Background()
+	c := newFixtureClient(t)
+
+	_, err := c.GetConfigurationItem(ctx, "config", "key")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotSupportedByTransport))
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	_, err = c.SubscribeConfigurationItems(ctx, "config", []string{"key"}, func(string, map[string]*ConfigurationItem) {})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotSupportedByTransport))
+}
+
+func TestHTTPClientOptions(t *testing.T) {
+	ctx := context.Background()
+	observer := &callRecorder{}
+	c := newFixtureClient(t, WithCallObserver(observer))
+
+	_, err := c.GetSecret(ctx, "vault", "db", map[string]string{"version_id": "2"})
+	require.NoError(t, err)
+	_, err = c.GetSecret(ctx, "vault", "forbidden", nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, observer.Calls("GetSecret", codes.OK))
+	assert.Equal(t, 1, observer.Calls("GetSecret", codes.PermissionDenied))
+
+	// The validations of the client apply before the request is sent, which
+	// would match no fixture.
+	_, err = c.GetState(ctx, "", "key", nil)
+	require.Error(t, err)
+	assert.Empty(t, c.(CallTracker).InFlight())
+}
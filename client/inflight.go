@@ -0,0 +1,311 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// inflightShards is the number of shards of the in-flight call registry, so
+// that concurrent calls rarely contend on the same lock.
+const inflightShards = 32
+
+// CallInfo describes a call in flight, as returned by InFlight.
+type CallInfo struct {
+	// ID identifies the call in CancelCall.
+	ID string
+	// Method is the name of the runtime API, such as "InvokeService".
+	Method string
+	// Target is the app, actor, store, pubsub topic, binding or workflow
+	// instance the call is made to, if known from the request.
+	Target string
+	// Start is when the call started.
+	Start time.Time
+}
+
+// CallLifecycleObserver is a CallObserver also notified when a call starts
+// and when it finishes, with its error, such as the context.Canceled error of
+// a call cancelled with CancelCall.
+type CallLifecycleObserver interface {
+	CallObserver
+	ObserveCallStart(info CallInfo)
+	ObserveCallFinish(info CallInfo, err error)
+}
+
+// InFlight returns the calls to the runtime in flight, oldest first, such as
+// the calls to cancel with CancelCall when shutting down. Calls made on the
+// connection returned by GrpcClientConn are not tracked.
+func (c *GRPCClient) InFlight() []CallInfo {
+	return c.inflight.list()
+}
+
+// CancelCall cancels the context of the call in flight with the given ID,
+// which then fails with an error that is a context.Canceled error. It returns
+// false if no call with this ID is in flight.
+func (c *GRPCClient) CancelCall(id string) bool {
+	return c.inflight.cancel(id)
+}
+
+// inflightCalls is the registry of the calls in flight, sharded by ID.
+type inflightCalls struct {
+	next     atomic.Uint64
+	observer CallLifecycleObserver
+	shards   [inflightShards]inflightShard
+
+	// closing is set by drain, after which no call is registered. unary
+	// counts the unary calls in flight, and drained is closed when it drops
+	// to zero while closing.
+	closing     atomic.Bool
+	unary       atomic.Int64
+	drained     chan struct{}
+	drainedOnce sync.Once
+}
+
+type inflightShard struct {
+	lock  sync.Mutex
+	calls map[uint64]*inflightCall
+}
+
+type inflightCall struct {
+	info     CallInfo
+	cancel   context.CancelFunc
+	canceled atomic.Bool
+}
+
+func newInflightCalls(observer CallObserver) *inflightCalls {
+	r := &inflightCalls{drained: make(chan struct{})}
+	r.observer, _ = observer.(CallLifecycleObserver)
+	for i := range r.shards {
+		r.shards[i].calls = make(map[uint64]*inflightCall)
+	}
+	return r
+}
+
+// start registers a call of method, returning its context, cancelled by
+// CancelCall, and the function to call with its error when it finishes. It
+// returns ErrClientClosed once the client is draining.
+func (r *inflightCalls) start(ctx context.Context, method, target string, stream bool) (context.Context, func(err error) error, error) {
+	id := r.next.Add(1)
+	ctx, cancel := context.WithCancel(ctx)
+	call := &inflightCall{
+		info:   CallInfo{ID: strconv.FormatUint(id, 10), Method: path.Base(method), Target: target, Start: time.Now()},
+		cancel: cancel,
+	}
+	shard := &r.shards[id%inflightShards]
+	shard.lock.Lock()
+	if r.closing.Load() {
+		shard.lock.Unlock()
+		cancel()
+		return nil, nil, ErrClientClosed
+	}
+	shard.calls[id] = call
+	if !stream {
+		r.unary.Add(1)
+	}
+	shard.lock.Unlock()
+	if r.observer != nil {
+		r.observer.ObserveCallStart(call.info)
+	}
+	var once sync.Once
+	return ctx, func(err error) error {
+		if err != nil && call.canceled.Load() {
+			err = &canceledCallError{id: call.info.ID, err: err}
+		}
+		once.Do(func() {
+			shard.lock.Lock()
+			delete(shard.calls, id)
+			shard.lock.Unlock()
+			if !stream && r.unary.Add(-1) == 0 && r.closing.Load() {
+				r.drainedOnce.Do(func() { close(r.drained) })
+			}
+			cancel()
+			if r.observer != nil {
+				r.observer.ObserveCallFinish(call.info, err)
+			}
+		})
+		return err
+	}, nil
+}
+
+// drain stops registering calls, and waits for the unary calls in flight to
+// finish or for ctx to be done. Streaming calls, such as subscriptions, are
+// not waited for.
+func (r *inflightCalls) drain(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	r.closing.Store(true)
+	// Once every shard has been locked, no call is being registered.
+	for i := range r.shards {
+		r.shards[i].lock.Lock()
+		r.shards[i].lock.Unlock() //nolint:staticcheck // SA2001: a barrier.
+	}
+	if r.unary.Load() == 0 {
+		return nil
+	}
+	select {
+	case <-r.drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// list returns the calls in flight, oldest first.
+func (r *inflightCalls) list() []CallInfo {
+	if r == nil {
+		return nil
+	}
+	var calls []CallInfo
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.lock.Lock()
+		for _, call := range shard.calls {
+			calls = append(calls, call.info)
+		}
+		shard.lock.Unlock()
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].Start.Before(calls[j].Start)
+	})
+	return calls
+}
+
+// cancel cancels the context of the call in flight with the given ID.
+func (r *inflightCalls) cancel(id string) bool {
+	if r == nil {
+		return false
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return false
+	}
+	shard := &r.shards[n%inflightShards]
+	shard.lock.Lock()
+	call, ok := shard.calls[n]
+	shard.lock.Unlock()
+	if !ok {
+		return false
+	}
+	call.canceled.Store(true)
+	call.cancel()
+	return true
+}
+
+// canceledCallError is the error of a call cancelled with CancelCall. It
+// keeps the gRPC status of the call, and is a context.Canceled error.
+type canceledCallError struct {
+	id  string
+	err error
+}
+
+func (e *canceledCallError) Error() string {
+	return "call " + e.id + " cancelled: " + e.err.Error()
+}
+
+func (e *canceledCallError) GRPCStatus() *status.Status {
+	s, _ := status.FromError(e.err)
+	return s
+}
+
+func (e *canceledCallError) Unwrap() error {
+	return context.Canceled
+}
+
+// callTarget returns the target of a call from its request, or an empty string
+// if it has none.
+func callTarget(req any) string {
+	switch r := req.(type) {
+	case *pb.InvokeServiceRequest:
+		return r.GetId()
+	case *pb.InvokeBindingRequest:
+		return r.GetName()
+	case interface {
+		GetActorType() string
+		GetActorId() string
+	}:
+		return r.GetActorType() + "/" + r.GetActorId()
+	case interface {
+		GetPubsubName() string
+		GetTopic() string
+	}:
+		return r.GetPubsubName() + "/" + r.GetTopic()
+	case interface{ GetStoreName() string }:
+		return r.GetStoreName()
+	case interface{ GetInstanceId() string }:
+		return r.GetInstanceId()
+	}
+	return ""
+}
+
+func inflightUnaryInterceptor(r *inflightCalls) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, finish, err := r.start(ctx, method, callTarget(req), false)
+		if err != nil {
+			return err
+		}
+		return finish(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+func inflightStreamInterceptor(r *inflightCalls) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, finish, err := r.start(ctx, method, "", true)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, finish(err)
+		}
+		// A stream ends with its first receive error, or when its context is
+		// done if it is abandoned by the caller.
+		go func() {
+			<-ctx.Done()
+			finish(ctx.Err())
+		}()
+		return &inflightStream{ClientStream: stream, finish: finish}, nil
+	}
+}
+
+// inflightStream unregisters a streaming call when its first receive error,
+// which is io.EOF for a successful call, is returned.
+type inflightStream struct {
+	grpc.ClientStream
+	finish func(err error) error
+}
+
+func (s *inflightStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		s.finish(nil)
+		return err
+	}
+	if err != nil {
+		return s.finish(err)
+	}
+	return nil
+}
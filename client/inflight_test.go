@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	anypb "github.com/golang/protobuf/ptypes/any"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// slowDaprServer blocks the service invocations until they are released, if
+// release is set, or cancelled.
+type slowDaprServer struct {
+	testDaprServer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *slowDaprServer) InvokeService(ctx context.Context, req *pb.InvokeServiceRequest) (*commonv1pb.InvokeResponse, error) {
+	s.started <- struct{}{}
+	select {
+	case <-s.release:
+		return &commonv1pb.InvokeResponse{Data: &anypb.Any{Value: []byte("done")}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// lifecycleRecorder is a CallLifecycleObserver.
+type lifecycleRecorder struct {
+	callRecorder
+	started  []CallInfo
+	finished []error
+}
+
+func (r *lifecycleRecorder) ObserveCallStart(info CallInfo) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.started = append(r.started, info)
+}
+
+func (r *lifecycleRecorder) ObserveCallFinish(info CallInfo, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.finished = append(r.finished, err)
+}
+
+func TestCancelCall(t *testing.T) {
+	ctx := context.Background()
+	srv := &slowDaprServer{started: make(chan struct{}, 1)}
+	observer := &lifecycleRecorder{}
+	c, closer := getTestClientWithServer(ctx, srv, WithCallObserver(observer))
+	defer closer()
+
+	assert.Empty(t, c.(CallTracker).InFlight())
+	assert.False(t, c.(CallTracker).CancelCall("1"))
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.InvokeMethod(ctx, "slow-app", "wait", "get")
+		errs <- err
+	}()
+	<-srv.started
+
+	calls := c.(CallTracker).InFlight()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "InvokeService", calls[0].Method)
+	assert.Equal(t, "slow-app", calls[0].Target)
+	assert.False(t, calls[0].Start.IsZero())
+
+	require.True(t, c.(CallTracker).CancelCall(calls[0].ID))
+	select {
+	case err := <-errs:
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled call did not return")
+	}
+	assert.Empty(t, c.(CallTracker).InFlight())
+	assert.False(t, c.(CallTracker).CancelCall(calls[0].ID))
+
+	assert.Equal(t, 1, observer.Calls("InvokeService", codes.Canceled))
+	observer.lock.Lock()
+	defer observer.lock.Unlock()
+	assert.Equal(t, calls, observer.started)
+	require.Len(t, observer.finished, 1)
+	assert.True(t, errors.Is(observer.finished[0], context.Canceled))
+}
+
+func TestInFlightCompleted(t *testing.T) {
+	ctx := context.Background()
+	c, closer := getTestClientWithServer(ctx, &testDaprServer{
+		state:                       make(map[string][]byte),
+		configurationSubscriptionID: map[string]chan struct{}{},
+	})
+	defer closer()
+
+	require.NoError(t, c.SaveState(ctx, "store", "key", []byte("value"), nil))
+	_, err := c.GetState(ctx, "store", "key", nil)
+	require.NoError(t, err)
+	assert.Empty(t, c.(CallTracker).InFlight())
+}
+
+func TestCallTarget(t *testing.T) {
+	assert.Equal(t, "app", callTarget(&pb.InvokeServiceRequest{Id: "app"}))
+	assert.Equal(t, "binding", callTarget(&pb.InvokeBindingRequest{Name: "binding"}))
+	assert.Equal(t, "counter/1", callTarget(&pb.InvokeActorRequest{ActorType: "counter", ActorId: "1"}))
+	assert.Equal(t, "messages/orders", callTarget(&pb.PublishEventRequest{PubsubName: "messages", Topic: "orders"}))
+	assert.Equal(t, "store", callTarget(&pb.GetStateRequest{StoreName: "store", Key: "key"}))
+	assert.Equal(t, "", callTarget(&emptypb.Empty{}))
+}
+
+func TestGracefulClose(t *testing.T) {
+	ctx := context.Background()
+	srv := &slowDaprServer{started: make(chan struct{}, 1), release: make(chan struct{})}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	results := make(chan error, 1)
+	go func() {
+		out, err := c.InvokeMethod(ctx, "slow-app", "wait", "get")
+		if err == nil && string(out) != "done" {
+			err = errors.New("unexpected response " + string(out))
+		}
+		results <- err
+	}()
+	<-srv.started
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- c.(GracefulCloser).GracefulClose(ctx)
+	}()
+
+	// New calls are rejected while the call in flight is drained.
+	assert.Eventually(t, func() bool {
+		_, err := c.InvokeMethod(ctx, "slow-app", "wait", "get")
+		return errors.Is(err, ErrClientClosed)
+	}, 5*time.Second, 10*time.Millisecond)
+	select {
+	case err := <-closed:
+		t.Fatalf("GracefulClose returned before the call in flight finished: %v", err)
+	default:
+	}
+
+	close(srv.release)
+	require.NoError(t, <-results)
+	select {
+	case err := <-closed:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("GracefulClose did not return")
+	}
+	assert.Empty(t, c.(CallTracker).InFlight())
+}
+
+func TestGracefulCloseTimeout(t *testing.T) {
+	ctx := context.Background()
+	srv := &slowDaprServer{started: make(chan struct{}, 1)}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	results := make(chan error, 1)
+	go func() {
+		_, err := c.InvokeMethod(ctx, "slow-app", "wait", "get")
+		results <- err
+	}()
+	<-srv.started
+
+	closeCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, c.(GracefulCloser).GracefulClose(closeCtx), context.DeadlineExceeded)
+	// Closing the connection fails the call still in flight.
+	require.Error(t, <-results)
+}
@@ -15,17 +15,65 @@ package client
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 
 	anypb "github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	v1 "github.com/dapr/dapr/pkg/proto/common/v1"
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/service/common"
 )
 
+// InvocationError is returned by the service invocation methods when the call
+// fails with a gRPC status, such as the status of a handler returning a
+// common.StatusError in the invoked app.
+type InvocationError struct {
+	status     *status.Status
+	resilience *ResilienceInfo
+	err        error
+}
+
+func (e *InvocationError) Error() string {
+	return e.status.Err().Error()
+}
+
+// GRPCStatus returns the gRPC status of the failed call.
+func (e *InvocationError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// Unwrap returns the error of the call, which is a context.Canceled error if
+// the call was cancelled with CancelCall.
+func (e *InvocationError) Unwrap() error {
+	return e.err
+}
+
+// Code returns the status code of the failed call.
+func (e *InvocationError) Code() codes.Code {
+	return e.status.Code()
+}
+
+// Resilience returns the outcome of the resiliency policies the sidecar applied
+// to the failed call, such as an open circuit breaker, or nil if the sidecar
+// didn't report any.
+func (e *InvocationError) Resilience() *ResilienceInfo {
+	return e.resilience
+}
+
+// StatusDetails returns the details of the status, such as
+// errdetails.BadRequest. Details whose type is not linked into the program are
+// skipped.
+func (e *InvocationError) StatusDetails() []proto.Message {
+	return common.StatusDetails(e.status)
+}
+
 // DataContent the service invocation content.
 type DataContent struct {
 	// Data is the input data
@@ -34,24 +82,52 @@ type DataContent struct {
 	ContentType string
 }
 
+// InvokeResponse is the response of a service invocation.
+type InvokeResponse struct {
+	// Data is the data returned by the invoked method, nil if none.
+	Data []byte
+	// ContentType is the type of the data.
+	ContentType string
+	// Headers are the headers of the response, holding the response headers
+	// of the invoked app.
+	Headers map[string][]string
+	// Resilience is the outcome of the resiliency policies the sidecar applied
+	// to the call, such as its retries, or nil if it didn't report any.
+	Resilience *ResilienceInfo
+}
+
 func (c *GRPCClient) invokeServiceWithRequest(ctx context.Context, req *pb.InvokeServiceRequest) (out []byte, err error) {
+	resp, err := c.invokeService(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// invokeService invokes the service with req, collecting the resiliency
+// headers of the response.
+func (c *GRPCClient) invokeService(ctx context.Context, req *pb.InvokeServiceRequest) (*InvokeResponse, error) {
 	if req == nil {
 		return nil, errors.New("nil request")
 	}
 
-	resp, err := c.protoClient.InvokeService(c.withAuthToken(ctx), req)
+	var header, trailer metadata.MD
+	resp, err := c.protoClient.InvokeService(c.withAuthToken(ctx), req, grpc.Header(&header), grpc.Trailer(&trailer))
+	resilience := parseResilienceInfo(header, trailer)
 	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			return nil, &InvocationError{status: s, resilience: resilience, err: err}
+		}
 		return nil, err
 	}
 
 	// allow for service to not return any value
-	if resp != nil && resp.GetData() != nil {
-		out = resp.GetData().Value
-		return
-	}
-
-	out = nil
-	return
+	return &InvokeResponse{
+		Data:        resp.GetData().GetValue(),
+		ContentType: resp.GetContentType(),
+		Headers:     header,
+		Resilience:  resilience,
+	}, nil
 }
 
 func queryAndVerbToHTTPExtension(query string, verb string) *v1.HTTPExtension {
@@ -61,23 +137,15 @@ func queryAndVerbToHTTPExtension(query string, verb string) *v1.HTTPExtension {
 	return &v1.HTTPExtension{Verb: v1.HTTPExtension_NONE}
 }
 
-func hasRequiredInvokeArgs(appID, methodName, verb string) error {
-	if appID == "" {
-		return errors.New("appID")
-	}
-	if methodName == "" {
-		return errors.New("methodName")
-	}
-	if verb == "" {
-		return errors.New("verb")
-	}
-	return nil
+func hasRequiredInvokeArgs(method, appID, methodName, verb string) error {
+	return requireArgs(method, "appID", appID, "methodName", methodName, "verb", verb)
 }
 
 // InvokeMethod invokes service without raw data ([]byte).
 func (c *GRPCClient) InvokeMethod(ctx context.Context, appID, methodName, verb string) (out []byte, err error) {
-	if err := hasRequiredInvokeArgs(appID, methodName, verb); err != nil {
-		return nil, fmt.Errorf("missing required parameter: %w", err)
+	ctx = withSDKMethod(ctx, "InvokeMethod")
+	if err := hasRequiredInvokeArgs("InvokeMethod", appID, methodName, verb); err != nil {
+		return nil, err
 	}
 	method, query := extractMethodAndQuery(methodName)
 	req := &pb.InvokeServiceRequest{
@@ -92,8 +160,9 @@ func (c *GRPCClient) InvokeMethod(ctx context.Context, appID, methodName, verb s
 
 // InvokeMethodWithContent invokes service with content (data + content type).
 func (c *GRPCClient) InvokeMethodWithContent(ctx context.Context, appID, methodName, verb string, content *DataContent) (out []byte, err error) {
-	if err := hasRequiredInvokeArgs(appID, methodName, verb); err != nil {
-		return nil, fmt.Errorf("missing required parameter: %w", err)
+	ctx = withSDKMethod(ctx, "InvokeMethodWithContent")
+	if err := hasRequiredInvokeArgs("InvokeMethodWithContent", appID, methodName, verb); err != nil {
+		return nil, err
 	}
 	if content == nil {
 		return nil, errors.New("content required")
@@ -111,10 +180,34 @@ func (c *GRPCClient) InvokeMethodWithContent(ctx context.Context, appID, methodN
 	return c.invokeServiceWithRequest(ctx, req)
 }
 
+// InvokeMethodWithResponse invokes service with content, which may be nil,
+// and returns the response with its content type and the outcome of the
+// resiliency policies the sidecar applied to the call.
+func (c *GRPCClient) InvokeMethodWithResponse(ctx context.Context, appID, methodName, verb string, content *DataContent) (*InvokeResponse, error) {
+	ctx = withSDKMethod(ctx, "InvokeMethodWithResponse")
+	if err := hasRequiredInvokeArgs("InvokeMethodWithResponse", appID, methodName, verb); err != nil {
+		return nil, err
+	}
+	method, query := extractMethodAndQuery(methodName)
+	req := &pb.InvokeServiceRequest{
+		Id: appID,
+		Message: &v1.InvokeRequest{
+			Method:        method,
+			HttpExtension: queryAndVerbToHTTPExtension(query, verb),
+		},
+	}
+	if content != nil {
+		req.Message.Data = &anypb.Any{Value: content.Data}
+		req.Message.ContentType = content.ContentType
+	}
+	return c.invokeService(ctx, req)
+}
+
 // InvokeMethodWithCustomContent invokes service with custom content (struct + content type).
 func (c *GRPCClient) InvokeMethodWithCustomContent(ctx context.Context, appID, methodName, verb string, contentType string, content interface{}) ([]byte, error) {
-	if err := hasRequiredInvokeArgs(appID, methodName, verb); err != nil {
-		return nil, fmt.Errorf("missing required parameter: %w", err)
+	ctx = withSDKMethod(ctx, "InvokeMethodWithCustomContent")
+	if err := hasRequiredInvokeArgs("InvokeMethodWithCustomContent", appID, methodName, verb); err != nil {
+		return nil, err
 	}
 	if contentType == "" {
 		return nil, errors.New("content type required")
@@ -123,7 +216,7 @@ func (c *GRPCClient) InvokeMethodWithCustomContent(ctx context.Context, appID, m
 		return nil, errors.New("content required")
 	}
 
-	contentData, err := json.Marshal(content)
+	contentData, err := jsonMarshal(content)
 	if err != nil {
 		return nil, fmt.Errorf("error serializing input struct: %w", err)
 	}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+// jsonCodec serializes the payloads of the client.
+type jsonCodec struct {
+	marshal   func(interface{}) ([]byte, error)
+	unmarshal func([]byte, interface{}) error
+}
+
+var currentJSONCodec atomic.Pointer[jsonCodec]
+
+func init() {
+	SetJSONCodec(nil, nil)
+}
+
+// SetJSONCodec sets the functions serializing to JSON the payloads of the
+// clients, such as the events published and the values of UpdateState, so
+// that a faster library compatible with encoding/json can be used. A nil
+// function resets to the one of encoding/json. It should be called before
+// the clients are used.
+func SetJSONCodec(marshal func(interface{}) ([]byte, error), unmarshal func([]byte, interface{}) error) {
+	if marshal == nil {
+		marshal = json.Marshal
+	}
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	currentJSONCodec.Store(&jsonCodec{marshal: marshal, unmarshal: unmarshal})
+}
+
+func jsonMarshal(v interface{}) ([]byte, error) {
+	return currentJSONCodec.Load().marshal(v)
+}
+
+func jsonUnmarshal(data []byte, v interface{}) error {
+	return currentJSONCodec.Load().unmarshal(data, v)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetJSONCodec(t *testing.T) {
+	var marshaled, unmarshaled atomic.Int32
+	SetJSONCodec(func(v interface{}) ([]byte, error) {
+		marshaled.Add(1)
+		return json.Marshal(v)
+	}, func(data []byte, v interface{}) error {
+		unmarshaled.Add(1)
+		return json.Unmarshal(data, v)
+	})
+	t.Cleanup(func() { SetJSONCodec(nil, nil) })
+
+	ctx := context.Background()
+	c, closer := getTestClientWithServer(ctx, newJobStateDaprServer())
+	defer closer()
+
+	require.NoError(t, UpdateState(ctx, c, testStore, "key", increment("a"), UpdateOptions{}))
+	assert.Equal(t, int32(1), marshaled.Load(), "the saved state is serialized by the codec")
+	require.NoError(t, UpdateState(ctx, c, testStore, "key", increment("b"), UpdateOptions{}))
+	assert.Equal(t, int32(2), marshaled.Load())
+	assert.Equal(t, int32(1), unmarshaled.Load(), "the state read is deserialized by the codec")
+
+	item, err := c.GetState(ctx, testStore, "key", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value":2,"writers":["a","b"]}`, string(item.Value))
+
+	require.NoError(t, testClient.PublishEvent(ctx, "messages", "test", counter{Value: 1}))
+	assert.Equal(t, int32(3), marshaled.Load(), "the events are serialized by the codec")
+}
+
+func TestSetJSONCodecDefault(t *testing.T) {
+	SetJSONCodec(nil, nil)
+	data, err := jsonMarshal(map[string]int{"a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(data))
+}
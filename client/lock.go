@@ -46,15 +46,17 @@ type UnlockResponse struct {
 }
 
 // TryLockAlpha1 attempts to grab a lock from a lock store.
+// If request.LockOwner is empty, a new owner ID is generated and set on the request,
+// so that it can be used to unlock.
 func (c *GRPCClient) TryLockAlpha1(ctx context.Context, storeName string, request *LockRequest) (*LockResponse, error) {
-	if storeName == "" {
-		return nil, errors.New("storeName is empty")
-	}
-
 	if request == nil {
 		return nil, errors.New("request is nil")
 	}
 
+	if request.LockOwner == "" {
+		request.LockOwner = c.ids().NewID()
+	}
+
 	req := pb.TryLockRequest{
 		ResourceId:      request.ResourceID,
 		LockOwner:       request.LockOwner,
@@ -74,10 +76,6 @@ func (c *GRPCClient) TryLockAlpha1(ctx context.Context, storeName string, reques
 
 // UnlockAlpha1 deletes unlocks a lock from a lock store.
 func (c *GRPCClient) UnlockAlpha1(ctx context.Context, storeName string, request *UnlockRequest) (*UnlockResponse, error) {
-	if storeName == "" {
-		return nil, errors.New("storeName is empty")
-	}
-
 	if request == nil {
 		return nil, errors.New("request is nil")
 	}
@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/ids"
 )
 
 const (
@@ -77,3 +78,31 @@ func TestLock(t *testing.T) {
 		assert.Equal(t, pb.UnlockResponse_SUCCESS.String(), r.Status)
 	})
 }
+
+// lockOwnerDaprServer records the lock owners of TryLockAlpha1 requests.
+type lockOwnerDaprServer struct {
+	testDaprServer
+	owners []string
+}
+
+func (s *lockOwnerDaprServer) TryLockAlpha1(ctx context.Context, req *pb.TryLockRequest) (*pb.TryLockResponse, error) {
+	s.owners = append(s.owners, req.LockOwner)
+	return &pb.TryLockResponse{Success: true}, nil
+}
+
+func TestLockOwnerGeneration(t *testing.T) {
+	ctx := context.Background()
+	srv := &lockOwnerDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv, WithIDGenerator(ids.NewSequence("owner-")))
+	defer closer()
+
+	req := &LockRequest{ResourceID: "res", ExpiryInSeconds: 5}
+	_, err := c.TryLockAlpha1(ctx, testLockStore, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "owner-1", req.LockOwner)
+
+	_, err = c.TryLockAlpha1(ctx, testLockStore, &LockRequest{ResourceID: "res", LockOwner: "mine"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"owner-1", "mine"}, srv.owners)
+}
@@ -2,7 +2,6 @@ package client
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -17,6 +16,7 @@ type GetMetadataResponse struct {
 	ExtendedMetadata     map[string]string
 	Subscriptions        []*MetadataSubscription
 	HTTPEndpoints        []*MetadataHTTPEndpoint
+	EnabledFeatures      []string
 }
 
 type MetadataActiveActorsCount struct {
@@ -106,17 +106,39 @@ func (c *GRPCClient) GetMetadata(ctx context.Context) (metadata *GetMetadataResp
 			ExtendedMetadata:     resp.GetExtendedMetadata(),
 			Subscriptions:        subscriptions,
 			HTTPEndpoints:        httpEndpoints,
+			EnabledFeatures:      resp.GetEnabledFeatures(),
 		}
 	}
 
 	return metadata, nil
 }
 
+// SupportedFeatures returns the list of features enabled in the sidecar,
+// such as preview features, as reported by its metadata.
+func (c *GRPCClient) SupportedFeatures(ctx context.Context) ([]string, error) {
+	md, err := c.GetMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return md.EnabledFeatures, nil
+}
+
+// HasFeature reports whether the named feature is enabled in the sidecar.
+func (c *GRPCClient) HasFeature(ctx context.Context, name string) (bool, error) {
+	features, err := c.SupportedFeatures(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range features {
+		if f == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // SetMetadata sets a value in the extended metadata of the sidecar
 func (c *GRPCClient) SetMetadata(ctx context.Context, key, value string) error {
-	if len(key) == 0 {
-		return errors.New("a key is required")
-	}
 	req := &pb.SetMetadataRequest{
 		Key:   key,
 		Value: value,
@@ -125,5 +147,6 @@ func (c *GRPCClient) SetMetadata(ctx context.Context, key, value string) error {
 	if err != nil {
 		return fmt.Errorf("error setting metadata: %w", err)
 	}
+	c.preflight.invalidate()
 	return nil
 }
@@ -4,7 +4,11 @@ import (
 	"context"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 // Test GetMetadata returns
@@ -27,3 +31,91 @@ func TestSetMetadata(t *testing.T) {
 		assert.Equal(t, "test_value", metadata.ExtendedMetadata["test_key"])
 	})
 }
+
+type featuresDaprServer struct {
+	testDaprServer
+}
+
+func (s *featuresDaprServer) GetMetadata(ctx context.Context, req *empty.Empty) (*pb.GetMetadataResponse, error) {
+	return &pb.GetMetadataResponse{
+		EnabledFeatures: []string{"ServiceInvocationStreaming", "Resiliency"},
+	}, nil
+}
+
+func TestSupportedFeatures(t *testing.T) {
+	ctx := context.Background()
+	c, closer := getTestClientWithServer(ctx, &featuresDaprServer{})
+	defer closer()
+
+	t.Run("list features", func(t *testing.T) {
+		features, err := c.(FeatureChecker).SupportedFeatures(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ServiceInvocationStreaming", "Resiliency"}, features)
+	})
+
+	t.Run("has feature", func(t *testing.T) {
+		ok, err := c.(FeatureChecker).HasFeature(ctx, "Resiliency")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("does not have feature", func(t *testing.T) {
+		ok, err := c.(FeatureChecker).HasFeature(ctx, "SchedulerReminders")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("no features", func(t *testing.T) {
+		ok, err := testClient.(FeatureChecker).HasFeature(ctx, "Resiliency")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+type placementDaprServer struct {
+	testDaprServer
+	placement string
+}
+
+func (s *placementDaprServer) GetMetadata(ctx context.Context, req *empty.Empty) (*pb.GetMetadataResponse, error) {
+	return &pb.GetMetadataResponse{
+		ExtendedMetadata: map[string]string{ActorPlacementMetadataKey: s.placement},
+	}, nil
+}
+
+func TestGetActorPlacement(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("placed", func(t *testing.T) {
+		c, closer := getTestClientWithServer(ctx, &placementDaprServer{
+			placement: `[{"actorType":"counter","actorID":"1","host":"10.0.0.1:50002","appID":"app1"},` +
+				`{"actorType":"counter","actorID":"2","host":"10.0.0.2:50002"}]`,
+		})
+		defer closer()
+		p, err := c.(ActorPlacementGetter).GetActorPlacement(ctx, "counter", "2")
+		require.NoError(t, err)
+		assert.Equal(t, &ActorPlacement{ActorType: "counter", ActorID: "2", Host: "10.0.0.2:50002"}, p)
+
+		_, err = c.(ActorPlacementGetter).GetActorPlacement(ctx, "counter", "3")
+		assert.ErrorIs(t, err, ErrActorNotPlaced)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		c, closer := getTestClientWithServer(ctx, &placementDaprServer{placement: "10.0.0.1"})
+		defer closer()
+		_, err := c.(ActorPlacementGetter).GetActorPlacement(ctx, "counter", "1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := testClient.(ActorPlacementGetter).GetActorPlacement(ctx, "counter", "1")
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("invalid argument", func(t *testing.T) {
+		_, err := testClient.(ActorPlacementGetter).GetActorPlacement(ctx, "counter", "")
+		var invalid *ErrInvalidArgument
+		assert.ErrorAs(t, err, &invalid)
+	})
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MetricCallsTotal and MetricCallDuration are the names of the metrics
+	// registered by WithMetrics, labeled by method and code.
+	MetricCallsTotal   = "dapr_client_calls_total"
+	MetricCallDuration = "dapr_client_call_duration_seconds"
+)
+
+// CallObserver records the calls made by a client, by method, such as
+// "GetState", and status code. Streaming calls are recorded when they end.
+// It must be safe for concurrent use.
+type CallObserver interface {
+	ObserveCall(method string, code codes.Code, duration time.Duration)
+}
+
+// RawAccessObserver is a CallObserver also recording the accesses to the raw
+// runtime client and connection, by accessor such as "GrpcClientConn", whose
+// calls bypass the SDK.
+type RawAccessObserver interface {
+	CallObserver
+	ObserveRawAccess(accessor string)
+}
+
+// WithCallObserver records every call made by the client with observer, which
+// can also be a CallLifecycleObserver or a RawAccessObserver.
+func WithCallObserver(observer CallObserver) ClientOption {
+	return func(o *clientOptions) {
+		o.callObserver = observer
+	}
+}
+
+// WithMetrics records every call made by the client in the
+// dapr_client_calls_total counter and the dapr_client_call_duration_seconds
+// histogram, labeled by method and code, which it registers with registerer.
+// Clients given the same registerer share the metrics.
+func WithMetrics(registerer prometheus.Registerer) ClientOption {
+	return func(o *clientOptions) {
+		o.metrics = newCallMetrics(registerer)
+	}
+}
+
+// callMetrics is a CallObserver recording the calls in Prometheus collectors.
+type callMetrics struct {
+	calls    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+var _ CallObserver = (*callMetrics)(nil)
+
+func newCallMetrics(registerer prometheus.Registerer) *callMetrics {
+	m := &callMetrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: MetricCallsTotal,
+			Help: "Number of calls made by the Dapr client.",
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    MetricCallDuration,
+			Help:    "Duration of the calls made by the Dapr client.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+	m.calls = registerCollector(registerer, m.calls)
+	m.duration = registerCollector(registerer, m.duration)
+	return m
+}
+
+// registerCollector registers c with registerer, returning the collector
+// registered before if there is one.
+func registerCollector[C prometheus.Collector](registerer prometheus.Registerer, c C) C {
+	if err := registerer.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		logger.Printf("error registering client metrics: %v", err)
+	}
+	return c
+}
+
+// ObserveCall records a call.
+func (m *callMetrics) ObserveCall(method string, code codes.Code, duration time.Duration) {
+	m.calls.WithLabelValues(method, code.String()).Inc()
+	m.duration.WithLabelValues(method, code.String()).Observe(duration.Seconds())
+}
+
+func metricsUnaryInterceptor(observer CallObserver) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		observer.ObserveCall(path.Base(method), status.Code(err), time.Since(start))
+		return err
+	}
+}
+
+func metricsStreamInterceptor(observer CallObserver) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			observer.ObserveCall(path.Base(method), status.Code(err), time.Since(start))
+			return nil, err
+		}
+		return &observedStream{ClientStream: stream, observer: observer, method: path.Base(method), start: start}, nil
+	}
+}
+
+// observedStream records a streaming call when its first receive error, which
+// is io.EOF for a successful call, is returned.
+type observedStream struct {
+	grpc.ClientStream
+	observer CallObserver
+	method   string
+	start    time.Time
+	once     sync.Once
+}
+
+func (s *observedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			code := codes.OK
+			if !errors.Is(err, io.EOF) {
+				code = status.Code(err)
+			}
+			s.observer.ObserveCall(s.method, code, time.Since(s.start))
+		})
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+func TestWithMetrics(t *testing.T) {
+	ctx := context.Background()
+	registry := prometheus.NewRegistry()
+	c, closer := getTestClientWithServer(ctx, &testDaprServer{
+		state:                       make(map[string][]byte),
+		configurationSubscriptionID: map[string]chan struct{}{},
+	}, WithMetrics(registry))
+	defer closer()
+
+	require.NoError(t, c.SaveState(ctx, "store", "key", []byte("value"), nil))
+	_, err := c.GetState(ctx, "store", "key", nil)
+	require.NoError(t, err)
+	_, err = c.GetState(ctx, "store", "key", nil)
+	require.NoError(t, err)
+
+	calls := func(method string, code codes.Code) float64 {
+		return testutil.ToFloat64(callsCounter(registry).WithLabelValues(method, code.String()))
+	}
+	assert.Equal(t, float64(1), calls("SaveState", codes.OK))
+	assert.Equal(t, float64(2), calls("GetState", codes.OK))
+
+	_, err = c.GetState(ctx, "", "key", nil)
+	require.Error(t, err)
+	assert.Equal(t, float64(2), calls("GetState", codes.OK), "calls rejected by the client are not recorded")
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 2)
+	assert.Equal(t, MetricCallDuration, families[0].GetName())
+	for _, m := range families[0].GetMetric() {
+		if m.GetLabel()[1].GetValue() == "GetState" {
+			assert.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+		}
+	}
+	assert.Equal(t, MetricCallsTotal, families[1].GetName())
+}
+
+func TestWithMetricsSharedRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	first := newCallMetrics(registry)
+	second := newCallMetrics(registry)
+	assert.Same(t, first.calls, second.calls)
+	assert.Same(t, first.duration, second.duration)
+
+	first.ObserveCall("InvokeService", codes.Unavailable, 50*time.Millisecond)
+	second.ObserveCall("InvokeService", codes.Unavailable, 2*time.Second)
+	assert.Equal(t, float64(2), testutil.ToFloat64(first.calls.WithLabelValues("InvokeService", "Unavailable")))
+	assert.Equal(t, 1, testutil.CollectAndCount(registry, MetricCallDuration))
+}
+
+// callsCounter returns the calls counter registered with registry.
+func callsCounter(registry prometheus.Registerer) *prometheus.CounterVec {
+	return newCallMetrics(registry).calls
+}
+
+// callRecorder is a CallObserver counting the calls by method and code.
+type callRecorder struct {
+	lock  sync.Mutex
+	calls map[string]int
+}
+
+func (r *callRecorder) ObserveCall(method string, code codes.Code, _ time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.calls == nil {
+		r.calls = make(map[string]int)
+	}
+	r.calls[method+"/"+code.String()]++
+}
+
+// Calls returns the number of calls recorded for method with code.
+func (r *callRecorder) Calls(method string, code codes.Code) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.calls[method+"/"+code.String()]
+}
+
+// rawAccessRecorder is a RawAccessObserver.
+type rawAccessRecorder struct {
+	callRecorder
+	accessors []string
+}
+
+func (r *rawAccessRecorder) ObserveRawAccess(accessor string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.accessors = append(r.accessors, accessor)
+}
+
+func TestRawAccess(t *testing.T) {
+	ctx := context.Background()
+	observer := &rawAccessRecorder{}
+	c, closer := getTestClientWithServer(ctx, &testDaprServer{
+		state:                       make(map[string][]byte),
+		configurationSubscriptionID: map[string]chan struct{}{},
+	}, WithCallObserver(observer))
+	defer closer()
+
+	require.NoError(t, c.SaveState(ctx, "store", "key", []byte("value"), nil))
+
+	// A raw call over the shared connection bypasses the interceptors.
+	resp, err := pb.NewDaprClient(c.(RawClient).GrpcClientConn()).GetState(ctx, &pb.GetStateRequest{StoreName: "store", Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(resp.GetData()))
+	assert.Equal(t, 0, observer.Calls("GetState", codes.OK))
+
+	// The Dapr client goes through them.
+	_, err = c.(RawClient).DaprClient().GetState(ctx, &pb.GetStateRequest{StoreName: "store", Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, observer.Calls("GetState", codes.OK))
+
+	assert.Equal(t, []string{"GrpcClientConn", "DaprClient"}, observer.accessors)
+}
@@ -0,0 +1,422 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // registers the gzip compressor for WithCompression
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+
+	"github.com/dapr/go-sdk/ids"
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// ClientOption configures a Dapr client created by one of the NewClientWith* functions.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	propagateKeys        []string
+	maxWorkflowEventSize int
+	publishPreflight     bool
+	bindingValidation    bool
+	loadBalancingPolicy  string
+	resolvers            []resolver.Builder
+	defaultTimeout       time.Duration
+	idGenerator          ids.Generator
+	compressor           string
+	storeDefaults        map[string]map[string]string
+	secretPageSize       int
+	maxBulkSecretMsgSize int
+	codec                encoding.Codec
+	maxBindingRespSize   int
+	addressResolver      func() (string, error)
+	asyncPublishWorkers  int
+	asyncPublishQueue    int
+	asyncPublishDrain    time.Duration
+	callObserver         CallObserver
+	metrics              *callMetrics
+	userAgentSuffixes    []string
+	tracePropagation     bool
+	actorInvokeTimeouts  map[string]time.Duration
+	defaultContentType   string
+	strictContentType    bool
+	pubsubNamespace      string
+}
+
+func newClientOptions(opts ...ClientOption) *clientOptions {
+	o := &clientOptions{
+		maxWorkflowEventSize: DefaultMaxWorkflowEventSize,
+		idGenerator:          ids.UUID(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithAutoPropagation forwards the given keys from the incoming handler
+// metadata in the call context to every outgoing Dapr call, without the
+// need to call common.PropagateMetadata in each handler.
+func WithAutoPropagation(keys []string) ClientOption {
+	return func(o *clientOptions) {
+		o.propagateKeys = append(o.propagateKeys, keys...)
+	}
+}
+
+// WithMaxWorkflowEventSize sets the maximum serialized size in bytes of the
+// payloads raised with RaiseWorkflowEvent. A non-positive value disables the check.
+func WithMaxWorkflowEventSize(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxWorkflowEventSize = n
+	}
+}
+
+// WithPublishPreflight checks with CanPublish before each publish and returns
+// ErrTopicNotAllowed with the reason, instead of making a round trip to the
+// sidecar that is bound to fail.
+func WithPublishPreflight() ClientOption {
+	return func(o *clientOptions) {
+		o.publishPreflight = true
+	}
+}
+
+// WithBindingValidation checks the operation of binding invocations against
+// the operations supported by the type of the binding, for common binding
+// types, and returns ErrUnsupportedBindingOperation instead of making a round
+// trip to the sidecar that is bound to fail. Bindings of other types are not
+// validated.
+func WithBindingValidation() ClientOption {
+	return func(o *clientOptions) {
+		o.bindingValidation = true
+	}
+}
+
+// WithPubsubNamespace publishes the events of PublishEvent and PublishEvents
+// to the topics of the Dapr namespace ns, as named by common.NamespacedTopic,
+// on pub/sub components shared by several namespaces without being namespace
+// scoped. PublishEventInNamespace and PublishEventsInNamespace take precedence.
+func WithPubsubNamespace(ns string) ClientOption {
+	return func(o *clientOptions) {
+		o.pubsubNamespace = ns
+	}
+}
+
+// WithLoadBalancingPolicy sets the gRPC load balancing policy, such as
+// "round_robin", used when the client dials the sidecar address.
+// It has no effect on NewClientWithConnection.
+func WithLoadBalancingPolicy(name string) ClientOption {
+	return func(o *clientOptions) {
+		o.loadBalancingPolicy = name
+	}
+}
+
+// WithResolver registers a gRPC name resolver for the client connection. The
+// sidecar address must then use the resolver's scheme, e.g. "myscheme:///dapr".
+// It has no effect on NewClientWithConnection.
+func WithResolver(builder resolver.Builder) ClientOption {
+	return func(o *clientOptions) {
+		o.resolvers = append(o.resolvers, builder)
+	}
+}
+
+// WithDefaultTimeout sets a deadline of d on every unary call whose context
+// doesn't already have one. Streaming calls, such as configuration
+// subscriptions, are not affected.
+func WithDefaultTimeout(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.defaultTimeout = d
+	}
+}
+
+// WithIDGenerator sets the generator of the identifiers created by the client,
+// such as bulk publish entry IDs and lock owners. The default is ids.UUID().
+func WithIDGenerator(g ids.Generator) ClientOption {
+	return func(o *clientOptions) {
+		if g != nil {
+			o.idGenerator = g
+		}
+	}
+}
+
+// WithCompression compresses the messages of every call with the named gRPC
+// compressor, such as gzip.Name from google.golang.org/grpc/encoding/gzip,
+// which is registered by the client.
+func WithCompression(name string) ClientOption {
+	return func(o *clientOptions) {
+		o.compressor = name
+	}
+}
+
+// WithStoreDefaults sets metadata merged into the metadata of every state,
+// secret and configuration call targeting the named component. Metadata passed
+// to a call overrides the defaults key-by-key. Lock calls carry no metadata and
+// are not affected.
+func WithStoreDefaults(componentName string, meta map[string]string) ClientOption {
+	return func(o *clientOptions) {
+		if o.storeDefaults == nil {
+			o.storeDefaults = make(map[string]map[string]string)
+		}
+		o.storeDefaults[componentName] = mergeMetadata(o.storeDefaults[componentName], meta)
+	}
+}
+
+// WithSecretPageSize sets the number of secrets BulkSecretIterator requests
+// per page. The default is DefaultSecretPageSize.
+func WithSecretPageSize(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.secretPageSize = n
+	}
+}
+
+// WithMaxBulkSecretMessageSize raises the maximum size, in bytes, of the
+// responses received by BulkSecretIterator, for secret stores that don't
+// support paging and return all secrets at once.
+func WithMaxBulkSecretMessageSize(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxBulkSecretMsgSize = n
+	}
+}
+
+// WithMaxBindingResponseSize raises the maximum size, in bytes, of the
+// responses received by InvokeBindingStream, for bindings returning large
+// payloads.
+func WithMaxBindingResponseSize(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxBindingRespSize = n
+	}
+}
+
+// WithCodec encodes and decodes the messages of every call with codec instead
+// of the default protobuf codec, such as PassthroughCodec to avoid copying
+// large payloads.
+func WithCodec(codec encoding.Codec) ClientOption {
+	return func(o *clientOptions) {
+		o.codec = codec
+	}
+}
+
+// WithAddressResolver sets the function with which NewClient and DefaultClient
+// resolve the address of the Dapr sidecar, instead of reading the port from
+// the DAPR_GRPC_PORT environment variable.
+func WithAddressResolver(fn func() (string, error)) ClientOption {
+	return func(o *clientOptions) {
+		o.addressResolver = fn
+	}
+}
+
+// WithAsyncPublishWorkers sets the number of workers publishing the events
+// queued by PublishEventAsync, and the number of events that can be queued.
+// Non-positive values use DefaultAsyncPublishWorkers and
+// DefaultAsyncPublishQueueSize.
+func WithAsyncPublishWorkers(n, queue int) ClientOption {
+	return func(o *clientOptions) {
+		o.asyncPublishWorkers = n
+		o.asyncPublishQueue = queue
+	}
+}
+
+// WithAsyncPublishDrainTimeout sets the time Close waits for the events queued
+// by PublishEventAsync to be published before cancelling them. The default is
+// DefaultAsyncPublishDrainTimeout.
+func WithAsyncPublishDrainTimeout(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.asyncPublishDrain = d
+	}
+}
+
+// dialOptions returns the gRPC dial options for the client options.
+func (o *clientOptions) dialOptions() []grpc.DialOption {
+	var opts []grpc.DialOption
+	if o.loadBalancingPolicy != "" {
+		opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]}`, o.loadBalancingPolicy)))
+	}
+	if len(o.resolvers) > 0 {
+		opts = append(opts, grpc.WithResolvers(o.resolvers...))
+	}
+	return opts
+}
+
+func (o *clientOptions) unaryInterceptors(calls *inflightCalls) []grpc.UnaryClientInterceptor {
+	interceptors := []grpc.UnaryClientInterceptor{
+		validationUnaryInterceptor(),
+		inflightUnaryInterceptor(calls),
+		propagationUnaryInterceptor(o.propagateKeys),
+		sdkMetadataUnaryInterceptor(o.sdkMetadata()),
+	}
+	if o.tracePropagation {
+		interceptors = append(interceptors, tracePropagationUnaryInterceptor())
+	}
+	if o.metrics != nil {
+		interceptors = append(interceptors, metricsUnaryInterceptor(o.metrics))
+	}
+	if o.callObserver != nil {
+		interceptors = append(interceptors, metricsUnaryInterceptor(o.callObserver))
+	}
+	if o.defaultTimeout > 0 {
+		interceptors = append(interceptors, defaultTimeoutUnaryInterceptor(o.defaultTimeout))
+	}
+	if o.compressor != "" {
+		interceptors = append(interceptors, callOptionsUnaryInterceptor(grpc.UseCompressor(o.compressor)))
+	}
+	if o.codec != nil {
+		interceptors = append(interceptors, callOptionsUnaryInterceptor(grpc.ForceCodec(o.codec)))
+	}
+	return interceptors
+}
+
+func (o *clientOptions) streamInterceptors(calls *inflightCalls) []grpc.StreamClientInterceptor {
+	interceptors := []grpc.StreamClientInterceptor{
+		validationStreamInterceptor(),
+		inflightStreamInterceptor(calls),
+		propagationStreamInterceptor(o.propagateKeys),
+		sdkMetadataStreamInterceptor(o.sdkMetadata()),
+	}
+	if o.tracePropagation {
+		interceptors = append(interceptors, tracePropagationStreamInterceptor())
+	}
+	if o.metrics != nil {
+		interceptors = append(interceptors, metricsStreamInterceptor(o.metrics))
+	}
+	if o.callObserver != nil {
+		interceptors = append(interceptors, metricsStreamInterceptor(o.callObserver))
+	}
+	if o.compressor != "" {
+		interceptors = append(interceptors, callOptionsStreamInterceptor(grpc.UseCompressor(o.compressor)))
+	}
+	if o.codec != nil {
+		interceptors = append(interceptors, callOptionsStreamInterceptor(grpc.ForceCodec(o.codec)))
+	}
+	return interceptors
+}
+
+// interceptedConn runs the client interceptors around every call made on the
+// underlying connection. Wrapping the connection rather than passing dial
+// options lets the interceptors apply to connections created by the caller.
+// The connection is a *grpc.ClientConn, or the HTTP transport of
+// NewHTTPClient, in which case the interceptors are passed a nil
+// *grpc.ClientConn.
+type interceptedConn struct {
+	conn   grpc.ClientConnInterface
+	cc     *grpc.ClientConn
+	unary  []grpc.UnaryClientInterceptor
+	stream []grpc.StreamClientInterceptor
+}
+
+func newInterceptedConn(conn grpc.ClientConnInterface, o *clientOptions, calls *inflightCalls) *interceptedConn {
+	cc, _ := conn.(*grpc.ClientConn)
+	return &interceptedConn{
+		conn:   conn,
+		cc:     cc,
+		unary:  o.unaryInterceptors(calls),
+		stream: o.streamInterceptors(calls),
+	}
+}
+
+func (c *interceptedConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return c.invokeAt(ctx, 0, method, args, reply, opts...)
+}
+
+func (c *interceptedConn) invokeAt(ctx context.Context, i int, method string, args, reply any, opts ...grpc.CallOption) error {
+	if i == len(c.unary) {
+		return c.conn.Invoke(ctx, method, args, reply, opts...)
+	}
+	next := func(ctx context.Context, method string, args, reply any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return c.invokeAt(ctx, i+1, method, args, reply, opts...)
+	}
+	return c.unary[i](ctx, method, args, reply, c.cc, next, opts...)
+}
+
+func (c *interceptedConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.newStreamAt(ctx, 0, desc, method, opts...)
+}
+
+func (c *interceptedConn) newStreamAt(ctx context.Context, i int, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if i == len(c.stream) {
+		return c.conn.NewStream(ctx, desc, method, opts...)
+	}
+	next := func(ctx context.Context, desc *grpc.StreamDesc, _ *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return c.newStreamAt(ctx, i+1, desc, method, opts...)
+	}
+	return c.stream[i](ctx, desc, c.cc, method, next, opts...)
+}
+
+func propagationUnaryInterceptor(keys []string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withPropagatedMetadata(ctx, keys), method, req, reply, cc, opts...)
+	}
+}
+
+func defaultTimeoutUnaryInterceptor(d time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func callOptionsUnaryInterceptor(callOpts ...grpc.CallOption) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(ctx, method, req, reply, cc, withCallOptions(callOpts, opts)...)
+	}
+}
+
+func callOptionsStreamInterceptor(callOpts ...grpc.CallOption) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(ctx, desc, cc, method, withCallOptions(callOpts, opts)...)
+	}
+}
+
+// withCallOptions returns the default call options followed by the ones of the
+// call, so that the latter take precedence.
+func withCallOptions(defaults, opts []grpc.CallOption) []grpc.CallOption {
+	all := make([]grpc.CallOption, 0, len(defaults)+len(opts))
+	all = append(all, defaults...)
+	return append(all, opts...)
+}
+
+func propagationStreamInterceptor(keys []string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withPropagatedMetadata(ctx, keys), desc, cc, method, opts...)
+	}
+}
+
+// withPropagatedMetadata merges the metadata marked for propagation into the
+// outgoing metadata of ctx. Keys already set on the outgoing call win.
+func withPropagatedMetadata(ctx context.Context, keys []string) context.Context {
+	if len(keys) > 0 {
+		ctx = common.PropagateMetadata(ctx, keys...)
+	}
+	md := common.PropagatedMetadata(ctx)
+	if len(md) == 0 {
+		return ctx
+	}
+
+	out, _ := metadata.FromOutgoingContext(ctx)
+	out = out.Copy()
+	for k, v := range md {
+		if len(out.Get(k)) == 0 {
+			out.Set(k, v...)
+		}
+	}
+	return metadata.NewOutgoingContext(ctx, out)
+}
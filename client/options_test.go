@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/service/common"
+)
+
+func TestWithPropagatedMetadata(t *testing.T) {
+	in := metadata.Pairs("x-tenant-id", "tenant-1", "x-user", "user-1", "x-other", "other")
+	ctx := metadata.NewIncomingContext(context.Background(), in)
+
+	t.Run("nothing to propagate", func(t *testing.T) {
+		_, ok := metadata.FromOutgoingContext(withPropagatedMetadata(ctx, nil))
+		assert.False(t, ok)
+	})
+
+	t.Run("explicit and automatic keys", func(t *testing.T) {
+		out, _ := metadata.FromOutgoingContext(withPropagatedMetadata(common.PropagateMetadata(ctx, "x-tenant-id"), []string{"x-user"}))
+		assert.Equal(t, []string{"tenant-1"}, out.Get("x-tenant-id"))
+		assert.Equal(t, []string{"user-1"}, out.Get("x-user"))
+		assert.Empty(t, out.Get("x-other"))
+	})
+
+	t.Run("per-call metadata wins", func(t *testing.T) {
+		callCtx := metadata.AppendToOutgoingContext(common.PropagateMetadata(ctx, "x-tenant-id"), "x-tenant-id", "tenant-2")
+		out, _ := metadata.FromOutgoingContext(withPropagatedMetadata(callCtx, nil))
+		assert.Equal(t, []string{"tenant-2"}, out.Get("x-tenant-id"))
+	})
+}
+
+// recordingBalancerBuilder wraps pick_first, counting how many balancers it built.
+type recordingBalancerBuilder struct {
+	balancer.Builder
+	built int32
+}
+
+func (b *recordingBalancerBuilder) Name() string {
+	return "dapr_sdk_test_lb"
+}
+
+func (b *recordingBalancerBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	atomic.AddInt32(&b.built, 1)
+	return b.Builder.Build(cc, opts)
+}
+
+func TestWithLoadBalancingPolicyAndResolver(t *testing.T) {
+	lb := &recordingBalancerBuilder{Builder: balancer.Get("pick_first")}
+	balancer.Register(lb)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterDaprServer(s, &testDaprServer{})
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	defer s.Stop()
+
+	r := manual.NewBuilderWithScheme("daprtest")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: lis.Addr().String()}}})
+
+	c, err := NewClientWithAddressContext(context.Background(), "daprtest:///sidecar",
+		WithLoadBalancingPolicy(lb.Name()),
+		WithResolver(r),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lb.built))
+	_, err = c.GetMetadata(context.Background())
+	assert.NoError(t, err)
+}
+
+// deadlineDaprServer records the deadline of the last GetMetadata call.
+type deadlineDaprServer struct {
+	testDaprServer
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (s *deadlineDaprServer) GetMetadata(ctx context.Context, req *empty.Empty) (*pb.GetMetadataResponse, error) {
+	s.deadline, s.hasDeadline = ctx.Deadline()
+	return &pb.GetMetadataResponse{}, nil
+}
+
+func TestWithDefaultTimeout(t *testing.T) {
+	ctx := context.Background()
+	srv := &deadlineDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv, WithDefaultTimeout(time.Minute))
+	defer closer()
+
+	t.Run("call without deadline gets the default", func(t *testing.T) {
+		start := time.Now()
+		_, err := c.GetMetadata(ctx)
+		require.NoError(t, err)
+		require.True(t, srv.hasDeadline)
+		assert.WithinDuration(t, start.Add(time.Minute), srv.deadline, 5*time.Second)
+	})
+
+	t.Run("call with deadline keeps it", func(t *testing.T) {
+		deadline := time.Now().Add(time.Hour)
+		deadlineCtx, cancel := context.WithDeadline(ctx, deadline)
+		defer cancel()
+		_, err := c.GetMetadata(deadlineCtx)
+		require.NoError(t, err)
+		require.True(t, srv.hasDeadline)
+		assert.WithinDuration(t, deadline, srv.deadline, 5*time.Second)
+	})
+
+	t.Run("no default timeout", func(t *testing.T) {
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+		_, err := c.GetMetadata(ctx)
+		require.NoError(t, err)
+		assert.False(t, srv.hasDeadline)
+	})
+}
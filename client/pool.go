@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ClientPool is a fixed-size set of clients, each with its own connection to
+// the Dapr sidecar, used to spread high call volumes over multiple gRPC
+// connections.
+type ClientPool struct {
+	clients []*GRPCClient
+	next    atomic.Uint64
+}
+
+// NewClientPool instantiates a pool of size clients connected to address
+// (including port). All clients share the same options.
+func NewClientPool(address string, size int, opts ...ClientOption) (*ClientPool, error) {
+	return NewClientPoolContext(context.Background(), address, size, opts...)
+}
+
+// NewClientPoolContext instantiates a pool of size clients connected to
+// address (including port), using ctx to create the connections.
+// If a connection can't be created, the connections already created are closed.
+func NewClientPoolContext(ctx context.Context, address string, size int, opts ...ClientOption) (*ClientPool, error) {
+	if size <= 0 {
+		return nil, errors.New("pool size must be positive")
+	}
+	p := &ClientPool{clients: make([]*GRPCClient, 0, size)}
+	for i := 0; i < size; i++ {
+		c, err := NewClientWithAddressContext(ctx, address, opts...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.clients = append(p.clients, c.(*GRPCClient))
+	}
+	return p, nil
+}
+
+// Get returns the next client of the pool, in round-robin order.
+// It is safe for concurrent use.
+func (p *ClientPool) Get() *GRPCClient {
+	n := p.next.Add(1) - 1
+	return p.clients[n%uint64(len(p.clients))]
+}
+
+// Size returns the number of clients in the pool.
+func (p *ClientPool) Size() int {
+	return len(p.clients)
+}
+
+// WithAuthToken sets the Dapr API token on all the clients of the pool.
+func (p *ClientPool) WithAuthToken(token string) {
+	for _, c := range p.clients {
+		c.WithAuthToken(token)
+	}
+}
+
+// Close closes the connections of all the clients of the pool.
+func (p *ClientPool) Close() {
+	for _, c := range p.clients {
+		c.Close()
+	}
+}
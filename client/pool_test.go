@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// peersDaprServer records the remote address of each GetSecret call, which
+// identifies the connection the call was made on.
+type peersDaprServer struct {
+	testDaprServer
+	lock  sync.Mutex
+	peers map[string]int
+}
+
+func (s *peersDaprServer) GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.GetSecretResponse, error) {
+	p, _ := peer.FromContext(ctx)
+	s.lock.Lock()
+	s.peers[p.Addr.String()]++
+	s.lock.Unlock()
+	return &pb.GetSecretResponse{}, nil
+}
+
+func TestClientPool(t *testing.T) {
+	ctx := context.Background()
+	srv := &peersDaprServer{peers: map[string]int{}}
+	s := grpc.NewServer()
+	pb.RegisterDaprServer(s, srv)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	t.Run("calls are distributed across connections", func(t *testing.T) {
+		pool, err := NewClientPool(lis.Addr().String(), 3)
+		require.NoError(t, err)
+		defer pool.Close()
+		assert.Equal(t, 3, pool.Size())
+
+		first := pool.Get()
+		assert.NotSame(t, first, pool.Get())
+		assert.NotSame(t, first, pool.Get())
+		assert.Same(t, first, pool.Get())
+
+		for i := 0; i < 5; i++ {
+			_, err := pool.Get().GetSecret(ctx, "store", "key", nil)
+			require.NoError(t, err)
+		}
+		srv.lock.Lock()
+		defer srv.lock.Unlock()
+		assert.Len(t, srv.peers, 3)
+		for _, n := range srv.peers {
+			assert.GreaterOrEqual(t, n, 1)
+		}
+	})
+
+	t.Run("close shuts down all connections", func(t *testing.T) {
+		pool, err := NewClientPool(lis.Addr().String(), 2)
+		require.NoError(t, err)
+		pool.Close()
+		for i := 0; i < pool.Size(); i++ {
+			assert.Nil(t, pool.Get().connection)
+		}
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		_, err := NewClientPool(lis.Addr().String(), 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := NewClientPool("", 2)
+		assert.Error(t, err)
+	})
+}
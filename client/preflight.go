@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTopicNotAllowed is returned by publish calls when the publish preflight
+// check determines that the app may not publish to the topic.
+var ErrTopicNotAllowed = errors.New("topic not allowed")
+
+// DefaultPublishPreflightTTL is the time CanPublish caches its results, so
+// components loaded or removed by the sidecar are eventually picked up.
+const DefaultPublishPreflightTTL = time.Minute
+
+type preflightResult struct {
+	allowed bool
+	reason  string
+	expires time.Time
+}
+
+// publishPreflight caches the results of CanPublish.
+type publishPreflight struct {
+	ttl time.Duration
+	now func() time.Time
+
+	lock    sync.RWMutex
+	results map[string]preflightResult
+}
+
+func newPublishPreflight() *publishPreflight {
+	return &publishPreflight{
+		ttl:     DefaultPublishPreflightTTL,
+		now:     time.Now,
+		results: map[string]preflightResult{},
+	}
+}
+
+func (p *publishPreflight) get(key string) (preflightResult, bool) {
+	if p == nil {
+		return preflightResult{}, false
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	res, ok := p.results[key]
+	if !ok || !p.now().Before(res.expires) {
+		return preflightResult{}, false
+	}
+	return res, true
+}
+
+func (p *publishPreflight) set(key string, res preflightResult) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	res.expires = p.now().Add(p.ttl)
+	p.results[key] = res
+}
+
+func (p *publishPreflight) invalidate() {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.results = map[string]preflightResult{}
+}
+
+// CanPublish reports whether the app may publish to topic on the given pubsub
+// component, based on the sidecar metadata. When not allowed, reason explains why.
+// The component must be a pubsub component registered with the sidecar; a
+// component scoped to other apps is not registered with the sidecar of this
+// one. Topic scopes are not exposed in the metadata, so they are still
+// enforced by the sidecar when publishing.
+// Results are cached for DefaultPublishPreflightTTL, or until the metadata is
+// changed through this client.
+func (c *GRPCClient) CanPublish(ctx context.Context, pubsubName, topicName string) (allowed bool, reason string, err error) {
+	if pubsubName == "" {
+		return false, "", invalidArgument("CanPublish", "pubsubName", reasonEmpty)
+	}
+	if topicName == "" {
+		return false, "", invalidArgument("CanPublish", "topicName", reasonEmpty)
+	}
+
+	if res, ok := c.preflight.get(pubsubName); ok {
+		return res.allowed, res.reason, nil
+	}
+
+	md, err := c.GetMetadata(ctx)
+	if err != nil {
+		return false, "", fmt.Errorf("error checking publish permissions: %w", err)
+	}
+	res := checkPublish(md, pubsubName)
+	c.preflight.set(pubsubName, res)
+	return res.allowed, res.reason, nil
+}
+
+func checkPublish(md *GetMetadataResponse, pubsubName string) preflightResult {
+	var component *MetadataRegisteredComponents
+	for _, rc := range md.RegisteredComponents {
+		if rc.Name == pubsubName {
+			component = rc
+			break
+		}
+	}
+	if component == nil {
+		return preflightResult{reason: fmt.Sprintf("pubsub component %s is not registered", pubsubName)}
+	}
+	if !strings.HasPrefix(component.Type, "pubsub.") {
+		return preflightResult{reason: fmt.Sprintf("component %s is not a pubsub component but %s", pubsubName, component.Type)}
+	}
+	return preflightResult{allowed: true}
+}
+
+// checkPublishPreflight returns ErrTopicNotAllowed if the publish preflight is
+// enabled and the app may not publish to the topic.
+func (c *GRPCClient) checkPublishPreflight(ctx context.Context, pubsubName, topicName string) error {
+	if !c.publishPreflightEnabled {
+		return nil
+	}
+	allowed, reason, err := c.CanPublish(ctx, pubsubName, topicName)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s", ErrTopicNotAllowed, reason)
+	}
+	return nil
+}
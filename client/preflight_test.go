@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+type preflightDaprServer struct {
+	testDaprServer
+	metadataCalls int32
+	publishCalls  int32
+}
+
+func (s *preflightDaprServer) GetMetadata(ctx context.Context, req *empty.Empty) (*pb.GetMetadataResponse, error) {
+	atomic.AddInt32(&s.metadataCalls, 1)
+	return &pb.GetMetadataResponse{
+		RegisteredComponents: []*pb.RegisteredComponents{
+			{Name: "messages", Type: "pubsub.redis", Version: "v1"},
+			{Name: "events", Type: "pubsub.kafka", Version: "v1"},
+			{Name: "store", Type: "state.redis", Version: "v1"},
+		},
+	}, nil
+}
+
+func (s *preflightDaprServer) PublishEvent(ctx context.Context, req *pb.PublishEventRequest) (*empty.Empty, error) {
+	atomic.AddInt32(&s.publishCalls, 1)
+	return &empty.Empty{}, nil
+}
+
+func TestCanPublish(t *testing.T) {
+	ctx := context.Background()
+	srv := &preflightDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	t.Run("missing arguments", func(t *testing.T) {
+		_, _, err := c.(PublishChecker).CanPublish(ctx, "", "orders")
+		assert.Error(t, err)
+		_, _, err = c.(PublishChecker).CanPublish(ctx, "messages", "")
+		assert.Error(t, err)
+	})
+
+	t.Run("registered pubsub component", func(t *testing.T) {
+		allowed, reason, err := c.(PublishChecker).CanPublish(ctx, "messages", "payments")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Empty(t, reason)
+
+		allowed, _, err = c.(PublishChecker).CanPublish(ctx, "events", "anything")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("unknown component", func(t *testing.T) {
+		allowed, reason, err := c.(PublishChecker).CanPublish(ctx, "nope", "orders")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Contains(t, reason, "not registered")
+	})
+
+	t.Run("not a pubsub component", func(t *testing.T) {
+		allowed, reason, err := c.(PublishChecker).CanPublish(ctx, "store", "orders")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Contains(t, reason, "not a pubsub component")
+	})
+
+	t.Run("results are cached until metadata changes", func(t *testing.T) {
+		calls := atomic.LoadInt32(&srv.metadataCalls)
+		_, _, err := c.(PublishChecker).CanPublish(ctx, "messages", "payments")
+		require.NoError(t, err)
+		assert.Equal(t, calls, atomic.LoadInt32(&srv.metadataCalls))
+
+		require.NoError(t, c.SetMetadata(ctx, "k", "v"))
+		_, _, err = c.(PublishChecker).CanPublish(ctx, "messages", "payments")
+		require.NoError(t, err)
+		assert.Equal(t, calls+1, atomic.LoadInt32(&srv.metadataCalls))
+	})
+
+	t.Run("results expire after the TTL", func(t *testing.T) {
+		now := time.Now()
+		preflight := c.(*GRPCClient).preflight
+		preflight.now = func() time.Time { return now }
+		defer func() { preflight.now = time.Now }()
+
+		_, _, err := c.(PublishChecker).CanPublish(ctx, "events", "orders")
+		require.NoError(t, err)
+		calls := atomic.LoadInt32(&srv.metadataCalls)
+
+		now = now.Add(DefaultPublishPreflightTTL - time.Second)
+		_, _, err = c.(PublishChecker).CanPublish(ctx, "events", "orders")
+		require.NoError(t, err)
+		assert.Equal(t, calls, atomic.LoadInt32(&srv.metadataCalls))
+
+		now = now.Add(time.Second)
+		_, _, err = c.(PublishChecker).CanPublish(ctx, "events", "orders")
+		require.NoError(t, err)
+		assert.Equal(t, calls+1, atomic.LoadInt32(&srv.metadataCalls))
+	})
+}
+
+func TestPublishPreflight(t *testing.T) {
+	ctx := context.Background()
+	srv := &preflightDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv, WithPublishPreflight())
+	defer closer()
+
+	t.Run("registered component is published to", func(t *testing.T) {
+		require.NoError(t, c.PublishEvent(ctx, "messages", "orders", "data"))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&srv.publishCalls))
+	})
+
+	t.Run("unknown component is rejected client-side", func(t *testing.T) {
+		err := c.PublishEvent(ctx, "nope", "orders", "data")
+		assert.ErrorIs(t, err, ErrTopicNotAllowed)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&srv.publishCalls))
+
+		res := c.PublishEvents(ctx, "nope", "orders", []interface{}{"a", "b"})
+		assert.ErrorIs(t, res.Error, ErrTopicNotAllowed)
+		assert.Len(t, res.FailedEvents, 2)
+	})
+}
@@ -14,32 +14,61 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"time"
 
-	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/ids"
+	"github.com/dapr/go-sdk/service/common"
 )
 
 const (
 	rawPayload = "rawPayload"
 	trueValue  = "true"
+
+	// metadataKeyGzip is the metadata key set by PublishEventWithGzip, which is
+	// removed before the event is published.
+	metadataKeyGzip = "dapr-go-sdk.gzip"
+	// metadataKeyMaxBatchSize is the metadata key set by WithMaxBatchSize,
+	// which is removed before the events are published.
+	metadataKeyMaxBatchSize = "dapr-go-sdk.maxBatchSize"
+	// metadataKeyNegativeTTL is the metadata key set by WithPublishTTL with a
+	// negative duration, for which PublishEvent returns an error.
+	metadataKeyNegativeTTL = "dapr-go-sdk.negativeTTL"
+	// contentEncodingExtension is the CloudEvent extension attribute holding
+	// the encoding of the event data, read by the subscribers of this SDK.
+	contentEncodingExtension = "contentencoding"
+	contentEncodingGzip      = "gzip"
+	eventSentType            = "com.dapr.event.sent"
+
+	// metadataKeyValueSchemaType is the metadata key the Kafka pubsub component
+	// reads to serialize the event value with a schema registry schema.
+	metadataKeyValueSchemaType = "valueSchemaType"
+	contentTypeProtobuf        = "application/x-protobuf"
 )
 
+// SchemaTypeAvro is the value schema type of events serialized with an Avro
+// schema, see WithSchema.
+const SchemaTypeAvro = "Avro"
+
 // PublishEventOption is the type for the functional option.
 type PublishEventOption func(*pb.PublishEventRequest)
 
 // PublishEvent publishes data onto specific pubsub topic.
 func (c *GRPCClient) PublishEvent(ctx context.Context, pubsubName, topicName string, data interface{}, opts ...PublishEventOption) error {
 	if pubsubName == "" {
-		return errors.New("pubsubName name required")
+		return invalidArgument(sdkMethod(ctx, "PublishEvent"), "pubsubName", reasonEmpty)
 	}
 	if topicName == "" {
-		return errors.New("topic name required")
+		return invalidArgument(sdkMethod(ctx, "PublishEvent"), "topicName", reasonEmpty)
 	}
 
 	request := &pb.PublishEventRequest{
@@ -49,6 +78,18 @@ func (c *GRPCClient) PublishEvent(ctx context.Context, pubsubName, topicName str
 	for _, o := range opts {
 		o(request)
 	}
+	// The topic is left as is by the options unless PublishEventInNamespace
+	// is used.
+	if c.pubsubNamespace != "" && request.Topic == topicName {
+		request.Topic = common.NamespacedTopic(c.pubsubNamespace, topicName)
+	}
+	if err := validatePublishTTL(request.Metadata); err != nil {
+		return err
+	}
+
+	if err := c.checkPublishPreflight(ctx, pubsubName, topicName); err != nil {
+		return err
+	}
 
 	if data != nil {
 		switch d := data.(type) {
@@ -56,16 +97,50 @@ func (c *GRPCClient) PublishEvent(ctx context.Context, pubsubName, topicName str
 			request.Data = d
 		case string:
 			request.Data = []byte(d)
+		case proto.Message:
+			var err error
+			if !c.detectsContentType() {
+				request.DataContentType = "application/json"
+				request.Data, err = jsonMarshal(d)
+			} else {
+				if request.DataContentType == "" {
+					request.DataContentType = contentTypeProtobuf
+				}
+				request.Data, err = proto.Marshal(d)
+			}
+			if err != nil {
+				return fmt.Errorf("error serializing input struct: %w", err)
+			}
 		default:
 			var err error
 			request.DataContentType = "application/json"
-			request.Data, err = json.Marshal(d)
+			request.Data, err = jsonMarshal(d)
 			if err != nil {
 				return fmt.Errorf("error serializing input struct: %w", err)
 			}
 		}
 	}
 
+	if err := c.resolveContentType("PublishEvent", pubsubName+"/"+topicName, &request.DataContentType, request.Data); err != nil {
+		return err
+	}
+
+	if _, ok := request.Metadata[metadataKeyGzip]; ok {
+		request.Metadata = withoutMetadata(request.Metadata, metadataKeyGzip)
+		if len(request.Data) > 0 && request.DataContentType != cloudEventContentType {
+			event, err := newGzippedEvent(c.ids().NewID(), request.DataContentType, request.Data)
+			if err != nil {
+				return fmt.Errorf("error compressing event payload: %w", err)
+			}
+			request.Data = event
+			request.DataContentType = cloudEventContentType
+		}
+	}
+
+	if isRawPayload(request.Metadata) {
+		request.Metadata = withRawContentType(request.Metadata, request.DataContentType)
+	}
+
 	_, err := c.protoClient.PublishEvent(c.withAuthToken(ctx), request)
 	if err != nil {
 		return fmt.Errorf("error publishing event unto %s topic: %w", topicName, err)
@@ -89,6 +164,9 @@ func PublishEventWithMetadata(metadata map[string]string) PublishEventOption {
 }
 
 // PublishEventWithRawPayload can be passed as option to PublishEvent to set rawPayload metadata.
+// Raw payloads are published without the CloudEvent envelope holding their
+// datacontenttype, so the content type, set with PublishEventWithContentType,
+// is sent as the contentType metadata instead.
 func PublishEventWithRawPayload() PublishEventOption {
 	return func(e *pb.PublishEventRequest) {
 		if e.Metadata == nil {
@@ -99,13 +177,173 @@ func PublishEventWithRawPayload() PublishEventOption {
 	}
 }
 
+// PublishEventInNamespace can be passed as option to PublishEvent to publish
+// the event to the topic of the Dapr namespace ns, as named by
+// common.NamespacedTopic, instead of the namespace set with
+// WithPubsubNamespace. It has no effect if ns is empty.
+func PublishEventInNamespace(ns string) PublishEventOption {
+	return func(e *pb.PublishEventRequest) {
+		e.Topic = common.NamespacedTopic(ns, e.Topic)
+	}
+}
+
+// WithPublishTTL can be passed as option to PublishEvent to set the time after
+// which the message expires if it has not been consumed, using the ttlInSeconds
+// metadata. Durations are rounded up to the whole second; PublishEvent returns
+// an error if the duration is negative.
+func WithPublishTTL(ttl time.Duration) PublishEventOption {
+	return func(e *pb.PublishEventRequest) {
+		if e.Metadata == nil {
+			e.Metadata = map[string]string{}
+		}
+		if ttl < 0 {
+			// Rounding would turn durations above -1s into a valid TTL of 0.
+			e.Metadata[metadataKeyNegativeTTL] = ttl.String()
+			return
+		}
+		seconds := int64(ttl / time.Second)
+		if ttl%time.Second > 0 {
+			seconds++
+		}
+		delete(e.Metadata, metadataKeyNegativeTTL)
+		e.Metadata[metadataKeyTTLInSeconds] = strconv.FormatInt(seconds, 10)
+	}
+}
+
+// PublishEventWithGzip can be passed as option to PublishEvent to compress the
+// payload with gzip. The payload is published in a CloudEvent created by the
+// SDK, holding the compressed data and the contentencoding extension set to
+// gzip, so that subscribers using this SDK decompress it before invoking their
+// handlers. Payloads that are already gzipped are not compressed again, and
+// payloads that are CloudEvents already are published as is.
+func PublishEventWithGzip() PublishEventOption {
+	return func(e *pb.PublishEventRequest) {
+		if e.Metadata == nil {
+			e.Metadata = map[string]string{}
+		}
+		e.Metadata[metadataKeyGzip] = trueValue
+	}
+}
+
+// WithSchema can be passed as option to PublishEvent to serialize the event
+// value with a schema of the given type, such as SchemaTypeAvro, from a schema
+// registry. It sets the valueSchemaType metadata read by the Kafka pubsub
+// component, which looks up the latest version of the <topic>-value subject in
+// the schema registry configured on the component.
+// The Kafka component only applies the schema to raw payloads, so combine it
+// with PublishEventWithRawPayload, and PublishEventWithContentType if the
+// payload needs a content type.
+func WithSchema(schemaType string) PublishEventOption {
+	return func(e *pb.PublishEventRequest) {
+		if e.Metadata == nil {
+			e.Metadata = map[string]string{}
+		}
+		e.Metadata[metadataKeyValueSchemaType] = schemaType
+	}
+}
+
+// isRawPayload reports whether the rawPayload metadata is set to true.
+func isRawPayload(metadata map[string]string) bool {
+	raw, _ := strconv.ParseBool(metadata[rawPayload])
+	return raw
+}
+
+// withRawContentType returns a copy of the metadata of a raw payload with the
+// contentType metadata set to contentType, the datacontenttype the payload
+// can't carry without its CloudEvent envelope. The metadata is returned as is
+// if contentType is empty or the contentType metadata is already set.
+func withRawContentType(metadata map[string]string, contentType string) map[string]string {
+	if _, ok := metadata[metadataKeyContentType]; ok || contentType == "" {
+		return metadata
+	}
+	m := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		m[k] = v
+	}
+	m[metadataKeyContentType] = contentType
+	return m
+}
+
+// isGzipped reports whether data starts with the gzip magic number.
+func isGzipped(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// newGzippedEvent returns a structured-mode CloudEvent holding data, of the
+// given content type, compressed with gzip unless it is already.
+func newGzippedEvent(id, contentType string, data []byte) ([]byte, error) {
+	if !isGzipped(data) {
+		var err error
+		if data, err = gzipPayload(data); err != nil {
+			return nil, err
+		}
+	}
+	event := map[string]any{
+		"specversion":            "1.0",
+		"id":                     id,
+		"source":                 requestEventSource,
+		"type":                   eventSentType,
+		contentEncodingExtension: contentEncodingGzip,
+		"data_base64":            data,
+	}
+	if contentType != "" {
+		event["datacontenttype"] = contentType
+	}
+	return json.Marshal(event)
+}
+
+// withoutMetadata returns a copy of metadata without key.
+func withoutMetadata(metadata map[string]string, key string) map[string]string {
+	m := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if k != key {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+func gzipPayload(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// validatePublishTTL checks that WithPublishTTL wasn't given a negative
+// duration, and that the ttlInSeconds metadata, if set, is a non-negative
+// number of seconds.
+func validatePublishTTL(metadata map[string]string) error {
+	if ttl, ok := metadata[metadataKeyNegativeTTL]; ok {
+		return fmt.Errorf("publish TTL must not be negative: %s", ttl)
+	}
+	ttl, ok := metadata[metadataKeyTTLInSeconds]
+	if !ok {
+		return nil
+	}
+	seconds, err := strconv.ParseInt(ttl, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid publish TTL %q: %w", ttl, err)
+	}
+	if seconds < 0 {
+		return fmt.Errorf("publish TTL must not be negative: %ds", seconds)
+	}
+	return nil
+}
+
 // PublishEventfromCustomContent serializes an struct and publishes its contents as data (JSON) onto topic in specific pubsub component.
 // Deprecated: This method is deprecated and will be removed in a future version of the SDK. Please use `PublishEvent` instead.
 func (c *GRPCClient) PublishEventfromCustomContent(ctx context.Context, pubsubName, topicName string, data interface{}) error {
+	ctx = withSDKMethod(ctx, "PublishEventfromCustomContent")
 	log.Println("DEPRECATED: client.PublishEventfromCustomContent is deprecated and will be removed in a future version of the SDK. Please use `PublishEvent` instead.")
 
 	// Perform the JSON marshaling here just in case someone passed a []byte or string as data
-	enc, err := json.Marshal(data)
+	enc, err := jsonMarshal(data)
 	if err != nil {
 		return fmt.Errorf("error serializing input struct: %w", err)
 	}
@@ -134,62 +372,105 @@ type PublishEventsOption func(*pb.BulkPublishRequest)
 // If all events are successfully published, response Error will be nil.
 // The FailedEvents field will contain all events that failed to publish.
 func (c *GRPCClient) PublishEvents(ctx context.Context, pubsubName, topicName string, events []interface{}, opts ...PublishEventsOption) PublishEventsResponse {
+	ctx = withSDKMethod(ctx, "PublishEvents")
 	if pubsubName == "" {
 		return PublishEventsResponse{
-			Error:        errors.New("pubsubName name required"),
+			Error:        invalidArgument("PublishEvents", "pubsubName", reasonEmpty),
 			FailedEvents: events,
 		}
 	}
 	if topicName == "" {
 		return PublishEventsResponse{
-			Error:        errors.New("topic name required"),
+			Error:        invalidArgument("PublishEvents", "topicName", reasonEmpty),
 			FailedEvents: events,
 		}
 	}
 
-	failedEvents := make([]interface{}, 0, len(events))
-	eventMap := make(map[string]interface{}, len(events))
+	if err := c.checkPublishPreflight(ctx, pubsubName, topicName); err != nil {
+		return PublishEventsResponse{
+			Error:        err,
+			FailedEvents: events,
+		}
+	}
+
+	failed := make([]bool, len(events))
+	entryIndex := make(map[string]int, len(events))
 	entries := make([]*pb.BulkPublishRequestEntry, 0, len(events))
-	for _, event := range events {
-		entry, err := createBulkPublishRequestEntry(event)
+	for i, event := range events {
+		entry, err := createBulkPublishRequestEntry(event, c.ids())
 		if err != nil {
-			failedEvents = append(failedEvents, event)
+			failed[i] = true
 			continue
 		}
-		eventMap[entry.EntryId] = event
+		entryIndex[entry.EntryId] = i
 		entries = append(entries, entry)
 	}
 
-	request := &pb.BulkPublishRequest{
+	template := &pb.BulkPublishRequest{
 		PubsubName: pubsubName,
 		Topic:      topicName,
 		Entries:    entries,
 	}
 	for _, o := range opts {
-		o(request)
+		o(template)
+	}
+	if c.pubsubNamespace != "" && template.Topic == topicName {
+		template.Topic = common.NamespacedTopic(c.pubsubNamespace, topicName)
+	}
+	if isRawPayload(template.Metadata) {
+		for _, entry := range template.Entries {
+			entry.Metadata = withRawContentType(entry.Metadata, entry.ContentType)
+		}
 	}
 
-	res, err := c.protoClient.BulkPublishEventAlpha1(c.withAuthToken(ctx), request)
-	// If there is an error, all events failed to publish.
-	if err != nil {
-		return PublishEventsResponse{
-			Error:        fmt.Errorf("error publishing events unto %s topic: %w", topicName, err),
-			FailedEvents: events,
+	// Split the entries in sub-batches of at most the size set with
+	// WithMaxBatchSize, if any.
+	batches := [][]*pb.BulkPublishRequestEntry{template.Entries}
+	if size, ok := template.Metadata[metadataKeyMaxBatchSize]; ok {
+		template.Metadata = withoutMetadata(template.Metadata, metadataKeyMaxBatchSize)
+		if n, _ := strconv.Atoi(size); n > 0 && len(template.Entries) > n {
+			batches = splitBulkPublishEntries(template.Entries, n)
+		}
+	}
+	var publishErr error
+	for _, batch := range batches {
+		request := &pb.BulkPublishRequest{
+			PubsubName: template.PubsubName,
+			Topic:      template.Topic,
+			Entries:    batch,
+			Metadata:   template.Metadata,
+		}
+
+		res, err := c.protoClient.BulkPublishEventAlpha1(c.withAuthToken(ctx), request)
+		// If there is an error, all events in the batch failed to publish.
+		if err != nil {
+			publishErr = err
+			for _, entry := range batch {
+				failed[entryIndex[entry.EntryId]] = true
+			}
+		} else {
+			for _, failedEntry := range res.FailedEntries {
+				if i, ok := entryIndex[failedEntry.EntryId]; ok {
+					failed[i] = true
+				}
+			}
 		}
 	}
 
-	for _, failedEntry := range res.FailedEntries {
-		event, ok := eventMap[failedEntry.EntryId]
-		if !ok {
-			// This should never happen.
-			failedEvents = append(failedEvents, failedEntry.EntryId)
+	// Failed events are reported in the order they were passed in.
+	failedEvents := make([]interface{}, 0, len(events))
+	for i, event := range events {
+		if failed[i] {
+			failedEvents = append(failedEvents, event)
 		}
-		failedEvents = append(failedEvents, event)
 	}
 
 	if len(failedEvents) != 0 {
+		if publishErr == nil {
+			publishErr = fmt.Errorf("%d of %d events failed", len(failedEvents), len(events))
+		}
 		return PublishEventsResponse{
-			Error:        fmt.Errorf("error publishing events unto %s topic: %w", topicName, err),
+			Error:        fmt.Errorf("error publishing events unto %s topic: %w", topicName, publishErr),
 			FailedEvents: failedEvents,
 		}
 	}
@@ -200,8 +481,19 @@ func (c *GRPCClient) PublishEvents(ctx context.Context, pubsubName, topicName st
 	}
 }
 
+// splitBulkPublishEntries splits entries in batches of at most size entries.
+func splitBulkPublishEntries(entries []*pb.BulkPublishRequestEntry, size int) [][]*pb.BulkPublishRequestEntry {
+	batches := make([][]*pb.BulkPublishRequestEntry, 0, (len(entries)+size-1)/size)
+	for size < len(entries) {
+		batches = append(batches, entries[:size:size])
+		entries = entries[size:]
+	}
+	return append(batches, entries)
+}
+
 // createBulkPublishRequestEntry creates a BulkPublishRequestEntry from an interface{}.
-func createBulkPublishRequestEntry(data interface{}) (*pb.BulkPublishRequestEntry, error) {
+// Entries without an ID get one from idGen.
+func createBulkPublishRequestEntry(data interface{}, idGen ids.Generator) (*pb.BulkPublishRequestEntry, error) {
 	entry := &pb.BulkPublishRequestEntry{}
 
 	switch d := data.(type) {
@@ -219,7 +511,7 @@ func createBulkPublishRequestEntry(data interface{}) (*pb.BulkPublishRequestEntr
 	default:
 		var err error
 		entry.ContentType = "application/json"
-		entry.Event, err = json.Marshal(d)
+		entry.Event, err = jsonMarshal(d)
 		if err != nil {
 			return &pb.BulkPublishRequestEntry{}, fmt.Errorf("error serializing input struct: %w", err)
 		}
@@ -230,7 +522,7 @@ func createBulkPublishRequestEntry(data interface{}) (*pb.BulkPublishRequestEntr
 	}
 
 	if entry.EntryId == "" {
-		entry.EntryId = uuid.New().String()
+		entry.EntryId = idGen.NewID()
 	}
 
 	return entry, nil
@@ -245,6 +537,21 @@ func PublishEventsWithContentType(contentType string) PublishEventsOption {
 	}
 }
 
+// WithMaxBatchSize can be passed as option to PublishEvents to split the
+// events in sub-batches of at most n events, each sent in its own bulk publish
+// request. A non-positive value sends all events in a single request.
+func WithMaxBatchSize(n int) PublishEventsOption {
+	return func(r *pb.BulkPublishRequest) {
+		// Copy the metadata so the marker never leaks into a caller's map.
+		md := make(map[string]string, len(r.Metadata)+1)
+		for k, v := range r.Metadata {
+			md[k] = v
+		}
+		md[metadataKeyMaxBatchSize] = strconv.Itoa(n)
+		r.Metadata = md
+	}
+}
+
 // PublishEventsWithMetadata can be passed as option to PublishEvents to set request metadata.
 func PublishEventsWithMetadata(metadata map[string]string) PublishEventsOption {
 	return func(r *pb.BulkPublishRequest) {
@@ -252,7 +559,18 @@ func PublishEventsWithMetadata(metadata map[string]string) PublishEventsOption {
 	}
 }
 
+// PublishEventsInNamespace can be passed as option to PublishEvents to publish
+// the events to the topic of the Dapr namespace ns, as named by
+// common.NamespacedTopic, instead of the namespace set with
+// WithPubsubNamespace. It has no effect if ns is empty.
+func PublishEventsInNamespace(ns string) PublishEventsOption {
+	return func(r *pb.BulkPublishRequest) {
+		r.Topic = common.NamespacedTopic(ns, r.Topic)
+	}
+}
+
 // PublishEventsWithRawPayload can be passed as option to PublishEvents to set rawPayload request metadata.
+// The content type of each event is sent as its contentType metadata.
 func PublishEventsWithRawPayload() PublishEventsOption {
 	return func(r *pb.BulkPublishRequest) {
 		if r.Metadata == nil {
@@ -0,0 +1,213 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAsyncPublishWorkers and DefaultAsyncPublishQueueSize are the
+	// number of workers and the size of the queue of PublishEventAsync when
+	// WithAsyncPublishWorkers is not set.
+	DefaultAsyncPublishWorkers   = 4
+	DefaultAsyncPublishQueueSize = 100
+
+	// DefaultAsyncPublishDrainTimeout is the time Close waits for the pending
+	// publishes of PublishEventAsync when WithAsyncPublishDrainTimeout is not
+	// set.
+	DefaultAsyncPublishDrainTimeout = 5 * time.Second
+)
+
+var (
+	// ErrPublishQueueFull is returned by PublishEventAsync when the queue of
+	// pending publishes is full.
+	ErrPublishQueueFull = errors.New("publish queue is full")
+
+	// ErrClientClosed is returned by PublishEventAsync after Close, and by
+	// the calls made after GracefulClose.
+	ErrClientClosed = errors.New("client is closed")
+)
+
+// PublishEventAsync queues data to be published onto the topic by a worker of
+// the client, and returns without waiting for the sidecar. cb, which may be
+// nil, is called by the worker with the result of the publish.
+//
+// The publish keeps the values of ctx, such as its metadata, but is not
+// cancelled with it, so that it outlives the request that queued it. data
+// must not be modified until cb is called.
+//
+// PublishEventAsync returns ErrPublishQueueFull instead of blocking when the
+// queue is full. Close waits for the pending publishes up to the drain
+// timeout, then cancels the ones left, which call cb with the error.
+func (c *GRPCClient) PublishEventAsync(ctx context.Context, pubsubName, topicName string, data interface{}, cb func(error), opts ...PublishEventOption) error {
+	if pubsubName == "" {
+		return invalidArgument("PublishEventAsync", "pubsubName", reasonEmpty)
+	}
+	if topicName == "" {
+		return invalidArgument("PublishEventAsync", "topicName", reasonEmpty)
+	}
+	return c.asyncPublisher.enqueue(&asyncPublish{
+		ctx:        ctx,
+		pubsubName: pubsubName,
+		topicName:  topicName,
+		data:       data,
+		cb:         cb,
+		opts:       opts,
+	}, c.PublishEvent)
+}
+
+// asyncPublish is a publish queued by PublishEventAsync.
+type asyncPublish struct {
+	ctx        context.Context
+	pubsubName string
+	topicName  string
+	data       interface{}
+	cb         func(error)
+	opts       []PublishEventOption
+}
+
+type publishFunc func(ctx context.Context, pubsubName, topicName string, data interface{}, opts ...PublishEventOption) error
+
+// asyncPublisher is the bounded worker pool of PublishEventAsync. The workers
+// are started by the first publish.
+type asyncPublisher struct {
+	workers      int
+	queueSize    int
+	drainTimeout time.Duration
+
+	lock    sync.RWMutex
+	started bool
+	closed  bool
+	queue   chan *asyncPublish
+	stop    context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+func newAsyncPublisher(workers, queueSize int, drainTimeout time.Duration) *asyncPublisher {
+	if workers <= 0 {
+		workers = DefaultAsyncPublishWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultAsyncPublishQueueSize
+	}
+	if drainTimeout <= 0 {
+		drainTimeout = DefaultAsyncPublishDrainTimeout
+	}
+	return &asyncPublisher{workers: workers, queueSize: queueSize, drainTimeout: drainTimeout}
+}
+
+func (p *asyncPublisher) enqueue(job *asyncPublish, publish publishFunc) error {
+	if !p.start(publish) {
+		return ErrClientClosed
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.closed {
+		return ErrClientClosed
+	}
+	select {
+	case p.queue <- job:
+		return nil
+	default:
+		return ErrPublishQueueFull
+	}
+}
+
+// start starts the workers unless they are already started, and returns
+// false if the publisher is closed.
+func (p *asyncPublisher) start(publish publishFunc) bool {
+	p.lock.RLock()
+	started, closed := p.started, p.closed
+	p.lock.RUnlock()
+	if started || closed {
+		return !closed
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return false
+	}
+	if p.started {
+		return true
+	}
+	p.started = true
+	p.queue = make(chan *asyncPublish, p.queueSize)
+	p.stop, p.cancel = context.WithCancel(context.Background())
+	p.running.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go p.work(publish)
+	}
+	return true
+}
+
+func (p *asyncPublisher) work(publish publishFunc) {
+	defer p.running.Done()
+	for job := range p.queue {
+		err := publish(detachedContext{Context: p.stop, values: job.ctx}, job.pubsubName, job.topicName, job.data, job.opts...)
+		if job.cb != nil {
+			job.cb(err)
+		}
+	}
+}
+
+// close rejects new publishes and waits for the pending ones up to the drain
+// timeout, after which it cancels the ones left and waits for their callbacks.
+func (p *asyncPublisher) close() {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	if p.closed || !p.started {
+		p.closed = true
+		p.lock.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.queue)
+	p.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(p.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		logger.Printf("cancelling pending publishes after waiting %s", p.drainTimeout)
+		p.cancel()
+		<-done
+	}
+	p.cancel()
+}
+
+// detachedContext has the values of the context of a queued publish, and the
+// cancellation of the publisher.
+type detachedContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.values.Value(key)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// blockingPublishDaprServer fails the events published onto the "fail" topic
+// and holds the other publishes until release is closed.
+type blockingPublishDaprServer struct {
+	testDaprServer
+	release chan struct{}
+
+	lock      sync.Mutex
+	published []string
+	metadata  []string
+}
+
+func (s *blockingPublishDaprServer) PublishEvent(ctx context.Context, req *pb.PublishEventRequest) (*empty.Empty, error) {
+	if req.Topic == "fail" {
+		return nil, status.Error(codes.Internal, "publish failed")
+	}
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.published = append(s.published, string(req.Data))
+	s.metadata = append(s.metadata, md.Get("request-id")...)
+	return &empty.Empty{}, nil
+}
+
+func newBlockingPublishDaprServer() *blockingPublishDaprServer {
+	return &blockingPublishDaprServer{
+		testDaprServer: testDaprServer{state: make(map[string][]byte)},
+		release:        make(chan struct{}),
+	}
+}
+
+func TestPublishEventAsync(t *testing.T) {
+	t.Run("callbacks", func(t *testing.T) {
+		srv := newBlockingPublishDaprServer()
+		close(srv.release)
+		c, closer := getTestClientWithServer(context.Background(), srv)
+		defer closer()
+		defer c.Close()
+
+		// The publish outlives the context that queued it, and keeps its values.
+		ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(), "request-id", "42"))
+		results := make(chan error, 2)
+		require.NoError(t, c.(AsyncPublisher).PublishEventAsync(ctx, "messages", "test", "ok", func(err error) { results <- err }))
+		cancel()
+		require.NoError(t, <-results)
+
+		require.NoError(t, c.(AsyncPublisher).PublishEventAsync(context.Background(), "messages", "fail", "ko", func(err error) { results <- err }))
+		err := <-results
+		assert.Equal(t, codes.Internal, status.Code(err))
+
+		assert.Equal(t, []string{"ok"}, srv.published)
+		assert.Equal(t, []string{"42"}, srv.metadata)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		assert.Error(t, testClient.(AsyncPublisher).PublishEventAsync(context.Background(), "", "test", "data", nil))
+		assert.Error(t, testClient.(AsyncPublisher).PublishEventAsync(context.Background(), "messages", "", "data", nil))
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		srv := newBlockingPublishDaprServer()
+		c, closer := getTestClientWithServer(context.Background(), srv, WithAsyncPublishWorkers(1, 2))
+		defer closer()
+
+		var wg sync.WaitGroup
+		cb := func(err error) {
+			assert.NoError(t, err)
+			wg.Done()
+		}
+		// One publish held by the worker, two in the queue.
+		wg.Add(1)
+		require.NoError(t, c.(AsyncPublisher).PublishEventAsync(context.Background(), "messages", "test", "1", cb))
+		require.Eventually(t, func() bool {
+			return len(c.(*GRPCClient).asyncPublisher.queue) == 0
+		}, time.Second, 5*time.Millisecond)
+		wg.Add(2)
+		require.NoError(t, c.(AsyncPublisher).PublishEventAsync(context.Background(), "messages", "test", "2", cb))
+		require.NoError(t, c.(AsyncPublisher).PublishEventAsync(context.Background(), "messages", "test", "3", cb))
+		assert.ErrorIs(t, c.(AsyncPublisher).PublishEventAsync(context.Background(), "messages", "test", "4", cb), ErrPublishQueueFull)
+
+		close(srv.release)
+		wg.Wait()
+		c.Close()
+		assert.Equal(t, []string{"1", "2", "3"}, srv.published)
+		assert.ErrorIs(t, c.(AsyncPublisher).PublishEventAsync(context.Background(), "messages", "test", "5", cb), ErrClientClosed)
+	})
+
+	t.Run("drain on close", func(t *testing.T) {
+		srv := newBlockingPublishDaprServer()
+		c, closer := getTestClientWithServer(context.Background(), srv, WithAsyncPublishWorkers(2, 10))
+		defer closer()
+
+		results := make(chan error, 3)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.(AsyncPublisher).PublishEventAsync(context.Background(), "messages", "test", "data", func(err error) { results <- err }))
+		}
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			close(srv.release)
+		}()
+		c.Close()
+		require.Len(t, results, 3)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, <-results)
+		}
+	})
+
+	t.Run("cancel on close", func(t *testing.T) {
+		srv := newBlockingPublishDaprServer()
+		defer close(srv.release)
+		c, closer := getTestClientWithServer(context.Background(), srv,
+			WithAsyncPublishWorkers(1, 10), WithAsyncPublishDrainTimeout(50*time.Millisecond))
+		defer closer()
+
+		results := make(chan error, 3)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.(AsyncPublisher).PublishEventAsync(context.Background(), "messages", "test", "data", func(err error) { results <- err }))
+		}
+		start := time.Now()
+		c.Close()
+		assert.Less(t, time.Since(start), time.Second)
+		require.Len(t, results, 3)
+		for i := 0; i < 3; i++ {
+			assert.Error(t, <-results)
+		}
+		assert.Empty(t, srv.published)
+	})
+}
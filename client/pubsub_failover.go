@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultFailoverCooldown is the time a FailoverPublisher publishes to the
+// secondary pub/sub after the primary failed, before trying it again.
+const DefaultFailoverCooldown = 30 * time.Second
+
+// FailoverPublisher publishes events to a primary pub/sub component, failing
+// over to a secondary one when the primary fails.
+type FailoverPublisher struct {
+	client    Client
+	primary   string
+	secondary string
+	health    func(error) bool
+	cooldown  time.Duration
+	now       func() time.Time
+
+	lock          sync.Mutex
+	failedOverAt  time.Time
+	primaryFailed bool
+}
+
+// FailoverPublisherOption configures a FailoverPublisher.
+type FailoverPublisherOption func(*FailoverPublisher)
+
+// WithFailoverCooldown sets the time the publisher publishes to the secondary
+// pub/sub after the primary failed, before trying it again. The default is
+// DefaultFailoverCooldown.
+func WithFailoverCooldown(d time.Duration) FailoverPublisherOption {
+	return func(p *FailoverPublisher) {
+		if d > 0 {
+			p.cooldown = d
+		}
+	}
+}
+
+// NewFailoverPublisher creates a publisher publishing to the primary pub/sub,
+// and to the secondary one when publishing to the primary fails with an
+// error for which health returns true, meaning that the component rather
+// than the event is at fault. A nil health defaults to IsComponentError.
+func NewFailoverPublisher(c Client, primary, secondary string, health func(error) bool, opts ...FailoverPublisherOption) *FailoverPublisher {
+	if health == nil {
+		health = IsComponentError
+	}
+	p := &FailoverPublisher{
+		client:    c,
+		primary:   primary,
+		secondary: secondary,
+		health:    health,
+		cooldown:  DefaultFailoverCooldown,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// IsComponentError reports whether err, returned by PublishEvent, is an error
+// of the pub/sub component or of the sidecar, such as codes.Unavailable,
+// rather than of the event.
+func IsComponentError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Internal, codes.DeadlineExceeded, codes.Aborted, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// Publish publishes data to topicName on the primary pub/sub, or on the
+// secondary one while the primary is cooling down after a failure. When
+// publishing to the primary fails with a component error, the event is
+// published to the secondary one and the primary cools down.
+func (p *FailoverPublisher) Publish(ctx context.Context, topicName string, data interface{}, opts ...PublishEventOption) error {
+	if p.usePrimary() {
+		err := p.client.PublishEvent(ctx, p.primary, topicName, data, opts...)
+		if err == nil || !p.health(err) {
+			return err
+		}
+		p.failOver()
+		if err2 := p.client.PublishEvent(ctx, p.secondary, topicName, data, opts...); err2 != nil {
+			return fmt.Errorf("error publishing to %s after %s failed with %v: %w", p.secondary, p.primary, err, err2)
+		}
+		return nil
+	}
+	return p.client.PublishEvent(ctx, p.secondary, topicName, data, opts...)
+}
+
+// Active returns the name of the pub/sub the events are published to.
+func (p *FailoverPublisher) Active() string {
+	if p.usePrimary() {
+		return p.primary
+	}
+	return p.secondary
+}
+
+// usePrimary reports whether the primary pub/sub isn't cooling down.
+func (p *FailoverPublisher) usePrimary() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return !p.primaryFailed || p.now().Sub(p.failedOverAt) >= p.cooldown
+}
+
+func (p *FailoverPublisher) failOver() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.primaryFailed = true
+	p.failedOverAt = p.now()
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// failingPubsubDaprServer fails the events published to the pub/subs set in
+// failures with their code, and records the pub/subs of the others.
+type failingPubsubDaprServer struct {
+	testDaprServer
+	lock      sync.Mutex
+	failures  map[string]codes.Code
+	published []string
+}
+
+func (s *failingPubsubDaprServer) PublishEvent(ctx context.Context, req *pb.PublishEventRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if code, ok := s.failures[req.PubsubName]; ok {
+		return nil, status.Error(code, "publish failed")
+	}
+	s.published = append(s.published, req.PubsubName)
+	return &empty.Empty{}, nil
+}
+
+func (s *failingPubsubDaprServer) setFailure(pubsubName string, code codes.Code) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if code == codes.OK {
+		delete(s.failures, pubsubName)
+		return
+	}
+	s.failures[pubsubName] = code
+}
+
+func TestFailoverPublisher(t *testing.T) {
+	ctx := context.Background()
+	srv := &failingPubsubDaprServer{failures: map[string]codes.Code{}}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	now := time.Unix(1700000000, 0)
+	p := NewFailoverPublisher(c, "primary", "backup", nil, WithFailoverCooldown(time.Minute))
+	p.now = func() time.Time { return now }
+
+	require.NoError(t, p.Publish(ctx, "orders", []byte("1")))
+	assert.Equal(t, []string{"primary"}, srv.published)
+
+	// Payload errors are not failed over.
+	srv.setFailure("primary", codes.InvalidArgument)
+	err := p.Publish(ctx, "orders", []byte("2"))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "primary", p.Active())
+
+	// Component errors are failed over, and the primary cools down.
+	srv.setFailure("primary", codes.Unavailable)
+	require.NoError(t, p.Publish(ctx, "orders", []byte("3")))
+	assert.Equal(t, "backup", p.Active())
+	srv.setFailure("primary", codes.OK)
+	now = now.Add(30 * time.Second)
+	require.NoError(t, p.Publish(ctx, "orders", []byte("4")))
+	assert.Equal(t, []string{"primary", "backup", "backup"}, srv.published)
+
+	// The primary is tried again after the cooldown.
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, "primary", p.Active())
+	require.NoError(t, p.Publish(ctx, "orders", []byte("5")))
+	assert.Equal(t, []string{"primary", "backup", "backup", "primary"}, srv.published)
+
+	// Both failing returns the error of the secondary.
+	srv.setFailure("primary", codes.Internal)
+	srv.setFailure("backup", codes.Unavailable)
+	err = p.Publish(ctx, "orders", []byte("6"))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Contains(t, err.Error(), "after primary failed")
+}
+
+func TestFailoverPublisherHealthPredicate(t *testing.T) {
+	ctx := context.Background()
+	srv := &failingPubsubDaprServer{failures: map[string]codes.Code{"primary": codes.Unavailable}}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	p := NewFailoverPublisher(c, "primary", "backup", func(error) bool { return false })
+	err := p.Publish(ctx, "orders", []byte("1"))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Empty(t, srv.published)
+}
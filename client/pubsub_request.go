@@ -0,0 +1,243 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dapr/go-sdk/ids"
+	"github.com/dapr/go-sdk/service/common"
+)
+
+const (
+	// CorrelationIDExtension is the CloudEvent extension attribute matching a
+	// reply published over pub/sub with its request.
+	CorrelationIDExtension = "correlationid"
+	// ReplyTopicExtension is the CloudEvent extension attribute holding the
+	// topic to publish the reply to a request to.
+	ReplyTopicExtension = "replytopic"
+
+	// DefaultRequestTimeout is the time a PubsubRequester waits for a reply.
+	DefaultRequestTimeout = 30 * time.Second
+	// DefaultReplyTopicPrefix is the prefix of the reply topics of the
+	// PubsubRequesters.
+	DefaultReplyTopicPrefix = "replies"
+
+	requestEventSource = "dapr-go-sdk"
+	requestEventType   = "com.dapr.request"
+	replyEventType     = "com.dapr.reply"
+)
+
+// RequesterOptions are the options of a PubsubRequester.
+type RequesterOptions struct {
+	// ReplyTopicPrefix is the prefix of the reply topic of the requester,
+	// DefaultReplyTopicPrefix if empty. The topic is the prefix followed by a
+	// dot and a unique ID, so that the replies reach the requester they are
+	// for.
+	ReplyTopicPrefix string
+	// Timeout is the time a request waits for its reply, DefaultRequestTimeout
+	// if zero.
+	Timeout time.Duration
+}
+
+// PubsubRequester implements request/reply over pub/sub: it publishes
+// requests to a topic with a correlation ID and its own reply topic, as the
+// CorrelationIDExtension and ReplyTopicExtension CloudEvent extensions, and
+// waits for the reply published by a handler wrapped with ReplyingHandler.
+type PubsubRequester struct {
+	client       Client
+	service      common.Service
+	pubsub       string
+	requestTopic string
+	replyTopic   string
+	timeout      time.Duration
+	ids          ids.Generator
+
+	register    sync.Once
+	registerErr error
+
+	lock    sync.Mutex
+	pending map[string]chan *common.TopicEvent
+}
+
+// NewPubsubRequester creates a requester publishing its requests to
+// requestTopic of pubsub with c, and receiving their replies with a handler of
+// its reply topic registered on svc.
+func NewPubsubRequester(c Client, svc common.Service, pubsub, requestTopic string, opts RequesterOptions) *PubsubRequester {
+	gen := ids.UUID()
+	if gc, ok := c.(*GRPCClient); ok {
+		gen = gc.ids()
+	}
+	prefix := opts.ReplyTopicPrefix
+	if prefix == "" {
+		prefix = DefaultReplyTopicPrefix
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	return &PubsubRequester{
+		client:       c,
+		service:      svc,
+		pubsub:       pubsub,
+		requestTopic: requestTopic,
+		replyTopic:   prefix + "." + gen.NewID(),
+		timeout:      timeout,
+		ids:          gen,
+		pending:      make(map[string]chan *common.TopicEvent),
+	}
+}
+
+// ReplyTopic returns the topic the requester receives its replies on.
+func (r *PubsubRequester) ReplyTopic() string {
+	return r.replyTopic
+}
+
+// Register registers the handler of the reply topic on the service, once. The
+// first Request registers it otherwise, but the runtime only discovers the
+// subscriptions of the service when it starts: call Register before starting
+// the service.
+func (r *PubsubRequester) Register() error {
+	r.register.Do(func() {
+		r.registerErr = r.service.AddTopicEventHandler(&common.Subscription{
+			PubsubName: r.pubsub,
+			Topic:      r.replyTopic,
+			Route:      "/" + r.replyTopic,
+		}, r.handleReply)
+	})
+	return r.registerErr
+}
+
+// Request publishes data as a request and returns its reply. The data is
+// serialized as JSON, unless it is a []byte, which is sent as is. It returns
+// an error wrapping context.DeadlineExceeded if no reply arrives in time.
+func (r *PubsubRequester) Request(ctx context.Context, data any) (*common.TopicEvent, error) {
+	if err := r.Register(); err != nil {
+		return nil, fmt.Errorf("error registering the handler of reply topic %s: %w", r.replyTopic, err)
+	}
+	id := r.ids.NewID()
+	event, err := newCorrelatedEvent(id, requestEventType, data, map[string]string{
+		CorrelationIDExtension: id,
+		ReplyTopicExtension:    r.replyTopic,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := make(chan *common.TopicEvent, 1)
+	r.lock.Lock()
+	r.pending[id] = reply
+	r.lock.Unlock()
+	defer func() {
+		r.lock.Lock()
+		delete(r.pending, id)
+		r.lock.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	if err := r.client.PublishEvent(ctx, r.pubsub, r.requestTopic, event, PublishEventWithContentType(cloudEventContentType)); err != nil {
+		return nil, fmt.Errorf("error publishing request %s: %w", id, err)
+	}
+	select {
+	case e := <-reply:
+		return e, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("error waiting for the reply to request %s: %w", id, ctx.Err())
+	}
+}
+
+// handleReply passes a reply to the request waiting for it. The replies
+// without a waiting request, such as the ones arriving after the request timed
+// out, are logged and dropped.
+func (r *PubsubRequester) handleReply(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+	id, _ := e.Extensions[CorrelationIDExtension].(string)
+	r.lock.Lock()
+	reply, ok := r.pending[id]
+	delete(r.pending, id)
+	r.lock.Unlock()
+	if !ok {
+		common.LoggerFromContext(ctx).Printf("dropping orphan reply %s with correlation ID %q on topic %s", e.ID, id, e.Topic)
+		return false, nil
+	}
+	reply <- e
+	return false, nil
+}
+
+// RequestHandler handles a request published by a PubsubRequester, returning
+// the data of its reply.
+type RequestHandler func(ctx context.Context, e *common.TopicEvent) (reply any, err error)
+
+// ReplyingHandler wraps fn into a topic event handler publishing the reply
+// returned by fn to the reply topic of the request on pubsub, with its
+// correlation ID. If fn or publishing the reply fails, the request is retried.
+// Events that are not requests, without reply topic, are handled by fn
+// without reply.
+func ReplyingHandler(c Client, pubsub string, fn RequestHandler) common.TopicEventHandler {
+	return func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		reply, err := fn(ctx, e)
+		if err != nil {
+			return true, err
+		}
+		topic, _ := e.Extensions[ReplyTopicExtension].(string)
+		id, _ := e.Extensions[CorrelationIDExtension].(string)
+		if topic == "" {
+			common.LoggerFromContext(ctx).Printf("not replying to event %s of topic %s without reply topic", e.ID, e.Topic)
+			return false, nil
+		}
+		event, err := newCorrelatedEvent(id, replyEventType, reply, map[string]string{CorrelationIDExtension: id})
+		if err != nil {
+			return false, err
+		}
+		if err := c.PublishEvent(ctx, pubsub, topic, event, PublishEventWithContentType(cloudEventContentType)); err != nil {
+			return true, fmt.Errorf("error publishing reply %s to topic %s: %w", id, topic, err)
+		}
+		return false, nil
+	}
+}
+
+const cloudEventContentType = "application/cloudevents+json"
+
+// newCorrelatedEvent returns a structured-mode CloudEvent with the given
+// extensions, whose data is data serialized as JSON, or data as is if it is a
+// []byte, which the runtime publishes without wrapping it in a new CloudEvent.
+func newCorrelatedEvent(id, eventType string, data any, extensions map[string]string) ([]byte, error) {
+	event := map[string]any{
+		"specversion": "1.0",
+		"id":          id,
+		"source":      requestEventSource,
+		"type":        eventType,
+	}
+	for k, v := range extensions {
+		event[k] = v
+	}
+	switch d := data.(type) {
+	case nil:
+	case []byte:
+		event["datacontenttype"] = "application/octet-stream"
+		event["data_base64"] = d
+	default:
+		b, err := jsonMarshal(d)
+		if err != nil {
+			return nil, fmt.Errorf("error serializing event data: %w", err)
+		}
+		event["datacontenttype"] = "application/json"
+		event["data"] = json.RawMessage(b)
+	}
+	return json.Marshal(event)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// topicHandlerService is a common.Service only registering topic event
+// handlers.
+type topicHandlerService struct {
+	common.Service
+	lock     sync.Mutex
+	handlers map[string]common.TopicEventHandler
+}
+
+func (s *topicHandlerService) AddTopicEventHandler(sub *common.Subscription, fn common.TopicEventHandler) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.handlers[sub.Topic]; ok {
+		return fmt.Errorf("topic %s already has a handler", sub.Topic)
+	}
+	s.handlers[sub.Topic] = fn
+	return nil
+}
+
+func (s *topicHandlerService) handler(topic string) common.TopicEventHandler {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.handlers[topic]
+}
+
+// loopbackDaprServer delivers the structured CloudEvents published on it to
+// the handlers of svc, asynchronously like a broker.
+type loopbackDaprServer struct {
+	testDaprServer
+	svc        *topicHandlerService
+	deliveries sync.WaitGroup
+	lock       sync.Mutex
+	results    map[string][]error
+}
+
+func (s *loopbackDaprServer) PublishEvent(ctx context.Context, req *pb.PublishEventRequest) (*empty.Empty, error) {
+	fn := s.svc.handler(req.Topic)
+	if fn == nil {
+		return nil, fmt.Errorf("no subscriber for topic %s", req.Topic)
+	}
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(req.Data, &attrs); err != nil {
+		return nil, err
+	}
+	e := &common.TopicEvent{Topic: req.Topic, PubsubName: req.PubsubName, RawData: attrs["data"], Extensions: map[string]interface{}{}}
+	_ = json.Unmarshal(attrs["id"], &e.ID)
+	for _, k := range []string{CorrelationIDExtension, ReplyTopicExtension} {
+		var v string
+		if json.Unmarshal(attrs[k], &v) == nil {
+			e.Extensions[k] = v
+		}
+	}
+	s.deliveries.Add(1)
+	go func() {
+		defer s.deliveries.Done()
+		_, err := fn(context.Background(), e)
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.results[req.Topic] = append(s.results[req.Topic], err)
+	}()
+	return &empty.Empty{}, nil
+}
+
+type doubleRequest struct {
+	N int `json:"n"`
+}
+
+func TestPubsubRequester(t *testing.T) {
+	ctx := context.Background()
+	svc := &topicHandlerService{handlers: map[string]common.TopicEventHandler{}}
+	srv := &loopbackDaprServer{svc: svc, results: map[string][]error{}}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	var delay time.Duration
+	require.NoError(t, svc.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "double", Route: "/double"},
+		ReplyingHandler(c, "messages", func(ctx context.Context, e *common.TopicEvent) (any, error) {
+			var req doubleRequest
+			if err := e.Struct(&req); err != nil {
+				return nil, err
+			}
+			time.Sleep(delay)
+			return doubleRequest{N: 2 * req.N}, nil
+		})))
+
+	r := NewPubsubRequester(c, svc, "messages", "double", RequesterOptions{ReplyTopicPrefix: "doubles", Timeout: 100 * time.Millisecond})
+	require.NoError(t, r.Register())
+	assert.Regexp(t, `^doubles\.`, r.ReplyTopic())
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				e, err := r.Request(ctx, doubleRequest{N: n})
+				if !assert.NoError(t, err) {
+					return
+				}
+				var reply doubleRequest
+				assert.NoError(t, e.Struct(&reply))
+				assert.Equal(t, 2*n, reply.N)
+			}(i)
+		}
+		wg.Wait()
+	})
+
+	t.Run("timeout and late reply", func(t *testing.T) {
+		srv.deliveries.Wait()
+		delay = 200 * time.Millisecond
+		defer func() { delay = 0 }()
+
+		_, err := r.Request(ctx, doubleRequest{N: 1})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// The late reply is dropped as an orphan.
+		srv.deliveries.Wait()
+		srv.lock.Lock()
+		defer srv.lock.Unlock()
+		replies := srv.results[r.ReplyTopic()]
+		assert.Len(t, replies, 11)
+		for _, err := range replies {
+			assert.NoError(t, err)
+		}
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		assert.Empty(t, r.pending)
+	})
+
+	t.Run("responder failure is retried", func(t *testing.T) {
+		h := ReplyingHandler(c, "messages", func(ctx context.Context, e *common.TopicEvent) (any, error) {
+			return nil, errors.New("failed")
+		})
+		retry, err := h(ctx, &common.TopicEvent{Extensions: map[string]interface{}{ReplyTopicExtension: r.ReplyTopic()}})
+		assert.True(t, retry)
+		assert.Error(t, err)
+	})
+
+	t.Run("event without reply topic", func(t *testing.T) {
+		h := ReplyingHandler(c, "messages", func(ctx context.Context, e *common.TopicEvent) (any, error) {
+			return "ignored", nil
+		})
+		retry, err := h(ctx, &common.TopicEvent{ID: "1", Topic: "double"})
+		assert.False(t, retry)
+		assert.NoError(t, err)
+	})
+}
@@ -14,11 +14,24 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/ids"
 )
 
 type _testCustomContentwithText struct {
@@ -203,6 +216,303 @@ func TestPublishEvents(t *testing.T) {
 	})
 }
 
+// batchRecordingDaprServer records the size and entry IDs of each bulk publish request.
+type batchRecordingDaprServer struct {
+	testDaprServer
+	lock       sync.Mutex
+	batchSizes []int
+	entryIDs   []string
+	metadata   []map[string]string
+}
+
+func (s *batchRecordingDaprServer) BulkPublishEventAlpha1(ctx context.Context, req *pb.BulkPublishRequest) (*pb.BulkPublishResponse, error) {
+	s.lock.Lock()
+	s.batchSizes = append(s.batchSizes, len(req.Entries))
+	s.metadata = append(s.metadata, req.Metadata)
+	for _, e := range req.Entries {
+		s.entryIDs = append(s.entryIDs, e.EntryId)
+	}
+	s.lock.Unlock()
+	return s.testDaprServer.BulkPublishEventAlpha1(ctx, req)
+}
+
+func TestPublishEventsWithMaxBatchSize(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("250 events with max 100 are split in three batches", func(t *testing.T) {
+		srv := &batchRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		events := make([]interface{}, 250)
+		for i := range events {
+			events[i] = fmt.Sprintf("event-%d", i)
+		}
+		res := c.PublishEvents(ctx, "messages", "test", events,
+			PublishEventsWithMetadata(map[string]string{"partitionKey": "a"}), WithMaxBatchSize(100))
+		assert.Nil(t, res.Error)
+		assert.Len(t, res.FailedEvents, 0)
+		assert.Equal(t, []int{100, 100, 50}, srv.batchSizes)
+		for _, md := range srv.metadata {
+			assert.Equal(t, map[string]string{"partitionKey": "a"}, md)
+		}
+	})
+
+	t.Run("failed entries are aggregated in original order", func(t *testing.T) {
+		srv := &batchRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		events := []interface{}{"fail-0", "ok-1", "ok-2", "fail-3", "failall-4", "ok-5"}
+		res := c.PublishEvents(ctx, "messages", "test", events, WithMaxBatchSize(2))
+		assert.Error(t, res.Error)
+		assert.Equal(t, []interface{}{"fail-0", "fail-3", "failall-4", "ok-5"}, res.FailedEvents)
+		assert.Equal(t, []int{2, 2, 2}, srv.batchSizes)
+	})
+
+	t.Run("batch size larger than events", func(t *testing.T) {
+		srv := &batchRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		res := c.PublishEvents(ctx, "messages", "test", []interface{}{"a", "b"}, WithMaxBatchSize(10))
+		assert.Nil(t, res.Error)
+		assert.Equal(t, []int{2}, srv.batchSizes)
+	})
+}
+
+func TestPublishEventsWithIDGenerator(t *testing.T) {
+	ctx := context.Background()
+	srv := &batchRecordingDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv, WithIDGenerator(ids.NewSequence("event-")))
+	defer closer()
+
+	res := c.PublishEvents(ctx, "messages", "test", []interface{}{
+		"a",
+		PublishEventsEvent{EntryID: "explicit", Data: []byte("b"), ContentType: "text/plain"},
+		"c",
+	})
+	assert.Nil(t, res.Error)
+	assert.Equal(t, []string{"event-1", "explicit", "event-2"}, srv.entryIDs)
+}
+
+// publishRecordingDaprServer records the metadata of each publish request.
+type publishRecordingDaprServer struct {
+	testDaprServer
+	lock         sync.Mutex
+	metadata     []map[string]string
+	contentTypes []string
+	data         [][]byte
+	topics       []string
+}
+
+func (s *publishRecordingDaprServer) PublishEvent(ctx context.Context, req *pb.PublishEventRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	s.metadata = append(s.metadata, req.Metadata)
+	s.contentTypes = append(s.contentTypes, req.DataContentType)
+	s.data = append(s.data, req.Data)
+	s.topics = append(s.topics, req.Topic)
+	s.lock.Unlock()
+	return s.testDaprServer.PublishEvent(ctx, req)
+}
+
+func TestPublishEventWithTTL(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("TTL is sent as ttlInSeconds metadata", func(t *testing.T) {
+		srv := &publishRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		err := c.PublishEvent(ctx, "messages", "test", []byte("ping"),
+			PublishEventWithRawPayload(), WithPublishTTL(90*time.Second))
+		require.NoError(t, err)
+		err = c.PublishEvent(ctx, "messages", "test", []byte("ping"), WithPublishTTL(1500*time.Millisecond))
+		require.NoError(t, err)
+
+		require.Len(t, srv.metadata, 2)
+		assert.Equal(t, map[string]string{"rawPayload": "true", "ttlInSeconds": "90"}, srv.metadata[0])
+		assert.Equal(t, map[string]string{"ttlInSeconds": "2"}, srv.metadata[1])
+	})
+
+	t.Run("negative TTL is rejected", func(t *testing.T) {
+		srv := &publishRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		err := c.PublishEvent(ctx, "messages", "test", []byte("ping"), WithPublishTTL(-time.Second))
+		require.Error(t, err)
+		err = c.PublishEvent(ctx, "messages", "test", []byte("ping"), WithPublishTTL(-500*time.Millisecond))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "-500ms")
+		assert.Empty(t, srv.metadata)
+	})
+}
+
+func TestPublishRawPayloadContentType(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("raw publish carries the content type", func(t *testing.T) {
+		srv := &publishRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		meta := map[string]string{"partitionKey": "a"}
+		err := c.PublishEvent(ctx, "messages", "test", []byte{0xca, 0xfe},
+			PublishEventWithMetadata(meta),
+			PublishEventWithRawPayload(),
+			PublishEventWithContentType("application/vnd.acme.order"))
+		require.NoError(t, err)
+		err = c.PublishEvent(ctx, "messages", "test", []byte{0xca, 0xfe},
+			PublishEventWithContentType("application/vnd.acme.order"))
+		require.NoError(t, err)
+
+		require.Len(t, srv.metadata, 2)
+		assert.Equal(t, map[string]string{
+			"partitionKey": "a",
+			"rawPayload":   "true",
+			"contentType":  "application/vnd.acme.order",
+		}, srv.metadata[0])
+		assert.Empty(t, srv.metadata[1])
+		assert.Equal(t, []string{"application/vnd.acme.order", "application/vnd.acme.order"}, srv.contentTypes)
+	})
+
+	t.Run("explicit contentType metadata is kept", func(t *testing.T) {
+		srv := &publishRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		err := c.PublishEvent(ctx, "messages", "test", []byte{0xca, 0xfe},
+			PublishEventWithMetadata(map[string]string{"contentType": "application/octet-stream"}),
+			PublishEventWithRawPayload(),
+			PublishEventWithContentType("application/vnd.acme.order"))
+		require.NoError(t, err)
+		require.Len(t, srv.metadata, 1)
+		assert.Equal(t, "application/octet-stream", srv.metadata[0]["contentType"])
+	})
+
+	t.Run("raw bulk publish carries the content types", func(t *testing.T) {
+		srv := &bulkEntryRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		res := c.PublishEvents(ctx, "messages", "test", []interface{}{
+			PublishEventsEvent{EntryID: "1", Data: []byte{0xca, 0xfe}, ContentType: "application/vnd.acme.order"},
+			"pong",
+		}, PublishEventsWithRawPayload())
+		require.NoError(t, res.Error)
+		require.Len(t, srv.entries, 2)
+		assert.Equal(t, map[string]string{"contentType": "application/vnd.acme.order"}, srv.entries[0].Metadata)
+		assert.Equal(t, map[string]string{"contentType": "text/plain"}, srv.entries[1].Metadata)
+	})
+}
+
+// bulkEntryRecordingDaprServer records the entries of bulk publish requests.
+type bulkEntryRecordingDaprServer struct {
+	testDaprServer
+	entries []*pb.BulkPublishRequestEntry
+	topics  []string
+}
+
+func (s *bulkEntryRecordingDaprServer) BulkPublishEventAlpha1(ctx context.Context, req *pb.BulkPublishRequest) (*pb.BulkPublishResponse, error) {
+	s.entries = append(s.entries, req.Entries...)
+	s.topics = append(s.topics, req.Topic)
+	return &pb.BulkPublishResponse{}, nil
+}
+
+func TestPublishEventNamespace(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("without namespace", func(t *testing.T) {
+		srv := &publishRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		require.NoError(t, c.PublishEvent(ctx, "messages", "orders", "ping"))
+		require.NoError(t, c.PublishEvent(ctx, "messages", "orders", "ping", PublishEventInNamespace("team-b")))
+		assert.Equal(t, []string{"orders", "team-borders"}, srv.topics)
+	})
+
+	t.Run("client namespace", func(t *testing.T) {
+		srv := &publishRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv, WithPubsubNamespace("team-a"))
+		defer closer()
+
+		require.NoError(t, c.PublishEvent(ctx, "messages", "orders", "ping",
+			PublishEventWithMetadata(map[string]string{"partitionKey": "a"})))
+		// The explicit namespace is preferred.
+		require.NoError(t, c.PublishEvent(ctx, "messages", "orders", "ping", PublishEventInNamespace("team-b")))
+		require.NoError(t, c.PublishEvent(ctx, "messages", "orders", "ping", PublishEventInNamespace("")))
+		assert.Equal(t, []string{"team-aorders", "team-borders", "team-aorders"}, srv.topics)
+		assert.Equal(t, map[string]string{"partitionKey": "a"}, srv.metadata[0])
+	})
+
+	t.Run("bulk publish", func(t *testing.T) {
+		srv := &bulkEntryRecordingDaprServer{}
+		c, closer := getTestClientWithServer(ctx, srv, WithPubsubNamespace("team-a"))
+		defer closer()
+
+		require.NoError(t, c.PublishEvents(ctx, "messages", "orders", []interface{}{"ping"}).Error)
+		require.NoError(t, c.PublishEvents(ctx, "messages", "orders", []interface{}{"ping"}, PublishEventsInNamespace("team-b")).Error)
+		assert.Equal(t, []string{"team-aorders", "team-borders"}, srv.topics)
+	})
+}
+
+func TestPublishEventWithSchema(t *testing.T) {
+	ctx := context.Background()
+	srv := &publishRecordingDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	err := c.PublishEvent(ctx, "messages", "orders", []byte(`{"id":1}`), WithSchema(SchemaTypeAvro))
+	require.NoError(t, err)
+	err = c.PublishEvent(ctx, "messages", "orders", []byte(`{"id":1}`),
+		PublishEventWithMetadata(map[string]string{"partitionKey": "a"}),
+		PublishEventWithRawPayload(),
+		PublishEventWithContentType("application/json"),
+		WithSchema(SchemaTypeAvro))
+	require.NoError(t, err)
+
+	require.Len(t, srv.metadata, 2)
+	assert.Equal(t, map[string]string{
+		"valueSchemaType": "Avro",
+	}, srv.metadata[0])
+	assert.Equal(t, map[string]string{
+		"partitionKey":    "a",
+		"valueSchemaType": "Avro",
+		"rawPayload":      "true",
+		"contentType":     "application/json",
+	}, srv.metadata[1])
+	assert.Equal(t, []string{"", "application/json"}, srv.contentTypes)
+}
+
+func TestPublishEventWithGzip(t *testing.T) {
+	ctx := context.Background()
+	srv := &publishRecordingDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv, WithIDGenerator(ids.NewSequence("event-")))
+	defer closer()
+
+	meta := map[string]string{"partitionKey": "a"}
+	err := c.PublishEvent(ctx, "messages", "orders", map[string]string{"message": "hello"},
+		PublishEventWithMetadata(meta), PublishEventWithGzip())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"partitionKey": "a"}, srv.metadata[0])
+	assert.Equal(t, cloudEventContentType, srv.contentTypes[0])
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(srv.data[0], &event))
+	assert.Equal(t, "event-1", event["id"])
+	assert.Equal(t, "gzip", event["contentencoding"])
+	assert.Equal(t, "application/json", event["datacontenttype"])
+	compressed, err := base64.StdEncoding.DecodeString(event["data_base64"].(string))
+	require.NoError(t, err)
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"message":"hello"}`, string(data))
+}
+
 func TestCreateBulkPublishRequestEntry(t *testing.T) {
 	type _testJSONStruct struct {
 		Key1 string `json:"key1"`
@@ -273,7 +583,7 @@ func TestCreateBulkPublishRequestEntry(t *testing.T) {
 
 		for _, tc := range testcases {
 			t.Run(tc.name, func(t *testing.T) {
-				entry, err := createBulkPublishRequestEntry(tc.data)
+				entry, err := createBulkPublishRequestEntry(tc.data, ids.UUID())
 				if tc.expectedError {
 					assert.Error(t, err)
 				} else {
@@ -291,7 +601,7 @@ func TestCreateBulkPublishRequestEntry(t *testing.T) {
 			Data:        []byte("ping"),
 			EntryID:     "123",
 			Metadata:    map[string]string{"key": "value"},
-		})
+		}, ids.UUID())
 		assert.Nil(t, err)
 		assert.Equal(t, "123", entry.EntryId)
 		assert.Equal(t, map[string]string{"key": "value"}, entry.Metadata)
@@ -317,7 +627,7 @@ func TestCreateBulkPublishRequestEntry(t *testing.T) {
 
 		for _, tc := range testcases {
 			t.Run(tc.name, func(t *testing.T) {
-				entry, err := createBulkPublishRequestEntry(tc.data)
+				entry, err := createBulkPublishRequestEntry(tc.data, ids.UUID())
 				assert.Nil(t, err)
 				assert.NotEmpty(t, entry.EntryId)
 				assert.Nil(t, entry.Metadata)
@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// The headers with which the sidecar reports the outcome of the resiliency
+// policies applied to a call. Sidecars that don't report it send none of them.
+const (
+	// ResiliencyPolicyHeader is the name of the resiliency policy applied.
+	ResiliencyPolicyHeader = "dapr-resiliency-policy"
+	// ResiliencyRetriesHeader is the number of times the call was retried.
+	ResiliencyRetriesHeader = "dapr-resiliency-retries"
+	// ResiliencyCircuitBreakerHeader is the state of the circuit breaker of
+	// the target: closed, open or half-open.
+	ResiliencyCircuitBreakerHeader = "dapr-resiliency-circuit-breaker"
+	// ResiliencyTimeoutHeader is "true" if the timeout of the policy expired.
+	ResiliencyTimeoutHeader = "dapr-resiliency-timeout"
+)
+
+// Circuit breaker states reported in the ResiliencyCircuitBreakerHeader.
+const (
+	CircuitBreakerClosed   = "closed"
+	CircuitBreakerOpen     = "open"
+	CircuitBreakerHalfOpen = "half-open"
+)
+
+// ResilienceInfo is the outcome of the resiliency policies the sidecar applied
+// to a call.
+type ResilienceInfo struct {
+	// Policy is the name of the resiliency policy, if reported.
+	Policy string
+	// Retries is the number of times the sidecar retried the call.
+	Retries int
+	// CircuitBreakerState is the state of the circuit breaker of the target,
+	// one of the CircuitBreaker* constants, empty if not reported.
+	CircuitBreakerState string
+	// TimedOut is true if the timeout of the policy expired.
+	TimedOut bool
+}
+
+// Retried reports whether the sidecar retried the call.
+func (r *ResilienceInfo) Retried() bool {
+	return r != nil && r.Retries > 0
+}
+
+// BreakerOpen reports whether the circuit breaker of the target is open, such
+// as when the call tripped it or was rejected by it.
+func (r *ResilienceInfo) BreakerOpen() bool {
+	return r != nil && r.CircuitBreakerState == CircuitBreakerOpen
+}
+
+// parseResilienceInfo returns the outcome of the resiliency policies reported
+// in the headers and trailers of a response, or nil if none is. Invalid values
+// are ignored.
+func parseResilienceInfo(mds ...metadata.MD) *ResilienceInfo {
+	var info *ResilienceInfo
+	get := func(key string) string {
+		for _, md := range mds {
+			if v := md.Get(key); len(v) > 0 {
+				if info == nil {
+					info = &ResilienceInfo{}
+				}
+				return strings.TrimSpace(v[0])
+			}
+		}
+		return ""
+	}
+	policy := get(ResiliencyPolicyHeader)
+	retries := get(ResiliencyRetriesHeader)
+	breaker := strings.ToLower(get(ResiliencyCircuitBreakerHeader))
+	timeout := get(ResiliencyTimeoutHeader)
+	if info == nil {
+		return nil
+	}
+	info.Policy = policy
+	if n, err := strconv.Atoi(retries); err == nil && n >= 0 {
+		info.Retries = n
+	}
+	switch breaker {
+	case CircuitBreakerClosed, CircuitBreakerOpen, CircuitBreakerHalfOpen:
+		info.CircuitBreakerState = breaker
+	}
+	info.TimedOut, _ = strconv.ParseBool(timeout)
+	return info
+}
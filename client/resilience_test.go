@@ -0,0 +1,92 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// resilienceDaprServer reports the outcome of resiliency policies depending
+// on the invoked method.
+type resilienceDaprServer struct {
+	testDaprServer
+}
+
+func (s *resilienceDaprServer) InvokeService(ctx context.Context, req *pb.InvokeServiceRequest) (*commonv1pb.InvokeResponse, error) {
+	switch req.GetMessage().GetMethod() {
+	case "retried":
+		if err := grpc.SetHeader(ctx, metadata.Pairs(
+			ResiliencyPolicyHeader, "orders-policy",
+			ResiliencyRetriesHeader, "2",
+			ResiliencyCircuitBreakerHeader, "Closed",
+		)); err != nil {
+			return nil, err
+		}
+	case "tripped":
+		if err := grpc.SetTrailer(ctx, metadata.Pairs(
+			ResiliencyCircuitBreakerHeader, CircuitBreakerOpen,
+			ResiliencyTimeoutHeader, "true",
+			ResiliencyRetriesHeader, "invalid",
+		)); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.Unavailable, "circuit breaker is open")
+	}
+	return s.testDaprServer.InvokeService(ctx, req)
+}
+
+func TestInvokeResilienceInfo(t *testing.T) {
+	ctx := context.Background()
+	c, closer := getTestClientWithServer(ctx, &resilienceDaprServer{})
+	defer closer()
+
+	t.Run("retried call", func(t *testing.T) {
+		resp, err := c.(ResponseInvoker).InvokeMethodWithResponse(ctx, "app", "retried", "post", &DataContent{ContentType: "text/plain", Data: []byte("ping")})
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(resp.Data))
+		assert.Equal(t, "text/plain", resp.ContentType)
+		assert.Equal(t, &ResilienceInfo{Policy: "orders-policy", Retries: 2, CircuitBreakerState: CircuitBreakerClosed}, resp.Resilience)
+		assert.True(t, resp.Resilience.Retried())
+		assert.False(t, resp.Resilience.BreakerOpen())
+	})
+
+	t.Run("tripped breaker", func(t *testing.T) {
+		_, err := c.(ResponseInvoker).InvokeMethodWithResponse(ctx, "app", "tripped", "post", nil)
+		var ie *InvocationError
+		require.True(t, errors.As(err, &ie))
+		assert.Equal(t, codes.Unavailable, ie.Code())
+		assert.Equal(t, &ResilienceInfo{CircuitBreakerState: CircuitBreakerOpen, TimedOut: true}, ie.Resilience())
+		assert.True(t, ie.Resilience().BreakerOpen())
+		assert.False(t, ie.Resilience().Retried())
+	})
+
+	t.Run("no resiliency headers", func(t *testing.T) {
+		resp, err := c.(ResponseInvoker).InvokeMethodWithResponse(ctx, "app", "plain", "get", nil)
+		require.NoError(t, err)
+		assert.Nil(t, resp.Resilience)
+		assert.False(t, resp.Resilience.Retried())
+	})
+}
@@ -17,23 +17,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 // GetSecret retrieves preconfigured secret from specified store using key.
 func (c *GRPCClient) GetSecret(ctx context.Context, storeName, key string, meta map[string]string) (data map[string]string, err error) {
-	if storeName == "" {
-		return nil, errors.New("empty storeName")
-	}
-	if key == "" {
-		return nil, errors.New("empty key")
-	}
 
 	req := &pb.GetSecretRequest{
 		Key:       key,
 		StoreName: storeName,
-		Metadata:  meta,
+		Metadata:  c.withStoreDefaults(storeName, meta),
 	}
 
 	resp, err := c.protoClient.GetSecret(c.withAuthToken(ctx), req)
@@ -48,15 +43,35 @@ func (c *GRPCClient) GetSecret(ctx context.Context, storeName, key string, meta
 	return
 }
 
+// GetSecretInto retrieves the secret name from the specified store and writes
+// its value to target, without copying it, which matters for large secrets
+// such as certificate chains. The value is the entry of the secret keyed by
+// its name, or the only entry of single-value secrets.
+func (c *GRPCClient) GetSecretInto(ctx context.Context, storeName, name string, target io.Writer) error {
+	ctx = withSDKMethod(ctx, "GetSecretInto")
+	if target == nil {
+		return errors.New("nil target")
+	}
+	data, err := c.GetSecret(ctx, storeName, name, nil)
+	if err != nil {
+		return err
+	}
+	value, err := secretValue(name, data)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(target, value); err != nil {
+		return fmt.Errorf("error writing secret %s: %w", name, err)
+	}
+	return nil
+}
+
 // GetBulkSecret retrieves all preconfigured secrets for this application.
 func (c *GRPCClient) GetBulkSecret(ctx context.Context, storeName string, meta map[string]string) (data map[string]map[string]string, err error) {
-	if storeName == "" {
-		return nil, errors.New("empty storeName")
-	}
 
 	req := &pb.GetBulkSecretRequest{
 		StoreName: storeName,
-		Metadata:  meta,
+		Metadata:  c.withStoreDefaults(storeName, meta),
 	}
 
 	resp, err := c.protoClient.GetBulkSecret(c.withAuthToken(ctx), req)
@@ -78,3 +93,40 @@ func (c *GRPCClient) GetBulkSecret(ctx context.Context, storeName string, meta m
 
 	return
 }
+
+// GetBulkSecretPartial retrieves the named secrets from the specified store one by one,
+// returning the secrets that could be read along with a per-secret error map for those
+// that could not (e.g. because they are not allowed by the secret scope policy).
+// Unlike GetBulkSecret, a single inaccessible secret does not fail the whole call.
+// If no keys are given, all secrets are retrieved with GetBulkSecret.
+func (c *GRPCClient) GetBulkSecretPartial(ctx context.Context, storeName string, keys []string, meta map[string]string) (data map[string]map[string]string, errs map[string]error, err error) {
+	ctx = withSDKMethod(ctx, "GetBulkSecretPartial")
+	if storeName == "" {
+		return nil, nil, invalidArgument("GetBulkSecretPartial", "storeName", reasonEmpty)
+	}
+	if len(keys) == 0 {
+		data, err = c.GetBulkSecret(ctx, storeName, meta)
+		return data, nil, err
+	}
+
+	for _, key := range keys {
+		if key == "" {
+			return nil, nil, invalidArgument("GetBulkSecretPartial", "key", reasonEmpty)
+		}
+	}
+
+	data = make(map[string]map[string]string, len(keys))
+	for _, key := range keys {
+		secret, err := c.GetSecret(ctx, storeName, key, meta)
+		if err != nil {
+			if errs == nil {
+				errs = map[string]error{}
+			}
+			errs[key] = err
+			continue
+		}
+		data[key] = secret
+	}
+
+	return data, errs, nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+const (
+	// DefaultSecretPageSize is the number of secrets requested per page by
+	// BulkSecretIterator when WithSecretPageSize is not set.
+	DefaultSecretPageSize = 100
+
+	// SecretPageSizeKey and SecretPageTokenKey are the metadata keys with the
+	// page size and page token sent by BulkSecretIterator.
+	SecretPageSizeKey  = "pageSize"
+	SecretPageTokenKey = "pageToken"
+
+	// SecretNextPageTokenKey is the name of the entry with which a paging
+	// secret store returns the token of the next page. The token is the value
+	// of the SecretNextPageTokenKey key of the entry.
+	SecretNextPageTokenKey = "__nextPageToken"
+)
+
+// SecretIterator iterates over the secrets of a store.
+type SecretIterator interface {
+	// Next advances to the next secret, fetching the next page when needed.
+	// It returns false at the end of the secrets, on error, or once the
+	// context of the iterator is done.
+	Next() bool
+	// Secret returns the name and values of the current secret.
+	Secret() (name string, values map[string]string)
+	// Err returns the error that stopped the iteration, if any.
+	Err() error
+}
+
+// BulkSecretIterator returns an iterator over the secrets of storeName that
+// fetches them lazily, one page at a time.
+// Each page is requested with GetBulkSecret, with the page size and the token
+// of the page in the pageSize and pageToken metadata; stores supporting paging
+// return the token of the next page in the SecretNextPageTokenKey entry.
+// Stores without paging return all secrets in the first page, which can be
+// received when larger than the default gRPC limit by setting
+// WithMaxBulkSecretMessageSize.
+func (c *GRPCClient) BulkSecretIterator(ctx context.Context, storeName string, meta map[string]string) (SecretIterator, error) {
+	if storeName == "" {
+		return nil, invalidArgument("BulkSecretIterator", "storeName", reasonEmpty)
+	}
+	pageSize := c.secretPageSize
+	if pageSize <= 0 {
+		pageSize = DefaultSecretPageSize
+	}
+	return &secretIterator{
+		ctx:       ctx,
+		client:    c,
+		storeName: storeName,
+		meta:      meta,
+		pageSize:  pageSize,
+		hasMore:   true,
+	}, nil
+}
+
+type secretIterator struct {
+	ctx       context.Context
+	client    *GRPCClient
+	storeName string
+	meta      map[string]string
+	pageSize  int
+
+	page      map[string]map[string]string
+	names     []string
+	pos       int
+	nextToken string
+	hasMore   bool
+	err       error
+}
+
+func (it *secretIterator) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		if it.pos < len(it.names) {
+			it.pos++
+			return true
+		}
+		if !it.hasMore {
+			return false
+		}
+		it.err = it.fetch()
+	}
+}
+
+func (it *secretIterator) Secret() (string, map[string]string) {
+	if it.pos == 0 || it.pos > len(it.names) {
+		return "", nil
+	}
+	name := it.names[it.pos-1]
+	return name, it.page[name]
+}
+
+func (it *secretIterator) Err() error {
+	return it.err
+}
+
+// fetch retrieves the next page of secrets.
+func (it *secretIterator) fetch() error {
+	meta := make(map[string]string, len(it.meta)+2)
+	for k, v := range it.meta {
+		meta[k] = v
+	}
+	meta[SecretPageSizeKey] = strconv.Itoa(it.pageSize)
+	if it.nextToken != "" {
+		meta[SecretPageTokenKey] = it.nextToken
+	}
+
+	var opts []grpc.CallOption
+	if it.client.maxBulkSecretMsgSize > 0 {
+		opts = append(opts, grpc.MaxCallRecvMsgSize(it.client.maxBulkSecretMsgSize))
+	}
+	req := &pb.GetBulkSecretRequest{
+		StoreName: it.storeName,
+		Metadata:  it.client.withStoreDefaults(it.storeName, meta),
+	}
+	resp, err := it.client.protoClient.GetBulkSecret(it.client.withAuthToken(it.ctx), req, opts...)
+	if err != nil {
+		return fmt.Errorf("error invoking service: %w", err)
+	}
+
+	token := it.nextToken
+	it.page = make(map[string]map[string]string, len(resp.GetData()))
+	it.nextToken = ""
+	for name, secret := range resp.GetData() {
+		if name == SecretNextPageTokenKey {
+			it.nextToken = secret.GetSecrets()[SecretNextPageTokenKey]
+			continue
+		}
+		it.page[name] = secret.GetSecrets()
+	}
+	it.names = make([]string, 0, len(it.page))
+	for name := range it.page {
+		it.names = append(it.names, name)
+	}
+	sort.Strings(it.names)
+	it.pos = 0
+	if token != "" && it.nextToken == token {
+		return fmt.Errorf("secret store %s returned the same page token twice", it.storeName)
+	}
+	it.hasMore = it.nextToken != ""
+	return nil
+}
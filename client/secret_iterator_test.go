@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// pagingSecretsDaprServer serves secrets in pages of the requested size when
+// paging is enabled, or all at once otherwise.
+type pagingSecretsDaprServer struct {
+	testDaprServer
+	secrets map[string]string
+	paging  bool
+
+	lock     sync.Mutex
+	requests []map[string]string
+}
+
+func (s *pagingSecretsDaprServer) GetBulkSecret(ctx context.Context, req *pb.GetBulkSecretRequest) (*pb.GetBulkSecretResponse, error) {
+	s.lock.Lock()
+	s.requests = append(s.requests, req.Metadata)
+	s.lock.Unlock()
+
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	start, end := 0, len(names)
+	if s.paging {
+		size, err := strconv.Atoi(req.Metadata[SecretPageSizeKey])
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page size")
+		}
+		if token := req.Metadata[SecretPageTokenKey]; token != "" {
+			start, _ = strconv.Atoi(token)
+		}
+		if end = start + size; end > len(names) {
+			end = len(names)
+		}
+	}
+
+	// Names are sorted so that pages are stable across requests.
+	sort.Strings(names)
+	resp := &pb.GetBulkSecretResponse{Data: map[string]*pb.SecretResponse{}}
+	for _, name := range names[start:end] {
+		resp.Data[name] = &pb.SecretResponse{Secrets: map[string]string{name: s.secrets[name]}}
+	}
+	if end < len(names) {
+		resp.Data[SecretNextPageTokenKey] = &pb.SecretResponse{Secrets: map[string]string{SecretNextPageTokenKey: strconv.Itoa(end)}}
+	}
+	return resp, nil
+}
+
+func collectSecrets(t *testing.T, it SecretIterator) map[string]string {
+	t.Helper()
+
+	got := map[string]string{}
+	for it.Next() {
+		name, values := it.Secret()
+		got[name] = values[name]
+	}
+	return got
+}
+
+func TestBulkSecretIterator(t *testing.T) {
+	ctx := context.Background()
+	secrets := map[string]string{}
+	for i := 0; i < 7; i++ {
+		secrets[fmt.Sprintf("secret-%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+
+	t.Run("pages through the secrets", func(t *testing.T) {
+		srv := &pagingSecretsDaprServer{secrets: secrets, paging: true}
+		c, closer := getTestClientWithServer(ctx, srv, WithSecretPageSize(3))
+		defer closer()
+
+		it, err := c.(SecretReader).BulkSecretIterator(ctx, "vault", map[string]string{"namespace": "apps"})
+		require.NoError(t, err)
+		assert.Equal(t, secrets, collectSecrets(t, it))
+		require.NoError(t, it.Err())
+
+		require.Len(t, srv.requests, 3)
+		assert.Equal(t, map[string]string{"namespace": "apps", "pageSize": "3"}, srv.requests[0])
+		assert.Equal(t, map[string]string{"namespace": "apps", "pageSize": "3", "pageToken": "3"}, srv.requests[1])
+		assert.Equal(t, map[string]string{"namespace": "apps", "pageSize": "3", "pageToken": "6"}, srv.requests[2])
+	})
+
+	t.Run("secrets are fetched lazily", func(t *testing.T) {
+		srv := &pagingSecretsDaprServer{secrets: secrets, paging: true}
+		c, closer := getTestClientWithServer(ctx, srv, WithSecretPageSize(3))
+		defer closer()
+
+		it, err := c.(SecretReader).BulkSecretIterator(ctx, "vault", nil)
+		require.NoError(t, err)
+		assert.Empty(t, srv.requests)
+		require.True(t, it.Next())
+		name, values := it.Secret()
+		assert.Equal(t, "secret-0", name)
+		assert.Equal(t, map[string]string{"secret-0": "value-0"}, values)
+		assert.Len(t, srv.requests, 1)
+	})
+
+	t.Run("store without paging falls back to a single large response", func(t *testing.T) {
+		large := map[string]string{}
+		value := strings.Repeat("x", 64*1024)
+		for i := 0; i < 80; i++ {
+			large[fmt.Sprintf("secret-%02d", i)] = value
+		}
+		srv := &pagingSecretsDaprServer{secrets: large}
+
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+		it, err := c.(SecretReader).BulkSecretIterator(ctx, "vault", nil)
+		require.NoError(t, err)
+		assert.False(t, it.Next())
+		assert.Equal(t, codes.ResourceExhausted, status.Code(it.Err()))
+
+		c, closer = getTestClientWithServer(ctx, srv, WithMaxBulkSecretMessageSize(16*1024*1024))
+		defer closer()
+		it, err = c.(SecretReader).BulkSecretIterator(ctx, "vault", nil)
+		require.NoError(t, err)
+		assert.Equal(t, large, collectSecrets(t, it))
+		require.NoError(t, it.Err())
+	})
+
+	t.Run("stops on context cancellation", func(t *testing.T) {
+		srv := &pagingSecretsDaprServer{secrets: secrets, paging: true}
+		c, closer := getTestClientWithServer(ctx, srv, WithSecretPageSize(3))
+		defer closer()
+
+		cctx, cancel := context.WithCancel(ctx)
+		it, err := c.(SecretReader).BulkSecretIterator(cctx, "vault", nil)
+		require.NoError(t, err)
+		require.True(t, it.Next())
+		cancel()
+		assert.False(t, it.Next())
+		assert.ErrorIs(t, it.Err(), context.Canceled)
+		assert.Len(t, srv.requests, 1)
+	})
+
+	t.Run("without store", func(t *testing.T) {
+		_, err := testClient.(SecretReader).BulkSecretIterator(ctx, "", nil)
+		assert.Error(t, err)
+	})
+}
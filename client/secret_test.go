@@ -14,10 +14,16 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 // go test -timeout 30s ./client -count 1 -run ^TestGetSecret$
@@ -72,3 +78,93 @@ func TestGetBulkSecret(t *testing.T) {
 		assert.NotNil(t, out)
 	})
 }
+
+// partialSecretDaprServer denies access to secrets named "forbidden-*".
+type partialSecretDaprServer struct {
+	testDaprServer
+}
+
+func (s *partialSecretDaprServer) GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.GetSecretResponse, error) {
+	if strings.HasPrefix(req.Key, "forbidden-") {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied by policy to get %q from %q", req.Key, req.StoreName)
+	}
+	return &pb.GetSecretResponse{
+		Data: map[string]string{req.Key: "value-" + req.Key},
+	}, nil
+}
+
+func TestGetBulkSecretPartial(t *testing.T) {
+	ctx := context.Background()
+	c, closer := getTestClientWithServer(ctx, &partialSecretDaprServer{})
+	defer closer()
+
+	t.Run("without store", func(t *testing.T) {
+		_, _, err := c.(SecretReader).GetBulkSecretPartial(ctx, "", []string{"a"}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("with empty key", func(t *testing.T) {
+		_, _, err := c.(SecretReader).GetBulkSecretPartial(ctx, "store", []string{"a", ""}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("without keys", func(t *testing.T) {
+		out, errs, err := c.(SecretReader).GetBulkSecretPartial(ctx, "store", nil, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, errs)
+		assert.Contains(t, out, "test")
+	})
+
+	t.Run("mix of accessible and forbidden secrets", func(t *testing.T) {
+		out, errs, err := c.(SecretReader).GetBulkSecretPartial(ctx, "store", []string{"a", "forbidden-b", "c", "forbidden-d"}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"a": {"a": "value-a"},
+			"c": {"c": "value-c"},
+		}, out)
+		assert.Len(t, errs, 2)
+		assert.Equal(t, codes.PermissionDenied, status.Code(errs["forbidden-b"]))
+		assert.Equal(t, codes.PermissionDenied, status.Code(errs["forbidden-d"]))
+	})
+}
+
+// largeSecretDaprServer returns a secret of 1 MiB named "chain", and a
+// secret with two values named "multi".
+type largeSecretDaprServer struct {
+	testDaprServer
+}
+
+var largeSecret = strings.Repeat("-----BEGIN CERTIFICATE-----\n", 1<<20/28)
+
+func (s *largeSecretDaprServer) GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.GetSecretResponse, error) {
+	if req.Key == "multi" {
+		return &pb.GetSecretResponse{Data: map[string]string{"a": "1", "b": "2"}}, nil
+	}
+	return &pb.GetSecretResponse{Data: map[string]string{req.Key: largeSecret}}, nil
+}
+
+func TestGetSecretInto(t *testing.T) {
+	ctx := context.Background()
+	c, closer := getTestClientWithServer(ctx, &largeSecretDaprServer{})
+	defer closer()
+
+	t.Run("writes the secret value", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, c.(SecretReader).GetSecretInto(ctx, "store", "chain", &buf))
+		assert.Equal(t, largeSecret, buf.String())
+	})
+
+	t.Run("without store", func(t *testing.T) {
+		assert.Error(t, c.(SecretReader).GetSecretInto(ctx, "", "chain", &bytes.Buffer{}))
+	})
+
+	t.Run("nil target", func(t *testing.T) {
+		assert.Error(t, c.(SecretReader).GetSecretInto(ctx, "store", "chain", nil))
+	})
+
+	t.Run("ambiguous value", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Error(t, c.(SecretReader).GetSecretInto(ctx, "store", "multi", &buf))
+		assert.Zero(t, buf.Len())
+	})
+}
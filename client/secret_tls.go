@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// secretTag is the struct tag GetBulkSecretInto reads the secret name from.
+const secretTag = "secret"
+
+// LoadTLSFromSecrets builds a TLS configuration from PEM-encoded material
+// stored as secrets in storeName: certSecret holds the certificate chain and
+// keySecret the matching private key. If caSecret is not empty, the
+// certificates it holds are used as RootCAs.
+// Errors name the secret that could not be used and why.
+func LoadTLSFromSecrets(ctx context.Context, c Client, storeName, certSecret, keySecret, caSecret string) (*tls.Config, error) {
+	if c == nil {
+		return nil, errors.New("nil client")
+	}
+	if certSecret == "" || keySecret == "" {
+		return nil, errors.New("certificate and key secret names required")
+	}
+
+	certPEM, err := getSecretValue(ctx, c, storeName, certSecret)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkPEMBlocks(certSecret, certPEM, func(t string) bool { return t == "CERTIFICATE" }); err != nil {
+		return nil, err
+	}
+	keyPEM, err := getSecretValue(ctx, c, storeName, keySecret)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkPEMBlocks(keySecret, keyPEM, func(t string) bool { return strings.HasSuffix(t, "PRIVATE KEY") }); err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("secret %s doesn't match certificate in secret %s: %w", keySecret, certSecret, err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caSecret != "" {
+		caPEM, err := getSecretValue(ctx, c, storeName, caSecret)
+		if err != nil {
+			return nil, err
+		}
+		if err = checkPEMBlocks(caSecret, caPEM, func(t string) bool { return t == "CERTIFICATE" }); err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+			return nil, fmt.Errorf("secret %s: invalid CA certificate", caSecret)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// GetBulkSecretInto retrieves all the secrets of storeName and assigns them to
+// the fields of the struct pointed to by target that have a `secret:"<name>"`
+// tag. Fields must be of type string or []byte. A tagged secret missing from
+// the store is an error.
+func (c *GRPCClient) GetBulkSecretInto(ctx context.Context, storeName string, target any) error {
+	ctx = withSDKMethod(ctx, "GetBulkSecretInto")
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("target must be a non-nil pointer to a struct, got %T", target)
+	}
+	v = v.Elem()
+
+	secrets, err := c.GetBulkSecret(ctx, storeName, nil)
+	if err != nil {
+		return err
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := field.Tag.Lookup(secretTag)
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("field %s with secret %s is not exported", field.Name, name)
+		}
+		value, err := secretValue(name, secrets[name])
+		if err != nil {
+			return err
+		}
+		f := v.Field(i)
+		switch {
+		case f.Kind() == reflect.String:
+			f.SetString(value)
+		case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Uint8:
+			f.SetBytes([]byte(value))
+		default:
+			return fmt.Errorf("field %s with secret %s has unsupported type %s", field.Name, name, field.Type)
+		}
+	}
+	return nil
+}
+
+// getSecretValue retrieves the value of the secret name from storeName.
+func getSecretValue(ctx context.Context, c Client, storeName, name string) (string, error) {
+	data, err := c.GetSecret(ctx, storeName, name, nil)
+	if err != nil {
+		return "", fmt.Errorf("error getting secret %s: %w", name, err)
+	}
+	return secretValue(name, data)
+}
+
+// secretValue returns the value of the secret name from its data: the entry
+// keyed by the secret name, or the only entry of single-value secrets.
+func secretValue(name string, data map[string]string) (string, error) {
+	if v, ok := data[name]; ok {
+		return v, nil
+	}
+	if len(data) == 1 {
+		for _, v := range data {
+			return v, nil
+		}
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("secret %s not found", name)
+	}
+	return "", fmt.Errorf("secret %s has %d values and none is named %s", name, len(data), name)
+}
+
+// checkPEMBlocks verifies that data holds at least one PEM block and that all
+// its blocks are of a type accepted by valid.
+func checkPEMBlocks(name, data string, valid func(blockType string) bool) error {
+	rest := []byte(data)
+	var n int
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if !valid(block.Type) {
+			return fmt.Errorf("secret %s: unexpected PEM block type %q", name, block.Type)
+		}
+		n++
+	}
+	if n == 0 {
+		return fmt.Errorf("secret %s: no PEM data found", name)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+type secretsDaprServer struct {
+	testDaprServer
+	secrets map[string]map[string]string
+}
+
+func (s *secretsDaprServer) GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.GetSecretResponse, error) {
+	data, ok := s.secrets[req.Key]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "secret %s not found", req.Key)
+	}
+	return &pb.GetSecretResponse{Data: data}, nil
+}
+
+func (s *secretsDaprServer) GetBulkSecret(ctx context.Context, req *pb.GetBulkSecretRequest) (*pb.GetBulkSecretResponse, error) {
+	resp := &pb.GetBulkSecretResponse{Data: map[string]*pb.SecretResponse{}}
+	for name, data := range s.secrets {
+		resp.Data[name] = &pb.SecretResponse{Secrets: data}
+	}
+	return resp, nil
+}
+
+// generateTestKeyPair returns a PEM-encoded self-signed certificate and its private key.
+func generateTestKeyPair(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+func TestLoadTLSFromSecrets(t *testing.T) {
+	ctx := context.Background()
+	certPEM, keyPEM := generateTestKeyPair(t)
+	_, otherKeyPEM := generateTestKeyPair(t)
+	srv := &secretsDaprServer{secrets: map[string]map[string]string{
+		"cert":      {"cert": certPEM},
+		"key":       {"tls.key": keyPEM},
+		"ca":        {"ca": certPEM},
+		"other-key": {"other-key": otherKeyPEM},
+		"garbage":   {"garbage": "not pem"},
+	}}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	t.Run("valid pair with CA", func(t *testing.T) {
+		config, err := LoadTLSFromSecrets(ctx, c, "store", "cert", "key", "ca")
+		require.NoError(t, err)
+		assert.Len(t, config.Certificates, 1)
+		assert.NotNil(t, config.RootCAs)
+	})
+
+	t.Run("valid pair without CA", func(t *testing.T) {
+		config, err := LoadTLSFromSecrets(ctx, c, "store", "cert", "key", "")
+		require.NoError(t, err)
+		assert.Len(t, config.Certificates, 1)
+		assert.Nil(t, config.RootCAs)
+	})
+
+	t.Run("mismatched pair", func(t *testing.T) {
+		_, err := LoadTLSFromSecrets(ctx, c, "store", "cert", "other-key", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "secret other-key doesn't match certificate in secret cert")
+	})
+
+	t.Run("key in certificate secret", func(t *testing.T) {
+		_, err := LoadTLSFromSecrets(ctx, c, "store", "key", "key", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `secret key: unexpected PEM block type "EC PRIVATE KEY"`)
+	})
+
+	t.Run("certificate in key secret", func(t *testing.T) {
+		_, err := LoadTLSFromSecrets(ctx, c, "store", "cert", "ca", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `secret ca: unexpected PEM block type "CERTIFICATE"`)
+	})
+
+	t.Run("no PEM data", func(t *testing.T) {
+		_, err := LoadTLSFromSecrets(ctx, c, "store", "cert", "key", "garbage")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "secret garbage: no PEM data found")
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		_, err := LoadTLSFromSecrets(ctx, c, "store", "missing", "key", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error getting secret missing")
+	})
+}
+
+func TestGetBulkSecretInto(t *testing.T) {
+	ctx := context.Background()
+	srv := &secretsDaprServer{secrets: map[string]map[string]string{
+		"db-password": {"db-password": "s3cret"},
+		"api-token":   {"token": "abc"},
+	}}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	t.Run("tagged fields", func(t *testing.T) {
+		var target struct {
+			Password string `secret:"db-password"`
+			Token    []byte `secret:"api-token"`
+			Other    string
+		}
+		err := c.(SecretReader).GetBulkSecretInto(ctx, "store", &target)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", target.Password)
+		assert.Equal(t, []byte("abc"), target.Token)
+		assert.Empty(t, target.Other)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		var target struct {
+			Password string `secret:"missing"`
+		}
+		err := c.(SecretReader).GetBulkSecretInto(ctx, "store", &target)
+		assert.ErrorContains(t, err, "secret missing not found")
+	})
+
+	t.Run("unsupported field type", func(t *testing.T) {
+		var target struct {
+			Password int `secret:"db-password"`
+		}
+		err := c.(SecretReader).GetBulkSecretInto(ctx, "store", &target)
+		assert.ErrorContains(t, err, "unsupported type int")
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		var target struct{}
+		assert.Error(t, c.(SecretReader).GetBulkSecretInto(ctx, "store", target))
+		assert.Error(t, c.(SecretReader).GetBulkSecretInto(ctx, "store", nil))
+	})
+
+	t.Run("without store", func(t *testing.T) {
+		var target struct{}
+		assert.Error(t, c.(SecretReader).GetBulkSecretInto(ctx, "", &target))
+	})
+}
@@ -50,6 +50,10 @@ const (
 	UndefinedType = "undefined"
 )
 
+// ErrETagMismatch is returned when a state operation guarded by an ETag is
+// rejected because the stored value has changed since the ETag was read.
+var ErrETagMismatch = errors.New("etag mismatch")
+
 type (
 	// StateConsistency is the consistency enum type.
 	StateConsistency int
@@ -87,10 +91,10 @@ func (o OperationType) String() string {
 func (s StateConsistency) String() string {
 	names := [...]string{
 		UndefinedType,
-		"strong",
 		"eventual",
+		"strong",
 	}
-	if s < StateConsistencyStrong || s > StateConsistencyEventual {
+	if s < StateConsistencyEventual || s > StateConsistencyStrong {
 		return UndefinedType
 	}
 
@@ -111,6 +115,10 @@ func (s StateConcurrency) String() string {
 	return names[s]
 }
 
+// metadataKeyContentType is the state item metadata key with the content type
+// of the value.
+const metadataKeyContentType = "contentType"
+
 var stateOptionDefault = &v1.StateOptions{
 	Concurrency: v1.StateOptions_CONCURRENCY_LAST_WRITE,
 	Consistency: v1.StateOptions_CONSISTENCY_STRONG,
@@ -128,6 +136,28 @@ type StateItem struct {
 	Value    []byte
 	Etag     string
 	Metadata map[string]string
+	// TTLExpireTime is the time the item expires, read from the ttlExpireTime
+	// metadata, or nil if the store doesn't report it or the item has no TTL.
+	TTLExpireTime *time.Time
+}
+
+// metadataKeyTTLExpireTime is the metadata key of the expiration time of a
+// state item with a TTL, in RFC 3339 format.
+const metadataKeyTTLExpireTime = "ttlExpireTime"
+
+// ttlExpireTime returns the expiration time in md, or nil if it is absent or
+// invalid.
+func ttlExpireTime(md map[string]string) *time.Time {
+	v, ok := md[metadataKeyTTLExpireTime]
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		logger.Printf("ignoring invalid %s state metadata %q: %v", metadataKeyTTLExpireTime, v, err)
+		return nil
+	}
+	return &t
 }
 
 // BulkStateItem represents a single state item.
@@ -175,6 +205,8 @@ type ETag struct {
 type StateOptions struct {
 	Concurrency StateConcurrency
 	Consistency StateConsistency
+	// ContentType, when set, is sent as the contentType metadata of saved items.
+	ContentType string
 }
 
 // StateOption StateOptions's function type.
@@ -194,10 +226,27 @@ func WithConsistency(consistency StateConsistency) StateOption {
 	}
 }
 
+// WithStateContentType sets the content type of the saved value, so that
+// readers can tell how to interpret non-JSON values.
+func WithStateContentType(contentType string) StateOption {
+	return func(so *StateOptions) {
+		so.ContentType = contentType
+	}
+}
+
 func toProtoSaveStateItem(si *SetStateItem) (item *v1.StateItem) {
+	metadata := si.Metadata
+	if si.Options != nil && si.Options.ContentType != "" {
+		metadata = make(map[string]string, len(si.Metadata)+1)
+		for k, v := range si.Metadata {
+			metadata[k] = v
+		}
+		metadata[metadataKeyContentType] = si.Options.ContentType
+	}
+
 	s := &v1.StateItem{
 		Key:      si.Key,
-		Metadata: si.Metadata,
+		Metadata: metadata,
 		Value:    si.Value,
 		Options:  toProtoStateOptions(si.Options),
 	}
@@ -216,8 +265,8 @@ func toProtoStateOptions(so *StateOptions) (opts *v1.StateOptions) {
 		return copyStateOptionDefaultPB()
 	}
 	return &v1.StateOptions{
-		Concurrency: v1.StateOptions_StateConcurrency(so.Concurrency),
-		Consistency: v1.StateOptions_StateConsistency(so.Consistency),
+		Concurrency: so.Concurrency.GetPBConcurrency(),
+		Consistency: so.Consistency.GetPBConsistency(),
 	}
 }
 
@@ -246,13 +295,20 @@ func toProtoDuration(d time.Duration) *duration.Duration {
 }
 
 // ExecuteStateTransaction provides way to execute multiple operations on a specified store.
-func (c *GRPCClient) ExecuteStateTransaction(ctx context.Context, storeName string, meta map[string]string, ops []*StateOperation) error {
+func (c *GRPCClient) ExecuteStateTransaction(ctx context.Context, storeName string, meta map[string]string, ops []*StateOperation, opts ...StateTransactionOption) error {
+	o := &stateTransactionOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	if storeName == "" {
-		return errors.New("nil storeName")
+		return invalidArgument(sdkMethod(ctx, "ExecuteStateTransaction"), "storeName", reasonEmpty)
 	}
-	if len(ops) == 0 {
+	if len(ops) == 0 && !o.dryRun {
 		return nil
 	}
+	if err := validateStateOperations(ops); err != nil {
+		return err
+	}
 
 	items := make([]*pb.TransactionalStateOperation, 0)
 	for _, op := range ops {
@@ -264,10 +320,13 @@ func (c *GRPCClient) ExecuteStateTransaction(ctx context.Context, storeName stri
 	}
 
 	req := &pb.ExecuteStateTransactionRequest{
-		Metadata:   meta,
+		Metadata:   c.withStoreDefaults(storeName, meta),
 		StoreName:  storeName,
 		Operations: items,
 	}
+	if o.dryRun {
+		return o.dryRunTransaction(req)
+	}
 	_, err := c.protoClient.ExecuteStateTransaction(c.withAuthToken(ctx), req)
 	if err != nil {
 		return fmt.Errorf("error executing state transaction: %w", err)
@@ -277,11 +336,13 @@ func (c *GRPCClient) ExecuteStateTransaction(ctx context.Context, storeName stri
 
 // SaveState saves the raw data into store, default options: strong, last-write.
 func (c *GRPCClient) SaveState(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...StateOption) error {
+	ctx = withSDKMethod(ctx, "SaveState")
 	return c.SaveStateWithETag(ctx, storeName, key, data, "", meta, so...)
 }
 
 // SaveStateWithETag saves the raw data into store using provided state options and etag.
 func (c *GRPCClient) SaveStateWithETag(ctx context.Context, storeName, key string, data []byte, etag string, meta map[string]string, so ...StateOption) error {
+	ctx = withSDKMethod(ctx, "SaveStateWithETag")
 	stateOptions := new(StateOptions)
 	for _, o := range so {
 		o(stateOptions)
@@ -303,9 +364,7 @@ func (c *GRPCClient) SaveStateWithETag(ctx context.Context, storeName, key strin
 
 // SaveBulkState saves the multiple state item to store.
 func (c *GRPCClient) SaveBulkState(ctx context.Context, storeName string, items ...*SetStateItem) error {
-	if storeName == "" {
-		return errors.New("nil store")
-	}
+	ctx = withSDKMethod(ctx, "SaveBulkState")
 	if items == nil {
 		return errors.New("nil item")
 	}
@@ -317,6 +376,7 @@ func (c *GRPCClient) SaveBulkState(ctx context.Context, storeName string, items
 
 	for _, si := range items {
 		item := toProtoSaveStateItem(si)
+		item.Metadata = c.withStoreDefaults(storeName, item.Metadata)
 		req.States = append(req.States, item)
 	}
 
@@ -329,18 +389,12 @@ func (c *GRPCClient) SaveBulkState(ctx context.Context, storeName string, items
 
 // GetBulkState retrieves state for multiple keys from specific store.
 func (c *GRPCClient) GetBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string, parallelism int32) ([]*BulkStateItem, error) {
-	if storeName == "" {
-		return nil, errors.New("nil store")
-	}
-	if len(keys) == 0 {
-		return nil, errors.New("keys required")
-	}
 	items := make([]*BulkStateItem, 0)
 
 	req := &pb.GetBulkStateRequest{
 		StoreName:   storeName,
 		Keys:        keys,
-		Metadata:    meta,
+		Metadata:    c.withStoreDefaults(storeName, meta),
 		Parallelism: parallelism,
 	}
 
@@ -369,20 +423,18 @@ func (c *GRPCClient) GetBulkState(ctx context.Context, storeName string, keys []
 
 // GetState retrieves state from specific store using default consistency option.
 func (c *GRPCClient) GetState(ctx context.Context, storeName, key string, meta map[string]string) (item *StateItem, err error) {
+	ctx = withSDKMethod(ctx, "GetState")
 	return c.GetStateWithConsistency(ctx, storeName, key, meta, StateConsistencyStrong)
 }
 
 // GetStateWithConsistency retrieves state from specific store using provided state consistency.
 func (c *GRPCClient) GetStateWithConsistency(ctx context.Context, storeName, key string, meta map[string]string, sc StateConsistency) (*StateItem, error) {
-	if err := hasRequiredStateArgs(storeName, key); err != nil {
-		return nil, fmt.Errorf("missing required arguments: %w", err)
-	}
-
+	ctx = withSDKMethod(ctx, "GetStateWithConsistency")
 	req := &pb.GetStateRequest{
 		StoreName:   storeName,
 		Key:         key,
-		Consistency: v1.StateOptions_StateConsistency(sc),
-		Metadata:    meta,
+		Consistency: sc.GetPBConsistency(),
+		Metadata:    c.withStoreDefaults(storeName, meta),
 	}
 
 	result, err := c.protoClient.GetState(c.withAuthToken(ctx), req)
@@ -391,25 +443,20 @@ func (c *GRPCClient) GetStateWithConsistency(ctx context.Context, storeName, key
 	}
 
 	return &StateItem{
-		Etag:     result.Etag,
-		Key:      key,
-		Value:    result.Data,
-		Metadata: result.Metadata,
+		Etag:          result.Etag,
+		Key:           key,
+		Value:         result.Data,
+		Metadata:      result.Metadata,
+		TTLExpireTime: ttlExpireTime(result.Metadata),
 	}, nil
 }
 
 // QueryStateAlpha1 runs a query against state store.
 func (c *GRPCClient) QueryStateAlpha1(ctx context.Context, storeName, query string, meta map[string]string) (*QueryResponse, error) {
-	if storeName == "" {
-		return nil, errors.New("store name is not set")
-	}
-	if query == "" {
-		return nil, errors.New("query is not set")
-	}
 	req := &pb.QueryStateRequest{
 		StoreName: storeName,
 		Query:     query,
-		Metadata:  meta,
+		Metadata:  c.withStoreDefaults(storeName, meta),
 	}
 	resp, err := c.protoClient.QueryStateAlpha1(c.withAuthToken(ctx), req)
 	if err != nil {
@@ -433,20 +480,18 @@ func (c *GRPCClient) QueryStateAlpha1(ctx context.Context, storeName, query stri
 
 // DeleteState deletes content from store using default state options.
 func (c *GRPCClient) DeleteState(ctx context.Context, storeName, key string, meta map[string]string) error {
+	ctx = withSDKMethod(ctx, "DeleteState")
 	return c.DeleteStateWithETag(ctx, storeName, key, nil, meta, nil)
 }
 
 // DeleteStateWithETag deletes content from store using provided state options and etag.
 func (c *GRPCClient) DeleteStateWithETag(ctx context.Context, storeName, key string, etag *ETag, meta map[string]string, opts *StateOptions) error {
-	if err := hasRequiredStateArgs(storeName, key); err != nil {
-		return fmt.Errorf("missing required arguments: %w", err)
-	}
-
+	ctx = withSDKMethod(ctx, "DeleteStateWithETag")
 	req := &pb.DeleteStateRequest{
 		StoreName: storeName,
 		Key:       key,
 		Options:   toProtoStateOptions(opts),
-		Metadata:  meta,
+		Metadata:  c.withStoreDefaults(storeName, meta),
 	}
 
 	if etag != nil {
@@ -463,8 +508,28 @@ func (c *GRPCClient) DeleteStateWithETag(ctx context.Context, storeName, key str
 	return nil
 }
 
+// DeleteStateIfUnchanged deletes the key from store only if its current ETag
+// still matches etag, using first-write concurrency.
+// Returns ErrETagMismatch if the value has changed since the ETag was read.
+func (c *GRPCClient) DeleteStateIfUnchanged(ctx context.Context, storeName, key, etag string) error {
+	ctx = withSDKMethod(ctx, "DeleteStateIfUnchanged")
+	if etag == "" {
+		return errors.New("etag required")
+	}
+	opts := &StateOptions{
+		Concurrency: StateConcurrencyFirstWrite,
+		Consistency: StateConsistencyStrong,
+	}
+	err := c.DeleteStateWithETag(ctx, storeName, key, &ETag{Value: etag}, nil, opts)
+	if isETagMismatch(err) {
+		return fmt.Errorf("error deleting state: %w", ErrETagMismatch)
+	}
+	return err
+}
+
 // DeleteBulkState deletes content for multiple keys from store.
 func (c *GRPCClient) DeleteBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string) error {
+	ctx = withSDKMethod(ctx, "DeleteBulkState")
 	if len(keys) == 0 {
 		return nil
 	}
@@ -483,6 +548,7 @@ func (c *GRPCClient) DeleteBulkState(ctx context.Context, storeName string, keys
 
 // DeleteBulkStateItems deletes content for multiple items from store.
 func (c *GRPCClient) DeleteBulkStateItems(ctx context.Context, storeName string, items []*DeleteStateItem) error {
+	ctx = withSDKMethod(ctx, "DeleteBulkStateItems")
 	if len(items) == 0 {
 		return nil
 	}
@@ -490,13 +556,9 @@ func (c *GRPCClient) DeleteBulkStateItems(ctx context.Context, storeName string,
 	states := make([]*v1.StateItem, 0, len(items))
 	for i := 0; i < len(items); i++ {
 		item := items[i]
-		if err := hasRequiredStateArgs(storeName, item.Key); err != nil {
-			return fmt.Errorf("missing required arguments: %w", err)
-		}
-
 		state := &v1.StateItem{
 			Key:      item.Key,
-			Metadata: item.Metadata,
+			Metadata: c.withStoreDefaults(storeName, item.Metadata),
 			Options:  toProtoStateOptions(item.Options),
 		}
 		if item.Etag != nil {
@@ -515,13 +577,3 @@ func (c *GRPCClient) DeleteBulkStateItems(ctx context.Context, storeName string,
 
 	return err
 }
-
-func hasRequiredStateArgs(storeName, key string) error {
-	if storeName == "" {
-		return errors.New("store")
-	}
-	if key == "" {
-		return errors.New("key")
-	}
-	return nil
-}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// MaxStateTransactionSize is the maximum size, in bytes, of the state
+// transactions validated by a dry run, which is the default maximum size of
+// the requests accepted by the sidecar.
+const MaxStateTransactionSize = 4 << 20
+
+// stateKeyDelimiter is the delimiter of the keys prefixed by the sidecar,
+// which the keys of the state items can't contain.
+const stateKeyDelimiter = "||"
+
+// StateTransactionOption is the type for the functional option of
+// ExecuteStateTransaction.
+type StateTransactionOption func(*stateTransactionOptions)
+
+type stateTransactionOptions struct {
+	dryRun  bool
+	request *pb.ExecuteStateTransactionRequest
+}
+
+// WithDryRun makes ExecuteStateTransaction validate the transaction, without
+// sending it to the sidecar: the keys, the operation types, the metadata and
+// the size, which must not exceed MaxStateTransactionSize. When req isn't nil,
+// it is set to the request that would have been sent, for logging or testing.
+func WithDryRun(req *pb.ExecuteStateTransactionRequest) StateTransactionOption {
+	return func(o *stateTransactionOptions) {
+		o.dryRun = true
+		o.request = req
+	}
+}
+
+// dryRunTransaction validates req as the sidecar would, and copies it to the
+// request of the options.
+func (o *stateTransactionOptions) dryRunTransaction(req *pb.ExecuteStateTransactionRequest) error {
+	if err := validateRequest("ExecuteStateTransaction", req); err != nil {
+		return err
+	}
+	if size := proto.Size(req); size > MaxStateTransactionSize {
+		return invalidArgument("ExecuteStateTransaction", "operations", fmt.Sprintf("transaction of %d bytes is larger than %d bytes", size, MaxStateTransactionSize))
+	}
+	if o.request != nil {
+		proto.Reset(o.request)
+		proto.Merge(o.request, req)
+	}
+	return nil
+}
+
+// validateStateOperations checks the operations of a state transaction.
+func validateStateOperations(ops []*StateOperation) error {
+	for _, op := range ops {
+		if op == nil || op.Item == nil {
+			return invalidArgument("ExecuteStateTransaction", "operations", reasonNil)
+		}
+		if op.Type.String() == UndefinedType {
+			return invalidArgument("ExecuteStateTransaction", "operations", fmt.Sprintf("operation type of key %q must be upsert or delete", op.Item.Key))
+		}
+		if strings.Contains(op.Item.Key, stateKeyDelimiter) {
+			return invalidArgument("ExecuteStateTransaction", "key", fmt.Sprintf("%q must not contain %q", op.Item.Key, stateKeyDelimiter))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	// DefaultStateKeyWrapAlgorithm is the key wrapping algorithm of the values
+	// encrypted by an EncryptedStateClient, unless set in its options.
+	DefaultStateKeyWrapAlgorithm = "A256KW"
+
+	// encryptedStateMagic prefixes the values encrypted by an
+	// EncryptedStateClient, followed by their JSON header, a newline, and the
+	// ciphertext.
+	encryptedStateMagic = "\x00dapr-enc\x00"
+	// encryptedStateVersion is the version of the header of the encrypted
+	// values.
+	encryptedStateVersion = 1
+)
+
+// EncryptedStateOptions are the options of NewEncryptedStateClient.
+type EncryptedStateOptions struct {
+	// CryptoComponent is the name of the crypto component. Required.
+	CryptoComponent string
+	// KeyName is the name (or name/version) of the key encrypting the values.
+	// Required.
+	KeyName string
+	// KeyWrapAlgorithm is the key wrapping algorithm, DefaultStateKeyWrapAlgorithm
+	// if empty.
+	KeyWrapAlgorithm string
+	// Stores are the names of the state stores whose values are encrypted.
+	// The values of the other stores are saved and read untouched.
+	Stores []string
+}
+
+// EncryptedStateClient is a Client encrypting the values saved to the
+// configured state stores, with SaveState, SaveStateWithETag, SaveBulkState
+// and the upserts of ExecuteStateTransaction, and decrypting them on GetState,
+// GetStateWithConsistency and GetBulkState, using the crypto building block.
+// Each encrypted value is stored with a header naming its key, so values
+// written before RotateTo can still be read. Values without that header, such
+// as the ones written before encryption was enabled, are returned untouched.
+// The other methods are the ones of the wrapped Client.
+type EncryptedStateClient struct {
+	Client
+
+	component string
+	alg       string
+	stores    map[string]bool
+
+	lock    sync.RWMutex
+	keyName string
+}
+
+// encryptedStateHeader is the header of an encrypted value.
+type encryptedStateHeader struct {
+	KeyName   string `json:"key"`
+	Algorithm string `json:"alg"`
+	Version   int    `json:"v"`
+}
+
+// NewEncryptedStateClient returns an EncryptedStateClient wrapping c.
+func NewEncryptedStateClient(c Client, opts EncryptedStateOptions) (*EncryptedStateClient, error) {
+	if c == nil {
+		return nil, errors.New("nil client")
+	}
+	if opts.CryptoComponent == "" {
+		return nil, errors.New("crypto component name is required")
+	}
+	if opts.KeyName == "" {
+		return nil, errors.New("key name is required")
+	}
+	if len(opts.Stores) == 0 {
+		return nil, errors.New("at least one state store is required")
+	}
+	ec := &EncryptedStateClient{
+		Client:    c,
+		component: opts.CryptoComponent,
+		alg:       opts.KeyWrapAlgorithm,
+		stores:    make(map[string]bool, len(opts.Stores)),
+		keyName:   opts.KeyName,
+	}
+	if ec.alg == "" {
+		ec.alg = DefaultStateKeyWrapAlgorithm
+	}
+	for _, s := range opts.Stores {
+		ec.stores[s] = true
+	}
+	return ec, nil
+}
+
+// RotateTo makes the values written from now on be encrypted with the key
+// keyName. The values encrypted with the previous keys can still be read, as
+// long as the crypto component still has these keys.
+func (c *EncryptedStateClient) RotateTo(keyName string) error {
+	if keyName == "" {
+		return errors.New("key name is required")
+	}
+	c.lock.Lock()
+	c.keyName = keyName
+	c.lock.Unlock()
+	return nil
+}
+
+// KeyName returns the name of the key encrypting the values written.
+func (c *EncryptedStateClient) KeyName() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.keyName
+}
+
+// SaveState encrypts data if storeName is encrypted, and saves it.
+func (c *EncryptedStateClient) SaveState(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...StateOption) error {
+	return c.SaveStateWithETag(ctx, storeName, key, data, "", meta, so...)
+}
+
+// SaveStateWithETag encrypts data if storeName is encrypted, and saves it.
+func (c *EncryptedStateClient) SaveStateWithETag(ctx context.Context, storeName, key string, data []byte, etag string, meta map[string]string, so ...StateOption) error {
+	if c.stores[storeName] {
+		var err error
+		if data, err = c.encrypt(ctx, data); err != nil {
+			return fmt.Errorf("error encrypting state %s: %w", key, err)
+		}
+	}
+	return c.Client.SaveStateWithETag(ctx, storeName, key, data, etag, meta, so...)
+}
+
+// SaveBulkState encrypts the values of the items if storeName is encrypted,
+// and saves them. The items are not modified.
+func (c *EncryptedStateClient) SaveBulkState(ctx context.Context, storeName string, items ...*SetStateItem) error {
+	if !c.stores[storeName] {
+		return c.Client.SaveBulkState(ctx, storeName, items...)
+	}
+	encrypted := make([]*SetStateItem, len(items))
+	for i, item := range items {
+		var err error
+		if encrypted[i], err = c.encryptItem(ctx, item); err != nil {
+			return err
+		}
+	}
+	return c.Client.SaveBulkState(ctx, storeName, encrypted...)
+}
+
+// ExecuteStateTransaction encrypts the values of the upserts if storeName is
+// encrypted, and executes the transaction. The operations are not modified.
+func (c *EncryptedStateClient) ExecuteStateTransaction(ctx context.Context, storeName string, meta map[string]string, ops []*StateOperation, opts ...StateTransactionOption) error {
+	if !c.stores[storeName] {
+		return c.Client.ExecuteStateTransaction(ctx, storeName, meta, ops, opts...)
+	}
+	encrypted := make([]*StateOperation, len(ops))
+	for i, op := range ops {
+		encrypted[i] = op
+		if op == nil || op.Type != StateOperationTypeUpsert {
+			continue
+		}
+		item, err := c.encryptItem(ctx, op.Item)
+		if err != nil {
+			return err
+		}
+		encrypted[i] = &StateOperation{Type: op.Type, Item: item}
+	}
+	return c.Client.ExecuteStateTransaction(ctx, storeName, meta, encrypted, opts...)
+}
+
+// GetState gets the state and decrypts it if storeName is encrypted.
+func (c *EncryptedStateClient) GetState(ctx context.Context, storeName, key string, meta map[string]string) (*StateItem, error) {
+	return c.GetStateWithConsistency(ctx, storeName, key, meta, StateConsistencyStrong)
+}
+
+// GetStateWithConsistency gets the state and decrypts it if storeName is
+// encrypted.
+func (c *EncryptedStateClient) GetStateWithConsistency(ctx context.Context, storeName, key string, meta map[string]string, sc StateConsistency) (*StateItem, error) {
+	item, err := c.Client.GetStateWithConsistency(ctx, storeName, key, meta, sc)
+	if err != nil || item == nil || !c.stores[storeName] {
+		return item, err
+	}
+	if item.Value, err = c.decrypt(ctx, item.Value); err != nil {
+		return nil, fmt.Errorf("error decrypting state %s: %w", key, err)
+	}
+	return item, nil
+}
+
+// GetBulkState gets the states and decrypts them if storeName is encrypted.
+func (c *EncryptedStateClient) GetBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string, parallelism int32) ([]*BulkStateItem, error) {
+	items, err := c.Client.GetBulkState(ctx, storeName, keys, meta, parallelism)
+	if err != nil || !c.stores[storeName] {
+		return items, err
+	}
+	for _, item := range items {
+		if item == nil || item.Error != "" {
+			continue
+		}
+		if item.Value, err = c.decrypt(ctx, item.Value); err != nil {
+			return nil, fmt.Errorf("error decrypting state %s: %w", item.Key, err)
+		}
+	}
+	return items, nil
+}
+
+// encryptItem returns a copy of item with its value encrypted.
+func (c *EncryptedStateClient) encryptItem(ctx context.Context, item *SetStateItem) (*SetStateItem, error) {
+	if item == nil {
+		return nil, nil
+	}
+	value, err := c.encrypt(ctx, item.Value)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting state %s: %w", item.Key, err)
+	}
+	encrypted := *item
+	encrypted.Value = value
+	return &encrypted, nil
+}
+
+// encrypt encrypts data with the current key, and prefixes it with its
+// header.
+func (c *EncryptedStateClient) encrypt(ctx context.Context, data []byte) ([]byte, error) {
+	h := encryptedStateHeader{KeyName: c.KeyName(), Algorithm: c.alg, Version: encryptedStateVersion}
+	out, err := c.Encrypt(ctx, bytes.NewReader(data), EncryptOptions{
+		ComponentName:    c.component,
+		KeyName:          h.KeyName,
+		KeyWrapAlgorithm: h.Algorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBufferString(encryptedStateMagic)
+	buf.Write(header)
+	buf.WriteByte('\n')
+	if _, err := io.Copy(buf, out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decrypt decrypts data with the key named in its header. Data without a
+// header is returned untouched.
+func (c *EncryptedStateClient) decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	h, ciphertext, ok := parseEncryptedState(data)
+	if !ok {
+		return data, nil
+	}
+	out, err := c.Decrypt(ctx, bytes.NewReader(ciphertext), DecryptOptions{
+		ComponentName: c.component,
+		KeyName:       h.KeyName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(out)
+}
+
+// parseEncryptedState splits an encrypted value into its header and its
+// ciphertext. It returns false if data is not an encrypted value.
+func parseEncryptedState(data []byte) (h encryptedStateHeader, ciphertext []byte, ok bool) {
+	if !bytes.HasPrefix(data, []byte(encryptedStateMagic)) {
+		return h, nil, false
+	}
+	header, ciphertext, ok := bytes.Cut(data[len(encryptedStateMagic):], []byte{'\n'})
+	if !ok || json.Unmarshal(header, &h) != nil || h.KeyName == "" || h.Version < 1 {
+		return h, nil, false
+	}
+	return h, ciphertext, true
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xorCryptoClient fakes the crypto building block, XOR-ing the data with the
+// byte of the key.
+type xorCryptoClient struct {
+	Client
+	keys map[string]byte
+}
+
+func (c *xorCryptoClient) xor(in io.Reader, keyName string) (io.Reader, error) {
+	k, ok := c.keys[keyName]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found", keyName)
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	for i := range data {
+		data[i] ^= k
+	}
+	return bytes.NewReader(data), nil
+}
+
+func (c *xorCryptoClient) Encrypt(ctx context.Context, in io.Reader, opts EncryptOptions) (io.Reader, error) {
+	return c.xor(in, opts.KeyName)
+}
+
+func (c *xorCryptoClient) Decrypt(ctx context.Context, in io.Reader, opts DecryptOptions) (io.Reader, error) {
+	return c.xor(in, opts.KeyName)
+}
+
+func TestEncryptedStateClient(t *testing.T) {
+	ctx := context.Background()
+	testClient, closer := getTestClient(ctx)
+	defer closer()
+	raw := &xorCryptoClient{Client: testClient, keys: map[string]byte{"key1": 0x11, "key2": 0x22}}
+
+	newClient := func(t *testing.T) *EncryptedStateClient {
+		c, err := NewEncryptedStateClient(raw, EncryptedStateOptions{
+			CryptoComponent: "crypto",
+			KeyName:         "key1",
+			Stores:          []string{"secure"},
+		})
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewEncryptedStateClient(raw, EncryptedStateOptions{KeyName: "key1", Stores: []string{"secure"}})
+		assert.Error(t, err)
+		_, err = NewEncryptedStateClient(raw, EncryptedStateOptions{CryptoComponent: "crypto", Stores: []string{"secure"}})
+		assert.Error(t, err)
+		_, err = NewEncryptedStateClient(raw, EncryptedStateOptions{CryptoComponent: "crypto", KeyName: "key1"})
+		assert.Error(t, err)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		c := newClient(t)
+		require.NoError(t, c.SaveState(ctx, "secure", "rt1", []byte("value1"), nil))
+		items := []*SetStateItem{{Key: "rt2", Value: []byte("value2")}}
+		require.NoError(t, c.SaveBulkState(ctx, "secure", items...))
+		assert.Equal(t, []byte("value2"), items[0].Value)
+		ops := []*StateOperation{
+			{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "rt3", Value: []byte("value3")}},
+		}
+		require.NoError(t, c.ExecuteStateTransaction(ctx, "secure", nil, ops))
+		assert.Equal(t, []byte("value3"), ops[0].Item.Value)
+
+		stored, err := testClient.GetState(ctx, "secure", "rt1", nil)
+		require.NoError(t, err)
+		assert.NotContains(t, string(stored.Value), "value1")
+		h, _, ok := parseEncryptedState(stored.Value)
+		require.True(t, ok)
+		assert.Equal(t, encryptedStateHeader{KeyName: "key1", Algorithm: DefaultStateKeyWrapAlgorithm, Version: 1}, h)
+
+		item, err := c.GetState(ctx, "secure", "rt1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("value1"), item.Value)
+		bulk, err := c.GetBulkState(ctx, "secure", []string{"rt2", "rt3"}, nil, 1)
+		require.NoError(t, err)
+		require.Len(t, bulk, 2)
+		assert.Equal(t, []byte("value2"), bulk[0].Value)
+		assert.Equal(t, []byte("value3"), bulk[1].Value)
+	})
+
+	t.Run("other stores are untouched", func(t *testing.T) {
+		c := newClient(t)
+		require.NoError(t, c.SaveState(ctx, "plain", "plain1", []byte("value"), nil))
+		stored, err := testClient.GetState(ctx, "plain", "plain1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("value"), stored.Value)
+	})
+
+	t.Run("legacy values pass through", func(t *testing.T) {
+		c := newClient(t)
+		require.NoError(t, testClient.SaveState(ctx, "secure", "legacy1", []byte("legacy"), nil))
+		item, err := c.GetState(ctx, "secure", "legacy1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("legacy"), item.Value)
+		bulk, err := c.GetBulkState(ctx, "secure", []string{"legacy1"}, nil, 1)
+		require.NoError(t, err)
+		require.Len(t, bulk, 1)
+		assert.Equal(t, []byte("legacy"), bulk[0].Value)
+	})
+
+	t.Run("rotation reads old and new keys", func(t *testing.T) {
+		c := newClient(t)
+		require.NoError(t, c.SaveState(ctx, "secure", "rot1", []byte("old"), nil))
+		assert.Error(t, c.RotateTo(""))
+		require.NoError(t, c.RotateTo("key2"))
+		assert.Equal(t, "key2", c.KeyName())
+		require.NoError(t, c.SaveState(ctx, "secure", "rot2", []byte("new"), nil))
+
+		stored, err := testClient.GetState(ctx, "secure", "rot2", nil)
+		require.NoError(t, err)
+		h, _, ok := parseEncryptedState(stored.Value)
+		require.True(t, ok)
+		assert.Equal(t, "key2", h.KeyName)
+
+		bulk, err := c.GetBulkState(ctx, "secure", []string{"rot1", "rot2"}, nil, 1)
+		require.NoError(t, err)
+		require.Len(t, bulk, 2)
+		assert.Equal(t, []byte("old"), bulk[0].Value)
+		assert.Equal(t, []byte("new"), bulk[1].Value)
+	})
+}
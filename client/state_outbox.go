@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// metadataKeyOutboxProjection is the metadata key marking the upsert of a
+// state transaction whose value is published by the transactional outbox,
+// instead of the value saved to the same key.
+const metadataKeyOutboxProjection = "outbox.projection"
+
+// SaveStateAndPublishIfUnchanged saves data to key only if its current ETag
+// still matches etag, using first-write concurrency, in a state transaction on
+// a store configured with the transactional outbox: the outbox publishes the
+// event only if the transaction commits. The event is data, or event if not
+// nil, sent as an outbox projection, which requires Dapr 1.13 or later.
+// Returns ErrETagMismatch, without publishing, if the value has changed since
+// the ETag was read.
+func (c *GRPCClient) SaveStateAndPublishIfUnchanged(ctx context.Context, storeName, key string, data []byte, etag string, event []byte) error {
+	ctx = withSDKMethod(ctx, "SaveStateAndPublishIfUnchanged")
+	if etag == "" {
+		return errors.New("etag required")
+	}
+	opts := &StateOptions{
+		Concurrency: StateConcurrencyFirstWrite,
+		Consistency: StateConsistencyStrong,
+	}
+	ops := []*StateOperation{{
+		Type: StateOperationTypeUpsert,
+		Item: &SetStateItem{Key: key, Value: data, Etag: &ETag{Value: etag}, Options: opts},
+	}}
+	if event != nil {
+		ops = append(ops, &StateOperation{
+			Type: StateOperationTypeUpsert,
+			Item: &SetStateItem{
+				Key:      key,
+				Value:    event,
+				Metadata: map[string]string{metadataKeyOutboxProjection: "true"},
+				Options:  opts,
+			},
+		})
+	}
+	err := c.ExecuteStateTransaction(ctx, storeName, nil, ops)
+	if isETagMismatch(err) {
+		return fmt.Errorf("error saving state: %w", ErrETagMismatch)
+	}
+	return err
+}
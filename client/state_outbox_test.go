@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// outboxDaprServer is an etagDaprServer whose transactions enforce ETag
+// checks and publish their upserts, or their projections, when they commit,
+// like a state store configured with the transactional outbox.
+type outboxDaprServer struct {
+	*etagDaprServer
+	published [][]byte
+}
+
+func (s *outboxDaprServer) ExecuteStateTransaction(ctx context.Context, in *pb.ExecuteStateTransactionRequest) (*empty.Empty, error) {
+	var events [][]byte
+	for _, op := range in.GetOperations() {
+		item := op.GetRequest()
+		if item.GetMetadata()[metadataKeyOutboxProjection] == "true" {
+			events = [][]byte{item.Value}
+			continue
+		}
+		if item.Etag != nil && item.Etag.Value != strconv.Itoa(s.versions[item.Key]) {
+			return nil, status.Errorf(codes.Aborted, "failed saving state with key %s: possible etag mismatch", item.Key)
+		}
+		if events == nil {
+			events = [][]byte{item.Value}
+		}
+	}
+	for _, op := range in.GetOperations() {
+		item := op.GetRequest()
+		if item.GetMetadata()[metadataKeyOutboxProjection] != "true" {
+			s.state[item.Key] = item.Value
+			s.versions[item.Key]++
+		}
+	}
+	s.published = append(s.published, events...)
+	return &empty.Empty{}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestSaveStateAndPublishIfUnchanged$
+func TestSaveStateAndPublishIfUnchanged(t *testing.T) {
+	ctx := context.Background()
+	srv := &outboxDaprServer{etagDaprServer: newETagDaprServer()}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	const key = "order-1"
+	require.NoError(t, c.SaveState(ctx, testStore, key, []byte("created"), nil))
+
+	t.Run("without etag", func(t *testing.T) {
+		assert.Error(t, c.(ConditionalStateWriter).SaveStateAndPublishIfUnchanged(ctx, testStore, key, []byte("paid"), "", nil))
+		assert.Empty(t, srv.published)
+	})
+
+	t.Run("failed precondition suppresses the publish", func(t *testing.T) {
+		item, err := c.GetState(ctx, testStore, key, nil)
+		require.NoError(t, err)
+		// Another writer updates the value after it was read.
+		require.NoError(t, c.SaveState(ctx, testStore, key, []byte("cancelled"), nil))
+
+		err = c.(ConditionalStateWriter).SaveStateAndPublishIfUnchanged(ctx, testStore, key, []byte("paid"), item.Etag, nil)
+		assert.ErrorIs(t, err, ErrETagMismatch)
+		assert.Empty(t, srv.published)
+		assert.Equal(t, []byte("cancelled"), srv.state[key])
+	})
+
+	t.Run("matching etag publishes the state", func(t *testing.T) {
+		item, err := c.GetState(ctx, testStore, key, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, c.(ConditionalStateWriter).SaveStateAndPublishIfUnchanged(ctx, testStore, key, []byte("paid"), item.Etag, nil))
+		assert.Equal(t, []byte("paid"), srv.state[key])
+		assert.Equal(t, [][]byte{[]byte("paid")}, srv.published)
+	})
+
+	t.Run("matching etag publishes the event", func(t *testing.T) {
+		srv.published = nil
+		item, err := c.GetState(ctx, testStore, key, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, c.(ConditionalStateWriter).SaveStateAndPublishIfUnchanged(ctx, testStore, key, []byte("shipped"), item.Etag, []byte(`{"event":"OrderShipped"}`)))
+		assert.Equal(t, []byte("shipped"), srv.state[key])
+		assert.Equal(t, [][]byte{[]byte(`{"event":"OrderShipped"}`)}, srv.published)
+	})
+}
@@ -15,12 +15,19 @@ package client
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	v1 "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 const (
@@ -35,6 +42,27 @@ func TestTypes(t *testing.T) {
 	assert.Equal(t, UndefinedType, c.String())
 	var d StateConsistency = -1
 	assert.Equal(t, UndefinedType, d.String())
+
+	assert.Equal(t, "strong", StateConsistencyStrong.String())
+	assert.Equal(t, "eventual", StateConsistencyEventual.String())
+	assert.Equal(t, "first-write", StateConcurrencyFirstWrite.String())
+	assert.Equal(t, "last-write", StateConcurrencyLastWrite.String())
+}
+
+func TestStateEnumsToProto(t *testing.T) {
+	assert.Equal(t, v1.StateOptions_CONSISTENCY_UNSPECIFIED, StateConsistencyUndefined.GetPBConsistency())
+	assert.Equal(t, v1.StateOptions_CONSISTENCY_EVENTUAL, StateConsistencyEventual.GetPBConsistency())
+	assert.Equal(t, v1.StateOptions_CONSISTENCY_STRONG, StateConsistencyStrong.GetPBConsistency())
+	assert.Equal(t, v1.StateOptions_CONCURRENCY_UNSPECIFIED, StateConcurrencyUndefined.GetPBConcurrency())
+	assert.Equal(t, v1.StateOptions_CONCURRENCY_FIRST_WRITE, StateConcurrencyFirstWrite.GetPBConcurrency())
+	assert.Equal(t, v1.StateOptions_CONCURRENCY_LAST_WRITE, StateConcurrencyLastWrite.GetPBConcurrency())
+
+	so := &StateOptions{}
+	WithConsistency(StateConsistencyEventual)(so)
+	WithConcurrency(StateConcurrencyFirstWrite)(so)
+	p := toProtoStateOptions(so)
+	assert.Equal(t, v1.StateOptions_CONSISTENCY_EVENTUAL, p.Consistency)
+	assert.Equal(t, v1.StateOptions_CONCURRENCY_FIRST_WRITE, p.Concurrency)
 }
 
 func TestDurationConverter(t *testing.T) {
@@ -382,3 +410,237 @@ func TestQueryState(t *testing.T) {
 		}
 	})
 }
+
+// etagDaprServer is a testDaprServer which tracks a version for each key and
+// enforces ETag checks on delete, like a state store with first-write concurrency.
+type etagDaprServer struct {
+	testDaprServer
+	versions map[string]int
+}
+
+func newETagDaprServer() *etagDaprServer {
+	return &etagDaprServer{
+		testDaprServer: testDaprServer{state: make(map[string][]byte)},
+		versions:       make(map[string]int),
+	}
+}
+
+func (s *etagDaprServer) GetState(ctx context.Context, req *pb.GetStateRequest) (*pb.GetStateResponse, error) {
+	return &pb.GetStateResponse{
+		Data: s.state[req.Key],
+		Etag: strconv.Itoa(s.versions[req.Key]),
+	}, nil
+}
+
+func (s *etagDaprServer) SaveState(ctx context.Context, req *pb.SaveStateRequest) (*empty.Empty, error) {
+	for _, item := range req.States {
+		s.state[item.Key] = item.Value
+		s.versions[item.Key]++
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *etagDaprServer) DeleteState(ctx context.Context, req *pb.DeleteStateRequest) (*empty.Empty, error) {
+	if req.Etag != nil && req.Etag.Value != strconv.Itoa(s.versions[req.Key]) {
+		return nil, status.Errorf(codes.Aborted, "failed deleting state with key %s: possible etag mismatch", req.Key)
+	}
+	delete(s.state, req.Key)
+	delete(s.versions, req.Key)
+	return &empty.Empty{}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestDeleteStateIfUnchanged$
+func TestDeleteStateIfUnchanged(t *testing.T) {
+	ctx := context.Background()
+	srv := newETagDaprServer()
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	store := testStore
+	key := "key1"
+
+	t.Run("without etag", func(t *testing.T) {
+		err := c.(ConditionalStateWriter).DeleteStateIfUnchanged(ctx, store, key, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("matching etag", func(t *testing.T) {
+		require.NoError(t, c.SaveState(ctx, store, key, []byte(testData), nil))
+		item, err := c.GetState(ctx, store, key, nil)
+		require.NoError(t, err)
+
+		err = c.(ConditionalStateWriter).DeleteStateIfUnchanged(ctx, store, key, item.Etag)
+		assert.NoError(t, err)
+		assert.NotContains(t, srv.state, key)
+	})
+
+	t.Run("stale etag", func(t *testing.T) {
+		require.NoError(t, c.SaveState(ctx, store, key, []byte(testData), nil))
+		item, err := c.GetState(ctx, store, key, nil)
+		require.NoError(t, err)
+
+		// Another writer updates the value after it was read.
+		require.NoError(t, c.SaveState(ctx, store, key, []byte("updated"), nil))
+
+		err = c.(ConditionalStateWriter).DeleteStateIfUnchanged(ctx, store, key, item.Etag)
+		assert.ErrorIs(t, err, ErrETagMismatch)
+		assert.Contains(t, srv.state, key)
+	})
+}
+
+type saveRecordingDaprServer struct {
+	testDaprServer
+	saved []*v1.StateItem
+}
+
+func (s *saveRecordingDaprServer) SaveState(ctx context.Context, req *pb.SaveStateRequest) (*empty.Empty, error) {
+	s.saved = append(s.saved, req.States...)
+	return s.testDaprServer.SaveState(ctx, req)
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestSaveStateWithContentType$
+func TestSaveStateWithContentType(t *testing.T) {
+	ctx := context.Background()
+	srv := &saveRecordingDaprServer{
+		testDaprServer: testDaprServer{state: make(map[string][]byte)},
+	}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	t.Run("content type set", func(t *testing.T) {
+		meta := map[string]string{"foo": "bar"}
+		err := c.SaveState(ctx, testStore, "key1", []byte("<a/>"), meta, WithStateContentType("application/xml"))
+		require.NoError(t, err)
+		require.Len(t, srv.saved, 1)
+		assert.Equal(t, "application/xml", srv.saved[0].Metadata["contentType"])
+		assert.Equal(t, "bar", srv.saved[0].Metadata["foo"])
+		// The caller's metadata is not modified.
+		assert.NotContains(t, meta, "contentType")
+	})
+
+	t.Run("content type not set", func(t *testing.T) {
+		srv.saved = nil
+		err := c.SaveState(ctx, testStore, "key1", []byte(testData), nil)
+		require.NoError(t, err)
+		require.Len(t, srv.saved, 1)
+		assert.NotContains(t, srv.saved[0].Metadata, "contentType")
+	})
+}
+
+// ttlDaprServer returns the items with the given ttlExpireTime metadata.
+type ttlDaprServer struct {
+	testDaprServer
+	ttlExpireTime string
+}
+
+func (s *ttlDaprServer) GetState(ctx context.Context, req *pb.GetStateRequest) (*pb.GetStateResponse, error) {
+	resp, err := s.testDaprServer.GetState(ctx, req)
+	if err != nil || s.ttlExpireTime == "" {
+		return resp, err
+	}
+	resp.Metadata = map[string]string{metadataKeyTTLExpireTime: s.ttlExpireTime}
+	return resp, nil
+}
+
+func TestGetStateTTLExpireTime(t *testing.T) {
+	ctx := context.Background()
+	srv := &ttlDaprServer{testDaprServer: testDaprServer{
+		state:                       make(map[string][]byte),
+		configurationSubscriptionID: map[string]chan struct{}{},
+	}}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+	require.NoError(t, c.SaveState(ctx, testStore, "key1", []byte("value"), nil))
+
+	t.Run("absent", func(t *testing.T) {
+		item, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Nil(t, item.TTLExpireTime)
+	})
+
+	t.Run("present", func(t *testing.T) {
+		srv.ttlExpireTime = "2023-06-01T12:30:00Z"
+		item, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		require.NotNil(t, item.TTLExpireTime)
+		assert.True(t, item.TTLExpireTime.Equal(time.Date(2023, 6, 1, 12, 30, 0, 0, time.UTC)))
+		assert.Equal(t, srv.ttlExpireTime, item.Metadata[metadataKeyTTLExpireTime])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		srv.ttlExpireTime = "tomorrow"
+		item, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Nil(t, item.TTLExpireTime)
+	})
+}
+
+// transactionCountingDaprServer counts the state transactions.
+type transactionCountingDaprServer struct {
+	testDaprServer
+	calls int
+}
+
+func (s *transactionCountingDaprServer) ExecuteStateTransaction(ctx context.Context, in *pb.ExecuteStateTransactionRequest) (*empty.Empty, error) {
+	s.calls++
+	return s.testDaprServer.ExecuteStateTransaction(ctx, in)
+}
+
+func TestExecuteStateTransactionDryRun(t *testing.T) {
+	ctx := context.Background()
+	srv := &transactionCountingDaprServer{testDaprServer: testDaprServer{
+		state:                       make(map[string][]byte),
+		configurationSubscriptionID: map[string]chan struct{}{},
+	}}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	ops := []*StateOperation{
+		{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "k1", Value: []byte("v1")}},
+		{Type: StateOperationTypeDelete, Item: &SetStateItem{Key: "k2"}},
+	}
+
+	t.Run("returns the request without calling the sidecar", func(t *testing.T) {
+		req := &pb.ExecuteStateTransactionRequest{}
+		require.NoError(t, c.ExecuteStateTransaction(ctx, testStore, map[string]string{"m": "v"}, ops, WithDryRun(req)))
+		assert.Zero(t, srv.calls)
+		assert.Equal(t, testStore, req.StoreName)
+		assert.Equal(t, map[string]string{"m": "v"}, req.Metadata)
+		require.Len(t, req.Operations, 2)
+		assert.Equal(t, "upsert", req.Operations[0].OperationType)
+		assert.Equal(t, "k1", req.Operations[0].Request.Key)
+		assert.Equal(t, []byte("v1"), req.Operations[0].Request.Value)
+		assert.Equal(t, "delete", req.Operations[1].OperationType)
+
+		require.NoError(t, c.ExecuteStateTransaction(ctx, testStore, nil, ops, WithDryRun(nil)))
+		assert.Zero(t, srv.calls)
+	})
+
+	t.Run("validates the transaction", func(t *testing.T) {
+		tests := map[string]struct {
+			ops   []*StateOperation
+			field string
+		}{
+			"empty key":         {[]*StateOperation{{Type: StateOperationTypeUpsert, Item: &SetStateItem{}}}, "key"},
+			"reserved key":      {[]*StateOperation{{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "app||k"}}}, "key"},
+			"undefined type":    {[]*StateOperation{{Item: &SetStateItem{Key: "k"}}}, "operations"},
+			"nil item":          {[]*StateOperation{{Type: StateOperationTypeDelete}}, "operations"},
+			"too large":         {[]*StateOperation{{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "k", Value: make([]byte, MaxStateTransactionSize)}}}, "operations"},
+			"long metadata key": {[]*StateOperation{{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "k", Metadata: map[string]string{strings.Repeat("k", MaxMetadataKeySize+1): "v"}}}}, "metadata"},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				err := c.ExecuteStateTransaction(ctx, testStore, nil, tt.ops, WithDryRun(nil))
+				var invalid *ErrInvalidArgument
+				require.ErrorAs(t, err, &invalid)
+				assert.Equal(t, tt.field, invalid.Field)
+			})
+		}
+		assert.Zero(t, srv.calls)
+	})
+
+	t.Run("executed without dry run", func(t *testing.T) {
+		require.NoError(t, c.ExecuteStateTransaction(ctx, testStore, nil, ops))
+		assert.Equal(t, 1, srv.calls)
+	})
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultUpdateStateMaxRetries is the number of times UpdateState retries
+	// after a conflict when UpdateOptions.MaxRetries is not set.
+	DefaultUpdateStateMaxRetries = 5
+
+	updateStateInitialBackoff = 10 * time.Millisecond
+	updateStateMaxBackoff     = time.Second
+)
+
+// ErrUpdateConflict is returned by UpdateState when the value kept being
+// changed concurrently until the retries were exhausted.
+var ErrUpdateConflict = errors.New("state update conflict")
+
+// UpdateOptions configures UpdateState.
+type UpdateOptions struct {
+	// MaxRetries is the number of times the update is retried after a
+	// conflict. Zero means DefaultUpdateStateMaxRetries.
+	MaxRetries int
+}
+
+// UpdateState runs a read-modify-write loop on the JSON-serialized value of
+// key: it reads the current value, passes it to fn, and saves the result
+// guarded by the ETag of the value read, using first-write concurrency.
+// If the key doesn't exist, fn is called with found set to false and the
+// result is created only if the key still doesn't exist.
+// When the value changes concurrently the loop is retried, with exponential
+// backoff, up to MaxRetries times before failing with ErrUpdateConflict.
+// Errors returned by fn abort the update and are returned as is.
+func UpdateState[T any](ctx context.Context, c Client, storeName, key string, fn func(current T, found bool) (T, error), opts UpdateOptions) error {
+	if fn == nil {
+		return errors.New("update function required")
+	}
+	maxRetries := opts.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = DefaultUpdateStateMaxRetries
+	}
+
+	backoff := updateStateInitialBackoff
+	for attempt := 0; ; attempt++ {
+		item, err := c.GetState(ctx, storeName, key, nil)
+		if err != nil {
+			return err
+		}
+
+		var current T
+		found := item != nil && (item.Etag != "" || len(item.Value) > 0)
+		etag := ""
+		if found {
+			etag = item.Etag
+			if err := jsonUnmarshal(item.Value, &current); err != nil {
+				return fmt.Errorf("error deserializing state %s: %w", key, err)
+			}
+		}
+
+		updated, err := fn(current, found)
+		if err != nil {
+			return err
+		}
+		data, err := jsonMarshal(updated)
+		if err != nil {
+			return fmt.Errorf("error serializing state %s: %w", key, err)
+		}
+
+		err = c.SaveStateWithETag(ctx, storeName, key, data, etag, nil,
+			WithConcurrency(StateConcurrencyFirstWrite), WithConsistency(StateConsistencyStrong))
+		if !isETagMismatch(err) {
+			return err
+		}
+		if attempt >= maxRetries {
+			return fmt.Errorf("error updating state %s after %d retries: %w", key, maxRetries, ErrUpdateConflict)
+		}
+
+		if err := waitRetry(ctx, backoff); err != nil {
+			return fmt.Errorf("error updating state %s: %w", key, err)
+		}
+		backoff *= 2
+		if backoff > updateStateMaxBackoff {
+			backoff = updateStateMaxBackoff
+		}
+	}
+}
+
+// isETagMismatch reports whether err is the error returned by the sidecar when
+// a write guarded by an ETag or first-write concurrency is rejected.
+func isETagMismatch(err error) bool {
+	return err != nil && (errors.Is(err, ErrETagMismatch) || status.Code(err) == codes.Aborted)
+}
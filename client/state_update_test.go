@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type counter struct {
+	Value   int      `json:"value"`
+	Writers []string `json:"writers"`
+}
+
+func increment(writer string) func(current counter, found bool) (counter, error) {
+	return func(current counter, found bool) (counter, error) {
+		current.Value++
+		current.Writers = append(current.Writers, writer)
+		return current, nil
+	}
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestUpdateState$
+func TestUpdateState(t *testing.T) {
+	ctx := context.Background()
+	srv := newJobStateDaprServer()
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	load := func(key string) counter {
+		t.Helper()
+		item, err := c.GetState(ctx, testStore, key, nil)
+		require.NoError(t, err)
+		var v counter
+		require.NoError(t, json.Unmarshal(item.Value, &v))
+		return v
+	}
+
+	t.Run("key not found", func(t *testing.T) {
+		var wasFound bool
+		err := UpdateState(ctx, c, testStore, "new", func(current counter, found bool) (counter, error) {
+			wasFound = found
+			return increment("a")(current, found)
+		}, UpdateOptions{})
+		require.NoError(t, err)
+		assert.False(t, wasFound)
+		assert.Equal(t, counter{Value: 1, Writers: []string{"a"}}, load("new"))
+
+		err = UpdateState(ctx, c, testStore, "new", func(current counter, found bool) (counter, error) {
+			wasFound = found
+			return increment("b")(current, found)
+		}, UpdateOptions{})
+		require.NoError(t, err)
+		assert.True(t, wasFound)
+		assert.Equal(t, counter{Value: 2, Writers: []string{"a", "b"}}, load("new"))
+	})
+
+	t.Run("conflicting writers converge", func(t *testing.T) {
+		// Writer b updates the value between the read and the write of writer a,
+		// whose first write conflicts and is retried on top of b's value.
+		attempts := 0
+		err := UpdateState(ctx, c, testStore, "conflict", func(current counter, found bool) (counter, error) {
+			attempts++
+			if attempts == 1 {
+				require.NoError(t, UpdateState(ctx, c, testStore, "conflict", increment("b"), UpdateOptions{}))
+			}
+			return increment("a")(current, found)
+		}, UpdateOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+		assert.Equal(t, counter{Value: 2, Writers: []string{"b", "a"}}, load("conflict"))
+	})
+
+	t.Run("concurrent writers converge", func(t *testing.T) {
+		const writers = 5
+		var wg sync.WaitGroup
+		errs := make(chan error, writers)
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- UpdateState(ctx, c, testStore, "concurrent", increment("w"), UpdateOptions{MaxRetries: 50})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		assert.Equal(t, writers, load("concurrent").Value)
+	})
+
+	t.Run("conflict after retries", func(t *testing.T) {
+		attempts := 0
+		err := UpdateState(ctx, c, testStore, "contended", func(current counter, found bool) (counter, error) {
+			attempts++
+			require.NoError(t, UpdateState(ctx, c, testStore, "contended", increment("other"), UpdateOptions{}))
+			return increment("a")(current, found)
+		}, UpdateOptions{MaxRetries: 2})
+		assert.ErrorIs(t, err, ErrUpdateConflict)
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, 3, load("contended").Value)
+	})
+
+	t.Run("retries stop at the deadline", func(t *testing.T) {
+		// The backoffs double from 10ms, so that the one after the fifth
+		// attempt, 160ms, would exceed the deadline.
+		dctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		attempts := 0
+		start := time.Now()
+		err := UpdateState(dctx, c, testStore, "deadline", func(current counter, found bool) (counter, error) {
+			attempts++
+			require.NoError(t, UpdateState(ctx, c, testStore, "deadline", increment("other"), UpdateOptions{}))
+			return increment("a")(current, found)
+		}, UpdateOptions{MaxRetries: 10})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.GreaterOrEqual(t, attempts, 2)
+		assert.Less(t, time.Since(start), 200*time.Millisecond, "returned before the deadline")
+	})
+
+	t.Run("update function error", func(t *testing.T) {
+		errAbort := errors.New("abort")
+		err := UpdateState(ctx, c, testStore, "new", func(current counter, found bool) (counter, error) {
+			return current, errAbort
+		}, UpdateOptions{})
+		assert.ErrorIs(t, err, errAbort)
+		assert.Equal(t, 2, load("new").Value)
+	})
+}
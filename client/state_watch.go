@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// MaxWatchStateKeys is the maximum number of keys a single WatchState call
+// may watch.
+const MaxWatchStateKeys = 100
+
+// watchStateJitter is the fraction of the interval added at random to each
+// poll, so that watchers started together don't poll in lockstep.
+const watchStateJitter = 0.1
+
+// StateChangeType is the type of change reported by WatchState.
+type StateChangeType string
+
+const (
+	StateChangeCreated StateChangeType = "created"
+	StateChangeUpdated StateChangeType = "updated"
+	StateChangeDeleted StateChangeType = "deleted"
+)
+
+// StateChange is a change of a watched state key.
+type StateChange struct {
+	Type StateChangeType
+	Key  string
+	// Value is the new value of the key; it is nil for deletions.
+	Value []byte
+	// OldEtag and NewEtag are the etags before and after the change. They are
+	// empty for stores that do not return etags, and when the key did not
+	// exist before or after the change.
+	OldEtag string
+	NewEtag string
+}
+
+type watchedState struct {
+	etag        string
+	fingerprint string
+}
+
+// WatchState watches the given keys for changes by polling the store with
+// GetBulkState every interval, plus a random jitter of up to 10%.
+// Changes are detected by comparing etags, or hashes of the values for stores
+// that do not return etags. The values read by the first poll are the baseline
+// and are not reported.
+// Polls that fail are skipped. The returned channel is closed once ctx is done.
+func (c *GRPCClient) WatchState(ctx context.Context, storeName string, keys []string, interval time.Duration) (<-chan StateChange, error) {
+	if storeName == "" {
+		return nil, invalidArgument("WatchState", "storeName", reasonEmpty)
+	}
+	if len(keys) == 0 {
+		return nil, invalidArgument("WatchState", "keys", reasonEmpty)
+	}
+	if len(keys) > MaxWatchStateKeys {
+		return nil, fmt.Errorf("cannot watch %d keys: the maximum is %d", len(keys), MaxWatchStateKeys)
+	}
+	if interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+
+	first, err := c.pollWatchedState(ctx, storeName, keys)
+	if err != nil {
+		return nil, err
+	}
+	current := first.states
+
+	ch := make(chan StateChange)
+	go func() {
+		defer close(ch)
+
+		timer := time.NewTimer(jitteredInterval(interval))
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			next, err := c.pollWatchedState(ctx, storeName, keys)
+			if err == nil {
+				for _, change := range diffWatchedState(keys, current, next) {
+					select {
+					case ch <- change:
+					case <-ctx.Done():
+						return
+					}
+				}
+				current = next.states
+			}
+			timer.Reset(jitteredInterval(interval))
+		}
+	}()
+	return ch, nil
+}
+
+// watchPoll is the result of a poll of the watched keys.
+type watchPoll struct {
+	states map[string]watchedState
+	values map[string][]byte
+	// failed holds the keys the store failed to read; their state is
+	// carried over from the previous poll.
+	failed map[string]struct{}
+}
+
+func (c *GRPCClient) pollWatchedState(ctx context.Context, storeName string, keys []string) (*watchPoll, error) {
+	items, err := c.GetBulkState(ctx, storeName, keys, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	poll := &watchPoll{
+		states: make(map[string]watchedState, len(items)),
+		values: make(map[string][]byte, len(items)),
+		failed: map[string]struct{}{},
+	}
+	for _, item := range items {
+		if item.Error != "" {
+			poll.failed[item.Key] = struct{}{}
+			continue
+		}
+		if len(item.Value) == 0 && item.Etag == "" {
+			// the key doesn't exist
+			continue
+		}
+		fingerprint := "etag:" + item.Etag
+		if item.Etag == "" {
+			fingerprint = fmt.Sprintf("sha256:%x", sha256.Sum256(item.Value))
+		}
+		poll.states[item.Key] = watchedState{etag: item.Etag, fingerprint: fingerprint}
+		poll.values[item.Key] = item.Value
+	}
+	return poll, nil
+}
+
+// diffWatchedState returns the changes between the previous states and the
+// latest poll, in the order of keys. Keys that failed to be read in the latest
+// poll keep their previous state.
+func diffWatchedState(keys []string, prev map[string]watchedState, next *watchPoll) []StateChange {
+	changes := make([]StateChange, 0)
+	for _, key := range keys {
+		if _, failed := next.failed[key]; failed {
+			if old, ok := prev[key]; ok {
+				next.states[key] = old
+			}
+			continue
+		}
+		old, existed := prev[key]
+		cur, exists := next.states[key]
+		switch {
+		case !existed && exists:
+			changes = append(changes, StateChange{Type: StateChangeCreated, Key: key, Value: next.values[key], NewEtag: cur.etag})
+		case existed && !exists:
+			changes = append(changes, StateChange{Type: StateChangeDeleted, Key: key, OldEtag: old.etag})
+		case existed && exists && old.fingerprint != cur.fingerprint:
+			changes = append(changes, StateChange{Type: StateChangeUpdated, Key: key, Value: next.values[key], OldEtag: old.etag, NewEtag: cur.etag})
+		}
+	}
+	return changes
+}
+
+func jitteredInterval(interval time.Duration) time.Duration {
+	//nolint:gosec
+	return interval + time.Duration(rand.Float64()*watchStateJitter*float64(interval))
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// scriptedStateDaprServer answers each GetBulkState call with the next
+// scripted set of items, repeating the last one once the script is exhausted.
+type scriptedStateDaprServer struct {
+	testDaprServer
+	lock   sync.Mutex
+	script [][]*pb.BulkStateItem
+	polls  int
+}
+
+func (s *scriptedStateDaprServer) GetBulkState(ctx context.Context, in *pb.GetBulkStateRequest) (*pb.GetBulkStateResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	i := s.polls
+	if i >= len(s.script) {
+		i = len(s.script) - 1
+	}
+	s.polls++
+	return &pb.GetBulkStateResponse{Items: s.script[i]}, nil
+}
+
+func receiveStateChanges(t *testing.T, ch <-chan StateChange, n int) []StateChange {
+	t.Helper()
+	changes := make([]StateChange, 0, n)
+	for len(changes) < n {
+		select {
+		case c, ok := <-ch:
+			require.True(t, ok, "channel closed early")
+			changes = append(changes, c)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for state changes")
+		}
+	}
+	return changes
+}
+
+func TestWatchState(t *testing.T) {
+	t.Run("etag changes", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		srv := &scriptedStateDaprServer{script: [][]*pb.BulkStateItem{
+			{{Key: "a", Data: []byte("1"), Etag: "1"}, {Key: "b", Data: []byte("x"), Etag: "1"}, {Key: "c"}},
+			{{Key: "a", Data: []byte("2"), Etag: "2"}, {Key: "b", Data: []byte("x"), Etag: "1"}, {Key: "c", Data: []byte("new"), Etag: "1"}},
+			{{Key: "a", Data: []byte("2"), Etag: "2"}, {Key: "b"}, {Key: "c", Data: []byte("new"), Etag: "1"}},
+		}}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		ch, err := c.(StateWatcher).WatchState(ctx, "store", []string{"a", "b", "c"}, 10*time.Millisecond)
+		require.NoError(t, err)
+
+		changes := receiveStateChanges(t, ch, 3)
+		assert.Equal(t, []StateChange{
+			{Type: StateChangeUpdated, Key: "a", Value: []byte("2"), OldEtag: "1", NewEtag: "2"},
+			{Type: StateChangeCreated, Key: "c", Value: []byte("new"), NewEtag: "1"},
+			{Type: StateChangeDeleted, Key: "b", OldEtag: "1"},
+		}, changes)
+	})
+
+	t.Run("stores without etags", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		srv := &scriptedStateDaprServer{script: [][]*pb.BulkStateItem{
+			{{Key: "a", Data: []byte("1")}},
+			{{Key: "a", Data: []byte("1")}},
+			{{Key: "a", Data: []byte("2")}},
+		}}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		ch, err := c.(StateWatcher).WatchState(ctx, "store", []string{"a"}, 10*time.Millisecond)
+		require.NoError(t, err)
+
+		changes := receiveStateChanges(t, ch, 1)
+		assert.Equal(t, []StateChange{{Type: StateChangeUpdated, Key: "a", Value: []byte("2")}}, changes)
+	})
+
+	t.Run("failed reads keep the previous state", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		srv := &scriptedStateDaprServer{script: [][]*pb.BulkStateItem{
+			{{Key: "a", Data: []byte("1"), Etag: "1"}},
+			{{Key: "a", Error: "unavailable"}},
+			{{Key: "a", Data: []byte("2"), Etag: "2"}},
+		}}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		ch, err := c.(StateWatcher).WatchState(ctx, "store", []string{"a"}, 10*time.Millisecond)
+		require.NoError(t, err)
+
+		changes := receiveStateChanges(t, ch, 1)
+		assert.Equal(t, []StateChange{{Type: StateChangeUpdated, Key: "a", Value: []byte("2"), OldEtag: "1", NewEtag: "2"}}, changes)
+	})
+
+	t.Run("channel closes on cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		srv := &scriptedStateDaprServer{script: [][]*pb.BulkStateItem{{{Key: "a", Data: []byte("1"), Etag: "1"}}}}
+		c, closer := getTestClientWithServer(context.Background(), srv)
+		defer closer()
+
+		ch, err := c.(StateWatcher).WatchState(ctx, "store", []string{"a"}, 10*time.Millisecond)
+		require.NoError(t, err)
+		cancel()
+
+		select {
+		case _, ok := <-ch:
+			assert.False(t, ok)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "channel not closed")
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		ctx := context.Background()
+		c, closer := getTestClientWithServer(ctx, &scriptedStateDaprServer{})
+		defer closer()
+
+		_, err := c.(StateWatcher).WatchState(ctx, "", []string{"a"}, time.Second)
+		assert.Error(t, err)
+		_, err = c.(StateWatcher).WatchState(ctx, "store", nil, time.Second)
+		assert.Error(t, err)
+		_, err = c.(StateWatcher).WatchState(ctx, "store", []string{"a"}, 0)
+		assert.Error(t, err)
+
+		keys := make([]string, MaxWatchStateKeys+1)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("key-%d", i)
+		}
+		_, err = c.(StateWatcher).WatchState(ctx, "store", keys, time.Second)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+// StoreDefaults returns a copy of the default metadata set with
+// WithStoreDefaults for the named component, or nil if there is none.
+func (c *GRPCClient) StoreDefaults(componentName string) map[string]string {
+	defaults, ok := c.storeDefaults[componentName]
+	if !ok {
+		return nil
+	}
+	return mergeMetadata(defaults, nil)
+}
+
+// withStoreDefaults returns meta merged over the default metadata of the named
+// component. meta is returned as is if the component has no defaults, and is
+// never modified.
+func (c *GRPCClient) withStoreDefaults(componentName string, meta map[string]string) map[string]string {
+	defaults, ok := c.storeDefaults[componentName]
+	if !ok {
+		return meta
+	}
+	return mergeMetadata(defaults, meta)
+}
+
+// mergeMetadata returns a new map with the entries of defaults, overridden
+// key-by-key by the entries of meta.
+func mergeMetadata(defaults, meta map[string]string) map[string]string {
+	merged := make(map[string]string, len(defaults)+len(meta))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range meta {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// metadataRecordingDaprServer records the metadata of the last call of each kind.
+type metadataRecordingDaprServer struct {
+	testDaprServer
+	getState         map[string]string
+	saveState        map[string]string
+	deleteState      map[string]string
+	getSecret        map[string]string
+	getConfiguration map[string]string
+}
+
+func (s *metadataRecordingDaprServer) GetState(ctx context.Context, req *pb.GetStateRequest) (*pb.GetStateResponse, error) {
+	s.getState = req.Metadata
+	return s.testDaprServer.GetState(ctx, req)
+}
+
+func (s *metadataRecordingDaprServer) SaveState(ctx context.Context, req *pb.SaveStateRequest) (*empty.Empty, error) {
+	s.saveState = req.States[0].Metadata
+	return s.testDaprServer.SaveState(ctx, req)
+}
+
+func (s *metadataRecordingDaprServer) DeleteState(ctx context.Context, req *pb.DeleteStateRequest) (*empty.Empty, error) {
+	s.deleteState = req.Metadata
+	return s.testDaprServer.DeleteState(ctx, req)
+}
+
+func (s *metadataRecordingDaprServer) GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.GetSecretResponse, error) {
+	s.getSecret = req.Metadata
+	return s.testDaprServer.GetSecret(ctx, req)
+}
+
+func (s *metadataRecordingDaprServer) GetConfiguration(ctx context.Context, req *pb.GetConfigurationRequest) (*pb.GetConfigurationResponse, error) {
+	s.getConfiguration = req.Metadata
+	return s.testDaprServer.GetConfiguration(ctx, req)
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestStoreDefaults$
+func TestStoreDefaults(t *testing.T) {
+	ctx := context.Background()
+	srv := &metadataRecordingDaprServer{
+		testDaprServer: testDaprServer{state: make(map[string][]byte)},
+	}
+	c, closer := getTestClientWithServer(ctx, srv,
+		WithStoreDefaults(testStore, map[string]string{"partitionKey": "default", "ttlInSeconds": "60"}),
+		WithStoreDefaults("secrets", map[string]string{"version": "2"}),
+		WithStoreDefaults("config", map[string]string{"label": "prod"}),
+	)
+	defer closer()
+
+	t.Run("accessor", func(t *testing.T) {
+		assert.Equal(t, map[string]string{"version": "2"}, c.(StoreDefaulter).StoreDefaults("secrets"))
+		assert.Nil(t, c.(StoreDefaulter).StoreDefaults("other"))
+
+		// The returned map is a copy.
+		c.(StoreDefaulter).StoreDefaults("secrets")["version"] = "3"
+		assert.Equal(t, "2", c.(StoreDefaulter).StoreDefaults("secrets")["version"])
+	})
+
+	t.Run("state calls use defaults", func(t *testing.T) {
+		require.NoError(t, c.SaveState(ctx, testStore, "key1", []byte(testData), nil))
+		assert.Equal(t, map[string]string{"partitionKey": "default", "ttlInSeconds": "60"}, srv.saveState)
+
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"partitionKey": "default", "ttlInSeconds": "60"}, srv.getState)
+	})
+
+	t.Run("call metadata overrides defaults", func(t *testing.T) {
+		meta := map[string]string{"partitionKey": "custom"}
+		require.NoError(t, c.DeleteState(ctx, testStore, "key1", meta))
+		assert.Equal(t, map[string]string{"partitionKey": "custom", "ttlInSeconds": "60"}, srv.deleteState)
+		assert.Equal(t, map[string]string{"partitionKey": "custom"}, meta, "call metadata is not modified")
+	})
+
+	t.Run("secret and configuration calls use defaults", func(t *testing.T) {
+		_, err := c.GetSecret(ctx, "secrets", "key1", map[string]string{"other": "value"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"version": "2", "other": "value"}, srv.getSecret)
+
+		_, err = c.GetConfigurationItems(ctx, "config", []string{"key1"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"label": "prod"}, srv.getConfiguration)
+	})
+
+	t.Run("unrelated components are unaffected", func(t *testing.T) {
+		require.NoError(t, c.SaveState(ctx, "other", "key1", []byte(testData), map[string]string{"foo": "bar"}))
+		assert.Equal(t, map[string]string{"foo": "bar"}, srv.saveState)
+
+		_, err := c.GetSecret(ctx, "other", "key1", nil)
+		require.NoError(t, err)
+		assert.Empty(t, srv.getSecret)
+	})
+}
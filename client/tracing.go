@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+const tracestateKey = "tracestate"
+
+// WithTracePropagation sends the W3C trace context and baggage of the call
+// context on every call, as set by the services for the callback being
+// handled or with common.ContextWithTraceContext and common.ContextWithBaggage,
+// so that they carry over to the downstream apps. Values set in the outgoing
+// metadata of a call take precedence. Baggage members beyond the size limits
+// of the W3C specification are dropped with a warning.
+func WithTracePropagation() ClientOption {
+	return func(o *clientOptions) {
+		o.tracePropagation = true
+	}
+}
+
+// withTraceMetadata adds the trace context and baggage of ctx to its outgoing
+// metadata, except the keys already set for the call.
+func withTraceMetadata(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	var kv []string
+	if tc, ok := common.TraceContextFromContext(ctx); ok && len(md.Get(traceparentKey)) == 0 && tc.TraceParent != "" {
+		kv = append(kv, traceparentKey, tc.TraceParent)
+		if tc.TraceState != "" && len(md.Get(tracestateKey)) == 0 {
+			kv = append(kv, tracestateKey, tc.TraceState)
+		}
+	}
+	if b := common.BaggageFromContext(ctx); len(b) > 0 && len(md.Get(common.BaggageMetadataKey)) == 0 {
+		value, dropped := common.FormatBaggage(b)
+		if dropped > 0 {
+			logger.Printf("warning: truncated outgoing baggage: dropped %d members beyond the size limits", dropped)
+		}
+		if value != "" {
+			kv = append(kv, common.BaggageMetadataKey, value)
+		}
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+func tracePropagationUnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withTraceMetadata(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+func tracePropagationStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withTraceMetadata(ctx), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+func TestWithTraceMetadata(t *testing.T) {
+	ctx := common.ContextWithTraceContext(context.Background(), common.TraceContext{
+		TraceParent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		TraceState:  "congo=t61rcWkgMzE",
+	})
+	ctx = common.ContextWithBaggage(ctx, map[string]string{"tenant": "acme", "flags": "a b"})
+
+	md, _ := metadata.FromOutgoingContext(withTraceMetadata(ctx))
+	assert.Equal(t, []string{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}, md.Get(traceparentKey))
+	assert.Equal(t, []string{"congo=t61rcWkgMzE"}, md.Get(tracestateKey))
+	assert.Equal(t, []string{"flags=a%20b,tenant=acme"}, md.Get(common.BaggageMetadataKey))
+
+	t.Run("metadata of the call wins", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(ctx, traceparentKey, "explicit", common.BaggageMetadataKey, "tenant=other")
+		md, _ := metadata.FromOutgoingContext(withTraceMetadata(ctx))
+		assert.Equal(t, []string{"explicit"}, md.Get(traceparentKey))
+		assert.Empty(t, md.Get(tracestateKey))
+		assert.Equal(t, []string{"tenant=other"}, md.Get(common.BaggageMetadataKey))
+	})
+
+	t.Run("nothing to propagate", func(t *testing.T) {
+		ctx := context.Background()
+		assert.Equal(t, ctx, withTraceMetadata(ctx))
+	})
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/version"
+)
+
+// WithUserAgentSuffix appends s, such as the name of the component of the app,
+// to the user agent sent by the client in the dapr-sdk-user-agent metadata of
+// every call, separated by a space. It can be used several times.
+func WithUserAgentSuffix(s string) ClientOption {
+	return func(o *clientOptions) {
+		if s = strings.TrimSpace(s); s != "" {
+			o.userAgentSuffixes = append(o.userAgentSuffixes, s)
+		}
+	}
+}
+
+// sdkMetadata returns the metadata identifying the SDK, set on every call.
+func (o *clientOptions) sdkMetadata() []string {
+	ua := strings.Join(append([]string{userAgent()}, o.userAgentSuffixes...), " ")
+	return []string{
+		common.SDKVersionMetadataKey, strings.TrimSpace(version.SDKVersion),
+		common.SDKUserAgentMetadataKey, ua,
+	}
+}
+
+// withSDKMetadata adds the pairs of kv to the outgoing metadata of ctx, except
+// the keys already set for the call, so that a call can override them.
+func withSDKMetadata(ctx context.Context, kv []string) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if len(md.Get(kv[i])) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, kv[i], kv[i+1])
+		}
+	}
+	return ctx
+}
+
+func sdkMetadataUnaryInterceptor(kv []string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withSDKMetadata(ctx, kv), method, req, reply, cc, opts...)
+	}
+}
+
+func sdkMetadataStreamInterceptor(kv []string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withSDKMetadata(ctx, kv), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/version"
+)
+
+// sdkMetadataDaprServer records the incoming metadata of the calls, by method.
+type sdkMetadataDaprServer struct {
+	testDaprServer
+
+	lock sync.Mutex
+	md   map[string]metadata.MD
+}
+
+func (s *sdkMetadataDaprServer) record(ctx context.Context, method string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.md[method] = md
+}
+
+func (s *sdkMetadataDaprServer) GetState(ctx context.Context, req *pb.GetStateRequest) (*pb.GetStateResponse, error) {
+	s.record(ctx, "GetState")
+	return &pb.GetStateResponse{}, nil
+}
+
+func (s *sdkMetadataDaprServer) PublishEvent(ctx context.Context, req *pb.PublishEventRequest) (*empty.Empty, error) {
+	s.record(ctx, "PublishEvent")
+	return &empty.Empty{}, nil
+}
+
+func (s *sdkMetadataDaprServer) InvokeService(ctx context.Context, req *pb.InvokeServiceRequest) (*commonv1pb.InvokeResponse, error) {
+	s.record(ctx, "InvokeService")
+	return &commonv1pb.InvokeResponse{}, nil
+}
+
+func TestSDKMetadata(t *testing.T) {
+	ctx := context.Background()
+	sdkVersion := strings.TrimSpace(version.SDKVersion)
+
+	t.Run("state, publish and invoke", func(t *testing.T) {
+		srv := &sdkMetadataDaprServer{md: map[string]metadata.MD{}}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		_, err := c.GetState(ctx, "store", "key", nil)
+		require.NoError(t, err)
+		require.NoError(t, c.PublishEvent(ctx, "messages", "test", []byte("data")))
+		_, err = c.InvokeMethod(ctx, "app", "method", "post")
+		require.NoError(t, err)
+
+		for _, method := range []string{"GetState", "PublishEvent", "InvokeService"} {
+			md := srv.md[method]
+			assert.Equal(t, []string{sdkVersion}, md.Get(common.SDKVersionMetadataKey), method)
+			assert.Equal(t, []string{"dapr-sdk-go/" + sdkVersion}, md.Get(common.SDKUserAgentMetadataKey), method)
+		}
+	})
+
+	t.Run("suffixes", func(t *testing.T) {
+		srv := &sdkMetadataDaprServer{md: map[string]metadata.MD{}}
+		c, closer := getTestClientWithServer(ctx, srv, WithUserAgentSuffix("orders"), WithUserAgentSuffix(" "), WithUserAgentSuffix("worker/2"))
+		defer closer()
+
+		_, err := c.GetState(ctx, "store", "key", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dapr-sdk-go/" + sdkVersion + " orders worker/2"}, srv.md["GetState"].Get(common.SDKUserAgentMetadataKey))
+	})
+
+	t.Run("per-call override", func(t *testing.T) {
+		srv := &sdkMetadataDaprServer{md: map[string]metadata.MD{}}
+		c, closer := getTestClientWithServer(ctx, srv, WithUserAgentSuffix("orders"))
+		defer closer()
+
+		callCtx := metadata.AppendToOutgoingContext(ctx, common.SDKUserAgentMetadataKey, "custom")
+		_, err := c.GetState(callCtx, "store", "key", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"custom"}, srv.md["GetState"].Get(common.SDKUserAgentMetadataKey))
+		assert.Equal(t, []string{sdkVersion}, srv.md["GetState"].Get(common.SDKVersionMetadataKey))
+	})
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// MaxMetadataKeySize is the maximum size, in bytes, of the metadata keys sent
+// to the sidecar.
+const MaxMetadataKeySize = 256
+
+var errStreamNotOpen = errors.New("stream not open: its request was not sent")
+
+const (
+	reasonEmpty = "must not be empty"
+	reasonNil   = "must not be nil"
+)
+
+// ErrInvalidArgument is returned, before any call to the sidecar, when an
+// argument of a client method is invalid, such as an empty store name.
+// Use errors.As to inspect it.
+type ErrInvalidArgument struct {
+	// Method is the name of the method, such as "GetState".
+	Method string
+	// Field is the name of the invalid argument, such as "storeName".
+	Field string
+	// Reason explains why the argument is invalid.
+	Reason string
+}
+
+func (e *ErrInvalidArgument) Error() string {
+	return fmt.Sprintf("%s: invalid argument %s: %s", e.Method, e.Field, e.Reason)
+}
+
+func invalidArgument(method, field, reason string) error {
+	return &ErrInvalidArgument{Method: method, Field: field, Reason: reason}
+}
+
+// requireArgs returns an ErrInvalidArgument for the first empty value of
+// fieldsAndValues, a list of argument names each followed by its value.
+func requireArgs(method string, fieldsAndValues ...string) error {
+	for i := 0; i+1 < len(fieldsAndValues); i += 2 {
+		if fieldsAndValues[i+1] == "" {
+			return invalidArgument(method, fieldsAndValues[i], reasonEmpty)
+		}
+	}
+	return nil
+}
+
+// validateRequest checks the arguments of the Dapr API request req, naming
+// them as in the client methods rather than as in the protos.
+func validateRequest(method string, req any) error {
+	var err error
+	switch r := req.(type) {
+	case *pb.GetStateRequest:
+		err = requireArgs(method, "storeName", r.StoreName, "key", r.Key)
+	case *pb.GetBulkStateRequest:
+		err = requireArgs(method, "storeName", r.StoreName)
+		if err == nil && len(r.Keys) == 0 {
+			err = invalidArgument(method, "keys", reasonEmpty)
+		}
+		for _, k := range r.Keys {
+			if err == nil {
+				err = requireArgs(method, "key", k)
+			}
+		}
+	case *pb.SaveStateRequest:
+		err = requireArgs(method, "storeName", r.StoreName)
+		for _, s := range r.States {
+			if err == nil {
+				err = requireArgs(method, "key", s.GetKey())
+			}
+		}
+	case *pb.QueryStateRequest:
+		err = requireArgs(method, "storeName", r.StoreName, "query", r.Query)
+	case *pb.DeleteStateRequest:
+		err = requireArgs(method, "storeName", r.StoreName, "key", r.Key)
+	case *pb.DeleteBulkStateRequest:
+		err = requireArgs(method, "storeName", r.StoreName)
+		for _, s := range r.States {
+			if err == nil {
+				err = requireArgs(method, "key", s.GetKey())
+			}
+		}
+	case *pb.ExecuteStateTransactionRequest:
+		err = requireArgs(method, "storeName", r.StoreName)
+		for _, op := range r.Operations {
+			if err == nil {
+				err = requireArgs(method, "key", op.GetRequest().GetKey())
+			}
+		}
+	case *pb.PublishEventRequest:
+		err = requireArgs(method, "pubsubName", r.PubsubName, "topicName", r.Topic)
+	case *pb.BulkPublishRequest:
+		err = requireArgs(method, "pubsubName", r.PubsubName, "topicName", r.Topic)
+	case *pb.InvokeServiceRequest:
+		err = requireArgs(method, "appID", r.Id, "methodName", r.GetMessage().GetMethod())
+	case *pb.InvokeBindingRequest:
+		err = requireArgs(method, "name", r.Name, "operation", r.Operation)
+	case *pb.GetSecretRequest:
+		err = requireArgs(method, "storeName", r.StoreName, "key", r.Key)
+	case *pb.GetBulkSecretRequest:
+		err = requireArgs(method, "storeName", r.StoreName)
+	case *pb.GetConfigurationRequest:
+		err = requireArgs(method, "storeName", r.StoreName)
+	case *pb.SubscribeConfigurationRequest:
+		err = requireArgs(method, "storeName", r.StoreName)
+	case *pb.UnsubscribeConfigurationRequest:
+		err = requireArgs(method, "storeName", r.StoreName, "id", r.Id)
+	case *pb.TryLockRequest:
+		err = requireArgs(method, "storeName", r.StoreName, "resourceID", r.ResourceId, "lockOwner", r.LockOwner)
+	case *pb.UnlockRequest:
+		err = requireArgs(method, "storeName", r.StoreName, "resourceID", r.ResourceId, "lockOwner", r.LockOwner)
+	case *pb.SetMetadataRequest:
+		err = requireArgs(method, "key", r.Key)
+	case *pb.InvokeActorRequest:
+		err = requireArgs(method, "actorType", r.ActorType, "actorID", r.ActorId, "method", r.Method)
+	case *pb.GetActorStateRequest:
+		err = requireArgs(method, "actorType", r.ActorType, "actorID", r.ActorId, "keyName", r.Key)
+	case *pb.ExecuteActorStateTransactionRequest:
+		err = requireArgs(method, "actorType", r.ActorType, "actorID", r.ActorId)
+	case *pb.RegisterActorReminderRequest:
+		err = requireArgs(method, "actorType", r.ActorType, "actorID", r.ActorId, "name", r.Name)
+	case *pb.UnregisterActorReminderRequest:
+		err = requireArgs(method, "actorType", r.ActorType, "actorID", r.ActorId, "name", r.Name)
+	case *pb.RegisterActorTimerRequest:
+		err = requireArgs(method, "actorType", r.ActorType, "actorID", r.ActorId, "name", r.Name)
+	case *pb.UnregisterActorTimerRequest:
+		err = requireArgs(method, "actorType", r.ActorType, "actorID", r.ActorId, "name", r.Name)
+	case *pb.StartWorkflowRequest:
+		err = requireArgs(method, "component", r.WorkflowComponent, "workflowName", r.WorkflowName)
+	case *pb.TerminateWorkflowRequest:
+		err = requireArgs(method, "component", r.WorkflowComponent, "instanceID", r.InstanceId)
+	case *pb.PurgeWorkflowRequest:
+		err = requireArgs(method, "component", r.WorkflowComponent, "instanceID", r.InstanceId)
+	case *pb.GetWorkflowRequest:
+		err = requireArgs(method, "component", r.WorkflowComponent, "instanceID", r.InstanceId)
+	case *pb.RaiseEventWorkflowRequest:
+		err = requireArgs(method, "component", r.WorkflowComponent, "instanceID", r.InstanceId, "eventName", r.EventName)
+	}
+	if err != nil {
+		return err
+	}
+	if m, ok := req.(proto.Message); ok {
+		return validateMetadataKeys(method, m.ProtoReflect())
+	}
+	return nil
+}
+
+// validateMetadataKeys checks the size of the keys of the metadata fields of m
+// and of its nested messages.
+func validateMetadataKeys(method string, m protoreflect.Message) error {
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.Name() != "metadata" || fd.MapKey().Kind() != protoreflect.StringKind {
+				return true
+			}
+			v.Map().Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+				if len(k.String()) > MaxMetadataKeySize {
+					err = invalidArgument(method, "metadata", fmt.Sprintf("key %.32q... is longer than %d bytes", k.String(), MaxMetadataKeySize))
+				}
+				return err == nil
+			})
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			for i := 0; i < v.List().Len() && err == nil; i++ {
+				err = validateMetadataKeys(method, v.List().Get(i).Message())
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			err = validateMetadataKeys(method, v.Message())
+		}
+		return err == nil
+	})
+	return err
+}
+
+// sdkMethodKey is the context key of the name of the client method making the
+// calls.
+type sdkMethodKey struct{}
+
+// withSDKMethod returns ctx naming method as the client method making the
+// calls, for the client methods whose name is not the one of the Dapr API
+// method they call. The name set by an outer client method is kept.
+func withSDKMethod(ctx context.Context, method string) context.Context {
+	if ctx == nil || ctx.Value(sdkMethodKey{}) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sdkMethodKey{}, method)
+}
+
+// sdkMethod returns the name of the client method making the call of ctx to
+// the Dapr API method, which defaults to the name of the API method.
+func sdkMethod(ctx context.Context, method string) string {
+	if ctx != nil {
+		if name, ok := ctx.Value(sdkMethodKey{}).(string); ok {
+			return name
+		}
+	}
+	return path.Base(method)
+}
+
+func validationUnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		name := sdkMethod(ctx, method)
+		if ctx == nil {
+			return invalidArgument(name, "ctx", reasonNil)
+		}
+		if err := validateRequest(name, req); err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func validationStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		name := sdkMethod(ctx, method)
+		if ctx == nil {
+			return nil, invalidArgument(name, "ctx", reasonNil)
+		}
+		if desc.ClientStreams {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		return &validatedStream{ctx: ctx, method: name, open: func() (grpc.ClientStream, error) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}}, nil
+	}
+}
+
+// validatedStream defers the opening of a server stream until its request is
+// sent, so that an invalid request is rejected before the stream is opened.
+type validatedStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	method string
+	open   func() (grpc.ClientStream, error)
+}
+
+func (s *validatedStream) SendMsg(m any) error {
+	if s.ClientStream == nil {
+		if err := validateRequest(s.method, m); err != nil {
+			return err
+		}
+		stream, err := s.open()
+		if err != nil {
+			return err
+		}
+		s.ClientStream = stream
+	}
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *validatedStream) Context() context.Context {
+	if s.ClientStream == nil {
+		return s.ctx
+	}
+	return s.ClientStream.Context()
+}
+
+func (s *validatedStream) Header() (metadata.MD, error) {
+	if s.ClientStream == nil {
+		return nil, errStreamNotOpen
+	}
+	return s.ClientStream.Header()
+}
+
+func (s *validatedStream) Trailer() metadata.MD {
+	if s.ClientStream == nil {
+		return nil
+	}
+	return s.ClientStream.Trailer()
+}
+
+func (s *validatedStream) CloseSend() error {
+	if s.ClientStream == nil {
+		return errStreamNotOpen
+	}
+	return s.ClientStream.CloseSend()
+}
+
+func (s *validatedStream) RecvMsg(m any) error {
+	if s.ClientStream == nil {
+		return errStreamNotOpen
+	}
+	return s.ClientStream.RecvMsg(m)
+}
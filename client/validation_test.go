@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidation(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		call   func(c Client) error
+		method string
+		field  string
+	}{
+		{"GetState store", func(c Client) error {
+			_, err := c.GetState(ctx, "", "key", nil)
+			return err
+		}, "GetState", "storeName"},
+		{"GetState key", func(c Client) error {
+			_, err := c.GetState(ctx, "store", "", nil)
+			return err
+		}, "GetState", "key"},
+		{"GetBulkState store", func(c Client) error {
+			_, err := c.GetBulkState(ctx, "", []string{"key"}, nil, 1)
+			return err
+		}, "GetBulkState", "storeName"},
+		{"GetBulkState keys", func(c Client) error {
+			_, err := c.GetBulkState(ctx, "store", nil, nil, 1)
+			return err
+		}, "GetBulkState", "keys"},
+		{"SaveState store", func(c Client) error {
+			return c.SaveState(ctx, "", "key", []byte("v"), nil)
+		}, "SaveState", "storeName"},
+		{"SaveState key", func(c Client) error {
+			return c.SaveState(ctx, "store", "", []byte("v"), nil)
+		}, "SaveState", "key"},
+		{"DeleteState key", func(c Client) error {
+			return c.DeleteState(ctx, "store", "", nil)
+		}, "DeleteState", "key"},
+		{"DeleteStateWithETag key", func(c Client) error {
+			return c.DeleteStateWithETag(ctx, "store", "", nil, nil, nil)
+		}, "DeleteStateWithETag", "key"},
+		{"DeleteBulkStateItems key", func(c Client) error {
+			return c.DeleteBulkStateItems(ctx, "store", []*DeleteStateItem{{}})
+		}, "DeleteBulkStateItems", "key"},
+		{"SaveBulkState store", func(c Client) error {
+			return c.SaveBulkState(ctx, "", &SetStateItem{Key: "key"})
+		}, "SaveBulkState", "storeName"},
+		{"GetStateWithConsistency key", func(c Client) error {
+			_, err := c.GetStateWithConsistency(ctx, "store", "", nil, StateConsistencyStrong)
+			return err
+		}, "GetStateWithConsistency", "key"},
+		{"DeleteBulkState store", func(c Client) error {
+			return c.DeleteBulkState(ctx, "", []string{"key"}, nil)
+		}, "DeleteBulkState", "storeName"},
+		{"ExecuteStateTransaction store", func(c Client) error {
+			return c.ExecuteStateTransaction(ctx, "", nil, nil)
+		}, "ExecuteStateTransaction", "storeName"},
+		{"ExecuteStateTransaction key", func(c Client) error {
+			return c.ExecuteStateTransaction(ctx, "store", nil, []*StateOperation{{Type: StateOperationTypeUpsert, Item: &SetStateItem{}}})
+		}, "ExecuteStateTransaction", "key"},
+		{"QueryStateAlpha1 query", func(c Client) error {
+			_, err := c.QueryStateAlpha1(ctx, "store", "", nil)
+			return err
+		}, "QueryStateAlpha1", "query"},
+		{"PublishEvent pubsub", func(c Client) error {
+			return c.PublishEvent(ctx, "", "topic", nil)
+		}, "PublishEvent", "pubsubName"},
+		{"PublishEvent topic", func(c Client) error {
+			return c.PublishEvent(ctx, "pubsub", "", nil)
+		}, "PublishEvent", "topicName"},
+		{"PublishEvents pubsub", func(c Client) error {
+			return c.PublishEvents(ctx, "", "topic", []interface{}{"event"}).Error
+		}, "PublishEvents", "pubsubName"},
+		{"InvokeMethod app", func(c Client) error {
+			_, err := c.InvokeMethod(ctx, "", "method", "post")
+			return err
+		}, "InvokeMethod", "appID"},
+		{"InvokeMethodWithContent method", func(c Client) error {
+			_, err := c.InvokeMethodWithContent(ctx, "app", "", "post", &DataContent{})
+			return err
+		}, "InvokeMethodWithContent", "methodName"},
+		{"InvokeBinding name", func(c Client) error {
+			_, err := c.InvokeBinding(ctx, &InvokeBindingRequest{Operation: "create"})
+			return err
+		}, "InvokeBinding", "name"},
+		{"InvokeOutputBinding operation", func(c Client) error {
+			return c.InvokeOutputBinding(ctx, &InvokeBindingRequest{Name: "binding"})
+		}, "InvokeOutputBinding", "operation"},
+		{"GetSecret store", func(c Client) error {
+			_, err := c.GetSecret(ctx, "", "key", nil)
+			return err
+		}, "GetSecret", "storeName"},
+		{"GetSecret key", func(c Client) error {
+			_, err := c.GetSecret(ctx, "store", "", nil)
+			return err
+		}, "GetSecret", "key"},
+		{"GetBulkSecret store", func(c Client) error {
+			_, err := c.GetBulkSecret(ctx, "", nil)
+			return err
+		}, "GetBulkSecret", "storeName"},
+		{"GetConfigurationItem store", func(c Client) error {
+			_, err := c.GetConfigurationItem(ctx, "", "key")
+			return err
+		}, "GetConfigurationItem", "storeName"},
+		{"SubscribeConfigurationItems store", func(c Client) error {
+			_, err := c.SubscribeConfigurationItems(ctx, "", []string{"key"}, func(string, map[string]*ConfigurationItem) {})
+			return err
+		}, "SubscribeConfigurationItems", "storeName"},
+		{"UnsubscribeConfigurationItems id", func(c Client) error {
+			return c.UnsubscribeConfigurationItems(ctx, "store", "")
+		}, "UnsubscribeConfigurationItems", "id"},
+		{"TryLockAlpha1 store", func(c Client) error {
+			_, err := c.TryLockAlpha1(ctx, "", &LockRequest{ResourceID: "resource"})
+			return err
+		}, "TryLockAlpha1", "storeName"},
+		{"TryLockAlpha1 resource", func(c Client) error {
+			_, err := c.TryLockAlpha1(ctx, "store", &LockRequest{})
+			return err
+		}, "TryLockAlpha1", "resourceID"},
+		{"UnlockAlpha1 owner", func(c Client) error {
+			_, err := c.UnlockAlpha1(ctx, "store", &UnlockRequest{ResourceID: "resource"})
+			return err
+		}, "UnlockAlpha1", "lockOwner"},
+		{"SetMetadata key", func(c Client) error {
+			return c.SetMetadata(ctx, "", "value")
+		}, "SetMetadata", "key"},
+		{"InvokeActor type", func(c Client) error {
+			_, err := c.InvokeActor(ctx, &InvokeActorRequest{ActorID: "id", Method: "method"})
+			return err
+		}, "InvokeActor", "actorType"},
+		{"GetActorState key", func(c Client) error {
+			_, err := c.GetActorState(ctx, &GetActorStateRequest{ActorType: "type", ActorID: "id"})
+			return err
+		}, "GetActorState", "keyName"},
+		{"RegisterActorReminder name", func(c Client) error {
+			return c.RegisterActorReminder(ctx, &RegisterActorReminderRequest{ActorType: "type", ActorID: "id"})
+		}, "RegisterActorReminder", "name"},
+		{"UnregisterActorTimer id", func(c Client) error {
+			return c.UnregisterActorTimer(ctx, &UnregisterActorTimerRequest{ActorType: "type", Name: "name"})
+		}, "UnregisterActorTimer", "actorID"},
+		{"StartWorkflow name", func(c Client) error {
+			_, err := c.(WorkflowManager).StartWorkflow(ctx, "dapr", "", StartWorkflowOptions{})
+			return err
+		}, "StartWorkflow", "workflowName"},
+		{"TerminateWorkflow instance", func(c Client) error {
+			return c.(WorkflowManager).TerminateWorkflow(ctx, "dapr", "")
+		}, "TerminateWorkflow", "instanceID"},
+		{"GetWorkflow instance", func(c Client) error {
+			_, err := c.(WorkflowManager).GetWorkflow(ctx, "dapr", "")
+			return err
+		}, "GetWorkflow", "instanceID"},
+		{"RaiseWorkflowEvent event", func(c Client) error {
+			return c.(WorkflowManager).RaiseWorkflowEvent(ctx, "dapr", "instance", "", nil)
+		}, "RaiseWorkflowEvent", "eventName"},
+		{"nil context", func(c Client) error {
+			_, err := c.GetState(nil, "store", "key", nil) //nolint:staticcheck
+			return err
+		}, "GetState", "ctx"},
+		{"metadata key", func(c Client) error {
+			return c.SaveState(ctx, "store", "key", []byte("v"), map[string]string{strings.Repeat("k", MaxMetadataKeySize+1): "v"})
+		}, "SaveState", "metadata"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(testClient)
+			var invalid *ErrInvalidArgument
+			require.True(t, errors.As(err, &invalid), "want ErrInvalidArgument, got %v", err)
+			assert.Equal(t, tt.method, invalid.Method)
+			assert.Equal(t, tt.field, invalid.Field)
+			assert.Contains(t, err.Error(), tt.method)
+		})
+	}
+}
+
+func TestValidationAllowsValidRequests(t *testing.T) {
+	ctx := context.Background()
+	meta := map[string]string{strings.Repeat("k", MaxMetadataKeySize): "v"}
+	require.NoError(t, testClient.SaveState(ctx, "store", "key", []byte("v"), meta))
+	_, err := testClient.GetState(ctx, "store", "key", meta)
+	require.NoError(t, err)
+	require.NoError(t, testClient.PublishEvent(ctx, "pubsub", "topic", []byte("data")))
+}
@@ -31,6 +31,10 @@ func (c *GRPCClient) Wait(ctx context.Context, timeout time.Duration) error {
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	if c.httpTransport != nil {
+		return c.httpTransport.wait(timeoutCtx)
+	}
+
 	// SDKs for other languages implement Wait by attempting to connect to a TCP endpoint
 	// with a timeout. Go's SDKs handles more endpoints than just TCP ones. To simplify
 	// the code here, we rely on GRPCs connectivity state management instead.
@@ -0,0 +1,316 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// DefaultMaxWorkflowEventSize is the default cap on the serialized size of
+// workflow event payloads raised with RaiseWorkflowEvent.
+const DefaultMaxWorkflowEventSize = 4 << 20
+
+// WorkflowStatus is the runtime status of a workflow instance.
+type WorkflowStatus string
+
+const (
+	WorkflowStatusRunning        WorkflowStatus = "RUNNING"
+	WorkflowStatusCompleted      WorkflowStatus = "COMPLETED"
+	WorkflowStatusContinuedAsNew WorkflowStatus = "CONTINUED_AS_NEW"
+	WorkflowStatusFailed         WorkflowStatus = "FAILED"
+	WorkflowStatusCanceled       WorkflowStatus = "CANCELED"
+	WorkflowStatusTerminated     WorkflowStatus = "TERMINATED"
+	WorkflowStatusPending        WorkflowStatus = "PENDING"
+	WorkflowStatusSuspended      WorkflowStatus = "SUSPENDED"
+)
+
+// WorkflowState is the state of a workflow instance.
+type WorkflowState struct {
+	InstanceID    string
+	WorkflowName  string
+	Status        WorkflowStatus
+	CreatedAt     time.Time
+	LastUpdatedAt time.Time
+	Properties    map[string]string
+}
+
+var (
+	// ErrWorkflowInstanceNotFound is matched by the errors of the workflow
+	// methods called with an instance ID the runtime doesn't know.
+	ErrWorkflowInstanceNotFound = errors.New("workflow instance not found")
+	// ErrWorkflowAlreadyTerminal is matched by the errors of TerminateWorkflow
+	// when the instance has already completed, failed or been terminated.
+	ErrWorkflowAlreadyTerminal = errors.New("workflow instance already in a terminal state")
+	// ErrWorkflowEventNotAccepted is matched by the errors of
+	// RaiseWorkflowEvent when the instance no longer accepts events, as it is
+	// in a terminal state.
+	ErrWorkflowEventNotAccepted = errors.New("workflow instance does not accept events")
+)
+
+// ErrWorkflowAlreadyExists is returned by StartWorkflow when an instance with
+// the requested ID already exists, such as when a start with an idempotency
+// key is repeated.
+type ErrWorkflowAlreadyExists struct {
+	InstanceID string
+	// State is the state of the existing instance, nil if it couldn't be
+	// retrieved.
+	State *WorkflowState
+
+	err error
+}
+
+func (e *ErrWorkflowAlreadyExists) Error() string {
+	return fmt.Sprintf("workflow instance %s already exists", e.InstanceID)
+}
+
+func (e *ErrWorkflowAlreadyExists) Unwrap() error {
+	return e.err
+}
+
+// workflowError is an error of the workflow API matching kind with errors.Is.
+type workflowError struct {
+	kind error
+	err  error
+}
+
+func (e *workflowError) Error() string {
+	return fmt.Sprintf("%v: %v", e.kind, e.err)
+}
+
+func (e *workflowError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *workflowError) Unwrap() error {
+	return e.err
+}
+
+// The messages of the errors of the workflow engine, which the runtime returns
+// with code Unknown or Internal.
+var (
+	workflowNotFoundMessages = []string{"no such instance exists"}
+	workflowExistsMessages   = []string{"already exists"}
+	workflowTerminalMessages = []string{"already completed", "not running", "terminal state"}
+)
+
+// classifyWorkflowError returns err matching the kind of failure of the
+// workflow API: not found, or else terminal if the instance is in a terminal
+// state. It returns err unchanged if it is neither.
+func classifyWorkflowError(err error, terminal error) error {
+	s, _ := status.FromError(err)
+	switch {
+	case s.Code() == codes.NotFound || containsAny(s.Message(), workflowNotFoundMessages):
+		return &workflowError{kind: ErrWorkflowInstanceNotFound, err: err}
+	case terminal != nil && (s.Code() == codes.FailedPrecondition || containsAny(s.Message(), workflowTerminalMessages)):
+		return &workflowError{kind: terminal, err: err}
+	}
+	return err
+}
+
+// isWorkflowConflict reports whether err is the start of an instance whose ID
+// is already used.
+func isWorkflowConflict(err error) bool {
+	s, _ := status.FromError(err)
+	return s.Code() == codes.AlreadyExists || containsAny(s.Message(), workflowExistsMessages)
+}
+
+func containsAny(s string, substrs []string) bool {
+	s = strings.ToLower(s)
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// StartWorkflowOptions are the options of StartWorkflow.
+type StartWorkflowOptions struct {
+	// InstanceID is the ID of the instance, generated by the runtime if empty.
+	InstanceID string
+	// IdempotencyKey identifies the start, so that it can be safely retried:
+	// it is used as the instance ID, which it must equal if both are set, and
+	// repeating the start returns an *ErrWorkflowAlreadyExists carrying the
+	// state of the instance started first.
+	IdempotencyKey string
+	// Input is the input of the workflow, serialized as JSON unless it is
+	// already a []byte.
+	Input any
+	// Options are the options of the workflow component.
+	Options map[string]string
+}
+
+// StartWorkflow starts an instance of the workflow workflowName and returns
+// its ID. If the instance already exists, it returns an
+// *ErrWorkflowAlreadyExists with its state.
+func (c *GRPCClient) StartWorkflow(ctx context.Context, component, workflowName string, opts StartWorkflowOptions) (string, error) {
+	ctx = withSDKMethod(ctx, "StartWorkflow")
+	instanceID := opts.InstanceID
+	if opts.IdempotencyKey != "" {
+		if instanceID != "" && instanceID != opts.IdempotencyKey {
+			return "", fmt.Errorf("instance ID %s and idempotency key %s of workflow %s differ", instanceID, opts.IdempotencyKey, workflowName)
+		}
+		instanceID = opts.IdempotencyKey
+	}
+	input, err := workflowPayload(opts.Input)
+	if err != nil {
+		return "", fmt.Errorf("error serializing workflow input: %w", err)
+	}
+
+	resp, err := c.protoClient.StartWorkflowBeta1(c.withAuthToken(ctx), &pb.StartWorkflowRequest{
+		InstanceId:        instanceID,
+		WorkflowComponent: component,
+		WorkflowName:      workflowName,
+		Options:           opts.Options,
+		Input:             input,
+	})
+	if err != nil {
+		if instanceID != "" && isWorkflowConflict(err) {
+			e := &ErrWorkflowAlreadyExists{InstanceID: instanceID, err: err}
+			if state, serr := c.GetWorkflow(ctx, component, instanceID); serr == nil {
+				e.State = state
+			} else {
+				logger.Printf("error getting existing workflow %s: %v", instanceID, serr)
+			}
+			return "", e
+		}
+		return "", fmt.Errorf("error starting workflow %s: %w", workflowName, err)
+	}
+	return resp.GetInstanceId(), nil
+}
+
+// TerminateWorkflow terminates a workflow instance. Its error matches
+// ErrWorkflowAlreadyTerminal if the instance is already in a terminal state.
+func (c *GRPCClient) TerminateWorkflow(ctx context.Context, component, instanceID string) error {
+	ctx = withSDKMethod(ctx, "TerminateWorkflow")
+	_, err := c.protoClient.TerminateWorkflowBeta1(c.withAuthToken(ctx), &pb.TerminateWorkflowRequest{
+		InstanceId:        instanceID,
+		WorkflowComponent: component,
+	})
+	if err != nil {
+		return fmt.Errorf("error terminating workflow %s: %w", instanceID, classifyWorkflowError(err, ErrWorkflowAlreadyTerminal))
+	}
+	return nil
+}
+
+// PurgeWorkflow removes the state of a workflow instance in a terminal state.
+func (c *GRPCClient) PurgeWorkflow(ctx context.Context, component, instanceID string) error {
+	ctx = withSDKMethod(ctx, "PurgeWorkflow")
+	_, err := c.protoClient.PurgeWorkflowBeta1(c.withAuthToken(ctx), &pb.PurgeWorkflowRequest{
+		InstanceId:        instanceID,
+		WorkflowComponent: component,
+	})
+	if err != nil {
+		return fmt.Errorf("error purging workflow %s: %w", instanceID, classifyWorkflowError(err, nil))
+	}
+	return nil
+}
+
+// GetWorkflow retrieves the state of a workflow instance.
+func (c *GRPCClient) GetWorkflow(ctx context.Context, component, instanceID string) (*WorkflowState, error) {
+	ctx = withSDKMethod(ctx, "GetWorkflow")
+
+	resp, err := c.protoClient.GetWorkflowBeta1(c.withAuthToken(ctx), &pb.GetWorkflowRequest{
+		InstanceId:        instanceID,
+		WorkflowComponent: component,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting workflow %s: %w", instanceID, classifyWorkflowError(err, nil))
+	}
+
+	return &WorkflowState{
+		InstanceID:    resp.GetInstanceId(),
+		WorkflowName:  resp.GetWorkflowName(),
+		Status:        WorkflowStatus(resp.GetRuntimeStatus()),
+		CreatedAt:     resp.GetCreatedAt().AsTime(),
+		LastUpdatedAt: resp.GetLastUpdatedAt().AsTime(),
+		Properties:    resp.GetProperties(),
+	}, nil
+}
+
+// RaiseWorkflowEvent raises an event on a running workflow instance.
+// The payload is serialized as JSON, unless it is already a []byte.
+// Payloads larger than the cap set with WithMaxWorkflowEventSize are rejected
+// before they are sent. The error matches ErrWorkflowEventNotAccepted if the
+// instance is in a terminal state.
+func (c *GRPCClient) RaiseWorkflowEvent(ctx context.Context, component, instanceID, eventName string, payload any) error {
+	ctx = withSDKMethod(ctx, "RaiseWorkflowEvent")
+	data, err := workflowPayload(payload)
+	if err != nil {
+		return fmt.Errorf("error serializing workflow event payload: %w", err)
+	}
+	if c.maxWorkflowEventSize > 0 && len(data) > c.maxWorkflowEventSize {
+		return fmt.Errorf("workflow event payload of %d bytes exceeds the maximum of %d bytes", len(data), c.maxWorkflowEventSize)
+	}
+
+	_, err = c.protoClient.RaiseEventWorkflowBeta1(c.withAuthToken(ctx), &pb.RaiseEventWorkflowRequest{
+		InstanceId:        instanceID,
+		WorkflowComponent: component,
+		EventName:         eventName,
+		EventData:         data,
+	})
+	if err != nil {
+		return fmt.Errorf("error raising event %s on workflow %s: %w", eventName, instanceID, classifyWorkflowError(err, ErrWorkflowEventNotAccepted))
+	}
+	return nil
+}
+
+// workflowPayload serializes payload as JSON, unless it is already a []byte.
+func workflowPayload(payload any) ([]byte, error) {
+	switch d := payload.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return d, nil
+	default:
+		return jsonMarshal(d)
+	}
+}
+
+// WaitForWorkflowStatusChange polls the workflow instance every pollInterval and
+// returns its state as soon as the status differs from `from`.
+// Returns an error if the context is done before the status changes.
+func (c *GRPCClient) WaitForWorkflowStatusChange(ctx context.Context, component, instanceID string, from WorkflowStatus, pollInterval time.Duration) (*WorkflowState, error) {
+	ctx = withSDKMethod(ctx, "WaitForWorkflowStatusChange")
+	if pollInterval <= 0 {
+		return nil, errors.New("poll interval must be positive")
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		state, err := c.GetWorkflow(ctx, component, instanceID)
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if err == nil && state.Status != from {
+			return state, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error waiting for workflow %s to leave status %s: %w", instanceID, from, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
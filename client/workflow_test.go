@@ -0,0 +1,259 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// workflowDaprServer reports the workflow as RUNNING for the first runningPolls
+// calls to GetWorkflowBeta1 and as COMPLETED afterwards.
+type workflowDaprServer struct {
+	testDaprServer
+	lock         sync.Mutex
+	runningPolls int
+	polls        int
+	events       []*pb.RaiseEventWorkflowRequest
+}
+
+func (s *workflowDaprServer) GetWorkflowBeta1(ctx context.Context, req *pb.GetWorkflowRequest) (*pb.GetWorkflowResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.polls++
+	status := string(WorkflowStatusCompleted)
+	if s.runningPolls < 0 || s.polls <= s.runningPolls {
+		status = string(WorkflowStatusRunning)
+	}
+	return &pb.GetWorkflowResponse{
+		InstanceId:    req.InstanceId,
+		WorkflowName:  "wf",
+		RuntimeStatus: status,
+	}, nil
+}
+
+func (s *workflowDaprServer) RaiseEventWorkflowBeta1(ctx context.Context, req *pb.RaiseEventWorkflowRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.events = append(s.events, req)
+	return &empty.Empty{}, nil
+}
+
+func TestRaiseWorkflowEvent(t *testing.T) {
+	ctx := context.Background()
+	srv := &workflowDaprServer{}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	t.Run("missing arguments", func(t *testing.T) {
+		assert.Error(t, c.(WorkflowManager).RaiseWorkflowEvent(ctx, "", "id", "event", nil))
+		assert.Error(t, c.(WorkflowManager).RaiseWorkflowEvent(ctx, "dapr", "", "event", nil))
+		assert.Error(t, c.(WorkflowManager).RaiseWorkflowEvent(ctx, "dapr", "id", "", nil))
+	})
+
+	t.Run("payload is marshaled as JSON", func(t *testing.T) {
+		payload := struct {
+			Approved bool   `json:"approved"`
+			By       string `json:"by"`
+		}{Approved: true, By: "alice"}
+		require.NoError(t, c.(WorkflowManager).RaiseWorkflowEvent(ctx, "dapr", "id", "approval", payload))
+
+		srv.lock.Lock()
+		defer srv.lock.Unlock()
+		require.Len(t, srv.events, 1)
+		assert.Equal(t, "approval", srv.events[0].EventName)
+		assert.Equal(t, "id", srv.events[0].InstanceId)
+		assert.Equal(t, "dapr", srv.events[0].WorkflowComponent)
+		assert.JSONEq(t, `{"approved":true,"by":"alice"}`, string(srv.events[0].EventData))
+	})
+
+	t.Run("payload over the cap", func(t *testing.T) {
+		gc := *(c.(*GRPCClient))
+		gc.maxWorkflowEventSize = 8
+		err := gc.RaiseWorkflowEvent(ctx, "dapr", "id", "event", []byte("0123456789"))
+		assert.Error(t, err)
+
+		srv.lock.Lock()
+		defer srv.lock.Unlock()
+		assert.Len(t, srv.events, 1)
+	})
+}
+
+func TestWaitForWorkflowStatusChange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid poll interval", func(t *testing.T) {
+		_, err := testClient.(WorkflowManager).WaitForWorkflowStatusChange(ctx, "dapr", "id", WorkflowStatusRunning, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("status flips after two polls", func(t *testing.T) {
+		srv := &workflowDaprServer{runningPolls: 2}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		state, err := c.(WorkflowManager).WaitForWorkflowStatusChange(ctx, "dapr", "id", WorkflowStatusRunning, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, WorkflowStatusCompleted, state.Status)
+		assert.Equal(t, "id", state.InstanceID)
+		assert.Equal(t, 3, srv.polls)
+	})
+
+	t.Run("context timeout", func(t *testing.T) {
+		srv := &workflowDaprServer{runningPolls: -1}
+		c, closer := getTestClientWithServer(ctx, srv)
+		defer closer()
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		state, err := c.(WorkflowManager).WaitForWorkflowStatusChange(timeoutCtx, "dapr", "id", WorkflowStatusRunning, 10*time.Millisecond)
+		assert.Nil(t, state)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// workflowLifecycleDaprServer simulates the workflow engine: instances are
+// started once, and can be terminated and receive events until they are in a
+// terminal state.
+type workflowLifecycleDaprServer struct {
+	testDaprServer
+	lock      sync.Mutex
+	instances map[string]WorkflowStatus
+	starts    []*pb.StartWorkflowRequest
+}
+
+func (s *workflowLifecycleDaprServer) StartWorkflowBeta1(ctx context.Context, req *pb.StartWorkflowRequest) (*pb.StartWorkflowResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.starts = append(s.starts, req)
+	if _, ok := s.instances[req.InstanceId]; ok {
+		return nil, status.Errorf(codes.Internal, "error starting workflow '%s': orchestration instance already exists", req.WorkflowName)
+	}
+	s.instances[req.InstanceId] = WorkflowStatusRunning
+	return &pb.StartWorkflowResponse{InstanceId: req.InstanceId}, nil
+}
+
+func (s *workflowLifecycleDaprServer) GetWorkflowBeta1(ctx context.Context, req *pb.GetWorkflowRequest) (*pb.GetWorkflowResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	st, ok := s.instances[req.InstanceId]
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "error while getting workflow info on instance '%s': no such instance exists", req.InstanceId)
+	}
+	return &pb.GetWorkflowResponse{InstanceId: req.InstanceId, WorkflowName: "wf", RuntimeStatus: string(st)}, nil
+}
+
+func (s *workflowLifecycleDaprServer) check(id string) error {
+	st, ok := s.instances[id]
+	if !ok {
+		return status.Error(codes.NotFound, "no such instance exists")
+	}
+	if st != WorkflowStatusRunning {
+		return status.Errorf(codes.FailedPrecondition, "workflow instance %s is not running", id)
+	}
+	return nil
+}
+
+func (s *workflowLifecycleDaprServer) TerminateWorkflowBeta1(ctx context.Context, req *pb.TerminateWorkflowRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.check(req.InstanceId); err != nil {
+		return nil, err
+	}
+	s.instances[req.InstanceId] = WorkflowStatusTerminated
+	return &empty.Empty{}, nil
+}
+
+func (s *workflowLifecycleDaprServer) RaiseEventWorkflowBeta1(ctx context.Context, req *pb.RaiseEventWorkflowRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.check(req.InstanceId); err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *workflowLifecycleDaprServer) PurgeWorkflowBeta1(ctx context.Context, req *pb.PurgeWorkflowRequest) (*empty.Empty, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.instances[req.InstanceId]; !ok {
+		return nil, status.Errorf(codes.Unknown, "error purging workflow: no such instance exists")
+	}
+	delete(s.instances, req.InstanceId)
+	return &empty.Empty{}, nil
+}
+
+func TestWorkflowLifecycleErrors(t *testing.T) {
+	ctx := context.Background()
+	srv := &workflowLifecycleDaprServer{instances: map[string]WorkflowStatus{}}
+	c, closer := getTestClientWithServer(ctx, srv)
+	defer closer()
+
+	t.Run("start with an idempotency key", func(t *testing.T) {
+		opts := StartWorkflowOptions{IdempotencyKey: "order-1", Input: map[string]int{"amount": 3}}
+		id, err := c.(WorkflowManager).StartWorkflow(ctx, "dapr", "wf", opts)
+		require.NoError(t, err)
+		assert.Equal(t, "order-1", id)
+
+		_, err = c.(WorkflowManager).StartWorkflow(ctx, "dapr", "wf", opts)
+		var exists *ErrWorkflowAlreadyExists
+		require.ErrorAs(t, err, &exists)
+		assert.Equal(t, "order-1", exists.InstanceID)
+		require.NotNil(t, exists.State)
+		assert.Equal(t, WorkflowStatusRunning, exists.State.Status)
+
+		srv.lock.Lock()
+		defer srv.lock.Unlock()
+		require.Len(t, srv.starts, 2)
+		assert.Equal(t, "order-1", srv.starts[0].InstanceId)
+		assert.JSONEq(t, `{"amount":3}`, string(srv.starts[0].Input))
+	})
+
+	t.Run("idempotency key and instance ID differ", func(t *testing.T) {
+		_, err := c.(WorkflowManager).StartWorkflow(ctx, "dapr", "wf", StartWorkflowOptions{InstanceID: "a", IdempotencyKey: "b"})
+		assert.Error(t, err)
+	})
+
+	t.Run("terminal instance", func(t *testing.T) {
+		require.NoError(t, c.(WorkflowManager).TerminateWorkflow(ctx, "dapr", "order-1"))
+
+		err := c.(WorkflowManager).TerminateWorkflow(ctx, "dapr", "order-1")
+		assert.ErrorIs(t, err, ErrWorkflowAlreadyTerminal)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		err = c.(WorkflowManager).RaiseWorkflowEvent(ctx, "dapr", "order-1", "approval", nil)
+		assert.ErrorIs(t, err, ErrWorkflowEventNotAccepted)
+		assert.NotErrorIs(t, err, ErrWorkflowInstanceNotFound)
+	})
+
+	t.Run("unknown instance", func(t *testing.T) {
+		require.NoError(t, c.(WorkflowManager).PurgeWorkflow(ctx, "dapr", "order-1"))
+
+		assert.ErrorIs(t, c.(WorkflowManager).PurgeWorkflow(ctx, "dapr", "order-1"), ErrWorkflowInstanceNotFound)
+		assert.ErrorIs(t, c.(WorkflowManager).TerminateWorkflow(ctx, "dapr", "order-1"), ErrWorkflowInstanceNotFound)
+		assert.ErrorIs(t, c.(WorkflowManager).RaiseWorkflowEvent(ctx, "dapr", "order-1", "approval", nil), ErrWorkflowInstanceNotFound)
+		_, err := c.(WorkflowManager).GetWorkflow(ctx, "dapr", "order-1")
+		assert.ErrorIs(t, err, ErrWorkflowInstanceNotFound)
+	})
+}
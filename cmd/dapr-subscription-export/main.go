@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command dapr-subscription-export writes the topic subscriptions registered
+// by an app as Dapr Subscription resources, one YAML file per subscription.
+//
+// The subscriptions are registered by a function loaded from a Go plugin,
+// built from the package of the app registering its handlers:
+//
+//	func RegisterSubscriptions(s common.Service) error
+//
+//	go build -buildmode=plugin -o subscriptions.so ./subscriptions
+//	dapr-subscription-export -plugin subscriptions.so -out deploy/subscriptions
+//
+// Without -out, the resources are written to the standard output.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"plugin"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dapr/go-sdk/service/common"
+	daprd "github.com/dapr/go-sdk/service/grpc"
+)
+
+func main() {
+	pluginPath := flag.String("plugin", "", "path of the Go plugin registering the subscriptions")
+	symbol := flag.String("symbol", "RegisterSubscriptions", "name of the registration function in the plugin")
+	outDir := flag.String("out", "", "directory to write the resources to, instead of the standard output")
+	flag.Parse()
+
+	if *pluginPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	register, err := loadRegistration(*pluginPath, *symbol)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out, err := export(register)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *outDir == "" {
+		if _, err := os.Stdout.Write(out); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	files, err := writeResources(out, *outDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, f := range files {
+		log.Printf("wrote %s", f)
+	}
+}
+
+// loadRegistration returns the registration function named symbol of the
+// plugin at path.
+func loadRegistration(path, symbol string) (func(common.Service) error, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading plugin %s: %w", path, err)
+	}
+	sym, err := p.Lookup(symbol)
+	if err != nil {
+		return nil, err
+	}
+	register, ok := sym.(func(common.Service) error)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %T, want func(common.Service) error", symbol, sym)
+	}
+	return register, nil
+}
+
+// export registers the subscriptions on a service that is never started and
+// returns their resources.
+func export(register func(common.Service) error) ([]byte, error) {
+	s := daprd.NewServiceWithListener(nil)
+	if err := register(s); err != nil {
+		return nil, fmt.Errorf("error registering subscriptions: %w", err)
+	}
+	return s.(common.SubscriptionInspector).ExportSubscriptions(common.ExportFormatYAML)
+}
+
+// writeResources writes each resource of out to a file of dir named after the
+// resource, and returns the paths of the files.
+func writeResources(out []byte, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	dec := yaml.NewDecoder(bytes.NewReader(out))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			return files, nil
+		} else if err != nil {
+			return nil, err
+		}
+		var meta struct {
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+		}
+		if err := doc.Decode(&meta); err != nil {
+			return nil, err
+		}
+		if meta.Metadata.Name == "" {
+			return nil, errors.New("resource without name")
+		}
+
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, meta.Metadata.Name+".yaml")
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil { //nolint:gosec
+			return nil, err
+		}
+		files = append(files, path)
+	}
+}
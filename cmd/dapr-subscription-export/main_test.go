@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+func TestExportWritesOneFilePerSubscription(t *testing.T) {
+	fn := func(ctx context.Context, e *common.TopicEvent) (bool, error) { return false, nil }
+	out, err := export(func(s common.Service) error {
+		if err := s.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "orders", Route: "/orders"}, fn); err != nil {
+			return err
+		}
+		return s.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "payments", Route: "/payments", Scopes: []string{"billing"}}, fn)
+	})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	files, err := writeResources(out, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "messages-orders.yaml"), filepath.Join(dir, "messages-payments.yaml")}, files)
+
+	payments, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: dapr.io/v2alpha1
+kind: Subscription
+metadata:
+  name: messages-payments
+spec:
+  pubsubname: messages
+  topic: payments
+  routes:
+    default: /payments
+scopes:
+  - billing
+`, string(payments))
+}
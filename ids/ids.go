@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ids contains the generators of the identifiers created by the SDK,
+// such as bulk publish entry IDs and lock owners.
+package ids
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// Generator generates unique identifiers.
+type Generator interface {
+	NewID() string
+}
+
+// GeneratorFunc is a function that implements Generator.
+type GeneratorFunc func() string
+
+// NewID returns f().
+func (f GeneratorFunc) NewID() string {
+	return f()
+}
+
+// UUID returns a Generator of random (version 4) UUIDs. This is the default
+// generator used by the SDK.
+func UUID() Generator {
+	return GeneratorFunc(func() string {
+		return uuid.New().String()
+	})
+}
+
+// NewSequence returns a deterministic Generator, meant for tests, that yields
+// prefix1, prefix2, prefix3, and so on. It is safe for concurrent use.
+func NewSequence(prefix string) Generator {
+	var n uint64
+	return GeneratorFunc(func() string {
+		return prefix + strconv.FormatUint(atomic.AddUint64(&n, 1), 10)
+	})
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ids
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUUID(t *testing.T) {
+	g := UUID()
+	a, b := g.NewID(), g.NewID()
+	assert.NotEqual(t, a, b)
+	_, err := uuid.Parse(a)
+	assert.NoError(t, err)
+}
+
+func TestNewSequence(t *testing.T) {
+	g := NewSequence("id-")
+	assert.Equal(t, "id-1", g.NewID())
+	assert.Equal(t, "id-2", g.NewID())
+	assert.Equal(t, "other-1", NewSequence("other-").NewID())
+}
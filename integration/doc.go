@@ -0,0 +1,21 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package integration holds the contract tests of the SDK against a real
+// daprd, run with the integration build tag:
+//
+//	go test -tags integration ./integration/...
+//
+// The daprd binary is located, or downloaded, as documented in the harness
+// package, and the tests are skipped if it isn't available.
+package integration
@@ -0,0 +1,253 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package harness runs a daprd binary for the integration tests of the SDK,
+// with in-memory components, and connects a client to it.
+package harness
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+)
+
+const (
+	// DaprdPathEnvVar is the environment variable with the path of the daprd
+	// binary to run.
+	DaprdPathEnvVar = "DAPRD_PATH"
+	// PlacementPathEnvVar is the environment variable with the path of the
+	// placement binary, needed by the actors.
+	PlacementPathEnvVar = "DAPR_PLACEMENT_PATH"
+	// VersionEnvVar is the environment variable pinning the version of the
+	// binaries, such as "1.11.3". When set, a binary of another version is
+	// not used, and the pinned version is downloaded if it can't be found.
+	VersionEnvVar = "DAPR_INTEGRATION_VERSION"
+
+	// PubsubName, StateStoreName and CronBindingName are the names of the
+	// components loaded by default.
+	PubsubName      = "pubsub"
+	StateStoreName  = "statestore"
+	CronBindingName = "cron"
+
+	readyTimeout = 30 * time.Second
+	stopTimeout  = 5 * time.Second
+)
+
+// DefaultComponents are the components loaded by default: an in-memory state
+// store, also used by the actors, an in-memory pubsub and a cron input
+// binding firing every second.
+var DefaultComponents = []string{
+	component(StateStoreName, "state.in-memory", "actorStateStore", "true"),
+	component(PubsubName, "pubsub.in-memory"),
+	component(CronBindingName, "bindings.cron", "schedule", "@every 1s"),
+}
+
+// component returns the YAML of a component, with the metadata given as a
+// list of names each followed by its value.
+func component(name, typ string, metadata ...string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "apiVersion: dapr.io/v1alpha1\nkind: Component\nmetadata:\n  name: %s\nspec:\n  type: %s\n  version: v1\n", name, typ)
+	if len(metadata) > 0 {
+		sb.WriteString("  metadata:\n")
+		for i := 0; i+1 < len(metadata); i += 2 {
+			fmt.Fprintf(&sb, "  - name: %s\n    value: %q\n", metadata[i], metadata[i+1])
+		}
+	}
+	return sb.String()
+}
+
+// Options configure a daprd started by Start.
+type Options struct {
+	// AppID is the ID of the app, "integration" by default.
+	AppID string
+	// AppPort is the port the app listens on, if any.
+	AppPort int
+	// Components are the YAML documents of the components to load,
+	// DefaultComponents if nil.
+	Components []string
+	// Placement starts a placement service, needed by the actors. The test
+	// is skipped if its binary can't be found.
+	Placement bool
+}
+
+// Daprd is a running daprd.
+type Daprd struct {
+	// GRPCPort and HTTPPort are the ports of the APIs of the sidecar.
+	GRPCPort int
+	HTTPPort int
+	// Client is a client connected to the sidecar, closed when the test ends.
+	Client dapr.Client
+}
+
+// FreePort returns a free TCP port on the loopback interface, such as for the
+// app of a test.
+func FreePort(t testing.TB) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error finding a free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// Start starts a daprd, waits until it is ready and connects a client to it.
+// The test is skipped if no daprd binary is available. The processes are
+// stopped when the test ends.
+func Start(t testing.TB, opts Options) *Daprd {
+	t.Helper()
+	daprd := locate(t, "daprd", DaprdPathEnvVar)
+
+	if opts.AppID == "" {
+		opts.AppID = "integration"
+	}
+	if opts.Components == nil {
+		opts.Components = DefaultComponents
+	}
+	resources := t.TempDir()
+	for i, c := range opts.Components {
+		if err := os.WriteFile(filepath.Join(resources, fmt.Sprintf("component-%d.yaml", i)), []byte(c), 0o600); err != nil {
+			t.Fatalf("error writing component: %v", err)
+		}
+	}
+
+	d := &Daprd{GRPCPort: FreePort(t), HTTPPort: FreePort(t)}
+	args := []string{
+		"--app-id", opts.AppID,
+		"--dapr-grpc-port", strconv.Itoa(d.GRPCPort),
+		"--dapr-http-port", strconv.Itoa(d.HTTPPort),
+		"--dapr-internal-grpc-port", strconv.Itoa(FreePort(t)),
+		"--metrics-port", strconv.Itoa(FreePort(t)),
+		"--resources-path", resources,
+		"--log-level", "info",
+	}
+	if opts.AppPort != 0 {
+		args = append(args, "--app-port", strconv.Itoa(opts.AppPort))
+	}
+	if opts.Placement {
+		placementPort := FreePort(t)
+		startProcess(t, locate(t, "placement", PlacementPathEnvVar),
+			"--port", strconv.Itoa(placementPort),
+			"--healthz-port", strconv.Itoa(FreePort(t)),
+			"--metrics-port", strconv.Itoa(FreePort(t)))
+		args = append(args, "--placement-host-address", net.JoinHostPort("127.0.0.1", strconv.Itoa(placementPort)))
+	}
+	startProcess(t, daprd, args...)
+	waitReady(t, d.HTTPPort)
+
+	c, err := dapr.NewClientWithAddress(net.JoinHostPort("127.0.0.1", strconv.Itoa(d.GRPCPort)))
+	if err != nil {
+		t.Fatalf("error connecting to daprd: %v", err)
+	}
+	t.Cleanup(c.Close)
+	d.Client = c
+	return d
+}
+
+// startProcess starts the binary, logging its output with the test, and stops
+// it when the test ends: it is interrupted, then killed if it doesn't exit in
+// time.
+func startProcess(t testing.TB, binary string, args ...string) {
+	t.Helper()
+	cmd := exec.Command(binary, args...)
+	out := &testWriter{t: t, prefix: filepath.Base(binary) + ": "}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("error starting %s: %v", binary, err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		_ = cmd.Process.Signal(os.Interrupt)
+		select {
+		case <-exited:
+		case <-time.After(stopTimeout):
+			_ = cmd.Process.Kill()
+			<-exited
+		}
+		out.close()
+	})
+}
+
+// waitReady waits until the outbound health endpoint of the sidecar, which
+// doesn't depend on the app, reports it ready.
+func waitReady(t testing.TB, httpPort int) {
+	t.Helper()
+	url := fmt.Sprintf("http://127.0.0.1:%d/v1.0/healthz/outbound", httpPort)
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+	for {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode/100 == 2 {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("daprd not ready after %s", readyTimeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// testWriter logs the lines written to it with the test, until it is closed,
+// as logging after the end of a test panics.
+type testWriter struct {
+	t      testing.TB
+	prefix string
+
+	lock   sync.Mutex
+	buf    []byte
+	closed bool
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return len(p), nil
+	}
+	w.buf = append(w.buf, p...)
+	for {
+		i := strings.IndexByte(string(w.buf), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.t.Log(w.prefix + string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+func (w *testWriter) close() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.closed = true
+}
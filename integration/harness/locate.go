@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harness
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	releaseURL      = "https://github.com/dapr/dapr/releases/download/v%s/%s_%s_%s.tar.gz"
+	downloadTimeout = 2 * time.Minute
+)
+
+// downloadLock serializes the downloads of the tests of a package.
+var downloadLock sync.Mutex
+
+// locate returns the path of the named Dapr binary, skipping the test if it
+// can't be found. The binary is looked up, in order, at the path in envVar,
+// in the Dapr CLI install directory and in PATH. If a version is pinned with
+// VersionEnvVar, a binary of another version is ignored, and the pinned
+// version is downloaded if needed.
+func locate(t testing.TB, name, envVar string) string {
+	t.Helper()
+	if p := os.Getenv(envVar); p != "" {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("%s set to %s: %v", envVar, p, err)
+		}
+		return p
+	}
+
+	version := strings.TrimPrefix(os.Getenv(VersionEnvVar), "v")
+	var candidates []string
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".dapr", "bin", name))
+	}
+	if p, err := exec.LookPath(name); err == nil {
+		candidates = append(candidates, p)
+	}
+	for _, p := range candidates {
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		if version == "" || binaryVersion(p) == version {
+			return p
+		}
+	}
+
+	if version == "" {
+		t.Skipf("%s not found: set %s, or %s to download it", name, envVar, VersionEnvVar)
+	}
+	p, err := download(name, version)
+	if err != nil {
+		t.Skipf("%s %s not found and not downloaded: %v", name, version, err)
+	}
+	return p
+}
+
+// binaryVersion returns the version printed by the binary, or an empty string
+// if it can't be run.
+func binaryVersion(path string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "v")
+}
+
+// download downloads the release of the named binary to the user cache
+// directory, unless it is already there, and returns its path.
+func download(name, version string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return "", errors.New("downloads are not supported on windows")
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cache, "dapr-go-sdk", "integration", version)
+	path := filepath.Join(dir, name)
+
+	downloadLock.Lock()
+	defer downloadLock.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+	url := fmt.Sprintf(releaseURL, version, name, runtime.GOOS, runtime.GOARCH)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error downloading %s: %s", url, resp.Status)
+	}
+	if err := extract(resp.Body, name, path); err != nil {
+		return "", fmt.Errorf("error extracting %s: %w", url, err)
+	}
+	return path, nil
+}
+
+// extract writes the file of the tar.gz archive r named name to path, through
+// a temporary file so that an interrupted download isn't used.
+func extract(r io.Reader, name, path string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s not found in the archive", name)
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg || filepath.Base(h.Name) != name {
+			continue
+		}
+		tmp := path + ".tmp"
+		f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr) //nolint:gosec
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		return os.Rename(tmp, path)
+	}
+}
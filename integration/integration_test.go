@@ -0,0 +1,186 @@
+//go:build integration
+
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/actor"
+	dapr "github.com/dapr/go-sdk/client"
+	"github.com/dapr/go-sdk/integration/harness"
+	"github.com/dapr/go-sdk/service/common"
+	daprd "github.com/dapr/go-sdk/service/http"
+)
+
+const (
+	appID          = "integration"
+	testTopic      = "contract"
+	testActorType  = "contractActor"
+	receiveTimeout = 10 * time.Second
+)
+
+// app is the SDK service the sidecar calls back, recording what it receives.
+type app struct {
+	events   chan string
+	bindings chan struct{}
+}
+
+// startApp starts the SDK service, with actor registration if withActors,
+// and returns its port.
+func startApp(t *testing.T, withActors bool) (*app, int) {
+	t.Helper()
+	a := &app{events: make(chan string, 10), bindings: make(chan struct{}, 10)}
+	port := harness.FreePort(t)
+	s := daprd.NewService(fmt.Sprintf("127.0.0.1:%d", port))
+
+	require.NoError(t, s.AddTopicEventHandler(&common.Subscription{
+		PubsubName: harness.PubsubName,
+		Topic:      testTopic,
+		Route:      "/" + testTopic,
+	}, func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+		a.events <- fmt.Sprint(e.Data)
+		return false, nil
+	}))
+	require.NoError(t, s.AddServiceInvocationHandler("echo", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		return &common.Content{Data: in.Data, ContentType: in.ContentType}, nil
+	}))
+	require.NoError(t, s.AddBindingInvocationHandler(harness.CronBindingName, func(ctx context.Context, in *common.BindingEvent) ([]byte, error) {
+		select {
+		case a.bindings <- struct{}{}:
+		default:
+		}
+		return nil, nil
+	}))
+	if withActors {
+		s.RegisterActorImplFactoryContext(func() actor.ServerContext { return &contractActor{} })
+	}
+
+	go func() {
+		if err := s.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("error starting app: %v", err)
+		}
+	}()
+	t.Cleanup(func() { _ = s.Stop() })
+	return a, port
+}
+
+// contractActor echoes its ID and the data it's invoked with.
+type contractActor struct {
+	actor.ServerImplBaseCtx
+}
+
+func (a *contractActor) Type() string {
+	return testActorType
+}
+
+func (a *contractActor) Echo(ctx context.Context, in string) (string, error) {
+	return a.ID() + ":" + in, nil
+}
+
+func TestContract(t *testing.T) {
+	a, appPort := startApp(t, false)
+	d := harness.Start(t, harness.Options{AppID: appID, AppPort: appPort})
+	c := d.Client
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context)
+	}{
+		{"publish subscribe round trip", func(t *testing.T, ctx context.Context) {
+			require.Eventually(t, func() bool {
+				return c.PublishEvent(ctx, harness.PubsubName, testTopic, "hello") == nil
+			}, receiveTimeout, 100*time.Millisecond)
+			select {
+			case data := <-a.events:
+				assert.Equal(t, "hello", data)
+			case <-time.After(receiveTimeout):
+				t.Fatal("event not received")
+			}
+		}},
+		{"state CRUD with etags", func(t *testing.T, ctx context.Context) {
+			require.NoError(t, c.SaveState(ctx, harness.StateStoreName, "key", []byte("v1"), nil))
+			item, err := c.GetState(ctx, harness.StateStoreName, "key", nil)
+			require.NoError(t, err)
+			assert.Equal(t, "v1", string(item.Value))
+			require.NotEmpty(t, item.Etag)
+
+			require.NoError(t, c.SaveStateWithETag(ctx, harness.StateStoreName, "key", []byte("v2"), item.Etag, nil))
+			err = c.SaveStateWithETag(ctx, harness.StateStoreName, "key", []byte("v3"), item.Etag, nil)
+			assert.Error(t, err, "a stale etag is rejected")
+
+			require.NoError(t, c.DeleteState(ctx, harness.StateStoreName, "key", nil))
+			item, err = c.GetState(ctx, harness.StateStoreName, "key", nil)
+			require.NoError(t, err)
+			assert.Empty(t, item.Value)
+		}},
+		{"service invocation", func(t *testing.T, ctx context.Context) {
+			out, err := c.InvokeMethodWithContent(ctx, appID, "echo", http.MethodPost, &dapr.DataContent{
+				ContentType: "text/plain",
+				Data:        []byte("ping"),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "ping", string(out))
+		}},
+		{"input binding via cron", func(t *testing.T, ctx context.Context) {
+			select {
+			case <-a.bindings:
+			case <-time.After(receiveTimeout):
+				t.Fatal("cron binding not triggered")
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			tt.run(t, ctx)
+		})
+	}
+}
+
+func TestActorActivation(t *testing.T) {
+	_, appPort := startApp(t, true)
+	d := harness.Start(t, harness.Options{AppID: appID, AppPort: appPort, Placement: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	data, err := json.Marshal("ping")
+	require.NoError(t, err)
+	var resp *dapr.InvokeActorResponse
+	// The actor types of the app are registered with the placement service
+	// shortly after the sidecar is ready.
+	require.Eventually(t, func() bool {
+		resp, err = d.Client.InvokeActor(ctx, &dapr.InvokeActorRequest{
+			ActorType: testActorType,
+			ActorID:   "1",
+			Method:    "Echo",
+			Data:      data,
+		})
+		return err == nil
+	}, receiveTimeout, 200*time.Millisecond)
+	var out string
+	require.NoError(t, json.Unmarshal(resp.Data, &out))
+	assert.Equal(t, "1:ping", out)
+}
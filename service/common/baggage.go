@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+const (
+	// BaggageMetadataKey is the metadata key, and HTTP header, of the W3C
+	// baggage.
+	BaggageMetadataKey = "baggage"
+	// MaxBaggageMembers is the maximum number of members of the baggage.
+	MaxBaggageMembers = 64
+	// MaxBaggageBytes is the maximum size of the baggage header.
+	MaxBaggageBytes = 8192
+	// MaxBaggageMemberBytes is the maximum size of a member of the baggage.
+	MaxBaggageMemberBytes = 4096
+)
+
+type baggageKey struct{}
+
+// ContextWithBaggage returns a copy of ctx carrying the W3C baggage b, which
+// the Dapr client sends on outgoing calls when trace propagation is enabled.
+func ContextWithBaggage(ctx context.Context, b map[string]string) context.Context {
+	return context.WithValue(ctx, baggageKey{}, copyBaggage(b))
+}
+
+// BaggageFromContext returns a copy of the W3C baggage of the callback being
+// handled, taken from its baggage metadata, or set with ContextWithBaggage. It
+// returns nil if there is none.
+func BaggageFromContext(ctx context.Context) map[string]string {
+	b, _ := ctx.Value(baggageKey{}).(map[string]string)
+	return copyBaggage(b)
+}
+
+// ParseBaggage parses the values of baggage headers, ignoring the properties of
+// the members and the invalid members. The members beyond the limits of the
+// W3C specification are dropped, and their number is returned.
+func ParseBaggage(values ...string) (b map[string]string, dropped int) {
+	size := 0
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			member = strings.TrimSpace(member)
+			if member == "" {
+				continue
+			}
+			if len(b) == MaxBaggageMembers || len(member) > MaxBaggageMemberBytes || size+len(member) > MaxBaggageBytes {
+				dropped++
+				continue
+			}
+			kv, _, _ := strings.Cut(member, ";")
+			k, v, ok := strings.Cut(kv, "=")
+			k = strings.TrimSpace(k)
+			if !ok || !isBaggageKey(k) {
+				continue
+			}
+			v, ok = unescapeBaggageValue(strings.TrimSpace(v))
+			if !ok {
+				continue
+			}
+			if b == nil {
+				b = make(map[string]string)
+			}
+			b[k] = v
+			size += len(member) + 1
+		}
+	}
+	return b, dropped
+}
+
+// FormatBaggage formats b as the value of a baggage header, with the members
+// sorted by key. The invalid keys and the members beyond the limits of the W3C
+// specification are dropped, and their number is returned.
+func FormatBaggage(b map[string]string) (value string, dropped int) {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	n := 0
+	for _, k := range keys {
+		member := k + "=" + escapeBaggageValue(b[k])
+		size := len(member)
+		if sb.Len() > 0 {
+			size++
+		}
+		if !isBaggageKey(k) || n == MaxBaggageMembers || len(member) > MaxBaggageMemberBytes || sb.Len()+size > MaxBaggageBytes {
+			dropped++
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(member)
+		n++
+	}
+	return sb.String(), dropped
+}
+
+func copyBaggage(b map[string]string) map[string]string {
+	if b == nil {
+		return nil
+	}
+	c := make(map[string]string, len(b))
+	for k, v := range b {
+		c[k] = v
+	}
+	return c
+}
+
+// isBaggageKey reports whether k is a token, as defined by RFC 7230.
+func isBaggageKey(k string) bool {
+	if k == "" {
+		return false
+	}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isBaggageOctet reports whether c may appear unescaped in a baggage value.
+func isBaggageOctet(c byte) bool {
+	return c > ' ' && c < 0x7f && c != '"' && c != ',' && c != ';' && c != '\\' && c != '%'
+}
+
+const hexDigits = "0123456789ABCDEF"
+
+func escapeBaggageValue(v string) string {
+	var sb strings.Builder
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if isBaggageOctet(c) {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hexDigits[c>>4])
+		sb.WriteByte(hexDigits[c&0xf])
+	}
+	return sb.String()
+}
+
+func unescapeBaggageValue(v string) (string, bool) {
+	if !strings.Contains(v, "%") {
+		return v, true
+	}
+	var sb strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] != '%' {
+			sb.WriteByte(v[i])
+			continue
+		}
+		if i+2 >= len(v) {
+			return "", false
+		}
+		hi, lo := strings.IndexByte(hexDigits, upper(v[i+1])), strings.IndexByte(hexDigits, upper(v[i+2]))
+		if hi < 0 || lo < 0 {
+			return "", false
+		}
+		sb.WriteByte(byte(hi<<4 | lo))
+		i += 2
+	}
+	return sb.String(), true
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
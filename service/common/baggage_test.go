@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBaggage(t *testing.T) {
+	b, dropped := ParseBaggage("tenant=acme, flags=a%2Cb%3Bc;ttl=10", " invalid ,k@y=v", "user=j%C3%B6rg")
+	assert.Equal(t, map[string]string{"tenant": "acme", "flags": "a,b;c", "user": "jörg"}, b)
+	assert.Zero(t, dropped)
+
+	b, dropped = ParseBaggage("")
+	assert.Nil(t, b)
+	assert.Zero(t, dropped)
+
+	members := make([]string, MaxBaggageMembers+2)
+	for i := range members {
+		members[i] = fmt.Sprintf("k%d=v", i)
+	}
+	b, dropped = ParseBaggage(strings.Join(members, ","))
+	assert.Len(t, b, MaxBaggageMembers)
+	assert.Equal(t, 2, dropped)
+
+	long := "big=" + strings.Repeat("x", MaxBaggageMemberBytes)
+	b, dropped = ParseBaggage(long, "small=1")
+	assert.Equal(t, map[string]string{"small": "1"}, b)
+	assert.Equal(t, 1, dropped)
+}
+
+func TestFormatBaggage(t *testing.T) {
+	value, dropped := FormatBaggage(map[string]string{"tenant": "acme", "flags": "a,b;c", "user": "jörg", "bad key": "v"})
+	assert.Equal(t, "flags=a%2Cb%3Bc,tenant=acme,user=j%C3%B6rg", value)
+	assert.Equal(t, 1, dropped)
+
+	b, _ := ParseBaggage(value)
+	assert.Equal(t, map[string]string{"tenant": "acme", "flags": "a,b;c", "user": "jörg"}, b)
+
+	// Members beyond the total size are dropped.
+	large := make(map[string]string)
+	for i := 0; i < 4; i++ {
+		large[fmt.Sprintf("k%d", i)] = strings.Repeat("x", 3000)
+	}
+	value, dropped = FormatBaggage(large)
+	assert.LessOrEqual(t, len(value), MaxBaggageBytes)
+	assert.Equal(t, 2, dropped)
+}
+
+func TestBaggageFromContext(t *testing.T) {
+	assert.Nil(t, BaggageFromContext(context.Background()))
+
+	b := map[string]string{"tenant": "acme"}
+	ctx := ContextWithBaggage(context.Background(), b)
+	b["tenant"] = "changed"
+	got := BaggageFromContext(ctx)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, got)
+	got["tenant"] = "changed"
+	assert.Equal(t, "acme", BaggageFromContext(ctx)["tenant"])
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BindingError is an error of a binding invocation handler with a code and a
+// message, returned to the runtime for logging. A retryable error fails the
+// event, for the binding to deliver it again. A non-retryable error is logged
+// and the event acknowledged, so that it is not delivered again.
+type BindingError struct {
+	// Code identifies the error, such as "INVALID_PAYLOAD".
+	Code string `json:"errorCode"`
+	// Message describes the error.
+	Message string `json:"message"`
+	// Retryable is true if the event may succeed when delivered again.
+	Retryable bool `json:"-"`
+}
+
+func (e *BindingError) Error() string {
+	return fmt.Sprintf("binding error %s: %s", e.Code, e.Message)
+}
+
+// GRPCStatus returns the gRPC status of the error, with code Internal and an
+// errdetails.ErrorInfo detail whose reason is the error code.
+func (e *BindingError) GRPCStatus() *status.Status {
+	return NewStatusError(codes.Internal, e.Error(), &errdetails.ErrorInfo{Reason: e.Code}).GRPCStatus()
+}
+
+// JSON returns the error as a Dapr JSON error, with its errorCode and message.
+func (e *BindingError) JSON() []byte {
+	b, _ := json.Marshal(e)
+	return b
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AsHTTPRequest builds an HTTP request from the invocation, so that it can be
+// served by an existing http.Handler. The request carries the verb, method as
+// path, query string, headers, and body of the invocation; invocations without
+// an HTTP verb are mapped to POST.
+// Use ContentResponseWriter to capture the response of the handler.
+func (e *InvocationEvent) AsHTTPRequest() (*http.Request, error) {
+	verb := e.Verb
+	if verb == "" || verb == "NONE" {
+		verb = http.MethodPost
+	}
+	target := "/" + strings.TrimPrefix(e.Method, "/")
+	if e.QueryString != "" {
+		target += "?" + e.QueryString
+	}
+
+	req, err := http.NewRequest(verb, target, bytes.NewReader(e.Data))
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP request for method %s: %w", e.Method, err)
+	}
+	for k, values := range e.Metadata {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if e.ContentType != "" {
+		req.Header.Set("Content-Type", e.ContentType)
+	}
+	return req, nil
+}
+
+// ContentResponseWriter is an http.ResponseWriter collecting the response of
+// an http.Handler into a Content.
+type ContentResponseWriter struct {
+	header     http.Header
+	body       bytes.Buffer
+	statusCode int
+}
+
+// NewContentResponseWriter creates a new ContentResponseWriter.
+func NewContentResponseWriter() *ContentResponseWriter {
+	return &ContentResponseWriter{
+		header: http.Header{},
+	}
+}
+
+// Header returns the response headers.
+func (w *ContentResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// Write appends data to the response body.
+func (w *ContentResponseWriter) Write(data []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(data)
+}
+
+// WriteHeader records the response status code. Only the first call has an
+// effect.
+func (w *ContentResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+// StatusCode returns the response status code, http.StatusOK if none was set.
+func (w *ContentResponseWriter) StatusCode() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
+
+// Content returns the response body and content type.
+func (w *ContentResponseWriter) Content() *Content {
+	return &Content{
+		Data:        w.body.Bytes(),
+		ContentType: w.header.Get("Content-Type"),
+	}
+}
+
+type httpClientKey struct{}
+
+// ContextWithHTTPClient returns a copy of ctx carrying the HTTP client for the
+// outbound calls of the handlers.
+func ContextWithHTTPClient(ctx context.Context, c *http.Client) context.Context {
+	return context.WithValue(ctx, httpClientKey{}, c)
+}
+
+// HTTPClientFromContext returns the HTTP client placed in ctx by the service,
+// set with its WithHTTPClient option, or http.DefaultClient if there is none.
+// Handlers use it for their outbound calls, such as to chain bindings.
+func HTTPClientFromContext(ctx context.Context) *http.Client {
+	if c, ok := ctx.Value(httpClientKey{}).(*http.Client); ok && c != nil {
+		return c
+	}
+	return http.DefaultClient
+}
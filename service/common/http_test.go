@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvocationEventAsHTTPRequest(t *testing.T) {
+	e := &InvocationEvent{
+		Data:        []byte(`{"name":"dapr"}`),
+		ContentType: "application/json",
+		Verb:        http.MethodPost,
+		QueryString: "lang=en",
+		Method:      "greet",
+		Metadata:    map[string][]string{"X-Tenant-Id": {"tenant-1"}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/greet", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "en", r.URL.Query().Get("lang"))
+		assert.Equal(t, "tenant-1", r.Header.Get("X-Tenant-Id"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var in struct {
+			Name string `json:"name"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"greeting": "hello " + in.Name})
+	})
+
+	req, err := e.AsHTTPRequest()
+	require.NoError(t, err)
+	w := NewContentResponseWriter()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.StatusCode())
+	out := w.Content()
+	assert.Equal(t, "application/json", out.ContentType)
+	assert.JSONEq(t, `{"greeting":"hello dapr"}`, string(out.Data))
+}
+
+func TestInvocationEventAsHTTPRequestWithoutVerb(t *testing.T) {
+	req, err := (&InvocationEvent{Method: "/ping", Verb: "NONE"}).AsHTTPRequest()
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "/ping", req.URL.Path)
+
+	w := NewContentResponseWriter()
+	_, err = w.Write([]byte("pong"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode())
+	assert.Equal(t, []byte("pong"), w.Content().Data)
+}
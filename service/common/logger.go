@@ -0,0 +1,55 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"log"
+)
+
+// Fields passed to a LoggerFactory to describe the callback being handled.
+const (
+	LogFieldMethod  = "method"
+	LogFieldPubsub  = "pubsub"
+	LogFieldTopic   = "topic"
+	LogFieldRoute   = "route"
+	LogFieldBinding = "binding"
+)
+
+// Logger is the logger made available to callback handlers.
+// *log.Logger satisfies this interface.
+type Logger interface {
+	Printf(format string, v ...any)
+	Println(v ...any)
+}
+
+// LoggerFactory creates a request-scoped logger for a single callback,
+// given fields that describe it (see the LogField* constants).
+type LoggerFactory func(fields map[string]string) Logger
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying the given logger.
+func ContextWithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext returns the request-scoped logger placed in ctx by the
+// service's LoggerFactory, or the standard logger if there is none.
+func LoggerFromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(Logger); ok && logger != nil {
+		return logger
+	}
+	return log.Default()
+}
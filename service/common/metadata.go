@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// SDKVersionMetadataKey is the metadata key holding the version of the
+	// SDK of the caller, set by the Dapr client on its outgoing calls.
+	SDKVersionMetadataKey = "dapr-sdk-version"
+	// SDKUserAgentMetadataKey is the metadata key holding the user agent of
+	// the caller, set by the Dapr client on its outgoing calls.
+	SDKUserAgentMetadataKey = "dapr-sdk-user-agent"
+)
+
+type propagatedMetadataKey struct{}
+
+// PropagateMetadata copies the given keys from the incoming handler metadata
+// in ctx into the metadata that the Dapr client forwards on outgoing calls
+// made with the returned context.
+// Metadata set explicitly on an outgoing call takes precedence over
+// propagated values.
+func PropagateMetadata(ctx context.Context, keys ...string) context.Context {
+	in, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(keys) == 0 {
+		return ctx
+	}
+
+	md := PropagatedMetadata(ctx)
+	if md == nil {
+		md = metadata.MD{}
+	}
+	for _, k := range keys {
+		if v := in.Get(k); len(v) > 0 {
+			md.Set(k, v...)
+		}
+	}
+	if len(md) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, propagatedMetadataKey{}, md)
+}
+
+// PropagatedMetadata returns a copy of the metadata marked for propagation
+// with PropagateMetadata, or nil if there is none.
+func PropagatedMetadata(ctx context.Context) metadata.MD {
+	md, ok := ctx.Value(propagatedMetadataKey{}).(metadata.MD)
+	if !ok {
+		return nil
+	}
+	return md.Copy()
+}
+
+// CallerSDKVersion returns the version of the SDK of the caller of a handler,
+// if the sidecar forwarded it in the incoming metadata, or an empty string.
+func CallerSDKVersion(ctx context.Context) string {
+	return incomingMetadata(ctx, SDKVersionMetadataKey)
+}
+
+// CallerUserAgent returns the user agent of the caller of a handler, if the
+// sidecar forwarded it in the incoming metadata, or an empty string.
+func CallerUserAgent(ctx context.Context) string {
+	return incomingMetadata(ctx, SDKUserAgentMetadataKey)
+}
+
+func incomingMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
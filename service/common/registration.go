@@ -0,0 +1,48 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"time"
+)
+
+// RegistrationKind is the kind of handler of a RegistrationInfo.
+type RegistrationKind string
+
+const (
+	// RegistrationInvocation is a service invocation handler.
+	RegistrationInvocation RegistrationKind = "invocation"
+	// RegistrationTopic is a topic event handler.
+	RegistrationTopic RegistrationKind = "topic"
+	// RegistrationBinding is a binding invocation handler.
+	RegistrationBinding RegistrationKind = "binding"
+)
+
+// RegistrationInfo describes the registration of a handler on a service, to
+// find where it was registered when debugging.
+type RegistrationInfo struct {
+	Kind RegistrationKind
+	// Name is the method of a service invocation handler, the
+	// "<pubsubname>/<topic>" of a topic event handler, or the name of a
+	// binding.
+	Name string
+	// Route is the route of the handler.
+	Route string
+	// RegisteredAt is the time the handler was registered.
+	RegisteredAt time.Time
+	// Caller is the "file:line" of the code registering the handler, outside
+	// of the SDK. It is empty when the SDK is built with the daprnocaller
+	// build tag.
+	Caller string
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"sync"
+)
+
+type responseMetadataKey struct{}
+
+type responseMetadata struct {
+	lock sync.Mutex
+	md   map[string]string
+}
+
+// SetResponseMetadata adds md to the metadata of the response of the topic
+// event handler called with ctx, such as metadata influencing the commit of
+// the message by the pubsub component. The services send it with the
+// response as headers prefixed with "metadata.". Keys that can't be sent are
+// dropped and logged. It has no effect outside of a topic event handler.
+func SetResponseMetadata(ctx context.Context, md map[string]string) {
+	rm, ok := ctx.Value(responseMetadataKey{}).(*responseMetadata)
+	if !ok {
+		LoggerFromContext(ctx).Printf("debug: response metadata dropped outside of a topic event handler")
+		return
+	}
+	rm.lock.Lock()
+	defer rm.lock.Unlock()
+	for k, v := range md {
+		rm.md[k] = v
+	}
+}
+
+// WithResponseMetadata returns a copy of ctx in which SetResponseMetadata
+// records the metadata returned by ResponseMetadata. It is used by the
+// services around the calls of the topic event handlers.
+func WithResponseMetadata(ctx context.Context) context.Context {
+	return context.WithValue(ctx, responseMetadataKey{}, &responseMetadata{md: map[string]string{}})
+}
+
+// ResponseMetadata returns a copy of the metadata set with SetResponseMetadata
+// in ctx, returned by WithResponseMetadata, or nil if there is none.
+func ResponseMetadata(ctx context.Context) map[string]string {
+	rm, ok := ctx.Value(responseMetadataKey{}).(*responseMetadata)
+	if !ok {
+		return nil
+	}
+	rm.lock.Lock()
+	defer rm.lock.Unlock()
+	if len(rm.md) == 0 {
+		return nil
+	}
+	md := make(map[string]string, len(rm.md))
+	for k, v := range rm.md {
+		md[k] = v
+	}
+	return md
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+
+	"github.com/dapr/go-sdk/service/internal/jsonschema"
+)
+
+// SchemaValidator validates the payload of topic events and service
+// invocations before the handler runs. It returns a *SchemaValidationError if
+// the payload doesn't match the schema.
+// Implement it to use another JSON Schema library than the built-in one of
+// CompileJSONSchema.
+type SchemaValidator interface {
+	Validate(data []byte) error
+}
+
+// SchemaValidatorFunc is a function implementing SchemaValidator.
+type SchemaValidatorFunc func(data []byte) error
+
+// Validate calls f(data).
+func (f SchemaValidatorFunc) Validate(data []byte) error {
+	return f(data)
+}
+
+// SchemaViolation is a part of a payload that doesn't match the schema.
+type SchemaViolation struct {
+	// Field is the JSON pointer to the value, empty for the payload.
+	Field string
+	// Description describes how the value doesn't match the schema.
+	Description string
+}
+
+// SchemaValidationError is returned by a SchemaValidator when the payload
+// doesn't match the schema, or isn't JSON.
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Description
+		if v.Field != "" {
+			msgs[i] = v.Field + ": " + v.Description
+		}
+	}
+	return "payload does not match the schema: " + strings.Join(msgs, "; ")
+}
+
+// CompileJSONSchema compiles a JSON Schema into a SchemaValidator. The
+// built-in validator supports the type, enum, const, properties, required,
+// additionalProperties, items, length, range, pattern, allOf, anyOf, oneOf and
+// not keywords. Schemas using other keywords, such as $ref, fail to compile:
+// validate them with a SchemaValidator wrapping a complete JSON Schema
+// library.
+func CompileJSONSchema(schema []byte) (SchemaValidator, error) {
+	s, err := jsonschema.Compile(schema)
+	if err != nil {
+		return nil, err
+	}
+	return SchemaValidatorFunc(func(data []byte) error {
+		violations, err := s.Validate(data)
+		if err != nil {
+			return &SchemaValidationError{Violations: []SchemaViolation{{Description: err.Error()}}}
+		}
+		if len(violations) == 0 {
+			return nil
+		}
+		e := &SchemaValidationError{Violations: make([]SchemaViolation, len(violations))}
+		for i, v := range violations {
+			e.Violations[i] = SchemaViolation{Field: v.Path, Description: v.Message}
+		}
+		return e
+	}), nil
+}
+
+// SchemaValidation is the validation of the payloads of the handlers of a
+// route, set on a service with its WithSchemaValidation option.
+type SchemaValidation struct {
+	// Route is the route of the topic event handlers, or the method of the
+	// service invocation handler, with or without the leading slash.
+	Route string
+	// Validator validates the payloads.
+	Validator SchemaValidator
+
+	err error
+}
+
+// WithJSONSchemaValidation validates the payloads of the handlers of route
+// against the JSON Schema schema, compiled with CompileJSONSchema. If the
+// schema doesn't compile, registering a handler for the route fails.
+func WithJSONSchemaValidation(route string, schema []byte) SchemaValidation {
+	v, err := CompileJSONSchema(schema)
+	if err != nil {
+		err = fmt.Errorf("invalid JSON schema for route %s: %w", route, err)
+	}
+	return SchemaValidation{Route: route, Validator: v, err: err}
+}
+
+// Err returns the error compiling the schema, if any.
+func (v SchemaValidation) Err() error {
+	return v.err
+}
+
+// ValidateTopicEvents returns a TopicMiddleware, to register with
+// AddTopicEventHandlerWithMiddleware, dropping the events whose data doesn't
+// pass v. The failures are logged with the logger of the handler context.
+func ValidateTopicEvents(v SchemaValidator) TopicMiddleware {
+	return func(next TopicEventHandler) TopicEventHandler {
+		return func(ctx context.Context, e *TopicEvent) (retry bool, err error) {
+			if err := v.Validate(e.RawData); err != nil {
+				LoggerFromContext(ctx).Printf("dropping event %s of topic %s: %v", e.ID, e.Topic, err)
+				return false, err
+			}
+			return next(ctx, e)
+		}
+	}
+}
+
+// ValidateInvocations wraps fn to reject the invocations whose data doesn't
+// pass v with an InvalidArgument *StatusError, whose errdetails.BadRequest
+// detail lists the violations. The failures are logged with the logger of the
+// handler context.
+func ValidateInvocations(v SchemaValidator, fn ServiceInvocationHandler) ServiceInvocationHandler {
+	return func(ctx context.Context, in *InvocationEvent) (*Content, error) {
+		err := v.Validate(in.Data)
+		if err == nil {
+			return fn(ctx, in)
+		}
+		LoggerFromContext(ctx).Printf("rejecting invocation of %s: %v", in.Method, err)
+		var ve *SchemaValidationError
+		if !errors.As(err, &ve) {
+			return nil, NewStatusError(codes.InvalidArgument, err.Error())
+		}
+		br := &errdetails.BadRequest{}
+		for _, violation := range ve.Violations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       violation.Field,
+				Description: violation.Description,
+			})
+		}
+		return nil, NewStatusError(codes.InvalidArgument, err.Error(), br)
+	}
+}
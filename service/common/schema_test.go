@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+const testOrderSchema = `{"type": "object", "required": ["id"], "properties": {"qty": {"type": "integer", "minimum": 1}}}`
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...any) {
+	l.lines = append(l.lines, format)
+}
+
+func (l *recordingLogger) Println(v ...any) {}
+
+func TestJSONSchemaValidation(t *testing.T) {
+	v := WithJSONSchemaValidation("/orders", []byte(testOrderSchema))
+	require.NoError(t, v.Err())
+	assert.NoError(t, v.Validator.Validate([]byte(`{"id": "1", "qty": 2}`)))
+
+	var ve *SchemaValidationError
+	require.ErrorAs(t, v.Validator.Validate([]byte(`{"qty": 0}`)), &ve)
+	assert.Equal(t, []SchemaViolation{
+		{Description: `missing required property "id"`},
+		{Field: "/qty", Description: "must be at least 1"},
+	}, ve.Violations)
+	require.ErrorAs(t, v.Validator.Validate([]byte(`not json`)), &ve)
+	assert.Len(t, ve.Violations, 1)
+
+	v = WithJSONSchemaValidation("/orders", []byte(`{"$ref": "#/$defs/order"}`))
+	assert.Error(t, v.Err())
+	assert.Nil(t, v.Validator)
+}
+
+func TestValidateInvocations(t *testing.T) {
+	v, err := CompileJSONSchema([]byte(testOrderSchema))
+	require.NoError(t, err)
+	called := 0
+	fn := ValidateInvocations(v, func(ctx context.Context, in *InvocationEvent) (*Content, error) {
+		called++
+		return &Content{Data: in.Data}, nil
+	})
+	logger := &recordingLogger{}
+	ctx := ContextWithLogger(context.Background(), logger)
+
+	out, err := fn(ctx, &InvocationEvent{Method: "orders", Data: []byte(`{"id": "1"}`)})
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"id": "1"}`), out.Data)
+	assert.Equal(t, 1, called)
+
+	_, err = fn(ctx, &InvocationEvent{Method: "orders", Data: []byte(`{"qty": 0}`)})
+	var se *StatusError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, codes.InvalidArgument, se.Code())
+	require.Len(t, se.Details(), 1)
+	br, ok := se.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, br.GetFieldViolations(), 2)
+	assert.Equal(t, "/qty", br.GetFieldViolations()[1].GetField())
+
+	_, err = fn(ctx, &InvocationEvent{Method: "orders", Data: []byte(`id=1`)})
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, codes.InvalidArgument, se.Code())
+	assert.Equal(t, 1, called)
+	assert.Len(t, logger.lines, 2)
+
+	// Plain errors of custom validators are rejected too.
+	fn = ValidateInvocations(SchemaValidatorFunc(func(data []byte) error {
+		return errors.New("bad payload")
+	}), func(ctx context.Context, in *InvocationEvent) (*Content, error) {
+		t.Fatal("handler called")
+		return nil, nil
+	})
+	_, err = fn(ctx, &InvocationEvent{})
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, codes.InvalidArgument, se.Code())
+	assert.Empty(t, se.Details())
+}
+
+func TestValidateTopicEvents(t *testing.T) {
+	v, err := CompileJSONSchema([]byte(testOrderSchema))
+	require.NoError(t, err)
+	called := 0
+	fn := ValidateTopicEvents(v)(func(ctx context.Context, e *TopicEvent) (bool, error) {
+		called++
+		return false, nil
+	})
+	logger := &recordingLogger{}
+	ctx := ContextWithLogger(context.Background(), logger)
+
+	retry, err := fn(ctx, &TopicEvent{RawData: []byte(`{"id": "1"}`)})
+	assert.NoError(t, err)
+	assert.False(t, retry)
+	assert.Equal(t, 1, called)
+
+	for _, data := range []string{`{"qty": 2}`, `<order/>`} {
+		retry, err = fn(ctx, &TopicEvent{RawData: []byte(data)})
+		var ve *SchemaValidationError
+		assert.ErrorAs(t, err, &ve)
+		assert.False(t, retry)
+	}
+	assert.Equal(t, 1, called)
+	assert.Len(t, logger.lines, 2)
+}
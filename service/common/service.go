@@ -15,6 +15,9 @@ package common
 
 import (
 	"context"
+	"errors"
+	"net"
+	"strings"
 
 	"github.com/dapr/go-sdk/actor"
 	"github.com/dapr/go-sdk/actor/config"
@@ -26,7 +29,13 @@ const (
 	APITokenKey       = "dapr-api-token" /* #nosec */
 )
 
+// ErrServerStopped is returned by Start when the service has been stopped.
+// Use Restart to serve again.
+var ErrServerStopped = errors.New("service stopped")
+
 // Service represents Dapr callback service.
+// The services of this SDK also implement optional interfaces, such as Restarter,
+// which callers check for with a type assertion.
 type Service interface {
 	// AddHealthCheckHandler sets a health check handler, name: http (router) and grpc (invalid).
 	AddHealthCheckHandler(name string, fn HealthCheckHandler) error
@@ -50,9 +59,117 @@ type Service interface {
 	GracefulStop() error
 }
 
+// Restarter is implemented by services that can serve again once stopped.
+type Restarter interface {
+	// Restart gracefully stops the service if it is running, then serves again on lis with the registered handlers.
+	// Blocks while serving.
+	Restart(lis net.Listener) error
+	// ListenAddr returns the resolved address of the listener of the service, such as the port chosen for ":0", or
+	// nil if the service isn't bound yet.
+	ListenAddr() net.Addr
+}
+
+// Drainer is implemented by services that drain their in-flight callbacks and
+// run shutdown hooks when gracefully stopped.
+type Drainer interface {
+	// OnShutdown registers a hook run by GracefulStop, in reverse registration order, with a context bounded by the
+	// shutdown timeout. The errors of failed hooks are returned by GracefulStop as a *ShutdownError.
+	OnShutdown(fn func(ctx context.Context) error)
+	// IsDraining reports whether the service is gracefully stopping and waiting for in-flight callbacks to complete.
+	IsDraining() bool
+}
+
+// InvocationCacher is implemented by services that can cache the responses of
+// their service invocation handlers.
+type InvocationCacher interface {
+	// AddServiceInvocationHandlerWithOptions appends provided service invocation handler with its name to the service,
+	// configured by opts, such as to cache its responses.
+	AddServiceInvocationHandlerWithOptions(name string, fn ServiceInvocationHandler, opts InvocationOptions) error
+	// InvalidateInvocationCache removes the response cached for key by the service invocation handler of name.
+	InvalidateInvocationCache(name, key string)
+	// InvocationCacheStats returns the stats of the caches of the service invocation handlers, by name.
+	InvocationCacheStats() map[string]InvocationCacheStats
+}
+
+// TopicMiddlewareRegistrar is implemented by services that can wrap their topic
+// event handlers with middleware.
+type TopicMiddlewareRegistrar interface {
+	// AddTopicEventHandlerWithMiddleware is the same as AddTopicEventHandler, with middleware applied to the handler
+	// only, inside the middleware set with UseTopicMiddleware.
+	AddTopicEventHandlerWithMiddleware(sub *Subscription, fn TopicEventHandler, mw ...TopicMiddleware) error
+	// UseTopicMiddleware applies middleware to all the topic event handlers, including the ones already registered.
+	// The first middleware is the outermost.
+	UseTopicMiddleware(mw ...TopicMiddleware)
+}
+
+// AsyncBindingRegistrar is implemented by services that can acknowledge binding
+// events before they are processed.
+type AsyncBindingRegistrar interface {
+	// AddBindingInvocationHandlerAsync appends provided binding accept handler with its name to the service.
+	// The binding event is acknowledged as soon as the handler has accepted it, without waiting for it to be processed.
+	AddBindingInvocationHandlerAsync(name string, fn BindingAcceptHandler) error
+}
+
+// RegistrationLister is implemented by services that can list the handlers
+// registered on them.
+type RegistrationLister interface {
+	// RegisteredInvocationMethods returns the sorted methods of the service invocation handlers.
+	RegisteredInvocationMethods() []string
+	// RegisteredTopics returns copies of the subscriptions the topic event handlers were registered with.
+	RegisteredTopics() []Subscription
+	// RegisteredBindings returns the sorted names of the bindings with a handler.
+	RegisteredBindings() []string
+	// Registrations returns the registrations of the service invocation, topic event and binding handlers, with the
+	// caller which registered them, in registration order.
+	Registrations() []RegistrationInfo
+}
+
+// SubscriptionInspector is implemented by services that can describe their
+// topic subscriptions.
+type SubscriptionInspector interface {
+	// ExportSubscriptions returns the declarations of the topic subscriptions as Dapr Subscription resources, so that
+	// they can be applied declaratively while the handlers stay registered programmatically.
+	ExportSubscriptions(format ExportFormat) ([]byte, error)
+	// RecentEvents returns the last events delivered to the handlers of topic, oldest first, if retained with the
+	// WithRecentEvents option of the service.
+	RecentEvents(topic string) []TopicEvent
+	// SubscriptionStats returns the stats of each topic subscription, keyed by "<pubsubname>/<topic>".
+	SubscriptionStats() map[string]SubscriptionStats
+}
+
 type (
 	ServiceInvocationHandler func(ctx context.Context, in *InvocationEvent) (out *Content, err error)
 	TopicEventHandler        func(ctx context.Context, e *TopicEvent) (retry bool, err error)
 	BindingInvocationHandler func(ctx context.Context, in *BindingEvent) (out []byte, err error)
-	HealthCheckHandler       func(context.Context) error
+	// BindingAcceptHandler accepts a binding event for later processing, e.g. by persisting it as a job, and returns
+	// the ID of the job. The event must be persisted before the handler returns, as it is acknowledged right after.
+	BindingAcceptHandler func(ctx context.Context, in *BindingEvent) (jobID string, err error)
+	HealthCheckHandler   func(context.Context) error
+	// TopicMiddleware wraps a topic event handler, e.g. to record metrics or validate payloads. The retry flag and
+	// error returned by next are the outcome of the delivery, which the middleware may change.
+	TopicMiddleware func(next TopicEventHandler) TopicEventHandler
 )
+
+// ShutdownError is returned by GracefulStop when shutdown hooks fail.
+type ShutdownError struct {
+	// Errors are the errors of the failed hooks, in the order the hooks ran.
+	Errors []error
+}
+
+func (e *ShutdownError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "shutdown hooks failed: " + strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the hook errors matches target.
+func (e *ShutdownError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// StatusError is an error with a gRPC status code and details, following the
+// google.rpc error model. Invocation handlers return it to pass structured
+// error details, such as errdetails.BadRequest, to the caller.
+type StatusError struct {
+	status *status.Status
+}
+
+// NewStatusError returns a StatusError with the given code, message and
+// details. If a detail can't be serialized, the error has code Internal.
+func NewStatusError(code codes.Code, msg string, details ...proto.Message) *StatusError {
+	s := &spb.Status{Code: int32(code), Message: msg}
+	for _, d := range details {
+		a, err := anypb.New(d)
+		if err != nil {
+			return &StatusError{status: status.New(codes.Internal, fmt.Sprintf("error serializing status detail: %v", err))}
+		}
+		s.Details = append(s.Details, a)
+	}
+	return &StatusError{status: status.FromProto(s)}
+}
+
+func (e *StatusError) Error() string {
+	return e.status.Err().Error()
+}
+
+// GRPCStatus returns the gRPC status of the error.
+func (e *StatusError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// Code returns the status code of the error.
+func (e *StatusError) Code() codes.Code {
+	return e.status.Code()
+}
+
+// Details returns the details of the error.
+func (e *StatusError) Details() []proto.Message {
+	return StatusDetails(e.status)
+}
+
+// StatusDetails returns the details of s whose types are registered, skipping
+// the others.
+func StatusDetails(s *status.Status) []proto.Message {
+	anys := s.Proto().GetDetails()
+	if len(anys) == 0 {
+		return nil
+	}
+	details := make([]proto.Message, 0, len(anys))
+	for _, a := range anys {
+		d, err := a.UnmarshalNew()
+		if err != nil {
+			continue
+		}
+		details = append(details, d)
+	}
+	return details
+}
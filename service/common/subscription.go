@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SubscriptionBuilder builds the subscriptions of a topic, validating them
+// before they are registered.
+type SubscriptionBuilder struct {
+	pubsubName      string
+	topic           string
+	route           string
+	rules           []subscriptionRule
+	deadLetterTopic string
+	namespace       string
+	metadata        map[string]string
+	errs            []error
+}
+
+type subscriptionRule struct {
+	match string
+	path  string
+}
+
+// NewSubscription starts building a subscription to topic on the named pubsub component.
+func NewSubscription(pubsubName, topic string) *SubscriptionBuilder {
+	return &SubscriptionBuilder{
+		pubsubName: pubsubName,
+		topic:      topic,
+	}
+}
+
+// WithRoute sets the single route events of the topic are delivered to.
+// It can't be combined with routing rules.
+func (b *SubscriptionBuilder) WithRoute(path string) *SubscriptionBuilder {
+	switch {
+	case path == "":
+		b.errs = append(b.errs, errors.New("route path required"))
+	case b.route != "":
+		b.errs = append(b.errs, fmt.Errorf("route already set to %s", b.route))
+	default:
+		b.route = path
+	}
+	return b
+}
+
+// WithRule adds a routing rule delivering the events matching the CEL
+// expression match to path. Rules are evaluated in the order they are added.
+// It can't be combined with a single route.
+func (b *SubscriptionBuilder) WithRule(match, path string) *SubscriptionBuilder {
+	switch {
+	case match == "":
+		b.errs = append(b.errs, errors.New("rule match expression required"))
+	case path == "":
+		b.errs = append(b.errs, errors.New("rule path required"))
+	default:
+		for _, r := range b.rules {
+			if r.path == path {
+				b.errs = append(b.errs, fmt.Errorf("duplicate rule path %s", path))
+				return b
+			}
+		}
+		b.rules = append(b.rules, subscriptionRule{match: match, path: path})
+	}
+	return b
+}
+
+// WithDeadLetter sets the topic to which events that can't be delivered are sent.
+func (b *SubscriptionBuilder) WithDeadLetter(topic string) *SubscriptionBuilder {
+	b.deadLetterTopic = topic
+	return b
+}
+
+// WithNamespace sets the Dapr namespace of the topic.
+func (b *SubscriptionBuilder) WithNamespace(ns string) *SubscriptionBuilder {
+	b.namespace = ns
+	return b
+}
+
+// WithMetadata sets a metadata entry of the subscription.
+func (b *SubscriptionBuilder) WithMetadata(key, value string) *SubscriptionBuilder {
+	if b.metadata == nil {
+		b.metadata = make(map[string]string)
+	}
+	b.metadata[key] = value
+	return b
+}
+
+// Build validates the configuration and returns the subscriptions to register
+// with AddTopicEventHandler: one for the route, or one per routing rule, with
+// priorities in the order the rules were added.
+// The first configuration error is returned.
+func (b *SubscriptionBuilder) Build() ([]*Subscription, error) {
+	if b.pubsubName == "" {
+		return nil, errors.New("pub/sub name required")
+	}
+	if b.topic == "" {
+		return nil, errors.New("topic name required")
+	}
+	if len(b.errs) > 0 {
+		return nil, b.errs[0]
+	}
+	if b.route != "" && len(b.rules) > 0 {
+		return nil, errors.New("a subscription can't have both a route and routing rules")
+	}
+	if b.deadLetterTopic == b.topic {
+		return nil, errors.New("dead letter topic must differ from the subscribed topic")
+	}
+
+	newSub := func() *Subscription {
+		return &Subscription{
+			PubsubName:      b.pubsubName,
+			Topic:           b.topic,
+			Metadata:        b.metadata,
+			DeadLetterTopic: b.deadLetterTopic,
+			Namespace:       b.namespace,
+		}
+	}
+	if len(b.rules) == 0 {
+		sub := newSub()
+		sub.Route = b.route
+		return []*Subscription{sub}, nil
+	}
+	subs := make([]*Subscription, len(b.rules))
+	for i, r := range b.rules {
+		subs[i] = newSub()
+		subs[i].Route = r.path
+		subs[i].Match = r.match
+		subs[i].Priority = i + 1
+	}
+	return subs, nil
+}
+
+// NamespacedTopic returns the name of topic in the Dapr namespace ns: the
+// topic prefixed with the namespace, as the runtime names the topics of
+// namespace-scoped pub/sub components. Like the runtime, it concatenates them
+// without a separator, so that "prod" and "orders" give "prodorders"; callers
+// wanting one must include it in ns. It returns topic if ns is empty.
+func NamespacedTopic(ns, topic string) string {
+	return ns + topic
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionBuilder(t *testing.T) {
+	t.Run("route", func(t *testing.T) {
+		subs, err := NewSubscription("messages", "orders").
+			WithRoute("/orders").
+			WithDeadLetter("orders-dlq").
+			WithMetadata("rawPayload", "true").
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, []*Subscription{{
+			PubsubName:      "messages",
+			Topic:           "orders",
+			Route:           "/orders",
+			DeadLetterTopic: "orders-dlq",
+			Metadata:        map[string]string{"rawPayload": "true"},
+		}}, subs)
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		subs, err := NewSubscription("messages", "orders").
+			WithRule(`event.type == "created"`, "/created").
+			WithRule(`event.type == "deleted"`, "/deleted").
+			Build()
+		require.NoError(t, err)
+		require.Len(t, subs, 2)
+		assert.Equal(t, "/created", subs[0].Route)
+		assert.Equal(t, `event.type == "created"`, subs[0].Match)
+		assert.Equal(t, 1, subs[0].Priority)
+		assert.Equal(t, "/deleted", subs[1].Route)
+		assert.Equal(t, 2, subs[1].Priority)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tests := map[string]*SubscriptionBuilder{
+			"no pubsub":          NewSubscription("", "orders"),
+			"no topic":           NewSubscription("messages", ""),
+			"route and rules":    NewSubscription("messages", "orders").WithRoute("/orders").WithRule(`event.type == "a"`, "/a"),
+			"two routes":         NewSubscription("messages", "orders").WithRoute("/a").WithRoute("/b"),
+			"empty route":        NewSubscription("messages", "orders").WithRoute(""),
+			"rule without match": NewSubscription("messages", "orders").WithRule("", "/a"),
+			"rule without path":  NewSubscription("messages", "orders").WithRule(`event.type == "a"`, ""),
+			"duplicate rule":     NewSubscription("messages", "orders").WithRule(`event.type == "a"`, "/a").WithRule(`event.type == "b"`, "/a"),
+			"dead letter loop":   NewSubscription("messages", "orders").WithDeadLetter("orders"),
+		}
+		for name, b := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := b.Build()
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestNamespacedTopic(t *testing.T) {
+	assert.Equal(t, "prodorders", NamespacedTopic("prod", "orders"))
+	assert.Equal(t, "prod.orders", NamespacedTopic("prod.", "orders"))
+	assert.Equal(t, "orders", NamespacedTopic("", "orders"))
+}
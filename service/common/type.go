@@ -15,6 +15,10 @@ package common
 
 import (
 	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
 )
 
 // TopicEvent is the content of the inbound topic message.
@@ -45,6 +49,21 @@ type TopicEvent struct {
 	Topic string `json:"topic"`
 	// PubsubName is name of the pub/sub this message came from
 	PubsubName string `json:"pubsubname"`
+	// ContentEncoding is the encoding the data was published with, such as gzip.
+	// Data and RawData hold the decoded content.
+	ContentEncoding string `json:"contentencoding,omitempty"`
+	// DeliveryAttempt is the number of times the broker has delivered the event,
+	// including this delivery, taken from the broker metadata of the message.
+	// It is 0 if the broker doesn't provide it.
+	DeliveryAttempt int `json:"-"`
+	// Metadata is the broker metadata of the message, such as the partition
+	// and offset of a Kafka message, forwarded by the sidecar. The keys are
+	// lowercased and without the "metadata." prefix.
+	Metadata map[string]string `json:"-"`
+	// Extensions are the extension attributes of the CloudEvent, such as the
+	// traceparent added by the runtime, other than the ones with a field of
+	// TopicEvent.
+	Extensions map[string]interface{} `json:"-"`
 }
 
 func (e *TopicEvent) Struct(target interface{}) error {
@@ -65,6 +84,10 @@ type InvocationEvent struct {
 	Verb string `json:"-"`
 	// QueryString represents an encoded HTTP url query string in the following format: name=value&name2=value2
 	QueryString string `json:"-"`
+	// Method is the name of the invoked method.
+	Method string `json:"-"`
+	// Metadata holds the headers of the invocation request.
+	Metadata map[string][]string `json:"-"`
 }
 
 // Content is a generic data content.
@@ -85,12 +108,61 @@ type BindingEvent struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// ContentType returns the content type of the event data, found in the
+// content-type metadata, or an empty string.
+func (e *BindingEvent) ContentType() string {
+	for k, v := range e.Metadata {
+		switch strings.ToLower(k) {
+		case "content-type", "contenttype":
+			return v
+		}
+	}
+	return ""
+}
+
+// DataAs decodes the event data into target according to its content type.
+// A *[]byte target receives a copy of the data as is. JSON data, or data with
+// no content type, is unmarshaled; other data can only be decoded into a
+// *string.
+func (e *BindingEvent) DataAs(target interface{}) error {
+	if b, ok := target.(*[]byte); ok {
+		*b = append([]byte(nil), e.Data...)
+		return nil
+	}
+
+	contentType := e.ContentType()
+	mediaType := ""
+	if contentType != "" {
+		var err error
+		mediaType, _, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return fmt.Errorf("invalid content type %s: %w", contentType, err)
+		}
+	}
+	if mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		return json.Unmarshal(e.Data, target)
+	}
+	if str, ok := target.(*string); ok {
+		*str = string(e.Data)
+		return nil
+	}
+	return fmt.Errorf("can't decode binding data with content type %s into %T", contentType, target)
+}
+
 // Subscription represents single topic subscription.
 type Subscription struct {
 	// PubsubName is name of the pub/sub this message came from
 	PubsubName string `json:"pubsubname"`
+	// PubsubNames, when set, are the names of the pub/subs the handler subscribes to the topic of, such as a primary
+	// and a backup component, instead of PubsubName. The subscription is registered once per pub/sub, sharing the
+	// handler.
+	PubsubNames []string `json:"-"`
 	// Topic is the name of the topic
 	Topic string `json:"topic"`
+	// Namespace, when set, is the Dapr namespace the topic belongs to, on pub/sub components shared by several
+	// namespaces without being namespace scoped. The topic, and the dead letter topic, are advertised prefixed with
+	// it, as named by NamespacedTopic.
+	Namespace string `json:"-"`
 	// Metadata is the subscription metadata
 	Metadata map[string]string `json:"metadata,omitempty"`
 	// Route is the route of the handler where HTTP topic events should be published (passed as Path in gRPC)
@@ -99,10 +171,102 @@ type Subscription struct {
 	Match string `json:"match"`
 	// Priority is the priority in which to evaluate the match (lower to higher).
 	Priority int `json:"priority"`
+	// DeadLetterTopic is the topic to which events that can't be delivered are sent.
+	DeadLetterTopic string `json:"deadLetterTopic,omitempty"`
 	// DisableTopicValidation allows to receive events from publisher topics that differ from the subscribed topic.
 	DisableTopicValidation bool `json:"disableTopicValidation"`
+	// OrderingMode controls whether the handlers of the topic may run concurrently.
+	OrderingMode OrderingMode `json:"-"`
+	// MaxConcurrency is the maximum number of handler invocations running at the same time for the topic
+	// with OrderingModeUnordered. Zero means unlimited.
+	MaxConcurrency int `json:"-"`
+	// Scopes are the IDs of the apps the subscription applies to when exported with ExportSubscriptions.
+	Scopes []string `json:"-"`
+	// BulkSubscribe are the bulk subscribe settings of the subscription when exported with ExportSubscriptions.
+	// The services don't handle bulk deliveries, so they are not part of the subscriptions listed to Dapr.
+	BulkSubscribe *BulkSubscribeOptions `json:"-"`
+}
+
+// BulkSubscribeOptions are the bulk subscribe settings of a subscription.
+type BulkSubscribeOptions struct {
+	// Enabled enables bulk subscribe.
+	Enabled bool
+	// MaxMessagesCount is the maximum number of messages delivered in a single bulk request.
+	MaxMessagesCount int32
+	// MaxAwaitDurationMs is the maximum time, in milliseconds, to wait for messages before a bulk request.
+	MaxAwaitDurationMs int32
+}
+
+// ExportFormat is the format of exported subscription declarations.
+type ExportFormat string
+
+// ExportFormatYAML exports subscriptions as Dapr Subscription resources in YAML, one document per subscription.
+const ExportFormatYAML ExportFormat = "yaml"
+
+// SubscriptionStats are the stats of the handlers of a topic subscription.
+// Latencies are computed over the most recent handler invocations.
+type SubscriptionStats struct {
+	// InFlight is the number of events being handled.
+	InFlight int64 `json:"inFlight"`
+	// Delivered is the number of events handled.
+	Delivered uint64 `json:"delivered"`
+	// Failed is the number of events whose handler returned an error.
+	Failed uint64 `json:"failed"`
+	// LastError is the last error returned by a handler.
+	LastError string `json:"lastError,omitempty"`
+	// LatencySamples is the number of recent invocations the latencies are computed over.
+	LatencySamples int `json:"latencySamples"`
+	// P50Latency is the median handler latency.
+	P50Latency time.Duration `json:"p50Latency"`
+	// P95Latency is the 95th percentile handler latency.
+	P95Latency time.Duration `json:"p95Latency"`
+	// MaxLatency is the highest handler latency.
+	MaxLatency time.Duration `json:"maxLatency"`
 }
 
+// InvocationOptions are the options of a service invocation handler registered with
+// AddServiceInvocationHandlerWithOptions.
+type InvocationOptions struct {
+	// Cache, when set, caches the responses of the handler.
+	Cache *CacheConfig
+}
+
+// CacheConfig configures the cache of the responses of a service invocation handler, for idempotent methods such as
+// lookups. The responses are cached by key; the responses with an error are never cached, and concurrent invocations
+// with the key of a response not cached yet share a single invocation of the handler.
+type CacheConfig struct {
+	// TTL is the time a response is cached. It must be positive.
+	TTL time.Duration
+	// MaxEntries is the maximum number of responses cached, the least recently used being evicted. Zero means
+	// unlimited.
+	MaxEntries int
+	// KeyFunc returns the cache key of an invocation, or an empty string to invoke the handler without caching its
+	// response. Nil keys the invocations by their verb, query string and data.
+	KeyFunc func(in *InvocationEvent) string
+}
+
+// InvocationCacheStats are the stats of the cache of a service invocation handler.
+type InvocationCacheStats struct {
+	// Hits is the number of invocations answered from the cache, including the ones sharing an in-flight invocation.
+	Hits uint64 `json:"hits"`
+	// Misses is the number of invocations of the handler to fill the cache.
+	Misses uint64 `json:"misses"`
+	// Evictions is the number of responses evicted to honor MaxEntries.
+	Evictions uint64 `json:"evictions"`
+	// Entries is the number of responses cached.
+	Entries int `json:"entries"`
+}
+
+// OrderingMode is the execution order of the handlers of a subscription.
+type OrderingMode int
+
+const (
+	// OrderingModeUnordered runs handler invocations concurrently, up to the MaxConcurrency of the subscription.
+	OrderingModeUnordered OrderingMode = iota
+	// OrderingModeOrdered runs the handler invocations of the topic one at a time.
+	OrderingModeOrdered
+)
+
 const (
 	// SubscriptionResponseStatusSuccess means message is processed successfully.
 	SubscriptionResponseStatusSuccess = "SUCCESS"
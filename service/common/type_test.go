@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindingEventDataAs(t *testing.T) {
+	type order struct {
+		ID int `json:"id"`
+	}
+
+	t.Run("json", func(t *testing.T) {
+		e := &BindingEvent{
+			Data:     []byte(`{"id":1}`),
+			Metadata: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+		}
+		var o order
+		require.NoError(t, e.DataAs(&o))
+		assert.Equal(t, order{ID: 1}, o)
+	})
+
+	t.Run("json without content type", func(t *testing.T) {
+		e := &BindingEvent{Data: []byte(`{"id":2}`)}
+		var o order
+		require.NoError(t, e.DataAs(&o))
+		assert.Equal(t, order{ID: 2}, o)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		e := &BindingEvent{
+			Data:     []byte(`not json`),
+			Metadata: map[string]string{"content-type": "application/cloudevents+json"},
+		}
+		var o order
+		assert.Error(t, e.DataAs(&o))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		data := []byte{0x00, 0x01, 0xff}
+		e := &BindingEvent{
+			Data:     data,
+			Metadata: map[string]string{"content-type": "application/octet-stream"},
+		}
+		var b []byte
+		require.NoError(t, e.DataAs(&b))
+		assert.Equal(t, data, b)
+		b[0] = 0x02
+		assert.Equal(t, byte(0x00), e.Data[0], "data is copied")
+
+		var o order
+		assert.Error(t, e.DataAs(&o))
+	})
+
+	t.Run("text", func(t *testing.T) {
+		e := &BindingEvent{
+			Data:     []byte("hello"),
+			Metadata: map[string]string{"contentType": "text/plain"},
+		}
+		var s string
+		require.NoError(t, e.DataAs(&s))
+		assert.Equal(t, "hello", s)
+	})
+}
@@ -17,11 +17,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/golang/protobuf/ptypes/empty"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
 )
 
 // AddBindingInvocationHandler appends provided binding invocation handler with its name to the service.
@@ -32,10 +34,22 @@ func (s *Server) AddBindingInvocationHandler(name string, fn common.BindingInvoc
 	if fn == nil {
 		return fmt.Errorf("binding handler required")
 	}
-	s.bindingHandlers[name] = fn
+	info := internal.NewRegistration(common.RegistrationBinding, name, name)
+	s.bindingHandlers[name] = internal.AuditBindingHandler(info, fn)
+	s.registrations.Add(info)
 	return nil
 }
 
+// RegisteredBindings returns the sorted names of the bindings with a handler.
+func (s *Server) RegisteredBindings() []string {
+	names := make([]string, 0, len(s.bindingHandlers))
+	for name := range s.bindingHandlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // ListInputBindings is called by Dapr to get the list of bindings the app will get invoked by. In this example, we are telling Dapr
 // To invoke our app with a binding named storage.
 func (s *Server) ListInputBindings(ctx context.Context, in *empty.Empty) (*pb.ListInputBindingsResponse, error) {
@@ -59,8 +73,12 @@ func (s *Server) OnBindingEvent(ctx context.Context, in *pb.BindingEventRequest)
 			Data:     in.Data,
 			Metadata: in.Metadata,
 		}
+		ctx = s.handlerContext(ctx, map[string]string{common.LogFieldBinding: in.Name})
 		data, err := fn(ctx, e)
 		if err != nil {
+			if internal.DropBindingEvent(ctx, in.Name, err) {
+				return &pb.BindingEventResponse{}, nil
+			}
 			return nil, fmt.Errorf("error executing %s binding: %w", in.Name, err)
 		}
 		return &pb.BindingEventResponse{
@@ -70,3 +88,12 @@ func (s *Server) OnBindingEvent(ctx context.Context, in *pb.BindingEventRequest)
 
 	return nil, fmt.Errorf("binding not implemented: %s", in.Name)
 }
+
+// AddBindingInvocationHandlerAsync appends provided binding accept handler with its name to the service.
+// The binding event is acknowledged as soon as fn has accepted it, and delivered again if fn fails.
+func (s *Server) AddBindingInvocationHandlerAsync(name string, fn common.BindingAcceptHandler) error {
+	if fn == nil {
+		return fmt.Errorf("binding handler required")
+	}
+	return s.AddBindingInvocationHandler(name, internal.AcceptBindingHandler(fn))
+}
@@ -20,6 +20,10 @@ import (
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	runtime "github.com/dapr/dapr/pkg/proto/runtime/v1"
 	"github.com/dapr/go-sdk/service/common"
@@ -105,3 +109,60 @@ func TestBinding(t *testing.T) {
 
 	stopTestServer(t, server)
 }
+
+// go test -timeout 30s ./service/grpc -count 1 -run ^TestBindingAsync$
+func TestBindingAsync(t *testing.T) {
+	ctx := context.Background()
+	server := getTestServer()
+
+	var accepted []string
+	failAccept := false
+	err := server.AddBindingInvocationHandlerAsync("jobs", func(ctx context.Context, in *common.BindingEvent) (string, error) {
+		if failAccept {
+			return "", errors.New("store unavailable")
+		}
+		accepted = append(accepted, string(in.Data))
+		return "job1", nil
+	})
+	assert.NoError(t, err)
+	assert.Error(t, server.AddBindingInvocationHandlerAsync("nil", nil))
+
+	t.Run("event is acknowledged once accepted", func(t *testing.T) {
+		out, err := server.OnBindingEvent(ctx, &runtime.BindingEventRequest{Name: "jobs", Data: []byte("work")})
+		assert.NoError(t, err)
+		assert.Empty(t, out.GetData())
+		assert.Equal(t, []string{"work"}, accepted)
+	})
+
+	t.Run("event is not acknowledged when not accepted", func(t *testing.T) {
+		failAccept = true
+		_, err := server.OnBindingEvent(ctx, &runtime.BindingEventRequest{Name: "jobs", Data: []byte("other")})
+		assert.Error(t, err)
+		assert.Equal(t, []string{"work"}, accepted)
+	})
+}
+
+func TestBindingError(t *testing.T) {
+	ctx := context.Background()
+	server := getTestServer()
+	err := server.AddBindingInvocationHandler("test", func(ctx context.Context, in *common.BindingEvent) ([]byte, error) {
+		return nil, &common.BindingError{Code: "INVALID_PAYLOAD", Message: "not an order", Retryable: string(in.Data) == "retry"}
+	})
+	require.NoError(t, err)
+
+	t.Run("non-retryable error acknowledges the event", func(t *testing.T) {
+		out, err := server.OnBindingEvent(ctx, &runtime.BindingEventRequest{Name: "test", Data: []byte("drop")})
+		require.NoError(t, err)
+		assert.Empty(t, out.GetData())
+	})
+
+	t.Run("retryable error fails the event", func(t *testing.T) {
+		_, err := server.OnBindingEvent(ctx, &runtime.BindingEventRequest{Name: "test", Data: []byte("retry")})
+		require.Error(t, err)
+		s, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.Internal, s.Code())
+		require.Len(t, s.Details(), 1)
+		assert.Equal(t, "INVALID_PAYLOAD", s.Details()[0].(*errdetails.ErrorInfo).GetReason())
+	})
+}
@@ -37,7 +37,7 @@ func (s *Server) AddHealthCheckHandler(_ string, fn common.HealthCheckHandler) e
 // HealthCheck check app health status.
 func (s *Server) HealthCheck(ctx context.Context, _ *emptypb.Empty) (*pb.HealthCheckResponse, error) {
 	if s.healthCheckHandler != nil {
-		if err := s.healthCheckHandler(ctx); err != nil {
+		if err := s.healthChecker.Check(ctx, s.healthCheckHandler); err != nil {
 			return &pb.HealthCheckResponse{}, err
 		}
 
@@ -17,8 +17,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func testHealthCheckHandler(ctx context.Context) (err error) {
@@ -65,3 +67,32 @@ func TestHealthCheck(t *testing.T) {
 
 	stopTestServer(t, server)
 }
+
+func TestHealthCheckWithOptions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("hanging handler hits the timeout", func(t *testing.T) {
+		server := newService(bufconn.Listen(1024*1024), nil, nil, WithHealthCheckTimeout(10*time.Millisecond))
+		hang := make(chan struct{})
+		defer close(hang)
+		err := server.AddHealthCheckHandler("", func(ctx context.Context) error {
+			<-hang
+			return nil
+		})
+		assert.NoError(t, err)
+
+		_, err = server.HealthCheck(ctx, nil)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("unhealthy after consecutive failures", func(t *testing.T) {
+		server := newService(bufconn.Listen(1024*1024), nil, nil, WithHealthFailureThreshold(2))
+		err := server.AddHealthCheckHandler("", testHealthCheckHandlerWithError)
+		assert.NoError(t, err)
+
+		_, err = server.HealthCheck(ctx, nil)
+		assert.NoError(t, err)
+		_, err = server.HealthCheck(ctx, nil)
+		assert.Error(t, err)
+	})
+}
@@ -17,14 +17,38 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	cpb "github.com/dapr/dapr/pkg/proto/common/v1"
+	"github.com/dapr/go-sdk/client"
 	cc "github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
 )
 
+// AddInvocationProxy forwards every invocation whose method starts with prefix
+// to the targetAppID app through the daprClient, passing on the verb, query
+// string, content type, payload, and propagated metadata, and responding with
+// the content type, headers and data of the response of the target.
+// When set, rewrite maps the incoming method to the one invoked on the target.
+// Errors returned by the target are passed back to the caller with their status.
+// Invocations are refused if targetAppID is the ID of this app.
+func (s *Server) AddInvocationProxy(prefix string, targetAppID string, daprClient client.Client, rewrite func(method string) string) error {
+	prefix = strings.TrimPrefix(prefix, "/")
+	p, err := internal.NewInvocationProxy(prefix, targetAppID, daprClient, rewrite)
+	if err != nil {
+		return err
+	}
+	s.invokeProxies = append(s.invokeProxies, p)
+	return nil
+}
+
 // AddServiceInvocationHandler appends provided service invocation handler with its method to the service.
 func (s *Server) AddServiceInvocationHandler(method string, fn cc.ServiceInvocationHandler) error {
 	if method == "" || method == "/" {
@@ -38,10 +62,61 @@ func (s *Server) AddServiceInvocationHandler(method string, fn cc.ServiceInvocat
 	if fn == nil {
 		return fmt.Errorf("invocation handler required")
 	}
+	info := internal.NewRegistration(cc.RegistrationInvocation, method, method)
+	fn, err := s.schemaValidations.InvocationHandler(method, internal.AuditInvocationHandler(info, fn))
+	if err != nil {
+		return err
+	}
 	s.invokeHandlers[method] = fn
+	s.invocationCaches.Set(method, nil)
+	s.registrations.Add(info)
 	return nil
 }
 
+// AddServiceInvocationHandlerWithOptions appends provided service invocation
+// handler with its method to the service, configured by opts.
+func (s *Server) AddServiceInvocationHandlerWithOptions(method string, fn cc.ServiceInvocationHandler, opts cc.InvocationOptions) error {
+	if fn == nil {
+		return fmt.Errorf("invocation handler required")
+	}
+	var cache *internal.InvocationCache
+	if opts.Cache != nil {
+		var err error
+		if cache, err = internal.NewInvocationCache(*opts.Cache); err != nil {
+			return err
+		}
+		fn = cache.Handler(fn)
+	}
+	if err := s.AddServiceInvocationHandler(method, fn); err != nil {
+		return err
+	}
+	s.invocationCaches.Set(strings.TrimPrefix(method, "/"), cache)
+	return nil
+}
+
+// InvalidateInvocationCache removes the response cached for key by the handler
+// of method, registered with a cache by AddServiceInvocationHandlerWithOptions.
+func (s *Server) InvalidateInvocationCache(method, key string) {
+	s.invocationCaches.Invalidate(strings.TrimPrefix(method, "/"), key)
+}
+
+// InvocationCacheStats returns the stats of the caches of the service
+// invocation handlers, by method.
+func (s *Server) InvocationCacheStats() map[string]cc.InvocationCacheStats {
+	return s.invocationCaches.Stats()
+}
+
+// RegisteredInvocationMethods returns the sorted methods of the service
+// invocation handlers.
+func (s *Server) RegisteredInvocationMethods() []string {
+	methods := make([]string, 0, len(s.invokeHandlers))
+	for m := range s.invokeHandlers {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
 // OnInvoke gets invoked when a remote service has called the app through Dapr.
 func (s *Server) OnInvoke(ctx context.Context, in *cpb.InvokeRequest) (*cpb.InvokeResponse, error) {
 	if in == nil {
@@ -61,6 +136,10 @@ func (s *Server) OnInvoke(ctx context.Context, in *cpb.InvokeRequest) (*cpb.Invo
 	if fn, ok := s.invokeHandlers[in.Method]; ok {
 		e := &cc.InvocationEvent{}
 		e.ContentType = in.ContentType
+		e.Method = in.Method
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			e.Metadata = md
+		}
 
 		if in.Data != nil {
 			e.Data = in.Data.Value
@@ -72,8 +151,12 @@ func (s *Server) OnInvoke(ctx context.Context, in *cpb.InvokeRequest) (*cpb.Invo
 			e.QueryString = in.HttpExtension.Querystring
 		}
 
-		ct, er := fn(ctx, e)
+		ct, er := fn(s.handlerContext(ctx, map[string]string{cc.LogFieldMethod: in.Method}), e)
 		if er != nil {
+			var se *cc.StatusError
+			if errors.As(er, &se) {
+				return nil, se.GRPCStatus().Err()
+			}
 			return nil, er
 		}
 
@@ -89,5 +172,38 @@ func (s *Server) OnInvoke(ctx context.Context, in *cpb.InvokeRequest) (*cpb.Invo
 			},
 		}, nil
 	}
+	if p := internal.MatchProxy(s.invokeProxies, in.Method); p != nil {
+		return s.forwardInvocation(ctx, p, in)
+	}
 	return nil, fmt.Errorf("method not implemented: %s", in.Method)
 }
+
+func (s *Server) forwardInvocation(ctx context.Context, p *internal.InvocationProxy, in *cpb.InvokeRequest) (*cpb.InvokeResponse, error) {
+	req := &internal.ProxiedRequest{
+		Method:      in.Method,
+		ContentType: in.ContentType,
+		Data:        in.GetData().GetValue(),
+	}
+	if in.HttpExtension != nil {
+		req.Verb = in.HttpExtension.Verb.String()
+		req.QueryString = in.HttpExtension.Querystring
+	}
+
+	hctx := s.handlerContext(ctx, map[string]string{cc.LogFieldMethod: in.Method})
+	out, err := p.Forward(hctx, req)
+	if errors.Is(err, internal.ErrProxyLoop) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Headers) > 0 {
+		if err := grpc.SetHeader(ctx, metadata.MD(out.Headers)); err != nil {
+			cc.LoggerFromContext(hctx).Printf("debug: dropped proxied response headers: %v", err)
+		}
+	}
+	return &cpb.InvokeResponse{
+		Data:        &any.Any{Value: out.Data},
+		ContentType: out.ContentType,
+	}, nil
+}
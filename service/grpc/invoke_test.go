@@ -17,13 +17,24 @@ import (
 	"context"
 	"errors"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/client"
 	cc "github.com/dapr/go-sdk/service/common"
 )
 
@@ -144,3 +155,178 @@ func TestInvoke(t *testing.T) {
 
 	stopTestServer(t, server)
 }
+
+// proxyTargetRuntime is a fake Dapr runtime that answers invocations of the
+// "target" app by echoing the request it received.
+type proxyTargetRuntime struct {
+	pb.UnimplementedDaprServer
+}
+
+func (r *proxyTargetRuntime) GetMetadata(ctx context.Context, in *emptypb.Empty) (*pb.GetMetadataResponse, error) {
+	return &pb.GetMetadataResponse{Id: "gateway"}, nil
+}
+
+func (r *proxyTargetRuntime) InvokeService(ctx context.Context, in *pb.InvokeServiceRequest) (*common.InvokeResponse, error) {
+	if in.Id != "target" {
+		return nil, status.Errorf(codes.Unavailable, "app %s not found", in.Id)
+	}
+	if in.Message.Method == "orders/missing" {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	echo := strings.Join([]string{
+		in.Message.Method,
+		in.Message.HttpExtension.Verb.String(),
+		in.Message.HttpExtension.Querystring,
+		in.Message.ContentType,
+		string(in.Message.Data.Value),
+		strings.Join(md.Get("x-tenant-id"), ","),
+	}, "|")
+	if err := grpc.SetHeader(ctx, metadata.Pairs("x-order-version", "3")); err != nil {
+		return nil, err
+	}
+	return &common.InvokeResponse{Data: &anypb.Any{Value: []byte(echo)}, ContentType: "text/plain"}, nil
+}
+
+// headerRecordingStream is a grpc.ServerTransportStream recording the headers
+// set by the handler.
+type headerRecordingStream struct {
+	header metadata.MD
+}
+
+func (s *headerRecordingStream) Method() string { return "/dapr.proto.runtime.v1.AppCallback/OnInvoke" }
+
+func (s *headerRecordingStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerRecordingStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *headerRecordingStream) SetTrailer(metadata.MD) error { return nil }
+
+func TestInvocationProxy(t *testing.T) {
+	c := startTestRuntime(t, &proxyTargetRuntime{})
+	server := newService(bufconn.Listen(1024*1024), nil, nil, WithAutoPropagation([]string{"x-tenant-id"}))
+
+	assert.Error(t, server.AddInvocationProxy("", "target", c, nil))
+	assert.Error(t, server.AddInvocationProxy("api/", "", c, nil))
+	assert.Error(t, server.AddInvocationProxy("api/", "target", nil, nil))
+	assert.NoError(t, server.AddInvocationProxy("api/", "target", c, func(method string) string {
+		return strings.TrimPrefix(method, "api/")
+	}))
+	assert.NoError(t, server.AddInvocationProxy("self/", "gateway", c, nil))
+	assert.NoError(t, server.AddServiceInvocationHandler("local", testInvokeHandler))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "tenant-1"))
+
+	t.Run("forwards the request", func(t *testing.T) {
+		stream := &headerRecordingStream{}
+		out, err := server.OnInvoke(grpc.NewContextWithServerTransportStream(ctx, stream), &common.InvokeRequest{
+			Method:        "api/orders/1",
+			ContentType:   "application/json",
+			Data:          &anypb.Any{Value: []byte(`{"a":1}`)},
+			HttpExtension: &common.HTTPExtension{Verb: common.HTTPExtension_PUT, Querystring: "v=2"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `orders/1|PUT|v=2|application/json|{"a":1}|tenant-1`, string(out.Data.Value))
+		assert.Equal(t, "text/plain", out.ContentType)
+		assert.Equal(t, []string{"3"}, stream.header.Get("x-order-version"))
+		assert.Empty(t, stream.header.Get("content-type"))
+	})
+
+	t.Run("maps the target error", func(t *testing.T) {
+		_, err := server.OnInvoke(ctx, &common.InvokeRequest{Method: "api/orders/missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Equal(t, "order not found", status.Convert(err).Message())
+	})
+
+	t.Run("local handlers take precedence", func(t *testing.T) {
+		out, err := server.OnInvoke(ctx, &common.InvokeRequest{Method: "local", Data: &anypb.Any{Value: []byte("hi")}})
+		require.NoError(t, err)
+		assert.Equal(t, "hi", string(out.Data.Value))
+	})
+
+	t.Run("rejects proxying to self", func(t *testing.T) {
+		_, err := server.OnInvoke(ctx, &common.InvokeRequest{Method: "self/loop"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+func TestInvokeStatusError(t *testing.T) {
+	server := getTestServer()
+	c := startTestRuntime(t, &forwardingRuntime{app: server})
+	ctx := context.Background()
+
+	require.NoError(t, server.AddServiceInvocationHandler("orders", func(ctx context.Context, in *cc.InvocationEvent) (*cc.Content, error) {
+		return nil, cc.NewStatusError(codes.InvalidArgument, "invalid order", &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "quantity", Description: "must be positive"},
+			},
+		})
+	}))
+	require.NoError(t, server.AddServiceInvocationHandler("plain", func(ctx context.Context, in *cc.InvocationEvent) (*cc.Content, error) {
+		return nil, errors.New("plain failure")
+	}))
+
+	t.Run("status details reach the caller", func(t *testing.T) {
+		_, err := c.InvokeMethod(ctx, "app", "orders", "post")
+		var invErr *client.InvocationError
+		require.ErrorAs(t, err, &invErr)
+		assert.Equal(t, codes.InvalidArgument, invErr.Code())
+		assert.Equal(t, "invalid order", invErr.GRPCStatus().Message())
+
+		details := invErr.StatusDetails()
+		require.Len(t, details, 1)
+		badRequest, ok := details[0].(*errdetails.BadRequest)
+		require.True(t, ok)
+		require.Len(t, badRequest.FieldViolations, 1)
+		assert.Equal(t, "quantity", badRequest.FieldViolations[0].Field)
+		assert.Equal(t, "must be positive", badRequest.FieldViolations[0].Description)
+	})
+
+	t.Run("plain errors keep their behavior", func(t *testing.T) {
+		_, err := c.InvokeMethod(ctx, "app", "plain", "post")
+		require.Error(t, err)
+		assert.Equal(t, codes.Unknown, status.Code(err))
+		assert.Equal(t, "plain failure", status.Convert(err).Message())
+		var invErr *client.InvocationError
+		require.ErrorAs(t, err, &invErr)
+		assert.Empty(t, invErr.StatusDetails())
+	})
+}
+
+func TestInvokeWithCache(t *testing.T) {
+	ctx := context.Background()
+	server := getTestServer()
+	calls := 0
+	err := server.AddServiceInvocationHandlerWithOptions("/cached", func(ctx context.Context, in *cc.InvocationEvent) (*cc.Content, error) {
+		calls++
+		return &cc.Content{ContentType: "text/plain", Data: in.Data}, nil
+	}, cc.InvocationOptions{Cache: &cc.CacheConfig{
+		TTL:     time.Minute,
+		KeyFunc: func(in *cc.InvocationEvent) string { return string(in.Data) },
+	}})
+	require.NoError(t, err)
+	err = server.AddServiceInvocationHandlerWithOptions("invalid", testInvokeHandler, cc.InvocationOptions{Cache: &cc.CacheConfig{}})
+	assert.Error(t, err)
+
+	invoke := func() {
+		in := &common.InvokeRequest{Method: "cached", Data: &anypb.Any{Value: []byte("a")}}
+		out, err := server.OnInvoke(ctx, in)
+		require.NoError(t, err)
+		assert.Equal(t, "a", string(out.GetData().GetValue()))
+	}
+	invoke()
+	invoke()
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, map[string]cc.InvocationCacheStats{"cached": {Hits: 1, Misses: 1, Entries: 1}}, server.InvocationCacheStats())
+
+	server.InvalidateInvocationCache("/cached", "a")
+	invoke()
+	assert.Equal(t, 2, calls)
+
+	// Registering the handler without options removes its cache.
+	require.NoError(t, server.AddServiceInvocationHandler("cached", testInvokeHandler))
+	assert.Empty(t, server.InvocationCacheStats())
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"time"
+
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+// ServiceOption configures a Server created by NewService or NewServiceWithGrpcServer.
+type ServiceOption func(*Server)
+
+// WithAutoPropagation marks the given keys of the incoming metadata for
+// propagation in every handler context, as if each handler had called
+// common.PropagateMetadata.
+func WithAutoPropagation(keys []string) ServiceOption {
+	return func(s *Server) {
+		s.propagateKeys = append(s.propagateKeys, keys...)
+	}
+}
+
+// WithHealthCheckTimeout sets the maximum time the health check handler may
+// run. A handler that doesn't complete in time counts as a failure.
+func WithHealthCheckTimeout(d time.Duration) ServiceOption {
+	return func(s *Server) {
+		s.healthChecker.Timeout = d
+	}
+}
+
+// WithHealthFailureThreshold makes the health check RPC report the app as
+// unhealthy only after n consecutive failures of the health check handler.
+// A success resets the count.
+func WithHealthFailureThreshold(n int) ServiceOption {
+	return func(s *Server) {
+		s.healthChecker.FailureThreshold = n
+	}
+}
+
+// WithLoggerFactory sets the factory used to create a request-scoped logger on
+// each callback. Handlers retrieve it with common.LoggerFromContext.
+func WithLoggerFactory(f common.LoggerFactory) ServiceOption {
+	return func(s *Server) {
+		s.loggerFactory = f
+	}
+}
+
+// WithDrainHook sets a function invoked when GracefulStop starts draining the
+// service, before in-flight callbacks complete. Use it, for example, to fail
+// readiness probes so that traffic moves to a new instance.
+func WithDrainHook(fn func()) ServiceOption {
+	return func(s *Server) {
+		s.drainHook = fn
+	}
+}
+
+// WithShutdownTimeout bounds the time the hooks registered with OnShutdown have
+// to run. The default is 10 seconds.
+func WithShutdownTimeout(d time.Duration) ServiceOption {
+	return func(s *Server) {
+		s.shutdownHooks.Timeout = d
+	}
+}
+
+// WithSubscriptionVerification makes the service check, after Start, that the
+// Dapr runtime accepted all the topic subscriptions it advertises. The runtime
+// metadata is polled with c until every subscription is active or the
+// verification timeout expires; the subscriptions still missing are then
+// logged, or passed to the callback set with
+// WithSubscriptionVerificationCallback.
+func WithSubscriptionVerification(c MetadataGetter) ServiceOption {
+	return func(s *Server) {
+		s.verifier = newSubscriptionVerifier(c)
+	}
+}
+
+// WithSubscriptionVerificationCallback sets the function called with the
+// subscriptions the Dapr runtime didn't accept. It has effect only together
+// with WithSubscriptionVerification.
+func WithSubscriptionVerificationCallback(fn func(missing []*common.Subscription)) ServiceOption {
+	return func(s *Server) {
+		s.onMissingSubscriptions = fn
+	}
+}
+
+// WithSubscriptionVerificationTimeout sets the time the Dapr runtime has to
+// accept the subscriptions. The default is 30 seconds.
+func WithSubscriptionVerificationTimeout(d time.Duration) ServiceOption {
+	return func(s *Server) {
+		s.verificationTimeout = d
+	}
+}
+
+// WithSchemaValidation validates the payloads of the topic event and service
+// invocation handlers of the routes of vs, created for example with
+// common.WithJSONSchemaValidation. Events that don't pass are dropped, and
+// invocations are rejected with InvalidArgument, before the handler runs.
+func WithSchemaValidation(vs ...common.SchemaValidation) ServiceOption {
+	return func(s *Server) {
+		s.schemaValidations.Add(vs...)
+	}
+}
+
+// WithRecentEvents retains the last n events delivered to the topic event
+// handlers of each topic, for debugging with RecentEvents. The events of a
+// topic delivered by several pub/subs share its buffer. 0, the default,
+// disables it.
+func WithRecentEvents(n int) ServiceOption {
+	return func(s *Server) {
+		s.recentEvents = internal.NewRecentEvents(n)
+	}
+}
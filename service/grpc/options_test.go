@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/client"
+	cc "github.com/dapr/go-sdk/service/common"
+)
+
+const tenantKey = "x-tenant-id"
+
+// forwardingRuntime is a fake Dapr runtime that delivers service invocations
+// straight back to the app, forwarding the metadata it received.
+type forwardingRuntime struct {
+	pb.UnimplementedDaprServer
+	app *Server
+}
+
+func (r *forwardingRuntime) InvokeService(ctx context.Context, in *pb.InvokeServiceRequest) (*commonv1pb.InvokeResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return r.app.OnInvoke(metadata.NewIncomingContext(context.Background(), md), in.Message)
+}
+
+// startTestRuntime serves runtime as a fake Dapr sidecar and returns a client connected to it.
+func startTestRuntime(t *testing.T, runtime pb.DaprServer, opts ...client.ClientOption) client.Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	pb.RegisterDaprServer(s, runtime)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	c := client.NewClientWithConnection(conn, opts...)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestMetadataPropagation(t *testing.T) {
+	run := func(t *testing.T, serviceOpts []ServiceOption, clientOpts []client.ClientOption, upstream func(ctx context.Context) context.Context) string {
+		t.Helper()
+
+		app := newService(bufconn.Listen(1024*1024), nil, nil, serviceOpts...)
+		c := startTestRuntime(t, &forwardingRuntime{app: app}, clientOpts...)
+
+		var seen string
+		require.NoError(t, app.AddServiceInvocationHandler("downstream", func(ctx context.Context, in *cc.InvocationEvent) (*cc.Content, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			if v := md.Get(tenantKey); len(v) > 0 {
+				seen = v[0]
+			}
+			return nil, nil
+		}))
+		require.NoError(t, app.AddServiceInvocationHandler("upstream", func(ctx context.Context, in *cc.InvocationEvent) (*cc.Content, error) {
+			_, err := c.InvokeMethod(upstream(ctx), "app", "downstream", "post")
+			return nil, err
+		}))
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantKey, "tenant-1"))
+		_, err := app.OnInvoke(ctx, &commonv1pb.InvokeRequest{Method: "upstream"})
+		require.NoError(t, err)
+		return seen
+	}
+	noop := func(ctx context.Context) context.Context { return ctx }
+
+	t.Run("not propagated by default", func(t *testing.T) {
+		assert.Empty(t, run(t, nil, nil, noop))
+	})
+
+	t.Run("explicit propagation", func(t *testing.T) {
+		seen := run(t, nil, nil, func(ctx context.Context) context.Context {
+			return cc.PropagateMetadata(ctx, tenantKey)
+		})
+		assert.Equal(t, "tenant-1", seen)
+	})
+
+	t.Run("service auto propagation", func(t *testing.T) {
+		assert.Equal(t, "tenant-1", run(t, []ServiceOption{WithAutoPropagation([]string{tenantKey})}, nil, noop))
+	})
+
+	t.Run("client auto propagation", func(t *testing.T) {
+		assert.Equal(t, "tenant-1", run(t, nil, []client.ClientOption{client.WithAutoPropagation([]string{tenantKey})}, noop))
+	})
+
+	t.Run("explicit outgoing metadata wins", func(t *testing.T) {
+		seen := run(t, []ServiceOption{WithAutoPropagation([]string{tenantKey})}, nil, func(ctx context.Context) context.Context {
+			return metadata.AppendToOutgoingContext(ctx, tenantKey, "tenant-2")
+		})
+		assert.Equal(t, "tenant-2", seen)
+	})
+}
+
+func TestLoggerFactory(t *testing.T) {
+	var buf bytes.Buffer
+	server := newService(bufconn.Listen(1024*1024), nil, nil, WithLoggerFactory(func(fields map[string]string) cc.Logger {
+		return log.New(&buf, "method="+fields[cc.LogFieldMethod]+" ", 0)
+	}))
+	require.NoError(t, server.AddServiceInvocationHandler("echo", func(ctx context.Context, in *cc.InvocationEvent) (*cc.Content, error) {
+		cc.LoggerFromContext(ctx).Println("handled")
+		return nil, nil
+	}))
+
+	_, err := server.OnInvoke(context.Background(), &commonv1pb.InvokeRequest{Method: "echo"})
+	require.NoError(t, err)
+	assert.Equal(t, "method=echo handled\n", buf.String())
+}
+
+func TestSchemaValidation(t *testing.T) {
+	ctx := context.Background()
+	schema := []byte(`{"type": "object", "required": ["id"]}`)
+	var logs bytes.Buffer
+	server := newService(bufconn.Listen(1024*1024), nil, nil,
+		WithSchemaValidation(cc.WithJSONSchemaValidation("/orders", schema)),
+		WithLoggerFactory(func(fields map[string]string) cc.Logger {
+			return log.New(&logs, "", 0)
+		}))
+	handled := 0
+	require.NoError(t, server.AddTopicEventHandler(&cc.Subscription{PubsubName: "messages", Topic: "orders", Route: "/orders"},
+		func(ctx context.Context, e *cc.TopicEvent) (retry bool, err error) {
+			handled++
+			return false, nil
+		}))
+	require.NoError(t, server.AddServiceInvocationHandler("orders", testInvokeHandler))
+
+	t.Run("topic events", func(t *testing.T) {
+		for data, want := range map[string]pb.TopicEventResponse_TopicEventResponseStatus{
+			`{"id": "1"}`: pb.TopicEventResponse_SUCCESS,
+			`{"qty": 1}`:  pb.TopicEventResponse_DROP,
+			`id=1`:        pb.TopicEventResponse_DROP,
+		} {
+			resp, err := server.OnTopicEvent(ctx, &pb.TopicEventRequest{
+				Id: "1", Source: "test", Type: "test", SpecVersion: "1.0",
+				DataContentType: "application/json", Data: []byte(data),
+				Topic: "orders", PubsubName: "messages", Path: "/orders",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, want, resp.GetStatus(), data)
+		}
+		assert.Equal(t, 1, handled)
+	})
+
+	t.Run("invocations", func(t *testing.T) {
+		_, err := server.OnInvoke(ctx, &commonv1pb.InvokeRequest{Method: "orders", Data: &anypb.Any{Value: []byte(`{"id": "1"}`)}})
+		require.NoError(t, err)
+		for _, data := range []string{`{"qty": 1}`, `id=1`} {
+			_, err = server.OnInvoke(ctx, &commonv1pb.InvokeRequest{Method: "orders", Data: &anypb.Any{Value: []byte(data)}})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), data)
+		}
+	})
+	assert.Contains(t, logs.String(), "does not match the schema")
+
+	t.Run("invalid schema fails registration", func(t *testing.T) {
+		server := newService(bufconn.Listen(1024*1024), nil, nil,
+			WithSchemaValidation(cc.WithJSONSchemaValidation("orders", []byte(`{"type": 1}`))))
+		assert.Error(t, server.AddTopicEventHandler(&cc.Subscription{PubsubName: "messages", Topic: "orders", Route: "/orders"}, eventHandler))
+		assert.Error(t, server.AddServiceInvocationHandler("orders", testInvokeHandler))
+		assert.NoError(t, server.AddServiceInvocationHandler("other", testInvokeHandler))
+	})
+}
+
+func TestBaggagePropagation(t *testing.T) {
+	run := func(t *testing.T, clientOpts ...client.ClientOption) map[string]string {
+		t.Helper()
+
+		app := newService(bufconn.Listen(1024*1024), nil, nil)
+		c := startTestRuntime(t, &forwardingRuntime{app: app}, clientOpts...)
+
+		var seen map[string]string
+		require.NoError(t, app.AddServiceInvocationHandler("downstream", func(ctx context.Context, in *cc.InvocationEvent) (*cc.Content, error) {
+			seen = cc.BaggageFromContext(ctx)
+			return nil, nil
+		}))
+		require.NoError(t, app.AddServiceInvocationHandler("upstream", func(ctx context.Context, in *cc.InvocationEvent) (*cc.Content, error) {
+			_, err := c.InvokeMethod(ctx, "app", "downstream", "post")
+			return nil, err
+		}))
+
+		ctx := cc.ContextWithBaggage(context.Background(), map[string]string{"tenant": "acme", "experiment": "a,b"})
+		_, err := c.InvokeMethod(ctx, "app", "upstream", "post")
+		require.NoError(t, err)
+		return seen
+	}
+
+	t.Run("not propagated by default", func(t *testing.T) {
+		assert.Nil(t, run(t))
+	})
+
+	t.Run("propagated across hops", func(t *testing.T) {
+		assert.Equal(t, map[string]string{"tenant": "acme", "experiment": "a,b"}, run(t, client.WithTracePropagation()))
+	})
+}
@@ -0,0 +1,66 @@
+//go:build !daprnocaller
+
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/test/bufconn"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	"github.com/dapr/go-sdk/service/common"
+)
+
+func TestServerRegistrations(t *testing.T) {
+	var buf bytes.Buffer
+	server := newService(bufconn.Listen(1024*1024), nil, nil, WithLoggerFactory(func(map[string]string) common.Logger {
+		return log.New(&buf, "", 0)
+	}))
+	_, file, line, _ := runtime.Caller(0)
+	require.NoError(t, server.AddServiceInvocationHandler("/panic", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		panic("boom")
+	}))
+	require.NoError(t, server.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "orders", Route: "/orders"}, eventHandler))
+	require.NoError(t, server.AddBindingInvocationHandlerAsync("storage", func(ctx context.Context, in *common.BindingEvent) (string, error) {
+		return "job", nil
+	}))
+	require.Error(t, server.AddBindingInvocationHandler("", testBindingHandler))
+
+	regs := server.Registrations()
+	require.Len(t, regs, 3)
+	assert.Equal(t, common.RegistrationInvocation, regs[0].Kind)
+	assert.Equal(t, "panic", regs[0].Name)
+	assert.Equal(t, fmt.Sprintf("%s:%d", file, line+1), regs[0].Caller)
+	assert.Equal(t, common.RegistrationTopic, regs[1].Kind)
+	assert.Equal(t, "messages/orders", regs[1].Name)
+	assert.Equal(t, "/orders", regs[1].Route)
+	assert.Equal(t, fmt.Sprintf("%s:%d", file, line+4), regs[1].Caller)
+	assert.Equal(t, common.RegistrationBinding, regs[2].Kind)
+	assert.Equal(t, "storage", regs[2].Name)
+	assert.Equal(t, fmt.Sprintf("%s:%d", file, line+5), regs[2].Caller)
+
+	assert.Panics(t, func() {
+		_, _ = server.OnInvoke(context.Background(), &commonv1pb.InvokeRequest{Method: "panic"})
+	})
+	assert.Contains(t, buf.String(), "invocation handler panic registered at "+regs[0].Caller+" panicked: boom")
+}
@@ -14,13 +14,18 @@ limitations under the License.
 package grpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net"
 	"os"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 	"github.com/dapr/go-sdk/actor"
@@ -30,7 +35,7 @@ import (
 )
 
 // NewService creates new Service.
-func NewService(address string) (s common.Service, err error) {
+func NewService(address string, opts ...ServiceOption) (s common.Service, err error) {
 	if address == "" {
 		return nil, errors.New("empty address")
 	}
@@ -39,40 +44,83 @@ func NewService(address string) (s common.Service, err error) {
 		err = fmt.Errorf("failed to TCP listen on %s: %w", address, err)
 		return
 	}
-	s = newService(lis, nil)
+	s = newService(lis, nil, nil, opts...)
 	return
 }
 
 // NewServiceWithListener creates new Service with specific listener.
 func NewServiceWithListener(lis net.Listener, opts ...grpc.ServerOption) common.Service {
-	return newService(lis, nil, opts...)
+	return newService(lis, nil, opts)
 }
 
 // NewServiceWithGrpcServer creates a new Service with specific listener and grpcServer
-func NewServiceWithGrpcServer(lis net.Listener, server *grpc.Server) common.Service {
-	return newService(lis, server)
+func NewServiceWithGrpcServer(lis net.Listener, server *grpc.Server, opts ...ServiceOption) common.Service {
+	return newService(lis, server, nil, opts...)
 }
 
-func newService(lis net.Listener, grpcServer *grpc.Server, opts ...grpc.ServerOption) *Server {
+// Server implements the optional interfaces of common.Service.
+var (
+	_ common.Restarter                = (*Server)(nil)
+	_ common.Drainer                  = (*Server)(nil)
+	_ common.InvocationCacher         = (*Server)(nil)
+	_ common.TopicMiddlewareRegistrar = (*Server)(nil)
+	_ common.AsyncBindingRegistrar    = (*Server)(nil)
+	_ common.RegistrationLister       = (*Server)(nil)
+	_ common.SubscriptionInspector    = (*Server)(nil)
+)
+
+func newService(lis net.Listener, grpcServer *grpc.Server, serverOpts []grpc.ServerOption, opts ...ServiceOption) *Server {
 	s := &Server{
 		listener:        lis,
 		invokeHandlers:  make(map[string]common.ServiceInvocationHandler),
 		topicRegistrar:  make(internal.TopicRegistrar),
 		bindingHandlers: make(map[string]common.BindingInvocationHandler),
 		authToken:       os.Getenv(common.AppAPITokenEnvVar),
+		healthChecker:   &internal.HealthChecker{},
+
+		schemaValidations: make(internal.SchemaValidations),
+		invocationCaches:  make(internal.InvocationCaches),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.verifier != nil {
+		s.verifier.onMissing = s.onMissingSubscriptions
+		if s.verificationTimeout > 0 {
+			s.verifier.timeout = s.verificationTimeout
+		}
 	}
 
 	if grpcServer == nil {
-		grpcServer = grpc.NewServer(opts...)
+		grpcServer = grpc.NewServer(serverOpts...)
+		s.serverOpts = serverOpts
+		s.ownsServer = true
 	}
+	s.registerServer(grpcServer)
+
+	return s
+}
 
+// registerServer registers the callback services and the services of the
+// AppCallbackRegistrar functions on grpcServer and makes it the server of s.
+func (s *Server) registerServer(grpcServer *grpc.Server) {
 	pb.RegisterAppCallbackServer(grpcServer, s)
 	pb.RegisterAppCallbackHealthCheckServer(grpcServer, s)
+	for _, fn := range s.registrars {
+		fn(grpcServer)
+	}
 	s.grpcServer = grpcServer
-
-	return s
 }
 
+// serverState is the lifecycle state of a Server.
+type serverState int
+
+const (
+	serverStateNew serverState = iota
+	serverStateStarted
+	serverStateStopped
+)
+
 // Server is the gRPC service implementation for Dapr.
 type Server struct {
 	pb.UnimplementedAppCallbackServer
@@ -80,11 +128,47 @@ type Server struct {
 	listener           net.Listener
 	invokeHandlers     map[string]common.ServiceInvocationHandler
 	topicRegistrar     internal.TopicRegistrar
+	topicMiddleware    internal.TopicMiddleware
 	bindingHandlers    map[string]common.BindingInvocationHandler
 	healthCheckHandler common.HealthCheckHandler
 	authToken          string
 	grpcServer         *grpc.Server
-	started            uint32
+	serverOpts         []grpc.ServerOption
+	ownsServer         bool
+	registrars         []func(*grpc.Server)
+	lock               sync.Mutex
+	state              serverState
+	propagateKeys      []string
+	loggerFactory      common.LoggerFactory
+	invokeProxies      []*internal.InvocationProxy
+	healthChecker      *internal.HealthChecker
+	draining           atomic.Bool
+	drainHook          func()
+	shutdownHooks      internal.ShutdownHooks
+	topicDeliveries    internal.InFlight
+	schemaValidations  internal.SchemaValidations
+	registrations      internal.Registrations
+	invocationCaches   internal.InvocationCaches
+	recentEvents       *internal.RecentEvents
+
+	verifier               *subscriptionVerifier
+	onMissingSubscriptions func(missing []*common.Subscription)
+	verificationTimeout    time.Duration
+}
+
+// AppCallbackRegistrar calls fn with the gRPC server of the service, to register
+// extra services on it, such as a health or reflection service, which are then
+// served alongside the app callbacks. It must be called before Start.
+// Restart calls fn again with the new gRPC server.
+func (s *Server) AppCallbackRegistrar(fn func(*grpc.Server)) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.state != serverStateNew {
+		return errors.New("services must be registered before the gRPC server starts")
+	}
+	fn(s.grpcServer)
+	s.registrars = append(s.registrars, fn)
+	return nil
 }
 
 // Deprecated: Use RegisterActorImplFactoryContext instead.
@@ -97,34 +181,152 @@ func (s *Server) RegisterActorImplFactoryContext(f actor.FactoryContext, opts ..
 }
 
 // Start registers the server and starts it.
+// With WithSubscriptionVerification, the topic subscriptions are verified in
+// the background.
+// Returns common.ErrServerStopped if the service has been stopped.
 func (s *Server) Start() error {
-	if !atomic.CompareAndSwapUint32(&s.started, 0, 1) {
+	s.lock.Lock()
+	switch s.state {
+	case serverStateStarted:
+		s.lock.Unlock()
 		return errors.New("a gRPC server can only be started once")
+	case serverStateStopped:
+		s.lock.Unlock()
+		return common.ErrServerStopped
+	}
+	s.state = serverStateStarted
+	grpcServer, lis := s.grpcServer, s.listener
+	if s.verifier != nil {
+		s.verifier.start(s.topicRegistrar.Subscriptions())
 	}
-	return s.grpcServer.Serve(s.listener)
+	s.lock.Unlock()
+
+	return grpcServer.Serve(lis)
 }
 
 // Stop stops the previously-started service.
+// Calling Stop more than once is safe. A service stopped before Start can't be
+// started anymore.
 func (s *Server) Stop() error {
-	if atomic.LoadUint32(&s.started) == 0 {
-		return nil
+	if grpcServer := s.markStopped(); grpcServer != nil {
+		grpcServer.Stop()
 	}
-	s.grpcServer.Stop()
-	s.grpcServer = nil
 	return nil
 }
 
 // GrecefulStop stops the previously-started service gracefully.
+// Calling GracefulStop more than once is safe.
+// While in-flight callbacks complete, IsDraining reports true.
+// New topic deliveries are rejected with codes.Unavailable, so that the
+// runtime redelivers them, and the in-flight ones have the shutdown timeout to
+// complete before the service is stopped.
+// The hooks registered with OnShutdown run once the service has stopped.
 func (s *Server) GracefulStop() error {
-	if atomic.LoadUint32(&s.started) == 0 {
+	s.gracefulStop()
+	return s.shutdownHooks.Run()
+}
+
+// OnShutdown registers fn to run when the service is gracefully stopped.
+// Hooks run in reverse registration order, with a context bounded by the
+// shutdown timeout, and the errors of failed hooks are aggregated.
+func (s *Server) OnShutdown(fn func(ctx context.Context) error) {
+	s.shutdownHooks.Add(fn)
+}
+
+func (s *Server) gracefulStop() {
+	if grpcServer := s.markStopped(); grpcServer != nil {
+		s.draining.Store(true)
+		defer s.draining.Store(false)
+		if s.drainHook != nil {
+			s.drainHook()
+		}
+		ctx, cancel := s.shutdownHooks.Context()
+		defer cancel()
+		if err := s.topicDeliveries.Drain(ctx); err != nil {
+			log.Printf("in-flight topic deliveries did not complete before the shutdown timeout, err: %v", err)
+			grpcServer.Stop()
+			return
+		}
+		grpcServer.GracefulStop()
+	}
+}
+
+// IsDraining reports whether the service is gracefully stopping and waiting
+// for in-flight callbacks to complete.
+func (s *Server) IsDraining() bool {
+	return s.draining.Load()
+}
+
+// markStopped moves the server to the stopped state, returning the
+// grpc.Server to stop, or nil if it was not started.
+func (s *Server) markStopped() *grpc.Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	started := s.state == serverStateStarted
+	s.state = serverStateStopped
+	if !started {
 		return nil
 	}
-	s.grpcServer.GracefulStop()
-	s.grpcServer = nil
-	return nil
+	if s.verifier != nil {
+		s.verifier.stop()
+	}
+	return s.grpcServer
+}
+
+// Restart gracefully stops the service if it is running, then serves again on
+// lis with a new grpc.Server, created with the same options and serving the
+// registered handlers and the services of the AppCallbackRegistrar functions.
+// Blocks while serving.
+// Services created with NewServiceWithGrpcServer can't be restarted, as the
+// grpc.Server is not managed by the service.
+func (s *Server) Restart(lis net.Listener) error {
+	if lis == nil {
+		return errors.New("listener required")
+	}
+	if !s.ownsServer {
+		return errors.New("a service using a custom gRPC server can't be restarted")
+	}
+	s.gracefulStop()
+
+	s.lock.Lock()
+	s.listener = lis
+	s.registerServer(grpc.NewServer(s.serverOpts...))
+	s.topicDeliveries.Reset()
+	s.shutdownHooks.Reset()
+	s.state = serverStateNew
+	s.lock.Unlock()
+
+	return s.Start()
+}
+
+// Registrations returns the registrations of the service invocation, topic
+// event and binding handlers, in registration order.
+func (s *Server) Registrations() []common.RegistrationInfo {
+	return s.registrations.List()
+}
+
+// ListenAddr returns the address of the listener of the service, resolved when
+// NewService binds it, such as the port chosen for the address ":0".
+func (s *Server) ListenAddr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.listener.Addr()
+}
+
+// handlerContext returns the context passed to a callback handler, carrying the
+// auto-propagated metadata, the baggage and the request-scoped logger.
+func (s *Server) handlerContext(ctx context.Context, fields map[string]string) context.Context {
+	ctx = common.PropagateMetadata(ctx, s.propagateKeys...)
+	if s.loggerFactory != nil {
+		ctx = common.ContextWithLogger(ctx, s.loggerFactory(fields))
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return internal.WithBaggage(ctx, md.Get(common.BaggageMetadataKey))
 }
 
 // GrpcServer returns the grpc.Server object managed by the server.
 func (s *Server) GrpcServer() *grpc.Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	return s.grpcServer
 }
@@ -14,11 +14,25 @@ limitations under the License.
 package grpc
 
 import (
+	"context"
+	"errors"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/service/common"
 )
 
 func TestServer(t *testing.T) {
@@ -27,6 +41,17 @@ func TestServer(t *testing.T) {
 	stopTestServer(t, server)
 }
 
+func TestServerListenAddr(t *testing.T) {
+	server, err := NewService("127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Stop()
+
+	addr, ok := server.(common.Restarter).ListenAddr().(*net.TCPAddr)
+	require.True(t, ok)
+	assert.NotZero(t, addr.Port)
+	assert.True(t, addr.IP.IsLoopback())
+}
+
 func TestServerWithListener(t *testing.T) {
 	server := NewServiceWithListener(bufconn.Listen(1024 * 1024))
 	assert.NotNil(t, server)
@@ -44,12 +69,12 @@ func TestService(t *testing.T) {
 }
 
 func getTestServer() *Server {
-	return newService(bufconn.Listen(1024*1024), nil)
+	return newService(bufconn.Listen(1024*1024), nil, nil)
 }
 
 func startTestServer(server *Server) {
 	go func() {
-		if err := server.Start(); err != nil && err.Error() != "closed" {
+		if err := server.Start(); err != nil && err.Error() != "closed" && !errors.Is(err, common.ErrServerStopped) {
 			panic(err)
 		}
 	}()
@@ -62,3 +87,277 @@ func stopTestServer(t *testing.T, server *Server) {
 	err := server.Stop()
 	assert.Nilf(t, err, "error stopping server")
 }
+
+func TestStartingStoppedService(t *testing.T) {
+	server := getTestServer()
+	require.NoError(t, server.Stop())
+	assert.ErrorIs(t, server.Start(), common.ErrServerStopped)
+}
+
+func TestServerRestart(t *testing.T) {
+	server := getTestServer()
+	err := server.AddServiceInvocationHandler("ping", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		return &common.Content{Data: []byte("pong")}, nil
+	})
+	require.NoError(t, err)
+	var shutdowns int
+	server.OnShutdown(func(ctx context.Context) error {
+		shutdowns++
+		return nil
+	})
+
+	ping := func(lis *bufconn.Listener) {
+		t.Helper()
+		conn, err := grpc.Dial("bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		require.NoError(t, err)
+		defer conn.Close()
+		resp, err := runtimev1pb.NewAppCallbackClient(conn).OnInvoke(context.Background(), &commonv1pb.InvokeRequest{Method: "ping"})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("pong"), resp.GetData().GetValue())
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start() }()
+	ping(server.listener.(*bufconn.Listener))
+
+	require.NoError(t, server.GracefulStop())
+	assert.NoError(t, <-errCh)
+	assert.NoError(t, server.Stop(), "stopping twice is safe")
+	assert.NoError(t, server.GracefulStop())
+	assert.Equal(t, 1, shutdowns, "the hooks run once per stop")
+	assert.ErrorIs(t, server.Start(), common.ErrServerStopped)
+
+	lis := bufconn.Listen(1024 * 1024)
+	go func() { errCh <- server.Restart(lis) }()
+	ping(lis)
+
+	require.NoError(t, server.GracefulStop())
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, 2, shutdowns, "the hooks are kept across restarts")
+}
+
+func TestServerRestartWithGrpcServer(t *testing.T) {
+	server := NewServiceWithGrpcServer(bufconn.Listen(1024*1024), grpc.NewServer())
+	assert.Error(t, server.(common.Restarter).Restart(bufconn.Listen(1024*1024)))
+}
+
+func TestServerDraining(t *testing.T) {
+	drainStarted := make(chan struct{})
+	server := newService(bufconn.Listen(1024*1024), nil, nil, WithDrainHook(func() { close(drainStarted) }))
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+	err := server.AddServiceInvocationHandler("slow", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		close(inFlight)
+		<-release
+		return &common.Content{Data: []byte("done")}, nil
+	})
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start() }()
+
+	lis := server.listener.(*bufconn.Listener)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	invokeErr := make(chan error, 1)
+	go func() {
+		_, err := runtimev1pb.NewAppCallbackClient(conn).OnInvoke(context.Background(), &commonv1pb.InvokeRequest{Method: "slow"})
+		invokeErr <- err
+	}()
+	<-inFlight
+	assert.False(t, server.IsDraining())
+
+	stopErr := make(chan error, 1)
+	go func() { stopErr <- server.GracefulStop() }()
+	<-drainStarted
+	assert.True(t, server.IsDraining())
+
+	close(release)
+	require.NoError(t, <-invokeErr)
+	require.NoError(t, <-stopErr)
+	assert.NoError(t, <-errCh)
+	assert.False(t, server.IsDraining())
+}
+
+func TestServerShutdownHooks(t *testing.T) {
+	server := getTestServer()
+	var order []int
+	errHook := errors.New("hook failed")
+	server.OnShutdown(func(ctx context.Context) error {
+		order = append(order, 1)
+		return nil
+	})
+	server.OnShutdown(func(ctx context.Context) error {
+		order = append(order, 2)
+		return errHook
+	})
+
+	err := server.GracefulStop()
+	assert.ErrorIs(t, err, errHook)
+	assert.Equal(t, []int{2, 1}, order)
+}
+
+func TestServerDrainsTopicDeliveries(t *testing.T) {
+	sub := &common.Subscription{PubsubName: "messages", Topic: "orders"}
+	event := &runtimev1pb.TopicEventRequest{
+		Id:              "1",
+		PubsubName:      sub.PubsubName,
+		Topic:           sub.Topic,
+		Data:            []byte("hello"),
+		DataContentType: "text/plain",
+	}
+
+	start := func(t *testing.T, opts ...ServiceOption) (*Server, runtimev1pb.AppCallbackClient, chan struct{}, chan struct{}) {
+		t.Helper()
+
+		server := newService(bufconn.Listen(1024*1024), nil, nil, opts...)
+		inFlight := make(chan struct{})
+		release := make(chan struct{})
+		require.NoError(t, server.AddTopicEventHandler(sub, func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+			close(inFlight)
+			<-release
+			return false, nil
+		}))
+		startTestServer(server)
+
+		lis := server.listener.(*bufconn.Listener)
+		conn, err := grpc.Dial("bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return server, runtimev1pb.NewAppCallbackClient(conn), inFlight, release
+	}
+
+	t.Run("in-flight delivery completes before stop", func(t *testing.T) {
+		server, app, inFlight, release := start(t)
+		deliveryErr := make(chan error, 1)
+		go func() {
+			resp, err := app.OnTopicEvent(context.Background(), event)
+			if err == nil && resp.GetStatus() != runtimev1pb.TopicEventResponse_SUCCESS {
+				err = errors.New(resp.GetStatus().String())
+			}
+			deliveryErr <- err
+		}()
+		<-inFlight
+
+		stopErr := make(chan error, 1)
+		go func() { stopErr <- server.GracefulStop() }()
+		require.Eventually(t, server.IsDraining, time.Second, 5*time.Millisecond)
+
+		_, err := server.OnTopicEvent(context.Background(), event)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		select {
+		case <-stopErr:
+			t.Fatal("GracefulStop returned before the in-flight delivery completed")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		close(release)
+		require.NoError(t, <-deliveryErr)
+		require.NoError(t, <-stopErr)
+	})
+
+	t.Run("shutdown timeout bounds the drain", func(t *testing.T) {
+		server, app, inFlight, release := start(t, WithShutdownTimeout(100*time.Millisecond))
+		defer close(release)
+		deliveryErr := make(chan error, 1)
+		go func() {
+			_, err := app.OnTopicEvent(context.Background(), event)
+			deliveryErr <- err
+		}()
+		<-inFlight
+
+		started := time.Now()
+		require.NoError(t, server.GracefulStop())
+		assert.Less(t, time.Since(started), 5*time.Second)
+		assert.Error(t, <-deliveryErr)
+	})
+}
+
+func TestServerRegisteredHandlers(t *testing.T) {
+	server := getTestServer()
+	assert.Empty(t, server.RegisteredInvocationMethods())
+	assert.Empty(t, server.RegisteredTopics())
+	assert.Empty(t, server.RegisteredBindings())
+
+	require.NoError(t, server.AddServiceInvocationHandler("/echo", testInvokeHandler))
+	require.NoError(t, server.AddServiceInvocationHandler("audit", testInvokeHandler))
+	require.NoError(t, server.AddBindingInvocationHandler("storage", testBindingHandler))
+	require.NoError(t, server.AddBindingInvocationHandler("cron", testBindingHandler))
+	sub := &common.Subscription{
+		PubsubName: "messages",
+		Topic:      "orders",
+		Route:      "/orders",
+		Metadata:   map[string]string{"rawPayload": "true"},
+	}
+	require.NoError(t, server.AddTopicEventHandler(sub, eventHandler))
+	require.NoError(t, server.AddTopicEventHandler(&common.Subscription{
+		PubsubName: "messages",
+		Topic:      "orders",
+		Route:      "/orders/v2",
+		Match:      `event.type == "v2"`,
+		Priority:   1,
+	}, eventHandler))
+
+	startTestServer(server)
+	defer stopTestServer(t, server)
+
+	assert.Equal(t, []string{"audit", "echo"}, server.RegisteredInvocationMethods())
+	assert.Equal(t, []string{"cron", "storage"}, server.RegisteredBindings())
+	topics := server.RegisteredTopics()
+	require.Len(t, topics, 2)
+	assert.Equal(t, *sub, topics[0])
+	assert.Equal(t, "/orders/v2", topics[1].Route)
+	assert.Equal(t, `event.type == "v2"`, topics[1].Match)
+
+	// The returned subscriptions are copies.
+	topics[0].Metadata["rawPayload"] = "false"
+	assert.Equal(t, "true", server.RegisteredTopics()[0].Metadata["rawPayload"])
+}
+
+func TestAppCallbackRegistrar(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	server := newService(lis, nil, nil)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", grpc_health_v1.HealthCheckResponse_SERVING)
+	require.NoError(t, server.AppCallbackRegistrar(func(s *grpc.Server) {
+		grpc_health_v1.RegisterHealthServer(s, healthServer)
+	}))
+	check := func(lis *bufconn.Listener) {
+		t.Helper()
+		conn, err := grpc.Dial("bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		require.NoError(t, err)
+		defer conn.Close()
+		resp, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "orders"})
+		require.NoError(t, err)
+		assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start() }()
+	check(lis)
+
+	// The server has started.
+	assert.Error(t, server.AppCallbackRegistrar(func(*grpc.Server) {}))
+
+	// The extra services are registered again on restart.
+	restartLis := bufconn.Listen(1024 * 1024)
+	go func() { errCh <- server.Restart(restartLis) }()
+	assert.NoError(t, <-errCh)
+	check(restartLis)
+
+	require.NoError(t, server.Stop())
+	assert.NoError(t, <-errCh)
+}
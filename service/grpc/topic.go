@@ -22,31 +22,84 @@ import (
 	"strings"
 
 	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 	"github.com/dapr/go-sdk/service/common"
 	"github.com/dapr/go-sdk/service/internal"
 )
 
+// CloudEvent extensions with the W3C trace context of the event.
+const (
+	traceParentExtension = "traceparent"
+	traceStateExtension  = "tracestate"
+)
+
+// subjectExtension is the CloudEvent attribute holding the subject of the
+// event, passed in the extensions.
+const subjectExtension = "subject"
+
 // AddTopicEventHandler appends provided event handler with topic name to the service.
 func (s *Server) AddTopicEventHandler(sub *common.Subscription, fn common.TopicEventHandler) error {
+	return s.AddTopicEventHandlerWithMiddleware(sub, fn)
+}
+
+// AddTopicEventHandlerWithMiddleware appends provided event handler with topic
+// name to the service, wrapped with mw.
+func (s *Server) AddTopicEventHandlerWithMiddleware(sub *common.Subscription, fn common.TopicEventHandler, mw ...common.TopicMiddleware) error {
 	if sub == nil {
 		return errors.New("subscription required")
 	}
+	if len(sub.PubsubNames) > 0 {
+		subs, err := internal.ExpandPubsubNames(sub)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			if err := s.AddTopicEventHandlerWithMiddleware(sub, fn, mw...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if sub.Namespace != "" {
+		sub = internal.NamespaceSubscription(sub)
+	}
+	mw, err := s.schemaValidations.TopicMiddleware(sub.Route, mw)
+	if err != nil {
+		return err
+	}
+	info := internal.NewRegistration(common.RegistrationTopic, sub.PubsubName+"/"+sub.Topic, sub.Route)
+	if fn != nil {
+		fn = internal.AuditTopicHandler(info, fn)
+	}
+	if err := s.topicRegistrar.AddSubscription(sub, fn, mw...); err != nil {
+		return err
+	}
+	s.registrations.Add(info)
+	return nil
+}
 
-	return s.topicRegistrar.AddSubscription(sub, fn)
+// UseTopicMiddleware applies mw to all the topic event handlers, the first
+// middleware being the outermost.
+func (s *Server) UseTopicMiddleware(mw ...common.TopicMiddleware) {
+	s.topicMiddleware.Use(mw...)
 }
 
 // ListTopicSubscriptions is called by Dapr to get the list of topics in a pubsub component the app wants to subscribe to.
 func (s *Server) ListTopicSubscriptions(ctx context.Context, in *empty.Empty) (*runtimev1pb.ListTopicSubscriptionsResponse, error) {
 	subs := make([]*runtimev1pb.TopicSubscription, 0)
-	for _, v := range s.topicRegistrar {
-		s := v.Subscription
+	for _, s := range s.topicRegistrar.Subscriptions() {
 		sub := &runtimev1pb.TopicSubscription{
-			PubsubName: s.PubsubName,
-			Topic:      s.Topic,
-			Metadata:   s.Metadata,
-			Routes:     convertRoutes(s.Routes),
+			PubsubName:      s.PubsubName,
+			Topic:           s.Topic,
+			Metadata:        s.Metadata,
+			Routes:          convertRoutes(s.Routes),
+			DeadLetterTopic: s.DeadLetterTopic,
 		}
 		subs = append(subs, sub)
 	}
@@ -75,12 +128,18 @@ func convertRoutes(routes *internal.TopicRoutes) *runtimev1pb.TopicRoutes {
 
 // OnTopicEvent fired whenever a message has been published to a topic that has been subscribed.
 // Dapr sends published messages in a CloudEvents v1.0 envelope.
+// The trace context of the event is available to the handler with common.TraceContextFromContext.
 func (s *Server) OnTopicEvent(ctx context.Context, in *runtimev1pb.TopicEventRequest) (*runtimev1pb.TopicEventResponse, error) {
 	if in == nil || in.Topic == "" || in.PubsubName == "" {
 		// this is really Dapr issue more than the event request format.
 		// since Dapr will not get updated until long after this event expires, just drop it
 		return &runtimev1pb.TopicEventResponse{Status: runtimev1pb.TopicEventResponse_DROP}, errors.New("pub/sub and topic names required")
 	}
+	if !s.topicDeliveries.Begin() {
+		return nil, status.Error(codes.Unavailable, "service is shutting down")
+	}
+	defer s.topicDeliveries.End()
+
 	key := in.PubsubName + "-" + in.Topic
 	noValidationKey := in.PubsubName
 
@@ -93,40 +152,9 @@ func (s *Server) OnTopicEvent(ctx context.Context, in *runtimev1pb.TopicEventReq
 	}
 
 	if ok {
-		data := interface{}(in.Data)
-		if len(in.Data) > 0 {
-			mediaType, _, err := mime.ParseMediaType(in.DataContentType)
-			if err == nil {
-				var v interface{}
-				switch mediaType {
-				case "application/json":
-					if err := json.Unmarshal(in.Data, &v); err == nil {
-						data = v
-					}
-				case "text/plain":
-					// Assume UTF-8 encoded string.
-					data = string(in.Data)
-				default:
-					if strings.HasPrefix(mediaType, "application/") &&
-						strings.HasSuffix(mediaType, "+json") {
-						if err := json.Unmarshal(in.Data, &v); err == nil {
-							data = v
-						}
-					}
-				}
-			}
-		}
-
-		e := &common.TopicEvent{
-			ID:              in.Id,
-			Source:          in.Source,
-			Type:            in.Type,
-			SpecVersion:     in.SpecVersion,
-			DataContentType: in.DataContentType,
-			Data:            data,
-			RawData:         in.Data,
-			Topic:           in.Topic,
-			PubsubName:      in.PubsubName,
+		e, err := topicEvent(ctx, in)
+		if err != nil {
+			return &runtimev1pb.TopicEventResponse{Status: runtimev1pb.TopicEventResponse_DROP}, err
 		}
 		h := sub.DefaultHandler
 		if in.Path != "" {
@@ -140,7 +168,18 @@ func (s *Server) OnTopicEvent(ctx context.Context, in *runtimev1pb.TopicEventReq
 				in.Path, in.PubsubName, in.Topic,
 			)
 		}
-		retry, err := h(ctx, e)
+		hctx := s.handlerContext(ctx, map[string]string{
+			common.LogFieldPubsub: in.PubsubName,
+			common.LogFieldTopic:  in.Topic,
+			common.LogFieldRoute:  in.Path,
+		})
+		if tc, ok := traceContextFromExtensions(in.GetExtensions()); ok {
+			hctx = common.ContextWithTraceContext(hctx, tc)
+		}
+		hctx = common.WithResponseMetadata(hctx)
+		s.recentEvents.Add(e)
+		retry, err := s.topicMiddleware.Wrap(h)(hctx, e)
+		sendResponseMetadata(ctx, hctx)
 		if err == nil {
 			return &runtimev1pb.TopicEventResponse{Status: runtimev1pb.TopicEventResponse_SUCCESS}, nil
 		}
@@ -154,3 +193,151 @@ func (s *Server) OnTopicEvent(ctx context.Context, in *runtimev1pb.TopicEventReq
 		in.PubsubName, in.Topic,
 	)
 }
+
+// topicEvent returns the event of in, which is a binary-mode CloudEvent whose
+// attributes are the fields of in, or a structured-mode CloudEvent held in its
+// data, such as when the broker delivers the event as an opaque payload. Both
+// modes give the same TopicEvent for the same event.
+func topicEvent(ctx context.Context, in *runtimev1pb.TopicEventRequest) (*common.TopicEvent, error) {
+	ext := in.GetExtensions().GetFields()
+	e := &common.TopicEvent{
+		ID:              in.Id,
+		Source:          in.Source,
+		Type:            in.Type,
+		SpecVersion:     in.SpecVersion,
+		DataContentType: in.DataContentType,
+		Subject:         ext[subjectExtension].GetStringValue(),
+		Topic:           in.Topic,
+		PubsubName:      in.PubsubName,
+		ContentEncoding: ext[internal.ContentEncodingExtension].GetStringValue(),
+		DeliveryAttempt: deliveryAttempt(ctx),
+		Extensions:      internal.ExtensionAttributes(in.GetExtensions().AsMap()),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		e.Metadata = internal.BrokerMetadata(md)
+	}
+	rawData, err := internal.DecodeContent(e.ContentEncoding, in.Data)
+	if err != nil {
+		return nil, err
+	}
+	if ce, ok := internal.StructuredCloudEvent(in.DataContentType, rawData); ok {
+		e.Extensions = internal.CloudEventExtensions(rawData)
+		if rawData, err = ce.RawData(); err != nil {
+			return nil, err
+		}
+		e.ID = ce.ID
+		e.Source = ce.Source
+		e.Type = ce.Type
+		e.SpecVersion = ce.SpecVersion
+		e.DataContentType = ce.DataContentType
+		e.Subject = ce.Subject
+		e.ContentEncoding = ce.ContentEncoding
+	}
+	e.RawData = rawData
+	e.Data = topicEventData(e.DataContentType, rawData)
+	return e, nil
+}
+
+// topicEventData returns rawData decoded according to its content type, or
+// as is if it is not JSON or text.
+func topicEventData(contentType string, rawData []byte) interface{} {
+	data := interface{}(rawData)
+	if len(rawData) == 0 {
+		return data
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return data
+	}
+	var v interface{}
+	switch mediaType {
+	case "application/json":
+		if err := json.Unmarshal(rawData, &v); err == nil {
+			data = v
+		}
+	case "text/plain":
+		// Assume UTF-8 encoded string.
+		data = string(rawData)
+	default:
+		if strings.HasPrefix(mediaType, "application/") &&
+			strings.HasSuffix(mediaType, "+json") {
+			if err := json.Unmarshal(rawData, &v); err == nil {
+				data = v
+			}
+		}
+	}
+	return data
+}
+
+// sendResponseMetadata sends the metadata set by the handler called with hctx
+// in the response headers of the call of ctx, as the topic event response has
+// no metadata field.
+func sendResponseMetadata(ctx, hctx context.Context) {
+	headers, dropped := internal.ResponseMetadataHeaders(common.ResponseMetadata(hctx))
+	if len(dropped) > 0 {
+		common.LoggerFromContext(hctx).Printf("debug: dropped unsupported response metadata keys %v", dropped)
+	}
+	if len(headers) == 0 {
+		return
+	}
+	md := make(metadata.MD, len(headers))
+	for k, v := range headers {
+		md.Set(k, v)
+	}
+	if err := grpc.SetHeader(ctx, md); err != nil {
+		common.LoggerFromContext(hctx).Printf("debug: dropped response metadata: %v", err)
+	}
+}
+
+// deliveryAttempt returns the delivery attempt of the event from the broker
+// metadata passed by the runtime in the incoming gRPC metadata.
+func deliveryAttempt(ctx context.Context) int {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0
+	}
+	return internal.DeliveryAttempt(func(key string) string {
+		if v := md.Get(internal.BrokerMetadataPrefix + key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	})
+}
+
+// traceContextFromExtensions returns the trace context in the traceparent and
+// tracestate extensions of a CloudEvent, and false if there is no traceparent.
+func traceContextFromExtensions(ext *structpb.Struct) (common.TraceContext, bool) {
+	fields := ext.GetFields()
+	traceParent := fields[traceParentExtension].GetStringValue()
+	if traceParent == "" {
+		return common.TraceContext{}, false
+	}
+	return common.TraceContext{
+		TraceParent: traceParent,
+		TraceState:  fields[traceStateExtension].GetStringValue(),
+	}, true
+}
+
+// RecentEvents returns the last events delivered to the handlers of topic,
+// oldest first, retained with WithRecentEvents.
+func (s *Server) RecentEvents(topic string) []common.TopicEvent {
+	return s.recentEvents.List(topic)
+}
+
+// SubscriptionStats returns the stats of each topic subscription, keyed by
+// "<pubsubname>/<topic>".
+func (s *Server) SubscriptionStats() map[string]common.SubscriptionStats {
+	return s.topicRegistrar.Stats()
+}
+
+// RegisteredTopics returns copies of the subscriptions the topic event
+// handlers were registered with, in registration order of the topics.
+func (s *Server) RegisteredTopics() []common.Subscription {
+	return s.topicRegistrar.Registered()
+}
+
+// ExportSubscriptions returns the declarations of the topic subscriptions as
+// Dapr Subscription resources, in registration order.
+func (s *Server) ExportSubscriptions(format common.ExportFormat) ([]byte, error) {
+	return internal.ExportSubscriptions(s.topicRegistrar.Subscriptions(), format)
+}
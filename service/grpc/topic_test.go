@@ -15,13 +15,27 @@ package grpc
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	runtime "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/client"
 	"github.com/dapr/go-sdk/service/common"
 )
 
@@ -91,6 +105,30 @@ func TestTopicSubscriptionList(t *testing.T) {
 	}
 }
 
+func TestTopicSubscriptionListOrder(t *testing.T) {
+	topics := []string{"a", "b", "c", "d", "e"}
+	rand.Shuffle(len(topics), func(i, j int) { topics[i], topics[j] = topics[j], topics[i] })
+
+	server := getTestServer()
+	for _, topic := range topics {
+		err := server.AddTopicEventHandler(&common.Subscription{
+			PubsubName: "messages",
+			Topic:      topic,
+			Route:      "/" + topic,
+		}, eventHandler)
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 5; i++ {
+		resp, err := server.ListTopicSubscriptions(context.Background(), &empty.Empty{})
+		require.NoError(t, err)
+		require.Len(t, resp.Subscriptions, len(topics))
+		for j, sub := range resp.Subscriptions {
+			assert.Equal(t, topics[j], sub.Topic)
+		}
+	}
+}
+
 // go test -timeout 30s ./service/grpc -count 1 -run ^TestTopic$
 func TestTopic(t *testing.T) {
 	ctx := context.Background()
@@ -314,3 +352,509 @@ func TestEventDataHandling(t *testing.T) {
 		})
 	}
 }
+
+// pubsubRuntime is a fake Dapr runtime that delivers published events
+// straight back to the app. Like Dapr, it delivers the attributes of published
+// CloudEvents as fields of the request, and their other attributes as
+// extensions.
+type pubsubRuntime struct {
+	runtime.UnimplementedDaprServer
+	app *Server
+}
+
+func (r *pubsubRuntime) PublishEvent(ctx context.Context, in *runtime.PublishEventRequest) (*empty.Empty, error) {
+	req := &runtime.TopicEventRequest{
+		Id:              "1",
+		Source:          "test",
+		Type:            "com.dapr.event.sent",
+		SpecVersion:     "1.0",
+		DataContentType: in.DataContentType,
+		Data:            in.Data,
+		Topic:           in.Topic,
+		PubsubName:      in.PubsubName,
+	}
+	if in.DataContentType == "application/cloudevents+json" {
+		var ce map[string]interface{}
+		if err := json.Unmarshal(in.Data, &ce); err != nil {
+			return nil, err
+		}
+		req.Id, _ = ce["id"].(string)
+		req.Source, _ = ce["source"].(string)
+		req.Type, _ = ce["type"].(string)
+		req.SpecVersion, _ = ce["specversion"].(string)
+		req.DataContentType, _ = ce["datacontenttype"].(string)
+		if b64, ok := ce["data_base64"].(string); ok {
+			data, err := base64.StdEncoding.DecodeString(b64)
+			if err != nil {
+				return nil, err
+			}
+			req.Data = data
+		}
+		for _, k := range []string{"id", "source", "type", "specversion", "datacontenttype", "data_base64"} {
+			delete(ce, k)
+		}
+		ext, err := structpb.NewStruct(ce)
+		if err != nil {
+			return nil, err
+		}
+		req.Extensions = ext
+	}
+	resp, err := r.app.OnTopicEvent(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != runtime.TopicEventResponse_SUCCESS {
+		return nil, fmt.Errorf("event not processed: %s", resp.Status)
+	}
+	return &empty.Empty{}, nil
+}
+
+func TestTopicCompressedEvents(t *testing.T) {
+	ctx := context.Background()
+	app := getTestServer()
+	c := startTestRuntime(t, &pubsubRuntime{app: app}, client.WithCompression(gzip.Name))
+
+	var events []*common.TopicEvent
+	sub := &common.Subscription{PubsubName: "messages", Topic: "test"}
+	require.NoError(t, app.AddTopicEventHandler(sub, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		events = append(events, e)
+		return false, nil
+	}))
+
+	payload := map[string]string{"message": strings.Repeat("hello ", 100)}
+	require.NoError(t, c.PublishEvent(ctx, "messages", "test", payload, client.PublishEventWithGzip()))
+	require.NoError(t, c.PublishEvent(ctx, "messages", "test", []byte("plain"), client.PublishEventWithContentType("text/plain")))
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "gzip", events[0].ContentEncoding)
+	assert.Equal(t, "application/json", events[0].DataContentType)
+	assert.Equal(t, map[string]interface{}{"message": payload["message"]}, events[0].Data)
+	assert.JSONEq(t, `{"message":"`+payload["message"]+`"}`, string(events[0].RawData))
+
+	assert.Equal(t, "", events[1].ContentEncoding)
+	assert.Equal(t, "plain", events[1].Data)
+	assert.Equal(t, []byte("plain"), events[1].RawData)
+
+	_, err := app.OnTopicEvent(ctx, &runtime.TopicEventRequest{
+		PubsubName: "messages",
+		Topic:      "test",
+		Data:       []byte("not gzip"),
+		Extensions: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"contentencoding": structpb.NewStringValue("gzip"),
+		}},
+	})
+	assert.Error(t, err)
+}
+
+func TestTopicEventTraceContext(t *testing.T) {
+	ctx := context.Background()
+	server := getTestServer()
+
+	var (
+		tc    common.TraceContext
+		found bool
+	)
+	sub := &common.Subscription{PubsubName: "messages", Topic: "test"}
+	require.NoError(t, server.AddTopicEventHandler(sub, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		tc, found = common.TraceContextFromContext(ctx)
+		return false, nil
+	}))
+
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	_, err := server.OnTopicEvent(ctx, &runtime.TopicEventRequest{
+		PubsubName: "messages",
+		Topic:      "test",
+		Extensions: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"traceparent": structpb.NewStringValue(traceParent),
+			"tracestate":  structpb.NewStringValue("congo=t61rcWkgMzE"),
+		}},
+	})
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, common.TraceContext{TraceParent: traceParent, TraceState: "congo=t61rcWkgMzE"}, tc)
+
+	_, err = server.OnTopicEvent(ctx, &runtime.TopicEventRequest{
+		PubsubName: "messages",
+		Topic:      "test",
+	})
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestTopicEventDeliveryAttempt(t *testing.T) {
+	server := getTestServer()
+	var attempt int
+	sub := &common.Subscription{PubsubName: "messages", Topic: "test"}
+	require.NoError(t, server.AddTopicEventHandler(sub, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		attempt = e.DeliveryAttempt
+		return false, nil
+	}))
+
+	in := &runtime.TopicEventRequest{PubsubName: "messages", Topic: "test"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("metadata.deliverycount", "4"))
+	_, err := server.OnTopicEvent(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, 4, attempt)
+
+	_, err = server.OnTopicEvent(context.Background(), in)
+	require.NoError(t, err)
+	assert.Equal(t, 0, attempt)
+}
+
+func TestTopicEventModes(t *testing.T) {
+	ctx := context.Background()
+	server := getTestServer()
+	var got *common.TopicEvent
+	sub := &common.Subscription{PubsubName: "messages", Topic: "test"}
+	require.NoError(t, server.AddTopicEventHandler(sub, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		got = e
+		return false, nil
+	}))
+	receive := func(in *runtime.TopicEventRequest) *common.TopicEvent {
+		t.Helper()
+		got = nil
+		_, err := server.OnTopicEvent(ctx, in)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		return got
+	}
+	binary := func(contentType, data string) *runtime.TopicEventRequest {
+		return &runtime.TopicEventRequest{
+			Id:              "event-1",
+			Source:          "orders",
+			Type:            "order.created",
+			SpecVersion:     "1.0",
+			DataContentType: contentType,
+			Data:            []byte(data),
+			Topic:           "test",
+			PubsubName:      "messages",
+			Extensions: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"subject": structpb.NewStringValue("order-42"),
+			}},
+		}
+	}
+	structured := func(contentType, event string) *runtime.TopicEventRequest {
+		// The attributes of the request are the ones of the envelope of the
+		// event, such as the one added by the sidecar for a raw payload.
+		return &runtime.TopicEventRequest{
+			Id:              "envelope",
+			Source:          "dapr",
+			Type:            "com.dapr.event.sent",
+			SpecVersion:     "1.0",
+			DataContentType: contentType,
+			Data:            []byte(event),
+			Topic:           "test",
+			PubsubName:      "messages",
+		}
+	}
+	const attributes = `"id":"event-1","source":"orders","type":"order.created","specversion":"1.0","subject":"order-42"`
+
+	tests := []struct {
+		name       string
+		binary     *runtime.TopicEventRequest
+		structured *runtime.TopicEventRequest
+	}{
+		{
+			"json",
+			binary("application/json", `{"id":42}`),
+			structured("application/cloudevents+json", `{`+attributes+`,"datacontenttype":"application/json","data":{"id":42}}`),
+		},
+		{
+			"text",
+			binary("text/plain", "hello"),
+			structured("application/cloudevents+json; charset=utf-8", `{`+attributes+`,"datacontenttype":"text/plain","data":"hello"}`),
+		},
+		{
+			"base64",
+			binary("application/octet-stream", "\x01\x02"),
+			structured("application/cloudevents+json", `{`+attributes+`,"datacontenttype":"application/octet-stream","data_base64":"AQI="}`),
+		},
+		{
+			"raw payload",
+			binary("application/json", `{"id":42}`),
+			structured("application/octet-stream", `{`+attributes+`,"datacontenttype":"application/json","data":{"id":42}}`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, receive(tt.binary), receive(tt.structured))
+			assert.Equal(t, "event-1", got.ID)
+			assert.Equal(t, "order-42", got.Subject)
+		})
+	}
+
+	t.Run("json payload is not an event", func(t *testing.T) {
+		e := receive(binary("application/json", `{"id":"1","source":"orders"}`))
+		assert.Equal(t, "order.created", e.Type)
+		assert.Equal(t, map[string]interface{}{"id": "1", "source": "orders"}, e.Data)
+	})
+}
+
+func TestTopicEventMetadata(t *testing.T) {
+	server := getTestServer()
+	var got map[string]string
+	sub := &common.Subscription{PubsubName: "messages", Topic: "test"}
+	require.NoError(t, server.AddTopicEventHandler(sub, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		got = e.Metadata
+		common.SetResponseMetadata(ctx, map[string]string{"commit": "false", "Bad Key": "dropped"})
+		common.SetResponseMetadata(ctx, map[string]string{"retry-after": "5"})
+		return false, nil
+	}))
+	go func() { _ = server.Start() }()
+	defer server.Stop()
+
+	lis := server.listener.(*bufconn.Listener)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"metadata.partition", "3", "metadata.offset", "42", "traceparent", "ignored")
+	var header metadata.MD
+	resp, err := runtime.NewAppCallbackClient(conn).OnTopicEvent(ctx,
+		&runtime.TopicEventRequest{PubsubName: "messages", Topic: "test"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, runtime.TopicEventResponse_SUCCESS, resp.Status)
+
+	assert.Equal(t, map[string]string{"partition": "3", "offset": "42"}, got)
+	assert.Equal(t, []string{"false"}, header.Get("metadata.commit"))
+	assert.Equal(t, []string{"5"}, header.Get("metadata.retry-after"))
+	assert.Empty(t, header.Get("metadata.bad key"))
+}
+
+func TestTopicSubscriptionListFromBuilder(t *testing.T) {
+	subs, err := common.NewSubscription("messages", "orders").
+		WithRule(`event.type == "created"`, "/created").
+		WithRule(`event.type == "deleted"`, "/deleted").
+		WithDeadLetter("orders-dlq").
+		Build()
+	require.NoError(t, err)
+
+	server := getTestServer()
+	for _, sub := range subs {
+		require.NoError(t, server.AddTopicEventHandler(sub, eventHandler))
+	}
+
+	resp, err := server.ListTopicSubscriptions(context.Background(), &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, resp.Subscriptions, 1)
+	sub := resp.Subscriptions[0]
+	assert.Equal(t, "orders-dlq", sub.DeadLetterTopic)
+	require.Len(t, sub.Routes.Rules, 2)
+	assert.Equal(t, "/created", sub.Routes.Rules[0].Path)
+	assert.Equal(t, "/deleted", sub.Routes.Rules[1].Path)
+}
+
+func TestTopicSubscriptionNamespace(t *testing.T) {
+	subs, err := common.NewSubscription("messages", "orders").
+		WithRoute("/orders").
+		WithDeadLetter("orders-dlq").
+		WithNamespace("team-a").
+		Build()
+	require.NoError(t, err)
+
+	server := getTestServer()
+	require.NoError(t, server.AddTopicEventHandler(subs[0], eventHandler))
+	require.NoError(t, server.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "audit", Route: "/audit"}, eventHandler))
+
+	resp, err := server.ListTopicSubscriptions(context.Background(), &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, resp.Subscriptions, 2)
+	assert.Equal(t, "team-aorders", resp.Subscriptions[0].Topic)
+	assert.Equal(t, "team-aorders-dlq", resp.Subscriptions[0].DeadLetterTopic)
+	assert.Equal(t, "audit", resp.Subscriptions[1].Topic)
+
+	_, err = server.OnTopicEvent(context.Background(), &runtime.TopicEventRequest{
+		PubsubName: "messages", Topic: "team-aorders", Path: "/orders",
+	})
+	require.NoError(t, err)
+}
+
+func TestTopicSubscriptionStats(t *testing.T) {
+	ctx := context.Background()
+	server := getTestServer()
+	sub := &common.Subscription{PubsubName: "messages", Topic: "test"}
+	require.NoError(t, server.AddTopicEventHandler(sub, eventHandler))
+
+	for i := 0; i < 3; i++ {
+		_, err := server.OnTopicEvent(ctx, &runtime.TopicEventRequest{PubsubName: "messages", Topic: "test"})
+		require.NoError(t, err)
+	}
+
+	stats := server.SubscriptionStats()["messages/test"]
+	assert.Equal(t, uint64(3), stats.Delivered)
+	assert.Equal(t, uint64(0), stats.Failed)
+	assert.Equal(t, 3, stats.LatencySamples)
+	assert.Positive(t, stats.MaxLatency)
+}
+
+// recordingMiddleware records the calls to the handler it wraps and their outcome.
+func recordingMiddleware(name string, calls *[]string) common.TopicMiddleware {
+	return func(next common.TopicEventHandler) common.TopicEventHandler {
+		return func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+			*calls = append(*calls, name)
+			retry, err := next(ctx, e)
+			*calls = append(*calls, fmt.Sprintf("%s: retry=%t err=%v", name, retry, err))
+			return retry, err
+		}
+	}
+}
+
+func TestTopicMiddleware(t *testing.T) {
+	ctx := context.Background()
+	sub1 := &common.Subscription{PubsubName: "messages", Topic: "test1"}
+	sub2 := &common.Subscription{PubsubName: "messages", Topic: "test2"}
+
+	t.Run("global and per-registration middleware", func(t *testing.T) {
+		server := getTestServer()
+		var calls []string
+		require.NoError(t, server.AddTopicEventHandlerWithMiddleware(sub1, eventHandlerWithRetryError, recordingMiddleware("route", &calls)))
+		server.UseTopicMiddleware(recordingMiddleware("outer", &calls), recordingMiddleware("inner", &calls))
+		require.NoError(t, server.AddTopicEventHandler(sub2, eventHandler))
+
+		resp, err := server.OnTopicEvent(ctx, &runtime.TopicEventRequest{PubsubName: sub1.PubsubName, Topic: sub1.Topic})
+		assert.Error(t, err)
+		assert.Equal(t, runtime.TopicEventResponse_RETRY, resp.GetStatus())
+		assert.Equal(t, []string{
+			"outer",
+			"inner",
+			"route",
+			"route: retry=true err=nil event",
+			"inner: retry=true err=nil event",
+			"outer: retry=true err=nil event",
+		}, calls)
+
+		calls = nil
+		resp, err = server.OnTopicEvent(ctx, &runtime.TopicEventRequest{PubsubName: sub2.PubsubName, Topic: sub2.Topic})
+		require.NoError(t, err)
+		assert.Equal(t, runtime.TopicEventResponse_SUCCESS, resp.GetStatus())
+		assert.Equal(t, []string{"outer", "inner", "inner: retry=false err=<nil>", "outer: retry=false err=<nil>"}, calls)
+	})
+
+	t.Run("middleware suppressing the error", func(t *testing.T) {
+		server := getTestServer()
+		require.NoError(t, server.AddTopicEventHandler(sub1, eventHandlerWithRetryError))
+		server.UseTopicMiddleware(func(next common.TopicEventHandler) common.TopicEventHandler {
+			return func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+				_, _ = next(ctx, e)
+				return false, nil
+			}
+		})
+
+		resp, err := server.OnTopicEvent(ctx, &runtime.TopicEventRequest{PubsubName: sub1.PubsubName, Topic: sub1.Topic})
+		require.NoError(t, err)
+		assert.Equal(t, runtime.TopicEventResponse_SUCCESS, resp.GetStatus())
+	})
+}
+
+func TestTopicMultiplePubsubNames(t *testing.T) {
+	ctx := context.Background()
+	server := getTestServer()
+	var pubsubs []string
+	require.NoError(t, server.AddTopicEventHandler(&common.Subscription{
+		PubsubNames: []string{"primary", "backup"},
+		Topic:       "orders",
+		Route:       "/orders",
+	}, func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+		pubsubs = append(pubsubs, e.PubsubName)
+		return false, nil
+	}))
+
+	resp, err := server.ListTopicSubscriptions(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, resp.Subscriptions, 2)
+	assert.Equal(t, "primary", resp.Subscriptions[0].PubsubName)
+	assert.Equal(t, "backup", resp.Subscriptions[1].PubsubName)
+	for _, sub := range server.RegisteredTopics() {
+		assert.Empty(t, sub.PubsubNames)
+	}
+
+	for _, name := range []string{"backup", "primary"} {
+		_, err := server.OnTopicEvent(ctx, &runtime.TopicEventRequest{PubsubName: name, Topic: "orders", Path: "/orders"})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"backup", "primary"}, pubsubs)
+
+	t.Run("invalid names", func(t *testing.T) {
+		for _, sub := range []*common.Subscription{
+			{PubsubNames: []string{"a", ""}, Topic: "orders"},
+			{PubsubNames: []string{"a", "a"}, Topic: "orders"},
+			{PubsubName: "c", PubsubNames: []string{"a", "b"}, Topic: "orders"},
+		} {
+			assert.Error(t, getTestServer().AddTopicEventHandler(sub, eventHandler))
+		}
+	})
+}
+
+func TestTopicRecentEvents(t *testing.T) {
+	ctx := context.Background()
+	server := newService(bufconn.Listen(1024*1024), nil, nil, WithRecentEvents(2))
+	require.NoError(t, server.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "orders"}, eventHandler))
+
+	for _, id := range []string{"1", "2", "3"} {
+		_, err := server.OnTopicEvent(ctx, &runtime.TopicEventRequest{
+			Id:              id,
+			Source:          "test",
+			Type:            "test",
+			SpecVersion:     "v1.0",
+			DataContentType: "text/plain",
+			Data:            []byte("order " + id),
+			Topic:           "orders",
+			PubsubName:      "messages",
+		})
+		require.NoError(t, err)
+	}
+	events := server.RecentEvents("orders")
+	require.Len(t, events, 2)
+	assert.Equal(t, "2", events[0].ID)
+	assert.Equal(t, "order 3", events[1].Data)
+	assert.Empty(t, getTestServer().RecentEvents("orders"))
+}
+
+func TestTopicEventExtensions(t *testing.T) {
+	ctx := context.Background()
+	server := getTestServer()
+	var ext map[string]interface{}
+	require.NoError(t, server.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "orders"},
+		func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+			ext = e.Extensions
+			return false, nil
+		}))
+
+	t.Run("binary mode", func(t *testing.T) {
+		_, err := server.OnTopicEvent(ctx, &runtime.TopicEventRequest{
+			Id:              "1",
+			Source:          "test",
+			Type:            "test",
+			SpecVersion:     "1.0",
+			DataContentType: "text/plain",
+			Data:            []byte("order"),
+			Topic:           "orders",
+			PubsubName:      "messages",
+			Extensions: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"subject":       structpb.NewStringValue("order"),
+				"correlationid": structpb.NewStringValue("c1"),
+			}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"correlationid": "c1"}, ext)
+	})
+
+	t.Run("structured mode", func(t *testing.T) {
+		_, err := server.OnTopicEvent(ctx, &runtime.TopicEventRequest{
+			Id:              "2",
+			Source:          "test",
+			Type:            "test",
+			SpecVersion:     "1.0",
+			DataContentType: "application/cloudevents+json",
+			Data:            []byte(`{"id":"2","specversion":"1.0","source":"test","type":"test","data":"order","replytopic":"replies"}`),
+			Topic:           "orders",
+			PubsubName:      "messages",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"replytopic": "replies"}, ext)
+	})
+}
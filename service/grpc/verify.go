@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"log"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+const (
+	defaultVerificationTimeout  = 30 * time.Second
+	defaultVerificationInterval = time.Second
+	// defaultVerificationPollTimeout bounds each metadata request, so that a
+	// hung one doesn't stall the verification.
+	defaultVerificationPollTimeout = 5 * time.Second
+)
+
+// MetadataGetter retrieves the metadata of the Dapr runtime. dapr.Client
+// satisfies this interface.
+type MetadataGetter interface {
+	GetMetadata(ctx context.Context) (*dapr.GetMetadataResponse, error)
+}
+
+// subscriptionVerifier checks that the Dapr runtime accepted the topic
+// subscriptions advertised by the service.
+type subscriptionVerifier struct {
+	client      MetadataGetter
+	onMissing   func(missing []*common.Subscription)
+	timeout     time.Duration
+	interval    time.Duration
+	pollTimeout time.Duration
+	cancel      context.CancelFunc
+}
+
+func newSubscriptionVerifier(c MetadataGetter) *subscriptionVerifier {
+	return &subscriptionVerifier{
+		client:      c,
+		timeout:     defaultVerificationTimeout,
+		interval:    defaultVerificationInterval,
+		pollTimeout: defaultVerificationPollTimeout,
+	}
+}
+
+// start verifies the expected subscriptions in the background until they are
+// all accepted, the timeout expires, or stop is called.
+func (v *subscriptionVerifier) start(expected []*internal.TopicSubscription) {
+	if len(expected) == 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	v.cancel = cancel
+	go v.verify(ctx, expected)
+}
+
+// stop cancels a running verification.
+func (v *subscriptionVerifier) stop() {
+	if v.cancel != nil {
+		v.cancel()
+		v.cancel = nil
+	}
+}
+
+func (v *subscriptionVerifier) verify(ctx context.Context, expected []*internal.TopicSubscription) {
+	deadline := time.NewTimer(v.timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	missing := expected
+	var lastErr error
+	for {
+		md, err := v.poll(ctx)
+		if err == nil {
+			lastErr = nil
+			if missing = missingSubscriptions(expected, md.Subscriptions); len(missing) == 0 {
+				return
+			}
+		} else {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			v.report(missing, lastErr)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll retrieves the runtime metadata, bounded by the poll timeout.
+func (v *subscriptionVerifier) poll(ctx context.Context) (*dapr.GetMetadataResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.pollTimeout)
+	defer cancel()
+	return v.client.GetMetadata(ctx)
+}
+
+// report passes the subscriptions the runtime didn't accept to the callback,
+// or logs them if there is no callback. If the last poll failed, missing holds
+// the subscriptions found missing by the last successful one, or all of them.
+func (v *subscriptionVerifier) report(missing []*internal.TopicSubscription, err error) {
+	if err != nil {
+		log.Printf("unable to verify topic subscriptions, reporting the last known missing ones, err: %v", err)
+	}
+	subs := make([]*common.Subscription, len(missing))
+	for i, sub := range missing {
+		subs[i] = &common.Subscription{
+			PubsubName:      sub.PubsubName,
+			Topic:           sub.Topic,
+			Metadata:        sub.Metadata,
+			Route:           sub.Route,
+			DeadLetterTopic: sub.DeadLetterTopic,
+		}
+	}
+	if v.onMissing != nil {
+		v.onMissing(subs)
+		return
+	}
+	for _, sub := range subs {
+		log.Printf("ERROR: topic subscription %s/%s was not accepted by the Dapr runtime", sub.PubsubName, sub.Topic)
+	}
+}
+
+// missingSubscriptions returns the expected subscriptions that are not active
+// in the runtime.
+func missingSubscriptions(expected []*internal.TopicSubscription, active []*dapr.MetadataSubscription) []*internal.TopicSubscription {
+	accepted := make(map[string]struct{}, len(active))
+	for _, sub := range active {
+		accepted[sub.PubsubName+"/"+sub.Topic] = struct{}{}
+	}
+	var missing []*internal.TopicSubscription
+	for _, sub := range expected {
+		if _, ok := accepted[sub.PubsubName+"/"+sub.Topic]; !ok {
+			missing = append(missing, sub)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/test/bufconn"
+
+	dapr "github.com/dapr/go-sdk/client"
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// fakeMetadataGetter returns the active subscriptions after a number of calls,
+// simulating a runtime that registers them slowly. Calls after failAfter, if
+// set, fail, and with hang set they block until their context is done.
+type fakeMetadataGetter struct {
+	active    []*dapr.MetadataSubscription
+	after     int32
+	failAfter int32
+	hang      bool
+	calls     atomic.Int32
+}
+
+func (f *fakeMetadataGetter) GetMetadata(ctx context.Context) (*dapr.GetMetadataResponse, error) {
+	n := f.calls.Add(1)
+	if f.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.failAfter > 0 && n > f.failAfter {
+		return nil, errors.New("runtime unavailable")
+	}
+	if n <= f.after {
+		return &dapr.GetMetadataResponse{}, nil
+	}
+	return &dapr.GetMetadataResponse{Subscriptions: f.active}, nil
+}
+
+func newVerifiedTestServer(t *testing.T, md MetadataGetter, onMissing func([]*common.Subscription)) *Server {
+	t.Helper()
+
+	server := newService(bufconn.Listen(1024*1024), nil, nil,
+		WithSubscriptionVerification(md),
+		WithSubscriptionVerificationCallback(onMissing),
+		WithSubscriptionVerificationTimeout(200*time.Millisecond),
+	)
+	server.verifier.interval = 10 * time.Millisecond
+	for _, topic := range []string{"orders", "payments"} {
+		err := server.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: topic}, eventHandler)
+		require.NoError(t, err)
+	}
+	return server
+}
+
+func TestSubscriptionVerification(t *testing.T) {
+	t.Run("missing subscription is reported", func(t *testing.T) {
+		md := &fakeMetadataGetter{active: []*dapr.MetadataSubscription{
+			{PubsubName: "messages", Topic: "orders"},
+		}}
+		missing := make(chan []*common.Subscription, 1)
+		server := newVerifiedTestServer(t, md, func(subs []*common.Subscription) { missing <- subs })
+		startTestServer(server)
+		defer server.Stop()
+
+		select {
+		case subs := <-missing:
+			require.Len(t, subs, 1)
+			assert.Equal(t, "messages", subs[0].PubsubName)
+			assert.Equal(t, "payments", subs[0].Topic)
+		case <-time.After(5 * time.Second):
+			t.Fatal("missing subscriptions not reported")
+		}
+	})
+
+	t.Run("last known missing subscriptions are reported when the last poll fails", func(t *testing.T) {
+		md := &fakeMetadataGetter{failAfter: 2, active: []*dapr.MetadataSubscription{
+			{PubsubName: "messages", Topic: "orders"},
+		}}
+		missing := make(chan []*common.Subscription, 1)
+		server := newVerifiedTestServer(t, md, func(subs []*common.Subscription) { missing <- subs })
+		startTestServer(server)
+		defer server.Stop()
+
+		select {
+		case subs := <-missing:
+			require.Len(t, subs, 1)
+			assert.Equal(t, "payments", subs[0].Topic)
+		case <-time.After(5 * time.Second):
+			t.Fatal("missing subscriptions not reported")
+		}
+	})
+
+	t.Run("hung polls are bounded", func(t *testing.T) {
+		md := &fakeMetadataGetter{hang: true}
+		missing := make(chan []*common.Subscription, 1)
+		server := newVerifiedTestServer(t, md, func(subs []*common.Subscription) { missing <- subs })
+		server.verifier.pollTimeout = 20 * time.Millisecond
+		startTestServer(server)
+		defer server.Stop()
+
+		select {
+		case subs := <-missing:
+			assert.Len(t, subs, 2)
+			assert.Greater(t, md.calls.Load(), int32(1))
+		case <-time.After(5 * time.Second):
+			t.Fatal("missing subscriptions not reported")
+		}
+	})
+
+	t.Run("slow registration is not reported", func(t *testing.T) {
+		md := &fakeMetadataGetter{after: 3, active: []*dapr.MetadataSubscription{
+			{PubsubName: "messages", Topic: "orders"},
+			{PubsubName: "messages", Topic: "payments"},
+		}}
+		var reported atomic.Bool
+		server := newVerifiedTestServer(t, md, func([]*common.Subscription) { reported.Store(true) })
+		startTestServer(server)
+		defer server.Stop()
+
+		time.Sleep(400 * time.Millisecond)
+		assert.False(t, reported.Load())
+		assert.EqualValues(t, 4, md.calls.Load())
+	})
+
+	t.Run("stop cancels the verification", func(t *testing.T) {
+		md := &fakeMetadataGetter{}
+		var reported atomic.Bool
+		server := newVerifiedTestServer(t, md, func([]*common.Subscription) { reported.Store(true) })
+		startTestServer(server)
+		require.Eventually(t, func() bool { return md.calls.Load() > 0 }, time.Second, 5*time.Millisecond)
+		require.NoError(t, server.Stop())
+
+		time.Sleep(400 * time.Millisecond)
+		assert.False(t, reported.Load())
+	})
+}
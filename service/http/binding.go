@@ -14,12 +14,14 @@ limitations under the License.
 package http
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
 	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
 )
 
 // AddBindingInvocationHandler appends provided binding invocation handler with its route to the service.
@@ -34,8 +36,12 @@ func (s *Server) AddBindingInvocationHandler(route string, fn common.BindingInvo
 	if !strings.HasPrefix(route, "/") {
 		route = fmt.Sprintf("/%s", route)
 	}
+	s.bindingRoutes[route[1:]] = struct{}{}
+	info := internal.NewRegistration(common.RegistrationBinding, route[1:], route)
+	fn = internal.AuditBindingHandler(info, fn)
+	s.registrations.Add(info)
 
-	s.mux.Handle(route, optionsHandler(http.HandlerFunc(
+	s.mux.Handle(route, optionsHandler(s.authHandler(http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			var (
 				content []byte
@@ -65,8 +71,18 @@ func (s *Server) AddBindingInvocationHandler(route string, fn common.BindingInvo
 				Data:     content,
 				Metadata: meta,
 			}
-			out, err := fn(r.Context(), in)
-			if err != nil {
+			ctx := s.handlerContext(r.Context(), r, map[string]string{common.LogFieldBinding: route[1:]})
+			out, err := fn(ctx, in)
+			var be *common.BindingError
+			switch {
+			case internal.DropBindingEvent(ctx, route[1:], err):
+				out = nil
+			case errors.As(err, &be):
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write(be.JSON())
+				return
+			case err != nil:
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
@@ -80,7 +96,22 @@ func (s *Server) AddBindingInvocationHandler(route string, fn common.BindingInvo
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-		})))
+		}))))
 
 	return nil
 }
+
+// RegisteredBindings returns the sorted routes of the binding handlers, without
+// the leading slash as the names of the bindings.
+func (s *Server) RegisteredBindings() []string {
+	return sortedKeys(s.bindingRoutes)
+}
+
+// AddBindingInvocationHandlerAsync appends provided binding accept handler with its route to the service.
+// The binding event is acknowledged as soon as fn has accepted it, and delivered again if fn fails.
+func (s *Server) AddBindingInvocationHandlerAsync(route string, fn common.BindingAcceptHandler) error {
+	if fn == nil {
+		return fmt.Errorf("binding handler required")
+	}
+	return s.AddBindingInvocationHandler(route, internal.AcceptBindingHandler(fn))
+}
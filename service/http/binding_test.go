@@ -104,3 +104,53 @@ func TestBindingHandlerErrors(t *testing.T) {
 	s.mux.ServeHTTP(resp, req)
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
 }
+
+func TestBindingHandlerAsync(t *testing.T) {
+	s := newServer("", nil)
+	failAccept := false
+	err := s.AddBindingInvocationHandlerAsync("/jobs", func(ctx context.Context, in *common.BindingEvent) (string, error) {
+		if failAccept {
+			return "", errors.New("store unavailable")
+		}
+		return "job1", nil
+	})
+	assert.NoErrorf(t, err, "error adding binding event handler")
+
+	req, err := http.NewRequest(http.MethodPost, "/jobs", strings.NewReader("work"))
+	assert.NoErrorf(t, err, "error creating request")
+	resp := httptest.NewRecorder()
+	s.mux.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	failAccept = true
+	req, err = http.NewRequest(http.MethodPost, "/jobs", strings.NewReader("work"))
+	assert.NoErrorf(t, err, "error creating request")
+	resp = httptest.NewRecorder()
+	s.mux.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
+
+func TestBindingHandlerBindingError(t *testing.T) {
+	s := newServer("", nil)
+	err := s.AddBindingInvocationHandler("/orders", func(ctx context.Context, in *common.BindingEvent) ([]byte, error) {
+		return nil, &common.BindingError{Code: "INVALID_PAYLOAD", Message: "not an order", Retryable: string(in.Data) == "retry"}
+	})
+	assert.NoError(t, err)
+
+	t.Run("non-retryable error acknowledges the event", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("drop"))
+		resp := httptest.NewRecorder()
+		s.mux.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "{}", resp.Body.String())
+	})
+
+	t.Run("retryable error fails the event", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("retry"))
+		resp := httptest.NewRecorder()
+		s.mux.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"errorCode":"INVALID_PAYLOAD","message":"not an order"}`, resp.Body.String())
+	})
+}
@@ -33,7 +33,7 @@ func (s *Server) AddHealthCheckHandler(route string, fn common.HealthCheckHandle
 
 	s.mux.Handle(route, optionsHandler(http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			if err := fn(r.Context()); err != nil {
+			if err := s.healthChecker.Check(r.Context(), fn); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
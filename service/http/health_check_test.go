@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -66,3 +67,33 @@ func TestHealthCheckHandler(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, resp.Code)
 	})
 }
+
+func TestHealthCheckHandlerWithOptions(t *testing.T) {
+	t.Run("hanging handler hits the timeout", func(t *testing.T) {
+		s := newServer("", nil, WithHealthCheckTimeout(10*time.Millisecond))
+		hang := make(chan struct{})
+		defer close(hang)
+		err := s.AddHealthCheckHandler("/", func(ctx context.Context) (err error) {
+			<-hang
+			return nil
+		})
+		assert.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		assert.NoError(t, err)
+		testRequest(t, s, req, http.StatusInternalServerError)
+	})
+
+	t.Run("unhealthy after consecutive failures", func(t *testing.T) {
+		s := newServer("", nil, WithHealthFailureThreshold(2))
+		err := s.AddHealthCheckHandler("/", func(ctx context.Context) (err error) {
+			return errors.New("app is unhealthy")
+		})
+		assert.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		assert.NoError(t, err)
+		testRequest(t, s, req, http.StatusNoContent)
+		testRequest(t, s, req, http.StatusInternalServerError)
+	})
+}
@@ -14,14 +14,18 @@ limitations under the License.
 package http
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	"github.com/dapr/go-sdk/client"
 	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
 )
 
 // AddServiceInvocationHandler appends provided service invocation handler with its route to the service.
@@ -37,21 +41,24 @@ func (s *Server) AddServiceInvocationHandler(route string, fn common.ServiceInvo
 	if !strings.HasPrefix(route, "/") {
 		route = "/" + route
 	}
+	info := internal.NewRegistration(common.RegistrationInvocation, route[1:], route)
+	fn, err := s.schemaValidations.InvocationHandler(route, internal.AuditInvocationHandler(info, fn))
+	if err != nil {
+		return err
+	}
+	s.invokeRoutes[route[1:]] = struct{}{}
+	s.invocationCaches.Set(route[1:], nil)
+	s.registrations.Add(info)
 
-	s.mux.Handle(route, optionsHandler(http.HandlerFunc(
+	s.mux.Handle(route, optionsHandler(s.authHandler(http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			if s.authToken != "" {
-				token := r.Header.Get(common.APITokenKey)
-				if token == "" || token != s.authToken {
-					http.Error(w, "authentication failed.", http.StatusNonAuthoritativeInfo)
-					return
-				}
-			}
 			// capture http args
 			e := &common.InvocationEvent{
 				Verb:        r.Method,
 				QueryString: r.URL.RawQuery,
 				ContentType: r.Header.Get("Content-type"),
+				Method:      strings.TrimPrefix(r.URL.Path, "/"),
+				Metadata:    r.Header,
 			}
 
 			var err error
@@ -71,12 +78,17 @@ func (s *Server) AddServiceInvocationHandler(route string, fn common.ServiceInvo
 			for k, v := range r.Header {
 				md.Set(k, v...)
 			}
-			ctx = metadata.NewIncomingContext(ctx, md)
+			ctx = s.handlerContext(metadata.NewIncomingContext(ctx, md), r, map[string]string{common.LogFieldMethod: strings.TrimPrefix(route, "/")})
 
 			// execute handler
 			o, err := fn(ctx, e)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				code := http.StatusInternalServerError
+				var se *common.StatusError
+				if errors.As(err, &se) {
+					code = internal.HTTPStatusFromCode(se.Code())
+				}
+				http.Error(w, err.Error(), code)
 				return
 			}
 
@@ -90,7 +102,108 @@ func (s *Server) AddServiceInvocationHandler(route string, fn common.ServiceInvo
 					return
 				}
 			}
-		})))
+		}))))
+
+	return nil
+}
+
+// AddServiceInvocationHandlerWithOptions appends provided service invocation
+// handler with its route to the service, configured by opts.
+func (s *Server) AddServiceInvocationHandlerWithOptions(route string, fn common.ServiceInvocationHandler, opts common.InvocationOptions) error {
+	if fn == nil {
+		return fmt.Errorf("invocation handler required")
+	}
+	var cache *internal.InvocationCache
+	if opts.Cache != nil {
+		var err error
+		if cache, err = internal.NewInvocationCache(*opts.Cache); err != nil {
+			return err
+		}
+		fn = cache.Handler(fn)
+	}
+	if err := s.AddServiceInvocationHandler(route, fn); err != nil {
+		return err
+	}
+	s.invocationCaches.Set(strings.TrimPrefix(route, "/"), cache)
+	return nil
+}
+
+// InvalidateInvocationCache removes the response cached for key by the handler
+// of route, registered with a cache by AddServiceInvocationHandlerWithOptions.
+func (s *Server) InvalidateInvocationCache(route, key string) {
+	s.invocationCaches.Invalidate(strings.TrimPrefix(route, "/"), key)
+}
+
+// InvocationCacheStats returns the stats of the caches of the service
+// invocation handlers, by route without the leading slash.
+func (s *Server) InvocationCacheStats() map[string]common.InvocationCacheStats {
+	return s.invocationCaches.Stats()
+}
+
+// RegisteredInvocationMethods returns the sorted routes of the service
+// invocation handlers, without the leading slash as the methods invoked.
+func (s *Server) RegisteredInvocationMethods() []string {
+	return sortedKeys(s.invokeRoutes)
+}
+
+// AddInvocationProxy forwards every invocation whose route starts with prefix
+// to the targetAppID app through the daprClient, passing on the verb, query
+// string, content type, payload, and propagated metadata, and responding with
+// the content type, headers and data of the response of the target.
+// When set, rewrite maps the incoming method to the one invoked on the target.
+// Errors returned by the target are mapped back to the matching HTTP status.
+// Invocations are refused if targetAppID is the ID of this app.
+func (s *Server) AddInvocationProxy(prefix string, targetAppID string, daprClient client.Client, rewrite func(method string) string) error {
+	prefix = strings.TrimPrefix(prefix, "/")
+	p, err := internal.NewInvocationProxy(prefix, targetAppID, daprClient, rewrite)
+	if err != nil {
+		return err
+	}
+
+	s.mux.Handle("/"+prefix+"*", optionsHandler(s.authHandler(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			req := &internal.ProxiedRequest{
+				Method:      strings.TrimPrefix(r.URL.Path, "/"),
+				Verb:        r.Method,
+				QueryString: r.URL.RawQuery,
+				ContentType: r.Header.Get("Content-type"),
+			}
+			var err error
+			if r.Body != nil {
+				req.Data, err = io.ReadAll(r.Body)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+
+			md := metadata.MD{}
+			for k, v := range r.Header {
+				md.Set(k, v...)
+			}
+			ctx := s.handlerContext(metadata.NewIncomingContext(r.Context(), md), r, map[string]string{common.LogFieldMethod: req.Method})
+
+			out, err := p.Forward(ctx, req)
+			if errors.Is(err, internal.ErrProxyLoop) {
+				http.Error(w, err.Error(), http.StatusLoopDetected)
+				return
+			}
+			if err != nil {
+				st := status.Convert(err)
+				http.Error(w, st.Message(), internal.HTTPStatusFromCode(st.Code()))
+				return
+			}
+			for k, v := range out.Headers {
+				w.Header()[http.CanonicalHeaderKey(k)] = v
+			}
+			if out.ContentType != "" {
+				w.Header().Set("Content-Type", out.ContentType)
+			}
+			if _, err := w.Write(out.Data); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}))))
 
 	return nil
 }
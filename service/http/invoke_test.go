@@ -18,15 +18,28 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
-
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/client"
 	"github.com/dapr/go-sdk/service/common"
 )
 
@@ -216,3 +229,141 @@ func TestInvocationHandlerWithCustomizedHeader(t *testing.T) {
 	assert.Contains(t, d2, customizedHeader)
 	assert.Equal(t, d2[customizedHeader], "Value")
 }
+
+// proxyTargetRuntime is a fake Dapr runtime that answers invocations of the
+// "target" app by echoing the request it received.
+type proxyTargetRuntime struct {
+	pb.UnimplementedDaprServer
+}
+
+func (r *proxyTargetRuntime) GetMetadata(ctx context.Context, in *emptypb.Empty) (*pb.GetMetadataResponse, error) {
+	return &pb.GetMetadataResponse{Id: "gateway"}, nil
+}
+
+func (r *proxyTargetRuntime) InvokeService(ctx context.Context, in *pb.InvokeServiceRequest) (*commonv1pb.InvokeResponse, error) {
+	if in.Message.Method == "orders/missing" {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	echo := strings.Join([]string{
+		in.Id,
+		in.Message.Method,
+		in.Message.HttpExtension.Verb.String(),
+		in.Message.HttpExtension.Querystring,
+		in.Message.ContentType,
+		string(in.Message.Data.Value),
+		strings.Join(md.Get("x-tenant-id"), ","),
+	}, "|")
+	if err := grpc.SetHeader(ctx, metadata.Pairs("x-order-version", "3")); err != nil {
+		return nil, err
+	}
+	return &commonv1pb.InvokeResponse{Data: &anypb.Any{Value: []byte(echo)}, ContentType: "text/plain"}, nil
+}
+
+func startTestRuntime(t *testing.T, runtime pb.DaprServer) client.Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	pb.RegisterDaprServer(s, runtime)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	c := client.NewClientWithConnection(conn)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestInvocationProxy(t *testing.T) {
+	c := startTestRuntime(t, &proxyTargetRuntime{})
+	s := newServer("", nil, WithAutoPropagation([]string{"x-tenant-id"}))
+
+	assert.Error(t, s.AddInvocationProxy("", "target", c, nil))
+	assert.Error(t, s.AddInvocationProxy("/api/", "", c, nil))
+	assert.NoError(t, s.AddInvocationProxy("/api/", "target", c, func(method string) string {
+		return strings.TrimPrefix(method, "api/")
+	}))
+	assert.NoError(t, s.AddInvocationProxy("/self/", "gateway", c, nil))
+
+	t.Run("forwards the request", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, "/api/orders/1?v=2", strings.NewReader(`{"a":1}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant-Id", "tenant-1")
+
+		rr := httptest.NewRecorder()
+		s.mux.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `target|orders/1|PUT|v=2|application/json|{"a":1}|tenant-1`, rr.Body.String())
+		assert.Equal(t, "text/plain", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "3", rr.Header().Get("X-Order-Version"))
+	})
+
+	t.Run("maps the target error", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/orders/missing", nil)
+		require.NoError(t, err)
+		testRequestWithResponseBody(t, s, req, http.StatusNotFound, []byte("order not found\n"))
+	})
+
+	t.Run("rejects proxying to self", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/self/loop", nil)
+		require.NoError(t, err)
+		testRequest(t, s, req, http.StatusLoopDetected)
+	})
+}
+
+func TestInvocationCallerSDK(t *testing.T) {
+	s := newServer("", nil)
+	var version, userAgent string
+	err := s.AddServiceInvocationHandler("/caller", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		version = common.CallerSDKVersion(ctx)
+		userAgent = common.CallerUserAgent(ctx)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/caller", nil)
+	require.NoError(t, err)
+	req.Header.Set(common.SDKVersionMetadataKey, "v1.8.0")
+	req.Header.Set(common.SDKUserAgentMetadataKey, "dapr-sdk-go/v1.8.0 orders")
+	resp := httptest.NewRecorder()
+	s.mux.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "v1.8.0", version)
+	assert.Equal(t, "dapr-sdk-go/v1.8.0 orders", userAgent)
+}
+
+func TestInvocationHandlerWithCache(t *testing.T) {
+	s := newServer("", nil)
+	calls := 0
+	err := s.AddServiceInvocationHandlerWithOptions("/cached", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		calls++
+		return &common.Content{ContentType: "text/plain", Data: in.Data}, nil
+	}, common.InvocationOptions{Cache: &common.CacheConfig{TTL: time.Minute}})
+	require.NoError(t, err)
+
+	invoke := func(data string) {
+		req, err := http.NewRequest(http.MethodPost, "/cached", strings.NewReader(data))
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		s.mux.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, data, resp.Body.String())
+	}
+	invoke("a")
+	invoke("a")
+	invoke("b")
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, map[string]common.InvocationCacheStats{"cached": {Hits: 1, Misses: 2, Entries: 2}}, s.InvocationCacheStats())
+
+	s.InvalidateInvocationCache("cached", "POST\x00\x00a")
+	invoke("a")
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+// ServiceOption configures a Server created by NewService or NewServiceWithMux.
+type ServiceOption func(*Server)
+
+// WithAutoPropagation marks the given request headers for
+// propagation in every handler context, as if each handler had called
+// common.PropagateMetadata.
+func WithAutoPropagation(keys []string) ServiceOption {
+	return func(s *Server) {
+		s.propagateKeys = append(s.propagateKeys, keys...)
+	}
+}
+
+// WithHealthCheckTimeout sets the maximum time the health check handler may
+// run. A handler that doesn't complete in time counts as a failure.
+func WithHealthCheckTimeout(d time.Duration) ServiceOption {
+	return func(s *Server) {
+		s.healthChecker.Timeout = d
+	}
+}
+
+// WithHealthFailureThreshold makes the health check endpoint report the app as
+// unhealthy only after n consecutive failures of the health check handler.
+// A success resets the count.
+func WithHealthFailureThreshold(n int) ServiceOption {
+	return func(s *Server) {
+		s.healthChecker.FailureThreshold = n
+	}
+}
+
+// WithLoggerFactory sets the factory used to create a request-scoped logger on
+// each callback. Handlers retrieve it with common.LoggerFromContext.
+func WithLoggerFactory(f common.LoggerFactory) ServiceOption {
+	return func(s *Server) {
+		s.loggerFactory = f
+	}
+}
+
+// WithHTTPClient sets the HTTP client for the outbound calls of the handlers,
+// such as to tune its timeouts and transport. Handlers retrieve it with
+// common.HTTPClientFromContext.
+func WithHTTPClient(c *http.Client) ServiceOption {
+	return func(s *Server) {
+		s.httpClient = c
+	}
+}
+
+// WithNotFoundHandler sets the handler responding to requests for unknown
+// routes, instead of the default 404 page.
+func WithNotFoundHandler(h http.Handler) ServiceOption {
+	return func(s *Server) {
+		s.notFoundHandler = h
+	}
+}
+
+// WithRouteAuth sets a hook authenticating every request to the service
+// invocation, binding and topic event routes, and to the stats endpoint,
+// except the ones exempted with WithAuthExemptRoutes. The routes called by the
+// runtime and the probes, such as /healthz, /dapr/subscribe, /dapr/config, the
+// actor routes and the health check handler, are not authenticated.
+// If fn returns an error, the request is rejected with 401 before reaching the
+// handler.
+// The hook replaces the built-in check of the dapr-api-token header against
+// the APP_API_TOKEN environment variable.
+func WithRouteAuth(fn func(r *http.Request) error) ServiceOption {
+	return func(s *Server) {
+		s.routeAuth = fn
+	}
+}
+
+// WithAuthExemptRoutes exempts requests for the given paths, such as the
+// route "/public" of a service invocation handler, from the route auth hook.
+func WithAuthExemptRoutes(routes ...string) ServiceOption {
+	return func(s *Server) {
+		if s.authExemptRoutes == nil {
+			s.authExemptRoutes = make(map[string]struct{}, len(routes))
+		}
+		for _, r := range routes {
+			if !strings.HasPrefix(r, "/") {
+				r = "/" + r
+			}
+			s.authExemptRoutes[r] = struct{}{}
+		}
+	}
+}
+
+// WithDrainHook sets a function invoked when the service starts draining on
+// Stop or GracefulStop, before in-flight requests complete. Use it, for example,
+// to fail readiness probes so that traffic moves to a new instance.
+func WithDrainHook(fn func()) ServiceOption {
+	return func(s *Server) {
+		s.drainHook = fn
+	}
+}
+
+// WithStatsEndpoint serves the subscription stats as JSON on GET /_sdk/stats.
+// The endpoint is subject to the route auth, like the other SDK routes, so it
+// is open to anyone who can reach the port of the app unless the
+// APP_API_TOKEN environment variable is set or WithRouteAuth is used.
+func WithStatsEndpoint() ServiceOption {
+	return func(s *Server) {
+		s.statsEndpoint = true
+	}
+}
+
+// WithShutdownTimeout bounds the time the hooks registered with OnShutdown have
+// to run. The default is 10 seconds.
+func WithShutdownTimeout(d time.Duration) ServiceOption {
+	return func(s *Server) {
+		s.shutdownHooks.Timeout = d
+	}
+}
+
+// WithSchemaValidation validates the payloads of the topic event and service
+// invocation handlers of the routes of vs, created for example with
+// common.WithJSONSchemaValidation. Events that don't pass are dropped, and
+// invocations are rejected with InvalidArgument, before the handler runs.
+func WithSchemaValidation(vs ...common.SchemaValidation) ServiceOption {
+	return func(s *Server) {
+		s.schemaValidations.Add(vs...)
+	}
+}
+
+// WithRecentEvents retains the last n events delivered to the topic event
+// handlers of each topic, for debugging with RecentEvents. The events of a
+// topic delivered by several pub/subs share its buffer. 0, the default,
+// disables it.
+func WithRecentEvents(n int) ServiceOption {
+	return func(s *Server) {
+		s.recentEvents = internal.NewRecentEvents(n)
+	}
+}
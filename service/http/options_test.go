@@ -0,0 +1,319 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+func TestAutoPropagation(t *testing.T) {
+	s := newServer("", nil, WithAutoPropagation([]string{"x-tenant-id"}))
+
+	var propagated []string
+	err := s.AddBindingInvocationHandler("/", func(ctx context.Context, in *common.BindingEvent) (out []byte, err error) {
+		propagated = common.PropagatedMetadata(ctx).Get("x-tenant-id")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	req.Header.Set("X-Tenant-Id", "tenant-1")
+	req.Header.Set("X-Other", "other")
+
+	testRequest(t, s, req, http.StatusOK)
+	assert.Equal(t, []string{"tenant-1"}, propagated)
+}
+
+func TestLoggerFactory(t *testing.T) {
+	var buf bytes.Buffer
+	s := newServer("", nil, WithLoggerFactory(func(fields map[string]string) common.Logger {
+		return log.New(&buf, "method="+fields[common.LogFieldMethod]+" ", 0)
+	}))
+	err := s.AddServiceInvocationHandler("/echo", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		common.LoggerFromContext(ctx).Println("handled")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/echo", nil)
+	assert.NoError(t, err)
+
+	testRequest(t, s, req, http.StatusOK)
+	assert.Equal(t, "method=echo handled\n", buf.String())
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestHTTPClient(t *testing.T) {
+	var called []string
+	c := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		called = append(called, r.URL.String())
+		return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody, Request: r}, nil
+	})}
+	s := newServer("", nil, WithHTTPClient(c))
+	err := s.AddBindingInvocationHandler("/chain", func(ctx context.Context, in *common.BindingEvent) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://next.example/ingest", bytes.NewReader(in.Data))
+		if err != nil {
+			return nil, err
+		}
+		resp, err := common.HTTPClientFromContext(ctx).Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			return nil, errors.New(resp.Status)
+		}
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/chain", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	testRequest(t, s, req, http.StatusOK)
+	assert.Equal(t, []string{"http://next.example/ingest"}, called)
+
+	assert.Same(t, http.DefaultClient, common.HTTPClientFromContext(context.Background()))
+}
+
+func TestRouteAuth(t *testing.T) {
+	auth := func(r *http.Request) error {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			return errors.New("invalid credentials")
+		}
+		return nil
+	}
+	s := newServer("", nil, WithRouteAuth(auth), WithAuthExemptRoutes("public"))
+	s.registerBaseHandler()
+	err := s.AddTopicEventHandler(&common.Subscription{
+		PubsubName: "messages",
+		Topic:      "public",
+		Route:      "/public",
+	}, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		return false, nil
+	})
+	assert.NoError(t, err)
+
+	var handled bool
+	err = s.AddTopicEventHandler(&common.Subscription{
+		PubsubName: "messages",
+		Topic:      "test",
+		Route:      "/events",
+	}, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		handled = true
+		return false, nil
+	})
+	assert.NoError(t, err)
+
+	t.Run("topic route rejects unauthenticated requests", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"id":"1","data":"ping"}`))
+		assert.NoError(t, err)
+		testRequestWithResponseBody(t, s, req, http.StatusUnauthorized, []byte("invalid credentials\n"))
+		assert.False(t, handled)
+	})
+
+	t.Run("topic route accepts authenticated requests", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"id":"1","data":"ping"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		testRequest(t, s, req, http.StatusOK)
+		assert.True(t, handled)
+	})
+
+	t.Run("exempt routes skip auth", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/public", strings.NewReader(`{"id":"1","data":"ping"}`))
+		assert.NoError(t, err)
+		testRequest(t, s, req, http.StatusOK)
+	})
+
+	t.Run("runtime routes skip auth", func(t *testing.T) {
+		for _, route := range []string{"/healthz", "/dapr/subscribe", "/dapr/config"} {
+			req, err := http.NewRequest(http.MethodGet, route, nil)
+			assert.NoError(t, err)
+			testRequest(t, s, req, http.StatusOK)
+		}
+	})
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	s := newServer("", nil, WithNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"not found"}`))
+	})))
+
+	req, err := http.NewRequest(http.MethodGet, "/unknown", nil)
+	assert.NoError(t, err)
+	testRequestWithResponseBody(t, s, req, http.StatusNotFound, []byte(`{"error":"not found"}`))
+}
+
+func TestStatsEndpoint(t *testing.T) {
+	t.Setenv(common.AppAPITokenEnvVar, "app-dapr-token")
+	s := newServer("", nil, WithStatsEndpoint())
+	err := s.AddTopicEventHandler(&common.Subscription{
+		PubsubName: "messages",
+		Topic:      "test",
+		Route:      "/events",
+	}, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		return false, errors.New("boom")
+	})
+	require.NoError(t, err)
+	s.registerBaseHandler()
+
+	req, err := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"id":"1","data":"ping"}`))
+	require.NoError(t, err)
+	req.Header.Set(common.APITokenKey, "app-dapr-token")
+	s.mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	t.Run("requires the token", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/_sdk/stats", nil)
+		require.NoError(t, err)
+		testRequest(t, s, req, http.StatusNonAuthoritativeInfo)
+	})
+
+	t.Run("serves the stats", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/_sdk/stats", nil)
+		require.NoError(t, err)
+		req.Header.Set(common.APITokenKey, "app-dapr-token")
+		rr := httptest.NewRecorder()
+		s.mux.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var stats map[string]common.SubscriptionStats
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+		assert.Equal(t, uint64(1), stats["messages/test"].Delivered)
+		assert.Equal(t, uint64(1), stats["messages/test"].Failed)
+		assert.Equal(t, "boom", stats["messages/test"].LastError)
+		assert.Equal(t, 1, stats["messages/test"].LatencySamples)
+		assert.Equal(t, s.SubscriptionStats(), stats)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		s := newServer("", nil)
+		s.registerBaseHandler()
+		req, err := http.NewRequest(http.MethodGet, "/_sdk/stats", nil)
+		require.NoError(t, err)
+		req.Header.Set(common.APITokenKey, "app-dapr-token")
+		testRequest(t, s, req, http.StatusNotFound)
+	})
+}
+
+func TestSchemaValidation(t *testing.T) {
+	schema := []byte(`{"type": "object", "required": ["id"]}`)
+	var logs bytes.Buffer
+	s := newServer("", nil,
+		WithSchemaValidation(
+			common.WithJSONSchemaValidation("orders", schema),
+			common.WithJSONSchemaValidation("/orders/invoke", schema)),
+		WithLoggerFactory(func(fields map[string]string) common.Logger {
+			return log.New(&logs, "", 0)
+		}))
+	handled := 0
+	require.NoError(t, s.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "orders", Route: "/orders"},
+		func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+			handled++
+			return false, nil
+		}))
+	require.NoError(t, s.AddServiceInvocationHandler("/orders/invoke", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		return &common.Content{Data: in.Data}, nil
+	}))
+
+	t.Run("topic events", func(t *testing.T) {
+		for data, want := range map[string]string{
+			`{"id": "1"}`: common.SubscriptionResponseStatusSuccess,
+			`{"qty": 1}`:  common.SubscriptionResponseStatusDrop,
+			`id=1`:        common.SubscriptionResponseStatusDrop,
+		} {
+			e := map[string]interface{}{
+				"specversion": "1.0", "type": "test", "source": "test", "id": "1",
+				"datacontenttype": "application/json", "data": json.RawMessage(data),
+				"pubsubname": "messages", "topic": "orders",
+			}
+			if !json.Valid([]byte(data)) {
+				e["datacontenttype"], e["data"] = "text/plain", data
+			}
+			event, err := json.Marshal(e)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(event))
+			req.Header.Set("Content-Type", "application/cloudevents+json")
+			resp := httptest.NewRecorder()
+			s.mux.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+			var status common.SubscriptionResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+			assert.Equal(t, want, status.Status, data)
+		}
+		assert.Equal(t, 1, handled)
+	})
+
+	t.Run("invocations", func(t *testing.T) {
+		for data, want := range map[string]int{
+			`{"id": "1"}`: http.StatusOK,
+			`{"qty": 1}`:  http.StatusBadRequest,
+			`id=1`:        http.StatusBadRequest,
+		} {
+			resp := httptest.NewRecorder()
+			s.mux.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/orders/invoke", strings.NewReader(data)))
+			assert.Equal(t, want, resp.Code, data)
+		}
+	})
+	assert.Contains(t, logs.String(), "does not match the schema")
+
+	t.Run("invalid schema fails registration", func(t *testing.T) {
+		s := newServer("", nil, WithSchemaValidation(common.WithJSONSchemaValidation("/orders", []byte(`{"type": 1}`))))
+		assert.Error(t, s.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "orders", Route: "/orders"}, testTopicFunc))
+		assert.Error(t, s.AddServiceInvocationHandler("orders", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+			return nil, nil
+		}))
+	})
+}
+
+func TestBaggage(t *testing.T) {
+	s := newServer("", nil)
+	var seen map[string]string
+	require.NoError(t, s.AddServiceInvocationHandler("/baggage", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		seen = common.BaggageFromContext(ctx)
+		return nil, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/baggage", nil)
+	req.Header.Add("Baggage", "tenant=acme;ttl=1")
+	req.Header.Add("Baggage", "experiment=a%2Cb")
+	resp := httptest.NewRecorder()
+	s.mux.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, map[string]string{"tenant": "acme", "experiment": "a,b"}, seen)
+
+	seen = nil
+	resp = httptest.NewRecorder()
+	s.mux.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/baggage", nil))
+	assert.Nil(t, seen)
+}
@@ -0,0 +1,66 @@
+//go:build !daprnocaller
+
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+func TestServiceRegistrations(t *testing.T) {
+	var buf bytes.Buffer
+	s := newServer("", nil, WithLoggerFactory(func(map[string]string) common.Logger {
+		return log.New(&buf, "", 0)
+	}))
+	_, file, line, _ := runtime.Caller(0)
+	require.NoError(t, s.AddServiceInvocationHandler("echo", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		return &common.Content{Data: in.Data}, nil
+	}))
+	require.NoError(t, s.AddTopicEventHandler(&common.Subscription{PubsubName: "messages", Topic: "orders", Route: "/orders"}, func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+		return false, nil
+	}))
+	require.NoError(t, s.AddBindingInvocationHandler("/storage", func(ctx context.Context, in *common.BindingEvent) ([]byte, error) {
+		panic("boom")
+	}))
+
+	regs := s.Registrations()
+	require.Len(t, regs, 3)
+	assert.Equal(t, common.RegistrationInfo{
+		Kind: common.RegistrationInvocation, Name: "echo", Route: "/echo",
+		RegisteredAt: regs[0].RegisteredAt, Caller: fmt.Sprintf("%s:%d", file, line+1),
+	}, regs[0])
+	assert.Equal(t, common.RegistrationTopic, regs[1].Kind)
+	assert.Equal(t, "messages/orders", regs[1].Name)
+	assert.Equal(t, fmt.Sprintf("%s:%d", file, line+4), regs[1].Caller)
+	assert.Equal(t, common.RegistrationBinding, regs[2].Kind)
+	assert.Equal(t, "/storage", regs[2].Route)
+	assert.Equal(t, fmt.Sprintf("%s:%d", file, line+7), regs[2].Caller)
+
+	req := httptest.NewRequest(http.MethodPost, "/storage", nil)
+	assert.Panics(t, func() { s.mux.ServeHTTP(httptest.NewRecorder(), req) })
+	assert.Contains(t, buf.String(), "binding handler storage registered at "+regs[2].Caller+" panicked: boom")
+}
@@ -15,11 +15,17 @@ package http
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/dapr/go-sdk/actor"
 	"github.com/dapr/go-sdk/actor/config"
@@ -29,20 +35,31 @@ import (
 )
 
 // NewService creates new Service.
-func NewService(address string) common.Service {
-	return newServer(address, nil)
+func NewService(address string, opts ...ServiceOption) common.Service {
+	return newServer(address, nil, opts...)
 }
 
 // NewServiceWithMux creates new Service with existing http mux.
-func NewServiceWithMux(address string, mux *chi.Mux) common.Service {
-	return newServer(address, mux)
+func NewServiceWithMux(address string, mux *chi.Mux, opts ...ServiceOption) common.Service {
+	return newServer(address, mux, opts...)
 }
 
-func newServer(address string, router *chi.Mux) *Server {
+// Server implements the optional interfaces of common.Service.
+var (
+	_ common.Restarter                = (*Server)(nil)
+	_ common.Drainer                  = (*Server)(nil)
+	_ common.InvocationCacher         = (*Server)(nil)
+	_ common.TopicMiddlewareRegistrar = (*Server)(nil)
+	_ common.AsyncBindingRegistrar    = (*Server)(nil)
+	_ common.RegistrationLister       = (*Server)(nil)
+	_ common.SubscriptionInspector    = (*Server)(nil)
+)
+
+func newServer(address string, router *chi.Mux, opts ...ServiceOption) *Server {
 	if router == nil {
 		router = chi.NewRouter()
 	}
-	return &Server{
+	s := &Server{
 		address: address,
 		httpServer: &http.Server{ //nolint:gosec
 			Addr:    address,
@@ -51,16 +68,62 @@ func newServer(address string, router *chi.Mux) *Server {
 		mux:            router,
 		topicRegistrar: make(internal.TopicRegistrar),
 		authToken:      os.Getenv(common.AppAPITokenEnvVar),
+		healthChecker:  &internal.HealthChecker{},
+		invokeRoutes:   make(map[string]struct{}),
+		bindingRoutes:  make(map[string]struct{}),
+
+		schemaValidations: make(internal.SchemaValidations),
+		invocationCaches:  make(internal.InvocationCaches),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.routeAuth == nil && s.authToken != "" {
+		s.routeAuth = apiTokenAuth(s.authToken)
 	}
+	if s.notFoundHandler != nil {
+		router.NotFound(s.notFoundHandler.ServeHTTP)
+	}
+	return s
 }
 
 // Server is the HTTP server wrapping mux many Dapr helpers.
 type Server struct {
-	address        string
-	mux            *chi.Mux
-	httpServer     *http.Server
-	topicRegistrar internal.TopicRegistrar
-	authToken      string
+	address         string
+	mux             *chi.Mux
+	httpServer      *http.Server
+	topicRegistrar  internal.TopicRegistrar
+	topicMiddleware internal.TopicMiddleware
+	authToken       string
+	propagateKeys   []string
+	loggerFactory   common.LoggerFactory
+	httpClient      *http.Client
+	healthChecker   *internal.HealthChecker
+
+	// invokeRoutes and bindingRoutes are the routes of the service invocation
+	// and binding handlers, without the leading slash.
+	invokeRoutes  map[string]struct{}
+	bindingRoutes map[string]struct{}
+
+	schemaValidations internal.SchemaValidations
+	registrations     internal.Registrations
+	invocationCaches  internal.InvocationCaches
+	recentEvents      *internal.RecentEvents
+
+	lock             sync.Mutex
+	state            serverState
+	listener         net.Listener
+	baseHandlersOnce sync.Once
+
+	routeAuth        func(r *http.Request) error
+	authExemptRoutes map[string]struct{}
+	notFoundHandler  http.Handler
+
+	draining  atomic.Bool
+	drainHook func()
+
+	statsEndpoint bool
+	shutdownHooks internal.ShutdownHooks
 }
 
 // Deprecated: Use RegisterActorImplFactoryContext instead.
@@ -72,22 +135,213 @@ func (s *Server) RegisterActorImplFactoryContext(f actor.FactoryContext, opts ..
 	runtime.GetActorRuntimeInstanceContext().RegisterActorFactory(f, opts...)
 }
 
+// serverState is the lifecycle state of a Server.
+type serverState int
+
+const (
+	serverStateNew serverState = iota
+	serverStateStarted
+	serverStateStopped
+)
+
+// serverStoppedError is returned by Start when the service has been stopped.
+// It matches both common.ErrServerStopped and http.ErrServerClosed, which
+// Start returned for stopped services before.
+type serverStoppedError struct{}
+
+func (serverStoppedError) Error() string {
+	return http.ErrServerClosed.Error()
+}
+
+func (serverStoppedError) Is(target error) bool {
+	return target == common.ErrServerStopped || target == http.ErrServerClosed
+}
+
 // Start starts the HTTP handler. Blocks while serving.
+// Returns common.ErrServerStopped if the service has been stopped.
 func (s *Server) Start() error {
-	s.registerBaseHandler()
-	return s.httpServer.ListenAndServe()
+	return s.serve(nil)
+}
+
+// serve serves on lis, or on the address of the service if lis is nil.
+func (s *Server) serve(lis net.Listener) error {
+	s.lock.Lock()
+	switch s.state {
+	case serverStateStarted:
+		s.lock.Unlock()
+		return errors.New("an HTTP server can only be started once")
+	case serverStateStopped:
+		s.lock.Unlock()
+		return serverStoppedError{}
+	}
+	s.state = serverStateStarted
+	httpServer := s.httpServer
+	s.lock.Unlock()
+
+	s.baseHandlersOnce.Do(s.registerBaseHandler)
+	if lis == nil {
+		addr := httpServer.Addr
+		if addr == "" {
+			addr = ":http"
+		}
+		var err error
+		if lis, err = net.Listen("tcp", addr); err != nil {
+			return err
+		}
+	}
+	s.lock.Lock()
+	s.listener = lis
+	s.lock.Unlock()
+	return httpServer.Serve(lis)
+}
+
+// Registrations returns the registrations of the service invocation, topic
+// event and binding handlers, in registration order.
+func (s *Server) Registrations() []common.RegistrationInfo {
+	return s.registrations.List()
+}
+
+// ListenAddr returns the address of the listener of the service, resolved once
+// Start binds it, such as the port chosen for the address ":0". Unlike the gRPC
+// service, which binds its listener in NewService, the HTTP service binds it in
+// Start, so ListenAddr returns nil until then.
+func (s *Server) ListenAddr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
 }
 
 // Stop stops previously started HTTP service with a five second timeout.
+// Calling Stop more than once is safe. A service stopped before Start can't be
+// started anymore.
 func (s *Server) Stop() error {
+	s.lock.Lock()
+	started := s.state == serverStateStarted
+	s.state = serverStateStopped
+	if !started {
+		s.lock.Unlock()
+		return nil
+	}
+	httpServer := s.httpServer
+	s.lock.Unlock()
+
+	s.draining.Store(true)
+	defer s.draining.Store(false)
+	if s.drainHook != nil {
+		s.drainHook()
+	}
+
 	ctxShutDown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	return s.httpServer.Shutdown(ctxShutDown)
+	return httpServer.Shutdown(ctxShutDown)
+}
+
+// Restart stops the service if it is running, then serves again on lis with
+// the registered handlers. Blocks while serving.
+func (s *Server) Restart(lis net.Listener) error {
+	if lis == nil {
+		return errors.New("listener required")
+	}
+	if err := s.Stop(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.httpServer = &http.Server{ //nolint:gosec
+		Addr:    lis.Addr().String(),
+		Handler: s.httpServer.Handler,
+	}
+	s.shutdownHooks.Reset()
+	s.state = serverStateNew
+	s.lock.Unlock()
+
+	return s.serve(lis)
 }
 
+// GracefulStop stops the service like Stop, then runs the hooks registered with
+// OnShutdown.
 func (s *Server) GracefulStop() error {
-	return s.Stop()
+	err := s.Stop()
+	if hookErr := s.shutdownHooks.Run(); err == nil {
+		err = hookErr
+	}
+	return err
+}
+
+// OnShutdown registers fn to run when the service is gracefully stopped.
+// Hooks run in reverse registration order, with a context bounded by the
+// shutdown timeout, and the errors of failed hooks are aggregated.
+func (s *Server) OnShutdown(fn func(ctx context.Context) error) {
+	s.shutdownHooks.Add(fn)
+}
+
+// IsDraining reports whether the service is stopping and waiting for in-flight
+// requests to complete.
+func (s *Server) IsDraining() bool {
+	return s.draining.Load()
+}
+
+// handlerContext returns the context passed to a callback handler, carrying the
+// auto-propagated headers, the baggage and the request-scoped logger.
+func (s *Server) handlerContext(ctx context.Context, r *http.Request, fields map[string]string) context.Context {
+	if len(s.propagateKeys) > 0 {
+		if _, ok := metadata.FromIncomingContext(ctx); !ok {
+			md := metadata.MD{}
+			for _, k := range s.propagateKeys {
+				if v := r.Header.Values(k); len(v) > 0 {
+					md.Set(k, v...)
+				}
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+		ctx = common.PropagateMetadata(ctx, s.propagateKeys...)
+	}
+	if s.loggerFactory != nil {
+		ctx = common.ContextWithLogger(ctx, s.loggerFactory(fields))
+	}
+	if s.httpClient != nil {
+		ctx = common.ContextWithHTTPClient(ctx, s.httpClient)
+	}
+	return internal.WithBaggage(ctx, r.Header.Values(common.BaggageMetadataKey))
+}
+
+// errAuthenticationFailed is returned by the built-in dapr-api-token check.
+var errAuthenticationFailed = errors.New("authentication failed")
+
+// apiTokenAuth returns a route auth hook requiring requests to carry the
+// given token in the dapr-api-token header.
+func apiTokenAuth(token string) func(r *http.Request) error {
+	return func(r *http.Request) error {
+		if v := r.Header.Get(common.APITokenKey); v == "" || v != token {
+			return errAuthenticationFailed
+		}
+		return nil
+	}
+}
+
+// authHandler runs the route auth hook, if any, before h, responding with 401
+// if it fails, or 203 if the built-in dapr-api-token check fails, as it always
+// has. Requests for exempt routes are passed through.
+func (s *Server) authHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.routeAuth != nil {
+			if _, exempt := s.authExemptRoutes[r.URL.Path]; !exempt {
+				if err := s.routeAuth(r); err != nil {
+					status := http.StatusUnauthorized
+					if errors.Is(err, errAuthenticationFailed) {
+						status = http.StatusNonAuthoritativeInfo
+					}
+					http.Error(w, err.Error(), status)
+					return
+				}
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
 }
 
 func setOptions(w http.ResponseWriter, r *http.Request) {
@@ -106,3 +360,12 @@ func optionsHandler(h http.Handler) http.HandlerFunc {
 		}
 	}
 }
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -14,14 +14,19 @@ limitations under the License.
 package http
 
 import (
+	"context"
 	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/common"
 )
 
 func TestStoppingUnstartedService(t *testing.T) {
@@ -56,6 +61,26 @@ func TestStartingStoppedService(t *testing.T) {
 	assert.Equal(t, startErr.Error(), http.ErrServerClosed.Error())
 }
 
+func TestServiceListenAddr(t *testing.T) {
+	s := newServer("127.0.0.1:0", nil)
+	assert.Nil(t, s.ListenAddr(), "not bound before Start")
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start() }()
+	require.Eventually(t, func() bool { return s.ListenAddr() != nil }, 5*time.Second, 10*time.Millisecond)
+	addr, ok := s.ListenAddr().(*net.TCPAddr)
+	require.True(t, ok)
+	assert.NotZero(t, addr.Port)
+
+	resp, err := http.Get("http://" + addr.String() + "/healthz") //nolint:noctx
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, s.Stop())
+	assert.True(t, errors.Is(<-errCh, http.ErrServerClosed))
+}
+
 func TestSettingOptions(t *testing.T) {
 	req, err := http.NewRequest(http.MethodOptions, "/", nil)
 	assert.NoErrorf(t, err, "error creating request")
@@ -94,3 +119,157 @@ func testRequestWithResponseBody(t *testing.T, s *Server, r *http.Request, expec
 	assert.Equal(t, expectedStatusCode, rez.StatusCode)
 	assert.Equal(t, expectedBody, rspBody)
 }
+
+func TestRestartingService(t *testing.T) {
+	s := newServer("", nil)
+	err := s.AddServiceInvocationHandler("/ping", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		return &common.Content{Data: []byte("pong")}, nil
+	})
+	require.NoError(t, err)
+	var shutdowns int
+	s.OnShutdown(func(ctx context.Context) error {
+		shutdowns++
+		return nil
+	})
+
+	serve := func(start func() error) <-chan error {
+		errCh := make(chan error, 1)
+		go func() { errCh <- start() }()
+		return errCh
+	}
+	ping := func(addr string) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			resp, err := http.Post("http://"+addr+"/ping", "text/plain", nil) //nolint:noctx
+			if err != nil {
+				return false
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			return string(body) == "pong"
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	lis1, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	errCh := serve(func() error { return s.Restart(lis1) })
+	ping(lis1.Addr().String())
+
+	require.NoError(t, s.GracefulStop())
+	assert.True(t, errors.Is(<-errCh, http.ErrServerClosed))
+	assert.NoError(t, s.GracefulStop(), "stopping twice is safe")
+	assert.Equal(t, 1, shutdowns, "the hooks run once per stop")
+	assert.True(t, errors.Is(s.Start(), common.ErrServerStopped))
+
+	lis2, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	errCh = serve(func() error { return s.Restart(lis2) })
+	ping(lis2.Addr().String())
+
+	require.NoError(t, s.GracefulStop())
+	assert.True(t, errors.Is(<-errCh, http.ErrServerClosed))
+	assert.Equal(t, 2, shutdowns, "the hooks are kept across restarts")
+}
+
+func TestServiceDraining(t *testing.T) {
+	drainStarted := make(chan struct{})
+	s := newServer("", nil, WithDrainHook(func() { close(drainStarted) }))
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+	err := s.AddServiceInvocationHandler("/slow", func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		close(inFlight)
+		<-release
+		return &common.Content{Data: []byte("done")}, nil
+	})
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Restart(lis) }()
+
+	respCh := make(chan error, 1)
+	go func() {
+		resp, err := http.Post("http://"+lis.Addr().String()+"/slow", "text/plain", nil) //nolint:noctx
+		if err == nil {
+			resp.Body.Close()
+		}
+		respCh <- err
+	}()
+	<-inFlight
+	assert.False(t, s.IsDraining())
+
+	stopErr := make(chan error, 1)
+	go func() { stopErr <- s.GracefulStop() }()
+	<-drainStarted
+	assert.True(t, s.IsDraining())
+
+	close(release)
+	require.NoError(t, <-respCh)
+	require.NoError(t, <-stopErr)
+	assert.True(t, errors.Is(<-errCh, http.ErrServerClosed))
+	assert.False(t, s.IsDraining())
+}
+
+func TestServiceShutdownHooks(t *testing.T) {
+	s := newServer("127.0.0.1:0", nil, WithShutdownTimeout(time.Second))
+	var order []int
+	errHook := errors.New("hook failed")
+	s.OnShutdown(func(ctx context.Context) error {
+		order = append(order, 1)
+		return errHook
+	})
+	s.OnShutdown(func(ctx context.Context) error {
+		order = append(order, 2)
+		return nil
+	})
+
+	err := s.GracefulStop()
+	assert.True(t, errors.Is(err, errHook))
+	assert.Equal(t, []int{2, 1}, order)
+}
+
+func TestServiceRegisteredHandlers(t *testing.T) {
+	s := newServer("127.0.0.1:0", nil)
+	assert.Empty(t, s.RegisteredInvocationMethods())
+	assert.Empty(t, s.RegisteredTopics())
+	assert.Empty(t, s.RegisteredBindings())
+
+	invokeFn := func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		return nil, nil
+	}
+	require.NoError(t, s.AddServiceInvocationHandler("/echo", invokeFn))
+	require.NoError(t, s.AddServiceInvocationHandler("audit", invokeFn))
+	require.NoError(t, s.AddBindingInvocationHandler("/storage", bindingHandlerFn))
+	require.NoError(t, s.AddBindingInvocationHandler("cron", bindingHandlerFn))
+	sub := &common.Subscription{
+		PubsubName: "messages",
+		Topic:      "orders",
+		Route:      "/orders",
+		Metadata:   map[string]string{"rawPayload": "true"},
+	}
+	require.NoError(t, s.AddTopicEventHandler(sub, testTopicFunc))
+	require.NoError(t, s.AddTopicEventHandler(&common.Subscription{
+		PubsubName: "events",
+		Topic:      "audit",
+		Route:      "/audit",
+	}, testTopicFunc))
+
+	go func() {
+		if err := s.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(err)
+		}
+	}()
+	defer s.Stop()
+
+	assert.Equal(t, []string{"audit", "echo"}, s.RegisteredInvocationMethods())
+	assert.Equal(t, []string{"cron", "storage"}, s.RegisteredBindings())
+	topics := s.RegisteredTopics()
+	require.Len(t, topics, 2)
+	assert.Equal(t, *sub, topics[0])
+	assert.Equal(t, "audit", topics[1].Topic)
+
+	// The returned subscriptions are copies.
+	topics[0].Metadata["rawPayload"] = "false"
+	assert.Equal(t, "true", s.RegisteredTopics()[0].Metadata["rawPayload"])
+}
@@ -17,6 +17,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 
@@ -65,6 +66,38 @@ type topicEventJSON struct {
 	Topic string `json:"topic"`
 	// PubsubName is name of the pub/sub this message came from
 	PubsubName string `json:"pubsubname"`
+	// ContentEncoding is the encoding of the data, such as gzip.
+	ContentEncoding string `json:"contentencoding,omitempty"`
+}
+
+// decode replaces encoded data with its decoded content.
+func (in *topicEventJSON) decode() error {
+	if in.ContentEncoding == "" {
+		return nil
+	}
+	var (
+		rawData []byte
+		err     error
+	)
+	if in.DataBase64 != "" {
+		rawData, err = base64.StdEncoding.DecodeString(in.DataBase64)
+	} else {
+		// Binary data in the data attribute is a base64-encoded string.
+		var str string
+		if err = json.Unmarshal(in.Data, &str); err == nil {
+			rawData, err = base64.StdEncoding.DecodeString(str)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s event data: %w", in.ContentEncoding, err)
+	}
+	decoded, err := internal.DecodeContent(in.ContentEncoding, rawData)
+	if err != nil {
+		return err
+	}
+	in.Data = nil
+	in.DataBase64 = base64.StdEncoding.EncodeToString(decoded)
+	return nil
 }
 
 func (in topicEventJSON) getData() (data any, rawData []byte) {
@@ -116,23 +149,32 @@ func (in topicEventJSON) getData() (data any, rawData []byte) {
 func (s *Server) registerBaseHandler() {
 	// register subscribe handler
 	f := func(w http.ResponseWriter, r *http.Request) {
-		subs := make([]*internal.TopicSubscription, 0, len(s.topicRegistrar))
-		for _, s := range s.topicRegistrar {
-			subs = append(subs, s.Subscription)
-		}
+		subs := s.topicRegistrar.Subscriptions()
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(subs); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 	}
-	s.mux.HandleFunc("/dapr/subscribe", f)
+	s.mux.Handle("/dapr/subscribe", http.HandlerFunc(f))
 
 	// register health check handler
 	fHealth := func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}
-	s.mux.Get("/healthz", fHealth)
+	s.mux.Method(http.MethodGet, "/healthz", http.HandlerFunc(fHealth))
+
+	// register subscription stats handler
+	if s.statsEndpoint {
+		fStats := func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(s.SubscriptionStats()); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		s.mux.Method(http.MethodGet, "/_sdk/stats", s.authHandler(http.HandlerFunc(fStats)))
+	}
 
 	// register actor config handler
 	fRegister := func(w http.ResponseWriter, r *http.Request) {
@@ -146,7 +188,7 @@ func (s *Server) registerBaseHandler() {
 			return
 		}
 	}
-	s.mux.Get("/dapr/config", fRegister)
+	s.mux.Method(http.MethodGet, "/dapr/config", http.HandlerFunc(fRegister))
 
 	// register actor method invoke handler
 	fInvoke := func(w http.ResponseWriter, r *http.Request) {
@@ -159,6 +201,10 @@ func (s *Server) registerBaseHandler() {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
+		if err == actorErr.ErrActorActivationPaused {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 		if err != actorErr.Success {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -166,7 +212,7 @@ func (s *Server) registerBaseHandler() {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write(rspData)
 	}
-	s.mux.Put("/actors/{actorType}/{actorId}/method/{methodName}", fInvoke)
+	s.mux.Method(http.MethodPut, "/actors/{actorType}/{actorId}/method/{methodName}", http.HandlerFunc(fInvoke))
 
 	// register deactivate actor handler
 	fDelete := func(w http.ResponseWriter, r *http.Request) {
@@ -181,7 +227,7 @@ func (s *Server) registerBaseHandler() {
 		}
 		w.WriteHeader(http.StatusOK)
 	}
-	s.mux.Delete("/actors/{actorType}/{actorId}", fDelete)
+	s.mux.Method(http.MethodDelete, "/actors/{actorType}/{actorId}", http.HandlerFunc(fDelete))
 
 	// register actor reminder invoke handler
 	fReminder := func(w http.ResponseWriter, r *http.Request) {
@@ -192,13 +238,19 @@ func (s *Server) registerBaseHandler() {
 		err := runtime.GetActorRuntimeInstanceContext().InvokeReminder(r.Context(), actorType, actorID, reminderName, reqData)
 		if err == actorErr.ErrActorTypeNotFound {
 			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err == actorErr.ErrActorActivationPaused {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
 		}
 		if err != actorErr.Success {
 			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
 		w.WriteHeader(http.StatusOK)
 	}
-	s.mux.Put("/actors/{actorType}/{actorId}/method/remind/{reminderName}", fReminder)
+	s.mux.Method(http.MethodPut, "/actors/{actorType}/{actorId}/method/remind/{reminderName}", http.HandlerFunc(fReminder))
 
 	// register actor timer invoke handler
 	fTimer := func(w http.ResponseWriter, r *http.Request) {
@@ -209,30 +261,67 @@ func (s *Server) registerBaseHandler() {
 		err := runtime.GetActorRuntimeInstanceContext().InvokeTimer(r.Context(), actorType, actorID, timerName, reqData)
 		if err == actorErr.ErrActorTypeNotFound {
 			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err == actorErr.ErrActorActivationPaused {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
 		}
 		if err != actorErr.Success {
 			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
 		w.WriteHeader(http.StatusOK)
 	}
-	s.mux.Put("/actors/{actorType}/{actorId}/method/timer/{timerName}", fTimer)
+	s.mux.Method(http.MethodPut, "/actors/{actorType}/{actorId}/method/timer/{timerName}", http.HandlerFunc(fTimer))
 }
 
 // AddTopicEventHandler appends provided event handler with it's name to the service.
 func (s *Server) AddTopicEventHandler(sub *common.Subscription, fn common.TopicEventHandler) error {
+	return s.AddTopicEventHandlerWithMiddleware(sub, fn)
+}
+
+// AddTopicEventHandlerWithMiddleware appends provided event handler with it's
+// name to the service, wrapped with mw.
+func (s *Server) AddTopicEventHandlerWithMiddleware(sub *common.Subscription, fn common.TopicEventHandler, mw ...common.TopicMiddleware) error {
 	if sub == nil {
 		return errors.New("subscription required")
 	}
+	if len(sub.PubsubNames) > 0 {
+		subs, err := internal.ExpandPubsubNames(sub)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			if err := s.AddTopicEventHandlerWithMiddleware(sub, fn, mw...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if sub.Namespace != "" {
+		sub = internal.NamespaceSubscription(sub)
+	}
 	// Route is only required for HTTP but should be specified for the
 	// app protocol to be interchangeable.
 	if sub.Route == "" {
 		return errors.New("handler route name")
 	}
-	if err := s.topicRegistrar.AddSubscription(sub, fn); err != nil {
+	mw, err := s.schemaValidations.TopicMiddleware(sub.Route, mw)
+	if err != nil {
+		return err
+	}
+	info := internal.NewRegistration(common.RegistrationTopic, sub.PubsubName+"/"+sub.Topic, sub.Route)
+	if fn != nil {
+		fn = internal.AuditTopicHandler(info, fn)
+	}
+	if err := s.topicRegistrar.AddSubscription(sub, fn, mw...); err != nil {
 		return err
 	}
+	s.registrations.Add(info)
+	fn = s.topicRegistrar.Handler(sub)
 
-	s.mux.Handle(sub.Route, optionsHandler(http.HandlerFunc(
+	s.mux.Handle(sub.Route, optionsHandler(s.authHandler(http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			// check for post with no data
 			var (
@@ -258,6 +347,11 @@ func (s *Server) AddTopicEventHandler(sub *common.Subscription, fn common.TopicE
 				return
 			}
 
+			if err = in.decode(); err != nil {
+				http.Error(w, err.Error(), PubSubHandlerDropStatusCode)
+				return
+			}
+
 			if in.PubsubName == "" {
 				in.Topic = sub.PubsubName
 			}
@@ -278,13 +372,42 @@ func (s *Server) AddTopicEventHandler(sub *common.Subscription, fn common.TopicE
 				Subject:         in.Subject,
 				PubsubName:      in.PubsubName,
 				Topic:           in.Topic,
+				ContentEncoding: in.ContentEncoding,
+				DeliveryAttempt: internal.DeliveryAttempt(func(key string) string {
+					return r.Header.Get(internal.BrokerMetadataPrefix + key)
+				}),
+				Metadata:   internal.BrokerMetadata(r.Header),
+				Extensions: internal.CloudEventExtensions(body),
 			}
 
-			w.Header().Add("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
+			// the route may be shared with the subscriptions of other pub/subs
+			h := fn
+			if in.PubsubName != "" && in.PubsubName != sub.PubsubName {
+				other := *sub
+				other.PubsubName = in.PubsubName
+				if ph := s.topicRegistrar.Handler(&other); ph != nil {
+					h = ph
+				}
+			}
 
 			// execute user handler
-			retry, err := fn(r.Context(), &te)
+			hctx := common.WithResponseMetadata(s.handlerContext(r.Context(), r, map[string]string{
+				common.LogFieldPubsub: sub.PubsubName,
+				common.LogFieldTopic:  sub.Topic,
+				common.LogFieldRoute:  sub.Route,
+			}))
+			s.recentEvents.Add(&te)
+			retry, err := s.topicMiddleware.Wrap(h)(hctx, &te)
+
+			headers, dropped := internal.ResponseMetadataHeaders(common.ResponseMetadata(hctx))
+			if len(dropped) > 0 {
+				common.LoggerFromContext(hctx).Printf("debug: dropped unsupported response metadata keys %v", dropped)
+			}
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+			w.Header().Add("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
 			if err == nil {
 				writeStatus(w, common.SubscriptionResponseStatusSuccess)
 				return
@@ -296,14 +419,44 @@ func (s *Server) AddTopicEventHandler(sub *common.Subscription, fn common.TopicE
 			}
 
 			writeStatus(w, common.SubscriptionResponseStatusDrop)
-		})))
+		}))))
 
 	return nil
 }
 
+// UseTopicMiddleware applies mw to all the topic event handlers, the first
+// middleware being the outermost.
+func (s *Server) UseTopicMiddleware(mw ...common.TopicMiddleware) {
+	s.topicMiddleware.Use(mw...)
+}
+
 func writeStatus(w http.ResponseWriter, s string) {
 	status := &common.SubscriptionResponse{Status: s}
 	if err := json.NewEncoder(w).Encode(status); err != nil {
 		http.Error(w, err.Error(), PubSubHandlerRetryStatusCode)
 	}
 }
+
+// RecentEvents returns the last events delivered to the handlers of topic,
+// oldest first, retained with WithRecentEvents.
+func (s *Server) RecentEvents(topic string) []common.TopicEvent {
+	return s.recentEvents.List(topic)
+}
+
+// SubscriptionStats returns the stats of each topic subscription, keyed by
+// "<pubsubname>/<topic>".
+func (s *Server) SubscriptionStats() map[string]common.SubscriptionStats {
+	return s.topicRegistrar.Stats()
+}
+
+// RegisteredTopics returns copies of the subscriptions the topic event
+// handlers were registered with, in registration order of the topics.
+func (s *Server) RegisteredTopics() []common.Subscription {
+	return s.topicRegistrar.Registered()
+}
+
+// ExportSubscriptions returns the declarations of the topic subscriptions as
+// Dapr Subscription resources, in registration order.
+func (s *Server) ExportSubscriptions(format common.ExportFormat) ([]byte, error) {
+	return internal.ExportSubscriptions(s.topicRegistrar.Subscriptions(), format)
+}
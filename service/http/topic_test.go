@@ -14,11 +14,14 @@ limitations under the License.
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"sort"
@@ -27,9 +30,14 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
 
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 	"github.com/dapr/go-sdk/actor/api"
 	"github.com/dapr/go-sdk/actor/mock"
+	"github.com/dapr/go-sdk/actor/runtime"
+	"github.com/dapr/go-sdk/client"
+	"github.com/dapr/go-sdk/client/daprtest"
 	"github.com/dapr/go-sdk/service/common"
 	"github.com/dapr/go-sdk/service/internal"
 )
@@ -146,6 +154,66 @@ func TestEventHandler(t *testing.T) {
 	makeEventRequest(t, s, "/errors", data, http.StatusOK)
 }
 
+func TestSubscribeOrder(t *testing.T) {
+	topics := []string{"a", "b", "c", "d", "e"}
+	rand.Shuffle(len(topics), func(i, j int) { topics[i], topics[j] = topics[j], topics[i] })
+
+	s := newServer("", nil)
+	for _, topic := range topics {
+		err := s.AddTopicEventHandler(&common.Subscription{
+			PubsubName: "messages",
+			Topic:      topic,
+			Route:      "/" + topic,
+		}, testTopicFunc)
+		require.NoError(t, err)
+	}
+	s.registerBaseHandler()
+
+	var first []byte
+	for i := 0; i < 5; i++ {
+		req, err := http.NewRequest(http.MethodGet, "/dapr/subscribe", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		s.mux.ServeHTTP(rr, req)
+		payload := rr.Body.Bytes()
+		if first == nil {
+			first = payload
+		}
+		assert.Equal(t, string(first), string(payload))
+
+		var subs []internal.TopicSubscription
+		require.NoError(t, json.Unmarshal(payload, &subs))
+		require.Len(t, subs, len(topics))
+		for j, sub := range subs {
+			assert.Equal(t, topics[j], sub.Topic)
+		}
+	}
+}
+
+func TestSubscribeNamespace(t *testing.T) {
+	s := newServer("", nil)
+	err := s.AddTopicEventHandler(&common.Subscription{
+		PubsubName:      "messages",
+		Topic:           "orders",
+		Route:           "/orders",
+		DeadLetterTopic: "orders-dlq",
+		Namespace:       "team-a",
+	}, testTopicFunc)
+	require.NoError(t, err)
+	s.registerBaseHandler()
+
+	req, err := http.NewRequest(http.MethodGet, "/dapr/subscribe", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	s.mux.ServeHTTP(rr, req)
+	var subs []map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &subs))
+	require.Len(t, subs, 1)
+	assert.Equal(t, "team-aorders", subs[0]["topic"])
+	assert.Equal(t, "team-aorders-dlq", subs[0]["deadLetterTopic"])
+	assert.NotContains(t, subs[0], "namespace")
+}
+
 func TestEventDataHandling(t *testing.T) {
 	tests := map[string]struct {
 		data   string
@@ -332,6 +400,39 @@ func TestActorHandler(t *testing.T) {
 	makeRequest(t, s, "/actors/testActorNotReminderCalleeType/testActorID/method/remind/testReminderName", string(reminderReqData), http.MethodPut, http.StatusInternalServerError)
 }
 
+func TestActorActivationsPaused(t *testing.T) {
+	// Point the default client, used by the actors to manage their state, to
+	// a fake sidecar, so that activations don't wait for one.
+	sidecar, err := daprtest.NewFaultyServer()
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(sidecar.Address())
+	require.NoError(t, err)
+	t.Setenv("DAPR_GRPC_PORT", port)
+	client.ResetDefaultClient()
+	defer func() {
+		client.ResetDefaultClient()
+		sidecar.Close()
+	}()
+
+	s := newServer("", nil)
+	s.registerBaseHandler()
+	s.RegisterActorImplFactoryContext(mock.ActorImplFactoryCtx)
+	makeRequest(t, s, "/actors/testActorType/activeActorID/method/Invoke", `"invoke request"`, http.MethodPut, http.StatusOK)
+
+	rt := runtime.GetActorRuntimeInstanceContext()
+	rt.PauseActivations()
+	defer rt.ResumeActivations()
+	makeRequest(t, s, "/healthz", "", http.MethodGet, http.StatusOK)
+	makeRequest(t, s, "/actors/testActorType/newActorID/method/Invoke", `"invoke request"`, http.MethodPut, http.StatusServiceUnavailable)
+	makeRequest(t, s, "/actors/testActorType/newActorID/method/remind/testReminderName", `{"data":"null"}`, http.MethodPut, http.StatusServiceUnavailable)
+	makeRequest(t, s, "/actors/testActorType/newActorID/method/timer/testTimerName", `{"callback":"Invoke","data":"null"}`, http.MethodPut, http.StatusServiceUnavailable)
+	makeRequest(t, s, "/actors/testActorType/activeActorID/method/Invoke", `"invoke request"`, http.MethodPut, http.StatusOK)
+
+	rt.ResumeActivations()
+	makeRequest(t, s, "/healthz", "", http.MethodGet, http.StatusOK)
+	makeRequest(t, s, "/actors/testActorType/newActorID/method/Invoke", `"invoke request"`, http.MethodPut, http.StatusOK)
+}
+
 func makeRequest(t *testing.T, s *Server, route, data, method string, expectedStatusCode int) {
 	t.Helper()
 
@@ -357,6 +458,28 @@ func makeEventRequest(t *testing.T, s *Server, route, data string, expectedStatu
 	testRequest(t, s, req, expectedStatusCode)
 }
 
+func TestEventHandlerMetadata(t *testing.T) {
+	s := newServer("", nil)
+	var got map[string]string
+	sub := &common.Subscription{PubsubName: "messages", Topic: "test", Route: "/events"}
+	require.NoError(t, s.AddTopicEventHandler(sub, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		got = e.Metadata
+		common.SetResponseMetadata(ctx, map[string]string{"commit": "false", "bad key": "dropped"})
+		return false, nil
+	}))
+
+	req, err := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"id":"1","data":"hello"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Metadata.Partition", "3")
+	resp := httptest.NewRecorder()
+	s.mux.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, map[string]string{"partition": "3"}, got)
+	assert.Equal(t, "false", resp.Header().Get("Metadata.Commit"))
+	assert.Len(t, resp.Header(), 2)
+}
+
 func TestAddingInvalidEventHandlers(t *testing.T) {
 	s := newServer("", nil)
 	err := s.AddTopicEventHandler(nil, testTopicFunc)
@@ -410,3 +533,207 @@ func TestRawPayloadDecode(t *testing.T) {
 	s.registerBaseHandler()
 	makeEventRequest(t, s, "/raw", rawData, http.StatusOK)
 }
+
+// publishRecordingRuntime is a fake Dapr runtime recording published events.
+type publishRecordingRuntime struct {
+	pb.UnimplementedDaprServer
+	published []*pb.PublishEventRequest
+}
+
+func (r *publishRecordingRuntime) PublishEvent(ctx context.Context, in *pb.PublishEventRequest) (*emptypb.Empty, error) {
+	r.published = append(r.published, in)
+	return &emptypb.Empty{}, nil
+}
+
+func TestCompressedEventHandler(t *testing.T) {
+	runtime := &publishRecordingRuntime{}
+	c := startTestRuntime(t, runtime)
+
+	s := newServer("", nil)
+	var events []*common.TopicEvent
+	sub := &common.Subscription{PubsubName: "messages", Topic: "test", Route: "/events"}
+	require.NoError(t, s.AddTopicEventHandler(sub, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		events = append(events, e)
+		return false, nil
+	}))
+
+	ctx := context.Background()
+	require.NoError(t, c.PublishEvent(ctx, "messages", "test", map[string]string{"message": "hello"}, client.PublishEventWithGzip()))
+	require.NoError(t, c.PublishEvent(ctx, "messages", "test", map[string]string{"message": "plain"}))
+	require.Len(t, runtime.published, 2)
+
+	// deliver the events the way Dapr does, keeping the attributes of the
+	// published CloudEvents and wrapping other data in a new one
+	assert.Equal(t, "application/cloudevents+json", runtime.published[0].DataContentType)
+	for _, p := range runtime.published {
+		ce := map[string]interface{}{
+			"id":              "1",
+			"specversion":     "1.0",
+			"type":            "com.dapr.event.sent",
+			"source":          "test",
+			"datacontenttype": p.DataContentType,
+			"data":            json.RawMessage(p.Data),
+		}
+		if p.DataContentType == "application/cloudevents+json" {
+			ce = nil
+			require.NoError(t, json.Unmarshal(p.Data, &ce))
+		}
+		ce["pubsubname"] = p.PubsubName
+		ce["topic"] = p.Topic
+		body, err := json.Marshal(ce)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, "/events", bytes.NewReader(body))
+		require.NoError(t, err)
+		testRequest(t, s, req, http.StatusOK)
+	}
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "gzip", events[0].ContentEncoding)
+	assert.Equal(t, map[string]interface{}{"message": "hello"}, events[0].Data)
+	assert.JSONEq(t, `{"message":"hello"}`, string(events[0].RawData))
+	assert.Equal(t, "", events[1].ContentEncoding)
+	assert.Equal(t, map[string]interface{}{"message": "plain"}, events[1].Data)
+
+	req, err := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"id":"1","contentencoding":"gzip","data_base64":"bm90IGd6aXA="}`))
+	require.NoError(t, err)
+	testRequest(t, s, req, PubSubHandlerDropStatusCode)
+}
+
+func TestEventHandlerDeliveryAttempt(t *testing.T) {
+	s := newServer("", nil)
+	var attempt int
+	sub := &common.Subscription{PubsubName: "messages", Topic: "test", Route: "/events"}
+	require.NoError(t, s.AddTopicEventHandler(sub, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		attempt = e.DeliveryAttempt
+		return false, nil
+	}))
+
+	req, err := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"id":"1","data":"hello"}`))
+	require.NoError(t, err)
+	req.Header.Set("Metadata.x-delivery-count", "2")
+	testRequest(t, s, req, http.StatusOK)
+	assert.Equal(t, 3, attempt)
+
+	req, err = http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"id":"1","data":"hello"}`))
+	require.NoError(t, err)
+	testRequest(t, s, req, http.StatusOK)
+	assert.Equal(t, 0, attempt)
+}
+
+// recordingMiddleware records the calls to the handler it wraps and their outcome.
+func recordingMiddleware(name string, calls *[]string) common.TopicMiddleware {
+	return func(next common.TopicEventHandler) common.TopicEventHandler {
+		return func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+			*calls = append(*calls, name)
+			retry, err := next(ctx, e)
+			*calls = append(*calls, fmt.Sprintf("%s: retry=%t err=%v", name, retry, err))
+			return retry, err
+		}
+	}
+}
+
+func TestTopicMiddleware(t *testing.T) {
+	sub1 := &common.Subscription{PubsubName: "messages", Topic: "test1", Route: "/test1"}
+	sub2 := &common.Subscription{PubsubName: "messages", Topic: "test2", Route: "/test2"}
+	event := `{"id":"1","datacontenttype":"application/json","data":{"message":"hello"}}`
+
+	t.Run("global and per-registration middleware", func(t *testing.T) {
+		s := newServer("", nil)
+		var calls []string
+		require.NoError(t, s.AddTopicEventHandlerWithMiddleware(sub1, testErrorTopicFunc, recordingMiddleware("route", &calls)))
+		s.UseTopicMiddleware(recordingMiddleware("outer", &calls), recordingMiddleware("inner", &calls))
+		require.NoError(t, s.AddTopicEventHandler(sub2, testTopicFunc))
+
+		makeRequestWithExpectedBody(t, s, "/test1", event, http.MethodPost, http.StatusOK, []byte(`{"status":"RETRY"}`+"\n"))
+		assert.Equal(t, []string{
+			"outer",
+			"inner",
+			"route",
+			"route: retry=true err=error to cause a retry",
+			"inner: retry=true err=error to cause a retry",
+			"outer: retry=true err=error to cause a retry",
+		}, calls)
+
+		calls = nil
+		makeRequestWithExpectedBody(t, s, "/test2", event, http.MethodPost, http.StatusOK, []byte(`{"status":"SUCCESS"}`+"\n"))
+		assert.Equal(t, []string{"outer", "inner", "inner: retry=false err=<nil>", "outer: retry=false err=<nil>"}, calls)
+	})
+
+	t.Run("middleware suppressing the error", func(t *testing.T) {
+		s := newServer("", nil)
+		require.NoError(t, s.AddTopicEventHandler(sub1, testErrorTopicFunc))
+		s.UseTopicMiddleware(func(next common.TopicEventHandler) common.TopicEventHandler {
+			return func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+				_, _ = next(ctx, e)
+				return false, nil
+			}
+		})
+
+		makeRequestWithExpectedBody(t, s, "/test1", event, http.MethodPost, http.StatusOK, []byte(`{"status":"SUCCESS"}`+"\n"))
+	})
+}
+
+func TestTopicMultiplePubsubNames(t *testing.T) {
+	s := newServer("", nil)
+	var pubsubs []string
+	require.NoError(t, s.AddTopicEventHandler(&common.Subscription{
+		PubsubNames: []string{"primary", "backup"},
+		Topic:       "orders",
+		Route:       "/orders",
+	}, func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+		pubsubs = append(pubsubs, e.PubsubName)
+		return false, nil
+	}))
+
+	subs := s.topicRegistrar.Subscriptions()
+	require.Len(t, subs, 2)
+	assert.Equal(t, "primary", subs[0].PubsubName)
+	assert.Equal(t, "backup", subs[1].PubsubName)
+
+	for _, name := range []string{"primary", "backup", "backup"} {
+		event := `{"id":"1","pubsubname":"` + name + `","topic":"orders","datacontenttype":"application/json","data":{}}`
+		makeRequestWithExpectedBody(t, s, "/orders", event, http.MethodPost, http.StatusOK, []byte(`{"status":"SUCCESS"}`+"\n"))
+	}
+	assert.Equal(t, []string{"primary", "backup", "backup"}, pubsubs)
+	stats := s.SubscriptionStats()
+	assert.Equal(t, uint64(1), stats["primary/orders"].Delivered)
+	assert.Equal(t, uint64(2), stats["backup/orders"].Delivered)
+}
+
+func TestTopicRecentEvents(t *testing.T) {
+	s := newServer("", nil, WithRecentEvents(2))
+	require.NoError(t, s.AddTopicEventHandler(&common.Subscription{
+		PubsubName: "messages",
+		Topic:      "orders",
+		Route:      "/orders",
+	}, func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+		return false, nil
+	}))
+
+	for _, id := range []string{"1", "2", "3"} {
+		event := `{"id":"` + id + `","pubsubname":"messages","topic":"orders","datacontenttype":"text/plain","data":"order ` + id + `"}`
+		makeRequestWithExpectedBody(t, s, "/orders", event, http.MethodPost, http.StatusOK, []byte(`{"status":"SUCCESS"}`+"\n"))
+	}
+	events := s.RecentEvents("orders")
+	require.Len(t, events, 2)
+	assert.Equal(t, "2", events[0].ID)
+	assert.Equal(t, "order 3", events[1].Data)
+	assert.Empty(t, newServer("", nil).RecentEvents("orders"))
+}
+
+func TestTopicEventExtensions(t *testing.T) {
+	s := newServer("", nil)
+	var ext map[string]interface{}
+	require.NoError(t, s.AddTopicEventHandler(&common.Subscription{
+		PubsubName: "messages",
+		Topic:      "orders",
+		Route:      "/orders",
+	}, func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+		ext = e.Extensions
+		return false, nil
+	}))
+
+	event := `{"id":"1","specversion":"1.0","source":"test","type":"test","pubsubname":"messages","topic":"orders","datacontenttype":"application/json","data":{},"correlationid":"c1","traceparent":"00-1-2-01"}`
+	makeRequestWithExpectedBody(t, s, "/orders", event, http.MethodPost, http.StatusOK, []byte(`{"status":"SUCCESS"}`+"\n"))
+	assert.Equal(t, map[string]interface{}{"correlationid": "c1", "traceparent": "00-1-2-01"}, ext)
+}
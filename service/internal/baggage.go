@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// WithBaggage returns ctx carrying the baggage parsed from the values of the
+// baggage metadata, if any. Dropping members beyond the size limits is logged.
+func WithBaggage(ctx context.Context, values []string) context.Context {
+	if len(values) == 0 {
+		return ctx
+	}
+	b, dropped := common.ParseBaggage(values...)
+	if dropped > 0 {
+		common.LoggerFromContext(ctx).Printf("warning: truncated incoming baggage: dropped %d members beyond the size limits", dropped)
+	}
+	if b == nil {
+		return ctx
+	}
+	return common.ContextWithBaggage(ctx, b)
+}
@@ -0,0 +1,19 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// AcceptBindingHandler returns a binding invocation handler acknowledging the
+// event once fn has accepted it. If fn fails, the error is returned so that the
+// event is not acknowledged and is delivered again.
+func AcceptBindingHandler(fn common.BindingAcceptHandler) common.BindingInvocationHandler {
+	return func(ctx context.Context, in *common.BindingEvent) (out []byte, err error) {
+		if _, err := fn(ctx, in); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,21 @@
+package internal
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// DropBindingEvent reports whether the event of binding name, whose handler
+// failed with err, must be acknowledged rather than delivered again, as err is
+// a non-retryable *common.BindingError. The dropped event is logged with the
+// logger of ctx.
+func DropBindingEvent(ctx context.Context, name string, err error) bool {
+	var be *common.BindingError
+	if !errors.As(err, &be) || be.Retryable {
+		return false
+	}
+	common.LoggerFromContext(ctx).Printf("dropping event of binding %s: %v", name, err)
+	return true
+}
@@ -0,0 +1,45 @@
+//go:build !daprnocaller
+
+package internal
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// sdkRegistrationFuncs are the prefixes of the functions of the SDK on the
+// call stack of a handler registration.
+var sdkRegistrationFuncs = []string{
+	"github.com/dapr/go-sdk/service/grpc.(*Server).",
+	"github.com/dapr/go-sdk/service/http.(*Server).",
+	"github.com/dapr/go-sdk/service/internal.",
+	"github.com/dapr/go-sdk/service/common.",
+}
+
+// registrationCaller returns the "file:line" of the first caller outside of
+// the SDK on the call stack, which registers a handler. Build with the
+// daprnocaller tag to skip it.
+func registrationCaller() string {
+	var pcs [16]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isSDKRegistrationFunc(frame.Function) {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func isSDKRegistrationFunc(name string) bool {
+	for _, prefix := range sdkRegistrationFuncs {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
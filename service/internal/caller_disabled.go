@@ -0,0 +1,9 @@
+//go:build daprnocaller
+
+package internal
+
+// registrationCaller returns an empty string, as the callers registering
+// handlers are not captured with the daprnocaller build tag.
+func registrationCaller() string {
+	return ""
+}
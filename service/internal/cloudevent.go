@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// CloudEventContentType is the content type of a structured-mode CloudEvent.
+const CloudEventContentType = "application/cloudevents+json"
+
+// CloudEvent holds the attributes of a structured-mode CloudEvent, whose data
+// is the event it carries.
+type CloudEvent struct {
+	ID              string          `json:"id"`
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	DataContentType string          `json:"datacontenttype"`
+	Subject         string          `json:"subject"`
+	ContentEncoding string          `json:"contentencoding"`
+	Data            json.RawMessage `json:"data"`
+	DataBase64      string          `json:"data_base64"`
+}
+
+// StructuredCloudEvent parses data, of the given content type, as a
+// structured-mode CloudEvent. It returns false if data is not one: its content
+// type must be application/cloudevents+json or, for brokers delivering the
+// event as an opaque payload, a JSON or binary type with data holding all the
+// required attributes.
+func StructuredCloudEvent(contentType string, data []byte) (*CloudEvent, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case CloudEventContentType:
+	case "", "application/json", "application/octet-stream":
+		if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	var ce CloudEvent
+	if err := json.Unmarshal(data, &ce); err != nil {
+		return nil, false
+	}
+	if ce.ID == "" || ce.Source == "" || ce.SpecVersion == "" || ce.Type == "" {
+		return nil, false
+	}
+	return &ce, true
+}
+
+// RawData returns the data of the event, decoded from data_base64 and from
+// its content encoding. A JSON string holding data of a non-JSON content type,
+// such as text/plain, is returned unquoted.
+func (ce *CloudEvent) RawData() ([]byte, error) {
+	var (
+		raw []byte
+		err error
+	)
+	switch {
+	case ce.DataBase64 != "":
+		raw, err = base64.StdEncoding.DecodeString(ce.DataBase64)
+	case len(ce.Data) > 0 && ce.Data[0] == '"' && (ce.ContentEncoding != "" || !isJSONContentType(ce.DataContentType)):
+		var s string
+		if err = json.Unmarshal(ce.Data, &s); err == nil {
+			raw = []byte(s)
+			if ce.ContentEncoding != "" {
+				// Encoded data in the data attribute is a base64-encoded string.
+				raw, err = base64.StdEncoding.DecodeString(s)
+			}
+		}
+	case !bytes.Equal(ce.Data, []byte("null")):
+		raw = ce.Data
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cloud event data: %w", err)
+	}
+	return DecodeContent(ce.ContentEncoding, raw)
+}
+
+// isJSONContentType reports whether contentType is JSON, which it is when not
+// set, as for the data of a CloudEvent.
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// cloudEventAttributes are the attributes of a CloudEvent that are not
+// extensions, or that are fields of common.TopicEvent.
+var cloudEventAttributes = map[string]struct{}{
+	"id":              {},
+	"source":          {},
+	"specversion":     {},
+	"type":            {},
+	"datacontenttype": {},
+	"dataschema":      {},
+	"subject":         {},
+	"time":            {},
+	"data":            {},
+	"data_base64":     {},
+	"topic":           {},
+	"pubsubname":      {},
+	"contentencoding": {},
+}
+
+// CloudEventExtensions returns the extension attributes of the
+// structured-mode CloudEvent data, or nil if it has none or is not a JSON
+// object.
+func CloudEventExtensions(data []byte) map[string]interface{} {
+	var attrs map[string]interface{}
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return nil
+	}
+	return ExtensionAttributes(attrs)
+}
+
+// ExtensionAttributes returns the extension attributes among the attributes
+// of a CloudEvent, or nil if there are none.
+func ExtensionAttributes(attrs map[string]interface{}) map[string]interface{} {
+	var ext map[string]interface{}
+	for k, v := range attrs {
+		if _, ok := cloudEventAttributes[k]; ok {
+			continue
+		}
+		if ext == nil {
+			ext = make(map[string]interface{})
+		}
+		ext[k] = v
+	}
+	return ext
+}
@@ -0,0 +1,61 @@
+package internal_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func TestStructuredCloudEvent(t *testing.T) {
+	const event = `{"id":"1","source":"s","type":"t","specversion":"1.0","data":{"a":1}}`
+
+	for _, contentType := range []string{"application/cloudevents+json", "application/json", "application/octet-stream", ""} {
+		ce, ok := internal.StructuredCloudEvent(contentType, []byte(event))
+		require.True(t, ok, contentType)
+		data, err := ce.RawData()
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":1}`, string(data))
+	}
+
+	_, ok := internal.StructuredCloudEvent("text/plain", []byte(event))
+	assert.False(t, ok)
+	_, ok = internal.StructuredCloudEvent("application/json", []byte(`{"id":"1","source":"s"}`))
+	assert.False(t, ok)
+	_, ok = internal.StructuredCloudEvent("application/cloudevents+json", []byte(`not json`))
+	assert.False(t, ok)
+}
+
+func TestCloudEventRawData(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	gz := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	tests := []struct {
+		name string
+		ce   internal.CloudEvent
+		want string
+	}{
+		{"string", internal.CloudEvent{DataContentType: "text/plain", Data: []byte(`"hello"`)}, "hello"},
+		{"json string", internal.CloudEvent{DataContentType: "application/json", Data: []byte(`"hello"`)}, `"hello"`},
+		{"base64", internal.CloudEvent{DataBase64: base64.StdEncoding.EncodeToString([]byte("hello"))}, "hello"},
+		{"gzip in data", internal.CloudEvent{ContentEncoding: "gzip", Data: []byte(`"` + gz + `"`)}, "hello"},
+		{"gzip in data_base64", internal.CloudEvent{ContentEncoding: "gzip", DataBase64: gz}, "hello"},
+		{"null", internal.CloudEvent{Data: []byte("null")}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.ce.RawData()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// BrokerMetadataPrefix is the prefix of the headers (HTTP) and metadata keys
+// (gRPC) with which the runtime passes the broker metadata of a message.
+const BrokerMetadataPrefix = "metadata."
+
+// deliveryAttemptKeys maps the broker metadata keys holding a delivery count
+// to the offset turning their value into a delivery attempt.
+var deliveryAttemptKeys = []struct {
+	key    string
+	offset int
+}{
+	// Azure Service Bus, counting the current delivery.
+	{key: "deliverycount"},
+	// GCP Pub/Sub with dead lettering, counting the current delivery.
+	{key: "deliveryattempt"},
+	// RabbitMQ quorum queues, counting the previous deliveries.
+	{key: "x-delivery-count", offset: 1},
+}
+
+// DeliveryAttempt returns the delivery attempt of a message, starting at 1,
+// from its broker metadata as returned by get, which is called with the
+// lowercased keys without prefix. It returns 0 if the broker doesn't provide it.
+func DeliveryAttempt(get func(key string) string) int {
+	for _, k := range deliveryAttemptKeys {
+		v := strings.TrimSpace(get(k.key))
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			continue
+		}
+		return n + k.offset
+	}
+	return 0
+}
+
+// BrokerMetadata returns the broker metadata of a message from its headers
+// (HTTP) or metadata (gRPC), given as lowercased keys with their values,
+// keyed without the prefix. It returns nil if there is none.
+func BrokerMetadata(headers map[string][]string) map[string]string {
+	var md map[string]string
+	for k, v := range headers {
+		k = strings.ToLower(k)
+		if !strings.HasPrefix(k, BrokerMetadataPrefix) || len(v) == 0 || len(k) == len(BrokerMetadataPrefix) {
+			continue
+		}
+		if md == nil {
+			md = make(map[string]string)
+		}
+		md[strings.TrimPrefix(k, BrokerMetadataPrefix)] = v[0]
+	}
+	return md
+}
+
+// ResponseMetadataHeaders returns the headers, prefixed with
+// BrokerMetadataPrefix, sending the response metadata md of a topic event
+// handler, and the keys dropped as they can't be sent in a header: the empty
+// keys, the ones with characters other than lowercase letters, digits, '-',
+// '_' and '.', after lowercasing, and the ones whose value is not printable
+// ASCII.
+func ResponseMetadataHeaders(md map[string]string) (headers map[string]string, dropped []string) {
+	for k, v := range md {
+		key := strings.ToLower(k)
+		if !validMetadataKey(key) || !validMetadataValue(v) {
+			dropped = append(dropped, k)
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(md))
+		}
+		headers[BrokerMetadataPrefix+key] = v
+	}
+	return headers, dropped
+}
+
+func validMetadataKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func validMetadataValue(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if v[i] < 0x20 || v[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
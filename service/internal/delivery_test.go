@@ -0,0 +1,30 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func TestDeliveryAttempt(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     int
+	}{
+		{name: "not provided", metadata: map[string]string{"other": "1"}, want: 0},
+		{name: "delivery count", metadata: map[string]string{"deliverycount": "3"}, want: 3},
+		{name: "delivery attempt", metadata: map[string]string{"deliveryattempt": " 2 "}, want: 2},
+		{name: "redelivery count", metadata: map[string]string{"x-delivery-count": "0"}, want: 1},
+		{name: "invalid value", metadata: map[string]string{"deliverycount": "many"}, want: 0},
+		{name: "negative value", metadata: map[string]string{"deliverycount": "-1"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := internal.DeliveryAttempt(func(key string) string { return tt.metadata[key] })
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ContentEncodingExtension is the CloudEvent extension attribute holding the
+// encoding of the event data, set by publishers using gzip compression.
+const ContentEncodingExtension = "contentencoding"
+
+// MaxDecodedContentSize is the maximum size of decoded event data, beyond
+// which DecodeContent fails rather than inflating a compressed payload
+// without bound.
+const MaxDecodedContentSize = 64 << 20
+
+// DecodeContent returns data decoded according to encoding. Data with no or an
+// identity encoding is returned as is.
+func DecodeContent(encoding string, data []byte) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "", "identity":
+		return data, nil
+	case "gzip":
+		if len(data) == 0 {
+			return data, nil
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing gzip event data: %w", err)
+		}
+		defer zr.Close()
+		decoded, err := io.ReadAll(io.LimitReader(zr, MaxDecodedContentSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing gzip event data: %w", err)
+		}
+		if len(decoded) > MaxDecodedContentSize {
+			return nil, fmt.Errorf("decompressed gzip event data exceeds %d bytes", MaxDecodedContentSize)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %s", encoding)
+	}
+}
@@ -0,0 +1,57 @@
+package internal_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeContent(t *testing.T) {
+	t.Run("identity", func(t *testing.T) {
+		got, err := internal.DecodeContent("", []byte("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hello"), got)
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		got, err := internal.DecodeContent("GZIP", gzipData(t, []byte("hello")))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hello"), got)
+	})
+
+	t.Run("gzip at the size limit", func(t *testing.T) {
+		got, err := internal.DecodeContent("gzip", gzipData(t, make([]byte, internal.MaxDecodedContentSize)))
+		require.NoError(t, err)
+		assert.Len(t, got, internal.MaxDecodedContentSize)
+	})
+
+	t.Run("gzip over the size limit", func(t *testing.T) {
+		_, err := internal.DecodeContent("gzip", gzipData(t, make([]byte, internal.MaxDecodedContentSize+1)))
+		assert.ErrorContains(t, err, "exceeds")
+	})
+
+	t.Run("invalid gzip", func(t *testing.T) {
+		_, err := internal.DecodeContent("gzip", []byte("not gzip"))
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		_, err := internal.DecodeContent("br", []byte("hello"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+const (
+	subscriptionAPIVersion = "dapr.io/v2alpha1"
+	subscriptionKind       = "Subscription"
+)
+
+// SubscriptionResource is a Dapr Subscription resource, as applied to
+// Kubernetes or loaded from the resources path of the runtime.
+type SubscriptionResource struct {
+	APIVersion string                   `yaml:"apiVersion"`
+	Kind       string                   `yaml:"kind"`
+	Metadata   SubscriptionResourceMeta `yaml:"metadata"`
+	Spec       SubscriptionResourceSpec `yaml:"spec"`
+	Scopes     []string                 `yaml:"scopes,omitempty"`
+}
+
+// SubscriptionResourceMeta is the metadata of a SubscriptionResource.
+type SubscriptionResourceMeta struct {
+	Name string `yaml:"name"`
+}
+
+// SubscriptionResourceSpec is the spec of a SubscriptionResource.
+type SubscriptionResourceSpec struct {
+	PubsubName      string                      `yaml:"pubsubname"`
+	Topic           string                      `yaml:"topic"`
+	Routes          *SubscriptionResourceRoutes `yaml:"routes,omitempty"`
+	Metadata        map[string]string           `yaml:"metadata,omitempty"`
+	DeadLetterTopic string                      `yaml:"deadLetterTopic,omitempty"`
+	BulkSubscribe   *SubscriptionResourceBulk   `yaml:"bulkSubscribe,omitempty"`
+}
+
+// SubscriptionResourceRoutes are the routes of a SubscriptionResource.
+type SubscriptionResourceRoutes struct {
+	Rules   []SubscriptionResourceRule `yaml:"rules,omitempty"`
+	Default string                     `yaml:"default,omitempty"`
+}
+
+// SubscriptionResourceRule is a routing rule of a SubscriptionResource.
+type SubscriptionResourceRule struct {
+	Match string `yaml:"match"`
+	Path  string `yaml:"path"`
+}
+
+// SubscriptionResourceBulk are the bulk subscribe settings of a
+// SubscriptionResource.
+type SubscriptionResourceBulk struct {
+	Enabled            bool  `yaml:"enabled"`
+	MaxMessagesCount   int32 `yaml:"maxMessagesCount,omitempty"`
+	MaxAwaitDurationMs int32 `yaml:"maxAwaitDurationMs,omitempty"`
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// resourceName returns a Kubernetes resource name for the subscription to
+// topic on pubsubName.
+func resourceName(pubsubName, topic string) string {
+	name := strings.ToLower(pubsubName + "-" + topic)
+	return strings.Trim(invalidNameChars.ReplaceAllString(name, "-"), "-")
+}
+
+// NewSubscriptionResource returns the Dapr Subscription resource declaring s.
+func NewSubscriptionResource(s *TopicSubscription) *SubscriptionResource {
+	r := &SubscriptionResource{
+		APIVersion: subscriptionAPIVersion,
+		Kind:       subscriptionKind,
+		Metadata:   SubscriptionResourceMeta{Name: resourceName(s.PubsubName, s.Topic)},
+		Spec: SubscriptionResourceSpec{
+			PubsubName:      s.PubsubName,
+			Topic:           s.Topic,
+			Metadata:        s.Metadata,
+			DeadLetterTopic: s.DeadLetterTopic,
+		},
+		Scopes: s.Scopes,
+	}
+	if s.Routes != nil {
+		routes := &SubscriptionResourceRoutes{Default: s.Routes.Default}
+		for _, rule := range s.Routes.Rules {
+			routes.Rules = append(routes.Rules, SubscriptionResourceRule{Match: rule.Match, Path: rule.Path})
+		}
+		r.Spec.Routes = routes
+	} else if s.Route != "" {
+		r.Spec.Routes = &SubscriptionResourceRoutes{Default: s.Route}
+	}
+	if b := s.BulkSubscribe; b != nil {
+		r.Spec.BulkSubscribe = &SubscriptionResourceBulk{
+			Enabled:            b.Enabled,
+			MaxMessagesCount:   b.MaxMessagesCount,
+			MaxAwaitDurationMs: b.MaxAwaitDurationMs,
+		}
+	}
+	return r
+}
+
+// ExportSubscriptions returns the Dapr Subscription resources declaring subs
+// in format.
+func ExportSubscriptions(subs []*TopicSubscription, format common.ExportFormat) ([]byte, error) {
+	if format != common.ExportFormatYAML {
+		return nil, fmt.Errorf("unsupported export format: %q", format)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, s := range subs {
+		if err := enc.Encode(NewSubscriptionResource(s)); err != nil {
+			return nil, fmt.Errorf("error encoding subscription for topic %s on pubsub %s: %w", s.Topic, s.PubsubName, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
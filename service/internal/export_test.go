@@ -0,0 +1,86 @@
+package internal_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files")
+
+func TestExportSubscriptions(t *testing.T) {
+	fn := func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		return false, nil
+	}
+	tests := map[string][]*common.Subscription{
+		"simple": {
+			{PubsubName: "messages", Topic: "orders", Route: "/orders"},
+		},
+		"routed": {
+			{
+				PubsubName:      "messages",
+				Topic:           "Orders.Created",
+				Route:           "/orders",
+				DeadLetterTopic: "poison",
+				Metadata:        map[string]string{"rawPayload": "true", "consumerID": "billing"},
+				Scopes:          []string{"billing", "shipping"},
+			},
+			{PubsubName: "messages", Topic: "Orders.Created", Route: "/orders/large", Match: `event.data.amount > 1000`, Priority: 2},
+			{PubsubName: "messages", Topic: "Orders.Created", Route: "/orders/eu", Match: `event.data.region == "eu"`, Priority: 1},
+		},
+		"bulk": {
+			{
+				PubsubName: "events",
+				Topic:      "clicks",
+				Route:      "/clicks",
+				BulkSubscribe: &common.BulkSubscribeOptions{
+					Enabled:            true,
+					MaxMessagesCount:   100,
+					MaxAwaitDurationMs: 40,
+				},
+			},
+			{PubsubName: "events", Topic: "views", Route: "/views"},
+		},
+	}
+	for name, subs := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := internal.TopicRegistrar{}
+			for _, sub := range subs {
+				require.NoError(t, m.AddSubscription(sub, fn))
+			}
+			got, err := internal.ExportSubscriptions(m.Subscriptions(), common.ExportFormatYAML)
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "subscriptions", name+".yaml")
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(golden, got, 0o600))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+
+			// The golden file decodes back to the exported resources.
+			dec := yaml.NewDecoder(bytes.NewReader(want))
+			for _, s := range m.Subscriptions() {
+				var r internal.SubscriptionResource
+				require.NoError(t, dec.Decode(&r))
+				assert.Equal(t, internal.NewSubscriptionResource(s), &r)
+			}
+		})
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := internal.ExportSubscriptions(nil, "xml")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// HealthChecker runs the app health check handler with an optional timeout,
+// only reporting the app as unhealthy after FailureThreshold consecutive failures.
+type HealthChecker struct {
+	Timeout          time.Duration
+	FailureThreshold int
+
+	lock     sync.Mutex
+	failures int
+}
+
+// Check runs fn and returns the error to report to Dapr, if any.
+func (h *HealthChecker) Check(ctx context.Context, fn common.HealthCheckHandler) error {
+	err := h.run(ctx, fn)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if err == nil {
+		h.failures = 0
+		return nil
+	}
+	h.failures++
+	if h.failures < h.FailureThreshold {
+		return nil
+	}
+	return err
+}
+
+func (h *HealthChecker) run(ctx context.Context, fn common.HealthCheckHandler) error {
+	if h.Timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	// Run the handler in its own goroutine so that a handler that doesn't
+	// honor the context still can't hang the health probe.
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fn(ctx)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("health check did not complete within %s: %w", h.Timeout, ctx.Err())
+	}
+}
@@ -0,0 +1,50 @@
+package internal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func TestHealthCheckerTimeout(t *testing.T) {
+	h := &internal.HealthChecker{Timeout: 10 * time.Millisecond}
+	hang := make(chan struct{})
+	defer close(hang)
+
+	err := h.Check(context.Background(), func(ctx context.Context) error {
+		<-hang
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHealthCheckerFailureThreshold(t *testing.T) {
+	h := &internal.HealthChecker{FailureThreshold: 3}
+	var healthy bool
+	fn := func(ctx context.Context) error {
+		if healthy {
+			return nil
+		}
+		return errors.New("unhealthy")
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, h.Check(ctx, fn))
+	assert.NoError(t, h.Check(ctx, fn))
+	assert.Error(t, h.Check(ctx, fn))
+	assert.Error(t, h.Check(ctx, fn))
+
+	healthy = true
+	assert.NoError(t, h.Check(ctx, fn))
+
+	// The streak is reset by a success.
+	healthy = false
+	assert.NoError(t, h.Check(ctx, fn))
+	assert.NoError(t, h.Check(ctx, fn))
+	assert.Error(t, h.Check(ctx, fn))
+}
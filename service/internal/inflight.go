@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"context"
+	"sync"
+)
+
+// InFlight tracks the in-flight calls of a handler, so that they can be
+// drained when a service stops.
+type InFlight struct {
+	lock     sync.Mutex
+	draining bool
+	calls    int
+	// done is closed when draining and no call is in flight.
+	done chan struct{}
+}
+
+// Begin records the start of a call. It returns false, without recording the
+// call, once Drain has been called.
+func (f *InFlight) Begin() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.draining {
+		return false
+	}
+	f.calls++
+	return true
+}
+
+// End records the end of a call started with Begin.
+func (f *InFlight) End() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls--
+	f.closeIfDrained()
+}
+
+// Drain rejects new calls and waits for the in-flight ones to end, or for ctx
+// to be done, in which case it returns the error of ctx.
+func (f *InFlight) Drain(ctx context.Context) error {
+	f.lock.Lock()
+	f.draining = true
+	if f.done == nil {
+		f.done = make(chan struct{})
+	}
+	f.closeIfDrained()
+	done := f.done
+	f.lock.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reset accepts new calls again after Drain. The calls still in flight are
+// waited for by the next Drain.
+func (f *InFlight) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.draining = false
+	f.done = make(chan struct{})
+}
+
+// closeIfDrained closes done if draining and no call is in flight. f.lock
+// must be held.
+func (f *InFlight) closeIfDrained() {
+	if !f.draining || f.calls > 0 {
+		return
+	}
+	select {
+	case <-f.done:
+	default:
+		close(f.done)
+	}
+}
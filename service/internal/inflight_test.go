@@ -0,0 +1,71 @@
+package internal_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func TestInFlight(t *testing.T) {
+	t.Run("drain waits for in-flight calls", func(t *testing.T) {
+		var f internal.InFlight
+		require.True(t, f.Begin())
+
+		drained := make(chan error, 1)
+		go func() { drained <- f.Drain(context.Background()) }()
+		require.Eventually(t, func() bool { return !f.Begin() }, time.Second, time.Millisecond)
+		select {
+		case <-drained:
+			t.Fatal("drain returned with a call in flight")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		f.End()
+		require.NoError(t, <-drained)
+	})
+
+	t.Run("drain is bounded by the context", func(t *testing.T) {
+		var f internal.InFlight
+		require.True(t, f.Begin())
+		defer f.End()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, f.Drain(ctx), context.DeadlineExceeded)
+	})
+
+	t.Run("reset accepts calls again", func(t *testing.T) {
+		var f internal.InFlight
+		require.NoError(t, f.Drain(context.Background()))
+		assert.False(t, f.Begin())
+		f.Reset()
+		require.True(t, f.Begin())
+		f.End()
+	})
+
+	t.Run("reset after a timed out drain", func(t *testing.T) {
+		var f internal.InFlight
+		require.True(t, f.Begin())
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, f.Drain(ctx), context.DeadlineExceeded)
+
+		// The call of the previous run ends while new calls are made.
+		f.Reset()
+		require.True(t, f.Begin())
+		f.End()
+		f.End()
+		require.True(t, f.Begin())
+
+		drained := make(chan error, 1)
+		go func() { drained <- f.Drain(context.Background()) }()
+		require.Eventually(t, func() bool { return !f.Begin() }, time.Second, time.Millisecond)
+		f.End()
+		require.NoError(t, <-drained)
+	})
+}
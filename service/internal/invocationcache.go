@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// InvocationCache caches the responses of a service invocation handler.
+type InvocationCache struct {
+	ttl        time.Duration
+	maxEntries int
+	keyFunc    func(in *common.InvocationEvent) string
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // of *cacheEntry, most recently used first
+	calls   map[string]*cacheCall
+	stats   common.InvocationCacheStats
+}
+
+type cacheEntry struct {
+	key     string
+	content *common.Content
+	expires time.Time
+}
+
+// cacheCall is an in-flight invocation of the handler, shared by the
+// concurrent invocations with its key.
+type cacheCall struct {
+	done    chan struct{}
+	content *common.Content
+	err     error
+}
+
+// NewInvocationCache creates a cache configured by cfg.
+func NewInvocationCache(cfg common.CacheConfig) (*InvocationCache, error) {
+	if cfg.TTL <= 0 {
+		return nil, errors.New("cache TTL must be positive")
+	}
+	if cfg.MaxEntries < 0 {
+		return nil, errors.New("cache max entries must not be negative")
+	}
+	keyFunc := cfg.KeyFunc
+	if keyFunc == nil {
+		keyFunc = defaultCacheKey
+	}
+	return &InvocationCache{
+		ttl:        cfg.TTL,
+		maxEntries: cfg.MaxEntries,
+		keyFunc:    keyFunc,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		calls:      make(map[string]*cacheCall),
+	}, nil
+}
+
+func defaultCacheKey(in *common.InvocationEvent) string {
+	return in.Verb + "\x00" + in.QueryString + "\x00" + string(in.Data)
+}
+
+// Handler wraps fn to answer the invocations from the cache.
+func (c *InvocationCache) Handler(fn common.ServiceInvocationHandler) common.ServiceInvocationHandler {
+	return func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		key := c.keyFunc(in)
+		if key == "" {
+			return fn(ctx, in)
+		}
+
+		c.lock.Lock()
+		if content, ok := c.get(key); ok {
+			c.stats.Hits++
+			c.lock.Unlock()
+			return content, nil
+		}
+		if call, ok := c.calls[key]; ok {
+			c.stats.Hits++
+			c.lock.Unlock()
+			select {
+			case <-call.done:
+				return copyContent(call.content), call.err
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		call := &cacheCall{done: make(chan struct{})}
+		c.calls[key] = call
+		c.stats.Misses++
+		c.lock.Unlock()
+
+		defer func() {
+			c.lock.Lock()
+			delete(c.calls, key)
+			if call.err == nil {
+				c.put(key, call.content)
+			}
+			c.lock.Unlock()
+			close(call.done)
+		}()
+		call.content, call.err = fn(ctx, in)
+		if call.err != nil {
+			return nil, call.err
+		}
+		return copyContent(call.content), nil
+	}
+}
+
+// Invalidate removes the response cached for key.
+func (c *InvocationCache) Invalidate(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+}
+
+// Stats returns the stats of the cache.
+func (c *InvocationCache) Stats() common.InvocationCacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+// get returns the response cached for key, if it hasn't expired.
+func (c *InvocationCache) get(key string) (*common.Content, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*cacheEntry)
+	if !time.Now().Before(entry.expires) {
+		c.remove(e)
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return copyContent(entry.content), true
+}
+
+func (c *InvocationCache) put(key string, content *common.Content) {
+	entry := &cacheEntry{key: key, content: content, expires: time.Now().Add(c.ttl)}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *InvocationCache) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*cacheEntry).key)
+}
+
+// copyContent returns a copy of content, sharing its data, so that the
+// handlers can't change the cached responses' fields.
+func copyContent(content *common.Content) *common.Content {
+	if content == nil {
+		return nil
+	}
+	c := *content
+	return &c
+}
+
+// InvocationCaches are the caches of the service invocation handlers, by
+// method without the leading slash.
+type InvocationCaches map[string]*InvocationCache
+
+// Set sets the cache of the handler of method, removing it if cache is nil.
+func (m InvocationCaches) Set(method string, cache *InvocationCache) {
+	if cache == nil {
+		delete(m, method)
+		return
+	}
+	m[method] = cache
+}
+
+// Invalidate removes the response cached for key by the handler of method.
+func (m InvocationCaches) Invalidate(method, key string) {
+	if cache, ok := m[method]; ok {
+		cache.Invalidate(key)
+	}
+}
+
+// Stats returns the stats of the caches, by method.
+func (m InvocationCaches) Stats() map[string]common.InvocationCacheStats {
+	stats := make(map[string]common.InvocationCacheStats, len(m))
+	for method, cache := range m {
+		stats[method] = cache.Stats()
+	}
+	return stats
+}
@@ -0,0 +1,159 @@
+package internal_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func TestInvocationCache(t *testing.T) {
+	ctx := context.Background()
+	var calls int32
+	fn := func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+		atomic.AddInt32(&calls, 1)
+		if string(in.Data) == "fail" {
+			return nil, errors.New("failed")
+		}
+		return &common.Content{Data: in.Data, ContentType: "text/plain"}, nil
+	}
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := internal.NewInvocationCache(common.CacheConfig{})
+		assert.Error(t, err)
+		_, err = internal.NewInvocationCache(common.CacheConfig{TTL: time.Minute, MaxEntries: -1})
+		assert.Error(t, err)
+	})
+
+	t.Run("hits and misses", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		c, err := internal.NewInvocationCache(common.CacheConfig{TTL: time.Minute})
+		require.NoError(t, err)
+		h := c.Handler(fn)
+		for i := 0; i < 3; i++ {
+			out, err := h(ctx, &common.InvocationEvent{Verb: "GET", Data: []byte("a")})
+			require.NoError(t, err)
+			assert.Equal(t, "a", string(out.Data))
+		}
+		_, err = h(ctx, &common.InvocationEvent{Verb: "POST", Data: []byte("a")})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.Equal(t, common.InvocationCacheStats{Hits: 2, Misses: 2, Entries: 2}, c.Stats())
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		c, err := internal.NewInvocationCache(common.CacheConfig{TTL: time.Minute})
+		require.NoError(t, err)
+		h := c.Handler(fn)
+		for i := 0; i < 2; i++ {
+			_, err := h(ctx, &common.InvocationEvent{Data: []byte("fail")})
+			assert.Error(t, err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.Equal(t, 0, c.Stats().Entries)
+	})
+
+	t.Run("empty key bypasses the cache", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		c, err := internal.NewInvocationCache(common.CacheConfig{
+			TTL:     time.Minute,
+			KeyFunc: func(in *common.InvocationEvent) string { return "" },
+		})
+		require.NoError(t, err)
+		h := c.Handler(fn)
+		for i := 0; i < 2; i++ {
+			_, err := h(ctx, &common.InvocationEvent{Data: []byte("a")})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.Equal(t, common.InvocationCacheStats{}, c.Stats())
+	})
+
+	t.Run("singleflight", func(t *testing.T) {
+		var n int32
+		release := make(chan struct{})
+		c, err := internal.NewInvocationCache(common.CacheConfig{TTL: time.Minute})
+		require.NoError(t, err)
+		h := c.Handler(func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+			atomic.AddInt32(&n, 1)
+			<-release
+			return &common.Content{Data: []byte("slow")}, nil
+		})
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				out, err := h(ctx, &common.InvocationEvent{Data: []byte("a")})
+				assert.NoError(t, err)
+				assert.Equal(t, "slow", string(out.Data))
+			}()
+		}
+		assert.Eventually(t, func() bool {
+			s := c.Stats()
+			return s.Hits+s.Misses == 10
+		}, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&n))
+		assert.Equal(t, common.InvocationCacheStats{Hits: 9, Misses: 1, Entries: 1}, c.Stats())
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		c, err := internal.NewInvocationCache(common.CacheConfig{TTL: 20 * time.Millisecond})
+		require.NoError(t, err)
+		h := c.Handler(fn)
+		_, err = h(ctx, &common.InvocationEvent{Data: []byte("a")})
+		require.NoError(t, err)
+		time.Sleep(40 * time.Millisecond)
+		_, err = h(ctx, &common.InvocationEvent{Data: []byte("a")})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.Equal(t, uint64(2), c.Stats().Misses)
+	})
+
+	t.Run("max entries", func(t *testing.T) {
+		c, err := internal.NewInvocationCache(common.CacheConfig{TTL: time.Minute, MaxEntries: 2})
+		require.NoError(t, err)
+		h := c.Handler(fn)
+		for _, d := range []string{"a", "b", "a", "c"} {
+			_, err := h(ctx, &common.InvocationEvent{Data: []byte(d)})
+			require.NoError(t, err)
+		}
+		stats := c.Stats()
+		assert.Equal(t, 2, stats.Entries)
+		assert.Equal(t, uint64(1), stats.Evictions)
+
+		// b was the least recently used.
+		atomic.StoreInt32(&calls, 0)
+		_, err = h(ctx, &common.InvocationEvent{Data: []byte("a")})
+		require.NoError(t, err)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("invalidation", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		c, err := internal.NewInvocationCache(common.CacheConfig{
+			TTL:     time.Minute,
+			KeyFunc: func(in *common.InvocationEvent) string { return string(in.Data) },
+		})
+		require.NoError(t, err)
+		h := c.Handler(fn)
+		_, err = h(ctx, &common.InvocationEvent{Data: []byte("a")})
+		require.NoError(t, err)
+		c.Invalidate("a")
+		_, err = h(ctx, &common.InvocationEvent{Data: []byte("a")})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
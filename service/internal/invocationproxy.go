@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/dapr/go-sdk/client"
+)
+
+// ErrProxyLoop is returned when an invocation proxy would forward requests to
+// the app it runs in.
+var ErrProxyLoop = errors.New("invocation proxy refuses to forward to its own app ID")
+
+// InvocationProxy forwards service invocations whose method starts with Prefix
+// to another app through the Dapr sidecar.
+type InvocationProxy struct {
+	Prefix      string
+	TargetAppID string
+	Client      client.Client
+	Rewrite     func(method string) string
+
+	lock   sync.Mutex
+	selfID string
+}
+
+// ProxiedRequest is an incoming invocation to forward.
+type ProxiedRequest struct {
+	Method      string
+	Verb        string
+	QueryString string
+	ContentType string
+	Data        []byte
+}
+
+// ProxiedResponse is the response of the target of a forwarded invocation.
+type ProxiedResponse struct {
+	Data        []byte
+	ContentType string
+	// Headers are the response headers of the target, without the content
+	// type and the transport headers.
+	Headers map[string][]string
+}
+
+// NewInvocationProxy validates the arguments and creates a new InvocationProxy.
+func NewInvocationProxy(prefix, targetAppID string, daprClient client.Client, rewrite func(method string) string) (*InvocationProxy, error) {
+	if prefix == "" {
+		return nil, errors.New("proxy prefix required")
+	}
+	if targetAppID == "" {
+		return nil, errors.New("proxy target app ID required")
+	}
+	if daprClient == nil {
+		return nil, errors.New("dapr client required")
+	}
+	return &InvocationProxy{
+		Prefix:      prefix,
+		TargetAppID: targetAppID,
+		Client:      daprClient,
+		Rewrite:     rewrite,
+	}, nil
+}
+
+// Forward invokes the target app with the request, returning its response.
+// Errors returned by the target are passed through unchanged, so that their
+// gRPC status is preserved.
+func (p *InvocationProxy) Forward(ctx context.Context, req *ProxiedRequest) (*ProxiedResponse, error) {
+	selfID, err := p.appID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if selfID == p.TargetAppID {
+		return nil, ErrProxyLoop
+	}
+
+	method := req.Method
+	if p.Rewrite != nil {
+		method = p.Rewrite(method)
+	}
+	if req.QueryString != "" {
+		method += "?" + req.QueryString
+	}
+	verb := req.Verb
+	if verb == "" {
+		verb = "NONE"
+	}
+
+	content := &client.DataContent{
+		Data:        req.Data,
+		ContentType: req.ContentType,
+	}
+	ri, ok := p.Client.(client.ResponseInvoker)
+	if !ok {
+		// Clients unable to return the response details only forward the data.
+		data, err := p.Client.InvokeMethodWithContent(ctx, p.TargetAppID, method, verb, content)
+		if err != nil {
+			return nil, err
+		}
+		return &ProxiedResponse{Data: data}, nil
+	}
+	resp, err := ri.InvokeMethodWithResponse(ctx, p.TargetAppID, method, verb, content)
+	if err != nil {
+		return nil, err
+	}
+	return &ProxiedResponse{
+		Data:        resp.Data,
+		ContentType: resp.ContentType,
+		Headers:     forwardedHeaders(resp.Headers),
+	}, nil
+}
+
+// forwardedHeaders returns the response headers of the target to send back to
+// the caller, dropping the content type, set from the response, and the
+// headers of the transports.
+func forwardedHeaders(headers map[string][]string) map[string][]string {
+	var fwd map[string][]string
+	for k, v := range headers {
+		key := strings.ToLower(k)
+		if _, ok := transportHeaders[key]; ok || strings.HasPrefix(key, "grpc-") || strings.HasPrefix(key, ":") {
+			continue
+		}
+		if fwd == nil {
+			fwd = make(map[string][]string, len(headers))
+		}
+		fwd[key] = v
+	}
+	return fwd
+}
+
+// transportHeaders are the headers of the HTTP and gRPC transports, which are
+// not forwarded.
+var transportHeaders = map[string]struct{}{
+	"content-type":      {},
+	"content-length":    {},
+	"connection":        {},
+	"keep-alive":        {},
+	"te":                {},
+	"trailer":           {},
+	"transfer-encoding": {},
+	"upgrade":           {},
+}
+
+// appID returns the app ID of the app the proxy runs in, as reported by the
+// sidecar. The value is cached once retrieved.
+func (p *InvocationProxy) appID(ctx context.Context) (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.selfID != "" {
+		return p.selfID, nil
+	}
+	md, err := p.Client.GetMetadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.selfID = md.ID
+	return p.selfID, nil
+}
+
+// MatchProxy returns the proxy with the longest prefix matching method, or nil.
+func MatchProxy(proxies []*InvocationProxy, method string) *InvocationProxy {
+	var match *InvocationProxy
+	for _, p := range proxies {
+		if strings.HasPrefix(method, p.Prefix) && (match == nil || len(p.Prefix) > len(match.Prefix)) {
+			match = p
+		}
+	}
+	return match
+}
+
+// HTTPStatusFromCode maps a gRPC status code to the matching HTTP status code.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
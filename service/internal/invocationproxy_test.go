@@ -0,0 +1,28 @@
+package internal_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func TestMatchProxy(t *testing.T) {
+	api := &internal.InvocationProxy{Prefix: "api/"}
+	orders := &internal.InvocationProxy{Prefix: "api/orders/"}
+	proxies := []*internal.InvocationProxy{api, orders}
+
+	assert.Same(t, orders, internal.MatchProxy(proxies, "api/orders/1"))
+	assert.Same(t, api, internal.MatchProxy(proxies, "api/users/1"))
+	assert.Nil(t, internal.MatchProxy(proxies, "other"))
+}
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	assert.Equal(t, http.StatusOK, internal.HTTPStatusFromCode(codes.OK))
+	assert.Equal(t, http.StatusNotFound, internal.HTTPStatusFromCode(codes.NotFound))
+	assert.Equal(t, http.StatusServiceUnavailable, internal.HTTPStatusFromCode(codes.Unavailable))
+	assert.Equal(t, http.StatusInternalServerError, internal.HTTPStatusFromCode(codes.DataLoss))
+}
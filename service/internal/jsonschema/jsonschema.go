@@ -0,0 +1,540 @@
+// Package jsonschema validates JSON documents against the commonly used subset
+// of JSON Schema (draft 2020-12): the type, enum, const, object, array,
+// number, string and combinator keywords. Schemas using other assertion
+// keywords, such as $ref, fail to compile rather than being partially
+// enforced.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is a part of a document that doesn't match the schema.
+type Violation struct {
+	// Path is the JSON pointer to the value, empty for the document.
+	Path string
+	// Message describes how the value doesn't match the schema.
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// ErrNotJSON is returned when the validated document isn't valid JSON.
+var ErrNotJSON = errors.New("payload is not valid JSON")
+
+// annotations are the keywords that don't assert anything on the document.
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "$anchor": true, "$defs": true, "definitions": true,
+	"title": true, "description": true, "default": true, "examples": true, "format": true,
+	"readOnly": true, "writeOnly": true, "deprecated": true, "contentMediaType": true, "contentEncoding": true,
+}
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	// always is set for the boolean schemas, which accept or reject anything.
+	always *bool
+
+	types []string
+	enum  []interface{}
+	cnst  *interface{}
+
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	minProperties        *int
+	maxProperties        *int
+
+	items       *Schema
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	allOf []*Schema
+	anyOf []*Schema
+	oneOf []*Schema
+	not   *Schema
+}
+
+// Compile parses and compiles schema.
+func Compile(schema []byte) (*Schema, error) {
+	v, err := decode(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return compile(v, "")
+}
+
+func compile(v interface{}, path string) (*Schema, error) {
+	if b, ok := v.(bool); ok {
+		return &Schema{always: &b}, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema%s must be an object or a boolean", at(path))
+	}
+	s := &Schema{}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		kpath := path + "/" + escape(k)
+		if err := s.compileKeyword(k, m[k], kpath); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Schema) compileKeyword(k string, v interface{}, path string) error {
+	var err error
+	switch k {
+	case "type":
+		s.types, err = typeNames(v, path)
+	case "enum":
+		vals, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("keyword%s must be an array", at(path))
+		}
+		s.enum = vals
+	case "const":
+		s.cnst = &v
+	case "properties":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("keyword%s must be an object", at(path))
+		}
+		s.properties = make(map[string]*Schema, len(m))
+		for name, ps := range m {
+			if s.properties[name], err = compile(ps, path+"/"+escape(name)); err != nil {
+				return err
+			}
+		}
+	case "required":
+		s.required, err = stringList(v, path)
+	case "additionalProperties":
+		s.additionalProperties, err = compile(v, path)
+	case "minProperties":
+		s.minProperties, err = count(v, path)
+	case "maxProperties":
+		s.maxProperties, err = count(v, path)
+	case "items":
+		s.items, err = compile(v, path)
+	case "minItems":
+		s.minItems, err = count(v, path)
+	case "maxItems":
+		s.maxItems, err = count(v, path)
+	case "uniqueItems":
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("keyword%s must be a boolean", at(path))
+		}
+		s.uniqueItems = b
+	case "minimum":
+		s.minimum, err = number(v, path)
+	case "maximum":
+		s.maximum, err = number(v, path)
+	case "exclusiveMinimum":
+		s.exclusiveMinimum, err = number(v, path)
+	case "exclusiveMaximum":
+		s.exclusiveMaximum, err = number(v, path)
+	case "multipleOf":
+		if s.multipleOf, err = number(v, path); err == nil && *s.multipleOf <= 0 {
+			err = fmt.Errorf("keyword%s must be greater than 0", at(path))
+		}
+	case "minLength":
+		s.minLength, err = count(v, path)
+	case "maxLength":
+		s.maxLength, err = count(v, path)
+	case "pattern":
+		p, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("keyword%s must be a string", at(path))
+		}
+		if s.pattern, err = regexp.Compile(p); err != nil {
+			return fmt.Errorf("keyword%s: %w", at(path), err)
+		}
+	case "allOf":
+		s.allOf, err = schemaList(v, path)
+	case "anyOf":
+		s.anyOf, err = schemaList(v, path)
+	case "oneOf":
+		s.oneOf, err = schemaList(v, path)
+	case "not":
+		s.not, err = compile(v, path)
+	default:
+		if !annotations[k] {
+			return fmt.Errorf("keyword%s is not supported", at(path))
+		}
+	}
+	return err
+}
+
+// Validate validates the JSON document data. It returns ErrNotJSON if data
+// isn't valid JSON.
+func (s *Schema) Validate(data []byte) ([]Violation, error) {
+	v, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotJSON, err)
+	}
+	return s.validate(v, ""), nil
+}
+
+func (s *Schema) validate(v interface{}, path string) []Violation {
+	if s.always != nil {
+		if *s.always {
+			return nil
+		}
+		return []Violation{{Path: path, Message: "no value is allowed"}}
+	}
+	var vs []Violation
+	fail := func(format string, args ...interface{}) {
+		vs = append(vs, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.types) > 0 && !hasType(v, s.types) {
+		fail("expected %s, got %s", strings.Join(s.types, " or "), typeOf(v))
+		// The other keywords would only report the same mismatch.
+		return vs
+	}
+	if s.enum != nil && !contains(s.enum, v) {
+		fail("value is not one of the allowed values")
+	}
+	if s.cnst != nil && !equal(*s.cnst, v) {
+		fail("value is not the allowed value")
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		vs = append(vs, s.validateObject(v, path)...)
+	case []interface{}:
+		vs = append(vs, s.validateArray(v, path)...)
+	case json.Number:
+		vs = append(vs, s.validateNumber(v, path)...)
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			fail("length must be at least %d", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			fail("length must be at most %d", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match pattern %q", s.pattern)
+		}
+	}
+
+	for _, sub := range s.allOf {
+		vs = append(vs, sub.validate(v, path)...)
+	}
+	if s.anyOf != nil && matching(s.anyOf, v, path) == 0 {
+		fail("must match at least one schema of anyOf")
+	}
+	if s.oneOf != nil {
+		if n := matching(s.oneOf, v, path); n != 1 {
+			fail("must match exactly one schema of oneOf, matches %d", n)
+		}
+	}
+	if s.not != nil && len(s.not.validate(v, path)) == 0 {
+		fail("must not match the schema of not")
+	}
+	return vs
+}
+
+func (s *Schema) validateObject(v map[string]interface{}, path string) []Violation {
+	var vs []Violation
+	for _, name := range s.required {
+		if _, ok := v[name]; !ok {
+			vs = append(vs, Violation{Path: path, Message: fmt.Sprintf("missing required property %q", name)})
+		}
+	}
+	if s.minProperties != nil && len(v) < *s.minProperties {
+		vs = append(vs, Violation{Path: path, Message: fmt.Sprintf("must have at least %d properties", *s.minProperties)})
+	}
+	if s.maxProperties != nil && len(v) > *s.maxProperties {
+		vs = append(vs, Violation{Path: path, Message: fmt.Sprintf("must have at most %d properties", *s.maxProperties)})
+	}
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ppath := path + "/" + escape(name)
+		if ps, ok := s.properties[name]; ok {
+			vs = append(vs, ps.validate(v[name], ppath)...)
+		} else if s.additionalProperties != nil {
+			if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+				vs = append(vs, Violation{Path: ppath, Message: "additional property is not allowed"})
+				continue
+			}
+			vs = append(vs, s.additionalProperties.validate(v[name], ppath)...)
+		}
+	}
+	return vs
+}
+
+func (s *Schema) validateArray(v []interface{}, path string) []Violation {
+	var vs []Violation
+	if s.minItems != nil && len(v) < *s.minItems {
+		vs = append(vs, Violation{Path: path, Message: fmt.Sprintf("must have at least %d items", *s.minItems)})
+	}
+	if s.maxItems != nil && len(v) > *s.maxItems {
+		vs = append(vs, Violation{Path: path, Message: fmt.Sprintf("must have at most %d items", *s.maxItems)})
+	}
+	if s.uniqueItems {
+		for i := 1; i < len(v); i++ {
+			if contains(v[:i], v[i]) {
+				vs = append(vs, Violation{Path: path + "/" + strconv.Itoa(i), Message: "items must be unique"})
+			}
+		}
+	}
+	if s.items != nil {
+		for i, item := range v {
+			vs = append(vs, s.items.validate(item, path+"/"+strconv.Itoa(i))...)
+		}
+	}
+	return vs
+}
+
+func (s *Schema) validateNumber(v json.Number, path string) []Violation {
+	var vs []Violation
+	f, _ := v.Float64()
+	fail := func(format string, args ...interface{}) {
+		vs = append(vs, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.minimum != nil && f < *s.minimum {
+		fail("must be at least %v", *s.minimum)
+	}
+	if s.maximum != nil && f > *s.maximum {
+		fail("must be at most %v", *s.maximum)
+	}
+	if s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum {
+		fail("must be greater than %v", *s.exclusiveMinimum)
+	}
+	if s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum {
+		fail("must be less than %v", *s.exclusiveMaximum)
+	}
+	if s.multipleOf != nil {
+		q := f / *s.multipleOf
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			fail("must be a multiple of %v", *s.multipleOf)
+		}
+	}
+	return vs
+}
+
+// matching returns the number of schemas v matches.
+func matching(schemas []*Schema, v interface{}, path string) int {
+	n := 0
+	for _, s := range schemas {
+		if len(s.validate(v, path)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// decode decodes a single JSON value, keeping numbers as json.Number.
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+var typeNamesSet = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true, "number": true, "integer": true, "string": true,
+}
+
+func typeNames(v interface{}, path string) ([]string, error) {
+	if name, ok := v.(string); ok {
+		v = []interface{}{name}
+	}
+	names, err := stringList(v, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if !typeNamesSet[name] {
+			return nil, fmt.Errorf("keyword%s: unknown type %q", at(path), name)
+		}
+	}
+	return names, nil
+}
+
+func hasType(v interface{}, types []string) bool {
+	got := typeOf(v)
+	for _, t := range types {
+		if t == got || (t == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		if f, ok := new(big.Float).SetString(v.String()); ok && f.IsInt() {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func contains(vals []interface{}, v interface{}) bool {
+	for _, val := range vals {
+		if equal(val, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal reports whether the JSON values a and b are equal, comparing numbers
+// by value.
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fa, _ := new(big.Float).SetString(a.String())
+		fb, _ := new(big.Float).SetString(b.String())
+		return fa != nil && fb != nil && fa.Cmp(fb) == 0
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !equal(av, bv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func schemaList(v interface{}, path string) ([]*Schema, error) {
+	vals, ok := v.([]interface{})
+	if !ok || len(vals) == 0 {
+		return nil, fmt.Errorf("keyword%s must be a non-empty array", at(path))
+	}
+	schemas := make([]*Schema, len(vals))
+	for i, val := range vals {
+		s, err := compile(val, path+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = s
+	}
+	return schemas, nil
+}
+
+func stringList(v interface{}, path string) ([]string, error) {
+	vals, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("keyword%s must be an array of strings", at(path))
+	}
+	strs := make([]string, len(vals))
+	for i, val := range vals {
+		if strs[i], ok = val.(string); !ok {
+			return nil, fmt.Errorf("keyword%s must be an array of strings", at(path))
+		}
+	}
+	return strs, nil
+}
+
+func number(v interface{}, path string) (*float64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("keyword%s must be a number", at(path))
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("keyword%s: %w", at(path), err)
+	}
+	return &f, nil
+}
+
+func count(v interface{}, path string) (*int, error) {
+	n, ok := v.(json.Number)
+	if ok {
+		if i, err := strconv.Atoi(n.String()); err == nil && i >= 0 {
+			return &i, nil
+		}
+	}
+	return nil, fmt.Errorf("keyword%s must be a non-negative integer", at(path))
+}
+
+// escape escapes name as a JSON pointer reference token.
+func escape(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+func at(path string) string {
+	if path == "" {
+		return ""
+	}
+	return " at " + path
+}
@@ -0,0 +1,148 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/internal/jsonschema"
+)
+
+const orderSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"title": "order",
+	"type": "object",
+	"required": ["id", "items"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "string", "pattern": "^o-[0-9]+$"},
+		"status": {"enum": ["new", "paid"]},
+		"total": {"type": "number", "minimum": 0, "exclusiveMaximum": 1000},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"uniqueItems": true,
+			"items": {
+				"type": "object",
+				"required": ["sku", "qty"],
+				"properties": {
+					"sku": {"type": "string", "minLength": 3},
+					"qty": {"type": "integer", "minimum": 1, "multipleOf": 1}
+				}
+			}
+		},
+		"note": {"anyOf": [{"type": "string"}, {"type": "null"}]}
+	}
+}`
+
+func TestCompile(t *testing.T) {
+	_, err := jsonschema.Compile([]byte(orderSchema))
+	require.NoError(t, err)
+
+	for name, schema := range map[string]string{
+		"not json":            `{`,
+		"not an object":       `"string"`,
+		"unknown type":        `{"type": "decimal"}`,
+		"unsupported keyword": `{"properties": {"a": {"$ref": "#/$defs/a"}}}`,
+		"invalid pattern":     `{"pattern": "("}`,
+		"negative count":      `{"minItems": -1}`,
+		"empty anyOf":         `{"anyOf": []}`,
+		"zero multipleOf":     `{"multipleOf": 0}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonschema.Compile([]byte(schema))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s, err := jsonschema.Compile([]byte(orderSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		doc  string
+		want []jsonschema.Violation
+	}{
+		{
+			name: "valid",
+			doc:  `{"id": "o-1", "status": "paid", "total": 10.5, "items": [{"sku": "abc", "qty": 2.0}], "note": null}`,
+		},
+		{
+			name: "wrong type",
+			doc:  `[]`,
+			want: []jsonschema.Violation{{Path: "", Message: "expected object, got array"}},
+		},
+		{
+			name: "missing and additional properties",
+			doc:  `{"id": "o-1", "extra": 1}`,
+			want: []jsonschema.Violation{
+				{Path: "", Message: `missing required property "items"`},
+				{Path: "/extra", Message: "additional property is not allowed"},
+			},
+		},
+		{
+			name: "nested values",
+			doc:  `{"id": "x", "status": "lost", "total": 1000, "items": [{"sku": "ab", "qty": 1.5}, {"sku": "ab", "qty": 1.5}], "note": 1}`,
+			want: []jsonschema.Violation{
+				{Path: "/id", Message: `must match pattern "^o-[0-9]+$"`},
+				{Path: "/items/1", Message: "items must be unique"},
+				{Path: "/items/0/qty", Message: "expected integer, got number"},
+				{Path: "/items/0/sku", Message: "length must be at least 3"},
+				{Path: "/items/1/qty", Message: "expected integer, got number"},
+				{Path: "/items/1/sku", Message: "length must be at least 3"},
+				{Path: "/note", Message: "must match at least one schema of anyOf"},
+				{Path: "/status", Message: "value is not one of the allowed values"},
+				{Path: "/total", Message: "must be less than 1000"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := s.Validate([]byte(tt.doc))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, violations)
+		})
+	}
+
+	t.Run("not json", func(t *testing.T) {
+		_, err := s.Validate([]byte("id=o-1"))
+		assert.True(t, errors.Is(err, jsonschema.ErrNotJSON))
+		_, err = s.Validate([]byte(`{"id": "o-1"} {}`))
+		assert.True(t, errors.Is(err, jsonschema.ErrNotJSON))
+	})
+}
+
+func TestValidateCombinators(t *testing.T) {
+	s, err := jsonschema.Compile([]byte(`{
+		"oneOf": [{"type": "integer"}, {"type": "number", "maximum": 10}],
+		"not": {"const": 3},
+		"allOf": [{"minimum": 0}]
+	}`))
+	require.NoError(t, err)
+
+	violations, err := s.Validate([]byte(`20`))
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	violations, err = s.Validate([]byte(`5`))
+	require.NoError(t, err)
+	assert.Equal(t, []jsonschema.Violation{{Message: "must match exactly one schema of oneOf, matches 2"}}, violations)
+
+	violations, err = s.Validate([]byte(`3.0`))
+	require.NoError(t, err)
+	assert.Len(t, violations, 2)
+
+	violations, err = s.Validate([]byte(`-0.5`))
+	require.NoError(t, err)
+	assert.Equal(t, []jsonschema.Violation{{Message: "must be at least 0"}}, violations)
+
+	reject, err := jsonschema.Compile([]byte(`false`))
+	require.NoError(t, err)
+	violations, err = reject.Validate([]byte(`{}`))
+	require.NoError(t, err)
+	assert.Len(t, violations, 1)
+}
@@ -0,0 +1,20 @@
+package internal
+
+import (
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// NamespaceSubscription returns a copy of sub with its topic and dead letter
+// topic in its namespace, as named by common.NamespacedTopic, and without
+// namespace.
+func NamespaceSubscription(sub *common.Subscription) *common.Subscription {
+	c := *sub
+	if c.Topic != "" {
+		c.Topic = common.NamespacedTopic(sub.Namespace, sub.Topic)
+	}
+	if c.DeadLetterTopic != "" {
+		c.DeadLetterTopic = common.NamespacedTopic(sub.Namespace, sub.DeadLetterTopic)
+	}
+	c.Namespace = ""
+	return &c
+}
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// ExpandPubsubNames returns a copy of sub for each of its PubsubNames, with
+// the PubsubName set to it. PubsubName must be empty or one of them.
+func ExpandPubsubNames(sub *common.Subscription) ([]*common.Subscription, error) {
+	seen := make(map[string]bool, len(sub.PubsubNames))
+	subs := make([]*common.Subscription, 0, len(sub.PubsubNames))
+	for _, name := range sub.PubsubNames {
+		if name == "" {
+			return nil, errors.New("pub/sub name required")
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate pub/sub name %s", name)
+		}
+		seen[name] = true
+		c := *sub
+		c.PubsubName = name
+		c.PubsubNames = nil
+		subs = append(subs, &c)
+	}
+	if sub.PubsubName != "" && !seen[sub.PubsubName] {
+		return nil, fmt.Errorf("pub/sub name %s is not one of the pub/sub names %v", sub.PubsubName, sub.PubsubNames)
+	}
+	return subs, nil
+}
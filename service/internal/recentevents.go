@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"sync"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// RecentEvents retains the last events delivered to the topic event handlers,
+// by topic, for debugging. A nil *RecentEvents retains nothing.
+type RecentEvents struct {
+	size int
+
+	lock  sync.Mutex
+	rings map[string]*eventRing
+}
+
+// eventRing holds the last events of a topic, overwriting the oldest one, so
+// that the evicted events and their data can be garbage collected.
+type eventRing struct {
+	events []common.TopicEvent
+	next   int
+}
+
+// NewRecentEvents returns a RecentEvents retaining the last size events of each
+// topic, or nil if size is not positive.
+func NewRecentEvents(size int) *RecentEvents {
+	if size <= 0 {
+		return nil
+	}
+	return &RecentEvents{size: size, rings: make(map[string]*eventRing)}
+}
+
+// Add retains a copy of e, evicting the oldest event of its topic if the
+// buffer is full. The data of e is copied, so that the buffer doesn't retain
+// the request it was read from.
+func (r *RecentEvents) Add(e *common.TopicEvent) {
+	if r == nil || e == nil {
+		return
+	}
+	c := *e
+	if c.RawData != nil {
+		c.RawData = append([]byte(nil), e.RawData...)
+		if _, ok := c.Data.([]byte); ok {
+			c.Data = c.RawData
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ring, ok := r.rings[e.Topic]
+	if !ok {
+		ring = &eventRing{events: make([]common.TopicEvent, 0, r.size)}
+		r.rings[e.Topic] = ring
+	}
+	if len(ring.events) < r.size {
+		ring.events = append(ring.events, c)
+		return
+	}
+	ring.events[ring.next] = c
+	ring.next = (ring.next + 1) % r.size
+}
+
+// List returns the events retained for topic, oldest first.
+func (r *RecentEvents) List(topic string) []common.TopicEvent {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ring, ok := r.rings[topic]
+	if !ok {
+		return nil
+	}
+	events := make([]common.TopicEvent, 0, len(ring.events))
+	events = append(events, ring.events[ring.next:]...)
+	return append(events, ring.events[:ring.next]...)
+}
@@ -0,0 +1,52 @@
+package internal_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func TestRecentEvents(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		r := internal.NewRecentEvents(0)
+		assert.Nil(t, r)
+		r.Add(&common.TopicEvent{ID: "1", Topic: "orders"})
+		assert.Empty(t, r.List("orders"))
+	})
+
+	t.Run("holds the last events", func(t *testing.T) {
+		r := internal.NewRecentEvents(3)
+		for i := 1; i <= 5; i++ {
+			r.Add(&common.TopicEvent{ID: fmt.Sprint(i), Topic: "orders"})
+		}
+		r.Add(&common.TopicEvent{ID: "other", Topic: "payments"})
+
+		ids := func(events []common.TopicEvent) []string {
+			s := make([]string, len(events))
+			for i, e := range events {
+				s[i] = e.ID
+			}
+			return s
+		}
+		assert.Equal(t, []string{"3", "4", "5"}, ids(r.List("orders")))
+		assert.Equal(t, []string{"other"}, ids(r.List("payments")))
+		assert.Empty(t, r.List("unknown"))
+	})
+
+	t.Run("copies the data", func(t *testing.T) {
+		r := internal.NewRecentEvents(1)
+		data := []byte("payload")
+		r.Add(&common.TopicEvent{ID: "1", Topic: "orders", RawData: data, Data: data})
+		data[0] = 'P'
+
+		events := r.List("orders")
+		require.Len(t, events, 1)
+		assert.Equal(t, "payload", string(events[0].RawData))
+		assert.Equal(t, []byte("payload"), events[0].Data)
+	})
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// Registrations records the registrations of the handlers of a service.
+type Registrations struct {
+	lock  sync.Mutex
+	infos []common.RegistrationInfo
+}
+
+// NewRegistration describes the registration of a handler by the caller of
+// the service.
+func NewRegistration(kind common.RegistrationKind, name, route string) common.RegistrationInfo {
+	return common.RegistrationInfo{
+		Kind:         kind,
+		Name:         name,
+		Route:        route,
+		RegisteredAt: time.Now(),
+		Caller:       registrationCaller(),
+	}
+}
+
+// Add records info, replacing the registration of the same handler.
+func (r *Registrations) Add(info common.RegistrationInfo) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i, existing := range r.infos {
+		if existing.Kind == info.Kind && existing.Name == info.Name && existing.Route == info.Route {
+			r.infos[i] = info
+			return
+		}
+	}
+	r.infos = append(r.infos, info)
+}
+
+// List returns the registrations in registration order.
+func (r *Registrations) List() []common.RegistrationInfo {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]common.RegistrationInfo(nil), r.infos...)
+}
+
+// AuditInvocationHandler wraps fn to log where it was registered when it
+// panics, before panicking again, or fails with a deadline.
+func AuditInvocationHandler(info common.RegistrationInfo, fn common.ServiceInvocationHandler) common.ServiceInvocationHandler {
+	return func(ctx context.Context, in *common.InvocationEvent) (out *common.Content, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logHandlerPanic(ctx, info, r)
+				panic(r)
+			}
+		}()
+		out, err = fn(ctx, in)
+		logHandlerTimeout(ctx, info, err)
+		return out, err
+	}
+}
+
+// AuditTopicHandler wraps fn as AuditInvocationHandler.
+func AuditTopicHandler(info common.RegistrationInfo, fn common.TopicEventHandler) common.TopicEventHandler {
+	return func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logHandlerPanic(ctx, info, r)
+				panic(r)
+			}
+		}()
+		retry, err = fn(ctx, e)
+		logHandlerTimeout(ctx, info, err)
+		return retry, err
+	}
+}
+
+// AuditBindingHandler wraps fn as AuditInvocationHandler.
+func AuditBindingHandler(info common.RegistrationInfo, fn common.BindingInvocationHandler) common.BindingInvocationHandler {
+	return func(ctx context.Context, in *common.BindingEvent) (out []byte, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logHandlerPanic(ctx, info, r)
+				panic(r)
+			}
+		}()
+		out, err = fn(ctx, in)
+		logHandlerTimeout(ctx, info, err)
+		return out, err
+	}
+}
+
+func logHandlerPanic(ctx context.Context, info common.RegistrationInfo, r interface{}) {
+	common.LoggerFromContext(ctx).Printf("%s panicked: %v", describeHandler(info), r)
+}
+
+func logHandlerTimeout(ctx context.Context, info common.RegistrationInfo, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		common.LoggerFromContext(ctx).Printf("%s timed out: %v", describeHandler(info), err)
+	}
+}
+
+func describeHandler(info common.RegistrationInfo) string {
+	s := fmt.Sprintf("%s handler %s", info.Kind, info.Name)
+	if info.Caller != "" {
+		s += " registered at " + info.Caller
+	}
+	return s
+}
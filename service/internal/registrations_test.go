@@ -0,0 +1,72 @@
+//go:build !daprnocaller
+
+package internal_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func TestRegistrations(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	info := internal.NewRegistration(common.RegistrationBinding, "storage", "/storage")
+	assert.Equal(t, fmt.Sprintf("%s:%d", file, line+1), info.Caller)
+	assert.Equal(t, common.RegistrationBinding, info.Kind)
+	assert.False(t, info.RegisteredAt.IsZero())
+
+	var r internal.Registrations
+	r.Add(info)
+	r.Add(internal.NewRegistration(common.RegistrationTopic, "messages/orders", "/orders"))
+	replaced := internal.NewRegistration(common.RegistrationBinding, "storage", "/storage")
+	r.Add(replaced)
+	list := r.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, replaced, list[0])
+	assert.Equal(t, "messages/orders", list[1].Name)
+}
+
+func TestAuditHandlers(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := common.ContextWithLogger(context.Background(), log.New(&buf, "", 0))
+	info := internal.NewRegistration(common.RegistrationInvocation, "echo", "/echo")
+
+	t.Run("panic logged with the caller", func(t *testing.T) {
+		buf.Reset()
+		fn := internal.AuditInvocationHandler(info, func(ctx context.Context, in *common.InvocationEvent) (*common.Content, error) {
+			panic("boom")
+		})
+		assert.PanicsWithValue(t, "boom", func() { _, _ = fn(ctx, &common.InvocationEvent{}) })
+		assert.Contains(t, buf.String(), "invocation handler echo registered at "+info.Caller+" panicked: boom")
+	})
+
+	t.Run("timeout logged with the caller", func(t *testing.T) {
+		buf.Reset()
+		fn := internal.AuditTopicHandler(info, func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+			return true, fmt.Errorf("query: %w", context.DeadlineExceeded)
+		})
+		retry, err := fn(ctx, &common.TopicEvent{})
+		assert.True(t, retry)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, buf.String(), "registered at "+info.Caller+" timed out")
+	})
+
+	t.Run("other errors not logged", func(t *testing.T) {
+		buf.Reset()
+		fn := internal.AuditBindingHandler(info, func(ctx context.Context, in *common.BindingEvent) ([]byte, error) {
+			return nil, fmt.Errorf("failed")
+		})
+		_, err := fn(ctx, &common.BindingEvent{})
+		assert.Error(t, err)
+		assert.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// SchemaValidations are the validations of the payloads of the handlers, keyed
+// by route without the leading slash.
+type SchemaValidations map[string]common.SchemaValidation
+
+// Add adds vs, replacing the validations of the same routes.
+func (m SchemaValidations) Add(vs ...common.SchemaValidation) {
+	for _, v := range vs {
+		m[strings.TrimPrefix(v.Route, "/")] = v
+	}
+}
+
+// TopicMiddleware returns mw preceded by the middleware validating the events
+// of route, if any, or the error compiling its schema.
+func (m SchemaValidations) TopicMiddleware(route string, mw []common.TopicMiddleware) ([]common.TopicMiddleware, error) {
+	v, ok := m[strings.TrimPrefix(route, "/")]
+	if !ok {
+		return mw, nil
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return append([]common.TopicMiddleware{common.ValidateTopicEvents(v.Validator)}, mw...), nil
+}
+
+// InvocationHandler returns fn wrapped to validate the invocations of route,
+// if any, or the error compiling its schema.
+func (m SchemaValidations) InvocationHandler(route string, fn common.ServiceInvocationHandler) (common.ServiceInvocationHandler, error) {
+	v, ok := m[strings.TrimPrefix(route, "/")]
+	if !ok {
+		return fn, nil
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return common.ValidateInvocations(v.Validator, fn), nil
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// DefaultShutdownTimeout is the default time the shutdown hooks have to run.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// ShutdownHooks is a registry of functions run when a service stops.
+type ShutdownHooks struct {
+	// Timeout bounds the context passed to the hooks. Zero means
+	// DefaultShutdownTimeout.
+	Timeout time.Duration
+
+	lock  sync.Mutex
+	hooks []func(ctx context.Context) error
+	// ran is set once the hooks have run, until Reset.
+	ran bool
+}
+
+// Add registers fn.
+func (h *ShutdownHooks) Add(fn func(ctx context.Context) error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.hooks = append(h.hooks, fn)
+}
+
+// Context returns a context bounded by the timeout.
+func (h *ShutdownHooks) Context() (context.Context, context.CancelFunc) {
+	h.lock.Lock()
+	timeout := h.Timeout
+	h.lock.Unlock()
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// Run runs the registered hooks in reverse registration order, sharing a
+// context bounded by the timeout. All hooks run even if some fail; their
+// errors are returned as a *common.ShutdownError. The hooks run once: later
+// calls do nothing until Reset.
+func (h *ShutdownHooks) Run() error {
+	h.lock.Lock()
+	if h.ran {
+		h.lock.Unlock()
+		return nil
+	}
+	h.ran = true
+	hooks := append([]func(ctx context.Context) error(nil), h.hooks...)
+	h.lock.Unlock()
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	ctx, cancel := h.Context()
+	defer cancel()
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return &common.ShutdownError{Errors: errs}
+	}
+	return nil
+}
+
+// Reset lets the registered hooks run again, when a stopped service is
+// restarted.
+func (h *ShutdownHooks) Reset() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.ran = false
+}
@@ -0,0 +1,60 @@
+package internal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/common"
+	"github.com/dapr/go-sdk/service/internal"
+)
+
+func TestShutdownHooks(t *testing.T) {
+	t.Run("hooks run in reverse order and errors are aggregated", func(t *testing.T) {
+		h := &internal.ShutdownHooks{Timeout: time.Second}
+		errFlush := errors.New("flush failed")
+		errClose := errors.New("close failed")
+		var order []string
+		h.Add(func(ctx context.Context) error {
+			order = append(order, "db")
+			return errClose
+		})
+		h.Add(func(ctx context.Context) error {
+			order = append(order, "cache")
+			return nil
+		})
+		h.Add(func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok, "hook context is bounded")
+			order = append(order, "buffers")
+			return errFlush
+		})
+
+		err := h.Run()
+		assert.Equal(t, []string{"buffers", "cache", "db"}, order)
+		var shutdownErr *common.ShutdownError
+		require.ErrorAs(t, err, &shutdownErr)
+		assert.Equal(t, []error{errFlush, errClose}, shutdownErr.Errors)
+		assert.ErrorIs(t, err, errFlush)
+		assert.ErrorIs(t, err, errClose)
+		assert.Equal(t, "shutdown hooks failed: flush failed; close failed", err.Error())
+
+		// Hooks run only once.
+		assert.NoError(t, h.Run())
+		assert.Len(t, order, 3)
+
+		// Hooks are kept for the next stop.
+		h.Reset()
+		assert.Error(t, h.Run())
+		assert.Equal(t, []string{"buffers", "cache", "db", "buffers", "cache", "db"}, order)
+	})
+
+	t.Run("no hooks", func(t *testing.T) {
+		h := &internal.ShutdownHooks{}
+		assert.NoError(t, h.Run())
+	})
+}
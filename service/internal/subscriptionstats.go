@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// statsWindow is the number of recent handler durations kept per subscription
+// to compute latency percentiles.
+const statsWindow = 128
+
+// subscriptionStats collects the stats of the handlers of a subscription using
+// atomics only, so that tracking adds no locking to event delivery.
+type subscriptionStats struct {
+	inFlight  atomic.Int64
+	delivered atomic.Uint64
+	failed    atomic.Uint64
+	lastError atomic.Value // string
+
+	// durations is a ring of the most recent handler durations in
+	// nanoseconds, and next the number of durations recorded so far.
+	durations [statsWindow]atomic.Int64
+	next      atomic.Uint64
+}
+
+// track wraps fn to record its invocations in the stats.
+func (s *subscriptionStats) track(fn common.TopicEventHandler) common.TopicEventHandler {
+	return func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		s.inFlight.Add(1)
+		start := time.Now()
+		defer func() {
+			i := s.next.Add(1) - 1
+			s.durations[i%statsWindow].Store(int64(time.Since(start)))
+			s.delivered.Add(1)
+			if err != nil {
+				s.failed.Add(1)
+				s.lastError.Store(err.Error())
+			}
+			s.inFlight.Add(-1)
+		}()
+		return fn(ctx, e)
+	}
+}
+
+// snapshot returns the current stats.
+func (s *subscriptionStats) snapshot() common.SubscriptionStats {
+	stats := common.SubscriptionStats{
+		InFlight:  s.inFlight.Load(),
+		Delivered: s.delivered.Load(),
+		Failed:    s.failed.Load(),
+	}
+	if lastError, ok := s.lastError.Load().(string); ok {
+		stats.LastError = lastError
+	}
+
+	n := s.next.Load()
+	if n > statsWindow {
+		n = statsWindow
+	}
+	if n == 0 {
+		return stats
+	}
+	durations := make([]time.Duration, n)
+	for i := range durations {
+		durations[i] = time.Duration(s.durations[i].Load())
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	stats.LatencySamples = len(durations)
+	stats.P50Latency = percentile(durations, 50)
+	stats.P95Latency = percentile(durations, 95)
+	stats.MaxLatency = durations[len(durations)-1]
+	return stats
+}
+
+// percentile returns the p-th percentile of the sorted durations, using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"sync"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+// ChainTopicMiddleware wraps fn with mw, the first middleware being the
+// outermost.
+func ChainTopicMiddleware(fn common.TopicEventHandler, mw ...common.TopicMiddleware) common.TopicEventHandler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		fn = mw[i](fn)
+	}
+	return fn
+}
+
+// TopicMiddleware is the middleware applied to all the topic event handlers of
+// a service.
+type TopicMiddleware struct {
+	lock sync.RWMutex
+	mw   []common.TopicMiddleware
+}
+
+// Use appends mw to the middleware.
+func (m *TopicMiddleware) Use(mw ...common.TopicMiddleware) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.mw = append(m.mw, mw...)
+}
+
+// Wrap wraps fn with the middleware.
+func (m *TopicMiddleware) Wrap(fn common.TopicEventHandler) common.TopicEventHandler {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return ChainTopicMiddleware(fn, m.mw...)
+}
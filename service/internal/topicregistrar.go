@@ -1,8 +1,10 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/dapr/go-sdk/service/common"
 )
@@ -10,6 +12,7 @@ import (
 // TopicRegistrar is a map of <pubsubname>-<topic> to `TopicRegistration`
 // and acts as a lookup as the application is building up subscriptions with
 // potentially multiple routes per topic.
+// Use Subscriptions to list the subscriptions in registration order.
 type TopicRegistrar map[string]*TopicRegistration
 
 // TopicRegistration encapsulates the subscription and handlers.
@@ -17,9 +20,51 @@ type TopicRegistration struct {
 	Subscription   *TopicSubscription
 	DefaultHandler common.TopicEventHandler
 	RouteHandlers  map[string]common.TopicEventHandler
+
+	// subs are the subscriptions of the handlers, in registration order.
+	subs []common.Subscription
+
+	// seq is the position of the registration in the registrar, used to list
+	// subscriptions in the order they were first registered.
+	seq int
+
+	// concurrency is the maximum number of concurrent handler invocations,
+	// zero if unlimited, and sem enforces it.
+	concurrency int
+	sem         chan struct{}
+
+	stats subscriptionStats
+}
+
+// subscriptionConcurrency returns the maximum number of concurrent handler
+// invocations for sub, zero if unlimited.
+func subscriptionConcurrency(sub *common.Subscription) int {
+	if sub.OrderingMode == common.OrderingModeOrdered {
+		return 1
+	}
+	return sub.MaxConcurrency
+}
+
+// limit wraps fn so that it doesn't run more often concurrently than allowed
+// by the registration.
+func (r *TopicRegistration) limit(fn common.TopicEventHandler) common.TopicEventHandler {
+	if r.sem == nil {
+		return fn
+	}
+	return func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		select {
+		case r.sem <- struct{}{}:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+		defer func() { <-r.sem }()
+		return fn(ctx, e)
+	}
 }
 
-func (m TopicRegistrar) AddSubscription(sub *common.Subscription, fn common.TopicEventHandler) error {
+// AddSubscription registers fn, wrapped with mw, as the handler of the route of
+// sub.
+func (m TopicRegistrar) AddSubscription(sub *common.Subscription, fn common.TopicEventHandler, mw ...common.TopicMiddleware) error {
 	if sub.Topic == "" {
 		return errors.New("topic name required")
 	}
@@ -29,24 +74,35 @@ func (m TopicRegistrar) AddSubscription(sub *common.Subscription, fn common.Topi
 	if fn == nil {
 		return fmt.Errorf("topic handler required")
 	}
-
-	var key string
-	if !sub.DisableTopicValidation {
-		key = sub.PubsubName + "-" + sub.Topic
-	} else {
-		key = sub.PubsubName
+	if sub.MaxConcurrency < 0 {
+		return errors.New("max concurrency must not be negative")
 	}
+	concurrency := subscriptionConcurrency(sub)
 
+	key := subscriptionKey(sub)
 	ts, ok := m[key]
 	if !ok {
 		ts = &TopicRegistration{
 			Subscription:   NewTopicSubscription(sub.PubsubName, sub.Topic),
 			RouteHandlers:  make(map[string]common.TopicEventHandler),
 			DefaultHandler: nil,
+			concurrency:    concurrency,
+			seq:            len(m),
+		}
+		if concurrency > 0 {
+			ts.sem = make(chan struct{}, concurrency)
 		}
 		ts.Subscription.SetMetadata(sub.Metadata)
+		ts.Subscription.Scopes = sub.Scopes
+		ts.Subscription.BulkSubscribe = sub.BulkSubscribe
 		m[key] = ts
+	} else if concurrency != 0 && concurrency != ts.concurrency {
+		return fmt.Errorf("conflicting ordering mode or max concurrency for topic %s", sub.Topic)
 	}
+	if err := ts.Subscription.SetDeadLetterTopic(sub.DeadLetterTopic); err != nil {
+		return err
+	}
+	fn = ts.limit(ts.stats.track(ChainTopicMiddleware(fn, mw...)))
 
 	if sub.Match != "" {
 		if err := ts.Subscription.AddRoutingRule(sub.Route, sub.Match, sub.Priority); err != nil {
@@ -59,6 +115,87 @@ func (m TopicRegistrar) AddSubscription(sub *common.Subscription, fn common.Topi
 		ts.DefaultHandler = fn
 	}
 	ts.RouteHandlers[sub.Route] = fn
+	c := *sub
+	c.Metadata = copyMetadata(sub.Metadata)
+	c.Scopes = append([]string(nil), sub.Scopes...)
+	ts.subs = append(ts.subs, c)
 
 	return nil
 }
+
+// Handler returns the handler registered for the route of sub, wrapped to
+// honor the ordering mode and max concurrency of the topic, or nil.
+func (m TopicRegistrar) Handler(sub *common.Subscription) common.TopicEventHandler {
+	ts, ok := m[subscriptionKey(sub)]
+	if !ok {
+		return nil
+	}
+	return ts.RouteHandlers[sub.Route]
+}
+
+// Subscriptions returns the subscriptions in the order they were first
+// registered. Routing rules within a subscription are ordered by priority, then
+// by registration.
+func (m TopicRegistrar) Subscriptions() []*TopicSubscription {
+	regs := m.registrations()
+	subs := make([]*TopicSubscription, len(regs))
+	for i, ts := range regs {
+		subs[i] = ts.Subscription
+	}
+	return subs
+}
+
+// Registered returns copies of the subscriptions the handlers were registered
+// with, grouped by topic in the order the topics were first registered.
+func (m TopicRegistrar) Registered() []common.Subscription {
+	var subs []common.Subscription
+	for _, ts := range m.registrations() {
+		subs = append(subs, ts.subs...)
+	}
+	for i := range subs {
+		// Don't share the metadata and scopes with the registrar.
+		subs[i].Metadata = copyMetadata(subs[i].Metadata)
+		subs[i].Scopes = append([]string(nil), subs[i].Scopes...)
+	}
+	return subs
+}
+
+// registrations returns the registrations in the order they were first
+// registered.
+func (m TopicRegistrar) registrations() []*TopicRegistration {
+	regs := make([]*TopicRegistration, 0, len(m))
+	for _, ts := range m {
+		regs = append(regs, ts)
+	}
+	sort.Slice(regs, func(i, j int) bool {
+		return regs[i].seq < regs[j].seq
+	})
+	return regs
+}
+
+// Stats returns the stats of each subscription, keyed by "<pubsubname>/<topic>".
+func (m TopicRegistrar) Stats() map[string]common.SubscriptionStats {
+	stats := make(map[string]common.SubscriptionStats, len(m))
+	for _, ts := range m {
+		stats[ts.Subscription.PubsubName+"/"+ts.Subscription.Topic] = ts.stats.snapshot()
+	}
+	return stats
+}
+
+func subscriptionKey(sub *common.Subscription) string {
+	if sub.DisableTopicValidation {
+		return sub.PubsubName
+	}
+	return sub.PubsubName + "-" + sub.Topic
+}
+
+func copyMetadata(md map[string]string) map[string]string {
+	if md == nil {
+		return nil
+	}
+	c := make(map[string]string, len(md))
+	for k, v := range md {
+		c[k] = v
+	}
+	return c
+}
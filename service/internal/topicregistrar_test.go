@@ -2,9 +2,15 @@ package internal_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/go-sdk/service/common"
 	"github.com/dapr/go-sdk/service/internal"
@@ -94,3 +100,215 @@ func TestTopicAddSubscriptionMetadata(t *testing.T) {
 	}
 	assert.Equal(t, expected, actual)
 }
+
+// concurrencyRecorder is a topic handler recording the highest number of
+// invocations running at the same time.
+type concurrencyRecorder struct {
+	lock    sync.Mutex
+	running int
+	max     int
+}
+
+func (c *concurrencyRecorder) handle(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+	c.lock.Lock()
+	c.running++
+	if c.running > c.max {
+		c.max = c.running
+	}
+	c.lock.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	c.lock.Lock()
+	c.running--
+	c.lock.Unlock()
+	return false, nil
+}
+
+func runConcurrently(t *testing.T, m internal.TopicRegistrar, subs []*common.Subscription, n int) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		for _, sub := range subs {
+			fn := m.Handler(sub)
+			if !assert.NotNil(t, fn) {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := fn(context.Background(), &common.TopicEvent{})
+				assert.NoError(t, err)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestTopicRegistrarOrderingMode(t *testing.T) {
+	t.Run("ordered runs one handler at a time per topic", func(t *testing.T) {
+		rec := &concurrencyRecorder{}
+		m := internal.TopicRegistrar{}
+		subs := []*common.Subscription{
+			{PubsubName: "test", Topic: "topic", Route: "/default", OrderingMode: common.OrderingModeOrdered},
+			{PubsubName: "test", Topic: "topic", Route: "/other", Match: `event.type == "other"`},
+		}
+		for _, sub := range subs {
+			require.NoError(t, m.AddSubscription(sub, rec.handle))
+		}
+
+		runConcurrently(t, m, subs, 10)
+		assert.Equal(t, 1, rec.max)
+	})
+
+	t.Run("unordered honors max concurrency", func(t *testing.T) {
+		rec := &concurrencyRecorder{}
+		m := internal.TopicRegistrar{}
+		sub := &common.Subscription{PubsubName: "test", Topic: "topic", MaxConcurrency: 2}
+		require.NoError(t, m.AddSubscription(sub, rec.handle))
+
+		runConcurrently(t, m, []*common.Subscription{sub}, 10)
+		assert.LessOrEqual(t, rec.max, 2)
+	})
+
+	t.Run("topics are limited independently", func(t *testing.T) {
+		rec := &concurrencyRecorder{}
+		m := internal.TopicRegistrar{}
+		subs := []*common.Subscription{
+			{PubsubName: "test", Topic: "topic1", OrderingMode: common.OrderingModeOrdered},
+			{PubsubName: "test", Topic: "topic2", OrderingMode: common.OrderingModeOrdered},
+		}
+		for _, sub := range subs {
+			require.NoError(t, m.AddSubscription(sub, rec.handle))
+		}
+
+		runConcurrently(t, m, subs, 10)
+		assert.LessOrEqual(t, rec.max, 2)
+	})
+
+	t.Run("conflicting settings", func(t *testing.T) {
+		fn := func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+			return false, nil
+		}
+		m := internal.TopicRegistrar{}
+		require.NoError(t, m.AddSubscription(&common.Subscription{PubsubName: "test", Topic: "topic", Route: "/a", MaxConcurrency: 4}, fn))
+		err := m.AddSubscription(&common.Subscription{PubsubName: "test", Topic: "topic", Route: "/b", Match: `event.type == "b"`, OrderingMode: common.OrderingModeOrdered}, fn)
+		assert.Error(t, err)
+		err = m.AddSubscription(&common.Subscription{PubsubName: "test", Topic: "other", MaxConcurrency: -1}, fn)
+		assert.Error(t, err)
+	})
+}
+
+func TestTopicRegistrarSubscriptionsOrder(t *testing.T) {
+	fn := func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		return false, nil
+	}
+	topics := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	priorities := []int{1, 2, 3, 4}
+
+	for i := 0; i < 10; i++ {
+		order := make([]string, len(topics))
+		copy(order, topics)
+		rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		prios := make([]int, len(priorities))
+		copy(prios, priorities)
+		rand.Shuffle(len(prios), func(i, j int) { prios[i], prios[j] = prios[j], prios[i] })
+
+		m := internal.TopicRegistrar{}
+		for _, topic := range order {
+			for _, p := range prios {
+				require.NoError(t, m.AddSubscription(&common.Subscription{
+					PubsubName: "messages",
+					Topic:      topic,
+					Route:      fmt.Sprintf("/%s/%d", topic, p),
+					Match:      fmt.Sprintf(`event.type == "%d"`, p),
+					Priority:   p,
+				}, fn))
+			}
+		}
+
+		subs := m.Subscriptions()
+		require.Len(t, subs, len(order))
+		for j, sub := range subs {
+			assert.Equal(t, order[j], sub.Topic)
+			require.Len(t, sub.Routes.Rules, len(priorities))
+			for k, rule := range sub.Routes.Rules {
+				assert.Equal(t, fmt.Sprintf("/%s/%d", sub.Topic, priorities[k]), rule.Path)
+			}
+		}
+		assert.Equal(t, subs, m.Subscriptions())
+	}
+}
+
+func TestTopicRegistrarStats(t *testing.T) {
+	m := internal.TopicRegistrar{}
+	sub := &common.Subscription{PubsubName: "messages", Topic: "orders", Route: "/orders"}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	require.NoError(t, m.AddSubscription(sub, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		if e.ID == "slow" {
+			close(started)
+			<-release
+		}
+		time.Sleep(time.Millisecond)
+		if e.ID == "bad" {
+			return false, errors.New("boom")
+		}
+		return false, nil
+	}))
+	h := m.Handler(sub)
+
+	assert.Equal(t, common.SubscriptionStats{}, m.Stats()["messages/orders"])
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = h(context.Background(), &common.TopicEvent{ID: "slow"})
+	}()
+	<-started
+	assert.Equal(t, int64(1), m.Stats()["messages/orders"].InFlight)
+	close(release)
+	<-done
+
+	for i := 0; i < 10; i++ {
+		_, _ = h(context.Background(), &common.TopicEvent{ID: "ok"})
+	}
+	_, _ = h(context.Background(), &common.TopicEvent{ID: "bad"})
+
+	stats := m.Stats()["messages/orders"]
+	assert.Equal(t, int64(0), stats.InFlight)
+	assert.Equal(t, uint64(12), stats.Delivered)
+	assert.Equal(t, uint64(1), stats.Failed)
+	assert.Equal(t, "boom", stats.LastError)
+	assert.Equal(t, 12, stats.LatencySamples)
+	assert.GreaterOrEqual(t, stats.P50Latency, time.Millisecond)
+	assert.GreaterOrEqual(t, stats.P95Latency, stats.P50Latency)
+	assert.GreaterOrEqual(t, stats.MaxLatency, stats.P95Latency)
+
+	// Only the most recent invocations are sampled.
+	for i := 0; i < 200; i++ {
+		_, _ = h(context.Background(), &common.TopicEvent{ID: "fast"})
+	}
+	stats = m.Stats()["messages/orders"]
+	assert.Equal(t, uint64(212), stats.Delivered)
+	assert.Equal(t, 128, stats.LatencySamples)
+}
+
+func TestTopicRegistrarRegistered(t *testing.T) {
+	fn := func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+		return false, nil
+	}
+	m := internal.TopicRegistrar{}
+	assert.Empty(t, m.Registered())
+
+	require.NoError(t, m.AddSubscription(&common.Subscription{PubsubName: "messages", Topic: "b", Route: "/b"}, fn))
+	require.NoError(t, m.AddSubscription(&common.Subscription{PubsubName: "messages", Topic: "a", Route: "/a"}, fn))
+	require.NoError(t, m.AddSubscription(&common.Subscription{PubsubName: "messages", Topic: "b", Route: "/b/v2", Match: `event.type == "v2"`, Priority: 1}, fn))
+	assert.Error(t, m.AddSubscription(&common.Subscription{PubsubName: "messages", Topic: "b", Route: "/b"}, fn))
+
+	subs := m.Registered()
+	require.Len(t, subs, 3)
+	assert.Equal(t, common.Subscription{PubsubName: "messages", Topic: "b", Route: "/b"}, subs[0])
+	assert.Equal(t, "/b/v2", subs[1].Route)
+	assert.Equal(t, "/a", subs[2].Route)
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+
+	"github.com/dapr/go-sdk/service/common"
 )
 
 // TopicSubscription internally represents single topic subscription.
@@ -18,6 +20,12 @@ type TopicSubscription struct {
 	Routes *TopicRoutes `json:"routes,omitempty"`
 	// Metadata is the subscription metadata.
 	Metadata map[string]string `json:"metadata,omitempty"`
+	// DeadLetterTopic is the topic to which events that can't be delivered are sent.
+	DeadLetterTopic string `json:"deadLetterTopic,omitempty"`
+	// Scopes are the IDs of the apps the exported subscription applies to.
+	Scopes []string `json:"-"`
+	// BulkSubscribe are the bulk subscribe settings of the exported subscription.
+	BulkSubscribe *common.BulkSubscribeOptions `json:"-"`
 }
 
 // TopicRoutes encapsulates the default route and multiple routing rules.
@@ -60,6 +68,20 @@ func (s *TopicSubscription) SetMetadata(metadata map[string]string) error {
 	return nil
 }
 
+// SetDeadLetterTopic sets the dead letter topic for the subscription if not
+// already set. An error is returned if it is already set to another topic.
+func (s *TopicSubscription) SetDeadLetterTopic(topic string) error {
+	if topic == "" || topic == s.DeadLetterTopic {
+		return nil
+	}
+	if s.DeadLetterTopic != "" {
+		return fmt.Errorf("subscription for topic %s on pubsub %s already has dead letter topic %s", s.Topic, s.PubsubName, s.DeadLetterTopic)
+	}
+	s.DeadLetterTopic = topic
+
+	return nil
+}
+
 // SetDefaultRoute sets the default route if not already set.
 // An error is returned if it is already set.
 func (s *TopicSubscription) SetDefaultRoute(path string) error {